@@ -0,0 +1,278 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// GET /api/access-control/roles
+func GetRoles(c *models.ReqContext) Response {
+	query := models.GetRolesQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get roles", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GET /api/access-control/roles/:roleUid
+func GetRoleByUID(c *models.ReqContext) Response {
+	query := models.GetRoleByUidQuery{OrgId: c.OrgId, Uid: c.Params(":roleUid")}
+	if err := bus.Dispatch(&query); err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+
+	return JSON(200, query.Result)
+}
+
+// POST /api/access-control/roles
+func (hs *HTTPServer) CreateRole(c *models.ReqContext, cmd models.CreateRoleCommand) Response {
+	cmd.OrgId = c.OrgId
+
+	if rsp := hs.canGrantPermissions(c, cmd.Permissions); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to create role")
+	}
+
+	return JSON(200, cmd.Result)
+}
+
+// PUT /api/access-control/roles/:roleUid
+func (hs *HTTPServer) UpdateRole(c *models.ReqContext, cmd models.UpdateRoleCommand) Response {
+	cmd.OrgId = c.OrgId
+	cmd.Uid = c.Params(":roleUid")
+
+	query := models.GetRoleByUidQuery{OrgId: cmd.OrgId, Uid: cmd.Uid}
+	if err := bus.Dispatch(&query); err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+
+	// Both the permissions being removed and added have to be held, so that
+	// a user can't take away permissions from others they couldn't grant.
+	if rsp := hs.canGrantPermissions(c, append(query.Result.Permissions, cmd.Permissions...)); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to update role")
+	}
+
+	return JSON(200, cmd.Result)
+}
+
+// DELETE /api/access-control/roles/:roleUid
+func (hs *HTTPServer) DeleteRole(c *models.ReqContext) Response {
+	query := models.GetRoleByUidQuery{OrgId: c.OrgId, Uid: c.Params(":roleUid")}
+	if err := bus.Dispatch(&query); err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+
+	if rsp := hs.canGrantPermissions(c, query.Result.Permissions); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&models.DeleteRoleCommand{OrgId: c.OrgId, Uid: query.Result.Uid}); err != nil {
+		return roleErrorResponse(err, "Failed to delete role")
+	}
+
+	return Success("Role deleted")
+}
+
+// GET /api/access-control/builtin-roles
+func (hs *HTTPServer) GetBuiltInRoles(c *models.ReqContext) Response {
+	return JSON(200, accesscontrol.BuiltInRoles(hs.Cfg.EditorsCanAdmin))
+}
+
+// GET /api/access-control/user/permissions
+func (hs *HTTPServer) GetSignedInUserPermissions(c *models.ReqContext) Response {
+	permissions, err := hs.AccessControl.GetUserPermissions(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return Error(500, "Failed to get permissions", err)
+	}
+
+	return JSON(200, permissions)
+}
+
+// GET /api/access-control/users/:userId/roles
+func GetUserRoles(c *models.ReqContext) Response {
+	query := models.GetUserRolesQuery{OrgId: c.OrgId, UserId: c.ParamsInt64(":userId")}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get user roles", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// POST /api/access-control/users/:userId/roles
+func (hs *HTTPServer) AddUserRole(c *models.ReqContext, cmd models.AddUserRoleCommand) Response {
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.ParamsInt64(":userId")
+
+	userQuery := models.GetSignedInUserQuery{UserId: cmd.UserId, OrgId: cmd.OrgId}
+	if err := bus.Dispatch(&userQuery); err != nil {
+		if err == models.ErrUserNotFound {
+			return Error(404, models.ErrUserNotFound.Error(), nil)
+		}
+		return Error(500, "Failed to get user", err)
+	}
+
+	if userQuery.Result.OrgId != cmd.OrgId {
+		return Error(404, "User is not a member of the organization", nil)
+	}
+
+	if rsp := hs.canAssignRole(c, cmd.RoleUid); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to assign role to user")
+	}
+
+	return Success("Role assigned to user")
+}
+
+// DELETE /api/access-control/users/:userId/roles/:roleUid
+func (hs *HTTPServer) RemoveUserRole(c *models.ReqContext) Response {
+	cmd := models.RemoveUserRoleCommand{
+		OrgId:   c.OrgId,
+		UserId:  c.ParamsInt64(":userId"),
+		RoleUid: c.Params(":roleUid"),
+	}
+
+	if rsp := hs.canAssignRole(c, cmd.RoleUid); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to remove role from user")
+	}
+
+	return Success("Role removed from user")
+}
+
+// GET /api/access-control/teams/:teamId/roles
+func GetTeamRoles(c *models.ReqContext) Response {
+	query := models.GetTeamRolesQuery{OrgId: c.OrgId, TeamId: c.ParamsInt64(":teamId")}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get team roles", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// POST /api/access-control/teams/:teamId/roles
+func (hs *HTTPServer) AddTeamRole(c *models.ReqContext, cmd models.AddTeamRoleCommand) Response {
+	cmd.OrgId = c.OrgId
+	cmd.TeamId = c.ParamsInt64(":teamId")
+
+	if rsp := hs.canAssignRole(c, cmd.RoleUid); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrTeamNotFound {
+			return Error(404, "Team not found", nil)
+		}
+		return roleErrorResponse(err, "Failed to assign role to team")
+	}
+
+	return Success("Role assigned to team")
+}
+
+// DELETE /api/access-control/teams/:teamId/roles/:roleUid
+func (hs *HTTPServer) RemoveTeamRole(c *models.ReqContext) Response {
+	cmd := models.RemoveTeamRoleCommand{
+		OrgId:   c.OrgId,
+		TeamId:  c.ParamsInt64(":teamId"),
+		RoleUid: c.Params(":roleUid"),
+	}
+
+	if rsp := hs.canAssignRole(c, cmd.RoleUid); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to remove role from team")
+	}
+
+	return Success("Role removed from team")
+}
+
+func (hs *HTTPServer) isAccessControlEnabled() bool {
+	return hs.AccessControl != nil && !hs.AccessControl.IsDisabled()
+}
+
+// hasAccess returns true if the signed in user has been granted any of the
+// actions, or the result of the built-in role check if access control is
+// disabled.
+func (hs *HTTPServer) hasAccess(c *models.ReqContext, fallback bool, actions ...string) (bool, error) {
+	if !hs.isAccessControlEnabled() {
+		return fallback, nil
+	}
+
+	for _, action := range actions {
+		granted, err := hs.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser, action)
+		if err != nil || granted {
+			return granted, err
+		}
+	}
+
+	return false, nil
+}
+
+// canAssignRole prevents privilege escalation by requiring the signed in
+// user to hold all permissions of the role being assigned or removed.
+func (hs *HTTPServer) canAssignRole(c *models.ReqContext, roleUid string) Response {
+	query := models.GetRoleByUidQuery{OrgId: c.OrgId, Uid: roleUid}
+	if err := bus.Dispatch(&query); err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+
+	return hs.canGrantPermissions(c, query.Result.Permissions)
+}
+
+// canGrantPermissions returns an error response if any of the permissions
+// is unknown or not held by the signed in user.
+func (hs *HTTPServer) canGrantPermissions(c *models.ReqContext, permissions []string) Response {
+	held, err := hs.AccessControl.GetUserPermissions(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return Error(500, "Failed to get permissions", err)
+	}
+
+	heldSet := make(map[string]bool, len(held))
+	for _, permission := range held {
+		heldSet[permission] = true
+	}
+
+	for _, permission := range permissions {
+		if !accesscontrol.IsValidAction(permission) {
+			return Error(400, fmt.Sprintf("Permission %q is unknown or can't be granted by an org role", permission), nil)
+		}
+		if !heldSet[permission] {
+			return Error(403, fmt.Sprintf("Not allowed to grant permission %q", permission), nil)
+		}
+	}
+
+	return nil
+}
+
+func roleErrorResponse(err error, message string) Response {
+	switch err {
+	case models.ErrRoleNotFound:
+		return Error(404, "Role not found", nil)
+	case models.ErrRoleNameTaken:
+		return Error(409, "Role name taken", nil)
+	case models.ErrRoleAlreadyAssigned:
+		return Error(409, "Role is already assigned", nil)
+	case models.ErrRoleNotAssigned:
+		return Error(404, "Role is not assigned", nil)
+	}
+
+	return Error(500, message, err)
+}
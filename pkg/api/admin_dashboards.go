@@ -0,0 +1,61 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards/schema"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const invalidDashboardsBatchSize = 500
+
+// GET /api/admin/dashboards/invalid
+func AdminGetInvalidDashboards(c *models.ReqContext) Response {
+	result := make([]*dtos.InvalidDashboard, 0)
+
+	var afterId int64
+	for {
+		query := models.GetAllDashboardsQuery{AfterId: afterId, Limit: invalidDashboardsBatchSize}
+		if err := bus.Dispatch(&query); err != nil {
+			return Error(500, "Failed to get dashboards", err)
+		}
+
+		for _, dash := range query.Result {
+			schemaVersion := dash.Data.Get("schemaVersion").MustInt(0)
+
+			// the dashboard is not saved, it is only migrated to be validated against the latest schema
+			_ = schema.Migrate(dash.Data)
+			if errs := schema.Validate(dash.Data); len(errs) > 0 {
+				result = append(result, &dtos.InvalidDashboard{
+					OrgId:         dash.OrgId,
+					Id:            dash.Id,
+					Uid:           dash.Uid,
+					Title:         dash.Title,
+					SchemaVersion: schemaVersion,
+					Errors:        errs,
+				})
+			}
+		}
+
+		if len(query.Result) < invalidDashboardsBatchSize {
+			break
+		}
+		afterId = query.Result[len(query.Result)-1].Id
+	}
+
+	return JSON(200, result)
+}
+
+// POST /api/admin/search/reindex
+func AdminRebuildSearchIndex(c *models.ReqContext) Response {
+	cmd := models.RebuildDashboardContentIndexCommand{}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return Error(500, "Failed to rebuild the search index", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message":    "Search index rebuilt",
+		"dashboards": cmd.Result,
+	})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAdminDashboardsApiEndpoint(t *testing.T) {
+	Convey("When listing invalid dashboards", t, func() {
+		loggedInUserScenarioWithRole("Should return the dashboards invalid after migration", "GET", "/api/admin/dashboards/invalid", "/api/admin/dashboards/invalid", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			valid := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+				"title":         "Rows",
+				"schemaVersion": 14,
+				"rows": []interface{}{map[string]interface{}{
+					"height": "250px",
+					"panels": []interface{}{map[string]interface{}{"id": 1, "span": 12}},
+				}},
+			}))
+			valid.Id = 1
+			invalid := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+				"title":         "Missing grid pos",
+				"uid":           "invalid",
+				"schemaVersion": 26,
+				"panels":        []interface{}{map[string]interface{}{"id": 1}},
+			}))
+			invalid.Id = 2
+			invalid.OrgId = 3
+
+			var queries []models.GetAllDashboardsQuery
+			bus.AddHandler("test", func(query *models.GetAllDashboardsQuery) error {
+				queries = append(queries, *query)
+				query.Result = []*models.Dashboard{valid, invalid}
+				return nil
+			})
+
+			sc.handlerFunc = AdminGetInvalidDashboards
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(queries, ShouldHaveLength, 1)
+
+			result := sc.ToJSON()
+			So(result.MustArray(), ShouldHaveLength, 1)
+			So(result.GetIndex(0).Get("orgId").MustInt64(), ShouldEqual, 3)
+			So(result.GetIndex(0).Get("uid").MustString(), ShouldEqual, "invalid")
+			So(result.GetIndex(0).Get("schemaVersion").MustInt(), ShouldEqual, 26)
+			So(result.GetIndex(0).Get("errors").GetIndex(0).Get("path").MustString(), ShouldEqual, "panels[0].gridPos")
+		})
+	})
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GET /api/admin/lockouts
+func AdminGetLockedUsers(c *models.ReqContext) Response {
+	lockedUsers, err := login.GetLockedUsers()
+	if err != nil {
+		return Error(500, "Failed to get locked users", err)
+	}
+
+	return JSON(200, lockedUsers)
+}
+
+// DELETE /api/admin/lockouts/:username
+func AdminUnlockUser(c *models.ReqContext) Response {
+	username := c.Params(":username")
+	if err := login.UnlockUser(username); err != nil {
+		return Error(500, "Failed to unlock user", err)
+	}
+
+	return Success("User unlocked")
+}
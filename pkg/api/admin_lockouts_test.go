@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAdminLockoutsApiEndpoint(t *testing.T) {
+	Convey("When listing locked users", t, func() {
+		setting.LoginLockoutAttempts = 2
+		setting.LoginLockoutDuration = 5 * time.Minute
+
+		loggedInUserScenarioWithRole("Should return users with too many failed login attempts", "GET", "/api/admin/lockouts", "/api/admin/lockouts", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			created := time.Now().Add(-time.Minute).Unix()
+			bus.AddHandler("test", func(query *models.GetLoginAttemptsQuery) error {
+				query.Result = []*models.LoginAttempt{
+					{Username: "locked", Created: created},
+					{Username: "locked", Created: created},
+					{Username: "other", Created: created},
+				}
+				return nil
+			})
+
+			sc.handlerFunc = AdminGetLockedUsers
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+			result := sc.ToJSON()
+			So(result.MustArray(), ShouldHaveLength, 1)
+			So(result.GetIndex(0).Get("username").MustString(), ShouldEqual, "locked")
+			So(result.GetIndex(0).Get("attempts").MustInt64(), ShouldEqual, 2)
+		})
+	})
+
+	Convey("When unlocking a user", t, func() {
+		loggedInUserScenarioWithRole("Should delete the failed login attempts", "DELETE", "/api/admin/lockouts/locked", "/api/admin/lockouts/:username", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			var username string
+			bus.AddHandler("test", func(cmd *models.DeleteUserLoginAttemptsCommand) error {
+				username = cmd.Username
+				return nil
+			})
+
+			sc.handlerFunc = AdminUnlockUser
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(username, ShouldEqual, "locked")
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/admin/sessions
+func (hs *HTTPServer) AdminSearchSessions(c *models.ReqContext) Response {
+	perPage := c.QueryInt("perpage")
+	if perPage <= 0 {
+		perPage = 100
+	}
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+
+	query := &models.SearchUserTokensQuery{
+		UserId:     c.QueryInt64("userId"),
+		AuthModule: c.Query("authModule"),
+		Page:       page,
+		Limit:      perPage,
+	}
+
+	result, err := hs.AuthTokenService.SearchUserTokens(c.Req.Context(), query)
+	if err != nil {
+		return Error(500, "Failed to search sessions", err)
+	}
+
+	sessions := make([]*dtos.UserSession, 0, len(result.Tokens))
+	for _, token := range result.Tokens {
+		sessions = append(sessions, &dtos.UserSession{
+			UserToken: *toUserTokenDTO(c, &token.UserToken),
+			UserId:    token.UserId,
+			Login:     token.Login,
+			Email:     token.Email,
+		})
+	}
+
+	return JSON(200, &dtos.SearchUserSessionsResult{
+		TotalCount: result.TotalCount,
+		Sessions:   sessions,
+		Page:       page,
+		PerPage:    perPage,
+	})
+}
+
+// POST /api/admin/sessions/revoke
+func (hs *HTTPServer) AdminRevokeAllSessions(c *models.ReqContext, cmd models.RevokeAllAuthTokensCmd) Response {
+	// The session of the admin revoking all sessions is kept.
+	var currentTokenID int64
+	if c.UserToken != nil {
+		currentTokenID = c.UserToken.Id
+	}
+
+	count, err := hs.AuthTokenService.RevokeAllTokens(c.Req.Context(), cmd.AuthModule, currentTokenID)
+	if err != nil {
+		return Error(500, "Failed to revoke sessions", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message": "Sessions revoked",
+		"count":   count,
+	})
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAdminSessionsApiEndpoint(t *testing.T) {
+	Convey("When searching sessions of all users", t, func() {
+		adminSessionsScenario("Should return sessions with users", "GET", "/api/admin/sessions?authModule=ldap&page=2&perpage=10", "/api/admin/sessions", func(sc *scenarioContext) {
+			var query *models.SearchUserTokensQuery
+			sc.userAuthTokenService.SearchUserTokensProvider = func(ctx context.Context, q *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error) {
+				query = q
+				return &models.SearchUserTokensResult{
+					TotalCount: 11,
+					Tokens: []*models.UserTokenWithUser{
+						{
+							UserToken: models.UserToken{Id: 5, UserId: 3, ClientIp: "127.0.0.1", AuthModule: models.AuthModuleLDAP},
+							Login:     "ldap-user",
+							Email:     "ldap-user@test.com",
+						},
+					},
+				}, nil
+			}
+
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(query.AuthModule, ShouldEqual, models.AuthModuleLDAP)
+			So(query.Page, ShouldEqual, 2)
+			So(query.Limit, ShouldEqual, 10)
+
+			result := sc.ToJSON()
+			So(result.Get("totalCount").MustInt64(), ShouldEqual, 11)
+			So(result.Get("page").MustInt(), ShouldEqual, 2)
+
+			session := result.Get("sessions").GetIndex(0)
+			So(session.Get("id").MustInt64(), ShouldEqual, 5)
+			So(session.Get("userId").MustInt64(), ShouldEqual, 3)
+			So(session.Get("login").MustString(), ShouldEqual, "ldap-user")
+			So(session.Get("clientIp").MustString(), ShouldEqual, "127.0.0.1")
+			So(session.Get("authLabel").MustString(), ShouldEqual, "LDAP")
+		})
+	})
+
+	Convey("When revoking sessions of all users", t, func() {
+		adminSessionsScenario("Should keep the session of the admin", "POST", "/api/admin/sessions/revoke", "/api/admin/sessions/revoke", func(sc *scenarioContext) {
+			var authModule string
+			var exceptTokenID int64
+			sc.userAuthTokenService.RevokeAllTokensProvider = func(ctx context.Context, module string, exceptTokenId int64) (int64, error) {
+				authModule = module
+				exceptTokenID = exceptTokenId
+				return 4, nil
+			}
+
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(authModule, ShouldEqual, "oauth_github")
+			So(exceptTokenID, ShouldEqual, 7)
+			So(sc.ToJSON().Get("count").MustInt64(), ShouldEqual, 4)
+		})
+	})
+}
+
+func adminSessionsScenario(desc string, method string, url string, routePattern string, fn scenarioFunc) {
+	Convey(desc+" "+url, func() {
+		defer bus.ClearBusHandlers()
+
+		fakeAuthTokenService := auth.NewFakeUserAuthTokenService()
+
+		hs := HTTPServer{
+			Bus:              bus.GetBus(),
+			AuthTokenService: fakeAuthTokenService,
+		}
+
+		sc := setupScenarioContext(url)
+		sc.userAuthTokenService = fakeAuthTokenService
+		sc.defaultHandler = Wrap(func(c *models.ReqContext) Response {
+			sc.context = c
+			sc.context.UserId = TestUserID
+			sc.context.IsGrafanaAdmin = true
+			sc.context.UserToken = &models.UserToken{Id: 7}
+
+			if method == "POST" {
+				return hs.AdminRevokeAllSessions(c, models.RevokeAllAuthTokensCmd{AuthModule: "oauth_github"})
+			}
+			return hs.AdminSearchSessions(c)
+		})
+
+		switch method {
+		case "GET":
+			sc.m.Get(routePattern, sc.defaultHandler)
+		case "POST":
+			sc.m.Post(routePattern, sc.defaultHandler)
+		}
+
+		fn(sc)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -25,8 +26,8 @@ func AdminCreateUser(c *models.ReqContext, form dtos.AdminCreateUserForm) {
 		}
 	}
 
-	if len(cmd.Password) < 4 {
-		c.JsonApiErr(400, "Password is missing or too short", nil)
+	if err := passwordpolicy.Validate(cmd.Password); err != nil {
+		passwordPolicyError(err).WriteTo(c)
 		return
 	}
 
@@ -55,11 +56,6 @@ func AdminCreateUser(c *models.ReqContext, form dtos.AdminCreateUserForm) {
 func AdminUpdateUserPassword(c *models.ReqContext, form dtos.AdminUpdateUserPasswordForm) {
 	userID := c.ParamsInt64(":id")
 
-	if len(form.Password) < 4 {
-		c.JsonApiErr(400, "New password too short", nil)
-		return
-	}
-
 	userQuery := models.GetUserByIdQuery{Id: userID}
 
 	if err := bus.Dispatch(&userQuery); err != nil {
@@ -67,6 +63,11 @@ func AdminUpdateUserPassword(c *models.ReqContext, form dtos.AdminUpdateUserPass
 		return
 	}
 
+	if err := passwordpolicy.ValidateForUser(userQuery.Result, form.Password); err != nil {
+		passwordPolicyError(err).WriteTo(c)
+		return
+	}
+
 	passwordHashed, err := util.EncodePassword(form.Password, userQuery.Result.Salt)
 	if err != nil {
 		c.JsonApiErr(500, "Could not encode password", err)
@@ -111,13 +112,23 @@ func AdminUpdateUserPermissions(c *models.ReqContext, form dtos.AdminUpdateUserP
 func AdminDeleteUser(c *models.ReqContext) {
 	userID := c.ParamsInt64(":id")
 
-	cmd := models.DeleteUserCommand{UserId: userID}
+	// the dashboards, versions, annotations and snapshots of the user can be
+	// handed over to another user when the user is deleted
+	cmd := models.DeleteUserCommand{UserId: userID, ReassignToUserId: c.QueryInt64("reassignTo")}
+	if cmd.ReassignToUserId > 0 && cmd.ReassignToUserId == userID {
+		c.JsonApiErr(400, "Cannot reassign content to the deleted user", nil)
+		return
+	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
 		if err == models.ErrUserNotFound {
 			c.JsonApiErr(404, models.ErrUserNotFound.Error(), nil)
 			return
 		}
+		if err == models.ErrReassignUserNotFound {
+			c.JsonApiErr(400, err.Error(), nil)
+			return
+		}
 		c.JsonApiErr(500, "Failed to delete user", err)
 		return
 	}
@@ -125,6 +136,36 @@ func AdminDeleteUser(c *models.ReqContext) {
 	c.JsonOK("User deleted")
 }
 
+// POST /api/admin/users/:id/restore
+func AdminRestoreUser(c *models.ReqContext) Response {
+	if err := bus.Dispatch(&models.RestoreUserCommand{UserId: c.ParamsInt64(":id")}); err != nil {
+		if err == models.ErrUserNotFound {
+			return Error(404, "Deleted user not found", nil)
+		}
+		return Error(500, "Failed to restore user", err)
+	}
+
+	return Success("User restored")
+}
+
+// GET /api/admin/deleted?type=user|org
+func AdminGetDeleted(c *models.ReqContext) Response {
+	resourceType := c.Query("type")
+	if resourceType == "" {
+		resourceType = models.DeletedResourceUser
+	}
+	if resourceType != models.DeletedResourceUser && resourceType != models.DeletedResourceOrg {
+		return Error(400, "Invalid type, must be user or org", nil)
+	}
+
+	query := models.GetDeletedResourcesQuery{Type: resourceType}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get deleted resources", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
 // POST /api/admin/users/:id/disable
 func (server *HTTPServer) AdminDisableUser(c *models.ReqContext) Response {
 	userID := c.ParamsInt64(":id")
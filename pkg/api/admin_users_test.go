@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/setting"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -258,6 +259,27 @@ func TestAdminApiEndpoint(t *testing.T) {
 				So(orgId, ShouldEqual, 1000)
 			})
 		})
+
+		Convey("With a password not meeting the password policy", func() {
+			setting.PasswordMinLength = 10
+			defer func() { setting.PasswordMinLength = 0 }()
+
+			createCmd := dtos.AdminCreateUserForm{
+				Login:    TestLogin,
+				Password: TestPassword,
+			}
+
+			adminCreateUserScenario("Should not create the user", "/api/admin/users", "/api/admin/users", createCmd, func(sc *scenarioContext) {
+				userLogin = ""
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+				So(sc.resp.Code, ShouldEqual, 400)
+
+				respJSON, err := simplejson.NewJson(sc.resp.Body.Bytes())
+				So(err, ShouldBeNil)
+				So(respJSON.Get("message").MustString(), ShouldEqual, "Password does not meet the password policy: it must be at least 10 characters long")
+				So(userLogin, ShouldBeEmpty)
+			})
+		})
 	})
 }
 
@@ -36,6 +36,9 @@ func GetAnnotations(c *models.ReqContext) Response {
 		if item.Email != "" {
 			item.AvatarUrl = dtos.GetGravatarUrl(item.Email)
 		}
+		if item.Login == "" && item.UserId > 0 {
+			item.Login = deletedUserString
+		}
 	}
 
 	return JSON(200, items)
@@ -74,6 +77,9 @@ func PostAnnotation(c *models.ReqContext, cmd dtos.PostAnnotationsCmd) Response
 	}
 
 	if err := repo.Save(&item); err != nil {
+		if quotaErr, ok := err.(models.QuotaReachedError); ok {
+			return Error(403, quotaErr.Error(), nil)
+		}
 		return Error(500, "Failed to save annotation", err)
 	}
 
@@ -135,6 +141,9 @@ func PostGraphiteAnnotation(c *models.ReqContext, cmd dtos.PostGraphiteAnnotatio
 	}
 
 	if err := repo.Save(&item); err != nil {
+		if quotaErr, ok := err.(models.QuotaReachedError); ok {
+			return Error(403, quotaErr.Error(), nil)
+		}
 		return Error(500, "Failed to save Graphite annotation", err)
 	}
 
@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 )
 
 func (hs *HTTPServer) registerRoutes() {
@@ -20,6 +21,11 @@ func (hs *HTTPServer) registerRoutes() {
 	redirectFromLegacyDashboardSoloURL := middleware.RedirectFromLegacyDashboardSoloURL()
 	redirectFromLegacyPanelEditURL := middleware.RedirectFromLegacyPanelEditURL()
 	quota := middleware.Quota(hs.QuotaService)
+	rateLimit := middleware.RateLimit(hs.RemoteCacheService, hs.Cfg)
+	authorize := middleware.Authorize(hs.AccessControl)
+	// users that can create teams can manage the teams they're admin of,
+	// which is checked by the team handlers
+	reqTeamsWrite := authorize(reqCanAccessTeams, accesscontrol.ActionTeamsWrite, accesscontrol.ActionTeamsCreate)
 	bind := binding.Bind
 
 	r := hs.RouteRegister
@@ -39,14 +45,14 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Get("/profile/switch-org/:id", reqSignedIn, hs.ChangeActiveOrgAndRedirectToHome)
 	r.Get("/org/", reqOrgAdmin, hs.Index)
 	r.Get("/org/new", reqGrafanaAdmin, hs.Index)
-	r.Get("/datasources/", reqOrgAdmin, hs.Index)
-	r.Get("/datasources/new", reqOrgAdmin, hs.Index)
-	r.Get("/datasources/edit/*", reqOrgAdmin, hs.Index)
-	r.Get("/org/users", reqOrgAdmin, hs.Index)
-	r.Get("/org/users/new", reqOrgAdmin, hs.Index)
-	r.Get("/org/users/invite", reqOrgAdmin, hs.Index)
-	r.Get("/org/teams", reqCanAccessTeams, hs.Index)
-	r.Get("/org/teams/*", reqCanAccessTeams, hs.Index)
+	r.Get("/datasources/", authorize(reqOrgAdmin, accesscontrol.ActionDatasourcesRead), hs.Index)
+	r.Get("/datasources/new", authorize(reqOrgAdmin, accesscontrol.ActionDatasourcesCreate), hs.Index)
+	r.Get("/datasources/edit/*", authorize(reqOrgAdmin, accesscontrol.ActionDatasourcesRead), hs.Index)
+	r.Get("/org/users", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersRead), hs.Index)
+	r.Get("/org/users/new", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersAdd), hs.Index)
+	r.Get("/org/users/invite", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersAdd), hs.Index)
+	r.Get("/org/teams", reqTeamsWrite, hs.Index)
+	r.Get("/org/teams/*", reqTeamsWrite, hs.Index)
 	r.Get("/org/apikeys/", reqOrgAdmin, hs.Index)
 	r.Get("/dashboard/import/", reqSignedIn, hs.Index)
 	r.Get("/configuration", reqGrafanaAdmin, hs.Index)
@@ -141,33 +147,44 @@ func (hs *HTTPServer) registerRoutes() {
 
 			userRoute.Get("/auth-tokens", Wrap(hs.GetUserAuthTokens))
 			userRoute.Post("/revoke-auth-token", bind(models.RevokeAuthTokenCmd{}), Wrap(hs.RevokeUserAuthToken))
+
+			userRoute.Get("/mfa", Wrap(GetUserMfaStatus))
+			userRoute.Post("/mfa/enroll", Wrap(EnrollUserMfa))
+			userRoute.Post("/mfa/activate", bind(dtos.UserMfaCodeForm{}), Wrap(hs.ActivateUserMfa))
+			userRoute.Post("/mfa/recovery-codes", bind(dtos.UserMfaCodeForm{}), Wrap(RegenerateUserMfaRecoveryCodes))
+			userRoute.Post("/mfa/disable", bind(dtos.UserMfaCodeForm{}), Wrap(DisableUserMfa))
 		})
 
 		// users (admin permission required)
 		apiRoute.Group("/users", func(usersRoute routing.RouteRegister) {
-			usersRoute.Get("/", Wrap(SearchUsers))
-			usersRoute.Get("/search", Wrap(SearchUsersWithPaging))
-			usersRoute.Get("/:id", Wrap(GetUserByID))
-			usersRoute.Get("/:id/teams", Wrap(GetUserTeams))
-			usersRoute.Get("/:id/orgs", Wrap(GetUserOrgList))
+			reqUsersRead := authorize(reqGrafanaAdmin, accesscontrol.ActionUsersRead)
+			reqUsersWrite := authorize(reqGrafanaAdmin, accesscontrol.ActionUsersWrite)
+
+			usersRoute.Get("/", reqUsersRead, Wrap(SearchUsers))
+			usersRoute.Get("/search", reqUsersRead, Wrap(SearchUsersWithPaging))
+			usersRoute.Get("/:id", reqUsersRead, Wrap(GetUserByID))
+			usersRoute.Get("/:id/teams", reqUsersRead, Wrap(GetUserTeams))
+			usersRoute.Get("/:id/orgs", reqUsersRead, Wrap(GetUserOrgList))
 			// query parameters /users/lookup?loginOrEmail=admin@example.com
-			usersRoute.Get("/lookup", Wrap(GetUserByLoginOrEmail))
-			usersRoute.Put("/:id", bind(models.UpdateUserCommand{}), Wrap(UpdateUser))
-			usersRoute.Post("/:id/using/:orgId", Wrap(UpdateUserActiveOrg))
-		}, reqGrafanaAdmin)
+			usersRoute.Get("/lookup", reqUsersRead, Wrap(GetUserByLoginOrEmail))
+			usersRoute.Put("/:id", reqUsersWrite, bind(models.UpdateUserCommand{}), Wrap(UpdateUser))
+			usersRoute.Post("/:id/using/:orgId", reqUsersWrite, Wrap(UpdateUserActiveOrg))
+		})
 
 		// team (admin permission required)
 		apiRoute.Group("/teams", func(teamsRoute routing.RouteRegister) {
-			teamsRoute.Post("/", bind(models.CreateTeamCommand{}), Wrap(hs.CreateTeam))
-			teamsRoute.Put("/:teamId", bind(models.UpdateTeamCommand{}), Wrap(hs.UpdateTeam))
-			teamsRoute.Delete("/:teamId", Wrap(hs.DeleteTeamByID))
-			teamsRoute.Get("/:teamId/members", Wrap(hs.GetTeamMembers))
-			teamsRoute.Post("/:teamId/members", bind(models.AddTeamMemberCommand{}), Wrap(hs.AddTeamMember))
-			teamsRoute.Put("/:teamId/members/:userId", bind(models.UpdateTeamMemberCommand{}), Wrap(hs.UpdateTeamMember))
-			teamsRoute.Delete("/:teamId/members/:userId", Wrap(hs.RemoveTeamMember))
-			teamsRoute.Get("/:teamId/preferences", Wrap(hs.GetTeamPreferences))
-			teamsRoute.Put("/:teamId/preferences", bind(dtos.UpdatePrefsCmd{}), Wrap(hs.UpdateTeamPreferences))
-		}, reqCanAccessTeams)
+			teamsRoute.Post("/", authorize(reqCanAccessTeams, accesscontrol.ActionTeamsCreate), bind(models.CreateTeamCommand{}), Wrap(hs.CreateTeam))
+			teamsRoute.Put("/:teamId", reqTeamsWrite, bind(models.UpdateTeamCommand{}), Wrap(hs.UpdateTeam))
+			teamsRoute.Delete("/:teamId", authorize(reqCanAccessTeams, accesscontrol.ActionTeamsDelete, accesscontrol.ActionTeamsCreate), Wrap(hs.DeleteTeamByID))
+			teamsRoute.Get("/:teamId/members", reqTeamsWrite, Wrap(hs.GetTeamMembers))
+			teamsRoute.Post("/:teamId/members", reqTeamsWrite, bind(models.AddTeamMemberCommand{}), Wrap(hs.AddTeamMember))
+			teamsRoute.Put("/:teamId/members/:userId", reqTeamsWrite, bind(models.UpdateTeamMemberCommand{}), Wrap(hs.UpdateTeamMember))
+			teamsRoute.Delete("/:teamId/members/:userId", reqTeamsWrite, Wrap(hs.RemoveTeamMember))
+			teamsRoute.Get("/:teamId/preferences", reqTeamsWrite, Wrap(hs.GetTeamPreferences))
+			teamsRoute.Put("/:teamId/preferences", reqTeamsWrite, bind(dtos.UpdatePrefsCmd{}), Wrap(hs.UpdateTeamPreferences))
+			teamsRoute.Get("/deleted", authorize(reqOrgAdmin, accesscontrol.ActionTeamsDelete), Wrap(GetDeletedTeams))
+			teamsRoute.Post("/:teamId/restore", authorize(reqOrgAdmin, accesscontrol.ActionTeamsDelete), Wrap(RestoreTeamByID))
+		})
 
 		// team without requirement of user to be org admin
 		apiRoute.Group("/teams", func(teamsRoute routing.RouteRegister) {
@@ -198,21 +215,27 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Group("/org", func(orgRoute routing.RouteRegister) {
 			orgRoute.Put("/", bind(dtos.UpdateOrgForm{}), Wrap(UpdateOrgCurrent))
 			orgRoute.Put("/address", bind(dtos.UpdateOrgAddressForm{}), Wrap(UpdateOrgAddressCurrent))
-			orgRoute.Get("/users", Wrap(GetOrgUsersForCurrentOrg))
-			orgRoute.Post("/users", quota("user"), bind(models.AddOrgUserCommand{}), Wrap(AddOrgUserToCurrentOrg))
-			orgRoute.Patch("/users/:userId", bind(models.UpdateOrgUserCommand{}), Wrap(UpdateOrgUserForCurrentOrg))
-			orgRoute.Delete("/users/:userId", Wrap(RemoveOrgUserForCurrentOrg))
-
-			// invites
-			orgRoute.Get("/invites", Wrap(GetPendingOrgInvites))
-			orgRoute.Post("/invites", quota("user"), bind(dtos.AddInviteForm{}), Wrap(AddOrgInvite))
-			orgRoute.Patch("/invites/:code/revoke", Wrap(RevokeInvite))
+			orgRoute.Get("/session-settings", Wrap(GetOrgSessionSettings))
+			orgRoute.Put("/session-settings", bind(models.UpdateOrgSessionSettingsCommand{}), Wrap(hs.UpdateOrgSessionSettings))
 
 			// prefs
 			orgRoute.Get("/preferences", Wrap(GetOrgPreferences))
 			orgRoute.Put("/preferences", bind(dtos.UpdatePrefsCmd{}), Wrap(UpdateOrgPreferences))
 		}, reqOrgAdmin)
 
+		// current org users and invites
+		apiRoute.Group("/org", func(orgRoute routing.RouteRegister) {
+			orgRoute.Get("/users", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersRead), Wrap(GetOrgUsersForCurrentOrg))
+			orgRoute.Post("/users", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersAdd), quota("user"), bind(models.AddOrgUserCommand{}), Wrap(AddOrgUserToCurrentOrg))
+			orgRoute.Patch("/users/:userId", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersRoleUpdate), bind(models.UpdateOrgUserCommand{}), Wrap(UpdateOrgUserForCurrentOrg))
+			orgRoute.Delete("/users/:userId", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersRemove), Wrap(RemoveOrgUserForCurrentOrg))
+
+			// invites
+			orgRoute.Get("/invites", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersAdd), Wrap(GetPendingOrgInvites))
+			orgRoute.Post("/invites", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersAdd), quota("user"), bind(dtos.AddInviteForm{}), Wrap(AddOrgInvite))
+			orgRoute.Patch("/invites/:code/revoke", authorize(reqOrgAdmin, accesscontrol.ActionOrgUsersAdd), Wrap(RevokeInvite))
+		})
+
 		// current org without requirement of user to be org admin
 		apiRoute.Group("/org", func(orgRoute routing.RouteRegister) {
 			orgRoute.Get("/users/lookup", Wrap(GetOrgUsersForCurrentOrgLookup))
@@ -230,12 +253,16 @@ func (hs *HTTPServer) registerRoutes() {
 			orgsRoute.Put("/", bind(dtos.UpdateOrgForm{}), Wrap(UpdateOrg))
 			orgsRoute.Put("/address", bind(dtos.UpdateOrgAddressForm{}), Wrap(UpdateOrgAddress))
 			orgsRoute.Delete("/", Wrap(DeleteOrgByID))
+			orgsRoute.Post("/restore", Wrap(RestoreOrgByID))
 			orgsRoute.Get("/users", Wrap(GetOrgUsers))
 			orgsRoute.Post("/users", bind(models.AddOrgUserCommand{}), Wrap(AddOrgUser))
 			orgsRoute.Patch("/users/:userId", bind(models.UpdateOrgUserCommand{}), Wrap(UpdateOrgUser))
 			orgsRoute.Delete("/users/:userId", Wrap(RemoveOrgUser))
 			orgsRoute.Get("/quotas", Wrap(GetOrgQuotas))
 			orgsRoute.Put("/quotas/:target", bind(models.UpdateOrgQuotaCmd{}), Wrap(UpdateOrgQuota))
+			orgsRoute.Get("/scim/tokens", Wrap(GetScimTokens))
+			orgsRoute.Post("/scim/tokens", bind(models.AddScimTokenCommand{}), Wrap(AddScimToken))
+			orgsRoute.Delete("/scim/tokens/:tokenId", Wrap(DeleteScimToken))
 		}, reqGrafanaAdmin)
 
 		// orgs (admin routes)
@@ -257,14 +284,51 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// Data sources
 		apiRoute.Group("/datasources", func(datasourceRoute routing.RouteRegister) {
-			datasourceRoute.Get("/", Wrap(GetDataSources))
-			datasourceRoute.Post("/", quota("data_source"), bind(models.AddDataSourceCommand{}), Wrap(AddDataSource))
-			datasourceRoute.Put("/:id", bind(models.UpdateDataSourceCommand{}), Wrap(UpdateDataSource))
-			datasourceRoute.Delete("/:id", Wrap(DeleteDataSourceById))
-			datasourceRoute.Delete("/name/:name", Wrap(DeleteDataSourceByName))
-			datasourceRoute.Get("/:id", Wrap(GetDataSourceById))
-			datasourceRoute.Get("/name/:name", Wrap(GetDataSourceByName))
-		}, reqOrgAdmin)
+			reqDatasourcesRead := authorize(reqOrgAdmin, accesscontrol.ActionDatasourcesRead)
+			// Editing and deleting are checked against the permissions of
+			// the data source in the handlers.
+			reqDatasourcesWrite := authorize(reqSignedIn, accesscontrol.ActionDatasourcesWrite)
+			reqDatasourcesDelete := authorize(reqSignedIn, accesscontrol.ActionDatasourcesDelete)
+
+			datasourceRoute.Get("/", reqDatasourcesRead, Wrap(GetDataSources))
+			datasourceRoute.Post("/", authorize(reqOrgAdmin, accesscontrol.ActionDatasourcesCreate), quota("data_source"), bind(models.AddDataSourceCommand{}), Wrap(AddDataSource))
+			datasourceRoute.Put("/:id", reqDatasourcesWrite, bind(models.UpdateDataSourceCommand{}), Wrap(UpdateDataSource))
+			datasourceRoute.Delete("/:id", reqDatasourcesDelete, Wrap(DeleteDataSourceById))
+			datasourceRoute.Delete("/name/:name", reqDatasourcesDelete, Wrap(DeleteDataSourceByName))
+			datasourceRoute.Get("/:id", reqDatasourcesRead, Wrap(GetDataSourceById))
+			datasourceRoute.Get("/name/:name", reqDatasourcesRead, Wrap(GetDataSourceByName))
+
+			// Permissions can be managed by users with the Admin permission
+			// on the data source, which is checked in the handlers.
+			reqDatasourcePermissionsRead := authorize(reqSignedIn, accesscontrol.ActionDatasourcesPermissionsRead)
+			reqDatasourcePermissionsWrite := authorize(reqSignedIn, accesscontrol.ActionDatasourcesPermissionsWrite)
+
+			datasourceRoute.Post("/:id/enable-permissions", reqDatasourcePermissionsWrite, Wrap(EnableDataSourcePermissions))
+			datasourceRoute.Post("/:id/disable-permissions", reqDatasourcePermissionsWrite, Wrap(DisableDataSourcePermissions))
+			datasourceRoute.Get("/:id/permissions", reqDatasourcePermissionsRead, Wrap(GetDataSourcePermissions))
+			datasourceRoute.Post("/:id/permissions", reqDatasourcePermissionsWrite, bind(models.AddDataSourcePermissionCommand{}), Wrap(AddDataSourcePermission))
+			datasourceRoute.Delete("/:id/permissions/:permissionId", reqDatasourcePermissionsWrite, Wrap(RemoveDataSourcePermission))
+		})
+
+		// Access control
+		apiRoute.Group("/access-control", func(acRoute routing.RouteRegister) {
+			reqRolesRead := authorize(reqOrgAdmin, accesscontrol.ActionRolesRead)
+			reqRolesWrite := authorize(reqOrgAdmin, accesscontrol.ActionRolesWrite)
+
+			acRoute.Get("/user/permissions", Wrap(hs.GetSignedInUserPermissions))
+			acRoute.Get("/builtin-roles", reqRolesRead, Wrap(hs.GetBuiltInRoles))
+			acRoute.Get("/roles", reqRolesRead, Wrap(GetRoles))
+			acRoute.Post("/roles", reqRolesWrite, bind(models.CreateRoleCommand{}), Wrap(hs.CreateRole))
+			acRoute.Get("/roles/:roleUid", reqRolesRead, Wrap(GetRoleByUID))
+			acRoute.Put("/roles/:roleUid", reqRolesWrite, bind(models.UpdateRoleCommand{}), Wrap(hs.UpdateRole))
+			acRoute.Delete("/roles/:roleUid", authorize(reqOrgAdmin, accesscontrol.ActionRolesDelete), Wrap(hs.DeleteRole))
+			acRoute.Get("/users/:userId/roles", reqRolesRead, Wrap(GetUserRoles))
+			acRoute.Post("/users/:userId/roles", reqRolesWrite, bind(models.AddUserRoleCommand{}), Wrap(hs.AddUserRole))
+			acRoute.Delete("/users/:userId/roles/:roleUid", reqRolesWrite, Wrap(hs.RemoveUserRole))
+			acRoute.Get("/teams/:teamId/roles", reqRolesRead, Wrap(GetTeamRoles))
+			acRoute.Post("/teams/:teamId/roles", reqRolesWrite, bind(models.AddTeamRoleCommand{}), Wrap(hs.AddTeamRole))
+			acRoute.Delete("/teams/:teamId/roles/:roleUid", reqRolesWrite, Wrap(hs.RemoveTeamRole))
+		})
 
 		apiRoute.Get("/datasources/id/:name", Wrap(GetDataSourceIdByName), reqSignedIn)
 
@@ -298,6 +362,7 @@ func (hs *HTTPServer) registerRoutes() {
 				folderUidRoute.Get("/", Wrap(GetFolderByUID))
 				folderUidRoute.Put("/", bind(models.UpdateFolderCommand{}), Wrap(UpdateFolder))
 				folderUidRoute.Delete("/", Wrap(DeleteFolder))
+				folderUidRoute.Post("/move", bind(models.MoveFolderCommand{}), Wrap(MoveFolder))
 
 				folderUidRoute.Group("/permissions", func(folderPermissionRoute routing.RouteRegister) {
 					folderPermissionRoute.Get("/", Wrap(GetFolderPermissionList))
@@ -306,11 +371,41 @@ func (hs *HTTPServer) registerRoutes() {
 			})
 		})
 
+		// Library panels
+		apiRoute.Group("/library-panels", func(libraryPanelRoute routing.RouteRegister) {
+			libraryPanelRoute.Get("/", Wrap(GetLibraryPanels))
+			libraryPanelRoute.Post("/", bind(models.CreateLibraryPanelCommand{}), Wrap(CreateLibraryPanel))
+			libraryPanelRoute.Get("/:uid", Wrap(GetLibraryPanelByUID))
+			libraryPanelRoute.Patch("/:uid", bind(models.UpdateLibraryPanelCommand{}), Wrap(UpdateLibraryPanel))
+			libraryPanelRoute.Delete("/:uid", Wrap(DeleteLibraryPanel))
+			libraryPanelRoute.Get("/:uid/dashboards", Wrap(GetLibraryPanelDashboards))
+		})
+
+		// Bundles
+		apiRoute.Group("/bundles", func(bundlesRoute routing.RouteRegister) {
+			bundlesRoute.Get("/export", Wrap(ExportBundle))
+			bundlesRoute.Post("/import", bind(dtos.ImportBundleCommand{}), Wrap(ImportBundle))
+		}, reqOrgAdmin)
+
+		// Dashboard usage
+		apiRoute.Group("/usage", func(usageRoute routing.RouteRegister) {
+			usageRoute.Get("/dashboards", Wrap(GetDashboardUsage))
+			usageRoute.Get("/dashboards/uid/:uid/views", Wrap(GetDashboardViews))
+			usageRoute.Get("/users", Wrap(GetUserDashboardUsage))
+			usageRoute.Get("/folders", Wrap(GetFolderUsage))
+		}, reqOrgAdmin)
+
 		// Dashboard
 		apiRoute.Group("/dashboards", func(dashboardRoute routing.RouteRegister) {
 			dashboardRoute.Get("/uid/:uid", Wrap(hs.GetDashboard))
 			dashboardRoute.Delete("/uid/:uid", Wrap(DeleteDashboardByUID))
 
+			dashboardRoute.Get("/uid/:uid/public-shares", Wrap(GetDashboardPublicShares))
+			dashboardRoute.Post("/uid/:uid/public-shares", bind(dtos.CreateDashboardPublicShareCommand{}), Wrap(CreateDashboardPublicShare))
+			dashboardRoute.Delete("/uid/:uid/public-shares/:shareUid", Wrap(RevokeDashboardPublicShare))
+
+			dashboardRoute.Post("/uid/:uid/snapshots", bind(dtos.GenerateDashboardSnapshotCommand{}), Wrap(GenerateDashboardSnapshot))
+
 			dashboardRoute.Get("/db/:slug", Wrap(hs.GetDashboard))
 			dashboardRoute.Delete("/db/:slug", Wrap(DeleteDashboardBySlug))
 
@@ -323,7 +418,10 @@ func (hs *HTTPServer) registerRoutes() {
 
 			dashboardRoute.Group("/id/:dashboardId", func(dashIdRoute routing.RouteRegister) {
 				dashIdRoute.Get("/versions", Wrap(GetDashboardVersions))
+				dashIdRoute.Get("/versions/export", Wrap(ExportDashboardVersionHistory))
 				dashIdRoute.Get("/versions/:id", Wrap(GetDashboardVersion))
+				dashIdRoute.Post("/versions/:id/pin", bind(dtos.PinDashboardVersionCommand{}), Wrap(PinDashboardVersion))
+				dashIdRoute.Delete("/versions/:id/pin", Wrap(UnpinDashboardVersion))
 				dashIdRoute.Post("/restore", bind(dtos.RestoreDashboardVersionCommand{}), Wrap(hs.RestoreDashboardVersion))
 
 				dashIdRoute.Group("/permissions", func(dashboardPermissionRoute routing.RouteRegister) {
@@ -331,7 +429,7 @@ func (hs *HTTPServer) registerRoutes() {
 					dashboardPermissionRoute.Post("/", bind(dtos.UpdateDashboardAclCommand{}), Wrap(UpdateDashboardPermissions))
 				})
 			})
-		})
+		}, rateLimit("dashboards"))
 
 		// Dashboard snapshots
 		apiRoute.Group("/dashboard/snapshots", func(dashboardRoute routing.RouteRegister) {
@@ -351,16 +449,16 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// Search
 		apiRoute.Get("/search/sorting", Wrap(hs.ListSortOptions))
-		apiRoute.Get("/search/", Wrap(Search))
+		apiRoute.Get("/search/", rateLimit("search"), Wrap(Search))
 
 		// metrics
-		apiRoute.Post("/tsdb/query", bind(dtos.MetricRequest{}), Wrap(hs.QueryMetrics))
+		apiRoute.Post("/tsdb/query", rateLimit("query"), bind(dtos.MetricRequest{}), Wrap(hs.QueryMetrics))
 		apiRoute.Get("/tsdb/testdata/scenarios", Wrap(GetTestDataScenarios))
 		apiRoute.Get("/tsdb/testdata/gensql", reqGrafanaAdmin, Wrap(GenerateSQLTestData))
 		apiRoute.Get("/tsdb/testdata/random-walk", Wrap(GetTestDataRandomWalk))
 
 		// DataSource w/ expressions
-		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/query", rateLimit("query"), bind(dtos.MetricRequest{}), Wrap(hs.QueryMetricsV2))
 
 		apiRoute.Group("/alerts", func(alertsRoute routing.RouteRegister) {
 			alertsRoute.Post("/test", bind(dtos.AlertTestCommand{}), Wrap(AlertTest))
@@ -402,35 +500,62 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// error test
 		r.Get("/metrics/error", Wrap(GenerateError))
-	}, reqSignedIn)
+	}, reqSignedIn, rateLimit("api"))
 
 	// admin api
 	r.Group("/api/admin", func(adminRoute routing.RouteRegister) {
-		adminRoute.Get("/settings", AdminGetSettings)
-		adminRoute.Post("/users", bind(dtos.AdminCreateUserForm{}), AdminCreateUser)
-		adminRoute.Put("/users/:id/password", bind(dtos.AdminUpdateUserPasswordForm{}), AdminUpdateUserPassword)
-		adminRoute.Put("/users/:id/permissions", bind(dtos.AdminUpdateUserPermissionsForm{}), AdminUpdateUserPermissions)
-		adminRoute.Delete("/users/:id", AdminDeleteUser)
-		adminRoute.Post("/users/:id/disable", Wrap(hs.AdminDisableUser))
-		adminRoute.Post("/users/:id/enable", Wrap(AdminEnableUser))
-		adminRoute.Get("/users/:id/quotas", Wrap(GetUserQuotas))
-		adminRoute.Put("/users/:id/quotas/:target", bind(models.UpdateUserQuotaCmd{}), Wrap(UpdateUserQuota))
-		adminRoute.Get("/stats", AdminGetStats)
-		adminRoute.Post("/pause-all-alerts", bind(dtos.PauseAllAlertsCommand{}), Wrap(PauseAllAlerts))
-
-		adminRoute.Post("/users/:id/logout", Wrap(hs.AdminLogoutUser))
-		adminRoute.Get("/users/:id/auth-tokens", Wrap(hs.AdminGetUserAuthTokens))
-		adminRoute.Post("/users/:id/revoke-auth-token", bind(models.RevokeAuthTokenCmd{}), Wrap(hs.AdminRevokeUserAuthToken))
-
-		adminRoute.Post("/provisioning/dashboards/reload", Wrap(hs.AdminProvisioningReloadDashboards))
-		adminRoute.Post("/provisioning/plugins/reload", Wrap(hs.AdminProvisioningReloadPlugins))
-		adminRoute.Post("/provisioning/datasources/reload", Wrap(hs.AdminProvisioningReloadDatasources))
-		adminRoute.Post("/provisioning/notifications/reload", Wrap(hs.AdminProvisioningReloadNotifications))
-		adminRoute.Post("/ldap/reload", Wrap(hs.ReloadLDAPCfg))
-		adminRoute.Post("/ldap/sync/:id", Wrap(hs.PostSyncUserWithLDAP))
-		adminRoute.Get("/ldap/:username", Wrap(hs.GetUserFromLDAP))
-		adminRoute.Get("/ldap/status", Wrap(hs.GetLDAPStatus))
-	}, reqGrafanaAdmin)
+		adminRoute.Get("/settings", reqGrafanaAdmin, AdminGetSettings)
+		adminRoute.Post("/users", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersCreate), bind(dtos.AdminCreateUserForm{}), AdminCreateUser)
+		adminRoute.Put("/users/:id/password", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersPasswordUpdate), bind(dtos.AdminUpdateUserPasswordForm{}), AdminUpdateUserPassword)
+		adminRoute.Put("/users/:id/permissions", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersPermissionsUpdate), bind(dtos.AdminUpdateUserPermissionsForm{}), AdminUpdateUserPermissions)
+		adminRoute.Delete("/users/:id", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersDelete), AdminDeleteUser)
+		adminRoute.Post("/users/:id/restore", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersDelete), Wrap(AdminRestoreUser))
+		adminRoute.Get("/deleted", reqGrafanaAdmin, Wrap(AdminGetDeleted))
+		adminRoute.Post("/users/:id/disable", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersDisable), Wrap(hs.AdminDisableUser))
+		adminRoute.Post("/users/:id/enable", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersDisable), Wrap(AdminEnableUser))
+		adminRoute.Get("/users/:id/quotas", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersQuotasRead), Wrap(GetUserQuotas))
+		adminRoute.Put("/users/:id/quotas/:target", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersQuotasUpdate), bind(models.UpdateUserQuotaCmd{}), Wrap(UpdateUserQuota))
+		adminRoute.Get("/stats", reqGrafanaAdmin, AdminGetStats)
+		adminRoute.Post("/pause-all-alerts", reqGrafanaAdmin, bind(dtos.PauseAllAlertsCommand{}), Wrap(PauseAllAlerts))
+
+		adminRoute.Post("/users/:id/logout", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersLogout), Wrap(hs.AdminLogoutUser))
+		adminRoute.Get("/users/:id/auth-tokens", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersRead), Wrap(hs.AdminGetUserAuthTokens))
+		adminRoute.Post("/users/:id/revoke-auth-token", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersLogout), bind(models.RevokeAuthTokenCmd{}), Wrap(hs.AdminRevokeUserAuthToken))
+		adminRoute.Delete("/users/:id/mfa", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersMfaReset), Wrap(hs.AdminResetUserMfa))
+		adminRoute.Get("/sessions", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersRead), Wrap(hs.AdminSearchSessions))
+		adminRoute.Post("/sessions/revoke", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersLogout), bind(models.RevokeAllAuthTokensCmd{}), Wrap(hs.AdminRevokeAllSessions))
+		adminRoute.Get("/lockouts", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersLockoutsRead), Wrap(AdminGetLockedUsers))
+		adminRoute.Delete("/lockouts/:username", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersLockoutsDelete), Wrap(AdminUnlockUser))
+
+		adminRoute.Get("/dashboards/invalid", reqGrafanaAdmin, Wrap(AdminGetInvalidDashboards))
+		adminRoute.Post("/search/reindex", reqGrafanaAdmin, Wrap(AdminRebuildSearchIndex))
+		adminRoute.Post("/provisioning/dashboards/reload", reqGrafanaAdmin, Wrap(hs.AdminProvisioningReloadDashboards))
+		adminRoute.Post("/provisioning/plugins/reload", reqGrafanaAdmin, Wrap(hs.AdminProvisioningReloadPlugins))
+		adminRoute.Post("/provisioning/datasources/reload", reqGrafanaAdmin, Wrap(hs.AdminProvisioningReloadDatasources))
+		adminRoute.Post("/provisioning/notifications/reload", reqGrafanaAdmin, Wrap(hs.AdminProvisioningReloadNotifications))
+		adminRoute.Post("/ldap/reload", reqGrafanaAdmin, Wrap(hs.ReloadLDAPCfg))
+		adminRoute.Post("/ldap/sync/:id", reqGrafanaAdmin, Wrap(hs.PostSyncUserWithLDAP))
+		adminRoute.Get("/ldap/:username", reqGrafanaAdmin, Wrap(hs.GetUserFromLDAP))
+		adminRoute.Get("/ldap/status", reqGrafanaAdmin, Wrap(hs.GetLDAPStatus))
+	}, reqSignedIn)
+
+	// SCIM provisioning api, authenticated with SCIM tokens
+	r.Group("/scim/v2", func(scimRoute routing.RouteRegister) {
+		scimRoute.Get("/ServiceProviderConfig", Wrap(GetScimServiceProviderConfig))
+		scimRoute.Get("/ResourceTypes", Wrap(GetScimResourceTypes))
+		scimRoute.Get("/Users", Wrap(GetScimUsers))
+		scimRoute.Post("/Users", Wrap(hs.CreateScimUser))
+		scimRoute.Get("/Users/:id", Wrap(GetScimUser))
+		scimRoute.Put("/Users/:id", Wrap(hs.ReplaceScimUser))
+		scimRoute.Patch("/Users/:id", Wrap(hs.PatchScimUser))
+		scimRoute.Delete("/Users/:id", Wrap(DeleteScimUser))
+		scimRoute.Get("/Groups", Wrap(GetScimGroups))
+		scimRoute.Post("/Groups", Wrap(CreateScimGroup))
+		scimRoute.Get("/Groups/:id", Wrap(GetScimGroup))
+		scimRoute.Put("/Groups/:id", Wrap(ReplaceScimGroup))
+		scimRoute.Patch("/Groups/:id", Wrap(PatchScimGroup))
+		scimRoute.Delete("/Groups/:id", Wrap(DeleteScimGroup))
+	}, scimEnabled, reqSignedIn)
 
 	// rendering
 	r.Get("/render/*", reqSignedIn, hs.RenderToPng)
@@ -452,9 +577,16 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Post("/api/snapshots/", reqSnapshotPublicModeOrSignedIn, bind(models.CreateDashboardSnapshotCommand{}), CreateDashboardSnapshot)
 	r.Get("/api/snapshot/shared-options/", reqSignedIn, GetSharingOptions)
 	r.Get("/api/snapshots/:key", GetDashboardSnapshot)
+	r.Get("/api/snapshots/:key/versions", Wrap(GetDashboardSnapshotVersions))
+	r.Get("/api/snapshots/:key/versions/:version", Wrap(GetDashboardSnapshotVersion))
+	r.Post("/api/snapshots/:key/refresh", reqSignedIn, Wrap(RefreshDashboardSnapshot))
 	r.Get("/api/snapshots-delete/:deleteKey", reqSnapshotPublicModeOrSignedIn, Wrap(DeleteDashboardSnapshotByDeleteKey))
 	r.Delete("/api/snapshots/:key", reqEditorRole, Wrap(DeleteDashboardSnapshot))
 
+	// Public dashboard shares, the token gives access
+	r.Get("/api/public/dashboards/:token", rateLimit("dashboards"), Wrap(GetPublicDashboard))
+	r.Post("/api/public/dashboards/:token/query", rateLimit("query"), bind(dtos.MetricRequest{}), Wrap(QueryPublicDashboardMetrics))
+
 	r.Get("/*", reqSignedIn, hs.Index)
 }
 
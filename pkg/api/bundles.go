@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/bundles"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/bundles/export
+func ExportBundle(c *models.ReqContext) Response {
+	bundle, err := bundles.Export(bundles.ExportOptions{
+		OrgId:         c.OrgId,
+		FolderUids:    c.QueryStrings("folderUid"),
+		DashboardUids: c.QueryStrings("dashboardUid"),
+	})
+	if err != nil {
+		if err == models.ErrFolderNotFound {
+			return Error(404, "Folder not found", err)
+		}
+		if err == models.ErrDashboardNotFound {
+			return Error(404, "Dashboard not found", err)
+		}
+		return Error(500, "Failed to export bundle", err)
+	}
+
+	filename := fmt.Sprintf("bundle-%s.json", time.Now().Format("20060102-150405"))
+	return JSON(200, bundle).Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+}
+
+// POST /api/bundles/import
+func ImportBundle(c *models.ReqContext, cmd dtos.ImportBundleCommand) Response {
+	result, err := bundles.Import(cmd.Bundle, bundles.ImportOptions{
+		OrgId:       c.OrgId,
+		User:        c.SignedInUser,
+		DryRun:      cmd.DryRun,
+		Overwrite:   cmd.Overwrite,
+		NewUids:     cmd.NewUids,
+		Uids:        cmd.Uids,
+		DataSources: cmd.DataSources,
+		FolderUid:   cmd.FolderUid,
+	})
+
+	switch {
+	case err == nil:
+		return JSON(200, result)
+	case err == bundles.ErrImportConflicts:
+		return JSON(409, util.DynMap{"status": "conflicts", "message": err.Error(), "result": result})
+	case err == bundles.ErrUnsupportedBundleVersion, errors.Is(err, bundles.ErrInvalidBundle),
+		errors.Is(err, bundles.ErrDataSourceMappingNotFound):
+		return Error(400, err.Error(), err)
+	case err == models.ErrFolderNotFound:
+		return Error(404, "Folder not found", err)
+	}
+
+	var quotaErr models.QuotaReachedError
+	if errors.As(err, &quotaErr) {
+		return Error(403, quotaErr.Error(), nil)
+	}
+
+	return Error(500, "Failed to import bundle", err)
+}
@@ -6,10 +6,12 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboards/schema"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
@@ -23,9 +25,12 @@ import (
 )
 
 const (
-	anonString = "Anonymous"
+	anonString        = "Anonymous"
+	deletedUserString = "Deleted user"
 )
 
+var dashboardsLogger = log.New("dashboards")
+
 func isDashboardStarredByUser(c *models.ReqContext, dashID int64) (bool, error) {
 	if !c.IsSignedIn {
 		return false, nil
@@ -126,6 +131,7 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) Response {
 			// is for better UX, showing in Save/Delete dialogs and so it won't break anything if it is empty.
 			hs.log.Warn("Failed to create ProvisionedExternalId", "err", err)
 		}
+		meta.ProvisionedCommit = provisioningData.CommitHash
 	}
 
 	// make sure db version is in sync with json model version
@@ -136,6 +142,8 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) Response {
 		Meta:      meta,
 	}
 
+	publishDashboardViewed(c, dash.Id)
+
 	c.TimeRequest(metrics.MApiDashboardGet)
 	return JSON(200, dto)
 }
@@ -143,6 +151,9 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) Response {
 func getUserLogin(userID int64) string {
 	query := models.GetUserByIdQuery{Id: userID}
 	err := bus.Dispatch(&query)
+	if err == models.ErrUserNotFound {
+		return deletedUserString
+	}
 	if err != nil {
 		return anonString
 	}
@@ -240,6 +251,8 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		OrgId:     c.OrgId,
 		User:      c.SignedInUser,
 		Overwrite: cmd.Overwrite,
+		Strict:    cmd.Strict,
+		Merge:     cmd.Merge,
 	}
 
 	dashboard, err := dashboards.NewService().SaveDashboard(dashItem, allowUiUpdate)
@@ -247,6 +260,13 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		return dashboardSaveErrorToApiResponse(err)
 	}
 
+	if provisioningData != nil {
+		err := hs.ProvisioningService.SaveDashboardToSource(provisioningData, dashboard, c.SignedInUser, cmd.Message)
+		if err != nil {
+			hs.log.Error("Failed to save dashboard to provisioning source", "dashboard", dashboard.Title, "provisioner", provisioningData.Name, "error", err)
+		}
+	}
+
 	if hs.Cfg.EditorsCanAdmin && newDashboard {
 		inFolder := cmd.FolderId > 0
 		err := dashboards.MakeUserAdmin(hs.Bus, cmd.OrgId, cmd.UserId, dashboard.Id, !inFolder)
@@ -256,14 +276,18 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 	}
 
 	c.TimeRequest(metrics.MApiDashboardSave)
-	return JSON(200, util.DynMap{
+	result := util.DynMap{
 		"status":  "success",
 		"slug":    dashboard.Slug,
 		"version": dashboard.Version,
 		"id":      dashboard.Id,
 		"uid":     dashboard.Uid,
 		"url":     dashboard.GetUrl(),
-	})
+	}
+	if dashItem.Merged {
+		result["merged"] = true
+	}
+	return JSON(200, result)
 }
 
 func dashboardSaveErrorToApiResponse(err error) Response {
@@ -276,20 +300,29 @@ func dashboardSaveErrorToApiResponse(err error) Response {
 		err == models.ErrDashboardWithSameUIDExists ||
 		err == models.ErrFolderNotFound ||
 		err == models.ErrDashboardFolderCannotHaveParent ||
+		err == models.ErrFolderCircularReference ||
 		err == models.ErrDashboardFolderNameExists ||
 		err == models.ErrDashboardRefreshIntervalTooShort ||
 		err == models.ErrDashboardCannotSaveProvisionedDashboard {
 		return Error(400, err.Error(), nil)
 	}
 
-	if err == models.ErrDashboardUpdateAccessDenied {
+	if err == models.ErrDashboardUpdateAccessDenied || err == models.ErrLibraryPanelAccessDenied {
 		return Error(403, err.Error(), err)
 	}
 
+	if quotaErr, ok := err.(models.QuotaReachedError); ok {
+		return Error(403, quotaErr.Error(), nil)
+	}
+
 	if validationErr, ok := err.(alerting.ValidationError); ok {
 		return Error(422, validationErr.Error(), nil)
 	}
 
+	if schemaErrs, ok := err.(schema.ValidationErrors); ok {
+		return JSON(400, util.DynMap{"status": "invalid-schema", "message": schemaErrs.Error(), "errors": schemaErrs})
+	}
+
 	if err == models.ErrDashboardWithSameNameInFolderExists {
 		return JSON(412, util.DynMap{"status": "name-exists", "message": err.Error()})
 	}
@@ -399,6 +432,10 @@ func GetDashboardVersions(c *models.ReqContext) Response {
 	}
 
 	for _, version := range query.Result {
+		if version.CreatedBy == "" && version.CreatedById > 0 {
+			version.CreatedBy = deletedUserString
+		}
+
 		if version.RestoredFrom == version.Version {
 			version.Message = "Initial save (created by migration)"
 			continue
@@ -451,11 +488,94 @@ func GetDashboardVersion(c *models.ReqContext) Response {
 		Created:       query.Result.Created,
 		Message:       query.Result.Message,
 		CreatedBy:     creator,
+		FolderId:      query.Result.FolderId,
+		Pinned:        query.Result.Pinned,
+		Label:         query.Result.Label,
 	}
 
 	return JSON(200, dashVersionMeta)
 }
 
+// POST /api/dashboards/id/:dashboardId/versions/:id/pin
+func PinDashboardVersion(c *models.ReqContext, apiCmd dtos.PinDashboardVersionCommand) Response {
+	return pinDashboardVersion(c, true, apiCmd.Label)
+}
+
+// DELETE /api/dashboards/id/:dashboardId/versions/:id/pin
+func UnpinDashboardVersion(c *models.ReqContext) Response {
+	return pinDashboardVersion(c, false, "")
+}
+
+func pinDashboardVersion(c *models.ReqContext, pinned bool, label string) Response {
+	dash, rsp := getDashboardHelper(c.OrgId, "", c.ParamsInt64(":dashboardId"), "")
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(dash.Id, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	cmd := models.PinDashboardVersionCommand{
+		DashboardId: dash.Id,
+		Version:     c.ParamsInt(":id"),
+		Pinned:      pinned,
+		Label:       label,
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrDashboardVersionNotFound {
+			return Error(404, "Dashboard version not found", err)
+		}
+		return Error(500, "Failed to update dashboard version", err)
+	}
+
+	if pinned {
+		return Success("Dashboard version pinned")
+	}
+	return Success("Dashboard version unpinned")
+}
+
+// GET /api/dashboards/id/:dashboardId/versions/export returns all versions of the dashboard as a bundle.
+func ExportDashboardVersionHistory(c *models.ReqContext) Response {
+	dash, rsp := getDashboardHelper(c.OrgId, "", c.ParamsInt64(":dashboardId"), "")
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(dash.Id, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	query := models.GetDashboardVersionHistoryQuery{DashboardId: dash.Id, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(404, fmt.Sprintf("No versions found for dashboardId %d", dash.Id), err)
+	}
+
+	for _, version := range query.Result {
+		if version.CreatedBy == "" {
+			version.CreatedBy = anonString
+		}
+	}
+
+	history := dtos.DashboardVersionHistory{
+		Dashboard: dtos.DashboardVersionHistoryMeta{
+			Id:       dash.Id,
+			Uid:      dash.Uid,
+			Title:    dash.Title,
+			FolderId: dash.FolderId,
+			Version:  dash.Version,
+		},
+		ExportedAt: time.Now(),
+		Versions:   query.Result,
+	}
+
+	filename := fmt.Sprintf("%s-versions.json", dash.Slug)
+	return JSON(200, history).Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+}
+
 // POST /api/dashboards/calculate-diff performs diffs on two dashboards
 func CalculateDashboardDiff(c *models.ReqContext, apiOptions dtos.CalculateDiffOptions) Response {
 	guardianBase := guardian.New(apiOptions.Base.DashboardId, c.OrgId, c.SignedInUser)
@@ -493,7 +613,7 @@ func CalculateDashboardDiff(c *models.ReqContext, apiOptions dtos.CalculateDiffO
 		return Error(500, "Unable to compute diff", err)
 	}
 
-	if options.DiffType == dashdiffs.DiffDelta {
+	if options.DiffType == dashdiffs.DiffDelta || options.DiffType == dashdiffs.DiffSemantic {
 		return Respond(200, result.Delta).Header("Content-Type", "application/json")
 	}
 
@@ -529,6 +649,14 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *models.ReqContext, apiCmd dtos.
 	saveCmd.Message = fmt.Sprintf("Restored from version %d", version.Version)
 	saveCmd.FolderId = dash.FolderId
 
+	if apiCmd.RestoreFolder && version.FolderId != dash.FolderId {
+		folderQuery := models.GetDashboardQuery{Id: version.FolderId, OrgId: c.OrgId}
+		// the folder might have been deleted since, the dashboard then stays in its current folder
+		if version.FolderId == 0 || bus.Dispatch(&folderQuery) == nil {
+			saveCmd.FolderId = version.FolderId
+		}
+	}
+
 	return hs.PostDashboard(c, saveCmd)
 }
 
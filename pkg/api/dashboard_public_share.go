@@ -0,0 +1,244 @@
+package api
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// GET /api/dashboards/uid/:uid/public-shares
+func GetDashboardPublicShares(c *models.ReqContext) Response {
+	dash, rsp := getPublicShareAdminDashboard(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	query := models.GetDashboardPublicSharesQuery{OrgId: c.OrgId, DashboardId: dash.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get public dashboard shares", err)
+	}
+
+	now := time.Now()
+	result := make([]*models.DashboardPublicShareDTO, 0, len(query.Result))
+	for _, share := range query.Result {
+		result = append(result, publicShareDTO(share, dash, now))
+	}
+
+	return JSON(200, result)
+}
+
+// POST /api/dashboards/uid/:uid/public-shares
+func CreateDashboardPublicShare(c *models.ReqContext, cmd dtos.CreateDashboardPublicShareCommand) Response {
+	if !setting.DashboardPublicSharesEnabled {
+		return Error(403, "Public dashboard shares are disabled", nil)
+	}
+
+	dash, rsp := getPublicShareAdminDashboard(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	if dash.IsFolder {
+		return Error(400, "Folders cannot be shared publicly", nil)
+	}
+
+	if cmd.Expires < 0 {
+		return Error(400, "Expires must not be negative", nil)
+	}
+
+	// share holders run the queries as the dashboard, so the creator must be
+	// allowed to run them
+	if rsp := checkPanelDataSourcesPermission(c, dash); rsp != nil {
+		return rsp
+	}
+
+	if cmd.TimeLocked {
+		if cmd.TimeFrom == "" && cmd.TimeTo == "" {
+			cmd.TimeFrom = dash.Data.Get("time").Get("from").MustString("now-6h")
+			cmd.TimeTo = dash.Data.Get("time").Get("to").MustString("now")
+		}
+
+		timeRange := tsdb.NewTimeRange(cmd.TimeFrom, cmd.TimeTo)
+		if _, err := timeRange.ParseFrom(); err != nil {
+			return Error(400, "Invalid timeFrom", err)
+		}
+		if _, err := timeRange.ParseTo(); err != nil {
+			return Error(400, "Invalid timeTo", err)
+		}
+	} else {
+		cmd.TimeFrom, cmd.TimeTo = "", ""
+	}
+
+	token, tokenHash, err := publicdashboards.GenerateToken()
+	if err != nil {
+		return Error(500, "Failed to generate public share token", err)
+	}
+
+	createCmd := models.CreateDashboardPublicShareCommand{
+		OrgId:       c.OrgId,
+		DashboardId: dash.Id,
+		UserId:      c.UserId,
+		TokenHash:   tokenHash,
+		TimeLocked:  cmd.TimeLocked,
+		TimeFrom:    cmd.TimeFrom,
+		TimeTo:      cmd.TimeTo,
+		Expires:     cmd.Expires,
+	}
+	if err := bus.Dispatch(&createCmd); err != nil {
+		return Error(500, "Failed to create public dashboard share", err)
+	}
+
+	return JSON(200, dtos.CreatedDashboardPublicShare{
+		DashboardPublicShareDTO: publicShareDTO(createCmd.Result, dash, time.Now()),
+		Token:                   token,
+	})
+}
+
+// DELETE /api/dashboards/uid/:uid/public-shares/:shareUid
+func RevokeDashboardPublicShare(c *models.ReqContext) Response {
+	dash, rsp := getPublicShareAdminDashboard(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd := models.RevokeDashboardPublicShareCommand{OrgId: c.OrgId, DashboardId: dash.Id, Uid: c.Params(":shareUid")}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrDashboardPublicShareNotFound {
+			return Error(404, "Public dashboard share not found", err)
+		}
+		return Error(500, "Failed to revoke public dashboard share", err)
+	}
+
+	return Success("Public dashboard share revoked")
+}
+
+// GET /api/public/dashboards/:token
+func GetPublicDashboard(c *models.ReqContext) Response {
+	share, dash, rsp := getPublicShare(c.Params(":token"))
+	if rsp != nil {
+		return rsp
+	}
+
+	if share.TimeLocked {
+		dash.Data.Set("time", map[string]interface{}{"from": share.TimeFrom, "to": share.TimeTo})
+	}
+	dash.Data.Set("version", dash.Version)
+
+	meta := dtos.DashboardMeta{
+		Slug:        dash.Slug,
+		Type:        models.DashTypeDB,
+		Created:     dash.Created,
+		Updated:     dash.Updated,
+		Version:     dash.Version,
+		Expires:     share.Expires,
+		IsPublic:    true,
+		TimeLocked:  share.TimeLocked,
+		FolderTitle: "General",
+	}
+
+	return JSON(200, dtos.DashboardFullWithMeta{Dashboard: dash.Data, Meta: meta})
+}
+
+// getPublicShare returns the active share of the token and its dashboard.
+// Shares of deleted dashboards and organizations are not found.
+func getPublicShare(token string) (*models.DashboardPublicShare, *models.Dashboard, Response) {
+	if !setting.DashboardPublicSharesEnabled || token == "" {
+		return nil, nil, Error(404, "Public dashboard not found", nil)
+	}
+
+	query := models.GetDashboardPublicShareByTokenQuery{TokenHash: publicdashboards.HashToken(token)}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDashboardPublicShareNotFound {
+			return nil, nil, Error(404, "Public dashboard not found", err)
+		}
+		return nil, nil, Error(500, "Failed to get public dashboard share", err)
+	}
+
+	share := query.Result
+	if share.Revoked {
+		return nil, nil, Error(404, "Public dashboard not found", models.ErrDashboardPublicShareNotFound)
+	}
+	if !share.IsActive(time.Now()) {
+		return nil, nil, Error(410, "Public dashboard share has expired", models.ErrDashboardPublicShareExpired)
+	}
+
+	// the store only returns shares of existing dashboards in organizations
+	// that aren't deleted, but the dashboard can be deleted in between
+	dashQuery := models.GetDashboardQuery{Id: share.DashboardId, OrgId: share.OrgId}
+	if err := bus.Dispatch(&dashQuery); err != nil {
+		if err == models.ErrDashboardNotFound {
+			return nil, nil, Error(404, "Public dashboard not found", err)
+		}
+		return nil, nil, Error(500, "Failed to get public dashboard", err)
+	}
+	if dashQuery.Result.IsFolder {
+		return nil, nil, Error(404, "Public dashboard not found", models.ErrDashboardNotFound)
+	}
+
+	return share, dashQuery.Result, nil
+}
+
+// getPublicShareAdminDashboard returns the dashboard of the uid parameter if
+// the user can administer it.
+func getPublicShareAdminDashboard(c *models.ReqContext) (*models.Dashboard, Response) {
+	dash, rsp := getDashboardHelper(c.OrgId, "", 0, c.Params(":uid"))
+	if rsp != nil {
+		return nil, rsp
+	}
+
+	guardian := guardian.New(dash.Id, c.OrgId, c.SignedInUser)
+	if canAdmin, err := guardian.CanAdmin(); err != nil || !canAdmin {
+		return nil, dashboardGuardianResponse(err)
+	}
+
+	return dash, nil
+}
+
+// checkPanelDataSourcesPermission returns an error response if the user can't
+// query a data source of the panels of the dashboard. Queries of unknown data
+// sources are ignored, they fail for share holders as well.
+func checkPanelDataSourcesPermission(c *models.ReqContext, dash *models.Dashboard) Response {
+	checked := map[int64]bool{}
+	for _, panelQuery := range dashboards.GetPanelQueries(dash.Data) {
+		ds, err := panelQuery.GetDataSource(c.OrgId)
+		if err != nil {
+			if err == models.ErrDataSourceNotFound {
+				continue
+			}
+			return Error(500, "Unable to load datasource of a panel", err)
+		}
+		if checked[ds.Id] {
+			continue
+		}
+		checked[ds.Id] = true
+
+		if rsp := checkDataSourcePermission(c, ds, models.DsPermissionQuery); rsp != nil {
+			return rsp
+		}
+	}
+
+	return nil
+}
+
+func publicShareDTO(share *models.DashboardPublicShare, dash *models.Dashboard, now time.Time) *models.DashboardPublicShareDTO {
+	return &models.DashboardPublicShareDTO{
+		Uid:          share.Uid,
+		DashboardUid: dash.Uid,
+		TimeLocked:   share.TimeLocked,
+		TimeFrom:     share.TimeFrom,
+		TimeTo:       share.TimeTo,
+		Revoked:      share.Revoked,
+		Expired:      !now.Before(share.Expires),
+		CreatedBy:    share.CreatedBy,
+		Expires:      share.Expires,
+		Created:      share.Created,
+		Updated:      share.Updated,
+	}
+}
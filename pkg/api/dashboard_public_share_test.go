@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakePublicQueryEndpoint struct {
+	queries []*tsdb.TsdbQuery
+}
+
+func (e *fakePublicQueryEndpoint) Query(ctx context.Context, ds *models.DataSource, query *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	e.queries = append(e.queries, query)
+	return &tsdb.Response{Results: map[string]*tsdb.QueryResult{"A": {RefId: "A"}}}, nil
+}
+
+func TestPublicDashboardQueries(t *testing.T) {
+	setting.DashboardPublicSharesEnabled = true
+	defer func() { setting.DashboardPublicSharesEnabled = false }()
+
+	endpoint := &fakePublicQueryEndpoint{}
+	tsdb.RegisterTsdbQueryEndpoint("public-test", func(dsInfo *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+		return endpoint, nil
+	})
+
+	dashboard := simplejson.NewFromAny(map[string]interface{}{
+		"title": "Status",
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id":   1,
+				"type": "row",
+				"panels": []interface{}{
+					map[string]interface{}{
+						"id":            2,
+						"maxDataPoints": 500,
+						"interval":      "1m",
+						"targets": []interface{}{
+							map[string]interface{}{"refId": "A", "scenarioId": "random_walk", "alias": "$host"},
+						},
+					},
+				},
+			},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "host", "current": map[string]interface{}{"value": "web1"}},
+			},
+		},
+	})
+
+	query := func(panelId int64, target map[string]interface{}) dtos.MetricRequest {
+		model := simplejson.NewFromAny(target)
+		model.Set("panelId", panelId)
+		model.Set("datasourceId", 5)
+		return dtos.MetricRequest{From: "now-1h", To: "now", Queries: []*simplejson.Json{model}}
+	}
+
+	Convey("Given a public dashboard share", t, func() {
+		share := &models.DashboardPublicShare{
+			OrgId:       1,
+			DashboardId: 10,
+			TokenHash:   publicdashboards.HashToken("token"),
+			TimeLocked:  true,
+			TimeFrom:    "now-7d",
+			TimeTo:      "now",
+			Expires:     time.Now().Add(time.Hour),
+		}
+		endpoint.queries = nil
+
+		setup := func() {
+			bus.AddHandler("test", func(query *models.GetDashboardPublicShareByTokenQuery) error {
+				if query.TokenHash != share.TokenHash {
+					return models.ErrDashboardPublicShareNotFound
+				}
+				query.Result = share
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+				query.Result = &models.Dashboard{Id: 10, OrgId: 1, Data: dashboard}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetDataSourcesQuery) error {
+				query.Result = []*models.DataSource{{Id: 5, OrgId: 1, Type: "public-test", IsDefault: true}}
+				return nil
+			})
+		}
+
+		anonymousUserScenario("When running a query of a panel", "GET", "/api/public/dashboards/token/query",
+			"/api/public/dashboards/:token/query", func(sc *scenarioContext) {
+				setup()
+				sc.handlerFunc = func(c *models.ReqContext) Response {
+					return QueryPublicDashboardMetrics(c, query(2, map[string]interface{}{
+						"refId": "A", "scenarioId": "random_walk", "alias": "web1",
+					}))
+				}
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(endpoint.queries, ShouldHaveLength, 1)
+				So(endpoint.queries[0].TimeRange.From, ShouldEqual, "now-7d")
+				So(endpoint.queries[0].Queries[0].Model.Get("alias").MustString(), ShouldEqual, "web1")
+			})
+
+		anonymousUserScenario("When running a query of a panel with more data points than the panel", "GET", "/api/public/dashboards/token/query",
+			"/api/public/dashboards/:token/query", func(sc *scenarioContext) {
+				setup()
+				sc.handlerFunc = func(c *models.ReqContext) Response {
+					return QueryPublicDashboardMetrics(c, query(2, map[string]interface{}{
+						"refId": "A", "scenarioId": "random_walk", "alias": "web1", "maxDataPoints": 1000000, "intervalMs": 1,
+					}))
+				}
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(endpoint.queries, ShouldHaveLength, 1)
+				So(endpoint.queries[0].Queries[0].MaxDataPoints, ShouldEqual, 500)
+				// 7 days in 500 data points
+				So(endpoint.queries[0].Queries[0].IntervalMs, ShouldEqual, 7*24*3600*1000/500)
+			})
+
+		anonymousUserScenario("When the dashboard of the share has been deleted", "GET", "/api/public/dashboards/token",
+			"/api/public/dashboards/:token", func(sc *scenarioContext) {
+				setup()
+				bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+					return models.ErrDashboardNotFound
+				})
+				sc.handlerFunc = GetPublicDashboard
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 404)
+			})
+
+		anonymousUserScenario("When running a changed query of a panel", "GET", "/api/public/dashboards/token/query",
+			"/api/public/dashboards/:token/query", func(sc *scenarioContext) {
+				setup()
+				sc.handlerFunc = func(c *models.ReqContext) Response {
+					return QueryPublicDashboardMetrics(c, query(2, map[string]interface{}{
+						"refId": "A", "scenarioId": "csv_content", "alias": "web1",
+					}))
+				}
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 403)
+				So(endpoint.queries, ShouldBeEmpty)
+			})
+
+		anonymousUserScenario("When running a query of another panel", "GET", "/api/public/dashboards/token/query",
+			"/api/public/dashboards/:token/query", func(sc *scenarioContext) {
+				setup()
+				sc.handlerFunc = func(c *models.ReqContext) Response {
+					return QueryPublicDashboardMetrics(c, query(3, map[string]interface{}{
+						"refId": "A", "scenarioId": "random_walk", "alias": "web1",
+					}))
+				}
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 403)
+				So(endpoint.queries, ShouldBeEmpty)
+			})
+
+		anonymousUserScenario("When the share is revoked", "GET", "/api/public/dashboards/token",
+			"/api/public/dashboards/:token", func(sc *scenarioContext) {
+				setup()
+				share.Revoked = true
+				sc.handlerFunc = GetPublicDashboard
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 404)
+			})
+
+		anonymousUserScenario("When the share has expired", "GET", "/api/public/dashboards/token",
+			"/api/public/dashboards/:token", func(sc *scenarioContext) {
+				setup()
+				share.Expires = time.Now().Add(-time.Minute)
+				sc.handlerFunc = GetPublicDashboard
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 410)
+			})
+
+		anonymousUserScenario("When getting the dashboard of a time locked share", "GET", "/api/public/dashboards/token",
+			"/api/public/dashboards/:token", func(sc *scenarioContext) {
+				setup()
+				sc.handlerFunc = GetPublicDashboard
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				result := sc.ToJSON()
+				So(result.Get("meta").Get("isPublic").MustBool(), ShouldBeTrue)
+				So(result.Get("meta").Get("canEdit").MustBool(), ShouldBeFalse)
+				So(result.Get("dashboard").Get("time").Get("from").MustString(), ShouldEqual, "now-7d")
+			})
+
+		anonymousUserScenario("When using an unknown token", "GET", "/api/public/dashboards/other",
+			"/api/public/dashboards/:token", func(sc *scenarioContext) {
+				setup()
+				sc.handlerFunc = GetPublicDashboard
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 404)
+			})
+
+		loggedInUserScenarioWithRole("When sharing a dashboard with a data source the user can't query", "GET", "/api/dashboards/uid/status/public-shares",
+			"/api/dashboards/uid/:uid/public-shares", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				setup()
+				origNewGuardian := guardian.New
+				defer func() { guardian.New = origNewGuardian }()
+				guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanAdminValue: true})
+
+				bus.AddHandler("test", func(query *models.GetDataSourcesQuery) error {
+					query.Result = []*models.DataSource{{Id: 5, OrgId: 1, Type: "public-test", IsDefault: true, PermissionsEnabled: true}}
+					return nil
+				})
+				permissions := map[int64]models.DsPermissionType{}
+				bus.AddHandler("test", func(query *models.GetUserDataSourcePermissionsQuery) error {
+					query.Result = permissions
+					return nil
+				})
+				created := false
+				bus.AddHandler("test", func(cmd *models.CreateDashboardPublicShareCommand) error {
+					created = true
+					cmd.Result = &models.DashboardPublicShare{Uid: "share", OrgId: cmd.OrgId, DashboardId: cmd.DashboardId}
+					return nil
+				})
+
+				sc.handlerFunc = func(c *models.ReqContext) Response {
+					return CreateDashboardPublicShare(c, dtos.CreateDashboardPublicShareCommand{Expires: 3600})
+				}
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+				So(sc.resp.Code, ShouldEqual, 403)
+				So(created, ShouldBeFalse)
+
+				permissions[5] = models.DsPermissionQuery
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(created, ShouldBeTrue)
+			})
+	})
+}
@@ -3,16 +3,21 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/snapshots"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -123,6 +128,10 @@ func CreateDashboardSnapshot(c *models.ReqContext, cmd models.CreateDashboardSna
 	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
+		if quotaErr, ok := err.(models.QuotaReachedError); ok {
+			c.JsonApiErr(403, quotaErr.Error(), nil)
+			return
+		}
 		c.JsonApiErr(500, "Failed to create snaphost", err)
 		return
 	}
@@ -298,11 +307,210 @@ func SearchDashboardSnapshots(c *models.ReqContext) Response {
 			UserId:      snapshot.UserId,
 			External:    snapshot.External,
 			ExternalUrl: snapshot.ExternalUrl,
-			Expires:     snapshot.Expires,
-			Created:     snapshot.Created,
-			Updated:     snapshot.Updated,
+
+			DashboardId:     snapshot.DashboardId,
+			RefreshInterval: snapshot.RefreshInterval,
+			RefreshError:    snapshot.RefreshError,
+			Version:         snapshot.Version,
+
+			Expires: snapshot.Expires,
+			Created: snapshot.Created,
+			Updated: snapshot.Updated,
 		}
 	}
 
 	return JSON(200, dtos)
 }
+
+// POST /api/dashboards/uid/:uid/snapshots
+func GenerateDashboardSnapshot(c *models.ReqContext, cmd dtos.GenerateDashboardSnapshotCommand) Response {
+	dash, rsp := getDashboardHelper(c.OrgId, "", 0, c.Params(":uid"))
+	if rsp != nil {
+		return rsp
+	}
+	if dash.IsFolder {
+		return Error(400, "Snapshots can only be generated from dashboards", nil)
+	}
+
+	guardian := guardian.New(dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := guardian.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	if cmd.Expires < 0 {
+		return Error(400, "Invalid expiry", nil)
+	}
+
+	var refreshInterval time.Duration
+	if cmd.RefreshInterval != "" {
+		var err error
+		if refreshInterval, err = gtime.ParseInterval(cmd.RefreshInterval); err != nil {
+			return Error(400, "Invalid refresh interval", err)
+		}
+		if refreshInterval < setting.SnapshotMinRefreshInterval {
+			return Error(400, fmt.Sprintf("Refresh interval must be at least %s", setting.SnapshotMinRefreshInterval), nil)
+		}
+	}
+
+	result, err := snapshots.Generate(c.Req.Context(), dash, c.SignedInUser, cmd.TimeFrom, cmd.TimeTo)
+	if err != nil {
+		return Error(400, "Failed to generate snapshot", err)
+	}
+
+	createCmd := models.CreateDashboardSnapshotCommand{
+		Dashboard:       result.Dashboard,
+		Name:            cmd.Name,
+		Expires:         cmd.Expires,
+		DashboardId:     dash.Id,
+		TimeFrom:        cmd.TimeFrom,
+		TimeTo:          cmd.TimeTo,
+		RefreshInterval: int64(refreshInterval / time.Second),
+		OrgId:           c.OrgId,
+		UserId:          c.UserId,
+	}
+	if createCmd.Name == "" {
+		createCmd.Name = dash.Title
+	}
+	if createCmd.Key, err = util.GetRandomString(32); err != nil {
+		return Error(500, "Could not generate random string", err)
+	}
+	if createCmd.DeleteKey, err = util.GetRandomString(32); err != nil {
+		return Error(500, "Could not generate random string", err)
+	}
+
+	if err := bus.Dispatch(&createCmd); err != nil {
+		if quotaErr, ok := err.(models.QuotaReachedError); ok {
+			return Error(403, quotaErr.Error(), nil)
+		}
+		return Error(500, "Failed to create snapshot", err)
+	}
+
+	metrics.MApiDashboardSnapshotCreate.Inc()
+
+	return JSON(200, util.DynMap{
+		"key":       createCmd.Key,
+		"deleteKey": createCmd.DeleteKey,
+		"url":       setting.ToAbsUrl("dashboard/snapshot/" + createCmd.Key),
+		"deleteUrl": setting.ToAbsUrl("api/snapshots-delete/" + createCmd.DeleteKey),
+		"version":   createCmd.Result.Version,
+		"errors":    result.Errors,
+	})
+}
+
+// POST /api/snapshots/:key/refresh
+func RefreshDashboardSnapshot(c *models.ReqContext) Response {
+	snapshot, rsp := getActiveDashboardSnapshot(c.Params(":key"))
+	if rsp != nil {
+		return rsp
+	}
+	if snapshot.OrgId != c.OrgId {
+		return Error(404, "Dashboard snapshot not found", nil)
+	}
+	if snapshot.DashboardId == 0 || snapshot.External {
+		return Error(400, "Only snapshots generated by the server can be refreshed", nil)
+	}
+
+	guardian := guardian.New(snapshot.DashboardId, c.OrgId, c.SignedInUser)
+	canEdit, err := guardian.CanEdit()
+	if err != nil {
+		return Error(500, "Error while checking permissions for snapshot", err)
+	}
+	if !canEdit && snapshot.UserId != c.SignedInUser.UserId {
+		return Error(403, "Access denied to this snapshot", nil)
+	}
+
+	cmd, err := snapshots.Refresh(c.Req.Context(), snapshot, c.SignedInUser)
+	if err != nil {
+		switch {
+		case errors.Is(err, snapshots.ErrDashboardAccessDenied):
+			return Error(403, "Access denied to the dashboard of the snapshot", err)
+		case errors.Is(err, models.ErrDashboardNotFound):
+			return Error(404, "Dashboard of the snapshot not found", err)
+		case cmd != nil:
+			return Error(400, "Failed to refresh snapshot", err)
+		}
+		return Error(500, "Failed to refresh snapshot", err)
+	}
+
+	queryErrors := []string{}
+	if cmd.Error != "" {
+		queryErrors = strings.Split(cmd.Error, "\n")
+	}
+
+	return JSON(200, util.DynMap{
+		"message": "Snapshot refreshed",
+		"version": cmd.Result.Version,
+		"errors":  queryErrors,
+	})
+}
+
+// GET /api/snapshots/:key/versions
+func GetDashboardSnapshotVersions(c *models.ReqContext) Response {
+	snapshot, rsp := getActiveDashboardSnapshot(c.Params(":key"))
+	if rsp != nil {
+		return rsp
+	}
+
+	query := models.GetDashboardSnapshotVersionsQuery{SnapshotId: snapshot.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get dashboard snapshot versions", err)
+	}
+
+	current := &models.DashboardSnapshotVersionDTO{Version: snapshot.Version, Created: snapshot.Updated, Current: true}
+	return JSON(200, append([]*models.DashboardSnapshotVersionDTO{current}, query.Result...))
+}
+
+// GET /api/snapshots/:key/versions/:version
+func GetDashboardSnapshotVersion(c *models.ReqContext) Response {
+	snapshot, rsp := getActiveDashboardSnapshot(c.Params(":key"))
+	if rsp != nil {
+		return rsp
+	}
+
+	version, err := strconv.Atoi(c.Params(":version"))
+	if err != nil {
+		return Error(400, "Invalid version", err)
+	}
+
+	dashboard, created := snapshot.Dashboard, snapshot.Updated
+	if version != snapshot.Version {
+		query := models.GetDashboardSnapshotVersionQuery{SnapshotId: snapshot.Id, Version: version}
+		if err := bus.Dispatch(&query); err != nil {
+			if errors.Is(err, models.ErrDashboardSnapshotVersionNotFound) {
+				return Error(404, "Dashboard snapshot version not found", nil)
+			}
+			return Error(500, "Failed to get dashboard snapshot version", err)
+		}
+		dashboard, created = query.Result.Dashboard, query.Result.Created
+	}
+
+	dto := dtos.DashboardFullWithMeta{
+		Dashboard: dashboard,
+		Meta: dtos.DashboardMeta{
+			Type:       models.DashTypeSnapshot,
+			IsSnapshot: true,
+			Created:    created,
+			Expires:    snapshot.Expires,
+		},
+	}
+
+	return JSON(200, dto)
+}
+
+// getActiveDashboardSnapshot returns the snapshot with the key, or a not found
+// response if it doesn't exist or has expired.
+func getActiveDashboardSnapshot(key string) (*models.DashboardSnapshot, Response) {
+	query := models.GetDashboardSnapshotQuery{Key: key}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrDashboardSnapshotNotFound) {
+			return nil, Error(404, "Dashboard snapshot not found", nil)
+		}
+		return nil, Error(500, "Failed to get dashboard snapshot", err)
+	}
+
+	if query.Result.Expires.Before(time.Now()) {
+		return nil, Error(404, "Dashboard snapshot not found", nil)
+	}
+
+	return query.Result, nil
+}
@@ -201,3 +201,87 @@ func TestDashboardSnapshotApiEndpoint(t *testing.T) {
 		})
 	})
 }
+
+func TestDashboardSnapshotVersionsApiEndpoint(t *testing.T) {
+	Convey("Given a refreshed snapshot", t, func() {
+		snapshot := &models.DashboardSnapshot{
+			Id:          1,
+			Key:         "12345",
+			OrgId:       1,
+			DashboardId: 10,
+			Version:     3,
+			Dashboard:   simplejson.NewFromAny(map[string]interface{}{"title": "v3"}),
+			Expires:     time.Now().Add(time.Hour),
+		}
+
+		bus.AddHandler("test", func(query *models.GetDashboardSnapshotQuery) error {
+			query.Result = snapshot
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetDashboardSnapshotVersionsQuery) error {
+			query.Result = []*models.DashboardSnapshotVersionDTO{{Version: 2}, {Version: 1}}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetDashboardSnapshotVersionQuery) error {
+			if query.Version != 2 {
+				return models.ErrDashboardSnapshotVersionNotFound
+			}
+			query.Result = &models.DashboardSnapshotVersion{
+				SnapshotId: 1,
+				Version:    2,
+				Dashboard:  simplejson.NewFromAny(map[string]interface{}{"title": "v2"}),
+			}
+			return nil
+		})
+
+		anonymousUserScenario("When listing the versions", "GET", "/api/snapshots/12345/versions",
+			"/api/snapshots/:key/versions", func(sc *scenarioContext) {
+				sc.handlerFunc = GetDashboardSnapshotVersions
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				result := sc.ToJSON()
+				So(result.MustArray(), ShouldHaveLength, 3)
+				So(result.GetIndex(0).Get("version").MustInt(), ShouldEqual, 3)
+				So(result.GetIndex(0).Get("current").MustBool(), ShouldBeTrue)
+				So(result.GetIndex(1).Get("version").MustInt(), ShouldEqual, 2)
+			})
+
+		anonymousUserScenario("When getting a previous version", "GET", "/api/snapshots/12345/versions/2",
+			"/api/snapshots/:key/versions/:version", func(sc *scenarioContext) {
+				sc.handlerFunc = GetDashboardSnapshotVersion
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				result := sc.ToJSON()
+				So(result.Get("dashboard").Get("title").MustString(), ShouldEqual, "v2")
+				So(result.Get("meta").Get("isSnapshot").MustBool(), ShouldBeTrue)
+			})
+
+		anonymousUserScenario("When getting a deleted version", "GET", "/api/snapshots/12345/versions/1",
+			"/api/snapshots/:key/versions/:version", func(sc *scenarioContext) {
+				sc.handlerFunc = GetDashboardSnapshotVersion
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 404)
+			})
+
+		anonymousUserScenario("When the snapshot has expired", "GET", "/api/snapshots/12345/versions",
+			"/api/snapshots/:key/versions", func(sc *scenarioContext) {
+				snapshot.Expires = time.Now().Add(-time.Minute)
+				sc.handlerFunc = GetDashboardSnapshotVersions
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 404)
+			})
+
+		loggedInUserScenarioWithRole("When refreshing a snapshot created by a client", "GET", "/api/snapshots/12345/refresh",
+			"/api/snapshots/:key/refresh", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				snapshot.DashboardId = 0
+				sc.handlerFunc = RefreshDashboardSnapshot
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 400)
+			})
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboards/schema"
 	"github.com/grafana/grafana/pkg/services/provisioning"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/require"
@@ -791,6 +792,7 @@ func TestDashboardApiEndpoint(t *testing.T) {
 				{SaveError: models.ErrDashboardUidToLong, ExpectedStatusCode: 400},
 				{SaveError: models.ErrDashboardCannotSaveProvisionedDashboard, ExpectedStatusCode: 400},
 				{SaveError: models.UpdatePluginDashboardError{PluginId: "plug"}, ExpectedStatusCode: 412},
+				{SaveError: schema.ValidationErrors{{Path: "title", Message: "is required"}}, ExpectedStatusCode: 400},
 			}
 
 			cmd := models.SaveDashboardCommand{
@@ -863,6 +865,21 @@ func TestDashboardApiEndpoint(t *testing.T) {
 				So(sc.resp.Code, ShouldEqual, 200)
 			})
 		})
+
+		Convey("when requesting a semantic diff", func() {
+			semanticCmd := cmd
+			semanticCmd.DiffType = "semantic"
+
+			postDiffScenario("When calling POST on", "/api/dashboards/calculate-diff", "/api/dashboards/calculate-diff", semanticCmd, models.ROLE_ADMIN, func(sc *scenarioContext) {
+				CallPostDashboard(sc)
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(sc.resp.Header().Get("Content-Type"), ShouldEqual, "application/json")
+
+				result := sc.ToJSON()
+				So(result.Get("panels").MustArray(), ShouldBeEmpty)
+				So(result.Get("general").GetIndex(0).Get("path").MustString(), ShouldEqual, "title")
+			})
+		})
 	})
 
 	Convey("Given dashboard in folder being restored should restore to folder", t, func() {
@@ -952,6 +969,50 @@ func TestDashboardApiEndpoint(t *testing.T) {
 		})
 	})
 
+	Convey("Given dashboard being restored with restoreFolder should restore to the folder of the version", t, func() {
+		fakeDash := models.NewDashboard("Child dash")
+		fakeDash.Id = 2
+		fakeDash.HasAcl = false
+
+		bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+			query.Result = fakeDash
+			return nil
+		})
+
+		bus.AddHandler("test", func(query *models.GetDashboardVersionQuery) error {
+			query.Result = &models.DashboardVersion{
+				DashboardId: 2,
+				Version:     1,
+				FolderId:    3,
+				Data:        fakeDash.Data,
+			}
+			return nil
+		})
+
+		mock := &dashboards.FakeDashboardService{
+			SaveDashboardResult: &models.Dashboard{
+				Id:      2,
+				Uid:     "uid",
+				Title:   "Dash",
+				Slug:    "dash",
+				Version: 1,
+			},
+		}
+
+		cmd := dtos.RestoreDashboardVersionCommand{
+			Version:       1,
+			RestoreFolder: true,
+		}
+
+		restoreDashboardVersionScenario("When calling POST on", "/api/dashboards/id/1/restore", "/api/dashboards/id/:dashboardId/restore", mock, cmd, func(sc *scenarioContext) {
+			CallRestoreDashboardVersion(sc)
+			So(sc.resp.Code, ShouldEqual, 200)
+			dto := mock.SavedDashboards[0]
+			So(dto.Dashboard.FolderId, ShouldEqual, 3)
+			So(dto.Message, ShouldEqual, "Restored from version 1")
+		})
+	})
+
 	Convey("Given provisioned dashboard", t, func() {
 		bus.AddHandler("test", func(query *models.GetDashboardsBySlugQuery) error {
 			query.Result = []*models.Dashboard{{}}
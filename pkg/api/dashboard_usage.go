@@ -0,0 +1,215 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+const (
+	defaultUsageLimit = 100
+	maxUsageLimit     = 5000
+)
+
+// publishDashboardViewed publishes a view of the dashboard for the view
+// counts and the usage analytics.
+func publishDashboardViewed(c *models.ReqContext, dashboardId int64) {
+	err := bus.Publish(&events.DashboardViewed{
+		Timestamp:   time.Now(),
+		OrgId:       c.OrgId,
+		DashboardId: dashboardId,
+		UserId:      c.UserId,
+	})
+	if err != nil {
+		dashboardsLogger.Warn("Failed to publish dashboard view", "dashboardId", dashboardId, "err", err)
+	}
+}
+
+// publishDashboardQueried publishes the queries of a request for the usage
+// analytics if it was sent by a panel of a dashboard of the organization.
+func publishDashboardQueried(c *models.ReqContext, dashboardId, queries, errors int64) {
+	if dashboardId <= 0 || !setting.DashboardUsageAnalytics {
+		return
+	}
+
+	query := models.GetDashboardQuery{Id: dashboardId, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err != models.ErrDashboardNotFound {
+			dashboardsLogger.Warn("Failed to get dashboard of queries", "dashboardId", dashboardId, "err", err)
+		}
+		return
+	}
+
+	err := bus.Publish(&events.DashboardQueried{
+		Timestamp:   time.Now(),
+		OrgId:       c.OrgId,
+		DashboardId: dashboardId,
+		UserId:      c.UserId,
+		Queries:     queries,
+		Errors:      errors,
+	})
+	if err != nil {
+		dashboardsLogger.Warn("Failed to publish dashboard queries", "dashboardId", dashboardId, "err", err)
+	}
+}
+
+// GET /api/usage/dashboards
+func GetDashboardUsage(c *models.ReqContext) Response {
+	from, to, err := usageDays(c)
+	if err != nil {
+		return Error(400, err.Error(), err)
+	}
+
+	limit, err := usageLimit(c)
+	if err != nil {
+		return Error(400, err.Error(), err)
+	}
+
+	sort := c.Query("sort")
+	switch sort {
+	case "", models.DashboardUsageSortViewsDesc, models.DashboardUsageSortViewsAsc:
+	default:
+		return Error(400, fmt.Sprintf("Invalid sort %q", sort), nil)
+	}
+
+	query := models.GetDashboardUsageQuery{
+		OrgId:  c.OrgId,
+		From:   from,
+		To:     to,
+		UserId: c.QueryInt64("userId"),
+		Sort:   sort,
+		Limit:  limit,
+	}
+	if c.Query("folderId") != "" {
+		folderId := c.QueryInt64("folderId")
+		query.FolderId = &folderId
+	}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get dashboard usage", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GET /api/usage/dashboards/uid/:uid/views
+func GetDashboardViews(c *models.ReqContext) Response {
+	dash, rsp := getDashboardHelper(c.OrgId, "", 0, c.Params(":uid"))
+	if rsp != nil {
+		return rsp
+	}
+
+	limit, err := usageLimit(c)
+	if err != nil {
+		return Error(400, err.Error(), err)
+	}
+
+	query := models.GetDashboardViewsQuery{OrgId: c.OrgId, DashboardId: dash.Id, Limit: limit}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get dashboard views", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GET /api/usage/users
+func GetUserDashboardUsage(c *models.ReqContext) Response {
+	from, to, err := usageDays(c)
+	if err != nil {
+		return Error(400, err.Error(), err)
+	}
+
+	limit, err := usageLimit(c)
+	if err != nil {
+		return Error(400, err.Error(), err)
+	}
+
+	query := models.GetUserDashboardUsageQuery{OrgId: c.OrgId, From: from, To: to, Limit: limit}
+	if uid := c.Query("dashboardUid"); uid != "" {
+		dash, rsp := getDashboardHelper(c.OrgId, "", 0, uid)
+		if rsp != nil {
+			return rsp
+		}
+		query.DashboardId = dash.Id
+	}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get user dashboard usage", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GET /api/usage/folders
+func GetFolderUsage(c *models.ReqContext) Response {
+	from, to, err := usageDays(c)
+	if err != nil {
+		return Error(400, err.Error(), err)
+	}
+
+	query := models.GetFolderUsageQuery{OrgId: c.OrgId, From: from, To: to}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get folder usage", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// usageDays returns the UTC days of the from and to query parameters. The
+// parameters are days like 2020-10-01, epoch milliseconds or relative times
+// like now-7d, and default to the last 30 days.
+func usageDays(c *models.ReqContext) (string, string, error) {
+	from, err := usageDay(c.QueryStrings("from"), "now-30d", true)
+	if err != nil {
+		return "", "", err
+	}
+
+	to, err := usageDay(c.QueryStrings("to"), "now", false)
+	if err != nil {
+		return "", "", err
+	}
+
+	return from, to, nil
+}
+
+func usageDay(values []string, defaultValue string, isFrom bool) (string, error) {
+	value := defaultValue
+	if len(values) > 0 && values[0] != "" {
+		value = values[0]
+	}
+
+	if _, err := time.Parse(models.DashboardUsageDayFormat, value); err == nil {
+		return value, nil
+	}
+
+	timeRange := tsdb.NewTimeRange(value, value)
+	parse := timeRange.ParseTo
+	if isFrom {
+		parse = timeRange.ParseFrom
+	}
+
+	t, err := parse()
+	if err != nil {
+		return "", fmt.Errorf("invalid time %q", value)
+	}
+
+	return t.UTC().Format(models.DashboardUsageDayFormat), nil
+}
+
+func usageLimit(c *models.ReqContext) (int, error) {
+	if c.Query("limit") == "" {
+		return defaultUsageLimit, nil
+	}
+
+	limit := c.QueryInt("limit")
+	if limit < 1 || limit > maxUsageLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxUsageLimit)
+	}
+
+	return limit, nil
+}
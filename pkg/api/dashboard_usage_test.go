@@ -0,0 +1,60 @@
+package api
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestPublishDashboardQueried(t *testing.T) {
+	Convey("Given a dashboard of the organization", t, func() {
+		bus.ClearBusHandlers()
+		defer bus.ClearBusHandlers()
+
+		setting.DashboardUsageAnalytics = true
+		defer func() { setting.DashboardUsageAnalytics = false }()
+
+		bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+			if query.Id != 1 || query.OrgId != 1 {
+				return models.ErrDashboardNotFound
+			}
+			query.Result = &models.Dashboard{Id: 1, OrgId: 1}
+			return nil
+		})
+
+		var published []*events.DashboardQueried
+		bus.AddEventListener(func(event *events.DashboardQueried) error {
+			published = append(published, event)
+			return nil
+		})
+
+		c := &models.ReqContext{SignedInUser: &models.SignedInUser{OrgId: 1, UserId: 2}}
+
+		Convey("Should publish the queries of its panels", func() {
+			publishDashboardQueried(c, 1, 3, 1)
+
+			So(published, ShouldHaveLength, 1)
+			So(published[0].DashboardId, ShouldEqual, 1)
+			So(published[0].Queries, ShouldEqual, 3)
+			So(published[0].Errors, ShouldEqual, 1)
+		})
+
+		Convey("Should not publish queries without dashboard", func() {
+			publishDashboardQueried(c, 0, 3, 0)
+
+			So(published, ShouldBeEmpty)
+		})
+
+		Convey("Should not publish queries of dashboards of other organizations", func() {
+			c.OrgId = 2
+			publishDashboardQueried(c, 1, 3, 0)
+
+			So(published, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+// POST /api/datasources/:id/enable-permissions
+func EnableDataSourcePermissions(c *models.ReqContext) Response {
+	ds, rsp := getDataSourceForPermissions(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&models.SetDataSourcePermissionsEnabledCommand{OrgId: c.OrgId, DatasourceId: ds.Id, Enabled: true}); err != nil {
+		return dataSourcePermissionErrorResponse(err, "Failed to enable datasource permissions")
+	}
+
+	// Org admins always have access, anyone else enabling permissions is
+	// added as admin so they don't lock themselves out.
+	if c.OrgRole != models.ROLE_ADMIN && c.UserId > 0 {
+		cmd := models.AddDataSourcePermissionCommand{
+			OrgId:        c.OrgId,
+			DatasourceId: ds.Id,
+			UserId:       c.UserId,
+			Permission:   models.DsPermissionAdmin,
+		}
+		if err := bus.Dispatch(&cmd); err != nil {
+			return dataSourcePermissionErrorResponse(err, "Failed to enable datasource permissions")
+		}
+	}
+
+	return Success("Datasource permissions enabled")
+}
+
+// POST /api/datasources/:id/disable-permissions
+func DisableDataSourcePermissions(c *models.ReqContext) Response {
+	ds, rsp := getDataSourceForPermissions(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&models.SetDataSourcePermissionsEnabledCommand{OrgId: c.OrgId, DatasourceId: ds.Id, Enabled: false}); err != nil {
+		return dataSourcePermissionErrorResponse(err, "Failed to disable datasource permissions")
+	}
+
+	return Success("Datasource permissions disabled")
+}
+
+// GET /api/datasources/:id/permissions
+func GetDataSourcePermissions(c *models.ReqContext) Response {
+	ds, rsp := getDataSourceForPermissions(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	query := models.GetDataSourcePermissionsQuery{OrgId: c.OrgId, DatasourceId: ds.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get datasource permissions", err)
+	}
+
+	for _, perm := range query.Result {
+		if perm.UserId > 0 {
+			perm.UserAvatarUrl = dtos.GetGravatarUrl(perm.UserEmail)
+		}
+		if perm.TeamId > 0 {
+			perm.TeamAvatarUrl = dtos.GetGravatarUrlWithDefault(perm.TeamEmail, perm.Team)
+		}
+	}
+
+	return JSON(200, &models.DataSourcePermissionsDTO{
+		DatasourceId: ds.Id,
+		Enabled:      ds.PermissionsEnabled,
+		Permissions:  query.Result,
+	})
+}
+
+// POST /api/datasources/:id/permissions
+func AddDataSourcePermission(c *models.ReqContext, cmd models.AddDataSourcePermissionCommand) Response {
+	ds, rsp := getDataSourceForPermissions(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	if !ds.PermissionsEnabled {
+		return Error(400, "Permissions are not enabled for the datasource", nil)
+	}
+
+	if !cmd.Permission.IsValid() {
+		return Error(400, "Invalid permission", nil)
+	}
+
+	if cmd.Role != nil && !cmd.Role.IsValid() {
+		return Error(400, "Invalid role", nil)
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.DatasourceId = ds.Id
+
+	if cmd.UserId > 0 {
+		userQuery := models.GetSignedInUserQuery{UserId: cmd.UserId, OrgId: c.OrgId}
+		if err := bus.Dispatch(&userQuery); err != nil || userQuery.Result.OrgId != c.OrgId {
+			return Error(400, "User is not a member of the organization", err)
+		}
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return dataSourcePermissionErrorResponse(err, "Failed to add datasource permission")
+	}
+
+	return Success("Datasource permission added")
+}
+
+// DELETE /api/datasources/:id/permissions/:permissionId
+func RemoveDataSourcePermission(c *models.ReqContext) Response {
+	ds, rsp := getDataSourceForPermissions(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd := models.RemoveDataSourcePermissionCommand{
+		OrgId:        c.OrgId,
+		DatasourceId: ds.Id,
+		Id:           c.ParamsInt64(":permissionId"),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return dataSourcePermissionErrorResponse(err, "Failed to remove datasource permission")
+	}
+
+	return Success("Datasource permission removed")
+}
+
+// getDataSourceForPermissions returns the data source of the request if the
+// signed in user can administer it.
+func getDataSourceForPermissions(c *models.ReqContext) (*models.DataSource, Response) {
+	ds, err := getRawDataSourceById(c.ParamsInt64(":id"), c.OrgId)
+	if err != nil {
+		if err == models.ErrDataSourceNotFound {
+			return nil, Error(404, "Data source not found", nil)
+		}
+		return nil, Error(500, "Failed to query datasources", err)
+	}
+
+	if rsp := checkDataSourcePermission(c, ds, models.DsPermissionAdmin); rsp != nil {
+		return nil, rsp
+	}
+
+	return ds, nil
+}
+
+// checkDataSourcePermission returns an error response if the signed in user
+// doesn't have at least the given permission on the data source.
+func checkDataSourcePermission(c *models.ReqContext, ds *models.DataSource, permission models.DsPermissionType) Response {
+	granted, err := datasources.HasPermission(c.SignedInUser, ds, permission)
+	if err != nil {
+		return Error(500, "Failed to get datasource permissions", err)
+	}
+
+	if !granted {
+		return Error(403, "Access denied to datasource", models.ErrDataSourceAccessDenied)
+	}
+
+	return nil
+}
+
+func dataSourcePermissionErrorResponse(err error, message string) Response {
+	switch err {
+	case models.ErrDataSourceNotFound:
+		return Error(404, "Data source not found", nil)
+	case models.ErrDataSourcePermissionNotFound:
+		return Error(404, "Datasource permission not found", nil)
+	case models.ErrDataSourcePermissionInvalid:
+		return Error(400, err.Error(), nil)
+	}
+
+	return Error(500, message, err)
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDataSourcePermissionsAPI(t *testing.T) {
+	Convey("Given data sources with permissions enabled", t, func() {
+		setup := func() {
+			bus.AddHandler("test", func(query *models.GetDataSourcesQuery) error {
+				query.Result = []*models.DataSource{
+					{Id: 1, Name: "open"},
+					{Id: 2, Name: "granted", PermissionsEnabled: true},
+					{Id: 3, Name: "restricted", PermissionsEnabled: true},
+				}
+				return nil
+			})
+
+			bus.AddHandler("test", func(query *models.GetDataSourceByIdQuery) error {
+				query.Result = &models.DataSource{Id: query.Id, OrgId: TestOrgID, PermissionsEnabled: query.Id > 1}
+				return nil
+			})
+
+			bus.AddHandler("test", func(query *models.GetUserDataSourcePermissionsQuery) error {
+				query.Result = map[int64]models.DsPermissionType{2: models.DsPermissionQuery}
+				return nil
+			})
+		}
+
+		loggedInUserScenarioWithRole("When a viewer lists data sources", "GET", "/api/datasources", "/api/datasources", models.ROLE_VIEWER, func(sc *scenarioContext) {
+			setup()
+
+			sc.handlerFunc = GetDataSources
+			sc.fakeReq("GET", "/api/datasources").exec()
+
+			respJSON := []map[string]interface{}{}
+			err := json.NewDecoder(sc.resp.Body).Decode(&respJSON)
+			So(err, ShouldBeNil)
+
+			So(respJSON, ShouldHaveLength, 2)
+			So(respJSON[0]["name"], ShouldEqual, "granted")
+			So(respJSON[1]["name"], ShouldEqual, "open")
+		})
+
+		loggedInUserScenarioWithRole("When an org admin lists data sources", "GET", "/api/datasources", "/api/datasources", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			setup()
+
+			sc.handlerFunc = GetDataSources
+			sc.fakeReq("GET", "/api/datasources").exec()
+
+			respJSON := []map[string]interface{}{}
+			err := json.NewDecoder(sc.resp.Body).Decode(&respJSON)
+			So(err, ShouldBeNil)
+			So(respJSON, ShouldHaveLength, 3)
+		})
+
+		loggedInUserScenarioWithRole("When a viewer gets a data source with query permission", "GET", "/api/datasources/2", "/api/datasources/:id", models.ROLE_VIEWER, func(sc *scenarioContext) {
+			setup()
+
+			sc.handlerFunc = GetDataSourceById
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 200)
+		})
+
+		loggedInUserScenarioWithRole("When a viewer gets a data source without permission", "GET", "/api/datasources/3", "/api/datasources/:id", models.ROLE_VIEWER, func(sc *scenarioContext) {
+			setup()
+
+			sc.handlerFunc = GetDataSourceById
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 403)
+		})
+
+		loggedInUserScenarioWithRole("When a viewer deletes a data source with query permission", "DELETE", "/api/datasources/2", "/api/datasources/:id", models.ROLE_VIEWER, func(sc *scenarioContext) {
+			setup()
+
+			sc.handlerFunc = DeleteDataSourceById
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 403)
+		})
+
+		loggedInUserScenarioWithRole("When an editor updates a data source with edit permission", "GET", "/api/datasources/3", "/api/datasources/:id", models.ROLE_EDITOR, func(sc *scenarioContext) {
+			setup()
+
+			bus.AddHandler("test", func(query *models.GetUserDataSourcePermissionsQuery) error {
+				query.Result = map[int64]models.DsPermissionType{3: models.DsPermissionEdit}
+				return nil
+			})
+
+			var updated *models.UpdateDataSourceCommand
+			bus.AddHandler("test", func(cmd *models.UpdateDataSourceCommand) error {
+				updated = cmd
+				cmd.Result = &models.DataSource{Id: cmd.Id, OrgId: cmd.OrgId, Name: cmd.Name}
+				return nil
+			})
+
+			sc.handlerFunc = func(c *models.ReqContext) Response {
+				return UpdateDataSource(c, models.UpdateDataSourceCommand{Name: "restricted", Type: "prometheus"})
+			}
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(updated, ShouldNotBeNil)
+			So(updated.Id, ShouldEqual, 3)
+		})
+
+		loggedInUserScenarioWithRole("When an editor updates a data source without permissions enabled", "GET", "/api/datasources/1", "/api/datasources/:id", models.ROLE_EDITOR, func(sc *scenarioContext) {
+			setup()
+
+			sc.handlerFunc = func(c *models.ReqContext) Response {
+				return UpdateDataSource(c, models.UpdateDataSourceCommand{Name: "open", Type: "prometheus"})
+			}
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 403)
+		})
+
+		loggedInUserScenarioWithRole("When a viewer gets permissions of a data source with query permission", "GET", "/api/datasources/2/permissions", "/api/datasources/:id/permissions", models.ROLE_VIEWER, func(sc *scenarioContext) {
+			setup()
+
+			sc.handlerFunc = GetDataSourcePermissions
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 403)
+		})
+
+		loggedInUserScenarioWithRole("When an editor with admin permission adds a permission to a data source", "GET", "/api/datasources/3/permissions", "/api/datasources/:id/permissions", models.ROLE_EDITOR, func(sc *scenarioContext) {
+			setup()
+
+			bus.AddHandler("test", func(query *models.GetUserDataSourcePermissionsQuery) error {
+				query.Result = map[int64]models.DsPermissionType{3: models.DsPermissionAdmin}
+				return nil
+			})
+
+			var added *models.AddDataSourcePermissionCommand
+			bus.AddHandler("test", func(cmd *models.AddDataSourcePermissionCommand) error {
+				added = cmd
+				return nil
+			})
+
+			viewerRole := models.ROLE_VIEWER
+			sc.handlerFunc = func(c *models.ReqContext) Response {
+				return AddDataSourcePermission(c, models.AddDataSourcePermissionCommand{Role: &viewerRole, Permission: models.DsPermissionQuery})
+			}
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(added, ShouldNotBeNil)
+			So(added.DatasourceId, ShouldEqual, 3)
+		})
+	})
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/datasource/wrapper"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -25,8 +27,13 @@ func GetDataSources(c *models.ReqContext) Response {
 		return Error(500, "Failed to query datasources", err)
 	}
 
+	filtered, err := datasources.FilterDatasources(c.SignedInUser, query.Result, models.DsPermissionQuery)
+	if err != nil {
+		return Error(500, "Failed to query datasources", err)
+	}
+
 	result := make(dtos.DataSourceList, 0)
-	for _, ds := range query.Result {
+	for _, ds := range filtered {
 		dsItem := dtos.DataSourceListItemDTO{
 			OrgId:     ds.OrgId,
 			Id:        ds.Id,
@@ -71,6 +78,10 @@ func GetDataSourceById(c *models.ReqContext) Response {
 	}
 
 	ds := query.Result
+	if rsp := checkDataSourcePermission(c, ds, models.DsPermissionQuery); rsp != nil {
+		return rsp
+	}
+
 	dtos := convertModelToDtos(ds)
 
 	return JSON(200, &dtos)
@@ -92,6 +103,10 @@ func DeleteDataSourceById(c *models.ReqContext) Response {
 		return Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	if rsp := checkDataSourcePermission(c, ds, models.DsPermissionAdmin); rsp != nil {
+		return rsp
+	}
+
 	cmd := &models.DeleteDataSourceByIdCommand{Id: id, OrgId: c.OrgId}
 
 	err = bus.Dispatch(cmd)
@@ -121,6 +136,10 @@ func DeleteDataSourceByName(c *models.ReqContext) Response {
 		return Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	if rsp := checkDataSourcePermission(c, getCmd.Result, models.DsPermissionAdmin); rsp != nil {
+		return rsp
+	}
+
 	cmd := &models.DeleteDataSourceByNameCommand{Name: name, OrgId: c.OrgId}
 	err := bus.Dispatch(cmd)
 	if err != nil {
@@ -174,7 +193,19 @@ func UpdateDataSource(c *models.ReqContext, cmd models.UpdateDataSourceCommand)
 		return resp
 	}
 
-	err := fillWithSecureJSONData(&cmd)
+	ds, err := getRawDataSourceById(cmd.Id, cmd.OrgId)
+	if err != nil {
+		if err == models.ErrDataSourceNotFound {
+			return Error(404, "Data source not found", nil)
+		}
+		return Error(500, "Failed to update datasource", err)
+	}
+
+	if rsp := checkDataSourcePermission(c, ds, models.DsPermissionEdit); rsp != nil {
+		return rsp
+	}
+
+	err = fillWithSecureJSONData(&cmd)
 	if err != nil {
 		return Error(500, "Failed to update datasource", err)
 	}
@@ -257,6 +288,10 @@ func GetDataSourceByName(c *models.ReqContext) Response {
 		return Error(500, "Failed to query datasources", err)
 	}
 
+	if rsp := checkDataSourcePermission(c, query.Result, models.DsPermissionQuery); rsp != nil {
+		return rsp
+	}
+
 	dtos := convertModelToDtos(query.Result)
 	return JSON(200, &dtos)
 }
@@ -273,6 +308,10 @@ func GetDataSourceIdByName(c *models.ReqContext) Response {
 	}
 
 	ds := query.Result
+	if rsp := checkDataSourcePermission(c, ds, models.DsPermissionQuery); rsp != nil {
+		return rsp
+	}
+
 	dtos := dtos.AnyId{
 		Id: ds.Id,
 	}
@@ -311,6 +350,13 @@ func (hs *HTTPServer) CallDatasourceResource(c *models.ReqContext) {
 		PluginID:                   plugin.Id,
 		DataSourceInstanceSettings: dsInstanceSettings,
 	}
+
+	if oauthtoken.IsOAuthPassThruEnabled(ds) {
+		if token := oauthtoken.GetCurrentOAuthToken(c.Req.Context(), c.SignedInUser); token != nil {
+			c.Req.Header.Set("Authorization", fmt.Sprintf("%s %s", token.Type(), token.AccessToken))
+		}
+	}
+
 	hs.BackendPluginManager.CallResource(pCtx, c, c.Params("*"))
 }
 
@@ -0,0 +1,13 @@
+package dtos
+
+import "github.com/grafana/grafana/pkg/services/bundles"
+
+type ImportBundleCommand struct {
+	Bundle      *bundles.Bundle   `json:"bundle" binding:"Required"`
+	DryRun      bool              `json:"dryRun"`
+	Overwrite   bool              `json:"overwrite"`
+	NewUids     bool              `json:"newUids"`
+	Uids        map[string]string `json:"uids"`
+	DataSources map[string]string `json:"dataSources"`
+	FolderUid   string            `json:"folderUid"`
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards/schema"
 )
 
 type DashboardMeta struct {
@@ -30,6 +32,9 @@ type DashboardMeta struct {
 	FolderUrl             string    `json:"folderUrl"`
 	Provisioned           bool      `json:"provisioned"`
 	ProvisionedExternalId string    `json:"provisionedExternalId"`
+	ProvisionedCommit     string    `json:"provisionedCommit,omitempty"`
+	IsPublic              bool      `json:"isPublic,omitempty"`
+	TimeLocked            bool      `json:"timeLocked,omitempty"`
 }
 
 type DashboardFullWithMeta struct {
@@ -55,4 +60,34 @@ type CalculateDiffTarget struct {
 
 type RestoreDashboardVersionCommand struct {
 	Version int `json:"version" binding:"Required"`
+	// RestoreFolder restores the dashboard to the folder it was in when the version was saved
+	RestoreFolder bool `json:"restoreFolder"`
+}
+
+type PinDashboardVersionCommand struct {
+	Label string `json:"label"`
+}
+
+// DashboardVersionHistory is the export bundle of all versions of a dashboard.
+type DashboardVersionHistory struct {
+	Dashboard  DashboardVersionHistoryMeta    `json:"dashboard"`
+	ExportedAt time.Time                      `json:"exportedAt"`
+	Versions   []*models.DashboardVersionMeta `json:"versions"`
+}
+
+type DashboardVersionHistoryMeta struct {
+	Id       int64  `json:"id"`
+	Uid      string `json:"uid"`
+	Title    string `json:"title"`
+	FolderId int64  `json:"folderId"`
+	Version  int    `json:"version"`
+}
+
+type InvalidDashboard struct {
+	OrgId         int64                   `json:"orgId"`
+	Id            int64                   `json:"id"`
+	Uid           string                  `json:"uid"`
+	Title         string                  `json:"title"`
+	SchemaVersion int                     `json:"schemaVersion"`
+	Errors        schema.ValidationErrors `json:"errors"`
 }
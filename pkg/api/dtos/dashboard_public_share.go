@@ -0,0 +1,18 @@
+package dtos
+
+import "github.com/grafana/grafana/pkg/models"
+
+type CreateDashboardPublicShareCommand struct {
+	// Expires is the lifetime of the share in seconds, 0 never expires
+	Expires    int64  `json:"expires"`
+	TimeLocked bool   `json:"timeLocked"`
+	TimeFrom   string `json:"timeFrom"`
+	TimeTo     string `json:"timeTo"`
+}
+
+// CreatedDashboardPublicShare is a new public share with its token. The
+// token can't be read again.
+type CreatedDashboardPublicShare struct {
+	*models.DashboardPublicShareDTO
+	Token string `json:"token"`
+}
@@ -0,0 +1,12 @@
+package dtos
+
+type GenerateDashboardSnapshotCommand struct {
+	Name string `json:"name"`
+	// Expires is the lifetime of the snapshot in seconds, 0 never expires
+	Expires  int64  `json:"expires"`
+	TimeFrom string `json:"timeFrom"`
+	TimeTo   string `json:"timeTo"`
+	// RefreshInterval is how often the snapshot is generated again, like 1h,
+	// empty never refreshes the snapshot
+	RefreshInterval string `json:"refreshInterval"`
+}
@@ -19,9 +19,11 @@ type AnyId struct {
 }
 
 type LoginCommand struct {
-	User     string `json:"user" binding:"Required"`
-	Password string `json:"password" binding:"Required"`
-	Remember bool   `json:"remember"`
+	User            string `json:"user" binding:"Required"`
+	Password        string `json:"password" binding:"Required"`
+	Remember        bool   `json:"remember"`
+	MfaCode         string `json:"mfaCode"`
+	MfaRecoveryCode string `json:"mfaRecoveryCode"`
 }
 
 type CurrentUser struct {
@@ -48,6 +50,9 @@ type MetricRequest struct {
 	To      string             `json:"to"`
 	Queries []*simplejson.Json `json:"queries"`
 	Debug   bool               `json:"debug"`
+	// DashboardId is the id of the dashboard of the panel sending the
+	// queries, used for the usage analytics
+	DashboardId int64 `json:"dashboardId"`
 }
 
 type UserStars struct {
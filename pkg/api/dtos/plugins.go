@@ -61,4 +61,5 @@ type ImportDashboardCommand struct {
 	Dashboard *simplejson.Json               `json:"dashboard"`
 	Inputs    []plugins.ImportDashboardInput `json:"inputs"`
 	FolderId  int64                          `json:"folderId"`
+	Strict    bool                           `json:"strict"`
 }
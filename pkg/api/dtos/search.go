@@ -0,0 +1,11 @@
+package dtos
+
+import "github.com/grafana/grafana/pkg/services/search"
+
+// SearchResult is the response of a search with cursor paging or facets.
+// Other searches respond with the list of hits only.
+type SearchResult struct {
+	Hits       search.HitList `json:"hits"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+	Facets     *search.Facets `json:"facets,omitempty"`
+}
@@ -57,3 +57,14 @@ type UserLookupDTO struct {
 	Login     string `json:"login"`
 	AvatarURL string `json:"avatarUrl"`
 }
+
+type UserMfaStatus struct {
+	Enabled                bool `json:"enabled"`
+	Enforced               bool `json:"enforced"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+type UserMfaCodeForm struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
@@ -11,6 +11,22 @@ type UserToken struct {
 	OperatingSystemVersion string    `json:"osVersion"`
 	Browser                string    `json:"browser"`
 	BrowserVersion         string    `json:"browserVersion"`
+	AuthModule             string    `json:"authModule"`
+	AuthLabel              string    `json:"authLabel"`
 	CreatedAt              time.Time `json:"createdAt"`
 	SeenAt                 time.Time `json:"seenAt"`
 }
+
+type UserSession struct {
+	UserToken
+	UserId int64  `json:"userId"`
+	Login  string `json:"login"`
+	Email  string `json:"email"`
+}
+
+type SearchUserSessionsResult struct {
+	TotalCount int64          `json:"totalCount"`
+	Sessions   []*UserSession `json:"sessions"`
+	Page       int            `json:"page"`
+	PerPage    int            `json:"perPage"`
+}
@@ -22,9 +22,10 @@ func GetFolders(c *models.ReqContext) Response {
 
 	for _, f := range folders {
 		result = append(result, dtos.FolderSearchHit{
-			Id:    f.Id,
-			Uid:   f.Uid,
-			Title: f.Title,
+			Id:        f.Id,
+			Uid:       f.Uid,
+			Title:     f.Title,
+			ParentUid: f.ParentUid,
 		})
 	}
 
@@ -82,6 +83,17 @@ func UpdateFolder(c *models.ReqContext, cmd models.UpdateFolderCommand) Response
 	return JSON(200, toFolderDto(g, cmd.Result))
 }
 
+func MoveFolder(c *models.ReqContext, cmd models.MoveFolderCommand) Response {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
+	err := s.MoveFolder(c.Params(":uid"), &cmd)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	g := guardian.New(cmd.Result.Id, c.OrgId, c.SignedInUser)
+	return JSON(200, toFolderDto(g, cmd.Result))
+}
+
 func DeleteFolder(c *models.ReqContext) Response {
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
 	f, err := s.DeleteFolder(c.Params(":uid"))
@@ -123,6 +135,7 @@ func toFolderDto(g guardian.DashboardGuardian, folder *models.Folder) dtos.Folde
 		UpdatedBy: updater,
 		Updated:   folder.Updated,
 		Version:   folder.Version,
+		ParentUid: folder.ParentUid,
 	}
 }
 
@@ -130,6 +143,8 @@ func toFolderError(err error) Response {
 	if err == models.ErrFolderTitleEmpty ||
 		err == models.ErrFolderSameNameExists ||
 		err == models.ErrFolderWithSameUIDExists ||
+		err == models.ErrFolderParentNotFound ||
+		err == models.ErrFolderCircularReference ||
 		err == models.ErrDashboardTypeMismatch ||
 		err == models.ErrDashboardInvalidUid ||
 		err == models.ErrDashboardUidToLong {
@@ -140,6 +155,10 @@ func toFolderError(err error) Response {
 		return Error(403, "Access denied", err)
 	}
 
+	if quotaErr, ok := err.(models.QuotaReachedError); ok {
+		return Error(403, quotaErr.Error(), nil)
+	}
+
 	if err == models.ErrFolderNotFound {
 		return JSON(404, util.DynMap{"status": "not-found", "message": models.ErrFolderNotFound.Error()})
 	}
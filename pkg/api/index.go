@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -279,7 +280,12 @@ func (hs *HTTPServer) setIndexViewData(c *models.ReqContext) (*dtos.IndexViewDat
 
 	configNodes := []*dtos.NavLink{}
 
-	if c.OrgRole == models.ROLE_ADMIN {
+	canReadDatasources, err := hs.hasAccess(c, c.OrgRole == models.ROLE_ADMIN, accesscontrol.ActionDatasourcesRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if canReadDatasources {
 		configNodes = append(configNodes, &dtos.NavLink{
 			Text:        "Data Sources",
 			Icon:        "database",
@@ -287,6 +293,14 @@ func (hs *HTTPServer) setIndexViewData(c *models.ReqContext) (*dtos.IndexViewDat
 			Id:          "datasources",
 			Url:         setting.AppSubUrl + "/datasources",
 		})
+	}
+
+	canReadOrgUsers, err := hs.hasAccess(c, c.OrgRole == models.ROLE_ADMIN, accesscontrol.ActionOrgUsersRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if canReadOrgUsers {
 		configNodes = append(configNodes, &dtos.NavLink{
 			Text:        "Users",
 			Id:          "users",
@@ -296,7 +310,13 @@ func (hs *HTTPServer) setIndexViewData(c *models.ReqContext) (*dtos.IndexViewDat
 		})
 	}
 
-	if c.OrgRole == models.ROLE_ADMIN || (hs.Cfg.EditorsCanAdmin && c.OrgRole == models.ROLE_EDITOR) {
+	canAccessTeams, err := hs.hasAccess(c, c.OrgRole == models.ROLE_ADMIN || (hs.Cfg.EditorsCanAdmin && c.OrgRole == models.ROLE_EDITOR),
+		accesscontrol.ActionTeamsWrite, accesscontrol.ActionTeamsCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	if canAccessTeams {
 		configNodes = append(configNodes, &dtos.NavLink{
 			Text:        "Teams",
 			Id:          "teams",
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/library-panels
+func GetLibraryPanels(c *models.ReqContext) Response {
+	query := models.GetLibraryPanelsQuery{
+		OrgId:        c.OrgId,
+		SignedInUser: c.SignedInUser,
+		FolderIds:    make([]int64, 0),
+		Query:        c.Query("query"),
+		Page:         c.QueryInt("page"),
+		PerPage:      c.QueryInt("perPage"),
+	}
+	for _, id := range c.QueryStrings("folderId") {
+		folderID, err := strconv.ParseInt(id, 10, 64)
+		if err == nil {
+			query.FolderIds = append(query.FolderIds, folderID)
+		}
+	}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get library panels", err)
+	}
+
+	canEditFolder := make(map[int64]bool)
+	result := make([]*models.LibraryPanelDTO, 0, len(query.Result.LibraryPanels))
+	for _, libraryPanel := range query.Result.LibraryPanels {
+		canEdit, ok := canEditFolder[libraryPanel.FolderId]
+		if !ok {
+			canEdit, _ = guardian.New(libraryPanel.FolderId, c.OrgId, c.SignedInUser).CanSave()
+			canEditFolder[libraryPanel.FolderId] = canEdit
+		}
+		result = append(result, toLibraryPanelDTO(libraryPanel, canEdit))
+	}
+
+	return JSON(200, util.DynMap{
+		"totalCount":    query.Result.TotalCount,
+		"page":          query.Page,
+		"perPage":       query.PerPage,
+		"libraryPanels": result,
+	})
+}
+
+// GET /api/library-panels/:uid
+func GetLibraryPanelByUID(c *models.ReqContext) Response {
+	libraryPanel, rsp := getLibraryPanelHelper(c, c.Params(":uid"), false)
+	if rsp != nil {
+		return rsp
+	}
+
+	canEdit, _ := guardian.New(libraryPanel.FolderId, c.OrgId, c.SignedInUser).CanSave()
+	return JSON(200, toLibraryPanelDTO(libraryPanel, canEdit))
+}
+
+// POST /api/library-panels
+func CreateLibraryPanel(c *models.ReqContext, cmd models.CreateLibraryPanelCommand) Response {
+	if rsp := checkLibraryPanelFolderPermission(c, cmd.FolderId, true); rsp != nil {
+		return rsp
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err, "Failed to create library panel")
+	}
+
+	return JSON(200, toLibraryPanelDTO(&models.LibraryPanelWithConnections{LibraryPanel: *cmd.Result}, true))
+}
+
+// PATCH /api/library-panels/:uid
+func UpdateLibraryPanel(c *models.ReqContext, cmd models.UpdateLibraryPanelCommand) Response {
+	libraryPanel, rsp := getLibraryPanelHelper(c, c.Params(":uid"), true)
+	if rsp != nil {
+		return rsp
+	}
+
+	if cmd.FolderId != nil && *cmd.FolderId != libraryPanel.FolderId {
+		if rsp := checkLibraryPanelFolderPermission(c, *cmd.FolderId, true); rsp != nil {
+			return rsp
+		}
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.Uid = libraryPanel.Uid
+	cmd.UserId = c.UserId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err, "Failed to update library panel")
+	}
+
+	return JSON(200, toLibraryPanelDTO(&models.LibraryPanelWithConnections{
+		LibraryPanel:        *cmd.Result,
+		ConnectedDashboards: libraryPanel.ConnectedDashboards,
+	}, true))
+}
+
+// DELETE /api/library-panels/:uid
+func DeleteLibraryPanel(c *models.ReqContext) Response {
+	libraryPanel, rsp := getLibraryPanelHelper(c, c.Params(":uid"), true)
+	if rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&models.DeleteLibraryPanelCommand{OrgId: c.OrgId, Uid: libraryPanel.Uid}); err != nil {
+		return toLibraryPanelError(err, "Failed to delete library panel")
+	}
+
+	return Success("Library panel deleted")
+}
+
+// GET /api/library-panels/:uid/dashboards
+func GetLibraryPanelDashboards(c *models.ReqContext) Response {
+	libraryPanel, rsp := getLibraryPanelHelper(c, c.Params(":uid"), false)
+	if rsp != nil {
+		return rsp
+	}
+
+	query := models.GetLibraryPanelDashboardsQuery{OrgId: c.OrgId, Uid: libraryPanel.Uid, SignedInUser: c.SignedInUser}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err, "Failed to get connected dashboards")
+	}
+
+	return JSON(200, query.Result)
+}
+
+// getLibraryPanelHelper returns the library panel if the user can view it or,
+// if edit is true, edit it.
+func getLibraryPanelHelper(c *models.ReqContext, uid string, edit bool) (*models.LibraryPanelWithConnections, Response) {
+	query := models.GetLibraryPanelQuery{OrgId: c.OrgId, Uid: uid}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, toLibraryPanelError(err, "Failed to get library panel")
+	}
+
+	if rsp := checkLibraryPanelFolderPermission(c, query.Result.FolderId, edit); rsp != nil {
+		return nil, rsp
+	}
+
+	return query.Result, nil
+}
+
+// checkLibraryPanelFolderPermission returns an error response unless the user
+// can view or, if edit is true, edit the library panels of the folder. Folder
+// id 0 is the General folder.
+func checkLibraryPanelFolderPermission(c *models.ReqContext, folderID int64, edit bool) Response {
+	if folderID != 0 {
+		s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
+		if _, err := s.GetFolderByID(folderID); err != nil {
+			return toFolderError(err)
+		}
+	}
+
+	if !edit {
+		return nil
+	}
+
+	canSave, err := guardian.New(folderID, c.OrgId, c.SignedInUser).CanSave()
+	if err != nil {
+		return Error(500, "Failed to check folder permissions", err)
+	}
+	if !canSave {
+		return Error(403, "Not allowed to edit library panels in this folder", nil)
+	}
+
+	return nil
+}
+
+func toLibraryPanelDTO(libraryPanel *models.LibraryPanelWithConnections, canEdit bool) *models.LibraryPanelDTO {
+	return &models.LibraryPanelDTO{
+		Id:       libraryPanel.Id,
+		OrgId:    libraryPanel.OrgId,
+		FolderId: libraryPanel.FolderId,
+		Uid:      libraryPanel.Uid,
+		Name:     libraryPanel.Name,
+		Model:    libraryPanel.Model,
+		Version:  libraryPanel.Version,
+		Meta: models.LibraryPanelMeta{
+			CanEdit:             canEdit,
+			ConnectedDashboards: libraryPanel.ConnectedDashboards,
+			Created:             libraryPanel.Created,
+			Updated:             libraryPanel.Updated,
+		},
+	}
+}
+
+func toLibraryPanelError(err error, message string) Response {
+	switch err {
+	case models.ErrLibraryPanelNotFound:
+		return Error(404, err.Error(), nil)
+	case models.ErrLibraryPanelNameEmpty,
+		models.ErrLibraryPanelInvalidModel,
+		models.ErrLibraryPanelInvalidUid,
+		models.ErrLibraryPanelWithSameNameExists,
+		models.ErrLibraryPanelWithSameUIDExists:
+		return Error(400, err.Error(), nil)
+	case models.ErrLibraryPanelHasConnectedDashboards:
+		return Error(403, err.Error(), nil)
+	case models.ErrLibraryPanelVersionMismatch:
+		return JSON(412, util.DynMap{"status": "version-mismatch", "message": err.Error()})
+	}
+
+	return Error(500, message, err)
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/grafana/grafana/pkg/login"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -106,7 +108,7 @@ func (hs *HTTPServer) LoginView(c *models.ReqContext) {
 		// Assign login token to auth proxy users if enable_login_token = true
 		if setting.AuthProxyEnabled && setting.AuthProxyEnableLoginToken {
 			user := &models.User{Id: c.SignedInUser.UserId, Email: c.SignedInUser.Email, Login: c.SignedInUser.Login}
-			err := hs.loginUserWithUser(user, c)
+			err := hs.loginUserWithUser(user, c, models.AuthModuleAuthProxy)
 			if err != nil {
 				c.Handle(500, "Failed to sign in user", err)
 				return
@@ -164,10 +166,12 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) Res
 	}
 
 	authQuery := &models.LoginUserQuery{
-		ReqContext: c,
-		Username:   cmd.User,
-		Password:   cmd.Password,
-		IpAddress:  c.Req.RemoteAddr,
+		ReqContext:      c,
+		Username:        cmd.User,
+		Password:        cmd.Password,
+		MfaCode:         cmd.MfaCode,
+		MfaRecoveryCode: cmd.MfaRecoveryCode,
+		IpAddress:       c.Req.RemoteAddr,
 	}
 
 	if err := bus.Dispatch(authQuery); err != nil {
@@ -176,6 +180,29 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) Res
 			return e401
 		}
 
+		if err == mfa.ErrCodeRequired || err == mfa.ErrInvalidCode {
+			return JSON(401, util.DynMap{
+				"message":     err.Error(),
+				"mfaRequired": true,
+			})
+		}
+
+		if err == mfa.ErrEnrollmentRequired {
+			return JSON(401, util.DynMap{
+				"message":               err.Error(),
+				"mfaEnrollmentRequired": true,
+				"mfaEnrollment":         authQuery.MfaEnrollment,
+			})
+		}
+
+		if err == passwordpolicy.ErrPasswordExpired {
+			return JSON(401, util.DynMap{
+				"message":           err.Error(),
+				"passwordExpired":   true,
+				"passwordResetCode": authQuery.PasswordResetCode,
+			})
+		}
+
 		// Do not expose disabled status,
 		// just show incorrect user credentials error (see #17947)
 		if err == login.ErrUserDisabled {
@@ -188,7 +215,7 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) Res
 
 	user := authQuery.User
 
-	err := hs.loginUserWithUser(user, c)
+	err := hs.loginUserWithUser(user, c, authQuery.AuthModule)
 	if err != nil {
 		return Error(500, "Error while signing in user", err)
 	}
@@ -197,6 +224,10 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) Res
 		"message": "Logged in",
 	}
 
+	if len(authQuery.MfaRecoveryCodes) > 0 {
+		result["mfaRecoveryCodes"] = authQuery.MfaRecoveryCodes
+	}
+
 	if redirectTo, _ := url.QueryUnescape(c.GetCookie("redirect_to")); len(redirectTo) > 0 {
 		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
 			result["redirectUrl"] = redirectTo
@@ -210,12 +241,12 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) Res
 	return JSON(200, result)
 }
 
-func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext) error {
+func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext, authModule string) error {
 	if user == nil {
 		return errors.New("could not login user")
 	}
 
-	userToken, err := hs.AuthTokenService.CreateToken(c.Req.Context(), user.Id, c.RemoteAddr(), c.Req.UserAgent())
+	userToken, err := hs.AuthTokenService.CreateToken(c.Req.Context(), user.Id, c.RemoteAddr(), c.Req.UserAgent(), authModule)
 	if err != nil {
 		return errutil.Wrap("failed to create auth token", err)
 	}
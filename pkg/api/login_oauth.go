@@ -183,7 +183,16 @@ func (hs *HTTPServer) OAuthLogin(ctx *models.ReqContext) {
 		Groups:     userInfo.Groups,
 	}
 
-	if userInfo.Role != "" {
+	if len(userInfo.OrgRoles) > 0 {
+		for orgID, role := range userInfo.OrgRoles {
+			rt := models.RoleType(role)
+			if !rt.IsValid() {
+				oauthLogger.Warn("Ignoring invalid org role", "orgId", orgID, "role", role)
+				continue
+			}
+			extUser.OrgRoles[orgID] = rt
+		}
+	} else if userInfo.Role != "" {
 		rt := models.RoleType(userInfo.Role)
 		if rt.IsValid() {
 			var orgID int64
@@ -218,7 +227,7 @@ func (hs *HTTPServer) OAuthLogin(ctx *models.ReqContext) {
 	}
 
 	// login
-	err = hs.loginUserWithUser(cmd.Result, ctx)
+	err = hs.loginUserWithUser(cmd.Result, ctx, extUser.AuthModule)
 	if err != nil {
 		hs.redirectWithError(ctx, err)
 		return
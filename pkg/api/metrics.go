@@ -2,10 +2,15 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"sort"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -16,6 +21,10 @@ import (
 	"github.com/grafana/grafana/pkg/util"
 )
 
+// publicShareMaxDataPoints is the max data points of the queries of public
+// dashboards whose panels don't set them.
+const publicShareMaxDataPoints = 1500
+
 // QueryMetricsV2 returns query metrics
 // POST /api/ds/query   DataSource query w/ expressions
 func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDto dtos.MetricRequest) Response {
@@ -50,6 +59,15 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDto dtos.MetricReq
 				}
 				return Error(500, "Unable to load datasource meta data", err)
 			}
+		} else if name != "__expr__" {
+			// Expressions can combine queries against several data sources,
+			// so access has to be checked for each of them.
+			if _, err := hs.DatasourceCache.GetDatasource(datasourceID, c.SignedInUser, c.SkipCache); err != nil {
+				if err == models.ErrDataSourceAccessDenied {
+					return Error(403, "Access denied to datasource", err)
+				}
+				return Error(500, "Unable to load datasource meta data", err)
+			}
 		}
 
 		request.Queries = append(request.Queries, &tsdb.Query{
@@ -65,6 +83,7 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDto dtos.MetricReq
 	var resp *tsdb.Response
 	var err error
 	if !expr {
+		addOAuthPassThruHeaders(c, ds, request)
 		resp, err = tsdb.HandleRequest(c.Req.Context(), ds, request)
 		if err != nil {
 			return Error(500, "Metric request error", err)
@@ -81,13 +100,16 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDto dtos.MetricReq
 	}
 
 	statusCode := 200
+	var errors int64
 	for _, res := range resp.Results {
 		if res.Error != nil {
 			res.ErrorString = res.Error.Error()
 			resp.Message = res.ErrorString
 			statusCode = 400
+			errors++
 		}
 	}
+	publishDashboardQueried(c, reqDto.DashboardId, int64(len(request.Queries)), errors)
 
 	return JSON(statusCode, &resp)
 }
@@ -130,6 +152,81 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 		})
 	}
 
+	addOAuthPassThruHeaders(c, ds, request)
+
+	resp, err := tsdb.HandleRequest(c.Req.Context(), ds, request)
+	if err != nil {
+		return Error(500, "Metric request error", err)
+	}
+
+	statusCode := 200
+	var errors int64
+	for _, res := range resp.Results {
+		if res.Error != nil {
+			res.ErrorString = res.Error.Error()
+			resp.Message = res.ErrorString
+			statusCode = 400
+			errors++
+		}
+	}
+	publishDashboardQueried(c, reqDto.DashboardId, int64(len(request.Queries)), errors)
+
+	return JSON(statusCode, &resp)
+}
+
+// QueryPublicDashboardMetrics runs the queries of a public dashboard panel
+// POST /api/public/dashboards/:token/query
+func QueryPublicDashboardMetrics(c *models.ReqContext, reqDto dtos.MetricRequest) Response {
+	share, dash, rsp := getPublicShare(c.Params(":token"))
+	if rsp != nil {
+		return rsp
+	}
+
+	if len(reqDto.Queries) == 0 {
+		return Error(400, "No queries found in query", nil)
+	}
+
+	timeRange := tsdb.NewTimeRange(reqDto.From, reqDto.To)
+	if share.TimeLocked {
+		timeRange = tsdb.NewTimeRange(share.TimeFrom, share.TimeTo)
+	}
+
+	request := &tsdb.TsdbQuery{
+		TimeRange: timeRange,
+		User:      &models.SignedInUser{OrgId: share.OrgId, OrgRole: models.ROLE_VIEWER, IsAnonymous: true},
+	}
+
+	// share holders can only run the saved queries of the panels of the dashboard
+	var ds *models.DataSource
+	for _, query := range reqDto.Queries {
+		panelQuery, err := dashboards.FindPanelQuery(dash.Data, query.Get("panelId").MustInt64(), query.Get("refId").MustString("A"))
+		if err != nil || !publicdashboards.QueryMatches(panelQuery, query) {
+			return Error(403, "Query does not match a panel of the dashboard", err)
+		}
+
+		queryDs, err := panelQuery.GetDataSource(share.OrgId)
+		if err != nil {
+			return Error(400, "Unable to load datasource of the query", err)
+		}
+		if datasourceID, err := query.Get("datasourceId").Int64(); err == nil && datasourceID != queryDs.Id {
+			return Error(403, "Query does not match a panel of the dashboard", nil)
+		}
+		if ds != nil && ds.Id != queryDs.Id {
+			return Error(400, "Queries of different data sources must be sent separately", nil)
+		}
+		ds = queryDs
+
+		maxDataPoints, intervalMs := publicQueryResolution(query, panelQuery, ds, timeRange)
+		request.Queries = append(request.Queries, &tsdb.Query{
+			RefId:         panelQuery.Model.Get("refId").MustString("A"),
+			MaxDataPoints: maxDataPoints,
+			IntervalMs:    intervalMs,
+			QueryType:     panelQuery.Model.Get("queryType").MustString(""),
+			Model:         panelQuery.Model,
+			DataSource:    ds,
+		})
+	}
+
 	resp, err := tsdb.HandleRequest(c.Req.Context(), ds, request)
 	if err != nil {
 		return Error(500, "Metric request error", err)
@@ -147,6 +244,55 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 	return JSON(statusCode, &resp)
 }
 
+// publicQueryResolution returns the max data points and interval of a query of
+// a public dashboard. The max data points are limited to the ones of the panel,
+// or to publicShareMaxDataPoints, and the interval to the min interval of the
+// panel and the time range split in max data points.
+func publicQueryResolution(query *simplejson.Json, panelQuery *dashboards.PanelQuery, ds *models.DataSource, timeRange *tsdb.TimeRange) (int64, int64) {
+	limit := panelQuery.MaxDataPoints
+	if limit <= 0 || limit > publicShareMaxDataPoints {
+		limit = publicShareMaxDataPoints
+	}
+	maxDataPoints := query.Get("maxDataPoints").MustInt64(100)
+	if maxDataPoints <= 0 || maxDataPoints > limit {
+		maxDataPoints = limit
+	}
+
+	minInterval, err := tsdb.GetIntervalFrom(ds, simplejson.NewFromAny(map[string]interface{}{"interval": panelQuery.Interval}), time.Millisecond)
+	if err != nil || minInterval < time.Millisecond {
+		minInterval = time.Millisecond
+	}
+	minIntervalMs := int64(minInterval / time.Millisecond)
+	if rangeIntervalMs := (timeRange.GetToAsMsEpoch() - timeRange.GetFromAsMsEpoch()) / maxDataPoints; rangeIntervalMs > minIntervalMs {
+		minIntervalMs = rangeIntervalMs
+	}
+
+	intervalMs := query.Get("intervalMs").MustInt64(1000)
+	if intervalMs < minIntervalMs {
+		intervalMs = minIntervalMs
+	}
+
+	return maxDataPoints, intervalMs
+}
+
+// addOAuthPassThruHeaders forwards the signed in user's OAuth token to backend
+// datasources that have OAuth pass-through enabled.
+func addOAuthPassThruHeaders(c *models.ReqContext, ds *models.DataSource, request *tsdb.TsdbQuery) {
+	if !oauthtoken.IsOAuthPassThruEnabled(ds) {
+		return
+	}
+
+	token := oauthtoken.GetCurrentOAuthToken(c.Req.Context(), c.SignedInUser)
+	if token == nil {
+		return
+	}
+
+	if request.Headers == nil {
+		request.Headers = map[string]string{}
+	}
+	request.Headers["Authorization"] = fmt.Sprintf("%s %s", token.Type(), token.AccessToken)
+}
+
 // GET /api/tsdb/testdata/scenarios
 func GetTestDataScenarios(c *models.ReqContext) Response {
 	result := make([]interface{}, 0)
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"fmt"
+
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/metrics"
@@ -148,6 +150,42 @@ func updateOrgAddressHelper(form dtos.UpdateOrgAddressForm, orgID int64) Respons
 	return Success("Address updated")
 }
 
+// GET /api/org/session-settings
+func GetOrgSessionSettings(c *models.ReqContext) Response {
+	query := models.GetOrgByIdQuery{Id: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrOrgNotFound {
+			return Error(404, "Organization not found", err)
+		}
+
+		return Error(500, "Failed to get organization", err)
+	}
+
+	return JSON(200, &models.OrgSessionSettingsDTO{
+		IdleTimeoutMinutes: query.Result.SessionIdleTimeout,
+	})
+}
+
+// PUT /api/org/session-settings
+func (hs *HTTPServer) UpdateOrgSessionSettings(c *models.ReqContext, cmd models.UpdateOrgSessionSettingsCommand) Response {
+	// Tokens are only updated when being rotated, so a shorter timeout would
+	// log out active users.
+	if cmd.IdleTimeoutMinutes < 0 || (cmd.IdleTimeoutMinutes > 0 && cmd.IdleTimeoutMinutes <= hs.Cfg.TokenRotationIntervalMinutes) {
+		return Error(400, fmt.Sprintf("Idle timeout must be 0 or greater than the token rotation interval of %d minutes", hs.Cfg.TokenRotationIntervalMinutes), nil)
+	}
+
+	cmd.OrgId = c.OrgId
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrOrgNotFound {
+			return Error(404, "Organization not found", err)
+		}
+
+		return Error(500, "Failed to update session settings", err)
+	}
+
+	return Success("Session settings updated")
+}
+
 // GET /api/orgs/:orgId
 func DeleteOrgByID(c *models.ReqContext) Response {
 	if err := bus.Dispatch(&models.DeleteOrgCommand{Id: c.ParamsInt64(":orgId")}); err != nil {
@@ -159,6 +197,17 @@ func DeleteOrgByID(c *models.ReqContext) Response {
 	return Success("Organization deleted")
 }
 
+// POST /api/orgs/:orgId/restore
+func RestoreOrgByID(c *models.ReqContext) Response {
+	if err := bus.Dispatch(&models.RestoreOrgCommand{Id: c.ParamsInt64(":orgId")}); err != nil {
+		if err == models.ErrOrgNotFound {
+			return Error(404, "Deleted organization not found", nil)
+		}
+		return Error(500, "Failed to restore organization", err)
+	}
+	return Success("Organization restored")
+}
+
 func SearchOrgs(c *models.ReqContext) Response {
 	query := models.SearchOrgsQuery{
 		Query: c.Query("query"),
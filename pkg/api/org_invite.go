@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -31,6 +32,10 @@ func AddOrgInvite(c *models.ReqContext, inviteDto dtos.AddInviteForm) Response {
 		return Error(400, "Invalid role specified", nil)
 	}
 
+	if !c.OrgRole.Includes(inviteDto.Role) {
+		return Error(403, "Cannot assign a role higher than your own", nil)
+	}
+
 	// first try get existing user
 	userQuery := models.GetUserByLoginQuery{LoginOrEmail: inviteDto.LoginOrEmail}
 	if err := bus.Dispatch(&userQuery); err != nil {
@@ -172,6 +177,10 @@ func (hs *HTTPServer) CompleteInvite(c *models.ReqContext, completeInvite dtos.C
 		return Error(412, fmt.Sprintf("Invite cannot be used in status %s", invite.Status), nil)
 	}
 
+	if err := passwordpolicy.Validate(completeInvite.Password); err != nil {
+		return passwordPolicyError(err)
+	}
+
 	cmd := models.CreateUserCommand{
 		Email:        completeInvite.Email,
 		Name:         completeInvite.Name,
@@ -197,7 +206,7 @@ func (hs *HTTPServer) CompleteInvite(c *models.ReqContext, completeInvite dtos.C
 		return rsp
 	}
 
-	err := hs.loginUserWithUser(user, c)
+	err := hs.loginUserWithUser(user, c, models.AuthModuleGrafana)
 	if err != nil {
 		return Error(500, "failed to accept invite", err)
 	}
@@ -9,6 +9,11 @@ import (
 // POST /api/org/users
 func AddOrgUserToCurrentOrg(c *models.ReqContext, cmd models.AddOrgUserCommand) Response {
 	cmd.OrgId = c.OrgId
+
+	if !c.OrgRole.Includes(cmd.Role) {
+		return Error(403, "Cannot assign a role higher than your own", nil)
+	}
+
 	return addOrgUserHelper(cmd)
 }
 
@@ -136,6 +141,15 @@ func getOrgUsersHelper(orgID int64, query string, limit int) ([]*models.OrgUserD
 func UpdateOrgUserForCurrentOrg(c *models.ReqContext, cmd models.UpdateOrgUserCommand) Response {
 	cmd.OrgId = c.OrgId
 	cmd.UserId = c.ParamsInt64(":userId")
+
+	if !c.OrgRole.Includes(cmd.Role) {
+		return Error(403, "Cannot assign a role higher than your own", nil)
+	}
+
+	if rsp := canManageOrgUser(c, cmd.UserId); rsp != nil {
+		return rsp
+	}
+
 	return updateOrgUserHelper(cmd)
 }
 
@@ -163,8 +177,14 @@ func updateOrgUserHelper(cmd models.UpdateOrgUserCommand) Response {
 
 // DELETE /api/org/users/:userId
 func RemoveOrgUserForCurrentOrg(c *models.ReqContext) Response {
+	userId := c.ParamsInt64(":userId")
+
+	if rsp := canManageOrgUser(c, userId); rsp != nil {
+		return rsp
+	}
+
 	return removeOrgUserHelper(&models.RemoveOrgUserCommand{
-		UserId:                   c.ParamsInt64(":userId"),
+		UserId:                   userId,
 		OrgId:                    c.OrgId,
 		ShouldDeleteOrphanedUser: true,
 	})
@@ -192,3 +212,25 @@ func removeOrgUserHelper(cmd *models.RemoveOrgUserCommand) Response {
 
 	return Success("User removed from organization")
 }
+
+// canManageOrgUser prevents users that have been granted org user management
+// without being org admin from changing or removing users with a higher role.
+func canManageOrgUser(c *models.ReqContext, userId int64) Response {
+	if c.OrgRole == models.ROLE_ADMIN {
+		return nil
+	}
+
+	query := models.GetSignedInUserQuery{UserId: userId, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrUserNotFound {
+			return Error(404, models.ErrUserNotFound.Error(), nil)
+		}
+		return Error(500, "Failed to get user", err)
+	}
+
+	if query.Result.OrgId == c.OrgId && !c.OrgRole.Includes(query.Result.OrgRole) {
+		return Error(403, "Cannot manage a user with a role higher than your own", nil)
+	}
+
+	return nil
+}
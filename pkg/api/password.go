@@ -1,9 +1,12 @@
 package api
 
 import (
+	"errors"
+
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -45,6 +48,10 @@ func ResetPassword(c *models.ReqContext, form dtos.ResetUserPasswordForm) Respon
 		return Error(400, "Passwords do not match", nil)
 	}
 
+	if err := passwordpolicy.ValidateForUser(query.Result, form.NewPassword); err != nil {
+		return passwordPolicyError(err)
+	}
+
 	cmd := models.ChangeUserPasswordCommand{}
 	cmd.UserId = query.Result.Id
 	var err error
@@ -59,3 +66,13 @@ func ResetPassword(c *models.ReqContext, form dtos.ResetUserPasswordForm) Respon
 
 	return Success("User password changed")
 }
+
+// passwordPolicyError returns the reason a password was rejected by the
+// password policy, or an internal error if it couldn't be checked.
+func passwordPolicyError(err error) Response {
+	if errors.Is(err, passwordpolicy.ErrWeakPassword) || err == passwordpolicy.ErrPasswordReused {
+		return Error(400, err.Error(), nil)
+	}
+
+	return Error(500, "Failed to validate password", err)
+}
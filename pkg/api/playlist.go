@@ -153,6 +153,9 @@ func CreatePlaylist(c *models.ReqContext, cmd models.CreatePlaylistCommand) Resp
 	cmd.OrgId = c.OrgId
 
 	if err := bus.Dispatch(&cmd); err != nil {
+		if quotaErr, ok := err.(models.QuotaReachedError); ok {
+			return Error(403, quotaErr.Error(), nil)
+		}
 		return Error(500, "Failed to create playlist", err)
 	}
 
@@ -250,6 +250,7 @@ func ImportDashboard(c *models.ReqContext, apiCmd dtos.ImportDashboardCommand) R
 		Overwrite: apiCmd.Overwrite,
 		FolderId:  apiCmd.FolderId,
 		Dashboard: apiCmd.Dashboard,
+		Strict:    apiCmd.Strict,
 	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
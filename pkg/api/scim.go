@@ -0,0 +1,313 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// scimEnabled responds with 404 to SCIM requests if SCIM is disabled.
+func scimEnabled(c *models.ReqContext) {
+	if !setting.SCIMEnabled {
+		scimResponse(404, scim.NewError(404, "", "SCIM is not enabled")).WriteTo(c)
+	}
+}
+
+func scimResponse(status int, body interface{}) *NormalResponse {
+	return Respond(status, body).Header("Content-Type", scim.ContentType)
+}
+
+// scimError returns SCIM errors with their status, and any other error as
+// internal server error.
+func scimError(err error) Response {
+	if scimErr, ok := err.(*scim.Error); ok {
+		return scimResponse(scimErr.StatusCode(), scimErr)
+	}
+
+	resp := scimResponse(500, scim.NewError(500, "", "Internal Server Error"))
+	resp.errMessage = "SCIM request failed"
+	resp.err = err
+	return resp
+}
+
+func scimDecode(c *models.ReqContext, v interface{}) error {
+	if err := json.NewDecoder(c.Req.Request.Body).Decode(v); err != nil {
+		return scim.NewError(400, scim.ErrorTypeInvalidSyntax, "Invalid request body: "+err.Error())
+	}
+	return nil
+}
+
+func scimListOptions(c *models.ReqContext) scim.ListOptions {
+	opts := scim.ListOptions{
+		Filter:     c.Query("filter"),
+		StartIndex: c.QueryInt("startIndex"),
+		Count:      scim.DefaultCount,
+	}
+
+	if count := c.Query("count"); count != "" {
+		opts.Count, _ = strconv.Atoi(count)
+	}
+
+	for _, attr := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			opts.ExcludeMembers = true
+		}
+	}
+
+	return opts
+}
+
+// GET /scim/v2/ServiceProviderConfig
+func GetScimServiceProviderConfig(c *models.ReqContext) Response {
+	return scimResponse(200, scim.ServiceProviderConfig())
+}
+
+// GET /scim/v2/ResourceTypes
+func GetScimResourceTypes(c *models.ReqContext) Response {
+	resourceTypes := scim.ResourceTypes()
+	resources := make([]interface{}, 0, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		resources = append(resources, resourceType)
+	}
+
+	return scimResponse(200, scim.ListResponse{
+		Schemas:      []string{scim.ListResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GET /scim/v2/Users
+func GetScimUsers(c *models.ReqContext) Response {
+	result, err := scim.ListUsers(c.OrgId, scimListOptions(c))
+	if err != nil {
+		return scimError(err)
+	}
+
+	return scimResponse(200, result)
+}
+
+// GET /scim/v2/Users/:id
+func GetScimUser(c *models.ReqContext) Response {
+	user, err := scim.GetUser(c.OrgId, c.Params(":id"))
+	if err != nil {
+		return scimError(err)
+	}
+
+	return scimResponse(200, user)
+}
+
+// POST /scim/v2/Users
+func (hs *HTTPServer) CreateScimUser(c *models.ReqContext) Response {
+	input := scim.User{}
+	if err := scimDecode(c, &input); err != nil {
+		return scimError(err)
+	}
+
+	user, err := scim.CreateUser(c.OrgId, &input)
+	if err != nil {
+		return scimError(err)
+	}
+
+	return hs.scimUserResponse(c, 201, user)
+}
+
+// PUT /scim/v2/Users/:id
+func (hs *HTTPServer) ReplaceScimUser(c *models.ReqContext) Response {
+	input := scim.User{}
+	if err := scimDecode(c, &input); err != nil {
+		return scimError(err)
+	}
+
+	user, err := scim.ReplaceUser(c.OrgId, c.Params(":id"), &input)
+	if err != nil {
+		return scimError(err)
+	}
+
+	return hs.scimUserResponse(c, 200, user)
+}
+
+// PATCH /scim/v2/Users/:id
+func (hs *HTTPServer) PatchScimUser(c *models.ReqContext) Response {
+	patch := scim.PatchRequest{}
+	if err := scimDecode(c, &patch); err != nil {
+		return scimError(err)
+	}
+
+	user, err := scim.PatchUser(c.OrgId, c.Params(":id"), &patch)
+	if err != nil {
+		return scimError(err)
+	}
+
+	return hs.scimUserResponse(c, 200, user)
+}
+
+// scimUserResponse revokes the sessions of users that have been deactivated.
+func (hs *HTTPServer) scimUserResponse(c *models.ReqContext, status int, user *scim.User) Response {
+	if user.Active != nil && !*user.Active {
+		userID, _ := strconv.ParseInt(user.Id, 10, 64)
+		if err := hs.AuthTokenService.RevokeAllUserTokens(c.Req.Context(), userID); err != nil {
+			return scimError(err)
+		}
+	}
+
+	return scimResponse(status, user)
+}
+
+// DELETE /scim/v2/Users/:id
+func DeleteScimUser(c *models.ReqContext) Response {
+	if err := scim.DeleteUser(c.OrgId, c.Params(":id")); err != nil {
+		return scimError(err)
+	}
+
+	return Empty(http.StatusNoContent)
+}
+
+// GET /scim/v2/Groups
+func GetScimGroups(c *models.ReqContext) Response {
+	result, err := scim.ListGroups(c.OrgId, scimListOptions(c))
+	if err != nil {
+		return scimError(err)
+	}
+
+	return scimResponse(200, result)
+}
+
+// GET /scim/v2/Groups/:id
+func GetScimGroup(c *models.ReqContext) Response {
+	group, err := scim.GetGroup(c.OrgId, c.Params(":id"))
+	if err != nil {
+		return scimError(err)
+	}
+
+	return scimResponse(200, group)
+}
+
+// POST /scim/v2/Groups
+func CreateScimGroup(c *models.ReqContext) Response {
+	input := scim.Group{}
+	if err := scimDecode(c, &input); err != nil {
+		return scimError(err)
+	}
+
+	group, err := scim.CreateGroup(c.OrgId, &input)
+	if err != nil {
+		return scimError(err)
+	}
+
+	return scimResponse(201, group)
+}
+
+// PUT /scim/v2/Groups/:id
+func ReplaceScimGroup(c *models.ReqContext) Response {
+	input := scim.Group{}
+	if err := scimDecode(c, &input); err != nil {
+		return scimError(err)
+	}
+
+	group, err := scim.ReplaceGroup(c.OrgId, c.Params(":id"), &input)
+	if err != nil {
+		return scimError(err)
+	}
+
+	return scimResponse(200, group)
+}
+
+// PATCH /scim/v2/Groups/:id
+func PatchScimGroup(c *models.ReqContext) Response {
+	patch := scim.PatchRequest{}
+	if err := scimDecode(c, &patch); err != nil {
+		return scimError(err)
+	}
+
+	group, err := scim.PatchGroup(c.OrgId, c.Params(":id"), &patch)
+	if err != nil {
+		return scimError(err)
+	}
+
+	return scimResponse(200, group)
+}
+
+// DELETE /scim/v2/Groups/:id
+func DeleteScimGroup(c *models.ReqContext) Response {
+	if err := scim.DeleteGroup(c.OrgId, c.Params(":id")); err != nil {
+		return scimError(err)
+	}
+
+	return Empty(http.StatusNoContent)
+}
+
+// GET /api/orgs/:orgId/scim/tokens
+func GetScimTokens(c *models.ReqContext) Response {
+	query := models.GetScimTokensQuery{OrgId: c.ParamsInt64(":orgId")}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to list SCIM tokens", err)
+	}
+
+	result := make([]*models.ScimTokenDTO, 0, len(query.Result))
+	for _, token := range query.Result {
+		result = append(result, &models.ScimTokenDTO{
+			Id:      token.Id,
+			Name:    token.Name,
+			Created: token.Created,
+		})
+	}
+
+	return JSON(200, result)
+}
+
+// POST /api/orgs/:orgId/scim/tokens
+func AddScimToken(c *models.ReqContext, cmd models.AddScimTokenCommand) Response {
+	cmd.OrgId = c.ParamsInt64(":orgId")
+
+	orgQuery := models.GetOrgByIdQuery{Id: cmd.OrgId}
+	if err := bus.Dispatch(&orgQuery); err != nil {
+		if err == models.ErrOrgNotFound {
+			return Error(404, "Organization not found", nil)
+		}
+		return Error(500, "Failed to get organization", err)
+	}
+
+	newKeyInfo, err := apikeygen.New(cmd.OrgId, cmd.Name)
+	if err != nil {
+		return Error(500, "Generating SCIM token failed", err)
+	}
+
+	cmd.Key = newKeyInfo.HashedKey
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrDuplicateScimToken {
+			return Error(409, err.Error(), nil)
+		}
+		return Error(500, "Failed to add SCIM token", err)
+	}
+
+	result := &dtos.NewApiKeyResult{
+		Name: cmd.Result.Name,
+		Key:  newKeyInfo.ClientSecret}
+
+	return JSON(200, result)
+}
+
+// DELETE /api/orgs/:orgId/scim/tokens/:tokenId
+func DeleteScimToken(c *models.ReqContext) Response {
+	cmd := models.DeleteScimTokenCommand{OrgId: c.ParamsInt64(":orgId"), Id: c.ParamsInt64(":tokenId")}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrScimTokenNotFound {
+			return Error(404, err.Error(), nil)
+		}
+		return Error(500, "Failed to delete SCIM token", err)
+	}
+
+	return Success("SCIM token deleted")
+}
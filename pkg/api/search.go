@@ -1,11 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/grafana/grafana/pkg/util"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
@@ -46,6 +48,23 @@ func Search(c *models.ReqContext) Response {
 		}
 	}
 
+	contentKinds := c.QueryStrings("contentKind")
+	for _, kind := range contentKinds {
+		if !models.IsValidDashboardContentKind(kind) {
+			return Error(400, "Invalid content kind "+kind, nil)
+		}
+	}
+
+	facets := c.QueryStrings("facet")
+	for _, facet := range facets {
+		if facet != search.FacetTag && facet != search.FacetFolder && facet != search.FacetType {
+			return Error(400, "Invalid facet "+facet, nil)
+		}
+	}
+
+	// an empty cursor requests the first page
+	_, cursorPaging := c.Req.URL.Query()["cursor"]
+
 	searchQuery := search.Query{
 		Title:        query,
 		Tags:         tags,
@@ -59,15 +78,33 @@ func Search(c *models.ReqContext) Response {
 		FolderIds:    folderIDs,
 		Permission:   permission,
 		Sort:         sort,
+		Content:      c.Query("content"),
+		ContentKinds: contentKinds,
+		DataSource:   c.Query("datasource"),
+		CursorPaging: cursorPaging,
+		Cursor:       c.Query("cursor"),
+		Facets:       facets,
 	}
 
 	err := bus.Dispatch(&searchQuery)
 	if err != nil {
+		if errors.Is(err, search.ErrInvalidCursor) || errors.Is(err, search.ErrCursorSortNotSupported) {
+			return Error(400, err.Error(), nil)
+		}
 		return Error(500, "Search failed", err)
 	}
 
 	c.TimeRequest(metrics.MApiDashboardSearch)
-	return JSON(200, searchQuery.Result)
+
+	if !cursorPaging && len(facets) == 0 {
+		return JSON(200, searchQuery.Result)
+	}
+
+	return JSON(200, dtos.SearchResult{
+		Hits:       searchQuery.Result,
+		NextCursor: searchQuery.NextCursor,
+		Facets:     searchQuery.FacetResult,
+	})
 }
 
 func (hs *HTTPServer) ListSortOptions(c *models.ReqContext) Response {
@@ -6,6 +6,7 @@ import (
 	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -62,6 +63,10 @@ func (hs *HTTPServer) SignUpStep2(c *models.ReqContext, form dtos.SignUpStep2For
 		return Error(401, "User signup is disabled", nil)
 	}
 
+	if err := passwordpolicy.Validate(form.Password); err != nil {
+		return passwordPolicyError(err)
+	}
+
 	createUserCmd := models.CreateUserCommand{
 		Email:    form.Email,
 		Login:    form.Username,
@@ -117,7 +122,7 @@ func (hs *HTTPServer) SignUpStep2(c *models.ReqContext, form dtos.SignUpStep2For
 		apiResponse["code"] = "redirect-to-select-org"
 	}
 
-	err := hs.loginUserWithUser(user, c)
+	err := hs.loginUserWithUser(user, c, models.AuthModuleGrafana)
 	if err != nil {
 		return Error(500, "failed to login user", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/teamguardian"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -12,7 +13,7 @@ import (
 func (hs *HTTPServer) CreateTeam(c *models.ReqContext, cmd models.CreateTeamCommand) Response {
 	cmd.OrgId = c.OrgId
 
-	if c.OrgRole == models.ROLE_VIEWER {
+	if !hs.isAccessControlEnabled() && c.OrgRole == models.ROLE_VIEWER {
 		return Error(403, "Not allowed to create team.", nil)
 	}
 
@@ -23,7 +24,12 @@ func (hs *HTTPServer) CreateTeam(c *models.ReqContext, cmd models.CreateTeamComm
 		return Error(500, "Failed to create Team", err)
 	}
 
-	if c.OrgRole == models.ROLE_EDITOR && hs.Cfg.EditorsCanAdmin {
+	addCreator, err := hs.isTeamAdminRequired(c)
+	if err != nil {
+		return Error(500, "Failed to evaluate permissions", err)
+	}
+
+	if addCreator {
 		// if the request is authenticated using API tokens
 		// the SignedInUser is an empty struct therefore
 		// an additional check whether it is an actual user is required
@@ -54,7 +60,7 @@ func (hs *HTTPServer) UpdateTeam(c *models.ReqContext, cmd models.UpdateTeamComm
 	cmd.OrgId = c.OrgId
 	cmd.Id = c.ParamsInt64(":teamId")
 
-	if err := teamguardian.CanAdmin(hs.Bus, cmd.OrgId, cmd.Id, c.SignedInUser); err != nil {
+	if err := hs.canAdminTeam(c, cmd.OrgId, cmd.Id, accesscontrol.ActionTeamsWrite); err != nil {
 		return Error(403, "Not allowed to update team", err)
 	}
 
@@ -72,9 +78,8 @@ func (hs *HTTPServer) UpdateTeam(c *models.ReqContext, cmd models.UpdateTeamComm
 func (hs *HTTPServer) DeleteTeamByID(c *models.ReqContext) Response {
 	orgId := c.OrgId
 	teamId := c.ParamsInt64(":teamId")
-	user := c.SignedInUser
 
-	if err := teamguardian.CanAdmin(hs.Bus, orgId, teamId, user); err != nil {
+	if err := hs.canAdminTeam(c, orgId, teamId, accesscontrol.ActionTeamsDelete); err != nil {
 		return Error(403, "Not allowed to delete team", err)
 	}
 
@@ -87,6 +92,26 @@ func (hs *HTTPServer) DeleteTeamByID(c *models.ReqContext) Response {
 	return Success("Team deleted")
 }
 
+// GET /api/teams/deleted
+func GetDeletedTeams(c *models.ReqContext) Response {
+	query := models.GetDeletedResourcesQuery{Type: models.DeletedResourceTeam, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get deleted teams", err)
+	}
+	return JSON(200, query.Result)
+}
+
+// POST /api/teams/:teamId/restore
+func RestoreTeamByID(c *models.ReqContext) Response {
+	if err := bus.Dispatch(&models.RestoreTeamCommand{OrgId: c.OrgId, Id: c.ParamsInt64(":teamId")}); err != nil {
+		if err == models.ErrTeamNotFound {
+			return Error(404, "Deleted team not found", nil)
+		}
+		return Error(500, "Failed to restore team", err)
+	}
+	return Success("Team restored")
+}
+
 // GET /api/teams/search
 func (hs *HTTPServer) SearchTeams(c *models.ReqContext) Response {
 	perPage := c.QueryInt("perpage")
@@ -99,7 +124,15 @@ func (hs *HTTPServer) SearchTeams(c *models.ReqContext) Response {
 	}
 
 	var userIdFilter int64
-	if hs.Cfg.EditorsCanAdmin && c.OrgRole != models.ROLE_ADMIN {
+	if hs.isAccessControlEnabled() {
+		teamAdminRequired, err := hs.isTeamAdminRequired(c)
+		if err != nil {
+			return Error(500, "Failed to evaluate permissions", err)
+		}
+		if teamAdminRequired {
+			userIdFilter = c.SignedInUser.UserId
+		}
+	} else if hs.Cfg.EditorsCanAdmin && c.OrgRole != models.ROLE_ADMIN {
 		userIdFilter = c.SignedInUser.UserId
 	}
 
@@ -147,7 +180,7 @@ func (hs *HTTPServer) GetTeamPreferences(c *models.ReqContext) Response {
 	teamId := c.ParamsInt64(":teamId")
 	orgId := c.OrgId
 
-	if err := teamguardian.CanAdmin(hs.Bus, orgId, teamId, c.SignedInUser); err != nil {
+	if err := hs.canAdminTeam(c, orgId, teamId, accesscontrol.ActionTeamsWrite); err != nil {
 		return Error(403, "Not allowed to view team preferences.", err)
 	}
 
@@ -159,9 +192,38 @@ func (hs *HTTPServer) UpdateTeamPreferences(c *models.ReqContext, dtoCmd dtos.Up
 	teamId := c.ParamsInt64(":teamId")
 	orgId := c.OrgId
 
-	if err := teamguardian.CanAdmin(hs.Bus, orgId, teamId, c.SignedInUser); err != nil {
+	if err := hs.canAdminTeam(c, orgId, teamId, accesscontrol.ActionTeamsWrite); err != nil {
 		return Error(403, "Not allowed to update team preferences.", err)
 	}
 
 	return updatePreferencesFor(orgId, 0, teamId, &dtoCmd)
 }
+
+// canAdminTeam allows org admins and admins of the team to manage it. With
+// access control enabled, users that have been granted the action can
+// manage all teams of the org.
+func (hs *HTTPServer) canAdminTeam(c *models.ReqContext, orgId int64, teamId int64, action string) error {
+	if hs.isAccessControlEnabled() {
+		granted, err := hs.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser, action)
+		if err != nil {
+			return err
+		}
+		if granted {
+			return nil
+		}
+	}
+
+	return teamguardian.CanAdmin(hs.Bus, orgId, teamId, c.SignedInUser)
+}
+
+// isTeamAdminRequired returns true if the user can only manage the teams
+// they're an admin of, i.e. editors when editors_can_admin is enabled or,
+// with access control, users that can create teams but not write to all.
+func (hs *HTTPServer) isTeamAdminRequired(c *models.ReqContext) (bool, error) {
+	if !hs.isAccessControlEnabled() {
+		return c.OrgRole == models.ROLE_EDITOR && hs.Cfg.EditorsCanAdmin, nil
+	}
+
+	granted, err := hs.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser, accesscontrol.ActionTeamsWrite)
+	return !granted, err
+}
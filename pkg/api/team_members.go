@@ -4,7 +4,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/services/teamguardian"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -34,10 +34,14 @@ func (hs *HTTPServer) AddTeamMember(c *models.ReqContext, cmd models.AddTeamMemb
 	cmd.OrgId = c.OrgId
 	cmd.TeamId = c.ParamsInt64(":teamId")
 
-	if err := teamguardian.CanAdmin(hs.Bus, cmd.OrgId, cmd.TeamId, c.SignedInUser); err != nil {
+	if err := hs.canAdminTeam(c, cmd.OrgId, cmd.TeamId, accesscontrol.ActionTeamsWrite); err != nil {
 		return Error(403, "Not allowed to add team member", err)
 	}
 
+	if rsp := hs.canChangeTeamMembers(c, cmd.OrgId, cmd.TeamId); rsp != nil {
+		return rsp
+	}
+
 	if err := hs.Bus.Dispatch(&cmd); err != nil {
 		if err == models.ErrTeamNotFound {
 			return Error(404, "Team not found", nil)
@@ -60,7 +64,7 @@ func (hs *HTTPServer) UpdateTeamMember(c *models.ReqContext, cmd models.UpdateTe
 	teamId := c.ParamsInt64(":teamId")
 	orgId := c.OrgId
 
-	if err := teamguardian.CanAdmin(hs.Bus, orgId, teamId, c.SignedInUser); err != nil {
+	if err := hs.canAdminTeam(c, orgId, teamId, accesscontrol.ActionTeamsWrite); err != nil {
 		return Error(403, "Not allowed to update team member", err)
 	}
 
@@ -87,10 +91,14 @@ func (hs *HTTPServer) RemoveTeamMember(c *models.ReqContext) Response {
 	teamId := c.ParamsInt64(":teamId")
 	userId := c.ParamsInt64(":userId")
 
-	if err := teamguardian.CanAdmin(hs.Bus, orgId, teamId, c.SignedInUser); err != nil {
+	if err := hs.canAdminTeam(c, orgId, teamId, accesscontrol.ActionTeamsWrite); err != nil {
 		return Error(403, "Not allowed to remove team member", err)
 	}
 
+	if rsp := hs.canChangeTeamMembers(c, orgId, teamId); rsp != nil {
+		return rsp
+	}
+
 	protectLastAdmin := false
 	if c.OrgRole != models.ROLE_ADMIN {
 		protectLastAdmin = true
@@ -109,3 +117,29 @@ func (hs *HTTPServer) RemoveTeamMember(c *models.ReqContext) Response {
 	}
 	return Success("Team Member removed")
 }
+
+// canChangeTeamMembers prevents privilege escalation through team membership
+// by requiring the signed in user to hold all permissions of the roles
+// assigned to the team, as for assigning these roles directly.
+func (hs *HTTPServer) canChangeTeamMembers(c *models.ReqContext, orgId int64, teamId int64) Response {
+	if !hs.isAccessControlEnabled() {
+		return nil
+	}
+
+	query := models.GetTeamRolesQuery{OrgId: orgId, TeamId: teamId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get team roles", err)
+	}
+
+	permissions := make([]string, 0)
+	for _, role := range query.Result {
+		for _, permission := range role.Permissions {
+			// permissions that can't be part of a role anymore aren't granted
+			if accesscontrol.IsValidAction(permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	return hs.canGrantPermissions(c, permissions)
+}
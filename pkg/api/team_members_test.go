@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeAccessControl struct {
+	permissions []string
+}
+
+func (ac *fakeAccessControl) IsDisabled() bool {
+	return false
+}
+
+func (ac *fakeAccessControl) Evaluate(ctx context.Context, user *models.SignedInUser, action string) (bool, error) {
+	for _, permission := range ac.permissions {
+		if permission == action {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (ac *fakeAccessControl) GetUserPermissions(ctx context.Context, user *models.SignedInUser) ([]string, error) {
+	return ac.permissions, nil
+}
+
+func TestTeamMembersAccessControl(t *testing.T) {
+	Convey("Given a user that can only write teams", t, func() {
+		hs := &HTTPServer{
+			Cfg:           setting.NewCfg(),
+			Bus:           bus.GetBus(),
+			AccessControl: &fakeAccessControl{permissions: []string{accesscontrol.ActionTeamsWrite}},
+		}
+
+		loggedInUserScenarioWithRole("When joining a team with an admin role", "GET", "/api/teams/1/members", "/api/teams/:teamId/members", models.ROLE_VIEWER, func(sc *scenarioContext) {
+			added := false
+			bus.AddHandler("test", func(query *models.GetTeamRolesQuery) error {
+				query.Result = []*models.CustomRoleDTO{
+					{Name: "admin", Permissions: []string{accesscontrol.ActionOrgUsersRoleUpdate, accesscontrol.ActionTeamsWrite}},
+				}
+				return nil
+			})
+			bus.AddHandler("test", func(cmd *models.AddTeamMemberCommand) error {
+				added = true
+				return nil
+			})
+
+			sc.handlerFunc = func(c *models.ReqContext) Response {
+				return hs.AddTeamMember(c, models.AddTeamMemberCommand{UserId: TestUserID})
+			}
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			So(sc.resp.Code, ShouldEqual, 403)
+			So(added, ShouldBeFalse)
+		})
+
+		loggedInUserScenarioWithRole("When leaving a team with an admin role", "DELETE", "/api/teams/1/members/2", "/api/teams/:teamId/members/:userId", models.ROLE_VIEWER, func(sc *scenarioContext) {
+			bus.AddHandler("test", func(query *models.GetTeamRolesQuery) error {
+				query.Result = []*models.CustomRoleDTO{
+					{Name: "admin", Permissions: []string{accesscontrol.ActionOrgUsersRoleUpdate}},
+				}
+				return nil
+			})
+
+			sc.handlerFunc = hs.RemoveTeamMember
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			So(sc.resp.Code, ShouldEqual, 403)
+		})
+
+		loggedInUserScenarioWithRole("When joining a team with roles the user holds", "GET", "/api/teams/1/members", "/api/teams/:teamId/members", models.ROLE_VIEWER, func(sc *scenarioContext) {
+			bus.AddHandler("test", func(query *models.GetTeamRolesQuery) error {
+				query.Result = []*models.CustomRoleDTO{
+					{Name: "team manager", Permissions: []string{accesscontrol.ActionTeamsWrite}},
+				}
+				return nil
+			})
+			bus.AddHandler("test", func(cmd *models.AddTeamMemberCommand) error {
+				return nil
+			})
+
+			sc.handlerFunc = func(c *models.ReqContext) Response {
+				return hs.AddTeamMember(c, models.AddTeamMemberCommand{UserId: TestUserID})
+			}
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+		})
+	})
+}
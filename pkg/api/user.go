@@ -4,6 +4,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -232,9 +233,8 @@ func ChangeUserPassword(c *models.ReqContext, cmd models.ChangeUserPasswordComma
 		return Error(401, "Invalid old password", nil)
 	}
 
-	password := models.Password(cmd.NewPassword)
-	if password.IsWeak() {
-		return Error(400, "New password is too short", nil)
+	if err := passwordpolicy.ValidateForUser(userQuery.Result, cmd.NewPassword); err != nil {
+		return passwordPolicyError(err)
 	}
 
 	cmd.UserId = c.UserId
@@ -349,6 +349,12 @@ func GetAuthProviderLabel(authModule string) string {
 		return "grafana.com"
 	case "auth.saml":
 		return "SAML"
+	case models.AuthModuleGrafana:
+		return "Grafana"
+	case models.AuthModuleAuthProxy:
+		return "Auth Proxy"
+	case models.AuthModuleJWT:
+		return "JWT"
 	case "ldap", "":
 		return "LDAP"
 	default:
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/user/mfa
+func GetUserMfaStatus(c *models.ReqContext) Response {
+	if !setting.MFAEnabled {
+		return Error(404, "Two-factor authentication is not enabled", nil)
+	}
+
+	userMfa, err := mfa.GetUserMfa(c.UserId)
+	if err != nil {
+		return Error(500, "Failed to get two-factor authentication status", err)
+	}
+
+	enforced, err := mfa.IsEnforced(c.UserId)
+	if err != nil {
+		return Error(500, "Failed to get two-factor authentication status", err)
+	}
+
+	result := dtos.UserMfaStatus{Enforced: enforced}
+	if userMfa != nil && userMfa.IsEnabled {
+		result.Enabled = true
+		result.RecoveryCodesRemaining = len(userMfa.RecoveryCodes)
+	}
+
+	return JSON(200, result)
+}
+
+// POST /api/user/mfa/enroll
+func EnrollUserMfa(c *models.ReqContext) Response {
+	if !setting.MFAEnabled {
+		return Error(404, "Two-factor authentication is not enabled", nil)
+	}
+
+	// Only built-in Grafana logins are checked for a second factor
+	authInfoQuery := &models.GetAuthInfoQuery{UserId: c.UserId}
+	err := bus.Dispatch(authInfoQuery)
+	if err == nil {
+		return Error(400, "Two-factor authentication is only available for Grafana logins", nil)
+	}
+	if err != models.ErrUserNotFound {
+		return Error(500, "Failed to get auth info of user", err)
+	}
+
+	enrollment, err := mfa.Enroll(c.UserId, c.Login)
+	if err != nil {
+		if err == mfa.ErrAlreadyEnabled {
+			return Error(400, err.Error(), nil)
+		}
+		return Error(500, "Failed to start two-factor authentication enrolment", err)
+	}
+
+	return JSON(200, enrollment)
+}
+
+// POST /api/user/mfa/activate
+func (hs *HTTPServer) ActivateUserMfa(c *models.ReqContext, cmd dtos.UserMfaCodeForm) Response {
+	if !setting.MFAEnabled {
+		return Error(404, "Two-factor authentication is not enabled", nil)
+	}
+
+	codes, err := mfa.Activate(c.UserId, cmd.Code)
+	if err != nil {
+		return mfaErrorResponse(err, "Failed to activate two-factor authentication")
+	}
+
+	// Sessions created before the second factor was enabled are signed out
+	if err := hs.revokeOtherUserTokens(c.Req.Context(), c.UserId, c.UserToken); err != nil {
+		return Error(500, "Failed to revoke user sessions", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": codes,
+	})
+}
+
+// POST /api/user/mfa/recovery-codes
+func RegenerateUserMfaRecoveryCodes(c *models.ReqContext, cmd dtos.UserMfaCodeForm) Response {
+	if !setting.MFAEnabled {
+		return Error(404, "Two-factor authentication is not enabled", nil)
+	}
+
+	codes, err := mfa.RegenerateRecoveryCodes(c.UserId, cmd.Code)
+	if err != nil {
+		return mfaErrorResponse(err, "Failed to regenerate recovery codes")
+	}
+
+	return JSON(200, util.DynMap{
+		"recoveryCodes": codes,
+	})
+}
+
+// POST /api/user/mfa/disable
+func DisableUserMfa(c *models.ReqContext, cmd dtos.UserMfaCodeForm) Response {
+	if !setting.MFAEnabled {
+		return Error(404, "Two-factor authentication is not enabled", nil)
+	}
+
+	if err := mfa.Disable(c.UserId, cmd.Code, cmd.RecoveryCode); err != nil {
+		return mfaErrorResponse(err, "Failed to disable two-factor authentication")
+	}
+
+	return Success("Two-factor authentication disabled")
+}
+
+// DELETE /api/admin/users/:id/mfa
+func (hs *HTTPServer) AdminResetUserMfa(c *models.ReqContext) Response {
+	userID := c.ParamsInt64(":id")
+
+	userQuery := models.GetUserByIdQuery{Id: userID}
+	if err := bus.Dispatch(&userQuery); err != nil {
+		if err == models.ErrUserNotFound {
+			return Error(404, "User not found", err)
+		}
+		return Error(500, "Could not read user from database", err)
+	}
+
+	if err := mfa.Reset(userID); err != nil {
+		return Error(500, "Failed to reset two-factor authentication", err)
+	}
+
+	if err := hs.AuthTokenService.RevokeAllUserTokens(c.Req.Context(), userID); err != nil {
+		return Error(500, "Failed to logout user", err)
+	}
+
+	return Success("Two-factor authentication reset")
+}
+
+func (hs *HTTPServer) revokeOtherUserTokens(ctx context.Context, userID int64, current *models.UserToken) error {
+	tokens, err := hs.AuthTokenService.GetUserTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if current != nil && token.Id == current.Id {
+			continue
+		}
+		if err := hs.AuthTokenService.RevokeToken(ctx, token); err != nil && err != models.ErrUserTokenNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mfaErrorResponse(err error, message string) Response {
+	switch err {
+	case mfa.ErrInvalidCode, mfa.ErrCodeRequired:
+		return Error(401, err.Error(), nil)
+	case mfa.ErrNotEnabled, mfa.ErrAlreadyEnabled, mfa.ErrNoPendingEnrollment:
+		return Error(400, err.Error(), nil)
+	case mfa.ErrEnforced:
+		return Error(403, err.Error(), nil)
+	}
+
+	return Error(500, message, err)
+}
@@ -58,51 +58,7 @@ func (server *HTTPServer) getUserAuthTokensInternal(c *models.ReqContext, userID
 
 	result := []*dtos.UserToken{}
 	for _, token := range tokens {
-		isActive := false
-		if c.UserToken != nil && c.UserToken.Id == token.Id {
-			isActive = true
-		}
-
-		parser := uaparser.NewFromSaved()
-		client := parser.Parse(token.UserAgent)
-
-		osVersion := ""
-		if client.Os.Major != "" {
-			osVersion = client.Os.Major
-
-			if client.Os.Minor != "" {
-				osVersion = osVersion + "." + client.Os.Minor
-			}
-		}
-
-		browserVersion := ""
-		if client.UserAgent.Major != "" {
-			browserVersion = client.UserAgent.Major
-
-			if client.UserAgent.Minor != "" {
-				browserVersion = browserVersion + "." + client.UserAgent.Minor
-			}
-		}
-
-		createdAt := time.Unix(token.CreatedAt, 0)
-		seenAt := time.Unix(token.SeenAt, 0)
-
-		if token.SeenAt == 0 {
-			seenAt = createdAt
-		}
-
-		result = append(result, &dtos.UserToken{
-			Id:                     token.Id,
-			IsActive:               isActive,
-			ClientIp:               token.ClientIp,
-			Device:                 client.Device.ToString(),
-			OperatingSystem:        client.Os.Family,
-			OperatingSystemVersion: osVersion,
-			Browser:                client.UserAgent.Family,
-			BrowserVersion:         browserVersion,
-			CreatedAt:              createdAt,
-			SeenAt:                 seenAt,
-		})
+		result = append(result, toUserTokenDTO(c, token))
 	}
 
 	return JSON(200, result)
@@ -142,3 +98,61 @@ func (server *HTTPServer) revokeUserAuthTokenInternal(c *models.ReqContext, user
 		"message": "User auth token revoked",
 	})
 }
+
+// toUserTokenDTO returns the details of a session, with the device and
+// browser parsed from the user agent.
+func toUserTokenDTO(c *models.ReqContext, token *models.UserToken) *dtos.UserToken {
+	isActive := false
+	if c.UserToken != nil && c.UserToken.Id == token.Id {
+		isActive = true
+	}
+
+	parser := uaparser.NewFromSaved()
+	client := parser.Parse(token.UserAgent)
+
+	osVersion := ""
+	if client.Os.Major != "" {
+		osVersion = client.Os.Major
+
+		if client.Os.Minor != "" {
+			osVersion = osVersion + "." + client.Os.Minor
+		}
+	}
+
+	browserVersion := ""
+	if client.UserAgent.Major != "" {
+		browserVersion = client.UserAgent.Major
+
+		if client.UserAgent.Minor != "" {
+			browserVersion = browserVersion + "." + client.UserAgent.Minor
+		}
+	}
+
+	createdAt := time.Unix(token.CreatedAt, 0)
+	seenAt := time.Unix(token.SeenAt, 0)
+
+	if token.SeenAt == 0 {
+		seenAt = createdAt
+	}
+
+	// Tokens created before the auth module was recorded have none.
+	authLabel := ""
+	if token.AuthModule != "" {
+		authLabel = GetAuthProviderLabel(token.AuthModule)
+	}
+
+	return &dtos.UserToken{
+		Id:                     token.Id,
+		IsActive:               isActive,
+		ClientIp:               token.ClientIp,
+		Device:                 client.Device.ToString(),
+		OperatingSystem:        client.Os.Family,
+		OperatingSystemVersion: osVersion,
+		Browser:                client.UserAgent.Family,
+		BrowserVersion:         browserVersion,
+		AuthModule:             token.AuthModule,
+		AuthLabel:              authLabel,
+		CreatedAt:              createdAt,
+		SeenAt:                 seenAt,
+	}
+}
@@ -127,6 +127,7 @@ func TestUserTokenApiEndpoint(t *testing.T) {
 					SeenAt:    0,
 				},
 			}
+			tokens[0].AuthModule = "oauth_github"
 			sc.userAuthTokenService.GetUserTokensProvider = func(ctx context.Context, userId int64) ([]*models.UserToken, error) {
 				return tokens, nil
 			}
@@ -148,6 +149,8 @@ func TestUserTokenApiEndpoint(t *testing.T) {
 			So(resultOne.Get("browserVersion").MustString(), ShouldEqual, "72.0")
 			So(resultOne.Get("os").MustString(), ShouldEqual, "Linux")
 			So(resultOne.Get("osVersion").MustString(), ShouldEqual, "")
+			So(resultOne.Get("authModule").MustString(), ShouldEqual, "oauth_github")
+			So(resultOne.Get("authLabel").MustString(), ShouldEqual, "GitHub")
 
 			resultTwo := result.GetIndex(1)
 			So(resultTwo.Get("id").MustInt64(), ShouldEqual, tokens[1].Id)
@@ -161,6 +164,8 @@ func TestUserTokenApiEndpoint(t *testing.T) {
 			So(resultTwo.Get("browserVersion").MustString(), ShouldEqual, "11.0")
 			So(resultTwo.Get("os").MustString(), ShouldEqual, "iOS")
 			So(resultTwo.Get("osVersion").MustString(), ShouldEqual, "11.0")
+			So(resultTwo.Get("authModule").MustString(), ShouldEqual, "")
+			So(resultTwo.Get("authLabel").MustString(), ShouldEqual, "")
 		})
 	})
 }
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/bundles"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+func exportBundleCommand(c utils.CommandLine, sqlStore *sqlstore.SqlStore) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing path of the archive to export to")
+	}
+
+	bundle, err := bundles.Export(bundles.ExportOptions{
+		OrgId:         int64(c.Int("org-id")),
+		FolderUids:    c.StringSlice("folder-uid"),
+		DashboardUids: c.StringSlice("dashboard-uid"),
+	})
+	if err != nil {
+		return errutil.Wrap("failed to export bundle", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return errutil.Wrap("failed to create archive", err)
+	}
+
+	if err := bundles.WriteArchive(file, bundle); err != nil {
+		file.Close()
+		return errutil.Wrap("failed to write archive", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return errutil.Wrap("failed to write archive", err)
+	}
+
+	logger.Infof("Exported %d folders, %d dashboards, %d library panels and %d data sources to %s %s\n",
+		len(bundle.Folders), len(bundle.Dashboards), len(bundle.LibraryPanels), len(bundle.DataSources), path,
+		color.GreenString("✔"))
+
+	return nil
+}
+
+func importBundleCommand(c utils.CommandLine, sqlStore *sqlstore.SqlStore) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing path of the archive to import")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errutil.Wrap("failed to read archive", err)
+	}
+
+	bundle, err := bundles.ReadArchive(bytes.NewReader(data), int64(len(data)), setting.DashboardBundleMaxSize)
+	if err != nil {
+		return err
+	}
+
+	dataSources, err := parseMapping(c.StringSlice("datasource"))
+	if err != nil {
+		return err
+	}
+
+	uids, err := parseMapping(c.StringSlice("uid"))
+	if err != nil {
+		return err
+	}
+
+	orgId := int64(c.Int("org-id"))
+	result, err := bundles.Import(bundle, bundles.ImportOptions{
+		OrgId: orgId,
+		User: &models.SignedInUser{
+			UserId:         AdminUserId,
+			OrgId:          orgId,
+			OrgRole:        models.ROLE_ADMIN,
+			IsGrafanaAdmin: true,
+		},
+		DryRun:      c.Bool("dry-run"),
+		Overwrite:   c.Bool("overwrite"),
+		NewUids:     c.Bool("new-uids"),
+		Uids:        uids,
+		DataSources: dataSources,
+		FolderUid:   c.String("folder-uid"),
+	})
+	if result != nil {
+		printImportResult(result)
+	}
+	if err != nil {
+		return errutil.Wrap("failed to import bundle", err)
+	}
+
+	if result.DryRun {
+		logger.Infof("\nDry run, no changes were made\n")
+		return nil
+	}
+
+	logger.Infof("\nBundle imported successfully %s\n", color.GreenString("✔"))
+	return nil
+}
+
+// parseMapping parses mappings of the form <from>=<to>.
+func parseMapping(values []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid mapping %q, expected <from>=<to>", value)
+		}
+		mapping[parts[0]] = parts[1]
+	}
+	return mapping, nil
+}
+
+func printImportResult(result *bundles.ImportResult) {
+	sections := []struct {
+		name    string
+		changes []*bundles.Change
+	}{
+		{"Data sources", result.DataSources},
+		{"Folders", result.Folders},
+		{"Library panels", result.LibraryPanels},
+		{"Dashboards", result.Dashboards},
+	}
+
+	for _, section := range sections {
+		if len(section.changes) == 0 {
+			continue
+		}
+
+		logger.Infof("%s:\n", section.name)
+		for _, change := range section.changes {
+			line := fmt.Sprintf("  %-9s %s (%s)", change.Action, change.Title, change.Uid)
+			if change.Target != "" && change.Target != change.Title {
+				line += " -> " + change.Target
+			}
+			if change.Reason != "" {
+				line += ": " + change.Reason
+			}
+			if change.Action == bundles.ActionConflict {
+				line = color.RedString(line)
+			}
+			logger.Info(line + "\n")
+
+			if change.Diff != nil {
+				printDashboardDiff(change)
+			}
+		}
+	}
+
+	for _, warning := range result.Warnings {
+		logger.Warnf("%s %s\n", color.YellowString("warning:"), warning)
+	}
+}
+
+func printDashboardDiff(change *bundles.Change) {
+	for _, panel := range change.Diff.Panels {
+		logger.Infof("      panel %q %s\n", panel.Title, panel.Change)
+	}
+	for _, variable := range change.Diff.Variables {
+		logger.Infof("      variable %q %s\n", variable.Name, variable.Change)
+	}
+	for _, annotation := range change.Diff.Annotations {
+		logger.Infof("      annotation %q %s\n", annotation.Name, annotation.Change)
+	}
+	for _, fields := range [][]*dashdiffs.FieldChange{change.Diff.Time, change.Diff.General} {
+		for _, field := range fields {
+			logger.Infof("      %s changed\n", field.Path)
+		}
+	}
+}
@@ -119,6 +119,67 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:   "reset-user-mfa",
+		Usage:  "reset-user-mfa <login or email>",
+		Action: runDbCommand(resetUserMfaCommand),
+	},
+	{
+		Name:   "export-bundle",
+		Usage:  "export-bundle <archive path>",
+		Action: runDbCommand(exportBundleCommand),
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "org-id",
+				Usage: "Id of the organization to export",
+				Value: 1,
+			},
+			&cli.StringSliceFlag{
+				Name:  "folder-uid",
+				Usage: "Uid of a folder to export with its subfolders and content, exports everything if no folders or dashboards are set",
+			},
+			&cli.StringSliceFlag{
+				Name:  "dashboard-uid",
+				Usage: "Uid of a dashboard to export",
+			},
+		},
+	},
+	{
+		Name:   "import-bundle",
+		Usage:  "import-bundle <archive path>",
+		Action: runDbCommand(importBundleCommand),
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "org-id",
+				Usage: "Id of the organization to import into",
+				Value: 1,
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Report the changes without applying them",
+			},
+			&cli.BoolFlag{
+				Name:  "overwrite",
+				Usage: "Overwrite existing folders, dashboards and library panels with the same uid",
+			},
+			&cli.BoolFlag{
+				Name:  "new-uids",
+				Usage: "Import folders, dashboards and library panels with new uids",
+			},
+			&cli.StringSliceFlag{
+				Name:  "uid",
+				Usage: "Import the item with a uid with another uid, <uid>=<new uid>",
+			},
+			&cli.StringSliceFlag{
+				Name:  "datasource",
+				Usage: "Replace references to a data source of the bundle with an existing data source, <name>=<existing name>",
+			},
+			&cli.StringFlag{
+				Name:  "folder-uid",
+				Usage: "Uid of the folder to import the top level content of the bundle into",
+			},
+		},
+	},
 	{
 		Name:  "data-migration",
 		Usage: "Runs a script that migrates or cleanups data in your db",
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+func resetUserMfaCommand(c utils.CommandLine, sqlStore *sqlstore.SqlStore) error {
+	loginOrEmail := c.Args().First()
+	if loginOrEmail == "" {
+		return fmt.Errorf("missing user login or email")
+	}
+
+	userQuery := models.GetUserByLoginQuery{LoginOrEmail: loginOrEmail}
+	if err := bus.Dispatch(&userQuery); err != nil {
+		return fmt.Errorf("could not read user from database. Error: %v", err)
+	}
+
+	userID := userQuery.Result.Id
+
+	if err := bus.Dispatch(&models.DeleteUserMfaCommand{UserId: userID}); err != nil {
+		return errutil.Wrapf(err, "failed to reset two-factor authentication")
+	}
+
+	// Sign out all sessions, they might have been created by whoever holds the lost device
+	tokenService := &auth.UserAuthTokenService{SQLStore: sqlStore}
+	if err := tokenService.Init(); err != nil {
+		return err
+	}
+	if err := tokenService.RevokeAllUserTokens(context.Background(), userID); err != nil {
+		return errutil.Wrapf(err, "failed to revoke user sessions")
+	}
+
+	logger.Infof("\n")
+	logger.Infof("Two-factor authentication reset for user %s %s", userQuery.Result.Login, color.GreenString("✔"))
+
+	return nil
+}
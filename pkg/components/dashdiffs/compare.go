@@ -24,6 +24,7 @@ const (
 	DiffJSON DiffType = iota
 	DiffBasic
 	DiffDelta
+	DiffSemantic
 )
 
 type Options struct {
@@ -51,6 +52,8 @@ func ParseDiffType(diff string) DiffType {
 		return DiffBasic
 	case "delta":
 		return DiffDelta
+	case "semantic":
+		return DiffSemantic
 	}
 	return DiffBasic
 }
@@ -81,6 +84,20 @@ func CalculateDiff(options *Options) (*Result, error) {
 	baseData := baseVersionQuery.Result.Data
 	newData := newVersionQuery.Result.Data
 
+	if options.DiffType == DiffSemantic {
+		semanticDiff, err := CalculateSemanticDiff(baseData, newData)
+		if err != nil {
+			return nil, err
+		}
+
+		semanticOutput, err := json.Marshal(semanticDiff)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{Delta: semanticOutput}, nil
+	}
+
 	left, jsonDiff, err := getDiff(baseData, newData)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,207 @@
+package dashdiffs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// MergeConflictError occurs when both versions of a three-way merge change the same part of the dashboard.
+// Paths are the conflicting parts, for example `panels[id=2]` or `title`.
+type MergeConflictError struct {
+	Paths []string
+}
+
+func (e MergeConflictError) Error() string {
+	return "dashdiff: conflicting changes of " + strings.Join(e.Paths, ", ")
+}
+
+// mergeValue is a value of a three-way merge, ok is false when the value does not exist.
+type mergeValue struct {
+	value interface{}
+	ok    bool
+}
+
+func lookup(m map[string]interface{}, key string) mergeValue {
+	value, ok := m[key]
+	return mergeValue{value: value, ok: ok}
+}
+
+// Merge merges the changes of ours and theirs, two dashboard JSON models changed from the base version. Panels
+// are merged by id, template variables and annotations by name, and any other property as a whole, so changes of
+// different panels, for example, are merged. The panels of a row are part of the row panel. The id and version
+// of the merged dashboard are the ones of theirs. Merge returns a MergeConflictError if both changed the same
+// panel, variable, annotation or property differently.
+func Merge(baseData, oursData, theirsData *simplejson.Json) (*simplejson.Json, error) {
+	base, err := normalize(baseData)
+	if err != nil {
+		return nil, err
+	}
+
+	ours, err := normalize(oursData)
+	if err != nil {
+		return nil, err
+	}
+
+	theirs, err := normalize(theirsData)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &merger{}
+	merged := map[string]interface{}{}
+	for _, key := range sortedKeys(base, ours, theirs) {
+		var result mergeValue
+		switch key {
+		case "id", "version":
+			result = lookup(theirs, key)
+		case "panels":
+			result = m.mergeList(key, lookup(base, key), lookup(ours, key), lookup(theirs, key), panelKey)
+		case "templating", "annotations":
+			result = m.mergeListParent(key, lookup(base, key), lookup(ours, key), lookup(theirs, key))
+		default:
+			result = m.merge(key, lookup(base, key), lookup(ours, key), lookup(theirs, key))
+		}
+
+		if result.ok {
+			merged[key] = result.value
+		}
+	}
+
+	if len(m.conflicts) > 0 {
+		return nil, MergeConflictError{Paths: m.conflicts}
+	}
+
+	return simplejson.NewFromAny(merged), nil
+}
+
+type merger struct {
+	conflicts []string
+}
+
+// merge merges a value as a whole.
+func (m *merger) merge(path string, base, ours, theirs mergeValue) mergeValue {
+	switch {
+	case reflect.DeepEqual(ours, base):
+		return theirs
+	case reflect.DeepEqual(theirs, base), reflect.DeepEqual(ours, theirs):
+		return ours
+	}
+
+	m.conflicts = append(m.conflicts, path)
+	return theirs
+}
+
+// mergeListParent merges the list of the templating or annotations object by name, and its other properties
+// as a whole.
+func (m *merger) mergeListParent(path string, base, ours, theirs mergeValue) mergeValue {
+	baseMap, baseOk := base.value.(map[string]interface{})
+	oursMap, oursOk := ours.value.(map[string]interface{})
+	theirsMap, theirsOk := theirs.value.(map[string]interface{})
+	if !oursOk || !theirsOk || (base.ok && !baseOk) {
+		return m.merge(path, base, ours, theirs)
+	}
+
+	merged := map[string]interface{}{}
+	for _, key := range sortedKeys(baseMap, oursMap, theirsMap) {
+		var result mergeValue
+		if key == "list" {
+			result = m.mergeList(path+".list", lookup(baseMap, key), lookup(oursMap, key), lookup(theirsMap, key), nameKey)
+		} else {
+			result = m.merge(path+"."+key, lookup(baseMap, key), lookup(oursMap, key), lookup(theirsMap, key))
+		}
+
+		if result.ok {
+			merged[key] = result.value
+		}
+	}
+
+	return mergeValue{value: merged, ok: true}
+}
+
+// mergeList merges the items of a list by key, with the items in the order of theirs followed by the items
+// added by ours. Items removed by both are not part of the merged list. Lists with items without key or with
+// duplicate keys are merged as a whole.
+func (m *merger) mergeList(path string, base, ours, theirs mergeValue, key func(interface{}) (string, bool)) mergeValue {
+	baseItems, _, baseOk := indexList(base, key)
+	oursItems, oursKeys, oursOk := indexList(ours, key)
+	theirsItems, theirsKeys, theirsOk := indexList(theirs, key)
+	if !baseOk || !oursOk || !theirsOk || !ours.ok || !theirs.ok {
+		return m.merge(path, base, ours, theirs)
+	}
+
+	merged := []interface{}{}
+	add := func(k string) {
+		itemPath := fmt.Sprintf("%s[%s]", path, k)
+		if result := m.merge(itemPath, lookup(baseItems, k), lookup(oursItems, k), lookup(theirsItems, k)); result.ok {
+			merged = append(merged, result.value)
+		}
+	}
+
+	for _, k := range theirsKeys {
+		add(k)
+	}
+	for _, k := range oursKeys {
+		if _, ok := theirsItems[k]; !ok {
+			add(k)
+		}
+	}
+
+	return mergeValue{value: merged, ok: true}
+}
+
+// indexList returns the items of a list by key and the keys in order. ok is false if the value is not a list
+// or an item has no key or a duplicate key. A missing list has no items.
+func indexList(value mergeValue, key func(interface{}) (string, bool)) (map[string]interface{}, []string, bool) {
+	items := map[string]interface{}{}
+	var keys []string
+	if !value.ok {
+		return items, keys, true
+	}
+
+	list, ok := value.value.([]interface{})
+	if !ok {
+		return nil, nil, false
+	}
+
+	for _, item := range list {
+		k, ok := key(item)
+		if !ok {
+			return nil, nil, false
+		}
+		if _, exists := items[k]; exists {
+			return nil, nil, false
+		}
+		items[k] = item
+		keys = append(keys, k)
+	}
+
+	return items, keys, true
+}
+
+func panelKey(item interface{}) (string, bool) {
+	panel, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	id, ok := panel["id"].(float64)
+	if !ok {
+		return "", false
+	}
+	return "id=" + strconv.FormatFloat(id, 'f', -1, 64), true
+}
+
+func nameKey(item interface{}) (string, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	name, ok := m["name"].(string)
+	if !ok {
+		return "", false
+	}
+	return "name=" + name, true
+}
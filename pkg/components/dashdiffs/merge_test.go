@@ -0,0 +1,97 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMerge(t *testing.T) {
+	Convey("Testing three-way dashboard merge", t, func() {
+		parse := func(json string) *simplejson.Json {
+			data, err := simplejson.NewJson([]byte(json))
+			So(err, ShouldBeNil)
+			return data
+		}
+
+		base := parse(`{
+			"id": 1, "title": "Overview", "version": 3, "refresh": "1m",
+			"templating": {"list": [{"name": "host", "query": "hosts"}]},
+			"panels": [
+				{"id": 1, "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+				{"id": 2, "title": "Memory", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}},
+				{"id": 3, "title": "Disk", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 8}}
+			]
+		}`)
+
+		Convey("Should merge changes of different panels", func() {
+			ours := parse(`{
+				"id": 1, "title": "Overview", "version": 3, "refresh": "1m",
+				"templating": {"list": [{"name": "host", "query": "hosts"}, {"name": "env", "query": "envs"}]},
+				"panels": [
+					{"id": 1, "title": "CPU usage", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+					{"id": 2, "title": "Memory", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}},
+					{"id": 4, "title": "Network", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 8}}
+				]
+			}`)
+			theirs := parse(`{
+				"id": 1, "title": "Overview", "version": 4, "refresh": "5m",
+				"templating": {"list": [{"name": "host", "query": "hosts"}]},
+				"panels": [
+					{"id": 2, "title": "Memory", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}, "description": "RSS"},
+					{"id": 1, "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+					{"id": 3, "title": "Disk", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 8}},
+					{"id": 5, "title": "Uptime", "gridPos": {"h": 4, "w": 6, "x": 0, "y": 16}}
+				]
+			}`)
+
+			merged, err := Merge(base, ours, theirs)
+			So(err, ShouldBeNil)
+
+			So(merged.Get("version").MustInt(), ShouldEqual, 4)
+			So(merged.Get("refresh").MustString(), ShouldEqual, "5m")
+
+			var titles []string
+			for _, panel := range merged.Get("panels").MustArray() {
+				titles = append(titles, panel.(map[string]interface{})["title"].(string))
+			}
+			So(titles, ShouldResemble, []string{"Memory", "CPU usage", "Uptime", "Network"})
+			So(merged.Get("panels").GetIndex(0).Get("description").MustString(), ShouldEqual, "RSS")
+
+			So(merged.Get("templating").Get("list").MustArray(), ShouldHaveLength, 2)
+		})
+
+		Convey("Should return conflicts of changes of the same panel or property", func() {
+			ours := parse(`{
+				"id": 1, "title": "Mine", "version": 3, "refresh": "1m",
+				"templating": {"list": [{"name": "host", "query": "hosts"}]},
+				"panels": [
+					{"id": 1, "title": "CPU usage", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+					{"id": 3, "title": "Disk", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 8}}
+				]
+			}`)
+			theirs := parse(`{
+				"id": 1, "title": "Theirs", "version": 4, "refresh": "1m",
+				"templating": {"list": [{"name": "host", "query": "hosts"}]},
+				"panels": [
+					{"id": 1, "title": "CPU", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 0}},
+					{"id": 2, "title": "Memory used", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}},
+					{"id": 3, "title": "Disk", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 8}}
+				]
+			}`)
+
+			_, err := Merge(base, ours, theirs)
+			So(err, ShouldResemble, MergeConflictError{Paths: []string{"panels[id=1]", "panels[id=2]", "title"}})
+			So(err.Error(), ShouldEqual, "dashdiff: conflicting changes of panels[id=1], panels[id=2], title")
+		})
+
+		Convey("Should merge panels without id as a whole", func() {
+			ours := parse(`{"id": 1, "title": "Overview", "version": 3, "panels": [{"title": "Text"}]}`)
+			theirs := parse(`{"id": 1, "title": "Overview", "version": 4, "refresh": "1m", "panels": [{"title": "Other"}]}`)
+
+			_, err := Merge(base, ours, theirs)
+			So(err, ShouldResemble, MergeConflictError{Paths: []string{"panels"}})
+		})
+	})
+}
@@ -0,0 +1,386 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// SemanticChangeType is the kind of change of a dashboard element in a semantic diff.
+type SemanticChangeType string
+
+const (
+	SemanticAdded    SemanticChangeType = "added"
+	SemanticRemoved  SemanticChangeType = "removed"
+	SemanticModified SemanticChangeType = "modified"
+)
+
+// timeSettings are the dashboard properties reported as time settings.
+var timeSettings = map[string]bool{
+	"time":       true,
+	"timepicker": true,
+	"refresh":    true,
+	"timezone":   true,
+}
+
+// SemanticDiff is the dashboard-aware diff of two dashboard versions.
+type SemanticDiff struct {
+	Panels      []*PanelChange `json:"panels"`
+	Variables   []*NamedChange `json:"variables"`
+	Annotations []*NamedChange `json:"annotations"`
+	Time        []*FieldChange `json:"time"`
+	General     []*FieldChange `json:"general"`
+}
+
+// IsEmpty returns true if the diff has no changes.
+func (d *SemanticDiff) IsEmpty() bool {
+	return len(d.Panels) == 0 && len(d.Variables) == 0 && len(d.Annotations) == 0 && len(d.Time) == 0 &&
+		len(d.General) == 0
+}
+
+// PanelChange is the change of a panel. Panels are identified by id, and panels without id by gridPos.
+// Properties are the other changed properties of a modified panel.
+type PanelChange struct {
+	Id         int64              `json:"id"`
+	Title      string             `json:"title"`
+	Type       string             `json:"type"`
+	Change     SemanticChangeType `json:"change"`
+	Moved      bool               `json:"moved"`
+	GridPos    *FieldChange       `json:"gridPos,omitempty"`
+	Queries    []*QueryChange     `json:"queries,omitempty"`
+	Properties []string           `json:"properties,omitempty"`
+}
+
+// QueryChange is the change of a panel query, identified by refId.
+type QueryChange struct {
+	RefId  string             `json:"refId"`
+	Change SemanticChangeType `json:"change"`
+	Before interface{}        `json:"before,omitempty"`
+	After  interface{}        `json:"after,omitempty"`
+}
+
+// NamedChange is the change of a template variable or an annotation, identified by name.
+type NamedChange struct {
+	Name       string             `json:"name"`
+	Change     SemanticChangeType `json:"change"`
+	Properties []string           `json:"properties,omitempty"`
+}
+
+// FieldChange is the change of the value at the path.
+type FieldChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// CalculateSemanticDiff computes the dashboard-aware diff of two dashboard JSON models.
+func CalculateSemanticDiff(baseData, newData *simplejson.Json) (*SemanticDiff, error) {
+	base, err := normalize(baseData)
+	if err != nil {
+		return nil, err
+	}
+
+	new, err := normalize(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SemanticDiff{
+		Panels:      diffPanels(flattenPanels(base["panels"]), flattenPanels(new["panels"])),
+		Variables:   diffNamedList(listOf(base, "templating"), listOf(new, "templating")),
+		Annotations: diffNamedList(listOf(base, "annotations"), listOf(new, "annotations")),
+		Time:        []*FieldChange{},
+		General:     []*FieldChange{},
+	}
+
+	for _, key := range sortedKeys(base, new) {
+		switch {
+		case key == "id" || key == "version" || key == "panels":
+			continue
+		case key == "templating" || key == "annotations":
+			// the lists are diffed by name, the other properties are general settings
+			result.General = append(result.General, diffFields(key, withoutList(base[key]), withoutList(new[key]))...)
+		case timeSettings[key]:
+			result.Time = append(result.Time, diffFields(key, base[key], new[key])...)
+		default:
+			result.General = append(result.General, diffFields(key, base[key], new[key])...)
+		}
+	}
+
+	return result, nil
+}
+
+// normalize decodes the dashboard JSON model to plain Go values, so that equal values are deeply equal.
+func normalize(data *simplejson.Json) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if data == nil {
+		return result, nil
+	}
+
+	bytes, err := data.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func diffPanels(basePanels, newPanels []map[string]interface{}) []*PanelChange {
+	changes := []*PanelChange{}
+	matched := make([]bool, len(basePanels))
+
+	for _, newPanel := range newPanels {
+		i := findPanel(basePanels, matched, newPanel)
+		if i < 0 {
+			changes = append(changes, panelChange(newPanel, SemanticAdded))
+			continue
+		}
+		matched[i] = true
+
+		if change := diffPanel(basePanels[i], newPanel); change != nil {
+			changes = append(changes, change)
+		}
+	}
+
+	for i, basePanel := range basePanels {
+		if !matched[i] {
+			changes = append(changes, panelChange(basePanel, SemanticRemoved))
+		}
+	}
+
+	return changes
+}
+
+// findPanel returns the index of the unmatched panel with the id of the panel, or with its gridPos if it has no id.
+func findPanel(panels []map[string]interface{}, matched []bool, panel map[string]interface{}) int {
+	id, hasID := panel["id"]
+	for i, p := range panels {
+		if matched[i] {
+			continue
+		}
+		if pid, ok := p["id"]; ok || hasID {
+			if ok && hasID && reflect.DeepEqual(pid, id) {
+				return i
+			}
+			continue
+		}
+		if p["gridPos"] != nil && reflect.DeepEqual(p["gridPos"], panel["gridPos"]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func diffPanel(basePanel, newPanel map[string]interface{}) *PanelChange {
+	change := panelChange(newPanel, SemanticModified)
+
+	if !reflect.DeepEqual(basePanel["gridPos"], newPanel["gridPos"]) {
+		change.Moved = true
+		change.GridPos = &FieldChange{Path: "gridPos", Before: basePanel["gridPos"], After: newPanel["gridPos"]}
+	}
+
+	change.Queries = diffQueries(toList(basePanel["targets"]), toList(newPanel["targets"]))
+
+	for _, key := range sortedKeys(basePanel, newPanel) {
+		switch key {
+		case "id", "gridPos", "targets", "panels":
+			continue
+		}
+		if !reflect.DeepEqual(basePanel[key], newPanel[key]) {
+			change.Properties = append(change.Properties, key)
+		}
+	}
+
+	if !change.Moved && len(change.Queries) == 0 && len(change.Properties) == 0 {
+		return nil
+	}
+
+	return change
+}
+
+func panelChange(panel map[string]interface{}, changeType SemanticChangeType) *PanelChange {
+	change := &PanelChange{Change: changeType}
+	if id, ok := panel["id"].(float64); ok {
+		change.Id = int64(id)
+	}
+	change.Title, _ = panel["title"].(string)
+	change.Type, _ = panel["type"].(string)
+	return change
+}
+
+func diffQueries(baseTargets, newTargets []interface{}) []*QueryChange {
+	var changes []*QueryChange
+
+	baseByRefID := map[string]interface{}{}
+	for i, target := range baseTargets {
+		baseByRefID[refID(target, i)] = target
+	}
+
+	seen := map[string]bool{}
+	for i, target := range newTargets {
+		id := refID(target, i)
+		seen[id] = true
+
+		baseTarget, ok := baseByRefID[id]
+		switch {
+		case !ok:
+			changes = append(changes, &QueryChange{RefId: id, Change: SemanticAdded, After: target})
+		case !reflect.DeepEqual(baseTarget, target):
+			changes = append(changes, &QueryChange{RefId: id, Change: SemanticModified, Before: baseTarget, After: target})
+		}
+	}
+
+	for i, target := range baseTargets {
+		if id := refID(target, i); !seen[id] {
+			changes = append(changes, &QueryChange{RefId: id, Change: SemanticRemoved, Before: target})
+		}
+	}
+
+	return changes
+}
+
+// refID returns the refId of the query, or its index for queries without refId.
+func refID(target interface{}, index int) string {
+	if t, ok := target.(map[string]interface{}); ok {
+		if id, ok := t["refId"].(string); ok && id != "" {
+			return id
+		}
+	}
+	return "#" + strconv.Itoa(index)
+}
+
+func diffNamedList(baseList, newList []interface{}) []*NamedChange {
+	changes := []*NamedChange{}
+
+	baseByName := map[string]map[string]interface{}{}
+	for _, item := range baseList {
+		if m, ok := item.(map[string]interface{}); ok {
+			baseByName[nameOf(m)] = m
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, item := range newList {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := nameOf(m)
+		seen[name] = true
+
+		baseItem, ok := baseByName[name]
+		if !ok {
+			changes = append(changes, &NamedChange{Name: name, Change: SemanticAdded})
+			continue
+		}
+
+		var properties []string
+		for _, key := range sortedKeys(baseItem, m) {
+			if !reflect.DeepEqual(baseItem[key], m[key]) {
+				properties = append(properties, key)
+			}
+		}
+		if len(properties) > 0 {
+			changes = append(changes, &NamedChange{Name: name, Change: SemanticModified, Properties: properties})
+		}
+	}
+
+	for _, item := range baseList {
+		if m, ok := item.(map[string]interface{}); ok && !seen[nameOf(m)] {
+			changes = append(changes, &NamedChange{Name: nameOf(m), Change: SemanticRemoved})
+		}
+	}
+
+	return changes
+}
+
+// diffFields returns the changed values of two values, comparing objects property by property.
+func diffFields(path string, before, after interface{}) []*FieldChange {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		var changes []*FieldChange
+		for _, key := range sortedKeys(beforeMap, afterMap) {
+			changes = append(changes, diffFields(path+"."+key, beforeMap[key], afterMap[key])...)
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	return []*FieldChange{{Path: path, Before: before, After: after}}
+}
+
+// flattenPanels returns the panels and the panels of collapsed rows.
+func flattenPanels(value interface{}) []map[string]interface{} {
+	var panels []map[string]interface{}
+	for _, p := range toList(value) {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		panels = append(panels, panel)
+		panels = append(panels, flattenPanels(panel["panels"])...)
+	}
+	return panels
+}
+
+// listOf returns the list of the templating or annotations property of the dashboard.
+func listOf(dashboard map[string]interface{}, key string) []interface{} {
+	if m, ok := dashboard[key].(map[string]interface{}); ok {
+		return toList(m["list"])
+	}
+	return nil
+}
+
+func withoutList(value interface{}) interface{} {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != "list" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func nameOf(item map[string]interface{}) string {
+	if name, ok := item["name"].(string); ok {
+		return name
+	}
+	return fmt.Sprintf("%v", item["name"])
+}
+
+func toList(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
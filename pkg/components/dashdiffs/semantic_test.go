@@ -0,0 +1,139 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const semanticBaseJSON = `{
+	"id": 1,
+	"title": "Overview",
+	"version": 3,
+	"tags": ["prod"],
+	"time": {"from": "now-6h", "to": "now"},
+	"refresh": "1m",
+	"templating": {"list": [
+		{"name": "host", "type": "query", "query": "hosts"},
+		{"name": "env", "type": "custom", "query": "prod,dev"}
+	]},
+	"annotations": {"list": [{"name": "Deploys", "enable": true}]},
+	"panels": [
+		{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0},
+			"targets": [{"refId": "A", "expr": "cpu"}, {"refId": "B", "expr": "load"}]},
+		{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}},
+		{"id": 3, "type": "row", "title": "Details", "collapsed": true, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8}, "panels": [
+			{"id": 4, "type": "table", "title": "Disks", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 9}}
+		]},
+		{"type": "text", "title": "Notes", "gridPos": {"h": 4, "w": 24, "x": 0, "y": 9}}
+	]
+}`
+
+const semanticNewJSON = `{
+	"id": 1,
+	"title": "Overview",
+	"version": 4,
+	"tags": ["prod", "team-a"],
+	"time": {"from": "now-1h", "to": "now"},
+	"refresh": "1m",
+	"templating": {"list": [
+		{"name": "host", "type": "query", "query": "hosts", "multi": true},
+		{"name": "region", "type": "custom", "query": "eu,us"}
+	]},
+	"annotations": {"list": [{"name": "Deploys", "enable": true}]},
+	"panels": [
+		{"id": 1, "type": "graph", "title": "CPU usage", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0},
+			"targets": [{"refId": "A", "expr": "cpu_usage"}, {"refId": "C", "expr": "steal"}]},
+		{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 8}},
+		{"id": 3, "type": "row", "title": "Details", "collapsed": true, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 16}, "panels": [
+			{"id": 4, "type": "table", "title": "Disks", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 17}}
+		]},
+		{"type": "text", "title": "Notes and links", "gridPos": {"h": 4, "w": 24, "x": 0, "y": 9}},
+		{"id": 5, "type": "stat", "title": "Uptime", "gridPos": {"h": 4, "w": 6, "x": 12, "y": 8}}
+	]
+}`
+
+func TestSemanticDiff(t *testing.T) {
+	Convey("Testing semantic dashboard diff", t, func() {
+		baseData, err := simplejson.NewJson([]byte(semanticBaseJSON))
+		So(err, ShouldBeNil)
+		newData, err := simplejson.NewJson([]byte(semanticNewJSON))
+		So(err, ShouldBeNil)
+
+		diff, err := CalculateSemanticDiff(baseData, newData)
+		So(err, ShouldBeNil)
+
+		Convey("Should report panel changes", func() {
+			So(diff.Panels, ShouldHaveLength, 6)
+
+			cpu := diff.Panels[0]
+			So(cpu.Id, ShouldEqual, 1)
+			So(cpu.Change, ShouldEqual, SemanticModified)
+			So(cpu.Moved, ShouldBeFalse)
+			So(cpu.Properties, ShouldResemble, []string{"title"})
+			So(cpu.Queries, ShouldHaveLength, 3)
+			So(cpu.Queries[0].RefId, ShouldEqual, "A")
+			So(cpu.Queries[0].Change, ShouldEqual, SemanticModified)
+			So(cpu.Queries[0].Before, ShouldResemble, map[string]interface{}{"refId": "A", "expr": "cpu"})
+			So(cpu.Queries[0].After, ShouldResemble, map[string]interface{}{"refId": "A", "expr": "cpu_usage"})
+			So(cpu.Queries[1].RefId, ShouldEqual, "C")
+			So(cpu.Queries[1].Change, ShouldEqual, SemanticAdded)
+			So(cpu.Queries[2].RefId, ShouldEqual, "B")
+			So(cpu.Queries[2].Change, ShouldEqual, SemanticRemoved)
+
+			memory := diff.Panels[1]
+			So(memory.Id, ShouldEqual, 2)
+			So(memory.Moved, ShouldBeTrue)
+			So(memory.GridPos.Before, ShouldResemble, map[string]interface{}{"h": 8.0, "w": 12.0, "x": 12.0, "y": 0.0})
+			So(memory.Properties, ShouldBeEmpty)
+
+			So(diff.Panels[2].Id, ShouldEqual, 3)
+			So(diff.Panels[2].Moved, ShouldBeTrue)
+			So(diff.Panels[3].Id, ShouldEqual, 4)
+			So(diff.Panels[3].Moved, ShouldBeTrue)
+
+			notes := diff.Panels[4]
+			So(notes.Title, ShouldEqual, "Notes and links")
+			So(notes.Change, ShouldEqual, SemanticModified)
+			So(notes.Properties, ShouldResemble, []string{"title"})
+
+			So(diff.Panels[5].Id, ShouldEqual, 5)
+			So(diff.Panels[5].Change, ShouldEqual, SemanticAdded)
+		})
+
+		Convey("Should report variable and annotation changes", func() {
+			So(diff.Variables, ShouldResemble, []*NamedChange{
+				{Name: "host", Change: SemanticModified, Properties: []string{"multi"}},
+				{Name: "region", Change: SemanticAdded},
+				{Name: "env", Change: SemanticRemoved},
+			})
+			So(diff.Annotations, ShouldBeEmpty)
+		})
+
+		Convey("Should report time settings and general changes", func() {
+			So(diff.Time, ShouldResemble, []*FieldChange{{Path: "time.from", Before: "now-6h", After: "now-1h"}})
+			So(diff.General, ShouldResemble, []*FieldChange{
+				{Path: "tags", Before: []interface{}{"prod"}, After: []interface{}{"prod", "team-a"}},
+			})
+		})
+
+		Convey("Should report removed panels", func() {
+			diff, err := CalculateSemanticDiff(newData, baseData)
+			So(err, ShouldBeNil)
+
+			last := diff.Panels[len(diff.Panels)-1]
+			So(last.Id, ShouldEqual, 5)
+			So(last.Change, ShouldEqual, SemanticRemoved)
+		})
+
+		Convey("Should not report changes of equal dashboards", func() {
+			diff, err := CalculateSemanticDiff(baseData, baseData)
+			So(err, ShouldBeNil)
+			So(diff.Panels, ShouldBeEmpty)
+			So(diff.Variables, ShouldBeEmpty)
+			So(diff.Time, ShouldBeEmpty)
+			So(diff.General, ShouldBeEmpty)
+		})
+	})
+}
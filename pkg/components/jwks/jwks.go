@@ -0,0 +1,200 @@
+// Package jwks verifies JWTs against a JSON Web Key Set loaded from a file
+// or URL.
+package jwks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	ErrKeyNotFound   = errors.New("no key in the JSON Web Key Set matches the token")
+	ErrMissingExpiry = errors.New("token has no expiry")
+)
+
+// minRefetchInterval is how long the key set is used before it's loaded again
+// for a token with an unknown key ID, so that such tokens can't make every
+// request load the key set.
+const minRefetchInterval = time.Minute
+
+var (
+	timeNow    = time.Now
+	httpClient = &http.Client{Timeout: 10 * time.Second}
+
+	keySetsMu sync.Mutex
+	keySets   = map[string]*KeySet{}
+)
+
+// KeySet is a JSON Web Key Set that is cached for the configured TTL, so
+// that it isn't loaded again for every token.
+type KeySet struct {
+	file     string
+	url      string
+	cacheTTL time.Duration
+
+	mu        sync.Mutex
+	keys      *jose.JSONWebKeySet
+	loadedAt  time.Time
+	expiresAt time.Time
+}
+
+// Expected are the expected issuer and audience of tokens, empty values
+// aren't checked.
+type Expected struct {
+	Issuer   string
+	Audience string
+}
+
+// Get returns the key set read from file, or fetched from url if file is
+// empty. Key sets are shared, so that they are only cached once per source.
+func Get(file, url string, cacheTTL time.Duration) *KeySet {
+	source := file + "|" + url
+
+	keySetsMu.Lock()
+	defer keySetsMu.Unlock()
+
+	keySet, ok := keySets[source]
+	if !ok || keySet.cacheTTL != cacheTTL {
+		keySet = &KeySet{file: file, url: url, cacheTTL: cacheTTL}
+		keySets[source] = keySet
+	}
+
+	return keySet
+}
+
+// IsJWT returns true if the value looks like a token in compact serialization,
+// so that other credentials in the same header can be told apart.
+func IsJWT(value string) bool {
+	return strings.Count(value, ".") == 2
+}
+
+// Verify checks the signature of the token against the key set, validates its
+// expiry, issuer and audience and returns its claims. Tokens without expiry
+// are rejected. The key set is loaded again if it has no key with the key ID
+// of the token, since the keys may have been rotated.
+func (s *KeySet) Verify(rawToken string, expected Expected) (map[string]interface{}, error) {
+	token, err := jwt.ParseSigned(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	keySet, err := s.get(false)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := keySet.Keys
+	if len(token.Headers) > 0 && token.Headers[0].KeyID != "" {
+		keyID := token.Headers[0].KeyID
+		keys = keySet.Key(keyID)
+		if len(keys) == 0 {
+			if keySet, err = s.get(true); err != nil {
+				return nil, err
+			}
+			keys = keySet.Key(keyID)
+		}
+	}
+
+	validation := jwt.Expected{Issuer: expected.Issuer, Time: timeNow()}
+	if expected.Audience != "" {
+		validation.Audience = jwt.Audience{expected.Audience}
+	}
+
+	for _, key := range keys {
+		var registered jwt.Claims
+		claims := map[string]interface{}{}
+		if err := token.Claims(key, &registered, &claims); err != nil {
+			continue
+		}
+
+		if registered.Expiry == nil {
+			return nil, ErrMissingExpiry
+		}
+		if err := registered.Validate(validation); err != nil {
+			return nil, err
+		}
+
+		return claims, nil
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// get returns the cached key set, or loads it if the cache has expired. With
+// refetch, the key set is loaded again unless it has been loaded recently.
+func (s *KeySet) get(refetch bool) (*jose.JSONWebKeySet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := timeNow()
+	if s.keys != nil && now.Before(s.expiresAt) && (!refetch || now.Before(s.loadedAt.Add(minRefetchInterval))) {
+		return s.keys, nil
+	}
+
+	keys, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.keys = keys
+	s.loadedAt = now
+	s.expiresAt = now.Add(s.cacheTTL)
+	return keys, nil
+}
+
+func (s *KeySet) load() (*jose.JSONWebKeySet, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case s.file != "":
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because the path comes from the configuration file.
+		data, err = ioutil.ReadFile(s.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JSON Web Key Set file: %w", err)
+		}
+	case s.url != "":
+		data, err = fetch(s.url)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("neither a JSON Web Key Set file nor URL is configured")
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	if err := json.Unmarshal(data, keys); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Web Key Set: %w", err)
+	}
+
+	return keys, nil
+}
+
+func fetch(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JSON Web Key Set: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JSON Web Key Set: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON Web Key Set: %w", err)
+	}
+
+	return data, nil
+}
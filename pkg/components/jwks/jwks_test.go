@@ -0,0 +1,130 @@
+package jwks
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestKeySet(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	file, err := ioutil.TempFile(os.TempDir(), "jwks-*.json")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	defer os.Remove(file.Name())
+
+	writeKeys := func(keys ...jose.JSONWebKey) {
+		data, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(file.Name(), data, 0600))
+	}
+
+	sign := func(key *rsa.PrivateKey, kid string, claims jwt.Claims) string {
+		opts := &jose.SignerOptions{}
+		if kid != "" {
+			opts = opts.WithHeader("kid", kid)
+		}
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, opts)
+		require.NoError(t, err)
+		token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	valid := jwt.Claims{Subject: "user", Expiry: jwt.NewNumericDate(now.Add(time.Hour))}
+
+	writeKeys(
+		jose.JSONWebKey{Key: key1.Public(), KeyID: "key-1"},
+		jose.JSONWebKey{Key: key2.Public(), KeyID: "key-2"},
+	)
+	keySet := Get(file.Name(), "", time.Hour)
+	require.Same(t, keySet, Get(file.Name(), "", time.Hour))
+
+	t.Run("Should verify a token signed with the key matching its key ID", func(t *testing.T) {
+		claims, err := keySet.Verify(sign(key2, "key-2", valid), Expected{})
+		require.NoError(t, err)
+		assert.Equal(t, "user", claims["sub"])
+	})
+
+	t.Run("Should verify a token without key ID with any key", func(t *testing.T) {
+		_, err := keySet.Verify(sign(key2, "", valid), Expected{})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should not verify a token signed with another key", func(t *testing.T) {
+		_, err := keySet.Verify(sign(key2, "key-1", valid), Expected{})
+		assert.Equal(t, ErrKeyNotFound, err)
+	})
+
+	t.Run("Should not verify an expired token", func(t *testing.T) {
+		expired := jwt.Claims{Subject: "user", Expiry: jwt.NewNumericDate(now.Add(-time.Hour))}
+		_, err := keySet.Verify(sign(key1, "key-1", expired), Expected{})
+		assert.Equal(t, jwt.ErrExpired, err)
+	})
+
+	t.Run("Should not verify a token without expiry", func(t *testing.T) {
+		_, err := keySet.Verify(sign(key1, "key-1", jwt.Claims{Subject: "user"}), Expected{})
+		assert.Equal(t, ErrMissingExpiry, err)
+	})
+
+	t.Run("Should validate the issuer and audience", func(t *testing.T) {
+		claims := valid
+		claims.Issuer = "https://issuer.example.com"
+		claims.Audience = jwt.Audience{"grafana"}
+		token := sign(key1, "key-1", claims)
+
+		_, err := keySet.Verify(token, Expected{Issuer: "https://issuer.example.com", Audience: "grafana"})
+		require.NoError(t, err)
+
+		_, err = keySet.Verify(token, Expected{Issuer: "https://other.example.com"})
+		assert.Equal(t, jwt.ErrInvalidIssuer, err)
+
+		_, err = keySet.Verify(token, Expected{Audience: "other"})
+		assert.Equal(t, jwt.ErrInvalidAudience, err)
+	})
+
+	t.Run("Should load the keys again for an unknown key ID", func(t *testing.T) {
+		key3, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		writeKeys(
+			jose.JSONWebKey{Key: key1.Public(), KeyID: "key-1"},
+			jose.JSONWebKey{Key: key3.Public(), KeyID: "key-3"},
+		)
+
+		_, err = keySet.Verify(sign(key3, "key-3", valid), Expected{})
+		assert.Equal(t, ErrKeyNotFound, err, "keys loaded recently shouldn't be loaded again")
+
+		now = now.Add(2 * minRefetchInterval)
+		_, err = keySet.Verify(sign(key3, "key-3", valid), Expected{})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should use the cached keys until the cache expires", func(t *testing.T) {
+		writeKeys(jose.JSONWebKey{Key: key2.Public(), KeyID: "key-2"})
+
+		_, err := keySet.Verify(sign(key1, "key-1", valid), Expected{})
+		require.NoError(t, err)
+
+		now = now.Add(2 * time.Hour)
+		valid.Expiry = jwt.NewNumericDate(now.Add(time.Hour))
+		_, err = keySet.Verify(sign(key1, "key-1", valid), Expected{})
+		assert.Equal(t, ErrKeyNotFound, err)
+	})
+}
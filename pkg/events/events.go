@@ -89,3 +89,19 @@ type UserUpdated struct {
 	Login     string    `json:"login"`
 	Email     string    `json:"email"`
 }
+
+type DashboardViewed struct {
+	Timestamp   time.Time `json:"timestamp"`
+	OrgId       int64     `json:"org_id"`
+	DashboardId int64     `json:"dashboard_id"`
+	UserId      int64     `json:"user_id"`
+}
+
+type DashboardQueried struct {
+	Timestamp   time.Time `json:"timestamp"`
+	OrgId       int64     `json:"org_id"`
+	DashboardId int64     `json:"dashboard_id"`
+	UserId      int64     `json:"user_id"`
+	Queries     int64     `json:"queries"`
+	Errors      int64     `json:"errors"`
+}
@@ -63,6 +63,9 @@ var (
 	// MApiOrgCreate is a metric api org created counter
 	MApiOrgCreate prometheus.Counter
 
+	// MApiRateLimited is a metric counter for api requests rejected by the rate limiter, labeled by route group
+	MApiRateLimited *prometheus.CounterVec
+
 	// MApiDashboardSnapshotCreate is a metric dashboard snapshots created
 	MApiDashboardSnapshotCreate prometheus.Counter
 
@@ -288,6 +291,12 @@ func init() {
 		Namespace: ExporterName,
 	})
 
+	MApiRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "api_rate_limited_total",
+		Help:      "api requests rejected by the rate limiter",
+		Namespace: ExporterName,
+	}, []string{"group"})
+
 	MApiDashboardSnapshotCreate = newCounterStartingAtZero(prometheus.CounterOpts{
 		Name:      "api_dashboard_snapshot_create_total",
 		Help:      "dashboard snapshots created",
@@ -538,6 +547,7 @@ func initMetricVars() {
 		MApiLoginOAuth,
 		MApiLoginSAML,
 		MApiOrgCreate,
+		MApiRateLimited,
 		MApiDashboardSnapshotCreate,
 		MApiDashboardSnapshotExternal,
 		MApiDashboardSnapshotGet,
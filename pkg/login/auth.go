@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/mfa"
 )
 
 var (
@@ -40,6 +41,14 @@ func AuthenticateUser(query *models.LoginUserQuery) error {
 	}
 
 	err := loginUsingGrafanaDB(query)
+	if err == mfa.ErrInvalidCode {
+		if err := saveInvalidLoginAttempt(query); err != nil {
+			loginLogger.Error("Failed to save invalid login attempt", "err", err)
+		}
+
+		return err
+	}
+
 	if err == nil || (err != models.ErrUserNotFound && err != ErrInvalidCredentials && err != ErrUserDisabled) {
 		return err
 	}
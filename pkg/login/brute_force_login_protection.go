@@ -8,10 +8,7 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 )
 
-var (
-	maxInvalidLoginAttempts int64 = 5
-	loginAttemptsWindow           = time.Minute * 5
-)
+var getTime = time.Now
 
 var validateLoginAttempts = func(username string) error {
 	if setting.DisableBruteForceLoginProtection {
@@ -20,14 +17,14 @@ var validateLoginAttempts = func(username string) error {
 
 	loginAttemptCountQuery := models.GetUserLoginAttemptCountQuery{
 		Username: username,
-		Since:    time.Now().Add(-loginAttemptsWindow),
+		Since:    getTime().Add(-setting.LoginLockoutDuration),
 	}
 
 	if err := bus.Dispatch(&loginAttemptCountQuery); err != nil {
 		return err
 	}
 
-	if loginAttemptCountQuery.Result >= maxInvalidLoginAttempts {
+	if loginAttemptCountQuery.Result >= setting.LoginLockoutAttempts {
 		return ErrTooManyLoginAttempts
 	}
 
@@ -46,3 +43,52 @@ var saveInvalidLoginAttempt = func(query *models.LoginUserQuery) error {
 
 	return bus.Dispatch(&loginAttemptCommand)
 }
+
+// GetLockedUsers returns the usernames that currently can't log in because
+// of too many failed login attempts.
+func GetLockedUsers() ([]*models.LockedUser, error) {
+	lockedUsers := make([]*models.LockedUser, 0)
+	if setting.DisableBruteForceLoginProtection {
+		return lockedUsers, nil
+	}
+
+	query := models.GetLoginAttemptsQuery{Since: getTime().Add(-setting.LoginLockoutDuration)}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	// attempts are ordered by username and newest first
+	attempts := make(map[string][]*models.LoginAttempt)
+	var usernames []string
+	for _, attempt := range query.Result {
+		if _, exists := attempts[attempt.Username]; !exists {
+			usernames = append(usernames, attempt.Username)
+		}
+		attempts[attempt.Username] = append(attempts[attempt.Username], attempt)
+	}
+
+	for _, username := range usernames {
+		userAttempts := attempts[username]
+		if int64(len(userAttempts)) < setting.LoginLockoutAttempts {
+			continue
+		}
+
+		// the user can log in again once fewer than the allowed number of
+		// attempts are within the lockout duration
+		unlockingAttempt := userAttempts[setting.LoginLockoutAttempts-1]
+		lockedUsers = append(lockedUsers, &models.LockedUser{
+			Username:    username,
+			Attempts:    int64(len(userAttempts)),
+			LastAttempt: time.Unix(userAttempts[0].Created, 0),
+			LockedUntil: time.Unix(unlockingAttempt.Created, 0).Add(setting.LoginLockoutDuration),
+		})
+	}
+
+	return lockedUsers, nil
+}
+
+// UnlockUser removes the failed login attempts of the username, so that the
+// user can log in again immediately.
+func UnlockUser(username string) error {
+	return bus.Dispatch(&models.DeleteUserLoginAttemptsCommand{Username: username})
+}
@@ -2,6 +2,7 @@ package login
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
@@ -11,11 +12,14 @@ import (
 
 func TestLoginAttemptsValidation(t *testing.T) {
 	Convey("Validate login attempts", t, func() {
+		setting.LoginLockoutAttempts = 5
+		setting.LoginLockoutDuration = 5 * time.Minute
+
 		Convey("Given brute force login protection enabled", func() {
 			setting.DisableBruteForceLoginProtection = false
 
 			Convey("When user login attempt count equals max-1 ", func() {
-				withLoginAttempts(maxInvalidLoginAttempts - 1)
+				withLoginAttempts(setting.LoginLockoutAttempts - 1)
 				err := validateLoginAttempts("user")
 
 				Convey("it should not result in error", func() {
@@ -24,7 +28,7 @@ func TestLoginAttemptsValidation(t *testing.T) {
 			})
 
 			Convey("When user login attempt count equals max ", func() {
-				withLoginAttempts(maxInvalidLoginAttempts)
+				withLoginAttempts(setting.LoginLockoutAttempts)
 				err := validateLoginAttempts("user")
 
 				Convey("it should result in too many login attempts error", func() {
@@ -33,7 +37,7 @@ func TestLoginAttemptsValidation(t *testing.T) {
 			})
 
 			Convey("When user login attempt count is greater than max ", func() {
-				withLoginAttempts(maxInvalidLoginAttempts + 5)
+				withLoginAttempts(setting.LoginLockoutAttempts + 5)
 				err := validateLoginAttempts("user")
 
 				Convey("it should result in too many login attempts error", func() {
@@ -69,7 +73,7 @@ func TestLoginAttemptsValidation(t *testing.T) {
 			setting.DisableBruteForceLoginProtection = true
 
 			Convey("When user login attempt count equals max-1 ", func() {
-				withLoginAttempts(maxInvalidLoginAttempts - 1)
+				withLoginAttempts(setting.LoginLockoutAttempts - 1)
 				err := validateLoginAttempts("user")
 
 				Convey("it should not result in error", func() {
@@ -78,7 +82,7 @@ func TestLoginAttemptsValidation(t *testing.T) {
 			})
 
 			Convey("When user login attempt count equals max ", func() {
-				withLoginAttempts(maxInvalidLoginAttempts)
+				withLoginAttempts(setting.LoginLockoutAttempts)
 				err := validateLoginAttempts("user")
 
 				Convey("it should not result in error", func() {
@@ -87,7 +91,7 @@ func TestLoginAttemptsValidation(t *testing.T) {
 			})
 
 			Convey("When user login attempt count is greater than max ", func() {
-				withLoginAttempts(maxInvalidLoginAttempts + 5)
+				withLoginAttempts(setting.LoginLockoutAttempts + 5)
 				err := validateLoginAttempts("user")
 
 				Convey("it should not result in error", func() {
@@ -119,6 +123,49 @@ func TestLoginAttemptsValidation(t *testing.T) {
 	})
 }
 
+func TestGetLockedUsers(t *testing.T) {
+	Convey("Get locked users", t, func() {
+		setting.DisableBruteForceLoginProtection = false
+		setting.LoginLockoutAttempts = 3
+		setting.LoginLockoutDuration = 5 * time.Minute
+
+		now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+		getTime = func() time.Time { return now }
+		defer func() { getTime = time.Now }()
+		defer bus.ClearBusHandlers()
+
+		var since time.Time
+		bus.AddHandler("test", func(query *models.GetLoginAttemptsQuery) error {
+			since = query.Since
+			minute := func(m int) int64 { return now.Add(time.Duration(-m) * time.Minute).Unix() }
+			query.Result = []*models.LoginAttempt{
+				{Username: "locked", Created: minute(1)},
+				{Username: "locked", Created: minute(2)},
+				{Username: "locked", Created: minute(3)},
+				{Username: "locked", Created: minute(4)},
+				{Username: "other", Created: minute(1)},
+				{Username: "other", Created: minute(2)},
+			}
+			return nil
+		})
+
+		lockedUsers, err := GetLockedUsers()
+		So(err, ShouldBeNil)
+		So(since, ShouldEqual, now.Add(-5*time.Minute))
+
+		Convey("it should only return users with too many attempts", func() {
+			So(lockedUsers, ShouldHaveLength, 1)
+			So(lockedUsers[0].Username, ShouldEqual, "locked")
+			So(lockedUsers[0].Attempts, ShouldEqual, 4)
+			So(lockedUsers[0].LastAttempt.Unix(), ShouldEqual, now.Add(-time.Minute).Unix())
+		})
+
+		Convey("it should return when the user can log in again", func() {
+			So(lockedUsers[0].LockedUntil.Unix(), ShouldEqual, now.Add(2*time.Minute).Unix())
+		})
+	})
+}
+
 func withLoginAttempts(loginAttempts int64) {
 	bus.AddHandler("test", func(query *models.GetUserLoginAttemptCountQuery) error {
 		query.Result = loginAttempts
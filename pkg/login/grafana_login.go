@@ -5,6 +5,8 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -20,6 +22,14 @@ var validatePassword = func(providedPassword string, userPassword string, userSa
 	return nil
 }
 
+var validatePasswordAge = passwordpolicy.ValidateAge
+
+// expiredPasswordCodeValidMinutes is how long the code returned for an
+// expired password can be used to set a new one.
+const expiredPasswordCodeValidMinutes = 10
+
+var validateMfa = mfa.ValidateLogin
+
 var loginUsingGrafanaDB = func(query *models.LoginUserQuery) error {
 	userQuery := models.GetUserByLoginQuery{LoginOrEmail: query.Username}
 
@@ -37,6 +47,26 @@ var loginUsingGrafanaDB = func(query *models.LoginUserQuery) error {
 		return err
 	}
 
+	if err := validateMfa(query, user); err != nil {
+		return err
+	}
+
+	if err := validatePasswordAge(user); err != nil {
+		if err != passwordpolicy.ErrPasswordExpired {
+			return err
+		}
+
+		// the user has proven both factors, so they can set a new password
+		// without a reset email
+		cmd := models.CreateResetPasswordCodeCommand{User: user, ValidMinutes: expiredPasswordCodeValidMinutes}
+		if err := bus.Dispatch(&cmd); err != nil {
+			return err
+		}
+		query.PasswordResetCode = cmd.Result
+		return err
+	}
+
 	query.User = user
+	query.AuthModule = models.AuthModuleGrafana
 	return nil
 }
@@ -7,6 +7,8 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 )
 
 func TestGrafanaLogin(t *testing.T) {
@@ -64,6 +66,42 @@ func TestGrafanaLogin(t *testing.T) {
 			})
 		})
 
+		grafanaLoginScenario("When login with expired password", func(sc *grafanaLoginScenarioContext) {
+			sc.withValidCredentials()
+			sc.withExpiredPassword()
+			err := loginUsingGrafanaDB(sc.loginUserQuery)
+
+			Convey("it should return password expired error", func() {
+				So(err, ShouldEqual, passwordpolicy.ErrPasswordExpired)
+			})
+
+			Convey("it should return a short-lived code to set a new password", func() {
+				So(sc.loginUserQuery.PasswordResetCode, ShouldEqual, "reset-code")
+				So(sc.resetCodeValidMinutes, ShouldEqual, expiredPasswordCodeValidMinutes)
+			})
+
+			Convey("it should not populate user object", func() {
+				So(sc.loginUserQuery.User, ShouldBeNil)
+			})
+		})
+
+		grafanaLoginScenario("When login with expired password without two-factor code", func(sc *grafanaLoginScenarioContext) {
+			sc.withValidCredentials()
+			sc.withExpiredPassword()
+			validateMfa = func(query *models.LoginUserQuery, user *models.User) error {
+				return mfa.ErrCodeRequired
+			}
+			err := loginUsingGrafanaDB(sc.loginUserQuery)
+
+			Convey("it should require the two-factor code first", func() {
+				So(err, ShouldEqual, mfa.ErrCodeRequired)
+			})
+
+			Convey("it should not return a code to set a new password", func() {
+				So(sc.loginUserQuery.PasswordResetCode, ShouldBeEmpty)
+			})
+		})
+
 		grafanaLoginScenario("When login with disabled user", func(sc *grafanaLoginScenarioContext) {
 			sc.withDisabledUser()
 			err := loginUsingGrafanaDB(sc.loginUserQuery)
@@ -86,6 +124,7 @@ func TestGrafanaLogin(t *testing.T) {
 type grafanaLoginScenarioContext struct {
 	loginUserQuery         *models.LoginUserQuery
 	validatePasswordCalled bool
+	resetCodeValidMinutes  int
 }
 
 type grafanaLoginScenarioFunc func(c *grafanaLoginScenarioContext)
@@ -93,6 +132,8 @@ type grafanaLoginScenarioFunc func(c *grafanaLoginScenarioContext)
 func grafanaLoginScenario(desc string, fn grafanaLoginScenarioFunc) {
 	Convey(desc, func() {
 		origValidatePassword := validatePassword
+		origValidatePasswordAge := validatePasswordAge
+		origValidateMfa := validateMfa
 
 		sc := &grafanaLoginScenarioContext{
 			loginUserQuery: &models.LoginUserQuery{
@@ -105,6 +146,8 @@ func grafanaLoginScenario(desc string, fn grafanaLoginScenarioFunc) {
 
 		defer func() {
 			validatePassword = origValidatePassword
+			validatePasswordAge = origValidatePasswordAge
+			validateMfa = origValidateMfa
 		}()
 
 		fn(sc)
@@ -144,6 +187,17 @@ func (sc *grafanaLoginScenarioContext) withValidCredentials() {
 	mockPasswordValidation(true, sc)
 }
 
+func (sc *grafanaLoginScenarioContext) withExpiredPassword() {
+	validatePasswordAge = func(user *models.User) error {
+		return passwordpolicy.ErrPasswordExpired
+	}
+	bus.AddHandler("test", func(cmd *models.CreateResetPasswordCodeCommand) error {
+		sc.resetCodeValidMinutes = cmd.ValidMinutes
+		cmd.Result = "reset-code"
+		return nil
+	})
+}
+
 func (sc *grafanaLoginScenarioContext) withNonExistingUser() {
 	sc.getUserByLoginQueryReturns(nil)
 }
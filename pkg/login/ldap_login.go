@@ -60,6 +60,7 @@ var loginUsingLDAP = func(query *models.LoginUserQuery) (bool, error) {
 		return true, err
 	}
 	query.User = upsert.Result
+	query.AuthModule = models.AuthModuleLDAP
 
 	return true, nil
 }
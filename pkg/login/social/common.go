@@ -70,23 +70,32 @@ func HttpGet(client *http.Client, url string) (response HttpGetResponse, err err
 	return
 }
 
-func (s *SocialBase) searchJSONForAttr(attributePath string, data []byte) (string, error) {
+func (s *SocialBase) searchJSON(attributePath string, data []byte) (interface{}, error) {
 	if attributePath == "" {
-		return "", errors.New("no attribute path specified")
+		return nil, errors.New("no attribute path specified")
 	}
 
 	if len(data) == 0 {
-		return "", errors.New("empty user info JSON response provided")
+		return nil, errors.New("empty user info JSON response provided")
 	}
 
 	var buf interface{}
 	if err := json.Unmarshal(data, &buf); err != nil {
-		return "", errutil.Wrap("failed to unmarshal user info JSON response", err)
+		return nil, errutil.Wrap("failed to unmarshal user info JSON response", err)
 	}
 
 	val, err := jmespath.Search(attributePath, buf)
 	if err != nil {
-		return "", errutil.Wrapf(err, "failed to search user info JSON response with provided path: %q", attributePath)
+		return nil, errutil.Wrapf(err, "failed to search user info JSON response with provided path: %q", attributePath)
+	}
+
+	return val, nil
+}
+
+func (s *SocialBase) searchJSONForAttr(attributePath string, data []byte) (string, error) {
+	val, err := s.searchJSON(attributePath, data)
+	if err != nil {
+		return "", err
 	}
 
 	strVal, ok := val.(string)
@@ -96,3 +105,24 @@ func (s *SocialBase) searchJSONForAttr(attributePath string, data []byte) (strin
 
 	return "", nil
 }
+
+func (s *SocialBase) searchJSONForStringArrayAttr(attributePath string, data []byte) ([]string, error) {
+	val, err := s.searchJSON(attributePath, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ifArr, ok := val.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	result := []string{}
+	for _, v := range ifArr {
+		if strVal, ok := v.(string); ok {
+			result = append(result, strVal)
+		}
+	}
+
+	return result, nil
+}
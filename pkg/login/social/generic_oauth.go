@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/mail"
 	"regexp"
+	"strconv"
 
 	"github.com/grafana/grafana/pkg/util/errutil"
 
@@ -15,14 +16,25 @@ import (
 	"golang.org/x/oauth2"
 )
 
+const (
+	userInfoSourceBoth    = "both"
+	userInfoSourceIdToken = "id_token"
+	userInfoSourceAPI     = "api"
+)
+
 type SocialGenericOAuth struct {
 	*SocialBase
-	allowedOrganizations []string
-	apiUrl               string
-	emailAttributeName   string
-	emailAttributePath   string
-	roleAttributePath    string
-	teamIds              []int
+	allowedOrganizations  []string
+	apiUrl                string
+	emailAttributeName    string
+	emailAttributePath    string
+	loginAttributePath    string
+	nameAttributePath     string
+	roleAttributePath     string
+	groupsAttributePath   string
+	orgRolesAttributePath string
+	userInfoSource        string
+	teamIds               []int
 }
 
 func (s *SocialGenericOAuth) Type() int {
@@ -94,11 +106,11 @@ func (s *SocialGenericOAuth) UserInfo(client *http.Client, token *oauth2.Token)
 
 	userInfo := &BasicUserInfo{}
 
-	if s.extractToken(&data, token) {
+	if s.useIdToken() && s.extractToken(&data, token) {
 		s.fillUserInfo(userInfo, &data)
 	}
 
-	if s.extractAPI(&data, client) {
+	if s.useAPI() && s.extractAPI(&data, client) {
 		s.fillUserInfo(userInfo, &data)
 	}
 
@@ -143,6 +155,32 @@ func (s *SocialGenericOAuth) fillUserInfo(userInfo *BasicUserInfo, data *UserInf
 	if userInfo.Login == "" {
 		userInfo.Login = s.extractLogin(data)
 	}
+	if len(userInfo.Groups) == 0 {
+		groups, err := s.extractGroups(data)
+		if err != nil {
+			s.log.Error("Failed to extract groups", "error", err)
+		} else {
+			userInfo.Groups = groups
+		}
+	}
+	if len(userInfo.OrgRoles) == 0 {
+		orgRoles, err := s.extractOrgRoles(data)
+		if err != nil {
+			s.log.Error("Failed to extract org roles", "error", err)
+		} else {
+			userInfo.OrgRoles = orgRoles
+		}
+	}
+}
+
+// useIdToken reports whether user info should be read from the id_token.
+func (s *SocialGenericOAuth) useIdToken() bool {
+	return s.userInfoSource != userInfoSourceAPI
+}
+
+// useAPI reports whether user info should be read from the user info API.
+func (s *SocialGenericOAuth) useAPI() bool {
+	return s.userInfoSource != userInfoSourceIdToken
 }
 
 func (s *SocialGenericOAuth) extractToken(data *UserInfoJson, token *oauth2.Token) bool {
@@ -239,7 +277,63 @@ func (s *SocialGenericOAuth) extractRole(data *UserInfoJson) (string, error) {
 	return role, nil
 }
 
+func (s *SocialGenericOAuth) extractGroups(data *UserInfoJson) ([]string, error) {
+	if s.groupsAttributePath == "" {
+		return nil, nil
+	}
+
+	return s.searchJSONForStringArrayAttr(s.groupsAttributePath, data.rawJSON)
+}
+
+// extractOrgRoles evaluates the org roles attribute path, which is expected
+// to result in an object mapping org ids to role names, e.g. {"1": "Admin", "2": "Viewer"}.
+func (s *SocialGenericOAuth) extractOrgRoles(data *UserInfoJson) (map[int64]string, error) {
+	if s.orgRolesAttributePath == "" {
+		return nil, nil
+	}
+
+	val, err := s.searchJSON(s.orgRolesAttributePath, data.rawJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if val == nil {
+		return nil, nil
+	}
+
+	mapping, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("org roles attribute path %q did not result in an object", s.orgRolesAttributePath)
+	}
+
+	orgRoles := make(map[int64]string, len(mapping))
+	for key, value := range mapping {
+		orgID, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, errutil.Wrapf(err, "invalid org id %q in org roles", key)
+		}
+
+		role, ok := value.(string)
+		if !ok || role == "" {
+			continue
+		}
+
+		orgRoles[orgID] = role
+	}
+
+	return orgRoles, nil
+}
+
 func (s *SocialGenericOAuth) extractLogin(data *UserInfoJson) string {
+	if s.loginAttributePath != "" {
+		login, err := s.searchJSONForAttr(s.loginAttributePath, data.rawJSON)
+		if err != nil {
+			s.log.Error("Failed to search JSON for login attribute", "error", err)
+		} else if login != "" {
+			return login
+		}
+	}
+
 	if data.Login != "" {
 		return data.Login
 	}
@@ -252,6 +346,15 @@ func (s *SocialGenericOAuth) extractLogin(data *UserInfoJson) string {
 }
 
 func (s *SocialGenericOAuth) extractName(data *UserInfoJson) string {
+	if s.nameAttributePath != "" {
+		name, err := s.searchJSONForAttr(s.nameAttributePath, data.rawJSON)
+		if err != nil {
+			s.log.Error("Failed to search JSON for name attribute", "error", err)
+		} else if name != "" {
+			return name
+		}
+	}
+
 	if data.Name != "" {
 		return data.Name
 	}
@@ -21,6 +21,10 @@ type BasicUserInfo struct {
 	Company string
 	Role    string
 	Groups  []string
+
+	// OrgRoles maps org ids to roles, for providers that can assign
+	// different roles in different orgs.
+	OrgRoles map[int64]string
 }
 
 type SocialConnector interface {
@@ -169,13 +173,18 @@ func NewOAuthService() {
 		// Generic - Uses the same scheme as Github.
 		if name == "generic_oauth" {
 			SocialMap["generic_oauth"] = &SocialGenericOAuth{
-				SocialBase:           newSocialBase(name, &config, info),
-				apiUrl:               info.ApiUrl,
-				emailAttributeName:   info.EmailAttributeName,
-				emailAttributePath:   info.EmailAttributePath,
-				roleAttributePath:    info.RoleAttributePath,
-				teamIds:              sec.Key("team_ids").Ints(","),
-				allowedOrganizations: util.SplitString(sec.Key("allowed_organizations").String()),
+				SocialBase:            newSocialBase(name, &config, info),
+				apiUrl:                info.ApiUrl,
+				emailAttributeName:    info.EmailAttributeName,
+				emailAttributePath:    info.EmailAttributePath,
+				loginAttributePath:    sec.Key("login_attribute_path").String(),
+				nameAttributePath:     sec.Key("name_attribute_path").String(),
+				roleAttributePath:     info.RoleAttributePath,
+				groupsAttributePath:   sec.Key("groups_attribute_path").String(),
+				orgRolesAttributePath: sec.Key("org_roles_attribute_path").String(),
+				userInfoSource:        sec.Key("user_info_source").In(userInfoSourceBoth, []string{userInfoSourceBoth, userInfoSourceIdToken, userInfoSourceAPI}),
+				teamIds:               sec.Key("team_ids").Ints(","),
+				allowedOrganizations:  util.SplitString(sec.Key("allowed_organizations").String()),
 			}
 		}
 
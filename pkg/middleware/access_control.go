@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// Authorize returns a function that creates a middleware requiring the
+// signed in user to have been granted at least one of the actions. When
+// access control is disabled the fallback handler, usually one of the
+// built-in role checks, is used instead.
+func Authorize(ac accesscontrol.AccessControl) func(fallback macaron.Handler, actions ...string) macaron.Handler {
+	return func(fallback macaron.Handler, actions ...string) macaron.Handler {
+		if ac == nil || ac.IsDisabled() {
+			return fallback
+		}
+
+		return func(c *models.ReqContext) {
+			for _, action := range actions {
+				granted, err := ac.Evaluate(c.Req.Context(), c.SignedInUser, action)
+				if err != nil {
+					c.Logger.Error("Failed to evaluate permission", "action", action, "error", err)
+					c.JsonApiErr(500, "Failed to evaluate permissions", err)
+					return
+				}
+
+				if granted {
+					return
+				}
+			}
+
+			accessForbidden(c)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+type fakeAccessControl struct {
+	disabled bool
+	granted  []string
+}
+
+func (f *fakeAccessControl) IsDisabled() bool {
+	return f.disabled
+}
+
+func (f *fakeAccessControl) Evaluate(ctx context.Context, user *models.SignedInUser, action string) (bool, error) {
+	for _, a := range f.granted {
+		if a == action {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeAccessControl) GetUserPermissions(ctx context.Context, user *models.SignedInUser) ([]string, error) {
+	return f.granted, nil
+}
+
+func TestMiddlewareAuthorize(t *testing.T) {
+	Convey("Given the authorize middleware", t, func() {
+		denyAll := func(c *models.ReqContext) {
+			accessForbidden(c)
+		}
+
+		middlewareScenario(t, "Access control disabled should use fallback", func(sc *scenarioContext) {
+			authorize := Authorize(&fakeAccessControl{disabled: true, granted: []string{"teams:write"}})
+			sc.m.Get("/api/secure", authorize(denyAll, "teams:write"), sc.defaultHandler)
+			sc.fakeReq("GET", "/api/secure").exec()
+			So(sc.resp.Code, ShouldEqual, 403)
+		})
+
+		middlewareScenario(t, "Access control enabled and action granted should return 200", func(sc *scenarioContext) {
+			authorize := Authorize(&fakeAccessControl{granted: []string{"teams:create"}})
+			sc.m.Get("/api/secure", authorize(denyAll, "teams:write", "teams:create"), sc.defaultHandler)
+			sc.fakeReq("GET", "/api/secure").exec()
+			So(sc.resp.Code, ShouldEqual, 200)
+		})
+
+		middlewareScenario(t, "Access control enabled and action not granted should return 403", func(sc *scenarioContext) {
+			authorize := Authorize(&fakeAccessControl{granted: []string{"teams:create"}})
+			sc.m.Get("/api/secure", authorize(sc.defaultHandler, "teams:write"), sc.defaultHandler)
+			sc.fakeReq("GET", "/api/secure").exec()
+			So(sc.resp.Code, ShouldEqual, 403)
+		})
+	})
+}
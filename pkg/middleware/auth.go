@@ -1,12 +1,21 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jmespath/go-jmespath"
 	macaron "gopkg.in/macaron.v1"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/jwks"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	authproxy "github.com/grafana/grafana/pkg/middleware/auth_proxy"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -33,6 +42,170 @@ func getApiKey(c *models.ReqContext) string {
 	return ""
 }
 
+func getJWT(c *models.ReqContext) string {
+	token := c.Req.Header.Get(setting.JWTAuthHeaderName)
+	if token == "" && setting.JWTAuthURLParamName != "" {
+		token = c.Query(setting.JWTAuthURLParamName)
+	}
+
+	return strings.TrimPrefix(token, "Bearer ")
+}
+
+// jwtUserCachePrefix is the prefix of the cache keys of the ids of the users
+// signed in with a JWT, by the synced claims of the token.
+const jwtUserCachePrefix = "jwt-user:%s"
+
+// initContextWithJWT signs the user in with a JWT from the configured header
+// or URL parameter, after verifying it against the configured key set. The
+// user is synced once and then cached by the synced claims of the token until
+// the token expires, so that a token with another role is synced again.
+func initContextWithJWT(store *remotecache.RemoteCache, ctx *models.ReqContext, orgID int64) bool {
+	if !setting.JWTAuthEnabled {
+		return false
+	}
+
+	token := getJWT(ctx)
+	if token == "" || !jwks.IsJWT(token) {
+		return false
+	}
+
+	keySet := jwks.Get(setting.JWTAuthJWKSetFile, setting.JWTAuthJWKSetURL, setting.JWTAuthCacheTTL)
+	claims, err := keySet.Verify(token, jwks.Expected{
+		Issuer:   setting.JWTAuthExpectedIssuer,
+		Audience: setting.JWTAuthExpectedAudience,
+	})
+	if err != nil {
+		ctx.Logger.Debug("Failed to verify JWT", "error", err)
+		ctx.JsonApiErr(401, "Invalid JWT", err)
+		return true
+	}
+
+	extUser, err := jwtClaimsToExternalUser(claims)
+	if err != nil {
+		ctx.JsonApiErr(401, "Invalid JWT", err)
+		return true
+	}
+
+	cacheKey := jwtUserCacheKey(extUser)
+	if cached, err := store.Get(cacheKey); err == nil {
+		if userID, ok := cached.(int64); ok {
+			userQuery := models.GetUserByIdQuery{Id: userID}
+			if err := bus.Dispatch(&userQuery); err == nil {
+				if userQuery.Result.IsDisabled {
+					ctx.JsonApiErr(401, "User is disabled", nil)
+					return true
+				}
+
+				query := models.GetSignedInUserQuery{UserId: userID, OrgId: orgID}
+				if err := bus.Dispatch(&query); err == nil {
+					ctx.SignedInUser = query.Result
+					ctx.IsSignedIn = true
+					return true
+				}
+			}
+
+			// the user may have been deleted since it was cached
+			ctx.Logger.Debug("Failed to get cached JWT user, syncing it again", "userId", userID)
+		}
+	}
+
+	upsert := &models.UpsertUserCommand{
+		ReqContext:    ctx,
+		SignupAllowed: setting.JWTAuthAutoSignUp,
+		ExternalUser:  extUser,
+	}
+	if err := bus.Dispatch(upsert); err != nil {
+		ctx.Logger.Debug("Failed to sign in user with JWT", "login", extUser.Login, "error", err)
+		ctx.JsonApiErr(401, "Failed to sign in user with JWT", err)
+		return true
+	}
+
+	if upsert.Result.IsDisabled {
+		ctx.JsonApiErr(401, "User is disabled", nil)
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{UserId: upsert.Result.Id, OrgId: orgID}
+	if err := bus.Dispatch(&query); err != nil {
+		ctx.Logger.Error("Failed to get user with id", "userId", upsert.Result.Id, "error", err)
+		ctx.JsonApiErr(401, "Failed to sign in user with JWT", err)
+		return true
+	}
+
+	// tokens without expiry are rejected when they're verified
+	expiry, _ := claims["exp"].(float64)
+	if ttl := time.Until(time.Unix(int64(expiry), 0)); ttl > 0 {
+		if err := store.Set(cacheKey, upsert.Result.Id, ttl); err != nil {
+			ctx.Logger.Warn("Failed to cache JWT user", "userId", upsert.Result.Id, "error", err)
+		}
+	}
+
+	ctx.SignedInUser = query.Result
+	ctx.IsSignedIn = true
+	return true
+}
+
+// jwtUserCacheKey forms the cache key of a user signed in with a JWT from the
+// claims that are synced to the user, so that changing any of them syncs the
+// user again.
+func jwtUserCacheKey(extUser *models.ExternalUserInfo) string {
+	key := strings.Join([]string{extUser.AuthId, extUser.Login, extUser.Email, extUser.Name}, "-")
+
+	orgIDs := make([]int64, 0, len(extUser.OrgRoles))
+	for orgID := range extUser.OrgRoles {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+	for _, orgID := range orgIDs {
+		key = strings.Join([]string{key, strconv.FormatInt(orgID, 10), string(extUser.OrgRoles[orgID])}, "-")
+	}
+
+	return fmt.Sprintf(jwtUserCachePrefix, authproxy.HashCacheKey(key))
+}
+
+func jwtClaimsToExternalUser(claims map[string]interface{}) (*models.ExternalUserInfo, error) {
+	login, _ := claims[setting.JWTAuthLoginClaim].(string)
+	email, _ := claims[setting.JWTAuthEmailClaim].(string)
+	if login == "" {
+		login = email
+	}
+	if login == "" {
+		return nil, errors.New("JWT has neither a login nor an email claim")
+	}
+
+	authID, _ := claims["sub"].(string)
+	if authID == "" {
+		authID = login
+	}
+
+	extUser := &models.ExternalUserInfo{
+		AuthModule: models.AuthModuleJWT,
+		AuthId:     authID,
+		Login:      login,
+		Email:      email,
+		OrgRoles:   map[int64]models.RoleType{},
+	}
+	extUser.Name, _ = claims["name"].(string)
+
+	if setting.JWTAuthRoleAttributePath != "" {
+		value, err := jmespath.Search(setting.JWTAuthRoleAttributePath, claims)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search JWT claims for role: %w", err)
+		}
+
+		role, _ := value.(string)
+		if rt := models.RoleType(role); rt.IsValid() {
+			orgID := int64(1)
+			if setting.AutoAssignOrg && setting.AutoAssignOrgId > 0 {
+				orgID = int64(setting.AutoAssignOrgId)
+			}
+			extUser.OrgRoles[orgID] = rt
+		}
+	}
+
+	return extUser, nil
+}
+
 func accessForbidden(c *models.ReqContext) {
 	if c.IsApiRequest() {
 		c.JsonApiErr(403, "Permission denied", nil)
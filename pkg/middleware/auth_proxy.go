@@ -55,6 +55,12 @@ func initContextWithAuthProxy(store *remotecache.RemoteCache, ctx *models.ReqCon
 		return true
 	}
 
+	if err := auth.VerifyAssertion(); err != nil {
+		logger.Error("Failed to verify auth proxy assertion", "message", err.Error(), "error", err.DetailsError)
+		ctx.Handle(407, err.Error(), err.DetailsError)
+		return true
+	}
+
 	id, err := logUserIn(auth, username, logger, false)
 	if err != nil {
 		ctx.Handle(407, err.Error(), err.DetailsError)
@@ -6,11 +6,14 @@ import (
 	"hash/fnv"
 	"net"
 	"net/mail"
+	"net/textproto"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/jwks"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
@@ -36,7 +39,12 @@ var isLDAPEnabled = ldap.IsEnabled
 var newLDAP = multildap.New
 
 // supportedHeaders states the supported headers configuration fields
-var supportedHeaderFields = []string{"Name", "Email", "Login", "Groups"}
+var supportedHeaderFields = []string{"Name", "Email", "Login", "Groups", "Role", "Teams"}
+
+// assertionKeySetCacheTTL is how long the key set used to verify assertions is cached
+const assertionKeySetCacheTTL = time.Hour
+
+var logger = log.New("auth.proxy")
 
 // AuthProxy struct
 type AuthProxy struct {
@@ -52,6 +60,8 @@ type AuthProxy struct {
 	headerType          string
 	headers             map[string]string
 	cacheTTL            int
+	assertionHeader     string
+	claims              map[string]interface{}
 }
 
 // Error auth proxy specific error
@@ -97,6 +107,7 @@ func New(options *Options) *AuthProxy {
 		cacheTTL:            setting.AuthProxySyncTtl,
 		LDAPAllowSignup:     setting.LDAPAllowSignup,
 		AuthProxyAutoSignUp: setting.AuthProxyAutoSignUp,
+		assertionHeader:     setting.AuthProxyJWTAssertionHeader,
 	}
 }
 
@@ -108,9 +119,73 @@ func (auth *AuthProxy) IsEnabled() bool {
 
 // HasHeader checks if the we have specified header
 func (auth *AuthProxy) HasHeader() bool {
+	if auth.assertionHeader != "" {
+		return auth.ctx.Req.Header.Get(auth.assertionHeader) != ""
+	}
+
 	return len(auth.header) != 0
 }
 
+// VerifyAssertion verifies the signed JWT sent by the proxy, if configured.
+// The user and the additional headers are then read from its claims instead
+// of the request headers.
+func (auth *AuthProxy) VerifyAssertion() *Error {
+	if auth.assertionHeader == "" {
+		return nil
+	}
+
+	keySet := jwks.Get(setting.AuthProxyJWKSetFile, setting.AuthProxyJWKSetURL, assertionKeySetCacheTTL)
+	claims, err := keySet.Verify(auth.ctx.Req.Header.Get(auth.assertionHeader), jwks.Expected{
+		Issuer:   setting.AuthProxyJWTExpectedIssuer,
+		Audience: setting.AuthProxyJWTExpectedAudience,
+	})
+	if err != nil {
+		return newError("Invalid auth proxy assertion", err)
+	}
+
+	auth.claims = claims
+	auth.header = auth.value(setting.AuthProxyHeaderName)
+	if auth.header == "" {
+		return newError("Auth proxy assertion does not contain the user", nil)
+	}
+
+	return nil
+}
+
+// value returns the value of the header, or of the claim with that name if
+// the proxy sends a signed assertion. Lists are joined with commas.
+func (auth *AuthProxy) value(name string) string {
+	if auth.claims == nil {
+		return auth.ctx.Req.Header.Get(name)
+	}
+
+	switch value := auth.claims[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		return strings.Join(values, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// hasValue returns true if the header, or the claim, is present even if empty.
+func (auth *AuthProxy) hasValue(name string) bool {
+	if auth.claims == nil {
+		_, ok := auth.ctx.Req.Header[textproto.CanonicalMIMEHeaderKey(name)]
+		return ok
+	}
+
+	_, ok := auth.claims[name]
+	return ok
+}
+
 // IsAllowedIP compares presented IP with the whitelist one
 func (auth *AuthProxy) IsAllowedIP() (bool, *Error) {
 	ip := auth.ctx.Req.RemoteAddr
@@ -253,7 +328,7 @@ func (auth *AuthProxy) LoginViaLDAP() (int64, *Error) {
 // LoginViaHeader logs in user from the header only
 func (auth *AuthProxy) LoginViaHeader() (int64, error) {
 	extUser := &models.ExternalUserInfo{
-		AuthModule: "authproxy",
+		AuthModule: models.AuthModuleAuthProxy,
 		AuthId:     auth.header,
 	}
 
@@ -273,9 +348,14 @@ func (auth *AuthProxy) LoginViaHeader() (int64, error) {
 	}
 
 	auth.headersIterator(func(field string, header string) {
-		if field == "Groups" {
+		switch field {
+		case "Groups":
 			extUser.Groups = util.SplitString(header)
-		} else {
+		case "Role":
+			extUser.OrgRoles = parseOrgRoles(header)
+		case "Teams":
+			// synced after the user has been upserted
+		default:
 			reflect.ValueOf(extUser).Elem().FieldByName(field).SetString(header)
 		}
 	})
@@ -291,9 +371,129 @@ func (auth *AuthProxy) LoginViaHeader() (int64, error) {
 		return 0, err
 	}
 
+	// An empty teams header removes the user from all synced teams, so it
+	// is enough for the header to be present.
+	if teamsHeader := auth.headers["Teams"]; teamsHeader != "" && auth.hasValue(teamsHeader) {
+		if err := syncTeams(upsert.Result.Id, parseOrgValues(auth.value(teamsHeader))); err != nil {
+			return 0, err
+		}
+	}
+
 	return upsert.Result.Id, nil
 }
 
+// defaultOrgID returns the org that roles and teams without an org ID
+// prefix apply to.
+func defaultOrgID() int64 {
+	if setting.AutoAssignOrg && setting.AutoAssignOrgId > 0 {
+		return int64(setting.AutoAssignOrgId)
+	}
+
+	return 1
+}
+
+// parseOrgValues parses a comma separated list of values, each of them
+// optionally prefixed with an org ID, e.g. "Backend, 2:Ops".
+func parseOrgValues(header string) map[int64][]string {
+	values := map[int64][]string{}
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		orgID := defaultOrgID()
+		if parts := strings.SplitN(item, ":", 2); len(parts) == 2 {
+			if id, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64); err == nil {
+				orgID = id
+				item = strings.TrimSpace(parts[1])
+			}
+		}
+
+		values[orgID] = append(values[orgID], item)
+	}
+
+	return values
+}
+
+// parseOrgRoles parses the role header, which is either a single role or a
+// role per org, e.g. "1:Admin, 2:Viewer".
+func parseOrgRoles(header string) map[int64]models.RoleType {
+	roles := map[int64]models.RoleType{}
+	for orgID, values := range parseOrgValues(header) {
+		for _, value := range values {
+			role := models.RoleType(value)
+			if !role.IsValid() {
+				logger.Warn("Ignoring invalid org role", "orgId", orgID, "role", value)
+				continue
+			}
+			roles[orgID] = role
+		}
+	}
+
+	return roles
+}
+
+// syncTeams makes the user a member of exactly the given teams in each org,
+// and removes the user from the synced teams of the orgs that aren't given.
+// Only memberships created by the sync are removed, so that teams the user
+// has been added to manually are kept.
+func syncTeams(userID int64, teams map[int64][]string) error {
+	membersQuery := &models.GetTeamMembersQuery{UserId: userID, External: true}
+	if err := bus.Dispatch(membersQuery); err != nil {
+		return err
+	}
+
+	current := map[int64]map[int64]bool{}
+	for orgID := range teams {
+		current[orgID] = map[int64]bool{}
+	}
+	for _, member := range membersQuery.Result {
+		if current[member.OrgId] == nil {
+			current[member.OrgId] = map[int64]bool{}
+		}
+		current[member.OrgId][member.TeamId] = true
+	}
+
+	for orgID, currentTeams := range current {
+		wanted := map[int64]bool{}
+		for _, name := range teams[orgID] {
+			teamQuery := &models.SearchTeamsQuery{OrgId: orgID, Name: name}
+			if err := bus.Dispatch(teamQuery); err != nil {
+				return err
+			}
+			if len(teamQuery.Result.Teams) == 0 {
+				logger.Warn("Ignoring team that does not exist", "orgId", orgID, "team", name)
+				continue
+			}
+
+			teamID := teamQuery.Result.Teams[0].Id
+			wanted[teamID] = true
+			if currentTeams[teamID] {
+				continue
+			}
+
+			cmd := &models.AddTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: userID, External: true}
+			if err := bus.Dispatch(cmd); err != nil && err != models.ErrTeamMemberAlreadyAdded {
+				return err
+			}
+		}
+
+		for teamID := range currentTeams {
+			if wanted[teamID] {
+				continue
+			}
+
+			cmd := &models.RemoveTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: userID}
+			if err := bus.Dispatch(cmd); err != nil && err != models.ErrTeamMemberNotFound {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // headersIterator iterates over all non-empty supported additional headers
 func (auth *AuthProxy) headersIterator(fn func(field string, header string)) {
 	for _, field := range supportedHeaderFields {
@@ -303,7 +503,7 @@ func (auth *AuthProxy) headersIterator(fn func(field string, header string)) {
 			continue
 		}
 
-		if value := auth.ctx.Req.Header.Get(h); value != "" {
+		if value := auth.value(h); value != "" {
 			fn(field, strings.TrimSpace(value))
 		}
 	}
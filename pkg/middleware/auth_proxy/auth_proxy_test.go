@@ -1,10 +1,16 @@
 package authproxy
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -15,6 +21,8 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/macaron.v1"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
 )
 
 type TestMultiLDAP struct {
@@ -199,3 +207,206 @@ func TestMiddlewareContext(t *testing.T) {
 		})
 	})
 }
+
+func TestAuthProxyRoleAndTeamHeaders(t *testing.T) {
+	Convey("auth proxy with role and teams headers", t, func() {
+		defer bus.ClearBusHandlers()
+
+		setting.AuthProxyHeaderName = "X-WEBAUTH-USER"
+		setting.AuthProxyHeaderProperty = "username"
+		setting.AuthProxyHeaders = map[string]string{"Role": "X-WEBAUTH-ROLE", "Teams": "X-WEBAUTH-TEAMS"}
+		defer func() { setting.AuthProxyHeaders = map[string]string{} }()
+
+		store := remotecache.NewFakeStore(t)
+		newRequest := func(role string, teams ...string) *http.Request {
+			req, err := http.NewRequest("GET", "http://example.com", nil)
+			So(err, ShouldBeNil)
+			req.Header.Set("X-WEBAUTH-USER", "markelog")
+			req.Header.Set("X-WEBAUTH-ROLE", role)
+			for _, value := range teams {
+				req.Header.Set("X-WEBAUTH-TEAMS", value)
+			}
+			return req
+		}
+
+		Convey("the cache key should change with the role", func() {
+			editor := prepareMiddleware(t, newRequest("Editor"), store)
+			admin := prepareMiddleware(t, newRequest("Admin"), store)
+			So(editor.getKey(), ShouldNotEqual, admin.getKey())
+		})
+
+		Convey("should sync org roles and teams", func() {
+			var upserted *models.UpsertUserCommand
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				upserted = cmd
+				cmd.Result = &models.User{Id: 7}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetTeamMembersQuery) error {
+				So(query.External, ShouldBeTrue)
+				So(query.OrgId, ShouldEqual, 0)
+				query.Result = []*models.TeamMemberDTO{{OrgId: 1, TeamId: 10}, {OrgId: 1, TeamId: 11}, {OrgId: 3, TeamId: 30}}
+				return nil
+			})
+			teamIDs := map[string]int64{"Backend": 10, "Frontend": 12, "Ops": 20}
+			bus.AddHandler("test", func(query *models.SearchTeamsQuery) error {
+				if id, ok := teamIDs[query.Name]; ok {
+					query.Result.Teams = []*models.TeamDTO{{Id: id, OrgId: query.OrgId, Name: query.Name}}
+				}
+				return nil
+			})
+			added := []int64{}
+			bus.AddHandler("test", func(cmd *models.AddTeamMemberCommand) error {
+				So(cmd.External, ShouldBeTrue)
+				added = append(added, cmd.TeamId)
+				return nil
+			})
+			removed := []int64{}
+			bus.AddHandler("test", func(cmd *models.RemoveTeamMemberCommand) error {
+				removed = append(removed, cmd.TeamId)
+				return nil
+			})
+
+			auth := prepareMiddleware(t, newRequest("Editor, 2:Admin", "Backend, Frontend, Unknown, 2:Ops"), store)
+			id, err := auth.LoginViaHeader()
+			So(err, ShouldBeNil)
+			So(id, ShouldEqual, 7)
+
+			So(upserted.ExternalUser.OrgRoles, ShouldResemble, map[int64]models.RoleType{
+				1: models.ROLE_EDITOR,
+				2: models.ROLE_ADMIN,
+			})
+			So(added, ShouldContain, int64(12))
+			So(added, ShouldContain, int64(20))
+			So(added, ShouldHaveLength, 2)
+			So(removed, ShouldContain, int64(11))
+			So(removed, ShouldContain, int64(30))
+			So(removed, ShouldHaveLength, 2)
+		})
+
+		Convey("should remove the user from the synced teams of all orgs with an empty teams header", func() {
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				cmd.Result = &models.User{Id: 7}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetTeamMembersQuery) error {
+				query.Result = []*models.TeamMemberDTO{{OrgId: 1, TeamId: 10}, {OrgId: 3, TeamId: 30}}
+				return nil
+			})
+			removed := map[int64]int64{}
+			bus.AddHandler("test", func(cmd *models.RemoveTeamMemberCommand) error {
+				removed[cmd.TeamId] = cmd.OrgId
+				return nil
+			})
+
+			auth := prepareMiddleware(t, newRequest("Viewer", " "), store)
+			_, err := auth.LoginViaHeader()
+			So(err, ShouldBeNil)
+			So(removed, ShouldResemble, map[int64]int64{10: 1, 30: 3})
+		})
+
+		Convey("should not sync teams without teams header", func() {
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				cmd.Result = &models.User{Id: 7}
+				return nil
+			})
+			synced := false
+			bus.AddHandler("test", func(query *models.GetTeamMembersQuery) error {
+				synced = true
+				return nil
+			})
+
+			auth := prepareMiddleware(t, newRequest("Viewer"), store)
+			_, err := auth.LoginViaHeader()
+			So(err, ShouldBeNil)
+			So(synced, ShouldBeFalse)
+		})
+	})
+}
+
+func TestAuthProxyAssertion(t *testing.T) {
+	Convey("auth proxy with a signed assertion", t, func() {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		So(err, ShouldBeNil)
+
+		keySetJSON, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: privateKey.Public(), KeyID: "proxy"}}})
+		So(err, ShouldBeNil)
+		keySetFile, err := ioutil.TempFile(os.TempDir(), "jwks-*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(keySetFile.Name())
+		_, err = keySetFile.Write(keySetJSON)
+		So(err, ShouldBeNil)
+		So(keySetFile.Close(), ShouldBeNil)
+
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: privateKey},
+			(&jose.SignerOptions{}).WithHeader("kid", "proxy"))
+		So(err, ShouldBeNil)
+
+		setting.AuthProxyHeaderName = "email"
+		setting.AuthProxyHeaders = map[string]string{"Groups": "groups"}
+		setting.AuthProxyJWTAssertionHeader = "X-Assertion"
+		setting.AuthProxyJWKSetFile = keySetFile.Name()
+		defer func() {
+			setting.AuthProxyHeaders = map[string]string{}
+			setting.AuthProxyJWTAssertionHeader = ""
+			setting.AuthProxyJWKSetFile = ""
+		}()
+
+		newRequest := func(assertion string) *AuthProxy {
+			req, err := http.NewRequest("GET", "http://example.com", nil)
+			So(err, ShouldBeNil)
+			req.Header.Set("X-Assertion", assertion)
+			// headers are ignored when the proxy sends an assertion
+			req.Header.Set("email", "spoofed@example.com")
+			return prepareMiddleware(t, req, remotecache.NewFakeStore(t))
+		}
+
+		Convey("should read the user and headers from the claims", func() {
+			assertion, err := jwt.Signed(signer).Claims(map[string]interface{}{
+				"email":  "markelog@example.com",
+				"groups": []string{"a", "b"},
+				"exp":    time.Now().Add(time.Minute).Unix(),
+			}).CompactSerialize()
+			So(err, ShouldBeNil)
+
+			auth := newRequest(assertion)
+			So(auth.HasHeader(), ShouldBeTrue)
+			So(auth.VerifyAssertion(), ShouldBeNil)
+			So(auth.header, ShouldEqual, "markelog@example.com")
+			So(auth.getKey(), ShouldEqual, fmt.Sprintf(CachePrefix, HashCacheKey("markelog@example.com-a,b")))
+		})
+
+		Convey("should reject an assertion for another audience", func() {
+			setting.AuthProxyJWTExpectedIssuer = "https://proxy.example.com"
+			setting.AuthProxyJWTExpectedAudience = "grafana"
+			defer func() {
+				setting.AuthProxyJWTExpectedIssuer = ""
+				setting.AuthProxyJWTExpectedAudience = ""
+			}()
+
+			sign := func(audience string) string {
+				assertion, err := jwt.Signed(signer).Claims(map[string]interface{}{
+					"email": "markelog@example.com",
+					"iss":   "https://proxy.example.com",
+					"aud":   audience,
+					"exp":   time.Now().Add(time.Minute).Unix(),
+				}).CompactSerialize()
+				So(err, ShouldBeNil)
+				return assertion
+			}
+
+			So(newRequest(sign("other")).VerifyAssertion(), ShouldNotBeNil)
+			So(newRequest(sign("grafana")).VerifyAssertion(), ShouldBeNil)
+		})
+
+		Convey("should reject an assertion that isn't signed by the proxy", func() {
+			auth := newRequest("eyJhbGciOiJub25lIn0.eyJlbWFpbCI6Im1hcmtlbG9nQGV4YW1wbGUuY29tIn0.")
+			So(auth.VerifyAssertion(), ShouldNotBeNil)
+		})
+
+		Convey("should not have a header without assertion", func() {
+			auth := newRequest("")
+			So(auth.HasHeader(), ShouldBeFalse)
+		})
+	})
+}
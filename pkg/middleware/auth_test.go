@@ -1,11 +1,23 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
 	"testing"
-
-	"github.com/grafana/grafana/pkg/setting"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestMiddlewareAuth(t *testing.T) {
@@ -48,3 +60,278 @@ func TestMiddlewareAuth(t *testing.T) {
 		})
 	})
 }
+
+func TestMiddlewareJWTAuth(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: privateKey.Public(), KeyID: "key-1", Algorithm: string(jose.RS256), Use: "sig"},
+	}}
+	keySetJSON, err := json.Marshal(keySet)
+	require.NoError(t, err)
+
+	keySetFile, err := ioutil.TempFile(os.TempDir(), "jwks-*.json")
+	require.NoError(t, err)
+	defer os.Remove(keySetFile.Name())
+	_, err = keySetFile.Write(keySetJSON)
+	require.NoError(t, err)
+	require.NoError(t, keySetFile.Close())
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: privateKey},
+		(&jose.SignerOptions{}).WithHeader("kid", "key-1"))
+	require.NoError(t, err)
+
+	sign := func(claims map[string]interface{}) string {
+		token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+
+	Convey("Given the JWT auth middleware", t, func() {
+		setting.JWTAuthEnabled = true
+		setting.JWTAuthHeaderName = "X-JWT-Assertion"
+		setting.JWTAuthURLParamName = "auth_token"
+		setting.JWTAuthJWKSetFile = keySetFile.Name()
+		setting.JWTAuthCacheTTL = time.Hour
+		setting.JWTAuthLoginClaim = "sub"
+		setting.JWTAuthEmailClaim = "email"
+		setting.JWTAuthRoleAttributePath = "role"
+		setting.JWTAuthAutoSignUp = true
+		defer func() {
+			setting.JWTAuthEnabled = false
+			setting.JWTAuthURLParamName = ""
+			setting.JWTAuthRoleAttributePath = ""
+			setting.JWTAuthAutoSignUp = false
+		}()
+
+		validClaims := map[string]interface{}{
+			"sub":   "embedder",
+			"email": "embedder@example.com",
+			"role":  "Editor",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+
+		setupUser := func(upserted **models.UpsertUserCommand) {
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				*upserted = cmd
+				cmd.Result = &models.User{Id: 12, Login: cmd.ExternalUser.Login}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+				return nil
+			})
+		}
+
+		middlewareScenario(t, "Valid JWT in the header", func(sc *scenarioContext) {
+			var upserted *models.UpsertUserCommand
+			setupUser(&upserted)
+
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", sign(validClaims))
+			sc.exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(sc.context.IsSignedIn, ShouldBeTrue)
+			So(sc.context.UserId, ShouldEqual, 12)
+			So(upserted.SignupAllowed, ShouldBeTrue)
+			So(upserted.ExternalUser.AuthModule, ShouldEqual, models.AuthModuleJWT)
+			So(upserted.ExternalUser.Login, ShouldEqual, "embedder")
+			So(upserted.ExternalUser.Email, ShouldEqual, "embedder@example.com")
+			So(upserted.ExternalUser.OrgRoles[1], ShouldEqual, models.ROLE_EDITOR)
+		})
+
+		middlewareScenario(t, "Valid JWT used again", func(sc *scenarioContext) {
+			upserts := 0
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				upserts++
+				cmd.Result = &models.User{Id: 12, Login: cmd.ExternalUser.Login}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetUserByIdQuery) error {
+				query.Result = &models.User{Id: query.Id}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+				return nil
+			})
+
+			token := sign(validClaims)
+			for i := 0; i < 2; i++ {
+				sc.fakeReq("GET", "/")
+				sc.req.Header.Set("X-JWT-Assertion", token)
+				sc.exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(sc.context.UserId, ShouldEqual, 12)
+			}
+			So(upserts, ShouldEqual, 1)
+		})
+
+		middlewareScenario(t, "Valid JWT used again with another role", func(sc *scenarioContext) {
+			var roles []models.RoleType
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				roles = append(roles, cmd.ExternalUser.OrgRoles[1])
+				cmd.Result = &models.User{Id: 12, Login: cmd.ExternalUser.Login}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetUserByIdQuery) error {
+				query.Result = &models.User{Id: query.Id}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+				return nil
+			})
+
+			claims := map[string]interface{}{}
+			for name, value := range validClaims {
+				claims[name] = value
+			}
+			for _, role := range []string{"Editor", "Viewer"} {
+				claims["role"] = role
+				sc.fakeReq("GET", "/")
+				sc.req.Header.Set("X-JWT-Assertion", sign(claims))
+				sc.exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+			}
+			So(roles, ShouldResemble, []models.RoleType{models.ROLE_EDITOR, models.ROLE_VIEWER})
+		})
+
+		middlewareScenario(t, "Valid JWT used again after the user is disabled", func(sc *scenarioContext) {
+			disabled := false
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				cmd.Result = &models.User{Id: 12, Login: cmd.ExternalUser.Login, IsDisabled: disabled}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetUserByIdQuery) error {
+				query.Result = &models.User{Id: query.Id, IsDisabled: disabled}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+				return nil
+			})
+
+			token := sign(validClaims)
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", token)
+			sc.exec()
+			So(sc.resp.Code, ShouldEqual, 200)
+
+			disabled = true
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", token)
+			sc.exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+		})
+
+		middlewareScenario(t, "Valid JWT of a disabled user", func(sc *scenarioContext) {
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				cmd.Result = &models.User{Id: 12, Login: cmd.ExternalUser.Login, IsDisabled: true}
+				return nil
+			})
+
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", sign(validClaims))
+			sc.exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+		})
+
+		middlewareScenario(t, "JWT of another issuer", func(sc *scenarioContext) {
+			setting.JWTAuthExpectedIssuer = "https://issuer.example.com"
+			defer func() { setting.JWTAuthExpectedIssuer = "" }()
+
+			var upserted *models.UpsertUserCommand
+			setupUser(&upserted)
+
+			claims := map[string]interface{}{"iss": "https://other.example.com"}
+			for name, value := range validClaims {
+				claims[name] = value
+			}
+
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", sign(claims))
+			sc.exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+			So(upserted, ShouldBeNil)
+		})
+
+		middlewareScenario(t, "JWT without expiry", func(sc *scenarioContext) {
+			var upserted *models.UpsertUserCommand
+			setupUser(&upserted)
+
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", sign(map[string]interface{}{"sub": "embedder"}))
+			sc.exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+			So(upserted, ShouldBeNil)
+		})
+
+		middlewareScenario(t, "Valid JWT in the URL parameter", func(sc *scenarioContext) {
+			var upserted *models.UpsertUserCommand
+			setupUser(&upserted)
+
+			sc.fakeReq("GET", "/?auth_token="+sign(validClaims)).exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(sc.context.IsSignedIn, ShouldBeTrue)
+		})
+
+		middlewareScenario(t, "Expired JWT", func(sc *scenarioContext) {
+			var upserted *models.UpsertUserCommand
+			setupUser(&upserted)
+
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", sign(map[string]interface{}{
+				"sub": "embedder",
+				"exp": time.Now().Add(-time.Hour).Unix(),
+			}))
+			sc.exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+			So(upserted, ShouldBeNil)
+		})
+
+		middlewareScenario(t, "JWT signed with an unknown key", func(sc *scenarioContext) {
+			var upserted *models.UpsertUserCommand
+			setupUser(&upserted)
+
+			otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			So(err, ShouldBeNil)
+			otherSigner, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: otherKey},
+				(&jose.SignerOptions{}).WithHeader("kid", "key-1"))
+			So(err, ShouldBeNil)
+			token, err := jwt.Signed(otherSigner).Claims(validClaims).CompactSerialize()
+			So(err, ShouldBeNil)
+
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", token)
+			sc.exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+			So(upserted, ShouldBeNil)
+		})
+
+		middlewareScenario(t, "Unknown user without auto sign-up", func(sc *scenarioContext) {
+			setting.JWTAuthAutoSignUp = false
+			bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+				So(cmd.SignupAllowed, ShouldBeFalse)
+				return errors.New("user not found")
+			})
+
+			sc.fakeReq("GET", "/")
+			sc.req.Header.Set("X-JWT-Assertion", sign(validClaims))
+			sc.exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+		})
+	})
+}
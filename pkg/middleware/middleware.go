@@ -59,12 +59,16 @@ func GetContextHandler(
 		}
 
 		// the order in which these are tested are important
-		// look for api key in Authorization header first
+		// look for a SCIM token first, which is only accepted by the SCIM API
+		// then look for a JWT, as it may be sent in the Authorization header
+		// then look for api key in Authorization header
 		// then init session and look for userId in session
 		// then look for api key in session (special case for render calls via api)
 		// then test if anonymous access is enabled
 		switch {
 		case initContextWithRenderAuth(ctx, renderService):
+		case initContextWithScimToken(ctx):
+		case initContextWithJWT(remoteCache, ctx, orgId):
 		case initContextWithApiKey(ctx):
 		case initContextWithBasicAuth(ctx, orgId):
 		case initContextWithAuthProxy(remoteCache, ctx, orgId):
@@ -225,6 +229,15 @@ func initContextWithToken(authTokenService models.UserTokenService, ctx *models.
 		return false
 	}
 
+	if isSessionIdle(token, query.Result.OrgSessionIdleTimeout) {
+		ctx.Logger.Info("Session idle timeout of organization exceeded", "userId", token.UserId, "orgId", query.Result.OrgId)
+		if err := authTokenService.RevokeToken(ctx.Req.Context(), token); err != nil && err != models.ErrUserTokenNotFound {
+			ctx.Logger.Error("Failed to revoke idle auth token", "error", err)
+		}
+		WriteSessionCookie(ctx, "", -1)
+		return false
+	}
+
 	ctx.SignedInUser = query.Result
 	ctx.IsSignedIn = true
 	ctx.UserToken = token
@@ -236,6 +249,23 @@ func initContextWithToken(authTokenService models.UserTokenService, ctx *models.
 	return true
 }
 
+// isSessionIdle returns true if the token hasn't been used within the idle
+// timeout in minutes. Tokens are only updated when being rotated, so the
+// last activity is only known with the accuracy of the rotation interval.
+func isSessionIdle(token *models.UserToken, idleTimeoutMinutes int) bool {
+	if idleTimeoutMinutes <= 0 {
+		return false
+	}
+
+	lastActivity := token.RotatedAt
+	if token.SeenAt > lastActivity {
+		lastActivity = token.SeenAt
+	}
+
+	idleSince := getTime().Sub(time.Unix(lastActivity, 0))
+	return idleSince > time.Duration(idleTimeoutMinutes)*time.Minute
+}
+
 func rotateEndOfRequestFunc(ctx *models.ReqContext, authTokenService models.UserTokenService, token *models.UserToken) macaron.BeforeFunc {
 	return func(w macaron.ResponseWriter) {
 		// if response has already been written, skip.
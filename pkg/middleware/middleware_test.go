@@ -328,6 +328,66 @@ func TestMiddlewareContext(t *testing.T) {
 			})
 		})
 
+		middlewareScenario(t, "Auth token in cookie exceeding the idle timeout of the org", func(sc *scenarioContext) {
+			sc.withTokenSessionCookie("token")
+
+			bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{OrgId: 2, UserId: 12, OrgSessionIdleTimeout: 30}
+				return nil
+			})
+
+			lastActivity := time.Now().Add(-time.Hour)
+			sc.userAuthTokenService.LookupTokenProvider = func(ctx context.Context, unhashedToken string) (*models.UserToken, error) {
+				return &models.UserToken{
+					UserId:    12,
+					RotatedAt: lastActivity.Add(-time.Hour).Unix(),
+					SeenAt:    lastActivity.Unix(),
+				}, nil
+			}
+
+			var revokedToken *models.UserToken
+			sc.userAuthTokenService.RevokeTokenProvider = func(ctx context.Context, token *models.UserToken) error {
+				revokedToken = token
+				return nil
+			}
+
+			sc.fakeReq("GET", "/").exec()
+
+			Convey("Should not init context with user info", func() {
+				So(sc.context.IsSignedIn, ShouldBeFalse)
+				So(sc.context.UserToken, ShouldBeNil)
+			})
+
+			Convey("Should revoke token", func() {
+				So(revokedToken, ShouldNotBeNil)
+				So(revokedToken.UserId, ShouldEqual, 12)
+			})
+		})
+
+		middlewareScenario(t, "Auth token in cookie within the idle timeout of the org", func(sc *scenarioContext) {
+			sc.withTokenSessionCookie("token")
+
+			bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{OrgId: 2, UserId: 12, OrgSessionIdleTimeout: 30}
+				return nil
+			})
+
+			sc.userAuthTokenService.LookupTokenProvider = func(ctx context.Context, unhashedToken string) (*models.UserToken, error) {
+				return &models.UserToken{
+					UserId:    12,
+					RotatedAt: time.Now().Add(-time.Hour).Unix(),
+					SeenAt:    time.Now().Add(-10 * time.Minute).Unix(),
+				}, nil
+			}
+
+			sc.fakeReq("GET", "/").exec()
+
+			Convey("Should init context with user info", func() {
+				So(sc.context.IsSignedIn, ShouldBeTrue)
+				So(sc.context.UserId, ShouldEqual, 12)
+			})
+		})
+
 		middlewareScenario(t, "When anonymous access is enabled", func(sc *scenarioContext) {
 			setting.AnonymousEnabled = true
 			setting.AnonymousOrgName = "test"
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const rateLimitCachePrefix = "rate-limit:%s:%s"
+
+// rateLimitBucket is the state of a token bucket, stored in the remote cache
+// so that it is shared by all Grafana instances.
+type rateLimitBucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+func init() {
+	remotecache.Register(rateLimitBucket{})
+}
+
+// RateLimit returns a function that returns a handler limiting the requests
+// to the route group per API key, user or IP.
+func RateLimit(store *remotecache.RemoteCache, cfg *setting.Cfg) func(group string) macaron.Handler {
+	return func(group string) macaron.Handler {
+		return func(c *models.ReqContext) {
+			if !cfg.RateLimiting.Enabled || c.IsRenderCall {
+				return
+			}
+
+			limit, ok := cfg.RateLimiting.Limits[group]
+			if !ok {
+				return
+			}
+
+			key := fmt.Sprintf(rateLimitCachePrefix, group, rateLimitIdentity(c, cfg.RateLimiting.TrustedProxies))
+			bucket, allowed, err := takeRateLimitToken(store, key, limit)
+			if err != nil {
+				// don't block requests if the cache is unavailable
+				c.Logger.Warn("Failed to check rate limit", "group", group, "error", err)
+				return
+			}
+
+			ratePerSecond := float64(limit.RequestsPerMinute) / 60
+			untilFull := time.Duration((float64(limit.Burst) - bucket.Tokens) / ratePerSecond * float64(time.Second))
+
+			header := c.Resp.Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(int(math.Floor(bucket.Tokens))))
+			header.Set("X-RateLimit-Reset", strconv.FormatInt(bucket.Updated.Add(untilFull).Unix(), 10))
+
+			if !allowed {
+				retryAfter := math.Ceil((1 - bucket.Tokens) / ratePerSecond)
+				header.Set("Retry-After", strconv.Itoa(int(retryAfter)))
+				metrics.MApiRateLimited.WithLabelValues(group).Inc()
+				c.JsonApiErr(429, "Too many requests", nil)
+			}
+		}
+	}
+}
+
+// rateLimitIdentity returns who the requests are limited for: the API key,
+// the signed in user or else the IP address.
+func rateLimitIdentity(c *models.ReqContext, trustedProxies []*net.IPNet) string {
+	switch {
+	case c.ApiKeyId > 0:
+		return fmt.Sprintf("apikey:%d", c.ApiKeyId)
+	case c.IsSignedIn && c.UserId > 0:
+		return fmt.Sprintf("user:%d", c.UserId)
+	default:
+		return "ip:" + rateLimitClientIP(c.Req.Request, trustedProxies)
+	}
+}
+
+// rateLimitClientIP returns the IP address of the client. Clients can set the
+// X-Forwarded-For and X-Real-IP headers, so they're only used for requests
+// of trusted proxies. The last address of X-Forwarded-For that isn't a
+// trusted proxy is the client.
+func rateLimitClientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	ip := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if !isTrustedProxy(ip, trustedProxies) {
+		return ip
+	}
+
+	if forwardedFor := req.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		addresses := strings.Split(forwardedFor, ",")
+		for i := len(addresses) - 1; i >= 0; i-- {
+			address := strings.TrimSpace(addresses[i])
+			if net.ParseIP(address) == nil {
+				break
+			}
+			ip = address
+			if !isTrustedProxy(address, trustedProxies) {
+				break
+			}
+		}
+		return ip
+	}
+
+	if realIP := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return ip
+}
+
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// takeRateLimitToken refills the bucket for the time passed since it was last
+// updated and takes a token from it if there is one. The cache has no atomic
+// updates, so concurrent requests may occasionally exceed the limit slightly.
+func takeRateLimitToken(store *remotecache.RemoteCache, key string, limit setting.RateLimit) (rateLimitBucket, bool, error) {
+	now := getTime()
+	bucket := rateLimitBucket{Tokens: float64(limit.Burst), Updated: now}
+
+	cached, err := store.Get(key)
+	if err != nil && err != remotecache.ErrCacheItemNotFound {
+		return bucket, false, err
+	}
+	if cachedBucket, ok := cached.(rateLimitBucket); ok {
+		elapsed := now.Sub(cachedBucket.Updated).Minutes()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		bucket.Tokens = math.Min(float64(limit.Burst), cachedBucket.Tokens+elapsed*float64(limit.RequestsPerMinute))
+	}
+
+	allowed := bucket.Tokens >= 1
+	if allowed {
+		bucket.Tokens--
+	}
+
+	// expire the bucket once it would have been refilled completely
+	expiration := time.Duration(float64(limit.Burst)/float64(limit.RequestsPerMinute)*float64(time.Minute)) + time.Minute
+	if err := store.Set(key, bucket, expiration); err != nil {
+		return bucket, false, err
+	}
+
+	return bucket, allowed, nil
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRateLimit(t *testing.T) {
+	Convey("Given the rate limit middleware", t, func() {
+		cfg := setting.NewCfg()
+		cfg.RateLimiting = setting.RateLimitSettings{
+			Enabled: true,
+			Limits:  map[string]setting.RateLimit{"api": {RequestsPerMinute: 60, Burst: 2}},
+		}
+
+		now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+		getTime = func() time.Time { return now }
+		defer func() { getTime = time.Now }()
+
+		request := func(sc *scenarioContext, remoteAddr string, headers ...string) {
+			sc.fakeReq("GET", "/api/limited")
+			sc.req.RemoteAddr = remoteAddr
+			for i := 0; i+1 < len(headers); i += 2 {
+				sc.req.Header.Set(headers[i], headers[i+1])
+			}
+			sc.exec()
+		}
+
+		middlewareScenario(t, "Requests exceeding the burst", func(sc *scenarioContext) {
+			sc.m.Get("/api/limited", RateLimit(sc.remoteCacheService, cfg)("api"), sc.defaultHandler)
+
+			request(sc, "10.0.0.1:1234")
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(sc.resp.Header().Get("X-RateLimit-Limit"), ShouldEqual, "2")
+			So(sc.resp.Header().Get("X-RateLimit-Remaining"), ShouldEqual, "1")
+
+			request(sc, "10.0.0.1:1234")
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(sc.resp.Header().Get("X-RateLimit-Remaining"), ShouldEqual, "0")
+			So(sc.resp.Header().Get("X-RateLimit-Reset"), ShouldEqual, "1601553602")
+
+			Convey("Should reject the next request", func() {
+				request(sc, "10.0.0.1:1234")
+				So(sc.resp.Code, ShouldEqual, 429)
+				So(sc.resp.Header().Get("Retry-After"), ShouldEqual, "1")
+			})
+
+			Convey("Should not limit requests from another IP", func() {
+				request(sc, "10.0.0.2:1234")
+				So(sc.resp.Code, ShouldEqual, 200)
+			})
+
+			Convey("Should allow requests again once the bucket is refilled", func() {
+				now = now.Add(time.Second)
+				request(sc, "10.0.0.1:1234")
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(sc.resp.Header().Get("X-RateLimit-Remaining"), ShouldEqual, "0")
+			})
+		})
+
+		middlewareScenario(t, "Requests with forwarded headers", func(sc *scenarioContext) {
+			sc.m.Get("/api/limited", RateLimit(sc.remoteCacheService, cfg)("api"), sc.defaultHandler)
+
+			Convey("Should ignore the headers of untrusted clients", func() {
+				request(sc, "10.0.0.1:1234", "X-Forwarded-For", "10.0.1.1")
+				request(sc, "10.0.0.1:1234", "X-Forwarded-For", "10.0.1.2")
+				request(sc, "10.0.0.1:1234", "X-Real-IP", "10.0.1.3")
+				So(sc.resp.Code, ShouldEqual, 429)
+			})
+
+			Convey("Should use the headers of trusted proxies", func() {
+				_, proxies, err := net.ParseCIDR("10.0.0.0/24")
+				So(err, ShouldBeNil)
+				cfg.RateLimiting.TrustedProxies = []*net.IPNet{proxies}
+
+				request(sc, "10.0.0.1:1234", "X-Forwarded-For", "10.0.1.1, 10.0.0.2")
+				request(sc, "10.0.0.2:1234", "X-Forwarded-For", "10.0.1.1")
+				request(sc, "10.0.0.1:1234", "X-Forwarded-For", "10.0.1.2")
+				So(sc.resp.Code, ShouldEqual, 200)
+				request(sc, "10.0.0.1:1234", "X-Real-IP", "10.0.1.2")
+				So(sc.resp.Code, ShouldEqual, 200)
+
+				request(sc, "10.0.0.1:1234", "X-Forwarded-For", "10.0.0.3, 10.0.1.1")
+				So(sc.resp.Code, ShouldEqual, 429)
+			})
+		})
+
+		middlewareScenario(t, "Route group without limit", func(sc *scenarioContext) {
+			sc.m.Get("/api/limited", RateLimit(sc.remoteCacheService, cfg)("search"), sc.defaultHandler)
+
+			for i := 0; i < 3; i++ {
+				request(sc, "10.0.0.1:1234")
+				So(sc.resp.Code, ShouldEqual, 200)
+			}
+			So(sc.resp.Header().Get("X-RateLimit-Limit"), ShouldBeEmpty)
+		})
+
+		middlewareScenario(t, "Rate limiting disabled", func(sc *scenarioContext) {
+			cfg.RateLimiting.Enabled = false
+			sc.m.Get("/api/limited", RateLimit(sc.remoteCacheService, cfg)("api"), sc.defaultHandler)
+
+			for i := 0; i < 3; i++ {
+				request(sc, "10.0.0.1:1234")
+				So(sc.resp.Code, ShouldEqual, 200)
+			}
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const errStringInvalidScimToken = "Invalid SCIM token"
+
+// initContextWithScimToken signs in requests to the SCIM API with a SCIM
+// token of an organization. Other credentials aren't accepted for the SCIM
+// API, and SCIM tokens aren't accepted for any other route.
+func initContextWithScimToken(ctx *models.ReqContext) bool {
+	if !setting.SCIMEnabled || !strings.HasPrefix(ctx.Req.URL.Path, "/scim/") {
+		return false
+	}
+
+	header := ctx.Req.Header.Get("Authorization")
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		scimUnauthorized(ctx, "Missing SCIM token")
+		return true
+	}
+
+	decoded, err := apikeygen.Decode(parts[1])
+	if err != nil {
+		scimUnauthorized(ctx, errStringInvalidScimToken)
+		return true
+	}
+
+	query := models.GetScimTokenByNameQuery{OrgId: decoded.OrgId, Name: decoded.Name}
+	if err := bus.Dispatch(&query); err != nil {
+		scimUnauthorized(ctx, errStringInvalidScimToken)
+		return true
+	}
+
+	isValid, err := apikeygen.IsValid(decoded, query.Result.Key)
+	if err != nil {
+		ctx.Logger.Error("Validating SCIM token failed", "error", err)
+		ctx.JSON(500, scim.NewError(500, "", "Validating SCIM token failed"))
+		return true
+	}
+	if !isValid {
+		scimUnauthorized(ctx, errStringInvalidScimToken)
+		return true
+	}
+
+	ctx.IsSignedIn = true
+	ctx.SignedInUser = &models.SignedInUser{
+		OrgId:   query.Result.OrgId,
+		OrgRole: models.ROLE_ADMIN,
+		Login:   "scim:" + query.Result.Name,
+	}
+	return true
+}
+
+func scimUnauthorized(ctx *models.ReqContext, detail string) {
+	ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+	ctx.JSON(401, scim.NewError(401, "", detail))
+}
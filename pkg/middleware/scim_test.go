@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestScimTokenMiddleware(t *testing.T) {
+	Convey("Given the SCIM token middleware", t, func() {
+		setting.SCIMEnabled = true
+		defer func() { setting.SCIMEnabled = false }()
+
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		So(err, ShouldBeNil)
+
+		middlewareScenario(t, "Valid SCIM token", func(sc *scenarioContext) {
+			bus.AddHandler("test", func(query *models.GetScimTokenByNameQuery) error {
+				query.Result = &models.ScimToken{Id: 1, OrgId: 12, Name: "asd", Key: keyhash}
+				return nil
+			})
+			sc.m.Get("/scim/v2/Users", sc.defaultHandler)
+
+			sc.fakeReq("GET", "/scim/v2/Users").withValidApiKey().exec()
+
+			So(sc.resp.Code, ShouldEqual, 200)
+			So(sc.context.IsSignedIn, ShouldBeTrue)
+			So(sc.context.OrgId, ShouldEqual, 12)
+			So(sc.context.OrgRole, ShouldEqual, models.ROLE_ADMIN)
+			So(sc.context.UserId, ShouldEqual, 0)
+		})
+
+		middlewareScenario(t, "SCIM token not matching the db hash", func(sc *scenarioContext) {
+			bus.AddHandler("test", func(query *models.GetScimTokenByNameQuery) error {
+				query.Result = &models.ScimToken{Id: 1, OrgId: 12, Name: "asd", Key: "Something_not_matching"}
+				return nil
+			})
+			sc.m.Get("/scim/v2/Users", sc.defaultHandler)
+
+			sc.fakeReq("GET", "/scim/v2/Users").withValidApiKey().exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+			So(sc.respJson["detail"], ShouldEqual, errStringInvalidScimToken)
+		})
+
+		middlewareScenario(t, "SCIM request without token", func(sc *scenarioContext) {
+			sc.m.Get("/scim/v2/Users", sc.defaultHandler)
+
+			sc.fakeReq("GET", "/scim/v2/Users").exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+			So(sc.resp.Header().Get("WWW-Authenticate"), ShouldNotBeEmpty)
+		})
+
+		middlewareScenario(t, "SCIM token for other routes", func(sc *scenarioContext) {
+			bus.AddHandler("test", func(query *models.GetScimTokenByNameQuery) error {
+				query.Result = &models.ScimToken{Id: 1, OrgId: 12, Name: "asd", Key: keyhash}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetApiKeyByNameQuery) error {
+				return models.ErrInvalidApiKey
+			})
+
+			sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+			So(sc.resp.Code, ShouldEqual, 401)
+			So(sc.respJson["message"], ShouldEqual, errStringInvalidAPIKey)
+		})
+	})
+}
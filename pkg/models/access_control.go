@@ -0,0 +1,144 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrRoleNotFound        = errors.New("Role not found")
+	ErrRoleNameTaken       = errors.New("Role name is taken")
+	ErrRoleAlreadyAssigned = errors.New("Role is already assigned")
+	ErrRoleNotAssigned     = errors.New("Role is not assigned")
+)
+
+// CustomRole is a named set of permissions defined by an org admin, which can
+// be assigned to users and teams on top of their built-in role.
+type CustomRole struct {
+	Id          int64
+	OrgId       int64
+	Uid         string
+	Name        string
+	Description string
+
+	Created time.Time
+	Updated time.Time
+}
+
+type RolePermission struct {
+	Id     int64
+	RoleId int64
+	Action string
+
+	Created time.Time
+}
+
+type UserRole struct {
+	Id     int64
+	OrgId  int64
+	UserId int64
+	RoleId int64
+
+	Created time.Time
+}
+
+type TeamRole struct {
+	Id     int64
+	OrgId  int64
+	TeamId int64
+	RoleId int64
+
+	Created time.Time
+}
+
+type CustomRoleDTO struct {
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"orgId"`
+	Uid         string    `json:"uid"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+// ---------------------
+// COMMANDS
+
+type CreateRoleCommand struct {
+	Uid         string   `json:"uid"`
+	Name        string   `json:"name" binding:"Required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	OrgId       int64    `json:"-"`
+
+	Result *CustomRoleDTO `json:"-"`
+}
+
+type UpdateRoleCommand struct {
+	Uid         string   `json:"-"`
+	Name        string   `json:"name" binding:"Required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	OrgId       int64    `json:"-"`
+
+	Result *CustomRoleDTO `json:"-"`
+}
+
+type DeleteRoleCommand struct {
+	OrgId int64
+	Uid   string
+}
+
+type AddUserRoleCommand struct {
+	OrgId   int64  `json:"-"`
+	UserId  int64  `json:"-"`
+	RoleUid string `json:"roleUid" binding:"Required"`
+}
+
+type RemoveUserRoleCommand struct {
+	OrgId   int64
+	UserId  int64
+	RoleUid string
+}
+
+type AddTeamRoleCommand struct {
+	OrgId   int64  `json:"-"`
+	TeamId  int64  `json:"-"`
+	RoleUid string `json:"roleUid" binding:"Required"`
+}
+
+type RemoveTeamRoleCommand struct {
+	OrgId   int64
+	TeamId  int64
+	RoleUid string
+}
+
+// ---------------------
+// QUERIES
+
+type GetRoleByUidQuery struct {
+	OrgId  int64
+	Uid    string
+	Result *CustomRoleDTO
+}
+
+type GetRolesQuery struct {
+	OrgId  int64
+	Result []*CustomRoleDTO
+}
+
+// GetUserRolesQuery returns the custom roles assigned to the user, either
+// directly or through membership of a team.
+type GetUserRolesQuery struct {
+	OrgId  int64
+	UserId int64
+	Result []*CustomRoleDTO
+}
+
+type GetTeamRolesQuery struct {
+	OrgId  int64
+	TeamId int64
+	Result []*CustomRoleDTO
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// ImportBundleCommand saves the content of an imported bundle in a single
+// transaction, so that a failed import changes nothing. Folders have to be
+// ordered parents first. Folders, library panels and dashboards reference
+// their folder by uid, and are created or updated depending on whether their
+// uid exists.
+type ImportBundleCommand struct {
+	OrgId   int64
+	UserId  int64
+	Message string
+
+	DataSources   []*AddDataSourceCommand
+	Folders       []*ImportBundleFolder
+	LibraryPanels []*ImportBundleLibraryPanel
+	Dashboards    []*ImportBundleDashboard
+}
+
+// ImportBundleFolder is a folder of an imported bundle. Acl replaces the
+// permissions of the folder unless it's nil.
+type ImportBundleFolder struct {
+	Uid       string
+	Title     string
+	ParentUid string
+	Acl       []*DashboardAcl
+}
+
+// ImportBundleLibraryPanel is a library panel of an imported bundle.
+type ImportBundleLibraryPanel struct {
+	Uid       string
+	Name      string
+	FolderUid string
+	Model     *simplejson.Json
+}
+
+// ImportBundleDashboard is a dashboard of an imported bundle. Acl replaces
+// the permissions of the dashboard unless it's nil.
+type ImportBundleDashboard struct {
+	Dashboard *simplejson.Json
+	FolderUid string
+	Acl       []*DashboardAcl
+
+	Result *Dashboard
+}
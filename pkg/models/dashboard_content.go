@@ -0,0 +1,43 @@
+package models
+
+// Kinds of indexed dashboard content
+const (
+	DashboardContentPanelTitle  = "panel_title"
+	DashboardContentDescription = "description"
+	DashboardContentQuery       = "query"
+	DashboardContentVariable    = "variable"
+	DashboardContentDataSource  = "datasource"
+)
+
+// DashboardContent is a piece of searchable dashboard content, like the
+// title of a panel, a query or a template variable. Name is the title of the
+// panel or the name of the variable or annotation the content belongs to.
+type DashboardContent struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	PanelId     int64
+	Kind        string
+	Name        string
+	Text        string
+}
+
+// IsValidDashboardContentKind returns true if kind is a kind of indexed
+// dashboard content.
+func IsValidDashboardContentKind(kind string) bool {
+	switch kind {
+	case DashboardContentPanelTitle, DashboardContentDescription, DashboardContentQuery, DashboardContentVariable, DashboardContentDataSource:
+		return true
+	}
+	return false
+}
+
+//
+// COMMANDS
+//
+
+// RebuildDashboardContentIndexCommand rebuilds the content index of all
+// dashboards. Result is the number of indexed dashboards.
+type RebuildDashboardContentIndexCommand struct {
+	Result int64
+}
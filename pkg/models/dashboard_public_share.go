@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrDashboardPublicShareNotFound = errors.New("public dashboard share not found")
+	ErrDashboardPublicShareExpired  = errors.New("public dashboard share has expired")
+)
+
+// DashboardPublicShare gives anonymous access to a dashboard and the queries
+// of its panels to the holders of a token. Only the hash of the token is
+// stored. When TimeLocked is set, the dashboard can only be viewed in the
+// time range from TimeFrom to TimeTo.
+type DashboardPublicShare struct {
+	Id          int64
+	Uid         string
+	OrgId       int64
+	DashboardId int64
+	TokenHash   string
+	TimeLocked  bool
+	TimeFrom    string
+	TimeTo      string
+	Revoked     bool
+	CreatedBy   int64
+
+	Expires time.Time
+	Created time.Time
+	Updated time.Time
+}
+
+// IsActive returns true if the share isn't revoked and hasn't expired.
+func (s *DashboardPublicShare) IsActive(now time.Time) bool {
+	return !s.Revoked && now.Before(s.Expires)
+}
+
+// DashboardPublicShareDTO is a public share without the token hash.
+type DashboardPublicShareDTO struct {
+	Uid          string    `json:"uid"`
+	DashboardUid string    `json:"dashboardUid"`
+	TimeLocked   bool      `json:"timeLocked"`
+	TimeFrom     string    `json:"timeFrom,omitempty"`
+	TimeTo       string    `json:"timeTo,omitempty"`
+	Revoked      bool      `json:"revoked"`
+	Expired      bool      `json:"expired"`
+	CreatedBy    int64     `json:"createdBy"`
+	Expires      time.Time `json:"expires"`
+	Created      time.Time `json:"created"`
+	Updated      time.Time `json:"updated"`
+}
+
+// CreateDashboardPublicShareCommand creates a public share of a dashboard.
+// Expires is the lifetime of the share in seconds, 0 never expires.
+type CreateDashboardPublicShareCommand struct {
+	OrgId       int64
+	DashboardId int64
+	UserId      int64
+	TokenHash   string
+	TimeLocked  bool
+	TimeFrom    string
+	TimeTo      string
+	Expires     int64
+
+	Result *DashboardPublicShare
+}
+
+// RevokeDashboardPublicShareCommand revokes a public share of a dashboard.
+// Revoked shares are kept, but their token no longer gives access.
+type RevokeDashboardPublicShareCommand struct {
+	OrgId       int64
+	DashboardId int64
+	Uid         string
+}
+
+type GetDashboardPublicSharesQuery struct {
+	OrgId       int64
+	DashboardId int64
+
+	Result []*DashboardPublicShare
+}
+
+// GetDashboardPublicShareByTokenQuery returns the share with the token hash,
+// including revoked and expired shares.
+type GetDashboardPublicShareByTokenQuery struct {
+	TokenHash string
+
+	Result *DashboardPublicShare
+}
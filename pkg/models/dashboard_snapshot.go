@@ -18,6 +18,16 @@ type DashboardSnapshot struct {
 	ExternalUrl       string
 	ExternalDeleteUrl string
 
+	// DashboardId is the dashboard the snapshot is generated from by the
+	// server, zero for snapshots created by clients.
+	DashboardId     int64
+	TimeFrom        string
+	TimeTo          string
+	RefreshInterval int64
+	NextRefresh     time.Time
+	RefreshError    string
+	Version         int
+
 	Expires time.Time
 	Created time.Time
 	Updated time.Time
@@ -25,6 +35,24 @@ type DashboardSnapshot struct {
 	Dashboard *simplejson.Json
 }
 
+// DashboardSnapshotVersion is a previous version of a snapshot that has been
+// refreshed.
+type DashboardSnapshotVersion struct {
+	Id         int64
+	SnapshotId int64
+	Version    int
+	Created    time.Time
+
+	Dashboard *simplejson.Json
+}
+
+// DashboardSnapshotVersionDTO without dashboard map
+type DashboardSnapshotVersionDTO struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Current bool      `json:"current"`
+}
+
 // DashboardSnapshotDTO without dashboard map
 type DashboardSnapshotDTO struct {
 	Id          int64  `json:"id"`
@@ -35,6 +63,11 @@ type DashboardSnapshotDTO struct {
 	External    bool   `json:"external"`
 	ExternalUrl string `json:"externalUrl"`
 
+	DashboardId     int64  `json:"dashboardId"`
+	RefreshInterval int64  `json:"refreshInterval"`
+	RefreshError    string `json:"refreshError"`
+	Version         int    `json:"version"`
+
 	Expires time.Time `json:"expires"`
 	Created time.Time `json:"created"`
 	Updated time.Time `json:"updated"`
@@ -56,12 +89,32 @@ type CreateDashboardSnapshotCommand struct {
 	Key       string `json:"key"`
 	DeleteKey string `json:"deleteKey"`
 
+	// these are passed when the server generates the snapshot
+	DashboardId     int64  `json:"-"`
+	TimeFrom        string `json:"-"`
+	TimeTo          string `json:"-"`
+	RefreshInterval int64  `json:"-"`
+
 	OrgId  int64 `json:"-"`
 	UserId int64 `json:"-"`
 
 	Result *DashboardSnapshot
 }
 
+// RefreshDashboardSnapshotCommand stores a newly generated dashboard of a
+// snapshot as its next version, or the error if generating it failed, and
+// schedules the next refresh. StopRefresh clears the refresh interval of
+// snapshots that can't be refreshed anymore instead.
+type RefreshDashboardSnapshotCommand struct {
+	Id             int64
+	Dashboard      *simplejson.Json
+	Error          string
+	VersionsToKeep int
+	StopRefresh    bool
+
+	Result *DashboardSnapshot
+}
+
 type DeleteDashboardSnapshotCommand struct {
 	DeleteKey string `json:"-"`
 }
@@ -77,6 +130,25 @@ type GetDashboardSnapshotQuery struct {
 	Result *DashboardSnapshot
 }
 
+type GetDueDashboardSnapshotsQuery struct {
+	Now time.Time
+
+	Result []*DashboardSnapshot
+}
+
+type GetDashboardSnapshotVersionsQuery struct {
+	SnapshotId int64
+
+	Result []*DashboardSnapshotVersionDTO
+}
+
+type GetDashboardSnapshotVersionQuery struct {
+	SnapshotId int64
+	Version    int
+
+	Result *DashboardSnapshotVersion
+}
+
 type DashboardSnapshots []*DashboardSnapshot
 type DashboardSnapshotsList []*DashboardSnapshotDTO
 
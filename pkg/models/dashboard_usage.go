@@ -0,0 +1,148 @@
+package models
+
+import "time"
+
+// Sort orders of dashboard usage
+const (
+	DashboardUsageSortViewsDesc = "views-desc"
+	DashboardUsageSortViewsAsc  = "views-asc"
+)
+
+// DashboardUsageDayFormat is the layout of the UTC day usage is aggregated by.
+const DashboardUsageDayFormat = "2006-01-02"
+
+// DashboardViewEvent is a view of a dashboard by a user. View events are
+// deleted by the cleanup service after the configured max age.
+type DashboardViewEvent struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	UserId      int64
+	Created     time.Time
+}
+
+// DashboardUsageCount counts the views, panel queries and failed panel
+// queries of a dashboard by a user on a day.
+type DashboardUsageCount struct {
+	OrgId       int64
+	DashboardId int64
+	UserId      int64
+	Day         string
+	Views       int64
+	Queries     int64
+	Errors      int64
+	LastViewed  *time.Time
+}
+
+// DashboardUsage is the usage of a dashboard in a time range.
+type DashboardUsage struct {
+	DashboardId  int64      `json:"dashboardId"`
+	DashboardUid string     `json:"dashboardUid"`
+	Title        string     `json:"title"`
+	FolderId     int64      `json:"folderId"`
+	FolderUid    string     `json:"folderUid"`
+	FolderTitle  string     `json:"folderTitle"`
+	Views        int64      `json:"views"`
+	Queries      int64      `json:"queries"`
+	Errors       int64      `json:"errors"`
+	Users        int64      `json:"users"`
+	LastViewed   *time.Time `json:"lastViewed"`
+}
+
+// UserDashboardUsage is the usage of dashboards by a user in a time range.
+type UserDashboardUsage struct {
+	UserId     int64      `json:"userId"`
+	Login      string     `json:"login"`
+	Name       string     `json:"name"`
+	Views      int64      `json:"views"`
+	Queries    int64      `json:"queries"`
+	Errors     int64      `json:"errors"`
+	Dashboards int64      `json:"dashboards"`
+	LastViewed *time.Time `json:"lastViewed"`
+}
+
+// FolderUsage is the usage of the dashboards in a folder in a time range.
+// UnusedDashboards counts the dashboards that haven't been viewed.
+type FolderUsage struct {
+	FolderId         int64      `json:"folderId"`
+	FolderUid        string     `json:"folderUid"`
+	FolderTitle      string     `json:"folderTitle"`
+	Dashboards       int64      `json:"dashboards"`
+	UnusedDashboards int64      `json:"unusedDashboards"`
+	Views            int64      `json:"views"`
+	Queries          int64      `json:"queries"`
+	Errors           int64      `json:"errors"`
+	LastViewed       *time.Time `json:"lastViewed"`
+}
+
+// DashboardView is a recorded view of a dashboard.
+type DashboardView struct {
+	UserId int64     `json:"userId"`
+	Login  string    `json:"login"`
+	Name   string    `json:"name"`
+	Viewed time.Time `json:"viewed"`
+}
+
+// SaveDashboardUsageCommand stores view events and adds the counts to the
+// aggregated usage and the view counts of the dashboards. Views and counts of
+// dashboards that don't exist in the organization are dropped.
+type SaveDashboardUsageCommand struct {
+	Views      []*DashboardViewEvent
+	Counts     []*DashboardUsageCount
+	ViewCounts []*DashboardViewCount
+}
+
+// DeleteOldDashboardViewEventsCommand deletes the view events created before
+// OlderThan.
+type DeleteOldDashboardViewEventsCommand struct {
+	OlderThan   time.Time
+	DeletedRows int64
+}
+
+// GetDashboardUsageQuery returns the usage of the dashboards of an
+// organization between the days From and To, including dashboards that
+// haven't been used. FolderId and UserId are only applied when set.
+type GetDashboardUsageQuery struct {
+	OrgId    int64
+	From     string
+	To       string
+	FolderId *int64
+	UserId   int64
+	Sort     string
+	Limit    int
+
+	Result []*DashboardUsage
+}
+
+// GetUserDashboardUsageQuery returns the usage of dashboards by the users of
+// an organization between the days From and To. DashboardId is only applied
+// when set.
+type GetUserDashboardUsageQuery struct {
+	OrgId       int64
+	From        string
+	To          string
+	DashboardId int64
+	Limit       int
+
+	Result []*UserDashboardUsage
+}
+
+// GetFolderUsageQuery returns the usage of the dashboards of an organization
+// by folder between the days From and To.
+type GetFolderUsageQuery struct {
+	OrgId int64
+	From  string
+	To    string
+
+	Result []*FolderUsage
+}
+
+// GetDashboardViewsQuery returns the most recent recorded views of a
+// dashboard.
+type GetDashboardViewsQuery struct {
+	OrgId       int64
+	DashboardId int64
+	Limit       int
+
+	Result []*DashboardView
+}
@@ -26,6 +26,11 @@ type DashboardVersion struct {
 
 	Message string           `json:"message"`
 	Data    *simplejson.Json `json:"data"`
+
+	// FolderId is the folder of the dashboard when the version was saved
+	FolderId int64  `json:"folderId"`
+	Pinned   bool   `json:"pinned"`
+	Label    string `json:"label"`
 }
 
 // DashboardVersionMeta extends the dashboard version model with the names
@@ -41,6 +46,9 @@ type DashboardVersionMeta struct {
 	Message       string           `json:"message"`
 	Data          *simplejson.Json `json:"data"`
 	CreatedBy     string           `json:"createdBy"`
+	FolderId      int64            `json:"folderId"`
+	Pinned        bool             `json:"pinned"`
+	Label         string           `json:"label"`
 }
 
 // DashboardVersionDTO represents a dashboard version, without the dashboard
@@ -53,7 +61,11 @@ type DashboardVersionDTO struct {
 	Version       int       `json:"version"`
 	Created       time.Time `json:"created"`
 	CreatedBy     string    `json:"createdBy"`
+	CreatedById   int64     `json:"-"`
 	Message       string    `json:"message"`
+	FolderId      int64     `json:"folderId"`
+	Pinned        bool      `json:"pinned"`
+	Label         string    `json:"label"`
 }
 
 //
@@ -77,6 +89,14 @@ type GetDashboardVersionsQuery struct {
 	Result []*DashboardVersionDTO
 }
 
+// GetDashboardVersionHistoryQuery returns all versions of a dashboard with their data, oldest first.
+type GetDashboardVersionHistoryQuery struct {
+	DashboardId int64
+	OrgId       int64
+
+	Result []*DashboardVersionMeta
+}
+
 //
 // Commands
 //
@@ -84,3 +104,12 @@ type GetDashboardVersionsQuery struct {
 type DeleteExpiredVersionsCommand struct {
 	DeletedRows int64
 }
+
+// PinDashboardVersionCommand pins or unpins a dashboard version. Pinned versions are never deleted by the
+// version retention, and can have a label, like "release 3.2".
+type PinDashboardVersionCommand struct {
+	DashboardId int64
+	Version     int
+	Pinned      bool
+	Label       string
+}
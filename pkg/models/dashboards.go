@@ -17,6 +17,7 @@ var (
 	ErrDashboardNotFound                         = errors.New("Dashboard not found")
 	ErrDashboardFolderNotFound                   = errors.New("Folder not found")
 	ErrDashboardSnapshotNotFound                 = errors.New("Dashboard snapshot not found")
+	ErrDashboardSnapshotVersionNotFound          = errors.New("Dashboard snapshot version not found")
 	ErrDashboardWithSameUIDExists                = errors.New("A dashboard with the same uid already exists")
 	ErrDashboardWithSameNameInFolderExists       = errors.New("A dashboard with the same name in the folder already exists")
 	ErrDashboardVersionMismatch                  = errors.New("The dashboard has been changed by someone else")
@@ -256,6 +257,8 @@ type SaveDashboardCommand struct {
 	PluginId     string           `json:"-"`
 	FolderId     int64            `json:"folderId"`
 	IsFolder     bool             `json:"isFolder"`
+	Strict       bool             `json:"strict"`
+	Merge        bool             `json:"merge"`
 
 	UpdatedAt time.Time
 
@@ -269,6 +272,12 @@ type DashboardProvisioning struct {
 	ExternalId  string
 	CheckSum    string
 	Updated     int64
+
+	// Commit metadata, set for dashboards provisioned from a git repository.
+	CommitHash    string
+	CommitAuthor  string
+	CommitMessage string
+	CommitTime    int64
 }
 
 type SaveProvisionedDashboardCommand struct {
@@ -283,6 +292,15 @@ type DeleteDashboardCommand struct {
 	OrgId int64
 }
 
+// DashboardViewCount counts all views of a dashboard, used to sort search
+// results by the number of views.
+type DashboardViewCount struct {
+	OrgId       int64
+	DashboardId int64
+	Views       int64
+	LastViewed  time.Time
+}
+
 type ValidateDashboardBeforeSaveCommand struct {
 	OrgId     int64
 	Dashboard *Dashboard
@@ -318,6 +336,23 @@ type GetDashboardsQuery struct {
 	Result       []*Dashboard
 }
 
+// GetAllDashboardsQuery returns at most Limit dashboards of all organizations with an id greater than AfterId,
+// ordered by id. Folders are not returned.
+type GetAllDashboardsQuery struct {
+	AfterId int64
+	Limit   int
+
+	Result []*Dashboard
+}
+
+// GetDashboardsByOrgQuery returns the dashboards and folders of an organization, ordered by id. Library panels
+// are not loaded, the dashboards only reference them.
+type GetDashboardsByOrgQuery struct {
+	OrgId int64
+
+	Result []*Dashboard
+}
+
 type GetDashboardPermissionsForUserQuery struct {
 	DashboardIds []int64
 	OrgId        int64
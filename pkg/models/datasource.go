@@ -37,6 +37,8 @@ var (
 	ErrDatasourceIsReadOnly              = errors.New("Data source is readonly. Can only be updated from configuration")
 	ErrDataSourceAccessDenied            = errors.New("Data source access denied")
 	ErrDataSourceFailedGenerateUniqueUid = errors.New("Failed to generate unique datasource id")
+	ErrDataSourcePermissionNotFound      = errors.New("Data source permission not found")
+	ErrDataSourcePermissionInvalid       = errors.New("Data source permission must have either a user, a team or a role")
 )
 
 type DsAccess string
@@ -63,6 +65,10 @@ type DataSource struct {
 	ReadOnly          bool
 	Uid               string
 
+	// PermissionsEnabled restricts access to the users, teams and roles
+	// that have been granted a DataSourcePermission.
+	PermissionsEnabled bool
+
 	Created time.Time
 	Updated time.Time
 }
@@ -229,18 +235,106 @@ type GetDataSourceByNameQuery struct {
 type DsPermissionType int
 
 const (
-	DsPermissionNoAccess DsPermissionType = iota
-	DsPermissionQuery
+	DsPermissionNoAccess DsPermissionType = 0
+	DsPermissionQuery    DsPermissionType = 1
+	DsPermissionEdit     DsPermissionType = 2
+	DsPermissionAdmin    DsPermissionType = 4
 )
 
 func (p DsPermissionType) String() string {
 	names := map[int]string{
+		int(DsPermissionAdmin):    "Admin",
+		int(DsPermissionEdit):     "Edit",
 		int(DsPermissionQuery):    "Query",
 		int(DsPermissionNoAccess): "No Access",
 	}
 	return names[int(p)]
 }
 
+func (p DsPermissionType) IsValid() bool {
+	return p == DsPermissionQuery || p == DsPermissionEdit || p == DsPermissionAdmin
+}
+
+// DataSourcePermission grants a user, a team or all users with a built-in
+// role access to a data source that has permissions enabled.
+type DataSourcePermission struct {
+	Id           int64
+	OrgId        int64
+	DatasourceId int64
+
+	UserId     int64
+	TeamId     int64
+	Role       *RoleType
+	Permission DsPermissionType
+
+	Created time.Time
+	Updated time.Time
+}
+
+type DataSourcePermissionDTO struct {
+	Id             int64            `json:"id"`
+	DatasourceId   int64            `json:"datasourceId"`
+	UserId         int64            `json:"userId,omitempty"`
+	UserLogin      string           `json:"userLogin,omitempty"`
+	UserEmail      string           `json:"userEmail,omitempty"`
+	UserAvatarUrl  string           `json:"userAvatarUrl,omitempty"`
+	TeamId         int64            `json:"teamId,omitempty"`
+	Team           string           `json:"team,omitempty"`
+	TeamEmail      string           `json:"-"`
+	TeamAvatarUrl  string           `json:"teamAvatarUrl,omitempty"`
+	Role           *RoleType        `json:"role,omitempty"`
+	Permission     DsPermissionType `json:"permission"`
+	PermissionName string           `json:"permissionName"`
+	Created        time.Time        `json:"created"`
+	Updated        time.Time        `json:"updated"`
+}
+
+type DataSourcePermissionsDTO struct {
+	DatasourceId int64                      `json:"datasourceId"`
+	Enabled      bool                       `json:"enabled"`
+	Permissions  []*DataSourcePermissionDTO `json:"permissions"`
+}
+
+type SetDataSourcePermissionsEnabledCommand struct {
+	OrgId        int64
+	DatasourceId int64
+	Enabled      bool
+}
+
+// AddDataSourcePermissionCommand adds a permission, or updates the level of
+// an existing permission for the same user, team or role.
+type AddDataSourcePermissionCommand struct {
+	UserId     int64            `json:"userId"`
+	TeamId     int64            `json:"teamId"`
+	Role       *RoleType        `json:"role"`
+	Permission DsPermissionType `json:"permission"`
+
+	OrgId        int64 `json:"-"`
+	DatasourceId int64 `json:"-"`
+
+	Result *DataSourcePermission `json:"-"`
+}
+
+type RemoveDataSourcePermissionCommand struct {
+	OrgId        int64
+	DatasourceId int64
+	Id           int64
+}
+
+type GetDataSourcePermissionsQuery struct {
+	OrgId        int64
+	DatasourceId int64
+	Result       []*DataSourcePermissionDTO
+}
+
+// GetUserDataSourcePermissionsQuery returns the highest permission granted
+// to the user per data source, through the user, their teams or their role.
+type GetUserDataSourcePermissionsQuery struct {
+	OrgId  int64
+	User   *SignedInUser
+	Result map[int64]DsPermissionType
+}
+
 type DatasourcesPermissionFilterQuery struct {
 	User        *SignedInUser
 	Datasources []*DataSource
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrReassignUserNotFound = errors.New("User to reassign content to not found")
+)
+
+// Types of deleted resources
+const (
+	DeletedResourceUser = "user"
+	DeletedResourceOrg  = "org"
+	DeletedResourceTeam = "team"
+)
+
+// DeletedResourceDTO is a user, organization or team that has been deleted,
+// but can still be restored until it's purged.
+type DeletedResourceDTO struct {
+	Type    string    `json:"type"`
+	Id      int64     `json:"id"`
+	OrgId   int64     `json:"orgId,omitempty"`
+	Name    string    `json:"name"`
+	Login   string    `json:"login,omitempty"`
+	Email   string    `json:"email,omitempty"`
+	Deleted time.Time `json:"deleted"`
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// ---------------------
+// COMMANDS
+
+type RestoreUserCommand struct {
+	UserId int64
+}
+
+type RestoreOrgCommand struct {
+	Id int64
+}
+
+type RestoreTeamCommand struct {
+	OrgId int64
+	Id    int64
+}
+
+// PurgeDeletedResourcesCommand permanently deletes the users, organizations
+// and teams that have been deleted before DeletedBefore.
+type PurgeDeletedResourcesCommand struct {
+	DeletedBefore time.Time
+
+	Result int64
+}
+
+// ---------------------
+// QUERIES
+
+// GetDeletedResourcesQuery returns the deleted resources of the type. Teams
+// are filtered by OrgId.
+type GetDeletedResourcesQuery struct {
+	Type  string
+	OrgId int64
+
+	Result []*DeletedResourceDTO
+}
@@ -15,6 +15,8 @@ var (
 	ErrFolderSameNameExists          = errors.New("A folder or dashboard in the general folder with the same name already exists")
 	ErrFolderFailedGenerateUniqueUid = errors.New("Failed to generate unique folder id")
 	ErrFolderAccessDenied            = errors.New("Access denied to folder")
+	ErrFolderParentNotFound          = errors.New("Parent folder not found")
+	ErrFolderCircularReference       = errors.New("A folder cannot be moved into itself or one of its subfolders")
 )
 
 type Folder struct {
@@ -30,6 +32,10 @@ type Folder struct {
 	UpdatedBy int64
 	CreatedBy int64
 	HasAcl    bool
+
+	// ParentId and ParentUid are empty for folders in the General folder
+	ParentId  int64
+	ParentUid string
 }
 
 // GetDashboardModel turns the command into the saveable model
@@ -75,8 +81,9 @@ func (cmd *UpdateFolderCommand) UpdateDashboardModel(dashFolder *Dashboard, orgI
 //
 
 type CreateFolderCommand struct {
-	Uid   string `json:"uid"`
-	Title string `json:"title"`
+	Uid       string `json:"uid"`
+	Title     string `json:"title"`
+	ParentUid string `json:"parentUid"`
 
 	Result *Folder
 }
@@ -90,6 +97,14 @@ type UpdateFolderCommand struct {
 	Result *Folder
 }
 
+// MoveFolderCommand moves a folder and its content to another folder. An
+// empty ParentUid moves the folder to the General folder.
+type MoveFolderCommand struct {
+	ParentUid string `json:"parentUid"`
+
+	Result *Folder
+}
+
 //
 // QUERIES
 //
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Typed errors
+var (
+	ErrLibraryPanelNotFound               = errors.New("Library panel not found")
+	ErrLibraryPanelWithSameNameExists     = errors.New("A library panel with the same name already exists in the folder")
+	ErrLibraryPanelWithSameUIDExists      = errors.New("A library panel with the same uid already exists")
+	ErrLibraryPanelInvalidUid             = errors.New("uid contains illegal characters or is longer than 40 characters")
+	ErrLibraryPanelVersionMismatch        = errors.New("The library panel has been changed by someone else")
+	ErrLibraryPanelHasConnectedDashboards = errors.New("The library panel is used by dashboards")
+	ErrLibraryPanelNameEmpty              = errors.New("Library panel name cannot be empty")
+	ErrLibraryPanelInvalidModel           = errors.New("Library panel model must be a panel without alerts or nested panels")
+	ErrLibraryPanelAccessDenied           = errors.New("Access denied to the folder of a library panel")
+)
+
+// LibraryPanel is a panel model that is shared by dashboards. Dashboards only
+// store a reference to the library panel, the panel itself is loaded when the
+// dashboard is loaded.
+type LibraryPanel struct {
+	Id       int64
+	OrgId    int64
+	FolderId int64
+	Uid      string
+	Name     string
+	Model    *simplejson.Json
+	Version  int64
+
+	Created   time.Time
+	CreatedBy int64
+	Updated   time.Time
+	UpdatedBy int64
+}
+
+// LibraryPanelDTO is the API representation of a library panel.
+type LibraryPanelDTO struct {
+	Id       int64            `json:"id"`
+	OrgId    int64            `json:"orgId"`
+	FolderId int64            `json:"folderId"`
+	Uid      string           `json:"uid"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+	Version  int64            `json:"version"`
+	Meta     LibraryPanelMeta `json:"meta"`
+}
+
+type LibraryPanelMeta struct {
+	CanEdit             bool      `json:"canEdit"`
+	ConnectedDashboards int64     `json:"connectedDashboards"`
+	Created             time.Time `json:"created"`
+	Updated             time.Time `json:"updated"`
+}
+
+// LibraryPanelDashboard is a dashboard that uses a library panel.
+type LibraryPanelDashboard struct {
+	Id       int64  `json:"id"`
+	Uid      string `json:"uid"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	FolderId int64  `json:"folderId"`
+}
+
+// LibraryPanelWithConnections is a library panel with the number of
+// dashboards that use it.
+type LibraryPanelWithConnections struct {
+	LibraryPanel        `xorm:"extends"`
+	ConnectedDashboards int64
+}
+
+type LibraryPanelSearchResult struct {
+	TotalCount    int64
+	LibraryPanels []*LibraryPanelWithConnections
+}
+
+// ---------------------
+// COMMANDS
+
+type CreateLibraryPanelCommand struct {
+	FolderId int64            `json:"folderId"`
+	Uid      string           `json:"uid"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+
+	OrgId  int64 `json:"-"`
+	UserId int64 `json:"-"`
+
+	Result *LibraryPanel `json:"-"`
+}
+
+// UpdateLibraryPanelCommand updates the folder, name and model of a library
+// panel. Fields that are not set are not changed.
+type UpdateLibraryPanelCommand struct {
+	FolderId *int64           `json:"folderId"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+	Version  int64            `json:"version"`
+
+	OrgId  int64  `json:"-"`
+	Uid    string `json:"-"`
+	UserId int64  `json:"-"`
+
+	Result *LibraryPanel `json:"-"`
+}
+
+type DeleteLibraryPanelCommand struct {
+	OrgId int64
+	Uid   string
+}
+
+// ---------------------
+// QUERIES
+
+type GetLibraryPanelQuery struct {
+	OrgId int64
+	Uid   string
+
+	Result *LibraryPanelWithConnections
+}
+
+// GetLibraryPanelsQuery searches the library panels in the folders the user
+// can view.
+type GetLibraryPanelsQuery struct {
+	OrgId        int64
+	SignedInUser *SignedInUser
+	FolderIds    []int64
+	Query        string
+	Page         int
+	PerPage      int
+
+	Result LibraryPanelSearchResult
+}
+
+// GetAllLibraryPanelsQuery returns all library panels of an organization.
+type GetAllLibraryPanelsQuery struct {
+	OrgId int64
+
+	Result []*LibraryPanel
+}
+
+// GetLibraryPanelDashboardsQuery returns the dashboards that use a library
+// panel and that the user can view.
+type GetLibraryPanelDashboardsQuery struct {
+	OrgId        int64
+	Uid          string
+	SignedInUser *SignedInUser
+
+	Result []*LibraryPanelDashboard
+}
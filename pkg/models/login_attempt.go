@@ -11,6 +11,15 @@ type LoginAttempt struct {
 	Created   int64
 }
 
+// LockedUser is a username that can't log in because of too many failed
+// login attempts.
+type LockedUser struct {
+	Username    string    `json:"username"`
+	Attempts    int64     `json:"attempts"`
+	LastAttempt time.Time `json:"lastAttempt"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
 // ---------------------
 // COMMANDS
 
@@ -26,6 +35,10 @@ type DeleteOldLoginAttemptsCommand struct {
 	DeletedRows int64
 }
 
+type DeleteUserLoginAttemptsCommand struct {
+	Username string
+}
+
 // ---------------------
 // QUERIES
 
@@ -34,3 +47,8 @@ type GetUserLoginAttemptCountQuery struct {
 	Since    time.Time
 	Result   int64
 }
+
+type GetLoginAttemptsQuery struct {
+	Since  time.Time
+	Result []*LoginAttempt
+}
@@ -43,6 +43,15 @@ type SendResetPasswordEmailCommand struct {
 	User *User
 }
 
+// CreateResetPasswordCodeCommand creates a code for POST
+// /api/user/password/reset that is valid for ValidMinutes, without sending it
+// by email.
+type CreateResetPasswordCodeCommand struct {
+	User         *User
+	ValidMinutes int
+	Result       string
+}
+
 type ValidateResetPasswordCodeQuery struct {
 	Code   string
 	Result *User
@@ -23,6 +23,11 @@ type Org struct {
 	State    string
 	Country  string
 
+	// SessionIdleTimeout is the time in minutes a user of the org can be
+	// inactive before being logged out. 0 means the server-wide setting
+	// applies.
+	SessionIdleTimeout int
+
 	Created time.Time
 	Updated time.Time
 }
@@ -52,6 +57,11 @@ type UpdateOrgAddressCommand struct {
 	Address
 }
 
+type UpdateOrgSessionSettingsCommand struct {
+	IdleTimeoutMinutes int   `json:"idleTimeoutMinutes"`
+	OrgId              int64 `json:"-"`
+}
+
 type GetOrgByIdQuery struct {
 	Id     int64
 	Result *Org
@@ -83,6 +93,10 @@ type OrgDetailsDTO struct {
 	Address Address `json:"address"`
 }
 
+type OrgSessionSettingsDTO struct {
+	IdleTimeoutMinutes int `json:"idleTimeoutMinutes"`
+}
+
 type UserOrgDTO struct {
 	OrgId int64    `json:"orgId"`
 	Name  string   `json:"name"`
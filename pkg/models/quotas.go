@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/grafana/grafana/pkg/setting"
@@ -9,6 +10,16 @@ import (
 
 var ErrInvalidQuotaTarget = errors.New("Invalid quota target")
 
+// QuotaReachedError is returned by commands that would exceed the quota of the
+// target.
+type QuotaReachedError struct {
+	Target string
+}
+
+func (e QuotaReachedError) Error() string {
+	return fmt.Sprintf("%s Quota reached", e.Target)
+}
+
 type Quota struct {
 	Id      int64
 	OrgId   int64
@@ -120,6 +131,11 @@ func GetQuotaScopes(target string) ([]QuotaScope, error) {
 			QuotaScope{Name: "org", Target: target, DefaultLimit: setting.Quota.Org.ApiKey},
 		)
 		return scopes, nil
+	case "alert", "annotation", "dashboard_version", "dashboard_snapshot", "playlist", "dashboard_bytes":
+		scopes = append(scopes,
+			QuotaScope{Name: "org", Target: target, DefaultLimit: setting.Quota.Org.ToMap()[target]},
+		)
+		return scopes, nil
 	case "session":
 		scopes = append(scopes,
 			QuotaScope{Name: "global", Target: target, DefaultLimit: setting.Quota.Global.Session},
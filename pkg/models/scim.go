@@ -0,0 +1,135 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrScimTokenNotFound  = errors.New("SCIM token not found")
+	ErrDuplicateScimToken = errors.New("SCIM token name must be unique within the organization")
+)
+
+// Resource types of the SCIM external ids
+const (
+	ScimResourceUser  = "User"
+	ScimResourceGroup = "Group"
+)
+
+// ScimToken is a bearer token that lets an identity provider provision the
+// users and teams of an organization through the SCIM API.
+type ScimToken struct {
+	Id      int64
+	OrgId   int64
+	Name    string
+	Key     string
+	Created time.Time
+}
+
+// ScimExternalId is the id an identity provider uses for a user or team of
+// an organization.
+type ScimExternalId struct {
+	Id           int64
+	OrgId        int64
+	ResourceType string
+	ResourceId   int64
+	ExternalId   string
+	Created      time.Time
+	Updated      time.Time
+}
+
+// ---------------------
+// COMMANDS
+
+type AddScimTokenCommand struct {
+	Name  string `json:"name" binding:"Required"`
+	OrgId int64  `json:"-"`
+	Key   string `json:"-"`
+
+	Result *ScimToken `json:"-"`
+}
+
+type DeleteScimTokenCommand struct {
+	OrgId int64
+	Id    int64
+}
+
+// SetScimExternalIdCommand sets the external id of a user or team, or
+// removes it if ExternalId is empty.
+type SetScimExternalIdCommand struct {
+	OrgId        int64
+	ResourceType string
+	ResourceId   int64
+	ExternalId   string
+}
+
+// ---------------------
+// QUERIES
+
+type GetScimTokensQuery struct {
+	OrgId  int64
+	Result []*ScimToken
+}
+
+type GetScimTokenByNameQuery struct {
+	OrgId  int64
+	Name   string
+	Result *ScimToken
+}
+
+// GetScimUsersQuery returns the members of the organization, or only the
+// given user if UserId is set. UserIds, Login and ExternalId further restrict
+// the members, with Login compared case-insensitively. If Limit is set, only
+// that page of members is returned and TotalCount holds the number of all
+// matching members.
+type GetScimUsersQuery struct {
+	OrgId      int64
+	UserId     int64
+	UserIds    []int64
+	Login      string
+	ExternalId string
+	Limit      int
+	Offset     int
+
+	Result     []*ScimUser
+	TotalCount int64
+}
+
+// GetScimGroupsQuery returns the teams of the organization, or only the
+// given team if TeamId is set.
+type GetScimGroupsQuery struct {
+	OrgId  int64
+	TeamId int64
+	Result []*ScimGroup
+}
+
+// ----------------------
+// Projections and DTOs
+
+type ScimTokenDTO struct {
+	Id      int64     `json:"id"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+}
+
+type ScimUser struct {
+	Id         int64
+	Login      string
+	Email      string
+	Name       string
+	IsAdmin    bool
+	IsDisabled bool
+	Role       RoleType
+	ExternalId string
+	Created    time.Time
+	Updated    time.Time
+}
+
+type ScimGroup struct {
+	Id         int64
+	Name       string
+	ExternalId string
+	Created    time.Time
+	Updated    time.Time
+}
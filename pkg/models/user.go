@@ -103,6 +103,10 @@ type BatchDisableUsersCommand struct {
 
 type DeleteUserCommand struct {
 	UserId int64
+	// ReassignToUserId is the user that becomes the creator of the
+	// dashboards, folders, dashboard versions, annotations and snapshots of
+	// the deleted user, 0 to keep them.
+	ReassignToUserId int64
 }
 
 type SetUsingOrgCommand struct {
@@ -183,6 +187,10 @@ type SignedInUser struct {
 	HelpFlags1     HelpFlags1
 	LastSeenAt     time.Time
 	Teams          []int64
+
+	// OrgSessionIdleTimeout is the session idle timeout in minutes of the
+	// current org, or 0 if it's not set.
+	OrgSessionIdleTimeout int
 }
 
 func (u *SignedInUser) ShouldUpdateLastSeenAt() bool {
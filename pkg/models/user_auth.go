@@ -7,7 +7,10 @@ import (
 )
 
 const (
-	AuthModuleLDAP = "ldap"
+	AuthModuleGrafana   = "grafana"
+	AuthModuleLDAP      = "ldap"
+	AuthModuleAuthProxy = "authproxy"
+	AuthModuleJWT       = "jwt"
 )
 
 type UserAuth struct {
@@ -69,11 +72,25 @@ type DeleteAuthInfoCommand struct {
 // QUERIES
 
 type LoginUserQuery struct {
-	ReqContext *ReqContext
-	Username   string
-	Password   string
-	User       *User
-	IpAddress  string
+	ReqContext      *ReqContext
+	Username        string
+	Password        string
+	MfaCode         string
+	MfaRecoveryCode string
+	User            *User
+	IpAddress       string
+
+	// AuthModule is set to the module that authenticated the user.
+	AuthModule string
+
+	// Set when two-factor enrolment is enforced and has to be completed
+	// as part of the login.
+	MfaEnrollment    *UserMfaEnrollment
+	MfaRecoveryCodes []string
+
+	// Set when the password of the user has expired, to set a new one with
+	// POST /api/user/password/reset.
+	PasswordResetCode string
 }
 
 type GetUserByAuthInfoQuery struct {
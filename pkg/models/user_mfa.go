@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrUserMfaNotFound = errors.New("Two-factor authentication is not configured for user")
+	ErrUserMfaCodeUsed = errors.New("Two-factor authentication code has already been used")
+)
+
+// UserMfa holds the TOTP secret and hashed recovery codes of a user. Until
+// IsEnabled is set the secret is pending, i.e. enrolment has been started
+// but not yet confirmed with a valid code. LastTotpStep is the time step
+// of the last accepted TOTP code, so that codes can't be replayed.
+type UserMfa struct {
+	Id            int64
+	UserId        int64
+	Secret        string
+	RecoveryCodes []string
+	IsEnabled     bool
+	LastTotpStep  int64
+	Created       time.Time
+	Updated       time.Time
+}
+
+// UserMfaEnrollment is returned when a user starts enrolment, to be shown
+// as QR code or entered manually in an authenticator app.
+type UserMfaEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioningUri"`
+}
+
+// ---------------------
+// COMMANDS
+
+type SaveUserMfaCommand struct {
+	UserId        int64
+	Secret        string
+	RecoveryCodes []string
+	IsEnabled     bool
+}
+
+// UseUserMfaTotpStepCommand records the time step of an accepted TOTP code.
+// It fails with ErrUserMfaCodeUsed unless the step is newer than the last
+// accepted one.
+type UseUserMfaTotpStepCommand struct {
+	UserId int64
+	Step   int64
+}
+
+// UseUserMfaRecoveryCodeCommand replaces the recovery codes of the user with
+// the remaining ones after one was used. It fails with ErrUserMfaCodeUsed if
+// the recovery codes have changed since they were read.
+type UseUserMfaRecoveryCodeCommand struct {
+	UserId        int64
+	RecoveryCodes []string
+	Remaining     []string
+}
+
+type DeleteUserMfaCommand struct {
+	UserId int64
+}
+
+// ---------------------
+// QUERIES
+
+type GetUserMfaQuery struct {
+	UserId int64
+	Result *UserMfa
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// UserPasswordHistory is a password hash previously set for a user, kept to
+// prevent password reuse and to know when the password was last changed.
+type UserPasswordHistory struct {
+	Id       int64
+	UserId   int64
+	Password string
+	Created  time.Time
+}
+
+// ---------------------
+// QUERIES
+
+// GetUserPasswordHistoryQuery returns the most recent passwords of the user,
+// newest first.
+type GetUserPasswordHistoryQuery struct {
+	UserId int64
+	Limit  int
+
+	Result []*UserPasswordHistory
+}
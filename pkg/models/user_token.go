@@ -23,16 +23,43 @@ type UserToken struct {
 	RotatedAt     int64
 	CreatedAt     int64
 	UpdatedAt     int64
+	AuthModule    string
 	UnhashedToken string
 }
 
+// UserTokenWithUser is a user token together with the user it belongs to,
+// used when listing sessions of all users.
+type UserTokenWithUser struct {
+	UserToken
+	Login string
+	Email string
+}
+
 type RevokeAuthTokenCmd struct {
 	AuthTokenId int64 `json:"authTokenId"`
 }
 
+// RevokeAllAuthTokensCmd revokes the sessions of all users, or only the ones
+// created through the given auth module.
+type RevokeAllAuthTokensCmd struct {
+	AuthModule string `json:"authModule"`
+}
+
+type SearchUserTokensQuery struct {
+	UserId     int64
+	AuthModule string
+	Page       int
+	Limit      int
+}
+
+type SearchUserTokensResult struct {
+	TotalCount int64
+	Tokens     []*UserTokenWithUser
+}
+
 // UserTokenService are used for generating and validating user tokens
 type UserTokenService interface {
-	CreateToken(ctx context.Context, userId int64, clientIP, userAgent string) (*UserToken, error)
+	CreateToken(ctx context.Context, userId int64, clientIP, userAgent, authModule string) (*UserToken, error)
 	LookupToken(ctx context.Context, unhashedToken string) (*UserToken, error)
 	TryRotateToken(ctx context.Context, token *UserToken, clientIP, userAgent string) (bool, error)
 	RevokeToken(ctx context.Context, token *UserToken) error
@@ -40,4 +67,6 @@ type UserTokenService interface {
 	ActiveTokenCount(ctx context.Context) (int64, error)
 	GetUserToken(ctx context.Context, userId, userTokenId int64) (*UserToken, error)
 	GetUserTokens(ctx context.Context, userId int64) ([]*UserToken, error)
+	RevokeAllTokens(ctx context.Context, authModule string, exceptTokenId int64) (int64, error)
+	SearchUserTokens(ctx context.Context, query *SearchUserTokensQuery) (*SearchUserTokensResult, error)
 }
@@ -58,6 +58,7 @@ func (a *queryEndpointAdapter) Query(ctx context.Context, ds *models.DataSource,
 			User:                       wrapper.BackendUserFromSignedInUser(query.User),
 			DataSourceInstanceSettings: instanceSettings,
 		},
+		Headers: query.Headers,
 		Queries: []backend.DataQuery{},
 	}
 
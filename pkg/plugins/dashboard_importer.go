@@ -19,6 +19,7 @@ type ImportDashboardCommand struct {
 	Inputs    []ImportDashboardInput
 	Overwrite bool
 	FolderId  int64
+	Strict    bool
 
 	OrgId    int64
 	User     *models.SignedInUser
@@ -81,6 +82,7 @@ func ImportDashboard(cmd *ImportDashboardCommand) error {
 		Dashboard: saveCmd.GetDashboardModel(),
 		Overwrite: saveCmd.Overwrite,
 		User:      cmd.User,
+		Strict:    cmd.Strict,
 	}
 
 	savedDash, err := dashboards.NewService().ImportDashboard(dto)
@@ -58,6 +58,7 @@ func (tw *DatasourcePluginWrapperV2) Query(ctx context.Context, ds *models.DataS
 			User:                       backend.ToProto().User(BackendUserFromSignedInUser(query.User)),
 			DataSourceInstanceSettings: backend.ToProto().DataSourceInstanceSettings(instanceSettings),
 		},
+		Headers: query.Headers,
 		Queries: []*pluginv2.DataQuery{},
 	}
 
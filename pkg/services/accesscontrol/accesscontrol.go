@@ -0,0 +1,77 @@
+package accesscontrol
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// AccessControl evaluates the permissions a signed in user has been granted
+// in their current org, through their built-in role and any custom roles
+// assigned to them or their teams.
+type AccessControl interface {
+	// IsDisabled returns true if fine-grained access control is turned off
+	// and callers should fall back to the built-in role checks.
+	IsDisabled() bool
+
+	// Evaluate returns true if the user has been granted the action.
+	Evaluate(ctx context.Context, user *models.SignedInUser, action string) (bool, error)
+
+	// GetUserPermissions returns all actions the user has been granted.
+	GetUserPermissions(ctx context.Context, user *models.SignedInUser) ([]string, error)
+}
+
+// Actions that can be granted through roles. The part before the colon is
+// the resource, the part after is the operation.
+const (
+	ActionOrgUsersRead       = "org.users:read"
+	ActionOrgUsersAdd        = "org.users:add"
+	ActionOrgUsersRoleUpdate = "org.users.role:update"
+	ActionOrgUsersRemove     = "org.users:remove"
+
+	ActionTeamsCreate = "teams:create"
+	ActionTeamsWrite  = "teams:write"
+	ActionTeamsDelete = "teams:delete"
+
+	ActionUsersRead              = "users:read"
+	ActionUsersWrite             = "users:write"
+	ActionUsersCreate            = "users:create"
+	ActionUsersDelete            = "users:delete"
+	ActionUsersDisable           = "users:disable"
+	ActionUsersLogout            = "users:logout"
+	ActionUsersPasswordUpdate    = "users.password:update"
+	ActionUsersPermissionsUpdate = "users.permissions:update"
+	ActionUsersQuotasRead        = "users.quotas:read"
+	ActionUsersQuotasUpdate      = "users.quotas:update"
+	ActionUsersMfaReset          = "users.mfa:reset"
+	ActionUsersLockoutsRead      = "users.lockouts:read"
+	ActionUsersLockoutsDelete    = "users.lockouts:delete"
+
+	ActionDatasourcesRead             = "datasources:read"
+	ActionDatasourcesCreate           = "datasources:create"
+	ActionDatasourcesWrite            = "datasources:write"
+	ActionDatasourcesDelete           = "datasources:delete"
+	ActionDatasourcesPermissionsRead  = "datasources.permissions:read"
+	ActionDatasourcesPermissionsWrite = "datasources.permissions:write"
+
+	ActionRolesRead   = "roles:read"
+	ActionRolesWrite  = "roles:write"
+	ActionRolesDelete = "roles:delete"
+)
+
+// IsValidAction returns true if the action is known and can be part of a
+// custom role. Custom roles are scoped to an org, so the server-wide actions
+// of the Grafana Admin role can't be part of them.
+func IsValidAction(action string) bool {
+	for name, role := range BuiltInRoles(true) {
+		if name == RoleGrafanaAdmin {
+			continue
+		}
+		for _, a := range role {
+			if a == action {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package accesscontrol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGetUserPermissions(t *testing.T) {
+	bus.ClearBusHandlers()
+	defer bus.ClearBusHandlers()
+
+	bus.AddHandlerCtx("test", func(ctx context.Context, query *models.GetUserRolesQuery) error {
+		if query.UserId == 2 {
+			query.Result = []*models.CustomRoleDTO{
+				{Name: "user manager", Permissions: []string{ActionOrgUsersRead, ActionOrgUsersAdd, ActionUsersWrite}},
+				{Name: "team manager", Permissions: []string{ActionTeamsWrite, ActionOrgUsersRead}},
+			}
+		}
+		return nil
+	})
+
+	ac := &OSSAccessControlService{Cfg: &setting.Cfg{
+		FeatureToggles: map[string]bool{"accesscontrol": true},
+	}}
+	require.False(t, ac.IsDisabled())
+
+	t.Run("Viewer without custom roles has no permissions", func(t *testing.T) {
+		permissions, err := ac.GetUserPermissions(context.Background(), &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_VIEWER})
+		require.NoError(t, err)
+		assert.Empty(t, permissions)
+	})
+
+	t.Run("Editor gets permissions of custom roles", func(t *testing.T) {
+		user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+		permissions, err := ac.GetUserPermissions(context.Background(), user)
+		require.NoError(t, err)
+		assert.Equal(t, []string{ActionOrgUsersAdd, ActionOrgUsersRead, ActionTeamsWrite}, permissions)
+
+		granted, err := ac.Evaluate(context.Background(), user, ActionOrgUsersAdd)
+		require.NoError(t, err)
+		assert.True(t, granted)
+
+		granted, err = ac.Evaluate(context.Background(), user, ActionOrgUsersRemove)
+		require.NoError(t, err)
+		assert.False(t, granted)
+	})
+
+	t.Run("Custom roles don't grant server admin permissions", func(t *testing.T) {
+		user := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+		granted, err := ac.Evaluate(context.Background(), user, ActionUsersWrite)
+		require.NoError(t, err)
+		assert.False(t, granted)
+	})
+
+	t.Run("Editor can create teams if editors can admin", func(t *testing.T) {
+		ac := &OSSAccessControlService{Cfg: &setting.Cfg{EditorsCanAdmin: true}}
+		granted, err := ac.Evaluate(context.Background(), &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_EDITOR}, ActionTeamsCreate)
+		require.NoError(t, err)
+		assert.True(t, granted)
+	})
+
+	t.Run("Org admin and Grafana admin get built-in permissions", func(t *testing.T) {
+		user := &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+		granted, err := ac.Evaluate(context.Background(), user, ActionRolesWrite)
+		require.NoError(t, err)
+		assert.True(t, granted)
+
+		granted, err = ac.Evaluate(context.Background(), user, ActionUsersWrite)
+		require.NoError(t, err)
+		assert.False(t, granted)
+
+		user.IsGrafanaAdmin = true
+		granted, err = ac.Evaluate(context.Background(), user, ActionUsersWrite)
+		require.NoError(t, err)
+		assert.True(t, granted)
+	})
+}
+
+func TestIsValidAction(t *testing.T) {
+	assert.True(t, IsValidAction(ActionTeamsCreate))
+	assert.True(t, IsValidAction(ActionDatasourcesPermissionsWrite))
+	assert.False(t, IsValidAction(ActionUsersDisable))
+	assert.False(t, IsValidAction(ActionUsersPermissionsUpdate))
+	assert.False(t, IsValidAction("dashboards:fly"))
+}
@@ -0,0 +1,80 @@
+package accesscontrol
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// RoleGrafanaAdmin is the name of the built-in role of server admins.
+const RoleGrafanaAdmin = "Grafana Admin"
+
+var orgAdminActions = []string{
+	ActionOrgUsersRead,
+	ActionOrgUsersAdd,
+	ActionOrgUsersRoleUpdate,
+	ActionOrgUsersRemove,
+	ActionTeamsCreate,
+	ActionTeamsWrite,
+	ActionTeamsDelete,
+	ActionDatasourcesRead,
+	ActionDatasourcesCreate,
+	ActionDatasourcesWrite,
+	ActionDatasourcesDelete,
+	ActionDatasourcesPermissionsRead,
+	ActionDatasourcesPermissionsWrite,
+	ActionRolesRead,
+	ActionRolesWrite,
+	ActionRolesDelete,
+}
+
+var grafanaAdminActions = []string{
+	ActionUsersRead,
+	ActionUsersWrite,
+	ActionUsersCreate,
+	ActionUsersDelete,
+	ActionUsersDisable,
+	ActionUsersLogout,
+	ActionUsersPasswordUpdate,
+	ActionUsersPermissionsUpdate,
+	ActionUsersQuotasRead,
+	ActionUsersQuotasUpdate,
+	ActionUsersMfaReset,
+	ActionUsersLockoutsRead,
+	ActionUsersLockoutsDelete,
+}
+
+// BuiltInRoles maps the built-in roles to the actions they grant, matching
+// what the role allowed before fine-grained access control. Editors can
+// create teams only if editors_can_admin is enabled, and will then be able
+// to manage the teams they're an admin of.
+func BuiltInRoles(editorsCanAdmin bool) map[string][]string {
+	editorActions := []string{}
+	if editorsCanAdmin {
+		editorActions = []string{ActionTeamsCreate}
+	}
+
+	return map[string][]string{
+		string(models.ROLE_VIEWER): {},
+		string(models.ROLE_EDITOR): editorActions,
+		string(models.ROLE_ADMIN):  orgAdminActions,
+		RoleGrafanaAdmin:           grafanaAdminActions,
+	}
+}
+
+// builtInActions returns the actions granted to the user by their org role
+// and, for server admins, the Grafana Admin role.
+func builtInActions(user *models.SignedInUser, editorsCanAdmin bool) []string {
+	roles := BuiltInRoles(editorsCanAdmin)
+
+	actions := make([]string, 0)
+	for _, role := range []models.RoleType{models.ROLE_VIEWER, models.ROLE_EDITOR, models.ROLE_ADMIN} {
+		if user.OrgRole.Includes(role) {
+			actions = append(actions, roles[string(role)]...)
+		}
+	}
+
+	if user.IsGrafanaAdmin {
+		actions = append(actions, roles[RoleGrafanaAdmin]...)
+	}
+
+	return actions
+}
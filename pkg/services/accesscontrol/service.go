@@ -0,0 +1,79 @@
+package accesscontrol
+
+import (
+	"context"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	registry.RegisterService(&OSSAccessControlService{})
+}
+
+// OSSAccessControlService evaluates permissions from the built-in roles and
+// the custom roles stored in the database.
+type OSSAccessControlService struct {
+	Cfg *setting.Cfg `inject:""`
+}
+
+func (ac *OSSAccessControlService) Init() error {
+	return nil
+}
+
+func (ac *OSSAccessControlService) IsDisabled() bool {
+	return ac.Cfg == nil || !ac.Cfg.IsAccessControlEnabled()
+}
+
+func (ac *OSSAccessControlService) Evaluate(ctx context.Context, user *models.SignedInUser, action string) (bool, error) {
+	permissions, err := ac.GetUserPermissions(ctx, user)
+	if err != nil {
+		return false, err
+	}
+
+	for _, permission := range permissions {
+		if permission == action {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user *models.SignedInUser) ([]string, error) {
+	editorsCanAdmin := ac.Cfg != nil && ac.Cfg.EditorsCanAdmin
+	actions := builtInActions(user, editorsCanAdmin)
+
+	// API keys and anonymous users only get the permissions of their role.
+	if user.IsRealUser() && user.OrgId > 0 {
+		query := models.GetUserRolesQuery{OrgId: user.OrgId, UserId: user.UserId}
+		if err := bus.DispatchCtx(ctx, &query); err != nil {
+			return nil, err
+		}
+
+		// Server admin actions are only granted by the Grafana Admin role,
+		// even if they have been stored in a custom role.
+		for _, role := range query.Result {
+			for _, action := range role.Permissions {
+				if IsValidAction(action) {
+					actions = append(actions, action)
+				}
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(actions))
+	permissions := make([]string, 0, len(actions))
+	for _, action := range actions {
+		if !seen[action] {
+			seen[action] = true
+			permissions = append(permissions, action)
+		}
+	}
+	sort.Strings(permissions)
+
+	return permissions, nil
+}
@@ -53,7 +53,7 @@ func (s *UserAuthTokenService) ActiveTokenCount(ctx context.Context) (int64, err
 	return count, err
 }
 
-func (s *UserAuthTokenService) CreateToken(ctx context.Context, userId int64, clientAddr, userAgent string) (*models.UserToken, error) {
+func (s *UserAuthTokenService) CreateToken(ctx context.Context, userId int64, clientAddr, userAgent, authModule string) (*models.UserToken, error) {
 	clientIP, err := util.ParseIPAddress(clientAddr)
 	if err != nil {
 		s.log.Debug("Failed to parse client IP address", "clientAddr", clientAddr, "err", err)
@@ -79,11 +79,15 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, userId int64, cl
 		UpdatedAt:     now,
 		SeenAt:        0,
 		AuthTokenSeen: false,
+		AuthModule:    authModule,
 	}
 
-	err = s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
-		_, err = dbSession.Insert(&userAuthToken)
-		return err
+	err = s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		if _, err := dbSession.Insert(&userAuthToken); err != nil {
+			return err
+		}
+
+		return s.revokeExceedingTokens(dbSession, userId)
 	})
 
 	if err != nil {
@@ -350,6 +354,39 @@ func (s *UserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context, use
 	})
 }
 
+// RevokeAllTokens revokes the tokens of all users, or only the tokens created
+// by the given auth module together with the tokens of users that are linked
+// to it. The token with the id exceptTokenId is kept, so that the user
+// revoking the tokens isn't logged out.
+func (s *UserAuthTokenService) RevokeAllTokens(ctx context.Context, authModule string, exceptTokenId int64) (int64, error) {
+	var affected int64
+	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		sql := `DELETE FROM user_auth_token WHERE id <> ?`
+		params := []interface{}{exceptTokenId}
+
+		if authModule != "" {
+			sql += ` AND (auth_module = ? OR user_id IN (SELECT user_id FROM user_auth WHERE auth_module = ?))`
+			params = append(params, authModule, authModule)
+		}
+
+		res, err := dbSession.Exec(append([]interface{}{sql}, params...)...)
+		if err != nil {
+			return err
+		}
+
+		affected, err = res.RowsAffected()
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	s.log.Info("user auth tokens revoked", "authModule", authModule, "count", affected)
+
+	return affected, nil
+}
+
 func (s *UserAuthTokenService) GetUserToken(ctx context.Context, userId, userTokenId int64) (*models.UserToken, error) {
 	var result models.UserToken
 	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
@@ -396,6 +433,94 @@ func (s *UserAuthTokenService) GetUserTokens(ctx context.Context, userId int64)
 	return result, err
 }
 
+// SearchUserTokens returns the active tokens of all users, most recently
+// created first.
+func (s *UserAuthTokenService) SearchUserTokens(ctx context.Context, query *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	result := &models.SearchUserTokensResult{Tokens: []*models.UserTokenWithUser{}}
+	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		filter := func(sess *sqlstore.DBSession) *sqlstore.DBSession {
+			sess.Table("user_auth_token").
+				Join("INNER", s.SQLStore.Dialect.Quote("user"), "user_auth_token.user_id = "+s.SQLStore.Dialect.Quote("user")+".id").
+				Where("user_auth_token.created_at > ? AND user_auth_token.rotated_at > ?", s.createdAfterParam(), s.rotatedAfterParam())
+
+			if query.UserId > 0 {
+				sess.And("user_auth_token.user_id = ?", query.UserId)
+			}
+			if query.AuthModule != "" {
+				sess.And("user_auth_token.auth_module = ?", query.AuthModule)
+			}
+
+			return sess
+		}
+
+		count, err := filter(dbSession).Count(&userAuthToken{})
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		var tokens []*userAuthTokenWithUser
+		err = filter(dbSession).
+			Cols("user_auth_token.*", s.SQLStore.Dialect.Quote("user")+".login", s.SQLStore.Dialect.Quote("user")+".email").
+			Desc("user_auth_token.created_at").
+			Limit(query.Limit, (query.Page-1)*query.Limit).
+			Find(&tokens)
+		if err != nil {
+			return err
+		}
+
+		for _, token := range tokens {
+			item := &models.UserTokenWithUser{Login: token.Login, Email: token.Email}
+			if err := token.toUserToken(&item.UserToken); err != nil {
+				return err
+			}
+			result.Tokens = append(result.Tokens, item)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// revokeExceedingTokens revokes the oldest tokens of the user if the user has
+// more than the configured maximum number of active sessions.
+func (s *UserAuthTokenService) revokeExceedingTokens(dbSession *sqlstore.DBSession, userId int64) error {
+	if s.Cfg.LoginMaxActiveSessions <= 0 {
+		return nil
+	}
+
+	var tokens []*userAuthToken
+	err := dbSession.Cols("id").
+		Where("user_id = ? AND created_at > ? AND rotated_at > ?", userId, s.createdAfterParam(), s.rotatedAfterParam()).
+		Desc("created_at").Desc("id").
+		Find(&tokens)
+	if err != nil {
+		return err
+	}
+
+	if len(tokens) <= s.Cfg.LoginMaxActiveSessions {
+		return nil
+	}
+
+	for _, token := range tokens[s.Cfg.LoginMaxActiveSessions:] {
+		if _, err := dbSession.Exec("DELETE FROM user_auth_token WHERE id = ?", token.Id); err != nil {
+			return err
+		}
+	}
+
+	s.log.Debug("oldest user auth tokens revoked", "userId", userId, "count", len(tokens)-s.Cfg.LoginMaxActiveSessions)
+
+	return nil
+}
+
 func (s *UserAuthTokenService) createdAfterParam() int64 {
 	tokenMaxLifetime := time.Duration(s.Cfg.LoginMaxLifetimeDays) * 24 * time.Hour
 	return getTime().Add(-tokenMaxLifetime).Unix()
@@ -27,7 +27,7 @@ func TestUserAuthToken(t *testing.T) {
 		}
 
 		Convey("When creating token", func() {
-			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent")
+			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent", models.AuthModuleGrafana)
 			So(err, ShouldBeNil)
 			So(userToken, ShouldNotBeNil)
 			So(userToken.AuthTokenSeen, ShouldBeFalse)
@@ -78,7 +78,7 @@ func TestUserAuthToken(t *testing.T) {
 			})
 
 			Convey("When creating an additional token", func() {
-				userToken2, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent")
+				userToken2, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent", models.AuthModuleGrafana)
 				So(err, ShouldBeNil)
 				So(userToken2, ShouldNotBeNil)
 
@@ -124,7 +124,7 @@ func TestUserAuthToken(t *testing.T) {
 					for i := 0; i < 3; i++ {
 						userId := userID + int64(i+1)
 						userIds = append(userIds, userId)
-						_, err := userAuthTokenService.CreateToken(context.Background(), userId, "192.168.10.11:1234", "some user agent")
+						_, err := userAuthTokenService.CreateToken(context.Background(), userId, "192.168.10.11:1234", "some user agent", models.AuthModuleGrafana)
 						So(err, ShouldBeNil)
 					}
 
@@ -141,7 +141,7 @@ func TestUserAuthToken(t *testing.T) {
 		})
 
 		Convey("expires correctly", func() {
-			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent")
+			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent", models.AuthModuleGrafana)
 			So(err, ShouldBeNil)
 
 			userToken, err = userAuthTokenService.LookupToken(context.Background(), userToken.UnhashedToken)
@@ -221,7 +221,7 @@ func TestUserAuthToken(t *testing.T) {
 		})
 
 		Convey("can properly rotate tokens", func() {
-			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent")
+			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent", models.AuthModuleGrafana)
 			So(err, ShouldBeNil)
 
 			prevToken := userToken.AuthToken
@@ -302,7 +302,7 @@ func TestUserAuthToken(t *testing.T) {
 		})
 
 		Convey("keeps prev token valid for 1 minute after it is confirmed", func() {
-			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent")
+			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent", models.AuthModuleGrafana)
 			So(err, ShouldBeNil)
 			So(userToken, ShouldNotBeNil)
 
@@ -333,7 +333,7 @@ func TestUserAuthToken(t *testing.T) {
 		})
 
 		Convey("will not mark token unseen when prev and current are the same", func() {
-			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent")
+			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent", models.AuthModuleGrafana)
 			So(err, ShouldBeNil)
 			So(userToken, ShouldNotBeNil)
 
@@ -352,7 +352,7 @@ func TestUserAuthToken(t *testing.T) {
 		})
 
 		Convey("Rotate token", func() {
-			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent")
+			userToken, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent", models.AuthModuleGrafana)
 			So(err, ShouldBeNil)
 			So(userToken, ShouldNotBeNil)
 
@@ -492,6 +492,114 @@ func TestUserAuthToken(t *testing.T) {
 	})
 }
 
+func TestUserAuthTokenSessions(t *testing.T) {
+	Convey("Test user auth token sessions", t, func() {
+		ctx := createTestContext(t)
+		userAuthTokenService := ctx.tokenService
+
+		now := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
+		getTime = func() time.Time {
+			return now
+		}
+
+		createUser := func(login string) int64 {
+			cmd := &models.CreateUserCommand{Login: login, Email: login + "@test.com"}
+			err := sqlstore.CreateUser(context.Background(), cmd)
+			So(err, ShouldBeNil)
+			return cmd.Result.Id
+		}
+
+		createToken := func(userID int64, authModule string) *models.UserToken {
+			now = now.Add(time.Minute)
+			token, err := userAuthTokenService.CreateToken(context.Background(), userID, "192.168.10.11:1234", "some user agent", authModule)
+			So(err, ShouldBeNil)
+			return token
+		}
+
+		Convey("When the maximum number of active sessions is exceeded", func() {
+			userAuthTokenService.Cfg.LoginMaxActiveSessions = 2
+			userID := createUser("limited")
+
+			first := createToken(userID, models.AuthModuleGrafana)
+			second := createToken(userID, models.AuthModuleGrafana)
+			third := createToken(userID, models.AuthModuleGrafana)
+
+			Convey("Should revoke the oldest session", func() {
+				tokens, err := userAuthTokenService.GetUserTokens(context.Background(), userID)
+				So(err, ShouldBeNil)
+				So(tokens, ShouldHaveLength, 2)
+
+				_, err = userAuthTokenService.GetUserToken(context.Background(), userID, first.Id)
+				So(err, ShouldEqual, models.ErrUserTokenNotFound)
+
+				ids := []int64{tokens[0].Id, tokens[1].Id}
+				So(ids, ShouldContain, second.Id)
+				So(ids, ShouldContain, third.Id)
+			})
+		})
+
+		Convey("Given sessions of users of several auth modules", func() {
+			grafanaUser := createUser("grafana-user")
+			ldapUser := createUser("ldap-user")
+			err := sqlstore.SetAuthInfo(&models.SetAuthInfoCommand{UserId: ldapUser, AuthModule: models.AuthModuleLDAP, AuthId: "ldap-user"})
+			So(err, ShouldBeNil)
+
+			admin := createToken(grafanaUser, models.AuthModuleGrafana)
+			createToken(grafanaUser, "oauth_github")
+			ldap := createToken(ldapUser, models.AuthModuleLDAP)
+			// Tokens created before the auth module was recorded
+			legacy := createToken(ldapUser, "")
+
+			Convey("Should list sessions with the users", func() {
+				result, err := userAuthTokenService.SearchUserTokens(context.Background(), &models.SearchUserTokensQuery{})
+				So(err, ShouldBeNil)
+				So(result.TotalCount, ShouldEqual, 4)
+				So(result.Tokens, ShouldHaveLength, 4)
+				So(result.Tokens[0].Id, ShouldEqual, legacy.Id)
+				So(result.Tokens[0].Login, ShouldEqual, "ldap-user")
+				So(result.Tokens[1].AuthModule, ShouldEqual, models.AuthModuleLDAP)
+				So(result.Tokens[3].Email, ShouldEqual, "grafana-user@test.com")
+
+				result, err = userAuthTokenService.SearchUserTokens(context.Background(), &models.SearchUserTokensQuery{AuthModule: "oauth_github"})
+				So(err, ShouldBeNil)
+				So(result.TotalCount, ShouldEqual, 1)
+				So(result.Tokens[0].UserId, ShouldEqual, grafanaUser)
+
+				result, err = userAuthTokenService.SearchUserTokens(context.Background(), &models.SearchUserTokensQuery{UserId: ldapUser, Limit: 1, Page: 2})
+				So(err, ShouldBeNil)
+				So(result.TotalCount, ShouldEqual, 2)
+				So(result.Tokens, ShouldHaveLength, 1)
+				So(result.Tokens[0].Id, ShouldEqual, ldap.Id)
+			})
+
+			Convey("Should revoke sessions of an auth module", func() {
+				count, err := userAuthTokenService.RevokeAllTokens(context.Background(), models.AuthModuleLDAP, 0)
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 2)
+
+				tokens, err := userAuthTokenService.GetUserTokens(context.Background(), grafanaUser)
+				So(err, ShouldBeNil)
+				So(tokens, ShouldHaveLength, 2)
+			})
+
+			Convey("Should revoke all sessions except the given one", func() {
+				count, err := userAuthTokenService.RevokeAllTokens(context.Background(), "", admin.Id)
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 3)
+
+				result, err := userAuthTokenService.SearchUserTokens(context.Background(), &models.SearchUserTokensQuery{})
+				So(err, ShouldBeNil)
+				So(result.Tokens, ShouldHaveLength, 1)
+				So(result.Tokens[0].Id, ShouldEqual, admin.Id)
+			})
+		})
+
+		Reset(func() {
+			getTime = time.Now
+		})
+	})
+}
+
 func createTestContext(t *testing.T) *testContext {
 	t.Helper()
 
@@ -18,9 +18,16 @@ type userAuthToken struct {
 	RotatedAt     int64
 	CreatedAt     int64
 	UpdatedAt     int64
+	AuthModule    string
 	UnhashedToken string `xorm:"-"`
 }
 
+type userAuthTokenWithUser struct {
+	userAuthToken `xorm:"extends"`
+	Login         string
+	Email         string
+}
+
 func userAuthTokenFromUserToken(ut *models.UserToken) (*userAuthToken, error) {
 	var uat userAuthToken
 	err := uat.fromUserToken(ut)
@@ -43,6 +50,7 @@ func (uat *userAuthToken) fromUserToken(ut *models.UserToken) error {
 	uat.RotatedAt = ut.RotatedAt
 	uat.CreatedAt = ut.CreatedAt
 	uat.UpdatedAt = ut.UpdatedAt
+	uat.AuthModule = ut.AuthModule
 	uat.UnhashedToken = ut.UnhashedToken
 
 	return nil
@@ -64,6 +72,7 @@ func (uat *userAuthToken) toUserToken(ut *models.UserToken) error {
 	ut.RotatedAt = uat.RotatedAt
 	ut.CreatedAt = uat.CreatedAt
 	ut.UpdatedAt = uat.UpdatedAt
+	ut.AuthModule = uat.AuthModule
 	ut.UnhashedToken = uat.UnhashedToken
 
 	return nil
@@ -7,7 +7,7 @@ import (
 )
 
 type FakeUserAuthTokenService struct {
-	CreateTokenProvider         func(ctx context.Context, userId int64, clientIP, userAgent string) (*models.UserToken, error)
+	CreateTokenProvider         func(ctx context.Context, userId int64, clientIP, userAgent, authModule string) (*models.UserToken, error)
 	TryRotateTokenProvider      func(ctx context.Context, token *models.UserToken, clientIP, userAgent string) (bool, error)
 	LookupTokenProvider         func(ctx context.Context, unhashedToken string) (*models.UserToken, error)
 	RevokeTokenProvider         func(ctx context.Context, token *models.UserToken) error
@@ -16,11 +16,13 @@ type FakeUserAuthTokenService struct {
 	GetUserTokenProvider        func(ctx context.Context, userId, userTokenId int64) (*models.UserToken, error)
 	GetUserTokensProvider       func(ctx context.Context, userId int64) ([]*models.UserToken, error)
 	BatchRevokedTokenProvider   func(ctx context.Context, userIds []int64) error
+	RevokeAllTokensProvider     func(ctx context.Context, authModule string, exceptTokenId int64) (int64, error)
+	SearchUserTokensProvider    func(ctx context.Context, query *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error)
 }
 
 func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
 	return &FakeUserAuthTokenService{
-		CreateTokenProvider: func(ctx context.Context, userId int64, clientIP, userAgent string) (*models.UserToken, error) {
+		CreateTokenProvider: func(ctx context.Context, userId int64, clientIP, userAgent, authModule string) (*models.UserToken, error) {
 			return &models.UserToken{
 				UserId:        0,
 				UnhashedToken: "",
@@ -53,11 +55,17 @@ func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
 		GetUserTokensProvider: func(ctx context.Context, userId int64) ([]*models.UserToken, error) {
 			return nil, nil
 		},
+		RevokeAllTokensProvider: func(ctx context.Context, authModule string, exceptTokenId int64) (int64, error) {
+			return 0, nil
+		},
+		SearchUserTokensProvider: func(ctx context.Context, query *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error) {
+			return &models.SearchUserTokensResult{}, nil
+		},
 	}
 }
 
-func (s *FakeUserAuthTokenService) CreateToken(ctx context.Context, userId int64, clientIP, userAgent string) (*models.UserToken, error) {
-	return s.CreateTokenProvider(context.Background(), userId, clientIP, userAgent)
+func (s *FakeUserAuthTokenService) CreateToken(ctx context.Context, userId int64, clientIP, userAgent, authModule string) (*models.UserToken, error) {
+	return s.CreateTokenProvider(context.Background(), userId, clientIP, userAgent, authModule)
 }
 
 func (s *FakeUserAuthTokenService) LookupToken(ctx context.Context, unhashedToken string) (*models.UserToken, error) {
@@ -91,3 +99,11 @@ func (s *FakeUserAuthTokenService) GetUserTokens(ctx context.Context, userId int
 func (s *FakeUserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context, userIds []int64) error {
 	return s.BatchRevokedTokenProvider(ctx, userIds)
 }
+
+func (s *FakeUserAuthTokenService) RevokeAllTokens(ctx context.Context, authModule string, exceptTokenId int64) (int64, error) {
+	return s.RevokeAllTokensProvider(ctx, authModule, exceptTokenId)
+}
+
+func (s *FakeUserAuthTokenService) SearchUserTokens(ctx context.Context, query *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error) {
+	return s.SearchUserTokensProvider(ctx, query)
+}
@@ -0,0 +1,193 @@
+package bundles
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+)
+
+// The archive of a bundle is a zip file with a JSON file per item:
+//
+//	bundle.json
+//	datasources/<uid>.json
+//	folders/<uid>.json
+//	library-panels/<uid>.json
+//	dashboards/<uid>.json
+const (
+	archiveManifest         = "bundle.json"
+	archiveDataSourcesDir   = "datasources"
+	archiveFoldersDir       = "folders"
+	archiveLibraryPanelsDir = "library-panels"
+	archiveDashboardsDir    = "dashboards"
+)
+
+type archiveManifestFile struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
+// WriteArchive writes the bundle as a zip archive.
+func WriteArchive(w io.Writer, bundle *Bundle) error {
+	zw := zip.NewWriter(w)
+
+	if err := writeArchiveFile(zw, archiveManifest, archiveManifestFile{Version: bundle.Version, ExportedAt: bundle.ExportedAt}); err != nil {
+		return err
+	}
+
+	for n, ds := range bundle.DataSources {
+		name := ds.Uid
+		if name == "" {
+			name = fmt.Sprintf("datasource-%d", n+1)
+		}
+		if err := writeArchiveFile(zw, path.Join(archiveDataSourcesDir, name+".json"), ds); err != nil {
+			return err
+		}
+	}
+
+	// folders are numbered to keep them ordered parents first
+	for n, folder := range bundle.Folders {
+		name := fmt.Sprintf("%04d-%s.json", n+1, folder.Uid)
+		if err := writeArchiveFile(zw, path.Join(archiveFoldersDir, name), folder); err != nil {
+			return err
+		}
+	}
+
+	for _, libraryPanel := range bundle.LibraryPanels {
+		if err := writeArchiveFile(zw, path.Join(archiveLibraryPanelsDir, libraryPanel.Uid+".json"), libraryPanel); err != nil {
+			return err
+		}
+	}
+
+	for _, dashboard := range bundle.Dashboards {
+		if err := writeArchiveFile(zw, path.Join(archiveDashboardsDir, dashboard.Uid+".json"), dashboard); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeArchiveFile(zw *zip.Writer, name string, value interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadArchive reads a bundle from a zip archive. Archives whose files are
+// larger than maxSize in total when uncompressed are rejected.
+func ReadArchive(r io.ReaderAt, size int64, maxSize int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+
+	bundle := &Bundle{
+		DataSources:   []*DataSource{},
+		Folders:       []*Folder{},
+		LibraryPanels: []*LibraryPanel{},
+		Dashboards:    []*Dashboard{},
+	}
+
+	hasManifest := false
+	remaining := maxSize
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		dir, name := path.Split(file.Name)
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		switch strings.TrimSuffix(dir, "/") {
+		case "":
+			if name != archiveManifest {
+				continue
+			}
+			var manifest archiveManifestFile
+			if err := readArchiveFile(file, &manifest, &remaining); err != nil {
+				return nil, err
+			}
+			bundle.Version = manifest.Version
+			bundle.ExportedAt = manifest.ExportedAt
+			hasManifest = true
+		case archiveDataSourcesDir:
+			ds := &DataSource{}
+			if err := readArchiveFile(file, ds, &remaining); err != nil {
+				return nil, err
+			}
+			bundle.DataSources = append(bundle.DataSources, ds)
+		case archiveFoldersDir:
+			folder := &Folder{}
+			if err := readArchiveFile(file, folder, &remaining); err != nil {
+				return nil, err
+			}
+			bundle.Folders = append(bundle.Folders, folder)
+		case archiveLibraryPanelsDir:
+			libraryPanel := &LibraryPanel{}
+			if err := readArchiveFile(file, libraryPanel, &remaining); err != nil {
+				return nil, err
+			}
+			bundle.LibraryPanels = append(bundle.LibraryPanels, libraryPanel)
+		case archiveDashboardsDir:
+			dashboard := &Dashboard{}
+			if err := readArchiveFile(file, dashboard, &remaining); err != nil {
+				return nil, err
+			}
+			bundle.Dashboards = append(bundle.Dashboards, dashboard)
+		}
+	}
+
+	if !hasManifest {
+		return nil, fmt.Errorf("%w: %s not found", ErrInvalidBundle, archiveManifest)
+	}
+
+	return bundle, nil
+}
+
+// readArchiveFile decodes the JSON file, reading at most remaining bytes,
+// which are then deducted from remaining. The uncompressed size in the zip
+// header is checked first, but as it can't be trusted, the read is limited
+// too.
+func readArchiveFile(file *zip.File, value interface{}, remaining *int64) error {
+	if file.UncompressedSize64 > uint64(*remaining) {
+		return fmt.Errorf("%w: %s: archive is too large", ErrInvalidBundle, file.Name)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(rc, *remaining+1))
+	if err != nil {
+		return err
+	}
+
+	if int64(len(data)) > *remaining {
+		return fmt.Errorf("%w: %s: archive is too large", ErrInvalidBundle, file.Name)
+	}
+	*remaining -= int64(len(data))
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidBundle, file.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+// Package bundles exports folders, dashboards and library panels of an organization, with their permissions and
+// the data sources they use, as a bundle, and imports bundles into another organization or Grafana instance.
+package bundles
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// BundleVersion is the version of the bundle format.
+const BundleVersion = 1
+
+var (
+	ErrUnsupportedBundleVersion  = errors.New("unsupported bundle version")
+	ErrInvalidBundle             = errors.New("invalid bundle")
+	ErrDataSourceMappingNotFound = errors.New("data source to map to not found")
+	ErrImportConflicts           = errors.New("the bundle conflicts with existing content, enable overwrite to replace it")
+)
+
+// Bundle is an export of folders, dashboards and library panels. Folders are ordered parents first. Items reference
+// their folder by uid, and dashboards and library panels reference data sources by name.
+type Bundle struct {
+	Version       int             `json:"version"`
+	ExportedAt    time.Time       `json:"exportedAt"`
+	DataSources   []*DataSource   `json:"dataSources"`
+	Folders       []*Folder       `json:"folders"`
+	LibraryPanels []*LibraryPanel `json:"libraryPanels"`
+	Dashboards    []*Dashboard    `json:"dashboards"`
+}
+
+// DataSource is the definition of a data source used by the bundle. Passwords and secure JSON data are not
+// exported.
+type DataSource struct {
+	Uid             string           `json:"uid"`
+	Name            string           `json:"name"`
+	Type            string           `json:"type"`
+	Access          models.DsAccess  `json:"access"`
+	Url             string           `json:"url"`
+	User            string           `json:"user"`
+	Database        string           `json:"database"`
+	BasicAuth       bool             `json:"basicAuth"`
+	BasicAuthUser   string           `json:"basicAuthUser"`
+	WithCredentials bool             `json:"withCredentials"`
+	JsonData        *simplejson.Json `json:"jsonData"`
+}
+
+// Folder is an exported folder. ParentUid is empty for folders in the General folder. Permissions is nil for
+// folders that use the permissions of their parent or the default permissions.
+type Folder struct {
+	Uid         string        `json:"uid"`
+	Title       string        `json:"title"`
+	ParentUid   string        `json:"parentUid"`
+	Permissions []*Permission `json:"permissions"`
+}
+
+// Dashboard is an exported dashboard. Library panels are references to the library panels of the bundle.
+type Dashboard struct {
+	Uid         string           `json:"uid"`
+	Title       string           `json:"title"`
+	FolderUid   string           `json:"folderUid"`
+	Dashboard   *simplejson.Json `json:"dashboard"`
+	Permissions []*Permission    `json:"permissions"`
+}
+
+type LibraryPanel struct {
+	Uid       string           `json:"uid"`
+	Name      string           `json:"name"`
+	FolderUid string           `json:"folderUid"`
+	Model     *simplejson.Json `json:"model"`
+}
+
+// Permission is a permission of a folder or dashboard for a role, a team by name or a user by login.
+type Permission struct {
+	Role       *models.RoleType      `json:"role,omitempty"`
+	Team       string                `json:"team,omitempty"`
+	User       string                `json:"user,omitempty"`
+	Permission models.PermissionType `json:"permission"`
+}
+
+// isDataSourceName returns false for references to the built-in data sources and to data source template
+// variables, which don't refer to a data source of the bundle.
+func isDataSourceName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "$") && !strings.HasPrefix(name, "-- ")
+}
+
+// walkDataSources calls fn for the data source names referenced by the JSON model, in panels, queries, template
+// variables and annotations, and replaces them with the returned names.
+func walkDataSources(data *simplejson.Json, fn func(name string) string) {
+	if data == nil {
+		return
+	}
+
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				if name, ok := item.(string); ok && key == "datasource" && isDataSourceName(name) {
+					v[key] = fn(name)
+					continue
+				}
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+
+	walk(data.Interface())
+}
+
+// walkLibraryPanelUids calls fn for the uids of the library panels referenced by the dashboard, including the
+// panels of collapsed rows, and replaces them with the returned uids.
+func walkLibraryPanelUids(data *simplejson.Json, fn func(uid string) string) {
+	if data == nil {
+		return
+	}
+
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for _, p := range panels {
+			panel, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if nested, ok := panel["panels"].([]interface{}); ok {
+				walk(nested)
+			}
+
+			if libraryPanel, ok := panel["libraryPanel"].(map[string]interface{}); ok {
+				if uid, ok := libraryPanel["uid"].(string); ok && uid != "" {
+					libraryPanel["uid"] = fn(uid)
+				}
+			}
+		}
+	}
+
+	walk(data.Get("panels").MustArray())
+}
+
+// copyJSON returns a deep copy of the JSON model.
+func copyJSON(data *simplejson.Json) (*simplejson.Json, error) {
+	if data == nil {
+		return simplejson.New(), nil
+	}
+
+	bytes, err := data.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return simplejson.NewJson(bytes)
+}
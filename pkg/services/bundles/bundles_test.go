@@ -0,0 +1,319 @@
+package bundles
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestExportImport(t *testing.T) {
+	sqlstore.InitTestDB(t)
+
+	createOrg := func(name string) (int64, *models.SignedInUser) {
+		cmd := models.CreateOrgCommand{Name: name}
+		require.NoError(t, bus.Dispatch(&cmd))
+		return cmd.Result.Id, &models.SignedInUser{UserId: 1, OrgId: cmd.Result.Id, OrgRole: models.ROLE_ADMIN}
+	}
+
+	createDataSource := func(orgID int64, name string) {
+		cmd := models.AddDataSourceCommand{OrgId: orgID, Name: name, Type: "prometheus", Access: "proxy",
+			Url: "http://" + name, SecureJsonData: map[string]string{"password": "secret"}}
+		require.NoError(t, bus.Dispatch(&cmd))
+	}
+
+	createTeam := func(orgID int64, name string) int64 {
+		cmd := models.CreateTeamCommand{OrgId: orgID, Name: name}
+		require.NoError(t, bus.Dispatch(&cmd))
+		return cmd.Result.Id
+	}
+
+	getDashboard := func(orgID int64, uid string) *models.Dashboard {
+		query := models.GetDashboardsByOrgQuery{OrgId: orgID}
+		require.NoError(t, bus.Dispatch(&query))
+		for _, dash := range query.Result {
+			if dash.Uid == uid {
+				return dash
+			}
+		}
+		return nil
+	}
+
+	sourceOrgID, sourceUser := createOrg("staging")
+	createDataSource(sourceOrgID, "prometheus-staging")
+	teamID := createTeam(sourceOrgID, "SRE")
+
+	folderService := dashboards.NewFolderService(sourceOrgID, sourceUser)
+	require.NoError(t, folderService.CreateFolder(&models.CreateFolderCommand{Uid: "team", Title: "Team"}))
+	services := models.CreateFolderCommand{Uid: "services", Title: "Services", ParentUid: "team"}
+	require.NoError(t, folderService.CreateFolder(&services))
+
+	require.NoError(t, bus.Dispatch(&models.UpdateDashboardAclCommand{
+		DashboardId: services.Result.Id,
+		Items: []*models.DashboardAcl{
+			{OrgId: sourceOrgID, DashboardId: services.Result.Id, TeamId: teamID, Permission: models.PERMISSION_EDIT,
+				Created: time.Now(), Updated: time.Now()},
+		},
+	}))
+
+	libraryPanel := models.CreateLibraryPanelCommand{
+		OrgId:    sourceOrgID,
+		FolderId: services.Result.Id,
+		Uid:      "cpu",
+		Name:     "CPU",
+		Model:    simplejson.NewFromAny(map[string]interface{}{"type": "graph", "datasource": "prometheus-staging"}),
+	}
+	require.NoError(t, bus.Dispatch(&libraryPanel))
+
+	saveDashboard := func(uid string, folderID int64, data map[string]interface{}) {
+		data["uid"] = uid
+		cmd := models.SaveDashboardCommand{
+			OrgId:     sourceOrgID,
+			FolderId:  folderID,
+			Overwrite: true,
+			Dashboard: simplejson.NewFromAny(data),
+		}
+		require.NoError(t, bus.Dispatch(&cmd))
+	}
+
+	saveDashboard("overview", services.Result.Id, map[string]interface{}{
+		"title": "Overview",
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "type": "graph", "datasource": "prometheus-staging",
+				"targets": []interface{}{map[string]interface{}{"refId": "A", "expr": "up"}}},
+			map[string]interface{}{"id": 2, "libraryPanel": map[string]interface{}{"uid": "cpu", "name": "CPU"}},
+		},
+		"templating": map[string]interface{}{"list": []interface{}{
+			map[string]interface{}{"name": "host", "type": "query", "datasource": "prometheus-staging"},
+			map[string]interface{}{"name": "ds", "type": "datasource", "query": "prometheus"},
+		}},
+	})
+	saveDashboard("other", 0, map[string]interface{}{"title": "Other"})
+
+	bundle, err := Export(ExportOptions{OrgId: sourceOrgID, DashboardUids: []string{"overview"}})
+	require.NoError(t, err)
+
+	t.Run("Should export the dashboards with their folders, library panels and data sources", func(t *testing.T) {
+		require.Len(t, bundle.Folders, 2)
+		assert.Equal(t, &Folder{Uid: "team", Title: "Team"}, bundle.Folders[0])
+		assert.Equal(t, "services", bundle.Folders[1].Uid)
+		assert.Equal(t, "team", bundle.Folders[1].ParentUid)
+		assert.Equal(t, []*Permission{{Team: "SRE", Permission: models.PERMISSION_EDIT}}, bundle.Folders[1].Permissions)
+
+		require.Len(t, bundle.Dashboards, 1)
+		assert.Equal(t, "services", bundle.Dashboards[0].FolderUid)
+		_, hasID := bundle.Dashboards[0].Dashboard.CheckGet("id")
+		assert.False(t, hasID)
+
+		require.Len(t, bundle.LibraryPanels, 1)
+		assert.Equal(t, "services", bundle.LibraryPanels[0].FolderUid)
+
+		require.Len(t, bundle.DataSources, 1)
+		assert.Equal(t, "prometheus-staging", bundle.DataSources[0].Name)
+		assert.Equal(t, "http://prometheus-staging", bundle.DataSources[0].Url)
+	})
+
+	t.Run("Should export the whole organization", func(t *testing.T) {
+		all, err := Export(ExportOptions{OrgId: sourceOrgID})
+		require.NoError(t, err)
+		assert.Len(t, all.Dashboards, 2)
+		assert.Len(t, all.Folders, 2)
+	})
+
+	t.Run("Should write and read archives", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteArchive(&buf, bundle))
+
+		read, err := ReadArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 1024*1024)
+		require.NoError(t, err)
+		assert.Equal(t, BundleVersion, read.Version)
+		assert.Equal(t, bundle.Folders, read.Folders)
+		require.Len(t, read.Dashboards, 1)
+		assert.Equal(t, "Overview", read.Dashboards[0].Dashboard.Get("title").MustString())
+		require.Len(t, read.DataSources, 1)
+		assert.Equal(t, bundle.DataSources[0].Name, read.DataSources[0].Name)
+
+		_, err = ReadArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 100)
+		require.True(t, errors.Is(err, ErrInvalidBundle))
+	})
+
+	targetOrgID, targetUser := createOrg("prod")
+	createDataSource(targetOrgID, "prometheus-prod")
+	createTeam(targetOrgID, "SRE")
+
+	options := ImportOptions{
+		OrgId:       targetOrgID,
+		User:        targetUser,
+		DataSources: map[string]string{"prometheus-staging": "prometheus-prod"},
+	}
+
+	t.Run("Should report the changes of a dry run", func(t *testing.T) {
+		dryRun := options
+		dryRun.DryRun = true
+		result, err := Import(bundle, dryRun)
+		require.NoError(t, err)
+
+		assert.Equal(t, []*Change{{Uid: bundle.DataSources[0].Uid, Title: "prometheus-staging", Action: ActionMap, Target: "prometheus-prod"}}, result.DataSources)
+		assert.Equal(t, []*Change{
+			{Uid: "team", Title: "Team", Action: ActionCreate},
+			{Uid: "services", Title: "Services", Action: ActionCreate},
+		}, result.Folders)
+		assert.Equal(t, ActionCreate, result.LibraryPanels[0].Action)
+		assert.Equal(t, ActionCreate, result.Dashboards[0].Action)
+		assert.Nil(t, getDashboard(targetOrgID, "overview"))
+	})
+
+	t.Run("Should import the bundle with the mapped data sources", func(t *testing.T) {
+		_, err := Import(bundle, options)
+		require.NoError(t, err)
+
+		dash := getDashboard(targetOrgID, "overview")
+		require.NotNil(t, dash)
+		assert.Equal(t, getDashboard(targetOrgID, "services").Id, dash.FolderId)
+		assert.Equal(t, getDashboard(targetOrgID, "team").Id, getDashboard(targetOrgID, "services").FolderId)
+		assert.Equal(t, "prometheus-prod", dash.Data.Get("panels").GetIndex(0).Get("datasource").MustString())
+		assert.Equal(t, "prometheus-prod", dash.Data.Get("templating").Get("list").GetIndex(0).Get("datasource").MustString())
+
+		libraryPanelQuery := models.GetLibraryPanelQuery{OrgId: targetOrgID, Uid: "cpu"}
+		require.NoError(t, bus.Dispatch(&libraryPanelQuery))
+		assert.Equal(t, "prometheus-prod", libraryPanelQuery.Result.Model.Get("datasource").MustString())
+		assert.Equal(t, int64(1), libraryPanelQuery.Result.ConnectedDashboards)
+
+		aclQuery := models.GetDashboardAclInfoListQuery{OrgId: targetOrgID, DashboardId: getDashboard(targetOrgID, "services").Id}
+		require.NoError(t, bus.Dispatch(&aclQuery))
+		var teams []string
+		for _, item := range aclQuery.Result {
+			if !item.Inherited {
+				teams = append(teams, item.Team)
+			}
+		}
+		assert.Equal(t, []string{"SRE"}, teams)
+	})
+
+	t.Run("Should not change content that has not changed", func(t *testing.T) {
+		result, err := Import(bundle, options)
+		require.NoError(t, err)
+		assert.Equal(t, ActionUnchanged, result.Folders[0].Action)
+		assert.Equal(t, ActionUnchanged, result.LibraryPanels[0].Action)
+		assert.Equal(t, ActionUnchanged, result.Dashboards[0].Action)
+	})
+
+	t.Run("Should report conflicts with the diff of changed dashboards", func(t *testing.T) {
+		changed, err := Export(ExportOptions{OrgId: sourceOrgID, DashboardUids: []string{"overview"}})
+		require.NoError(t, err)
+		changed.Dashboards[0].Dashboard.Get("panels").GetIndex(0).Set("title", "Up")
+
+		result, err := Import(changed, options)
+		require.Equal(t, ErrImportConflicts, err)
+		require.Len(t, result.Conflicts(), 1)
+		conflict := result.Conflicts()[0]
+		assert.Equal(t, "overview", conflict.Uid)
+		require.NotNil(t, conflict.Diff)
+		assert.Equal(t, []string{"title"}, conflict.Diff.Panels[0].Properties)
+
+		overwrite := options
+		overwrite.Overwrite = true
+		result, err = Import(changed, overwrite)
+		require.NoError(t, err)
+		assert.Equal(t, ActionUpdate, result.Dashboards[0].Action)
+		assert.Equal(t, "Up", getDashboard(targetOrgID, "overview").Data.Get("panels").GetIndex(0).Get("title").MustString())
+	})
+
+	t.Run("Should import with new uids", func(t *testing.T) {
+		newUids := options
+		newUids.NewUids = true
+		newUids.Uids = map[string]string{"overview": "overview-copy"}
+		newUids.FolderUid = "team"
+		result, err := Import(bundle, newUids)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, "team", result.Folders[0].Uid)
+		assert.Equal(t, "team", result.Folders[0].OriginalUid)
+		assert.Equal(t, ActionCreate, result.Folders[0].Action)
+		assert.Equal(t, "overview-copy", result.Dashboards[0].Uid)
+
+		dash := getDashboard(targetOrgID, "overview-copy")
+		require.NotNil(t, dash)
+		libraryPanelUid := dash.Data.Get("panels").GetIndex(1).Get("libraryPanel").Get("uid").MustString()
+		assert.Equal(t, result.LibraryPanels[0].Uid, libraryPanelUid)
+		assert.NotEqual(t, "cpu", libraryPanelUid)
+		assert.Equal(t, getDashboard(targetOrgID, "team").Id, getDashboard(targetOrgID, result.Folders[0].Uid).FolderId)
+	})
+
+	t.Run("Should fail if a mapped data source does not exist", func(t *testing.T) {
+		missing := options
+		missing.DataSources = map[string]string{"prometheus-staging": "missing"}
+		_, err := Import(bundle, missing)
+		require.True(t, errors.Is(err, ErrDataSourceMappingNotFound))
+	})
+
+	t.Run("Should create missing data sources without secrets", func(t *testing.T) {
+		otherOrgID, otherUser := createOrg("other")
+		result, err := Import(bundle, ImportOptions{OrgId: otherOrgID, User: otherUser})
+		require.NoError(t, err)
+		assert.Equal(t, ActionCreate, result.DataSources[0].Action)
+		assert.Equal(t, []string{`team "SRE" not found, skipping its permission of "Services"`}, result.Warnings)
+
+		query := models.GetDataSourceByNameQuery{OrgId: otherOrgID, Name: "prometheus-staging"}
+		require.NoError(t, bus.Dispatch(&query))
+		assert.Equal(t, "http://prometheus-staging", query.Result.Url)
+		assert.Empty(t, query.Result.SecureJsonData)
+	})
+
+	t.Run("Should not change anything if the import fails", func(t *testing.T) {
+		origQuota := setting.Quota
+		defer func() { setting.Quota = origQuota }()
+		setting.Quota = setting.QuotaSettings{
+			Enabled: true,
+			Org: &setting.OrgQuota{User: -1, DataSource: -1, Dashboard: 2, ApiKey: -1, Alert: -1, Annotation: -1,
+				DashboardVersion: -1, Snapshot: -1, Playlist: -1, DashboardBytes: -1},
+		}
+
+		quotaOrgID, quotaUser := createOrg("quota")
+		_, err := Import(bundle, ImportOptions{OrgId: quotaOrgID, User: quotaUser})
+		require.Error(t, err)
+		assert.True(t, errors.As(err, &models.QuotaReachedError{}))
+
+		assert.Nil(t, getDashboard(quotaOrgID, "team"))
+		assert.Nil(t, getDashboard(quotaOrgID, "services"))
+		dataSourceQuery := models.GetDataSourcesQuery{OrgId: quotaOrgID}
+		require.NoError(t, bus.Dispatch(&dataSourceQuery))
+		assert.Empty(t, dataSourceQuery.Result)
+		libraryPanelQuery := models.GetLibraryPanelQuery{OrgId: quotaOrgID, Uid: "cpu"}
+		assert.Equal(t, models.ErrLibraryPanelNotFound, bus.Dispatch(&libraryPanelQuery))
+	})
+
+	t.Run("Should only grant permissions to members of the organization", func(t *testing.T) {
+		member := models.CreateUserCommand{Login: "member", Email: "member@example.com"}
+		require.NoError(t, bus.Dispatch(&member))
+		require.NoError(t, bus.Dispatch(&models.AddOrgUserCommand{OrgId: targetOrgID, UserId: member.Result.Id, Role: models.ROLE_VIEWER}))
+		outsider := models.CreateUserCommand{Login: "outsider"}
+		require.NoError(t, bus.Dispatch(&outsider))
+
+		shared := &Bundle{
+			Version: BundleVersion,
+			Folders: []*Folder{{Uid: "shared", Title: "Shared", Permissions: []*Permission{
+				{User: "member@example.com", Permission: models.PERMISSION_EDIT},
+				{User: "outsider", Permission: models.PERMISSION_ADMIN},
+			}}},
+		}
+		result, err := Import(shared, options)
+		require.NoError(t, err)
+		assert.Equal(t, []string{`user "outsider" is not a member of the organization, skipping its permission of "Shared"`}, result.Warnings)
+
+		aclQuery := models.GetDashboardAclInfoListQuery{OrgId: targetOrgID, DashboardId: getDashboard(targetOrgID, "shared").Id}
+		require.NoError(t, bus.Dispatch(&aclQuery))
+		require.Len(t, aclQuery.Result, 1)
+		assert.Equal(t, member.Result.Id, aclQuery.Result[0].UserId)
+	})
+}
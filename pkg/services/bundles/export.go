@@ -0,0 +1,286 @@
+package bundles
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// ExportOptions selects the content of the bundle. Folders are exported with their subfolders and the dashboards
+// and library panels in them. If no folders or dashboards are selected, the whole organization is exported.
+type ExportOptions struct {
+	OrgId         int64
+	FolderUids    []string
+	DashboardUids []string
+}
+
+// Export exports the selected folders and dashboards of an organization as a bundle. The bundle includes the
+// folders above the selected content, the library panels used by the exported dashboards and the data sources
+// referenced by the exported dashboards and library panels.
+func Export(opts ExportOptions) (*Bundle, error) {
+	query := models.GetDashboardsByOrgQuery{OrgId: opts.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	e := &exporter{
+		orgId:    opts.OrgId,
+		byId:     make(map[int64]*models.Dashboard, len(query.Result)),
+		selected: map[int64]bool{},
+		included: map[int64]bool{},
+	}
+
+	byUid := make(map[string]*models.Dashboard, len(query.Result))
+	for _, dash := range query.Result {
+		e.byId[dash.Id] = dash
+		byUid[dash.Uid] = dash
+	}
+
+	exportAll := len(opts.FolderUids) == 0 && len(opts.DashboardUids) == 0
+	for _, uid := range opts.FolderUids {
+		folder, ok := byUid[uid]
+		if !ok || !folder.IsFolder {
+			return nil, models.ErrFolderNotFound
+		}
+		e.selected[folder.Id] = true
+	}
+	for _, uid := range opts.DashboardUids {
+		dash, ok := byUid[uid]
+		if !ok || dash.IsFolder {
+			return nil, models.ErrDashboardNotFound
+		}
+		e.selected[dash.Id] = true
+	}
+
+	for _, dash := range query.Result {
+		if exportAll || e.isSelected(dash.Id) {
+			e.include(dash.Id)
+		}
+	}
+
+	bundle := &Bundle{
+		Version:       BundleVersion,
+		ExportedAt:    time.Now(),
+		DataSources:   []*DataSource{},
+		Folders:       []*Folder{},
+		LibraryPanels: []*LibraryPanel{},
+		Dashboards:    []*Dashboard{},
+	}
+
+	dataSourceNames := map[string]bool{}
+	libraryPanelUids := map[string]bool{}
+
+	for _, dash := range query.Result {
+		if !e.included[dash.Id] || dash.IsFolder {
+			continue
+		}
+
+		data, err := copyJSON(dash.Data)
+		if err != nil {
+			return nil, err
+		}
+		data.Del("id")
+		data.Del("version")
+
+		walkDataSources(data, func(name string) string {
+			dataSourceNames[name] = true
+			return name
+		})
+		walkLibraryPanelUids(data, func(uid string) string {
+			libraryPanelUids[uid] = true
+			return uid
+		})
+
+		permissions, err := e.permissions(dash)
+		if err != nil {
+			return nil, err
+		}
+
+		bundle.Dashboards = append(bundle.Dashboards, &Dashboard{
+			Uid:         dash.Uid,
+			Title:       dash.Title,
+			FolderUid:   e.uid(dash.FolderId),
+			Dashboard:   data,
+			Permissions: permissions,
+		})
+	}
+
+	libraryPanelsQuery := models.GetAllLibraryPanelsQuery{OrgId: opts.OrgId}
+	if err := bus.Dispatch(&libraryPanelsQuery); err != nil {
+		return nil, err
+	}
+
+	for _, libraryPanel := range libraryPanelsQuery.Result {
+		inSelectedFolder := libraryPanel.FolderId > 0 && (exportAll || e.isSelected(libraryPanel.FolderId))
+		if !libraryPanelUids[libraryPanel.Uid] && !inSelectedFolder && !(exportAll && libraryPanel.FolderId == 0) {
+			continue
+		}
+		e.include(libraryPanel.FolderId)
+
+		model, err := copyJSON(libraryPanel.Model)
+		if err != nil {
+			return nil, err
+		}
+		walkDataSources(model, func(name string) string {
+			dataSourceNames[name] = true
+			return name
+		})
+
+		bundle.LibraryPanels = append(bundle.LibraryPanels, &LibraryPanel{
+			Uid:       libraryPanel.Uid,
+			Name:      libraryPanel.Name,
+			FolderUid: e.uid(libraryPanel.FolderId),
+			Model:     model,
+		})
+	}
+
+	folders, err := e.folders()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Folders = folders
+
+	dataSourcesQuery := models.GetDataSourcesQuery{OrgId: opts.OrgId}
+	if err := bus.Dispatch(&dataSourcesQuery); err != nil {
+		return nil, err
+	}
+
+	for _, ds := range dataSourcesQuery.Result {
+		if !dataSourceNames[ds.Name] {
+			continue
+		}
+
+		bundle.DataSources = append(bundle.DataSources, &DataSource{
+			Uid:             ds.Uid,
+			Name:            ds.Name,
+			Type:            ds.Type,
+			Access:          ds.Access,
+			Url:             ds.Url,
+			User:            ds.User,
+			Database:        ds.Database,
+			BasicAuth:       ds.BasicAuth,
+			BasicAuthUser:   ds.BasicAuthUser,
+			WithCredentials: ds.WithCredentials,
+			JsonData:        ds.JsonData,
+		})
+	}
+
+	return bundle, nil
+}
+
+type exporter struct {
+	orgId int64
+	byId  map[int64]*models.Dashboard
+	// selected are the ids of the selected folders and dashboards
+	selected map[int64]bool
+	// included are the ids of the exported folders and dashboards
+	included map[int64]bool
+}
+
+// isSelected returns true if the folder or dashboard or one of the folders above it is selected.
+func (e *exporter) isSelected(id int64) bool {
+	for id > 0 {
+		if e.selected[id] {
+			return true
+		}
+		dash, ok := e.byId[id]
+		if !ok {
+			return false
+		}
+		id = dash.FolderId
+	}
+	return false
+}
+
+// include includes the folder or dashboard and the folders above it.
+func (e *exporter) include(id int64) {
+	for id > 0 && !e.included[id] {
+		e.included[id] = true
+		dash, ok := e.byId[id]
+		if !ok {
+			return
+		}
+		id = dash.FolderId
+	}
+}
+
+func (e *exporter) uid(id int64) string {
+	if dash, ok := e.byId[id]; ok {
+		return dash.Uid
+	}
+	return ""
+}
+
+func (e *exporter) depth(id int64) int {
+	depth := 0
+	for dash, ok := e.byId[id]; ok && dash.FolderId > 0; dash, ok = e.byId[dash.FolderId] {
+		depth++
+	}
+	return depth
+}
+
+// folders returns the included folders, parents first.
+func (e *exporter) folders() ([]*Folder, error) {
+	var ids []int64
+	for id := range e.included {
+		if dash, ok := e.byId[id]; ok && dash.IsFolder {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		di, dj := e.depth(ids[i]), e.depth(ids[j])
+		if di != dj {
+			return di < dj
+		}
+		return ids[i] < ids[j]
+	})
+
+	folders := make([]*Folder, 0, len(ids))
+	for _, id := range ids {
+		folder := e.byId[id]
+		permissions, err := e.permissions(folder)
+		if err != nil {
+			return nil, err
+		}
+
+		folders = append(folders, &Folder{
+			Uid:         folder.Uid,
+			Title:       folder.Title,
+			ParentUid:   e.uid(folder.FolderId),
+			Permissions: permissions,
+		})
+	}
+
+	return folders, nil
+}
+
+// permissions returns the permissions set for the folder or dashboard, or nil if it has none.
+func (e *exporter) permissions(dash *models.Dashboard) ([]*Permission, error) {
+	if !dash.HasAcl {
+		return nil, nil
+	}
+
+	query := models.GetDashboardAclInfoListQuery{OrgId: e.orgId, DashboardId: dash.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	permissions := []*Permission{}
+	for _, item := range query.Result {
+		if item.DashboardId != dash.Id || item.Inherited {
+			continue
+		}
+
+		permissions = append(permissions, &Permission{
+			Role:       item.Role,
+			Team:       item.Team,
+			User:       item.UserLogin,
+			Permission: item.Permission,
+		})
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,750 @@
+package bundles
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// ImportOptions configures the import of a bundle.
+type ImportOptions struct {
+	OrgId int64
+	User  *models.SignedInUser
+	// DryRun reports the changes without applying them
+	DryRun bool
+	// Overwrite replaces existing folders, dashboards and library panels with the same uid
+	Overwrite bool
+	// NewUids imports the folders, dashboards and library panels with new uids, except the ones mapped by Uids
+	NewUids bool
+	// Uids maps uids of the bundle to the uids to import them with
+	Uids map[string]string
+	// DataSources maps data source names of the bundle to the names of existing data sources
+	DataSources map[string]string
+	// FolderUid is the existing folder to import the top level content of the bundle into, empty for the
+	// General folder
+	FolderUid string
+}
+
+// Action is the change of an item of the bundle made by the import.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	ActionConflict  Action = "conflict"
+	// ActionMap is the action of data sources of the bundle that are replaced by an existing data source
+	ActionMap Action = "map"
+)
+
+// Change is the change of an item of the bundle. Uid is the uid the item is imported with. Target is the name of
+// the data source a data source of the bundle is mapped to. Diff is the change of existing dashboards.
+type Change struct {
+	Uid         string                  `json:"uid"`
+	OriginalUid string                  `json:"originalUid,omitempty"`
+	Title       string                  `json:"title"`
+	Action      Action                  `json:"action"`
+	Target      string                  `json:"target,omitempty"`
+	Reason      string                  `json:"reason,omitempty"`
+	Diff        *dashdiffs.SemanticDiff `json:"diff,omitempty"`
+}
+
+// ImportResult is the report of an import.
+type ImportResult struct {
+	DryRun        bool      `json:"dryRun"`
+	DataSources   []*Change `json:"dataSources"`
+	Folders       []*Change `json:"folders"`
+	LibraryPanels []*Change `json:"libraryPanels"`
+	Dashboards    []*Change `json:"dashboards"`
+	Warnings      []string  `json:"warnings"`
+}
+
+// Conflicts returns the conflicting changes.
+func (r *ImportResult) Conflicts() []*Change {
+	var conflicts []*Change
+	for _, changes := range [][]*Change{r.Folders, r.LibraryPanels, r.Dashboards} {
+		for _, change := range changes {
+			if change.Action == ActionConflict {
+				conflicts = append(conflicts, change)
+			}
+		}
+	}
+	return conflicts
+}
+
+// Import imports a bundle. Data source references are replaced with the mapped data sources, and data sources
+// that don't exist are created without their secrets. Existing data sources are never changed. Import returns
+// ErrImportConflicts, and applies no changes, if the bundle conflicts with existing content and Overwrite is not
+// set. A dry run only reports the changes.
+func Import(bundle *Bundle, opts ImportOptions) (*ImportResult, error) {
+	if bundle.Version < 1 || bundle.Version > BundleVersion {
+		return nil, ErrUnsupportedBundleVersion
+	}
+
+	i := &importer{
+		opts: opts,
+		result: &ImportResult{
+			DryRun:        opts.DryRun,
+			DataSources:   []*Change{},
+			Folders:       []*Change{},
+			LibraryPanels: []*Change{},
+			Dashboards:    []*Change{},
+			Warnings:      []string{},
+		},
+		uids:            map[string]string{},
+		bundleUids:      map[string]bool{},
+		dataSourceNames: map[string]string{},
+		teams:           map[string]int64{},
+		users:           map[string]int64{},
+	}
+
+	for from, to := range opts.Uids {
+		i.uids[from] = to
+	}
+
+	if err := i.plan(bundle); err != nil {
+		return nil, err
+	}
+
+	if len(i.result.Conflicts()) > 0 && !opts.DryRun {
+		return i.result, ErrImportConflicts
+	}
+
+	if opts.DryRun {
+		return i.result, nil
+	}
+
+	if err := i.apply(); err != nil {
+		return nil, err
+	}
+
+	return i.result, nil
+}
+
+type importer struct {
+	opts   ImportOptions
+	result *ImportResult
+
+	// uids maps the uids of the bundle to the imported uids
+	uids map[string]string
+	// dataSourceNames maps the data source names of the bundle to the imported names
+	dataSourceNames map[string]string
+	// bundleUids are the uids of the folders, dashboards and library panels of the bundle
+	bundleUids map[string]bool
+	// bundleFolders are the imported uids of the folders of the bundle
+	bundleFolders map[string]bool
+
+	existing      map[string]*models.Dashboard
+	existingById  map[int64]*models.Dashboard
+	libraryPanels map[string]*models.LibraryPanel
+	teams         map[string]int64
+	users         map[string]int64
+
+	dataSources          []*DataSource
+	plannedFolders       []*plannedFolder
+	plannedLibraryPanels []*plannedLibraryPanel
+	plannedDashboards    []*plannedDashboard
+}
+
+type plannedFolder struct {
+	folder      *Folder
+	change      *Change
+	permissions []*models.DashboardAcl
+}
+
+type plannedLibraryPanel struct {
+	libraryPanel *LibraryPanel
+	change       *Change
+}
+
+type plannedDashboard struct {
+	dashboard   *Dashboard
+	change      *Change
+	permissions []*models.DashboardAcl
+}
+
+func (i *importer) plan(bundle *Bundle) error {
+	query := models.GetDashboardsByOrgQuery{OrgId: i.opts.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	i.existing = make(map[string]*models.Dashboard, len(query.Result))
+	i.existingById = make(map[int64]*models.Dashboard, len(query.Result))
+	for _, dash := range query.Result {
+		i.existing[dash.Uid] = dash
+		i.existingById[dash.Id] = dash
+	}
+
+	if i.opts.FolderUid != "" {
+		if folder, ok := i.existing[i.opts.FolderUid]; !ok || !folder.IsFolder {
+			return models.ErrFolderNotFound
+		}
+	}
+
+	libraryPanelsQuery := models.GetAllLibraryPanelsQuery{OrgId: i.opts.OrgId}
+	if err := bus.Dispatch(&libraryPanelsQuery); err != nil {
+		return err
+	}
+
+	i.libraryPanels = make(map[string]*models.LibraryPanel, len(libraryPanelsQuery.Result))
+	for _, libraryPanel := range libraryPanelsQuery.Result {
+		i.libraryPanels[libraryPanel.Uid] = libraryPanel
+	}
+
+	if err := i.planDataSources(bundle.DataSources); err != nil {
+		return err
+	}
+
+	for _, folder := range bundle.Folders {
+		i.bundleUids[folder.Uid] = true
+	}
+	for _, libraryPanel := range bundle.LibraryPanels {
+		i.bundleUids[libraryPanel.Uid] = true
+	}
+	for _, dashboard := range bundle.Dashboards {
+		i.bundleUids[dashboard.Uid] = true
+	}
+
+	folders, err := sortFolders(bundle.Folders)
+	if err != nil {
+		return err
+	}
+
+	i.bundleFolders = make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		i.bundleFolders[i.mapUid(folder.Uid)] = true
+	}
+
+	for _, folder := range folders {
+		if err := i.planFolder(folder); err != nil {
+			return err
+		}
+	}
+
+	for _, libraryPanel := range bundle.LibraryPanels {
+		if err := i.planLibraryPanel(libraryPanel); err != nil {
+			return err
+		}
+	}
+
+	for _, dashboard := range bundle.Dashboards {
+		if err := i.planDashboard(dashboard); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *importer) planDataSources(dataSources []*DataSource) error {
+	query := models.GetDataSourcesQuery{OrgId: i.opts.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	byName := make(map[string]*models.DataSource, len(query.Result))
+	byUid := make(map[string]*models.DataSource, len(query.Result))
+	for _, ds := range query.Result {
+		byName[ds.Name] = ds
+		byUid[ds.Uid] = ds
+	}
+
+	for from, to := range i.opts.DataSources {
+		if _, ok := byName[to]; !ok {
+			return fmt.Errorf("%w: %s", ErrDataSourceMappingNotFound, to)
+		}
+		i.dataSourceNames[from] = to
+	}
+
+	for _, ds := range dataSources {
+		change := &Change{Uid: ds.Uid, Title: ds.Name}
+
+		if to, ok := i.opts.DataSources[ds.Name]; ok {
+			change.Action = ActionMap
+			change.Target = to
+		} else if existing, ok := byName[ds.Name]; ok {
+			change.Action = ActionUnchanged
+			change.Target = existing.Name
+			if existing.Type != ds.Type {
+				i.warn("data source %q is of type %s instead of %s", ds.Name, existing.Type, ds.Type)
+			}
+		} else if existing, ok := byUid[ds.Uid]; ok && ds.Uid != "" {
+			change.Action = ActionMap
+			change.Target = existing.Name
+			i.dataSourceNames[ds.Name] = existing.Name
+		} else {
+			change.Action = ActionCreate
+			change.Target = ds.Name
+			i.dataSources = append(i.dataSources, ds)
+		}
+
+		i.result.DataSources = append(i.result.DataSources, change)
+	}
+
+	return nil
+}
+
+func (i *importer) planFolder(folder *Folder) error {
+	uid := i.mapUid(folder.Uid)
+	parentUid := i.parentUid(folder.ParentUid, folder.Title)
+	change := i.newChange(uid, folder.Uid, folder.Title)
+
+	existing := i.existing[uid]
+	switch {
+	case existing == nil:
+		change.Action = i.checkTitle(change, parentUid, folder.Title, true)
+	case !existing.IsFolder:
+		change.Action = ActionConflict
+		change.Reason = "a dashboard with the same uid exists"
+	case existing.Title == folder.Title && i.existingUid(existing.FolderId) == parentUid:
+		change.Action = ActionUnchanged
+	default:
+		change.Action = i.updateOrConflict(change)
+	}
+
+	permissions, err := i.resolvePermissions(folder.Permissions, folder.Title)
+	if err != nil {
+		return err
+	}
+
+	i.result.Folders = append(i.result.Folders, change)
+	i.plannedFolders = append(i.plannedFolders, &plannedFolder{
+		folder:      &Folder{Uid: uid, Title: folder.Title, ParentUid: parentUid},
+		change:      change,
+		permissions: permissions,
+	})
+
+	return nil
+}
+
+func (i *importer) planLibraryPanel(libraryPanel *LibraryPanel) error {
+	uid := i.mapUid(libraryPanel.Uid)
+	folderUid := i.parentUid(libraryPanel.FolderUid, libraryPanel.Name)
+	change := i.newChange(uid, libraryPanel.Uid, libraryPanel.Name)
+
+	model, err := copyJSON(libraryPanel.Model)
+	if err != nil {
+		return err
+	}
+	walkDataSources(model, i.mapDataSource)
+
+	existing := i.libraryPanels[uid]
+	if existing == nil {
+		change.Action = ActionCreate
+		folderId, exists := i.existingFolderId(folderUid)
+		for _, other := range i.libraryPanels {
+			if exists && other.FolderId == folderId && other.Name == libraryPanel.Name {
+				change.Action = ActionConflict
+				change.Reason = "a library panel with the same name exists in the folder"
+			}
+		}
+	} else {
+		same, err := equalJSON(existing.Model, model)
+		if err != nil {
+			return err
+		}
+
+		if same && existing.Name == libraryPanel.Name && i.existingUid(existing.FolderId) == folderUid {
+			change.Action = ActionUnchanged
+		} else {
+			change.Action = i.updateOrConflict(change)
+		}
+	}
+
+	i.result.LibraryPanels = append(i.result.LibraryPanels, change)
+	i.plannedLibraryPanels = append(i.plannedLibraryPanels, &plannedLibraryPanel{
+		libraryPanel: &LibraryPanel{Uid: uid, Name: libraryPanel.Name, FolderUid: folderUid, Model: model},
+		change:       change,
+	})
+
+	return nil
+}
+
+func (i *importer) planDashboard(dashboard *Dashboard) error {
+	data, err := copyJSON(dashboard.Dashboard)
+	if err != nil {
+		return err
+	}
+
+	uid := i.mapUid(dashboard.Uid)
+	data.Del("id")
+	data.Del("version")
+	data.Set("uid", uid)
+	walkDataSources(data, i.mapDataSource)
+	walkLibraryPanelUids(data, i.mapUid)
+
+	title := data.Get("title").MustString(dashboard.Title)
+	folderUid := i.parentUid(dashboard.FolderUid, title)
+	change := i.newChange(uid, dashboard.Uid, title)
+
+	existing := i.existing[uid]
+	switch {
+	case existing == nil:
+		change.Action = i.checkTitle(change, folderUid, title, false)
+	case existing.IsFolder:
+		change.Action = ActionConflict
+		change.Reason = "a folder with the same uid exists"
+	default:
+		diff, err := dashdiffs.CalculateSemanticDiff(existing.Data, data)
+		if err != nil {
+			return err
+		}
+
+		if diff.IsEmpty() && i.existingUid(existing.FolderId) == folderUid {
+			change.Action = ActionUnchanged
+		} else {
+			change.Action = i.updateOrConflict(change)
+			if !diff.IsEmpty() {
+				change.Diff = diff
+			}
+		}
+	}
+
+	permissions, err := i.resolvePermissions(dashboard.Permissions, title)
+	if err != nil {
+		return err
+	}
+
+	i.result.Dashboards = append(i.result.Dashboards, change)
+	i.plannedDashboards = append(i.plannedDashboards, &plannedDashboard{
+		dashboard:   &Dashboard{Uid: uid, Title: title, FolderUid: folderUid, Dashboard: data},
+		change:      change,
+		permissions: permissions,
+	})
+
+	return nil
+}
+
+func (i *importer) newChange(uid, originalUid, title string) *Change {
+	change := &Change{Uid: uid, Title: title}
+	if uid != originalUid {
+		change.OriginalUid = originalUid
+	}
+	return change
+}
+
+func (i *importer) updateOrConflict(change *Change) Action {
+	if i.opts.Overwrite {
+		return ActionUpdate
+	}
+	change.Reason = "changed content with the same uid exists"
+	return ActionConflict
+}
+
+// checkTitle returns the action of a new folder or dashboard, which conflicts with another folder or dashboard
+// with the same title in the folder.
+func (i *importer) checkTitle(change *Change, folderUid, title string, isFolder bool) Action {
+	folderId, exists := i.existingFolderId(folderUid)
+	if !exists {
+		return ActionCreate
+	}
+
+	for _, other := range i.existing {
+		if other.FolderId == folderId && other.IsFolder == isFolder && other.Title == title {
+			change.Reason = "a folder or dashboard with the same name exists in the folder"
+			return ActionConflict
+		}
+	}
+
+	return ActionCreate
+}
+
+// mapUid returns the uid to import an item of the bundle with. Uids of items that are not part of the bundle are
+// only changed if they are mapped by the options.
+func (i *importer) mapUid(uid string) string {
+	if uid == "" {
+		return ""
+	}
+
+	if mapped, ok := i.uids[uid]; ok {
+		return mapped
+	}
+
+	mapped := uid
+	if i.opts.NewUids && i.bundleUids[uid] {
+		mapped = util.GenerateShortUID()
+	}
+	i.uids[uid] = mapped
+	return mapped
+}
+
+// parentUid returns the imported uid of the folder of an item of the bundle. Items of the General folder and items
+// of unknown folders are imported into the folder of the options.
+func (i *importer) parentUid(uid string, title string) string {
+	if uid == "" {
+		return i.opts.FolderUid
+	}
+
+	mapped := i.mapUid(uid)
+	if folder, ok := i.existing[mapped]; i.bundleFolders[mapped] || (ok && folder.IsFolder) {
+		return mapped
+	}
+
+	i.warn("folder %s of %q not found, importing it into the top level folder", uid, title)
+	return i.opts.FolderUid
+}
+
+func (i *importer) mapDataSource(name string) string {
+	if mapped, ok := i.dataSourceNames[name]; ok {
+		return mapped
+	}
+	return name
+}
+
+// existingUid returns the uid of the existing folder with the id, or an empty uid for the General folder.
+func (i *importer) existingUid(folderId int64) string {
+	if folder, ok := i.existingById[folderId]; ok {
+		return folder.Uid
+	}
+	return ""
+}
+
+// existingFolderId returns the id of the existing folder with the uid, or false if the folder doesn't exist yet.
+func (i *importer) existingFolderId(uid string) (int64, bool) {
+	if uid == "" {
+		return 0, true
+	}
+	if folder, ok := i.existing[uid]; ok {
+		return folder.Id, true
+	}
+	return 0, false
+}
+
+func (i *importer) warn(format string, args ...interface{}) {
+	i.result.Warnings = append(i.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// resolvePermissions returns the permissions with the ids of the teams and users. Permissions of unknown teams
+// and users are skipped with a warning.
+func (i *importer) resolvePermissions(permissions []*Permission, title string) ([]*models.DashboardAcl, error) {
+	if permissions == nil {
+		return nil, nil
+	}
+
+	items := []*models.DashboardAcl{}
+	for _, permission := range permissions {
+		item := &models.DashboardAcl{
+			OrgId:      i.opts.OrgId,
+			Role:       permission.Role,
+			Permission: permission.Permission,
+		}
+
+		switch {
+		case permission.Team != "":
+			id, err := i.teamId(permission.Team)
+			if err != nil {
+				return nil, err
+			}
+			if id == 0 {
+				i.warn("team %q not found, skipping its permission of %q", permission.Team, title)
+				continue
+			}
+			item.TeamId = id
+		case permission.User != "":
+			id, err := i.userId(permission.User)
+			if err != nil {
+				return nil, err
+			}
+			if id == 0 {
+				i.warn("user %q is not a member of the organization, skipping its permission of %q", permission.User, title)
+				continue
+			}
+			item.UserId = id
+		case permission.Role == nil || !permission.Role.IsValid():
+			i.warn("invalid permission of %q skipped", title)
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (i *importer) teamId(name string) (int64, error) {
+	if id, ok := i.teams[name]; ok {
+		return id, nil
+	}
+
+	query := models.SearchTeamsQuery{OrgId: i.opts.OrgId, Name: name, Limit: 1, Page: 1}
+	if err := bus.Dispatch(&query); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	if len(query.Result.Teams) > 0 {
+		id = query.Result.Teams[0].Id
+	}
+	i.teams[name] = id
+	return id, nil
+}
+
+// userId returns the id of the member of the org with the login or email, or 0 if there is none. Users that are
+// not members of the org can't be granted permissions in it.
+func (i *importer) userId(login string) (int64, error) {
+	if id, ok := i.users[login]; ok {
+		return id, nil
+	}
+
+	query := models.GetOrgUsersQuery{OrgId: i.opts.OrgId, Query: login}
+	if err := bus.Dispatch(&query); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	for _, user := range query.Result {
+		if user.Login == login || user.Email == login {
+			id = user.UserId
+			break
+		}
+	}
+	i.users[login] = id
+	return id, nil
+}
+
+// apply saves the planned changes in a single transaction, so that nothing is changed if any of them fails.
+// Folders and dashboards are validated as by the dashboard service first. The import requires the org admin role,
+// which can save all folders and dashboards of the org.
+func (i *importer) apply() error {
+	cmd := models.ImportBundleCommand{
+		OrgId:   i.opts.OrgId,
+		UserId:  i.opts.User.UserId,
+		Message: "Imported from bundle",
+	}
+
+	for _, ds := range i.dataSources {
+		cmd.DataSources = append(cmd.DataSources, &models.AddDataSourceCommand{
+			Uid:             ds.Uid,
+			Name:            ds.Name,
+			Type:            ds.Type,
+			Access:          ds.Access,
+			Url:             ds.Url,
+			User:            ds.User,
+			Database:        ds.Database,
+			BasicAuth:       ds.BasicAuth,
+			BasicAuthUser:   ds.BasicAuthUser,
+			WithCredentials: ds.WithCredentials,
+			JsonData:        ds.JsonData,
+		})
+	}
+
+	for _, planned := range i.plannedFolders {
+		if planned.change.Action != ActionCreate && planned.change.Action != ActionUpdate {
+			continue
+		}
+
+		folder := planned.folder
+		dash := models.NewDashboardFolder(folder.Title)
+		dash.SetUid(folder.Uid)
+		if err := dashboards.PrepareImport(&dashboards.SaveDashboardDTO{OrgId: i.opts.OrgId, User: i.opts.User, Dashboard: dash}); err != nil {
+			return fmt.Errorf("failed to import folder %q: %w", folder.Title, err)
+		}
+
+		cmd.Folders = append(cmd.Folders, &models.ImportBundleFolder{
+			Uid:       folder.Uid,
+			Title:     dash.Title,
+			ParentUid: folder.ParentUid,
+			Acl:       planned.permissions,
+		})
+	}
+
+	for _, planned := range i.plannedLibraryPanels {
+		if planned.change.Action != ActionCreate && planned.change.Action != ActionUpdate {
+			continue
+		}
+
+		libraryPanel := planned.libraryPanel
+		cmd.LibraryPanels = append(cmd.LibraryPanels, &models.ImportBundleLibraryPanel{
+			Uid:       libraryPanel.Uid,
+			Name:      libraryPanel.Name,
+			FolderUid: libraryPanel.FolderUid,
+			Model:     libraryPanel.Model,
+		})
+	}
+
+	for _, planned := range i.plannedDashboards {
+		if planned.change.Action != ActionCreate && planned.change.Action != ActionUpdate {
+			continue
+		}
+
+		dash := models.NewDashboardFromJson(planned.dashboard.Dashboard)
+		dash.OrgId = i.opts.OrgId
+		if err := dashboards.PrepareImport(&dashboards.SaveDashboardDTO{OrgId: i.opts.OrgId, User: i.opts.User, Dashboard: dash}); err != nil {
+			return fmt.Errorf("failed to import dashboard %q: %w", planned.dashboard.Title, err)
+		}
+
+		cmd.Dashboards = append(cmd.Dashboards, &models.ImportBundleDashboard{
+			Dashboard: dash.Data,
+			FolderUid: planned.dashboard.FolderUid,
+			Acl:       planned.permissions,
+		})
+	}
+
+	return bus.Dispatch(&cmd)
+}
+
+// sortFolders returns the folders ordered parents first.
+func sortFolders(folders []*Folder) ([]*Folder, error) {
+	byUid := make(map[string]*Folder, len(folders))
+	for _, folder := range folders {
+		if folder.Uid == "" {
+			return nil, fmt.Errorf("%w: folder %q without uid", ErrInvalidBundle, folder.Title)
+		}
+		byUid[folder.Uid] = folder
+	}
+
+	sorted := make([]*Folder, 0, len(folders))
+	added := map[string]bool{}
+	var add func(folder *Folder, depth int) error
+	add = func(folder *Folder, depth int) error {
+		if added[folder.Uid] {
+			return nil
+		}
+		if depth > len(folders) {
+			return fmt.Errorf("%w: folder %q is its own parent", ErrInvalidBundle, folder.Title)
+		}
+		if parent, ok := byUid[folder.ParentUid]; ok {
+			if err := add(parent, depth+1); err != nil {
+				return err
+			}
+		}
+		added[folder.Uid] = true
+		sorted = append(sorted, folder)
+		return nil
+	}
+
+	for _, folder := range folders {
+		if err := add(folder, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// equalJSON returns true if two JSON models are equal.
+func equalJSON(a, b *simplejson.Json) (bool, error) {
+	var values [2]interface{}
+	for n, data := range []*simplejson.Json{a, b} {
+		if data == nil {
+			continue
+		}
+		bytes, err := data.Encode()
+		if err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(bytes, &values[n]); err != nil {
+			return false, err
+		}
+	}
+
+	return reflect.DeepEqual(values[0], values[1]), nil
+}
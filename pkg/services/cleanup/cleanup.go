@@ -40,6 +40,7 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 			srv.cleanUpTmpFiles()
 			srv.deleteExpiredSnapshots()
 			srv.deleteExpiredDashboardVersions()
+			srv.deleteOldDashboardViewEvents()
 			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
 				time.Minute*10, func() {
 					srv.deleteOldLoginAttempts()
@@ -47,6 +48,13 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 			if err != nil {
 				srv.log.Error("failed to lock and execute cleanup of old login attempts", "error", err)
 			}
+			err = srv.ServerLockService.LockAndExecute(ctx, "purge deleted users, orgs and teams",
+				time.Minute*10, func() {
+					srv.purgeDeletedResources()
+				})
+			if err != nil {
+				srv.log.Error("failed to lock and execute purge of deleted users, orgs and teams", "error", err)
+			}
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -110,13 +118,34 @@ func (srv *CleanUpService) deleteExpiredDashboardVersions() {
 	}
 }
 
+func (srv *CleanUpService) deleteOldDashboardViewEvents() {
+	if setting.DashboardUsageEventsMaxAge <= 0 {
+		return
+	}
+
+	cmd := models.DeleteOldDashboardViewEventsCommand{
+		OlderThan: time.Now().Add(-setting.DashboardUsageEventsMaxAge),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		srv.log.Error("Failed to delete old dashboard view events", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted old dashboard view events", "rows affected", cmd.DeletedRows)
+	}
+}
+
 func (srv *CleanUpService) deleteOldLoginAttempts() {
 	if srv.Cfg.DisableBruteForceLoginProtection {
 		return
 	}
 
+	// attempts are kept as long as they can lock out a user
+	maxAge := setting.LoginLockoutDuration
+	if maxAge < time.Minute*10 {
+		maxAge = time.Minute * 10
+	}
+
 	cmd := models.DeleteOldLoginAttemptsCommand{
-		OlderThan: time.Now().Add(time.Minute * -10),
+		OlderThan: time.Now().Add(-maxAge),
 	}
 	if err := bus.Dispatch(&cmd); err != nil {
 		srv.log.Error("Problem deleting expired login attempts", "error", err.Error())
@@ -124,3 +153,20 @@ func (srv *CleanUpService) deleteOldLoginAttempts() {
 		srv.log.Debug("Deleted expired login attempts", "rows affected", cmd.DeletedRows)
 	}
 }
+
+// purgeDeletedResources permanently deletes the users, organizations and teams
+// that have been deleted longer than the retention period ago.
+func (srv *CleanUpService) purgeDeletedResources() {
+	if setting.DeletedRetentionDays <= 0 {
+		return
+	}
+
+	cmd := models.PurgeDeletedResourcesCommand{
+		DeletedBefore: time.Now().AddDate(0, 0, -setting.DeletedRetentionDays),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		srv.log.Error("Problem purging deleted users, orgs and teams", "error", err.Error())
+	} else {
+		srv.log.Debug("Purged deleted users, orgs and teams", "rows affected", cmd.Result)
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards/schema"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -53,6 +56,12 @@ type SaveDashboardDTO struct {
 	Message   string
 	Overwrite bool
 	Dashboard *models.Dashboard
+	// Strict rejects dashboards that are invalid against the schema of their schemaVersion
+	Strict bool
+	// Merge merges the dashboard with the latest version when the dashboard has been changed by someone else
+	Merge bool
+	// Merged is set when the saved dashboard has been merged with the latest version
+	Merged bool
 }
 
 type dashboardServiceImpl struct {
@@ -84,29 +93,7 @@ func (dr *dashboardServiceImpl) GetProvisionedDashboardDataByDashboardID(dashboa
 func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO, validateAlerts bool, validateProvisionedDashboard bool) (*models.SaveDashboardCommand, error) {
 	dash := dto.Dashboard
 
-	dash.Title = strings.TrimSpace(dash.Title)
-	dash.Data.Set("title", dash.Title)
-	dash.SetUid(strings.TrimSpace(dash.Uid))
-
-	if dash.Title == "" {
-		return nil, models.ErrDashboardTitleEmpty
-	}
-
-	if dash.IsFolder && dash.FolderId > 0 {
-		return nil, models.ErrDashboardFolderCannotHaveParent
-	}
-
-	if dash.IsFolder && strings.EqualFold(dash.Title, models.RootFolderName) {
-		return nil, models.ErrDashboardFolderNameExists
-	}
-
-	if !util.IsValidShortUID(dash.Uid) {
-		return nil, models.ErrDashboardInvalidUid
-	} else if len(dash.Uid) > 40 {
-		return nil, models.ErrDashboardUidToLong
-	}
-
-	if err := validateDashboardRefreshInterval(dash); err != nil {
+	if err := validateDashboard(dash); err != nil {
 		return nil, err
 	}
 
@@ -161,6 +148,10 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 		return nil, models.ErrDashboardUpdateAccessDenied
 	}
 
+	if err := validateLibraryPanels(dash, dto.OrgId, dto.User); err != nil {
+		return nil, err
+	}
+
 	cmd := &models.SaveDashboardCommand{
 		Dashboard: dash.Data,
 		Message:   dto.Message,
@@ -179,6 +170,30 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 	return cmd, nil
 }
 
+// validateDashboard trims the title and uid of the dashboard and validates
+// them and its refresh interval.
+func validateDashboard(dash *models.Dashboard) error {
+	dash.Title = strings.TrimSpace(dash.Title)
+	dash.Data.Set("title", dash.Title)
+	dash.SetUid(strings.TrimSpace(dash.Uid))
+
+	if dash.Title == "" {
+		return models.ErrDashboardTitleEmpty
+	}
+
+	if dash.IsFolder && strings.EqualFold(dash.Title, models.RootFolderName) {
+		return models.ErrDashboardFolderNameExists
+	}
+
+	if !util.IsValidShortUID(dash.Uid) {
+		return models.ErrDashboardInvalidUid
+	} else if len(dash.Uid) > 40 {
+		return models.ErrDashboardUidToLong
+	}
+
+	return validateDashboardRefreshInterval(dash)
+}
+
 func validateDashboardRefreshInterval(dash *models.Dashboard) error {
 	if setting.MinRefreshInterval == "" {
 		return nil
@@ -206,6 +221,86 @@ func validateDashboardRefreshInterval(dash *models.Dashboard) error {
 	return nil
 }
 
+// validateLibraryPanels checks that the user can view the folders of the
+// library panels used by the dashboard. Unknown library panels are ignored,
+// they are stored as they are.
+func validateLibraryPanels(dash *models.Dashboard, orgID int64, user *models.SignedInUser) error {
+	if dash.IsFolder {
+		return nil
+	}
+
+	for _, uid := range libraryPanelUids(dash.Data.Get("panels").MustArray(), nil) {
+		query := models.GetLibraryPanelQuery{OrgId: orgID, Uid: uid}
+		if err := bus.Dispatch(&query); err != nil {
+			if err == models.ErrLibraryPanelNotFound {
+				continue
+			}
+			return err
+		}
+
+		folderGuardian := guardian.New(query.Result.FolderId, orgID, user)
+		if canView, err := folderGuardian.CanView(); err != nil || !canView {
+			if err != nil {
+				return err
+			}
+			return models.ErrLibraryPanelAccessDenied
+		}
+	}
+
+	return nil
+}
+
+// libraryPanelUids appends the uids of the library panels of the panels,
+// including the panels of collapsed rows, to uids.
+func libraryPanelUids(panels []interface{}, uids []string) []string {
+	for _, item := range panels {
+		panel := simplejson.NewFromAny(item)
+		uids = libraryPanelUids(panel.Get("panels").MustArray(), uids)
+
+		uid := panel.Get("libraryPanel").Get("uid").MustString()
+		if uid == "" {
+			continue
+		}
+		found := false
+		for _, existing := range uids {
+			if existing == uid {
+				found = true
+				break
+			}
+		}
+		if !found {
+			uids = append(uids, uid)
+		}
+	}
+
+	return uids
+}
+
+// migrateAndValidateDashboard migrates the dashboard JSON model to the latest schemaVersion and validates it.
+// Invalid dashboards are only rejected in strict mode.
+func (dr *dashboardServiceImpl) migrateAndValidateDashboard(dto *SaveDashboardDTO) error {
+	dash := dto.Dashboard
+	if dash.IsFolder || dash.Data == nil {
+		return nil
+	}
+
+	if err := schema.Migrate(dash.Data); err != nil {
+		dr.log.Debug("Dashboard schema not migrated", "dashboardUid", dash.Uid, "dashboardTitle", dash.Title, "error", err)
+	}
+
+	errs := schema.Validate(dash.Data)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if dto.Strict {
+		return errs
+	}
+
+	dr.log.Warn("Saving invalid dashboard", "dashboardUid", dash.Uid, "dashboardTitle", dash.Title, "error", errs)
+	return nil
+}
+
 func (dr *dashboardServiceImpl) updateAlerting(cmd *models.SaveDashboardCommand, dto *SaveDashboardDTO) error {
 	alertCmd := models.UpdateDashboardAlertsCommand{
 		OrgId:     dto.OrgId,
@@ -277,7 +372,18 @@ func (dr *dashboardServiceImpl) SaveFolderForProvisionedDashboards(dto *SaveDash
 }
 
 func (dr *dashboardServiceImpl) SaveDashboard(dto *SaveDashboardDTO, allowUiUpdate bool) (*models.Dashboard, error) {
+	if err := dr.migrateAndValidateDashboard(dto); err != nil {
+		return nil, err
+	}
+
 	cmd, err := dr.buildSaveDashboardCommand(dto, true, !allowUiUpdate)
+	if err == models.ErrDashboardVersionMismatch && dto.Merge {
+		if mergeErr := dr.mergeWithLatestVersion(dto); mergeErr != nil {
+			dr.log.Debug("Failed to merge dashboard with the latest version", "dashboardUid", dto.Dashboard.Uid, "error", mergeErr)
+			return nil, err
+		}
+		cmd, err = dr.buildSaveDashboardCommand(dto, true, !allowUiUpdate)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -295,6 +401,39 @@ func (dr *dashboardServiceImpl) SaveDashboard(dto *SaveDashboardDTO, allowUiUpda
 	return cmd.Result, nil
 }
 
+// mergeWithLatestVersion merges the changes of the dashboard, made from the version of the dashboard, with the
+// changes of the latest version of the dashboard.
+func (dr *dashboardServiceImpl) mergeWithLatestVersion(dto *SaveDashboardDTO) error {
+	dash := dto.Dashboard
+
+	latestQuery := models.GetDashboardQuery{Id: dash.Id, OrgId: dto.OrgId}
+	if dash.Id == 0 {
+		latestQuery.Uid = dash.Uid
+	}
+	if err := bus.Dispatch(&latestQuery); err != nil {
+		return err
+	}
+
+	baseQuery := models.GetDashboardVersionQuery{DashboardId: latestQuery.Result.Id, Version: dash.Version, OrgId: dto.OrgId}
+	if err := bus.Dispatch(&baseQuery); err != nil {
+		return err
+	}
+
+	merged, err := dashdiffs.Merge(baseQuery.Result.Data, dash.Data, latestQuery.Result.Data)
+	if err != nil {
+		return err
+	}
+
+	dash.Data = merged
+	dash.Title = merged.Get("title").MustString()
+	dash.UpdateSlug()
+	dash.SetId(latestQuery.Result.Id)
+	dash.SetVersion(latestQuery.Result.Version)
+	dto.Merged = true
+
+	return nil
+}
+
 // DeleteDashboard removes dashboard from the DB. Errors out if the dashboard was provisioned. Should be used for
 // operations by the user where we want to make sure user does not delete provisioned dashboard.
 func (dr *dashboardServiceImpl) DeleteDashboard(dashboardId int64, orgId int64) error {
@@ -322,9 +461,8 @@ func (dr *dashboardServiceImpl) deleteDashboard(dashboardId int64, orgId int64,
 }
 
 func (dr *dashboardServiceImpl) ImportDashboard(dto *SaveDashboardDTO) (*models.Dashboard, error) {
-	if err := validateDashboardRefreshInterval(dto.Dashboard); err != nil {
-		dr.log.Warn("Changing refresh interval for imported dashboard to minimum refresh interval", "dashboardUid", dto.Dashboard.Uid, "dashboardTitle", dto.Dashboard.Title, "minRefreshInterval", setting.MinRefreshInterval)
-		dto.Dashboard.Data.Set("refresh", setting.MinRefreshInterval)
+	if err := dr.prepareImport(dto); err != nil {
+		return nil, err
 	}
 
 	cmd, err := dr.buildSaveDashboardCommand(dto, false, true)
@@ -340,6 +478,26 @@ func (dr *dashboardServiceImpl) ImportDashboard(dto *SaveDashboardDTO) (*models.
 	return cmd.Result, nil
 }
 
+// PrepareImport migrates and validates an imported dashboard as ImportDashboard does, without saving it. It's used by
+// imports that save several dashboards at once.
+func PrepareImport(dto *SaveDashboardDTO) error {
+	dr := &dashboardServiceImpl{log: log.New("dashboard-service")}
+	if err := dr.prepareImport(dto); err != nil {
+		return err
+	}
+
+	return validateDashboard(dto.Dashboard)
+}
+
+func (dr *dashboardServiceImpl) prepareImport(dto *SaveDashboardDTO) error {
+	if err := validateDashboardRefreshInterval(dto.Dashboard); err != nil {
+		dr.log.Warn("Changing refresh interval for imported dashboard to minimum refresh interval", "dashboardUid", dto.Dashboard.Uid, "dashboardTitle", dto.Dashboard.Title, "minRefreshInterval", setting.MinRefreshInterval)
+		dto.Dashboard.Data.Set("refresh", setting.MinRefreshInterval)
+	}
+
+	return dr.migrateAndValidateDashboard(dto)
+}
+
 // UnprovisionDashboard removes info about dashboard being provisioned. Used after provisioning configs are changed
 // and provisioned dashboards are left behind but not deleted.
 func (dr *dashboardServiceImpl) UnprovisionDashboard(dashboardId int64) error {
@@ -7,7 +7,9 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards/schema"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	. "github.com/smartystreets/goconvey/convey"
 	"golang.org/x/xerrors"
@@ -37,13 +39,6 @@ func TestDashboardService(t *testing.T) {
 				}
 			})
 
-			Convey("Should return validation error if it's a folder and have a folder id", func() {
-				dto.Dashboard = models.NewDashboardFolder("Folder")
-				dto.Dashboard.FolderId = 1
-				_, err := service.SaveDashboard(dto, false)
-				So(err, ShouldEqual, models.ErrDashboardFolderCannotHaveParent)
-			})
-
 			Convey("Should return validation error if folder is named General", func() {
 				dto.Dashboard = models.NewDashboardFolder("General")
 				_, err := service.SaveDashboard(dto, false)
@@ -152,6 +147,180 @@ func TestDashboardService(t *testing.T) {
 				_, err := service.SaveDashboard(dto, false)
 				So(err.Error(), ShouldEqual, "Alert validation error")
 			})
+
+			Convey("Should check the folders of the library panels of the dashboard", func() {
+				bus.AddHandler("test", func(cmd *models.GetProvisionedDashboardDataByIdQuery) error {
+					cmd.Result = nil
+					return nil
+				})
+
+				bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+					return nil
+				})
+
+				bus.AddHandler("test", func(cmd *models.ValidateDashboardBeforeSaveCommand) error {
+					cmd.Result = &models.ValidateDashboardBeforeSaveResult{}
+					return nil
+				})
+
+				bus.AddHandler("test", func(query *models.GetLibraryPanelQuery) error {
+					if query.Uid != "cpu" {
+						return models.ErrLibraryPanelNotFound
+					}
+					query.Result = &models.LibraryPanelWithConnections{LibraryPanel: models.LibraryPanel{Uid: "cpu", FolderId: 5}}
+					return nil
+				})
+
+				saved := false
+				bus.AddHandler("test", func(cmd *models.SaveDashboardCommand) error {
+					saved = true
+					cmd.Result = cmd.GetDashboardModel()
+					return nil
+				})
+
+				bus.AddHandler("test", func(cmd *models.UpdateDashboardAlertsCommand) error {
+					return nil
+				})
+
+				folderGuardian := &guardian.FakeDashboardGuardian{CanViewValue: false}
+				guardian.New = func(dashId int64, orgId int64, user *models.SignedInUser) guardian.DashboardGuardian {
+					if dashId == 5 {
+						return folderGuardian
+					}
+					return &guardian.FakeDashboardGuardian{CanSaveValue: true, CanViewValue: true}
+				}
+
+				dto.Dashboard = models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+					"title": "Dash",
+					"panels": []interface{}{
+						map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": "unknown"}},
+						map[string]interface{}{"id": 2, "type": "row", "panels": []interface{}{
+							map[string]interface{}{"id": 3, "libraryPanel": map[string]interface{}{"uid": "cpu"}},
+						}},
+					},
+				}))
+				dto.User = &models.SignedInUser{UserId: 1}
+
+				_, err := service.SaveDashboard(dto, false)
+				So(err, ShouldEqual, models.ErrLibraryPanelAccessDenied)
+				So(saved, ShouldBeFalse)
+
+				folderGuardian.CanViewValue = true
+				_, err = service.SaveDashboard(dto, false)
+				So(err, ShouldBeNil)
+				So(saved, ShouldBeTrue)
+			})
+
+			Convey("Should return schema validation error if dashboard is invalid in strict mode", func() {
+				dto.Dashboard = models.NewDashboard("Dash")
+				dto.Dashboard.Data.Set("schemaVersion", 26)
+				dto.Dashboard.Data.Set("panels", []interface{}{map[string]interface{}{"id": 1}})
+				dto.Strict = true
+
+				_, err := service.SaveDashboard(dto, false)
+				So(err, ShouldHaveSameTypeAs, schema.ValidationErrors{})
+				So(err.Error(), ShouldEqual, "Invalid dashboard: panels[0].gridPos: is required")
+			})
+
+			Convey("Should migrate dashboard to the latest schema version", func() {
+				bus.AddHandler("test", func(cmd *models.GetProvisionedDashboardDataByIdQuery) error {
+					cmd.Result = nil
+					return nil
+				})
+
+				bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+					return xerrors.New("Alert validation error")
+				})
+
+				dto.Dashboard = models.NewDashboard("Dash")
+				dto.Dashboard.Data.Set("schemaVersion", 14)
+				dto.Dashboard.Data.Set("rows", []interface{}{map[string]interface{}{
+					"height": "250px",
+					"panels": []interface{}{map[string]interface{}{"id": 1, "span": 12}},
+				}})
+				dto.Strict = true
+
+				_, err := service.SaveDashboard(dto, false)
+				So(err.Error(), ShouldEqual, "Alert validation error")
+				So(dto.Dashboard.Data.Get("schemaVersion").MustInt(), ShouldEqual, schema.LatestVersion)
+				So(dto.Dashboard.Data.Get("panels").GetIndex(0).Get("gridPos").Get("w").MustInt(), ShouldEqual, 24)
+			})
+
+			Convey("When the dashboard has been changed by someone else", func() {
+				panel := func(id int, title string) map[string]interface{} {
+					return map[string]interface{}{"id": id, "title": title, "gridPos": map[string]interface{}{"h": 8, "w": 12, "x": 0, "y": 0}}
+				}
+				dashboard := func(version int, panels ...interface{}) *simplejson.Json {
+					return simplejson.NewFromAny(map[string]interface{}{
+						"id": 1, "uid": "dash", "title": "Dash", "version": version, "schemaVersion": 26, "panels": panels,
+					})
+				}
+
+				validations := 0
+				bus.AddHandler("test", func(cmd *models.ValidateDashboardBeforeSaveCommand) error {
+					validations++
+					if cmd.Dashboard.Version != 3 {
+						return models.ErrDashboardVersionMismatch
+					}
+					cmd.Result = &models.ValidateDashboardBeforeSaveResult{}
+					return nil
+				})
+				bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+					return nil
+				})
+				bus.AddHandler("test", func(cmd *models.GetProvisionedDashboardDataByIdQuery) error {
+					return nil
+				})
+				bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+					query.Result = models.NewDashboardFromJson(dashboard(3, panel(1, "CPU"), panel(2, "Memory used")))
+					return nil
+				})
+				bus.AddHandler("test", func(query *models.GetDashboardVersionQuery) error {
+					query.Result = &models.DashboardVersion{Data: dashboard(2, panel(1, "CPU"), panel(2, "Memory"))}
+					return nil
+				})
+				var saved *models.SaveDashboardCommand
+				bus.AddHandler("test", func(cmd *models.SaveDashboardCommand) error {
+					saved = cmd
+					cmd.Result = cmd.GetDashboardModel()
+					return nil
+				})
+				bus.AddHandler("test", func(cmd *models.UpdateDashboardAlertsCommand) error {
+					return nil
+				})
+
+				dto.User = &models.SignedInUser{UserId: 1, OrgRole: models.ROLE_ADMIN}
+
+				Convey("Should return version mismatch error without merge", func() {
+					dto.Dashboard = models.NewDashboardFromJson(dashboard(2, panel(1, "CPU usage"), panel(2, "Memory")))
+					_, err := service.SaveDashboard(dto, false)
+					So(err, ShouldEqual, models.ErrDashboardVersionMismatch)
+					So(dto.Merged, ShouldBeFalse)
+				})
+
+				Convey("Should save the dashboard merged with the latest version", func() {
+					dto.Dashboard = models.NewDashboardFromJson(dashboard(2, panel(1, "CPU usage"), panel(2, "Memory")))
+					dto.Merge = true
+
+					_, err := service.SaveDashboard(dto, false)
+					So(err, ShouldBeNil)
+					So(validations, ShouldEqual, 2)
+					So(dto.Merged, ShouldBeTrue)
+					So(saved.Dashboard.Get("version").MustInt(), ShouldEqual, 3)
+					So(saved.Dashboard.Get("panels").GetIndex(0).Get("title").MustString(), ShouldEqual, "CPU usage")
+					So(saved.Dashboard.Get("panels").GetIndex(1).Get("title").MustString(), ShouldEqual, "Memory used")
+				})
+
+				Convey("Should return version mismatch error if the changes conflict", func() {
+					dto.Dashboard = models.NewDashboardFromJson(dashboard(2, panel(1, "CPU"), panel(2, "Memory free")))
+					dto.Merge = true
+
+					_, err := service.SaveDashboard(dto, false)
+					So(err, ShouldEqual, models.ErrDashboardVersionMismatch)
+					So(dto.Merged, ShouldBeFalse)
+					So(saved, ShouldBeNil)
+				})
+			})
 		})
 
 		Convey("Save provisioned dashboard validation", func() {
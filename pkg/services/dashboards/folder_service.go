@@ -14,6 +14,7 @@ type FolderService interface {
 	GetFolderByUID(uid string) (*models.Folder, error)
 	CreateFolder(cmd *models.CreateFolderCommand) error
 	UpdateFolder(uid string, cmd *models.UpdateFolderCommand) error
+	MoveFolder(uid string, cmd *models.MoveFolderCommand) error
 	DeleteFolder(uid string) (*models.Folder, error)
 }
 
@@ -44,9 +45,11 @@ func (dr *dashboardServiceImpl) GetFolders(limit int64) ([]*models.Folder, error
 
 	for _, hit := range searchQuery.Result {
 		folders = append(folders, &models.Folder{
-			Id:    hit.Id,
-			Uid:   hit.Uid,
-			Title: hit.Title,
+			Id:        hit.Id,
+			Uid:       hit.Uid,
+			Title:     hit.Title,
+			ParentId:  hit.FolderId,
+			ParentUid: hit.FolderUid,
 		})
 	}
 
@@ -94,6 +97,12 @@ func (dr *dashboardServiceImpl) GetFolderByUID(uid string) (*models.Folder, erro
 func (dr *dashboardServiceImpl) CreateFolder(cmd *models.CreateFolderCommand) error {
 	dashFolder := cmd.GetDashboardModel(dr.orgId, dr.user.UserId)
 
+	parentId, err := dr.getParentFolderID(cmd.ParentUid)
+	if err != nil {
+		return err
+	}
+	dashFolder.FolderId = parentId
+
 	dto := &SaveDashboardDTO{
 		Dashboard: dashFolder,
 		OrgId:     dr.orgId,
@@ -158,6 +167,50 @@ func (dr *dashboardServiceImpl) UpdateFolder(existingUid string, cmd *models.Upd
 	return nil
 }
 
+// MoveFolder moves a folder, including its dashboards and subfolders, to
+// another folder. The user needs to be able to edit the folder and the new
+// parent folder.
+func (dr *dashboardServiceImpl) MoveFolder(uid string, cmd *models.MoveFolderCommand) error {
+	query := models.GetDashboardQuery{OrgId: dr.orgId, Uid: uid}
+	dashFolder, err := getFolder(query)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	parentId, err := dr.getParentFolderID(cmd.ParentUid)
+	if err != nil {
+		return err
+	}
+
+	dashFolder.FolderId = parentId
+
+	dto := &SaveDashboardDTO{
+		Dashboard: dashFolder,
+		OrgId:     dr.orgId,
+		User:      dr.user,
+	}
+
+	saveDashboardCmd, err := dr.buildSaveDashboardCommand(dto, false, false)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	err = bus.Dispatch(saveDashboardCmd)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	query = models.GetDashboardQuery{OrgId: dr.orgId, Id: saveDashboardCmd.Result.Id}
+	dashFolder, err = getFolder(query)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	cmd.Result = dashToFolder(dashFolder)
+
+	return nil
+}
+
 func (dr *dashboardServiceImpl) DeleteFolder(uid string) (*models.Folder, error) {
 	query := models.GetDashboardQuery{OrgId: dr.orgId, Uid: uid}
 	dashFolder, err := getFolder(query)
@@ -181,6 +234,24 @@ func (dr *dashboardServiceImpl) DeleteFolder(uid string) (*models.Folder, error)
 	return dashToFolder(dashFolder), nil
 }
 
+// getParentFolderID returns the id of the parent folder with the given uid, or
+// 0 for the General folder if uid is empty.
+func (dr *dashboardServiceImpl) getParentFolderID(uid string) (int64, error) {
+	if uid == "" {
+		return 0, nil
+	}
+
+	parent, err := getFolder(models.GetDashboardQuery{OrgId: dr.orgId, Uid: uid})
+	if err != nil {
+		if err == models.ErrFolderNotFound {
+			return 0, models.ErrFolderParentNotFound
+		}
+		return 0, err
+	}
+
+	return parent.Id, nil
+}
+
 func getFolder(query models.GetDashboardQuery) (*models.Dashboard, error) {
 	if err := bus.Dispatch(&query); err != nil {
 		return nil, toFolderError(err)
@@ -194,7 +265,7 @@ func getFolder(query models.GetDashboardQuery) (*models.Dashboard, error) {
 }
 
 func dashToFolder(dash *models.Dashboard) *models.Folder {
-	return &models.Folder{
+	folder := &models.Folder{
 		Id:        dash.Id,
 		Uid:       dash.Uid,
 		Title:     dash.Title,
@@ -205,7 +276,17 @@ func dashToFolder(dash *models.Dashboard) *models.Folder {
 		CreatedBy: dash.CreatedBy,
 		Updated:   dash.Updated,
 		UpdatedBy: dash.UpdatedBy,
+		ParentId:  dash.FolderId,
+	}
+
+	if dash.FolderId > 0 {
+		query := models.GetDashboardRefByIdQuery{Id: dash.FolderId}
+		if err := bus.Dispatch(&query); err == nil {
+			folder.ParentUid = query.Result.Uid
+		}
 	}
+
+	return folder
 }
 
 func toFolderError(err error) error {
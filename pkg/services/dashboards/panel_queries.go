@@ -0,0 +1,207 @@
+package dashboards
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// MixedDataSource is the data source of panels with queries of several data
+// sources.
+const MixedDataSource = "-- Mixed --"
+
+var (
+	ErrPanelNotFound      = errors.New("panel not found in dashboard")
+	ErrPanelQueryNotFound = errors.New("query not found in panel")
+)
+
+// variableRegex matches the variable syntaxes $var, [[var]] and ${var}, with
+// optional field paths and formats.
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[\s*(\w+)(?:\s*:\s*\w+)?\s*\]\]|\$\{(\w+)(?:\.[^:^}]+)?(?::\w+)?\}`)
+
+// PanelQuery is a query of a dashboard panel, with the template variables
+// replaced by their saved values.
+type PanelQuery struct {
+	PanelId int64
+	// Model is the query with the variables replaced.
+	Model *simplejson.Json
+	// Raw is the query as saved in the dashboard.
+	Raw *simplejson.Json
+	// DataSource is the name of the data source of the query, empty for the
+	// default data source.
+	DataSource string
+	// MaxDataPoints and Interval are the query options of the panel, zero and
+	// empty when they're not set.
+	MaxDataPoints int64
+	Interval      string
+}
+
+// GetDataSource returns the data source of the query in the organization.
+func (q *PanelQuery) GetDataSource(orgID int64) (*models.DataSource, error) {
+	if q.DataSource != "" {
+		query := models.GetDataSourceByNameQuery{Name: q.DataSource, OrgId: orgID}
+		if err := bus.Dispatch(&query); err != nil {
+			return nil, err
+		}
+		return query.Result, nil
+	}
+
+	query := models.GetDataSourcesQuery{OrgId: orgID}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+	for _, ds := range query.Result {
+		if ds.IsDefault {
+			return ds, nil
+		}
+	}
+
+	return nil, models.ErrDataSourceNotFound
+}
+
+// FindPanelQuery returns the query with the refId of the panel with the id,
+// including panels in collapsed rows.
+func FindPanelQuery(dashboard *simplejson.Json, panelId int64, refId string) (*PanelQuery, error) {
+	panel := findPanel(dashboard.Get("panels").MustArray(), panelId)
+	if panel == nil {
+		return nil, ErrPanelNotFound
+	}
+
+	for _, query := range panelQueries(panel, variableValues(dashboard)) {
+		if query.Model.Get("refId").MustString("A") == refId {
+			return query, nil
+		}
+	}
+
+	return nil, ErrPanelQueryNotFound
+}
+
+// GetPanelQueries returns the queries of all panels of the dashboard,
+// including panels in collapsed rows.
+func GetPanelQueries(dashboard *simplejson.Json) []*PanelQuery {
+	variables := variableValues(dashboard)
+
+	var queries []*PanelQuery
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for _, item := range panels {
+			panel := simplejson.NewFromAny(item)
+			queries = append(queries, panelQueries(panel, variables)...)
+			walk(panel.Get("panels").MustArray())
+		}
+	}
+	walk(dashboard.Get("panels").MustArray())
+
+	return queries
+}
+
+func panelQueries(panel *simplejson.Json, variables map[string]string) []*PanelQuery {
+	panelId := panel.Get("id").MustInt64()
+	panelDataSource := interpolate(panel.Get("datasource").MustString(), variables)
+	maxDataPoints := panel.Get("maxDataPoints").MustInt64()
+	interval := interpolate(panel.Get("interval").MustString(), variables)
+
+	var queries []*PanelQuery
+	for _, item := range panel.Get("targets").MustArray() {
+		target := simplejson.NewFromAny(item)
+
+		dataSource := panelDataSource
+		if dataSource == MixedDataSource {
+			dataSource = interpolate(target.Get("datasource").MustString(), variables)
+		}
+		if dataSource == "default" {
+			dataSource = ""
+		}
+
+		model := simplejson.NewFromAny(interpolateValue(item, variables))
+		if _, ok := model.CheckGet("refId"); !ok {
+			model.Set("refId", "A")
+		}
+
+		queries = append(queries, &PanelQuery{
+			PanelId:       panelId,
+			Model:         model,
+			Raw:           target,
+			DataSource:    dataSource,
+			MaxDataPoints: maxDataPoints,
+			Interval:      interval,
+		})
+	}
+
+	return queries
+}
+
+func findPanel(panels []interface{}, panelId int64) *simplejson.Json {
+	for _, item := range panels {
+		panel := simplejson.NewFromAny(item)
+		if panel.Get("id").MustInt64() == panelId {
+			return panel
+		}
+		if nested := findPanel(panel.Get("panels").MustArray(), panelId); nested != nil {
+			return nested
+		}
+	}
+	return nil
+}
+
+// variableValues returns the saved values of the template variables of the
+// dashboard. Multiple values are joined with commas.
+func variableValues(dashboard *simplejson.Json) map[string]string {
+	values := map[string]string{}
+	for _, item := range dashboard.Get("templating").Get("list").MustArray() {
+		variable := simplejson.NewFromAny(item)
+		current := variable.Get("current").Get("value")
+
+		if value, err := current.String(); err == nil {
+			values[variable.Get("name").MustString()] = value
+			continue
+		}
+
+		if list, err := current.StringArray(); err == nil {
+			values[variable.Get("name").MustString()] = strings.Join(list, ",")
+		}
+	}
+	return values
+}
+
+func interpolate(text string, variables map[string]string) string {
+	return variableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+		for _, name := range groups[1:] {
+			if name == "" {
+				continue
+			}
+			if value, ok := variables[name]; ok {
+				return value
+			}
+		}
+		return match
+	})
+}
+
+// interpolateValue returns a copy of the JSON value with the variables of its
+// strings replaced.
+func interpolateValue(value interface{}, variables map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return interpolate(v, variables)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = interpolateValue(item, variables)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = interpolateValue(item, variables)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,85 @@
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestPanelQueries(t *testing.T) {
+	dashboard := simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id":         1,
+				"datasource": "$ds",
+				"targets": []interface{}{
+					map[string]interface{}{"refId": "A", "rawSql": "SELECT * FROM ${table} WHERE host IN ([[host]])"},
+				},
+			},
+			map[string]interface{}{
+				"id":   2,
+				"type": "row",
+				"panels": []interface{}{
+					map[string]interface{}{
+						"id":         3,
+						"datasource": MixedDataSource,
+						"targets": []interface{}{
+							map[string]interface{}{"refId": "A", "datasource": "TestData", "scenarioId": "random_walk"},
+							map[string]interface{}{"refId": "B", "scenarioId": "csv_metric_values", "stringInput": "1,$unknown"},
+						},
+					},
+				},
+			},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "ds", "current": map[string]interface{}{"value": "MySQL"}},
+				map[string]interface{}{"name": "table", "current": map[string]interface{}{"value": "hosts"}},
+				map[string]interface{}{"name": "host", "current": map[string]interface{}{"value": []interface{}{"a", "b"}}},
+			},
+		},
+	})
+
+	t.Run("Should replace variables with their saved values", func(t *testing.T) {
+		query, err := FindPanelQuery(dashboard, 1, "A")
+		require.NoError(t, err)
+
+		assert.Equal(t, "MySQL", query.DataSource)
+		assert.Equal(t, "SELECT * FROM hosts WHERE host IN (a,b)", query.Model.Get("rawSql").MustString())
+		assert.Equal(t, "SELECT * FROM ${table} WHERE host IN ([[host]])", query.Raw.Get("rawSql").MustString())
+	})
+
+	t.Run("Should find queries of panels in rows and their data sources", func(t *testing.T) {
+		query, err := FindPanelQuery(dashboard, 3, "A")
+		require.NoError(t, err)
+		assert.Equal(t, "TestData", query.DataSource)
+
+		query, err = FindPanelQuery(dashboard, 3, "B")
+		require.NoError(t, err)
+		assert.Equal(t, "", query.DataSource)
+		assert.Equal(t, "1,$unknown", query.Model.Get("stringInput").MustString())
+	})
+
+	t.Run("Should return errors for unknown panels and queries", func(t *testing.T) {
+		_, err := FindPanelQuery(dashboard, 4, "A")
+		assert.Equal(t, ErrPanelNotFound, err)
+
+		_, err = FindPanelQuery(dashboard, 3, "C")
+		assert.Equal(t, ErrPanelQueryNotFound, err)
+	})
+
+	t.Run("Should return the queries of all panels", func(t *testing.T) {
+		queries := GetPanelQueries(dashboard)
+		require.Len(t, queries, 3)
+
+		assert.Equal(t, int64(1), queries[0].PanelId)
+		assert.Equal(t, "MySQL", queries[0].DataSource)
+		assert.Equal(t, int64(3), queries[1].PanelId)
+		assert.Equal(t, "TestData", queries[1].DataSource)
+		assert.Equal(t, int64(3), queries[2].PanelId)
+		assert.Equal(t, "B", queries[2].Model.Get("refId").MustString())
+	})
+}
@@ -0,0 +1,671 @@
+package schema
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const (
+	// LatestVersion is the schemaVersion dashboards are migrated to. It must be the same as in the
+	// DashboardMigrator of the frontend.
+	LatestVersion = 26
+	// MinMigratableVersion is the oldest schemaVersion the backend can migrate. Older dashboards are
+	// migrated by the frontend when they are loaded.
+	MinMigratableVersion = 13
+
+	gridColumnCount  = 24
+	gridCellHeight   = 30
+	gridCellVMargin  = 8
+	defaultPanelSpan = 4
+	defaultRowHeight = 250
+	minPanelHeight   = gridCellHeight * 3
+)
+
+// ErrSchemaVersionTooOld is returned when the dashboard is older than MinMigratableVersion.
+var ErrSchemaVersionTooOld = errors.New("dashboard schema version is too old to be migrated by the backend")
+
+type object = map[string]interface{}
+
+// Migrate migrates the dashboard JSON model to LatestVersion. Dashboards that are up to date or newer are
+// not changed.
+func Migrate(data *simplejson.Json) error {
+	dash, err := data.Map()
+	if err != nil {
+		return nil
+	}
+
+	oldVersion := 0
+	if v, ok := toFloat(dash["schemaVersion"]); ok {
+		oldVersion = int(v)
+	}
+
+	if oldVersion >= LatestVersion {
+		return nil
+	}
+	if oldVersion < MinMigratableVersion {
+		return ErrSchemaVersionTooOld
+	}
+
+	var panelUpgrades []func(panel object)
+
+	if oldVersion < 14 {
+		dash["graphTooltip"] = 0
+		if truthy(dash["sharedCrosshair"]) {
+			dash["graphTooltip"] = 1
+		}
+		delete(dash, "sharedCrosshair")
+	}
+
+	if oldVersion < 16 {
+		upgradeToGridLayout(dash)
+	}
+
+	if oldVersion < 17 {
+		panelUpgrades = append(panelUpgrades, upgradeMinSpan)
+	}
+
+	if oldVersion < 18 {
+		panelUpgrades = append(panelUpgrades, upgradeGaugeOptions)
+	}
+
+	if oldVersion < 19 {
+		panelUpgrades = append(panelUpgrades, func(panel object) {
+			if links, ok := panel["links"].([]interface{}); ok {
+				for i, link := range links {
+					if l, ok := link.(object); ok {
+						links[i] = upgradePanelLink(l)
+					}
+				}
+			}
+		})
+	}
+
+	if oldVersion < 20 {
+		panelUpgrades = append(panelUpgrades, func(panel object) {
+			updatePanelDataLinks(panel, updateVariablesSyntax, true)
+		})
+	}
+
+	if oldVersion < 21 {
+		panelUpgrades = append(panelUpgrades, func(panel object) {
+			updatePanelDataLinks(panel, func(url string) string {
+				return strings.Replace(url, "__series.labels", "__field.labels", -1)
+			}, false)
+		})
+	}
+
+	if oldVersion < 22 {
+		panelUpgrades = append(panelUpgrades, func(panel object) {
+			if panel["type"] != "table" {
+				return
+			}
+			styles, _ := panel["styles"].([]interface{})
+			for _, style := range styles {
+				if s, ok := style.(object); ok {
+					s["align"] = "auto"
+				}
+			}
+		})
+	}
+
+	if oldVersion < 23 {
+		for _, variable := range templateVariables(dash) {
+			multi, isMulti := variable["multi"].(bool)
+			if !isMulti {
+				continue
+			}
+			if current, ok := variable["current"].(object); ok {
+				variable["current"] = alignCurrentWithMulti(current, multi)
+			}
+		}
+	}
+
+	if oldVersion < 24 {
+		panelUpgrades = append(panelUpgrades, func(panel object) {
+			if panel["type"] != "table" || !truthy(panel["styles"]) || panel["table"] == "table2" {
+				return
+			}
+			panel["type"] = "table-old"
+		})
+	}
+
+	if oldVersion < 25 {
+		for _, variable := range templateVariables(dash) {
+			if variable["type"] == "query" {
+				upgradeVariableTags(variable)
+			}
+		}
+	}
+
+	if oldVersion < 26 {
+		panelUpgrades = append(panelUpgrades, func(panel object) {
+			if panel["type"] != "text2" {
+				return
+			}
+			panel["type"] = "text"
+			if options, ok := panel["options"].(object); ok {
+				delete(options, "angular")
+			}
+		})
+	}
+
+	panels, _ := dash["panels"].([]interface{})
+	for _, p := range panels {
+		panel, ok := p.(object)
+		if !ok {
+			continue
+		}
+
+		for _, upgrade := range panelUpgrades {
+			upgrade(panel)
+			nested, _ := panel["panels"].([]interface{})
+			for _, n := range nested {
+				if nestedPanel, ok := n.(object); ok {
+					upgrade(nestedPanel)
+				}
+			}
+		}
+	}
+
+	dash["schemaVersion"] = LatestVersion
+	return nil
+}
+
+// truthy returns if the value is truthy in javascript.
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+
+	if number, ok := toFloat(value); ok {
+		return number != 0 && !math.IsNaN(number)
+	}
+
+	return true
+}
+
+func templateVariables(dash object) []object {
+	var variables []object
+	templating, _ := dash["templating"].(object)
+	list, _ := templating["list"].([]interface{})
+	for _, v := range list {
+		if variable, ok := v.(object); ok {
+			variables = append(variables, variable)
+		}
+	}
+	return variables
+}
+
+// upgradeToGridLayout moves the panels of the rows of the dashboard to the panels of the dashboard, positioned
+// on the grid. Rows become row panels if at least one row is collapsed, repeated or shows its title.
+func upgradeToGridLayout(dash object) {
+	rows, ok := dash["rows"].([]interface{})
+	if !ok {
+		return
+	}
+	delete(dash, "rows")
+
+	panels, _ := dash["panels"].([]interface{})
+	if panels == nil {
+		panels = []interface{}{}
+	}
+
+	maxPanelID := 0.0
+	showRows := false
+	for _, r := range rows {
+		row, _ := r.(object)
+		if truthy(row["collapse"]) || truthy(row["showTitle"]) || truthy(row["repeat"]) {
+			showRows = true
+		}
+		rowPanels, _ := row["panels"].([]interface{})
+		for _, p := range rowPanels {
+			panel, _ := p.(object)
+			if id, ok := toFloat(panel["id"]); ok && id > maxPanelID {
+				maxPanelID = id
+			}
+		}
+	}
+	nextRowID := int64(maxPanelID) + 1
+
+	yPos := 0
+	widthFactor := gridColumnCount / 12
+
+	for _, r := range rows {
+		row, ok := r.(object)
+		if !ok || truthy(row["repeatIteration"]) {
+			continue
+		}
+
+		var height interface{} = defaultRowHeight
+		if truthy(row["height"]) {
+			height = row["height"]
+		}
+		rowGridHeight := getGridHeight(height)
+
+		var rowPanel object
+		collapsed := false
+		if showRows {
+			rowPanel = object{
+				"id":     nextRowID,
+				"type":   "row",
+				"panels": []interface{}{},
+				"gridPos": object{
+					"x": 0,
+					"y": yPos,
+					"w": gridColumnCount,
+					"h": rowGridHeight,
+				},
+			}
+			for key, rowKey := range map[string]string{"title": "title", "collapsed": "collapse", "repeat": "repeat"} {
+				if value, ok := row[rowKey]; ok {
+					rowPanel[key] = value
+				}
+			}
+			collapsed = truthy(row["collapse"])
+			nextRowID++
+			yPos++
+		}
+
+		area := newRowArea(rowGridHeight, gridColumnCount, yPos)
+
+		rowPanels, _ := row["panels"].([]interface{})
+		for _, p := range rowPanels {
+			panel, ok := p.(object)
+			if !ok {
+				continue
+			}
+
+			span := float64(defaultPanelSpan)
+			if s, ok := toFloat(panel["span"]); ok && s != 0 {
+				span = s
+			}
+			if minSpan, ok := toFloat(panel["minSpan"]); ok && minSpan != 0 {
+				panel["minSpan"] = math.Min(gridColumnCount, float64(widthFactor)*minSpan)
+			}
+
+			panelWidth := int(math.Floor(span)) * widthFactor
+			panelHeight := rowGridHeight
+			if truthy(panel["height"]) {
+				panelHeight = getGridHeight(panel["height"])
+			}
+
+			x, y, _ := area.getPanelPosition(panelHeight, panelWidth, false)
+			yPos = area.yPos
+			panel["gridPos"] = object{
+				"x": x,
+				"y": yPos + y,
+				"w": panelWidth,
+				"h": panelHeight,
+			}
+			area.addPanel(x, yPos+y, panelWidth, panelHeight)
+
+			delete(panel, "span")
+
+			if rowPanel != nil && collapsed {
+				rowPanel["panels"] = append(rowPanel["panels"].([]interface{}), panel)
+			} else {
+				panels = append(panels, panel)
+			}
+		}
+
+		if rowPanel != nil {
+			panels = append(panels, rowPanel)
+		}
+
+		if !(rowPanel != nil && collapsed) {
+			yPos += rowGridHeight
+		}
+	}
+
+	dash["panels"] = panels
+}
+
+// getGridHeight returns the height in grid cells of a height in pixels.
+func getGridHeight(height interface{}) int {
+	pixels := float64(minPanelHeight)
+	if s, ok := height.(string); ok {
+		if h, err := strconv.Atoi(leadingDigits(strings.Replace(s, "px", "", -1))); err == nil {
+			pixels = float64(h)
+		}
+	} else if h, ok := toFloat(height); ok {
+		pixels = h
+	}
+
+	if pixels < minPanelHeight {
+		pixels = minPanelHeight
+	}
+
+	return int(math.Ceil(pixels / (gridCellHeight + gridCellVMargin)))
+}
+
+// leadingDigits returns the integer at the start of the string, like parseInt in javascript.
+func leadingDigits(s string) string {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || end == 0 && s[end] == '-') {
+		end++
+	}
+	return s[:end]
+}
+
+// rowArea represents a dashboard row filled by panels. area has the height of the filled cells of each
+// column of the row.
+type rowArea struct {
+	area   []int
+	yPos   int
+	height int
+}
+
+func newRowArea(height int, width int, yPos int) *rowArea {
+	return &rowArea{area: make([]int, width), yPos: yPos, height: height}
+}
+
+func (r *rowArea) reset() {
+	for i := range r.area {
+		r.area[i] = 0
+	}
+}
+
+// addPanel updates the area after adding the panel.
+func (r *rowArea) addPanel(x int, y int, w int, h int) {
+	for i := x; i < x+w && i < len(r.area); i++ {
+		if r.area[i] == 0 || y+h-r.yPos > r.area[i] {
+			r.area[i] = y + h - r.yPos
+		}
+	}
+}
+
+// getPanelPosition returns the position of a new panel in the row, wrapping to a new row if the panel does
+// not fit.
+func (r *rowArea) getPanelPosition(panelHeight int, panelWidth int, callOnce bool) (int, int, bool) {
+	startPlace, endPlace := -1, -1
+	for i := len(r.area) - 1; i >= 0; i-- {
+		if r.height-r.area[i] <= 0 {
+			break
+		}
+		if endPlace == -1 {
+			endPlace = i
+		} else if i < len(r.area)-1 && r.area[i] <= r.area[i+1] {
+			startPlace = i
+		} else {
+			break
+		}
+	}
+
+	if startPlace != -1 && endPlace != -1 && endPlace-startPlace >= panelWidth-1 {
+		y := 0
+		for _, filled := range r.area[startPlace:] {
+			if filled > y {
+				y = filled
+			}
+		}
+		return startPlace, y, true
+	}
+
+	if !callOnce {
+		// wrap to next row
+		r.yPos += r.height
+		r.reset()
+		return r.getPanelPosition(panelHeight, panelWidth, true)
+	}
+
+	return 0, 0, false
+}
+
+// upgradeMinSpan replaces the minimum span of repeated panels by the maximum number of panels per row.
+func upgradeMinSpan(panel object) {
+	if minSpan, ok := toFloat(panel["minSpan"]); ok && minSpan != 0 {
+		max := gridColumnCount / minSpan
+		factors := []int{1, 2, 3, 4, 6, 8, 12, 24}
+		for i, factor := range factors {
+			if float64(factor) > max {
+				if i > 0 {
+					panel["maxPerRow"] = factors[i-1]
+				}
+				break
+			}
+		}
+	}
+	delete(panel, "minSpan")
+}
+
+func upgradeGaugeOptions(panel object) {
+	options, ok := panel["options-gauge"].(object)
+	if !ok {
+		return
+	}
+
+	valueOptions := object{}
+	for _, key := range []string{"unit", "stat", "decimals", "prefix", "suffix"} {
+		if value, ok := options[key]; ok {
+			valueOptions[key] = value
+		}
+		delete(options, key)
+	}
+	options["valueOptions"] = valueOptions
+
+	// correct order
+	if thresholds, ok := options["thresholds"].([]interface{}); ok {
+		for i, j := 0, len(thresholds)-1; i < j; i, j = i+1, j-1 {
+			thresholds[i], thresholds[j] = thresholds[j], thresholds[i]
+		}
+	}
+
+	// this options prop was due to a bug
+	delete(options, "options")
+
+	panel["options"] = options
+	delete(panel, "options-gauge")
+}
+
+var slugifyForURLPattern = regexp.MustCompile(`[^\w ]+`)
+var spacesPattern = regexp.MustCompile(` +`)
+
+// upgradePanelLink converts a panel link to a data link.
+func upgradePanelLink(link object) object {
+	url, _ := link["url"].(string)
+
+	if dashboard, ok := link["dashboard"].(string); url == "" && ok && dashboard != "" {
+		slug := slugifyForURLPattern.ReplaceAllString(strings.ToLower(dashboard), "")
+		url = "dashboard/db/" + spacesPattern.ReplaceAllString(slug, "-")
+	}
+
+	if dashURI, ok := link["dashUri"].(string); url == "" && ok && dashURI != "" {
+		url = "dashboard/" + dashURI
+	}
+
+	// some models are incomplete and have no dashboard or dashUri
+	if url == "" {
+		url = "/"
+	}
+
+	if truthy(link["keepTime"]) {
+		url = appendQueryToURL(url, "$__url_time_range")
+	}
+
+	if truthy(link["includeVars"]) {
+		url = appendQueryToURL(url, "$__all_variables")
+	}
+
+	if params, ok := link["params"].(string); ok {
+		url = appendQueryToURL(url, params)
+	}
+
+	result := object{"url": url}
+	for _, key := range []string{"title", "targetBlank"} {
+		if value, ok := link[key]; ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func appendQueryToURL(url string, query string) string {
+	if query == "" {
+		return url
+	}
+
+	if pos := strings.Index(url, "?"); pos != -1 {
+		if len(url)-pos > 1 {
+			url += "&"
+		}
+	} else {
+		url += "?"
+	}
+
+	return url + query
+}
+
+// updatePanelDataLinks updates the urls of the data links of graph panels and panels with field options and,
+// if updateTitle is true, the title of the field options.
+func updatePanelDataLinks(panel object, update func(string) string, updateTitle bool) {
+	options, ok := panel["options"].(object)
+	if !ok {
+		return
+	}
+
+	updateLinks := func(links []interface{}) {
+		for _, link := range links {
+			if l, ok := link.(object); ok {
+				if url, ok := l["url"].(string); ok {
+					l["url"] = update(url)
+				}
+			}
+		}
+	}
+
+	// For graph panel
+	if links, ok := options["dataLinks"].([]interface{}); ok {
+		updateLinks(links)
+	}
+
+	// For panel with fieldOptions
+	fieldOptions, _ := options["fieldOptions"].(object)
+	defaults, ok := fieldOptions["defaults"].(object)
+	if !ok {
+		return
+	}
+
+	if links, ok := defaults["links"].([]interface{}); ok {
+		updateLinks(links)
+	}
+
+	if title, ok := defaults["title"].(string); ok && updateTitle && title != "" {
+		defaults["title"] = update(title)
+	}
+}
+
+var legacyVariableNamesPattern = regexp.MustCompile(`(__series_name)|(\$__series_name)|(__value_time)|(__field_name)|(\$__field_name)`)
+
+func updateVariablesSyntax(text string) string {
+	return legacyVariableNamesPattern.ReplaceAllStringFunc(text, func(match string) string {
+		switch match {
+		case "__series_name":
+			return "__series.name"
+		case "$__series_name":
+			return "${__series.name}"
+		case "__value_time":
+			return "__value.time"
+		case "__field_name":
+			return "__field.name"
+		case "$__field_name":
+			return "${__field.name}"
+		}
+		return match
+	})
+}
+
+// alignCurrentWithMulti makes the current value of a variable a list if the variable is multi-value, and a
+// single value otherwise.
+func alignCurrentWithMulti(current object, multi bool) object {
+	value, isList := current["value"].([]interface{})
+
+	if multi && !isList {
+		aligned := copyObject(current)
+		aligned["value"] = []interface{}{current["value"]}
+		if text, ok := current["text"].([]interface{}); ok {
+			aligned["text"] = text
+		} else {
+			aligned["text"] = []interface{}{current["text"]}
+		}
+		return aligned
+	}
+
+	if !multi && isList {
+		aligned := copyObject(current)
+		aligned["value"] = firstOrEmpty(value)
+		if text, ok := current["text"].([]interface{}); ok {
+			aligned["text"] = firstOrEmpty(text)
+		}
+		return aligned
+	}
+
+	return current
+}
+
+func firstOrEmpty(list []interface{}) interface{} {
+	if len(list) > 0 {
+		return list[0]
+	}
+	return ""
+}
+
+func copyObject(o object) object {
+	c := make(object, len(o))
+	for key, value := range o {
+		c[key] = value
+	}
+	return c
+}
+
+// upgradeVariableTags converts the tags of a query variable from strings to objects.
+func upgradeVariableTags(variable object) {
+	tags, ok := variable["tags"].([]interface{})
+	if !ok {
+		variable["tags"] = []interface{}{}
+		return
+	}
+
+	currents := map[string]object{}
+	current, _ := variable["current"].(object)
+	currentTags, _ := current["tags"].([]interface{})
+	for _, t := range currentTags {
+		if tag, ok := t.(object); ok {
+			if text, ok := tag["text"].(string); ok {
+				currents[text] = tag
+			}
+		}
+	}
+
+	newTags := []interface{}{}
+	for _, t := range tags {
+		switch tag := t.(type) {
+		case object:
+			// new format let's assume it's correct
+			newTags = append(newTags, tag)
+		case string:
+			newTag, ok := currents[tag]
+			if !ok {
+				newTag = object{}
+			}
+			if _, ok := newTag["text"]; !ok {
+				newTag["text"] = tag
+			}
+			if _, ok := newTag["selected"]; !ok {
+				newTag["selected"] = false
+			}
+			newTags = append(newTags, newTag)
+		}
+	}
+	variable["tags"] = newTags
+}
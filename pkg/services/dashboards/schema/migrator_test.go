@@ -0,0 +1,148 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestMigrate(t *testing.T) {
+	migrate := func(t *testing.T, dashboard string) map[string]interface{} {
+		data, err := simplejson.NewJson([]byte(dashboard))
+		require.NoError(t, err)
+		require.NoError(t, Migrate(data))
+		require.Empty(t, Validate(data))
+
+		// compare the JSON models
+		encoded, err := data.Encode()
+		require.NoError(t, err)
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(encoded, &result))
+		return result
+	}
+
+	gridPos := func(x, y, w, h float64) map[string]interface{} {
+		return map[string]interface{}{"x": x, "y": y, "w": w, "h": h}
+	}
+
+	t.Run("Should move the panels of rows to the grid", func(t *testing.T) {
+		result := migrate(t, `{
+			"title": "Rows",
+			"schemaVersion": 13,
+			"sharedCrosshair": true,
+			"rows": [
+				{"height": "250px", "panels": [
+					{"id": 1, "type": "graph", "span": 6},
+					{"id": 2, "type": "graph", "span": 6, "minSpan": 3}
+				]},
+				{"height": 250, "panels": [{"id": 3, "type": "singlestat", "span": 12}]}
+			]
+		}`)
+
+		require.Equal(t, float64(LatestVersion), result["schemaVersion"])
+		require.Equal(t, float64(1), result["graphTooltip"])
+		require.NotContains(t, result, "rows")
+		require.NotContains(t, result, "sharedCrosshair")
+
+		panels := result["panels"].([]interface{})
+		require.Len(t, panels, 3)
+		require.Equal(t, gridPos(0, 0, 12, 7), panels[0].(map[string]interface{})["gridPos"])
+		require.Equal(t, gridPos(12, 0, 12, 7), panels[1].(map[string]interface{})["gridPos"])
+		require.Equal(t, gridPos(0, 7, 24, 7), panels[2].(map[string]interface{})["gridPos"])
+		require.NotContains(t, panels[0], "span")
+
+		// minSpan 3 of 12 columns is 6 of 24 columns, so at most 4 panels per row
+		require.Equal(t, float64(4), panels[1].(map[string]interface{})["maxPerRow"])
+		require.NotContains(t, panels[1], "minSpan")
+	})
+
+	t.Run("Should create row panels for collapsed rows", func(t *testing.T) {
+		result := migrate(t, `{
+			"title": "Collapsed",
+			"schemaVersion": 15,
+			"rows": [
+				{"title": "Visible", "height": "250px", "panels": [{"id": 1, "type": "graph", "span": 12}]},
+				{"title": "Collapsed", "collapse": true, "height": "250px", "panels": [{"id": 2, "type": "graph", "span": 12}]}
+			]
+		}`)
+
+		panels := result["panels"].([]interface{})
+		require.Len(t, panels, 3)
+		require.Equal(t, gridPos(0, 1, 24, 7), panels[0].(map[string]interface{})["gridPos"])
+
+		row := panels[1].(map[string]interface{})
+		require.Equal(t, "row", row["type"])
+		require.Equal(t, float64(3), row["id"])
+		require.Equal(t, gridPos(0, 0, 24, 7), row["gridPos"])
+		require.Empty(t, row["panels"])
+
+		collapsed := panels[2].(map[string]interface{})
+		require.Equal(t, true, collapsed["collapsed"])
+		require.Equal(t, float64(4), collapsed["id"])
+		require.Len(t, collapsed["panels"], 1)
+		require.Equal(t, gridPos(0, 9, 24, 7), collapsed["panels"].([]interface{})[0].(map[string]interface{})["gridPos"])
+	})
+
+	t.Run("Should upgrade panels and variables", func(t *testing.T) {
+		result := migrate(t, `{
+			"title": "Panels",
+			"schemaVersion": 18,
+			"templating": {"list": [
+				{"name": "multi", "type": "custom", "multi": true, "current": {"text": "a", "value": "a"}},
+				{"name": "single", "type": "custom", "multi": false, "current": {"text": ["b", "c"], "value": ["b", "c"]}},
+				{"name": "tags", "type": "query", "tags": ["x", {"text": "y"}], "current": {"tags": [{"text": "x", "selected": true}]}}
+			]},
+			"panels": [
+				{"id": 1, "type": "graph", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0},
+					"links": [{"dashboard": "My Dashboard!", "keepTime": true, "params": "a=b", "title": "Link"}],
+					"options": {"dataLinks": [{"url": "/d?name=$__series_name&label=__series.labels.job"}]}},
+				{"id": 2, "type": "table", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}, "styles": [{"pattern": "/.*/"}]},
+				{"id": 3, "type": "row", "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8}, "panels": [
+					{"id": 4, "type": "text2", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 9}, "options": {"angular": {}, "mode": "markdown"}}
+				]}
+			]
+		}`)
+
+		panels := result["panels"].([]interface{})
+		graph := panels[0].(map[string]interface{})
+		require.Equal(t, []interface{}{map[string]interface{}{
+			"url":   "dashboard/db/my-dashboard?$__url_time_range&a=b",
+			"title": "Link",
+		}}, graph["links"])
+		dataLink := graph["options"].(map[string]interface{})["dataLinks"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "/d?name=${__series.name}&label=__field.labels.job", dataLink["url"])
+
+		table := panels[1].(map[string]interface{})
+		require.Equal(t, "table-old", table["type"])
+		require.Equal(t, "auto", table["styles"].([]interface{})[0].(map[string]interface{})["align"])
+
+		text := panels[2].(map[string]interface{})["panels"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "text", text["type"])
+		require.Equal(t, map[string]interface{}{"mode": "markdown"}, text["options"])
+
+		variables := result["templating"].(map[string]interface{})["list"].([]interface{})
+		require.Equal(t, map[string]interface{}{"text": []interface{}{"a"}, "value": []interface{}{"a"}}, variables[0].(map[string]interface{})["current"])
+		require.Equal(t, map[string]interface{}{"text": "b", "value": "b"}, variables[1].(map[string]interface{})["current"])
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"text": "x", "selected": true},
+			map[string]interface{}{"text": "y"},
+		}, variables[2].(map[string]interface{})["tags"])
+	})
+
+	t.Run("Should not change up to date dashboards", func(t *testing.T) {
+		data := simplejson.NewFromAny(map[string]interface{}{"title": "New", "schemaVersion": 27, "rows": []interface{}{}})
+		require.NoError(t, Migrate(data))
+		require.Equal(t, 27, data.Get("schemaVersion").MustInt())
+		_, hasRows := data.CheckGet("rows")
+		require.True(t, hasRows)
+	})
+
+	t.Run("Should not migrate dashboards that are too old", func(t *testing.T) {
+		data := simplejson.NewFromAny(map[string]interface{}{"title": "Old", "schemaVersion": 12, "rows": []interface{}{}})
+		require.Equal(t, ErrSchemaVersionTooOld, Migrate(data))
+		require.Equal(t, 12, data.Get("schemaVersion").MustInt())
+	})
+}
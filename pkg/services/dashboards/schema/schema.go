@@ -0,0 +1,299 @@
+// Package schema validates dashboard JSON models against the schema of their schemaVersion and migrates them
+// to the latest schemaVersion, like the dashboard migrator of the frontend.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// ValidationError is an error in a dashboard JSON model. Path is the path of the invalid value, for example
+// `panels[0].gridPos.w`, and is empty for the dashboard itself.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors are the errors of an invalid dashboard JSON model.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	if len(e) == 0 {
+		return "Dashboard is valid"
+	}
+	if len(e) == 1 {
+		return "Invalid dashboard: " + e[0].Error()
+	}
+	return fmt.Sprintf("Invalid dashboard: %s (and %d more errors)", e[0].Error(), len(e)-1)
+}
+
+// jsonSchema is the subset of JSON Schema used by the dashboard schemas.
+type jsonSchema struct {
+	Type        typeList               `json:"type"`
+	Enum        []interface{}          `json:"enum"`
+	Minimum     *float64               `json:"minimum"`
+	Maximum     *float64               `json:"maximum"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	Required    []string               `json:"required"`
+	Items       *jsonSchema            `json:"items"`
+	Not         *jsonSchema            `json:"not"`
+	AllOf       []*jsonSchema          `json:"allOf"`
+	Ref         string                 `json:"$ref"`
+	Definitions map[string]*jsonSchema `json:"definitions"`
+}
+
+// typeList is the type keyword, either a type or a list of types.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// versionedSchema is the schema of the dashboards from a schemaVersion until the minVersion of the next schema.
+type versionedSchema struct {
+	minVersion int
+	ref        string
+}
+
+var (
+	dashboardSchema *jsonSchema
+
+	versionedSchemas = []versionedSchema{
+		{minVersion: 0, ref: "#/definitions/rowsDashboard"},
+		{minVersion: 16, ref: "#/definitions/gridDashboard"},
+	}
+)
+
+func init() {
+	dashboardSchema = &jsonSchema{}
+	if err := json.Unmarshal([]byte(dashboardSchemaJSON), dashboardSchema); err != nil {
+		panic(fmt.Sprintf("invalid dashboard schema: %v", err))
+	}
+}
+
+// Validate validates the dashboard JSON model against the schema of its schemaVersion.
+func Validate(data *simplejson.Json) ValidationErrors {
+	errs := ValidationErrors{}
+	if data == nil {
+		return append(errs, ValidationError{Message: "should be object"})
+	}
+
+	version := 0
+	if v, ok := toFloat(data.Get("schemaVersion").Interface()); ok {
+		version = int(v)
+	}
+
+	ref := versionedSchemas[0].ref
+	for _, s := range versionedSchemas {
+		if version >= s.minVersion {
+			ref = s.ref
+		}
+	}
+
+	validateValue(data.Interface(), &jsonSchema{Ref: ref}, "", &errs)
+	if len(errs) == 0 && version >= 16 {
+		validatePanelIDs(data.Get("panels").Interface(), "panels", map[int64]bool{}, &errs)
+	}
+
+	return errs
+}
+
+// validatePanelIDs adds an error for each panel with the id of another panel, including the panels of rows.
+func validatePanelIDs(value interface{}, path string, seen map[int64]bool, errs *ValidationErrors) {
+	panels, _ := value.([]interface{})
+	for i, p := range panels {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		panelPath := fmt.Sprintf("%s[%d]", path, i)
+		if id, ok := toFloat(panel["id"]); ok {
+			if seen[int64(id)] {
+				*errs = append(*errs, ValidationError{Path: panelPath + ".id", Message: fmt.Sprintf("duplicate panel id %d", int64(id))})
+			}
+			seen[int64(id)] = true
+		}
+
+		validatePanelIDs(panel["panels"], panelPath+".panels", seen, errs)
+	}
+}
+
+func validateValue(value interface{}, s *jsonSchema, path string, errs *ValidationErrors) {
+	if s.Ref != "" {
+		definition, ok := dashboardSchema.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+		if !ok {
+			panic(fmt.Sprintf("unknown schema reference %s", s.Ref))
+		}
+		validateValue(value, definition, path, errs)
+		return
+	}
+
+	for _, sub := range s.AllOf {
+		validateValue(value, sub, path, errs)
+	}
+
+	if s.Not != nil {
+		notErrs := ValidationErrors{}
+		validateValue(value, s.Not, path, &notErrs)
+		if len(notErrs) == 0 {
+			message := "should not be valid against the schema"
+			if len(s.Not.Required) > 0 {
+				message = fmt.Sprintf("should not have property %s", strings.Join(s.Not.Required, ", "))
+			}
+			*errs = append(*errs, ValidationError{Path: path, Message: message})
+		}
+	}
+
+	valueType := jsonType(value)
+	if len(s.Type) > 0 && !matchesType(valueType, value, s.Type) {
+		*errs = append(*errs, ValidationError{Path: path, Message: "should be " + strings.Join(s.Type, " or ")})
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("should be one of %v", s.Enum)})
+	}
+
+	if number, ok := toFloat(value); ok {
+		if s.Minimum != nil && number < *s.Minimum {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("should be >= %v", *s.Minimum)})
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("should be <= %v", *s.Maximum)})
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, ValidationError{Path: joinPath(path, name), Message: "is required"})
+			}
+		}
+
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := v[name]; ok {
+				validateValue(property, s.Properties[name], joinPath(path, name), errs)
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				validateValue(item, s.Items, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+
+	return "unknown"
+}
+
+func matchesType(valueType string, value interface{}, types typeList) bool {
+	for _, t := range types {
+		if t == valueType {
+			return true
+		}
+		if t == "integer" && valueType == "number" {
+			number, _ := toFloat(value)
+			if number == math.Trunc(number) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	number, isNumber := toFloat(value)
+	for _, e := range enum {
+		if isNumber {
+			if n, ok := toFloat(e); ok && n == number {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(value, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat returns the value of a JSON number, decoded by simplejson or set from Go.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestValidate(t *testing.T) {
+	validate := func(t *testing.T, json string) ValidationErrors {
+		data, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		return Validate(data)
+	}
+
+	t.Run("Should accept a valid dashboard", func(t *testing.T) {
+		errs := validate(t, `{
+			"title": "Valid",
+			"uid": null,
+			"schemaVersion": 26,
+			"tags": ["a"],
+			"time": {"from": "now-6h", "to": "now"},
+			"templating": {"list": [{"name": "host", "type": "query", "multi": true}]},
+			"panels": [
+				{"id": 1, "type": "graph", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}, "targets": [{"refId": "A"}]},
+				{"id": 2, "type": "row", "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8}, "panels": [
+					{"id": 3, "type": "text", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 9}}
+				]}
+			]
+		}`)
+		require.Empty(t, errs)
+	})
+
+	t.Run("Should return the errors of an invalid dashboard", func(t *testing.T) {
+		errs := validate(t, `{
+			"schemaVersion": 26,
+			"tags": "a",
+			"graphTooltip": 3,
+			"templating": {"list": [{"type": "query"}]},
+			"rows": [],
+			"panels": [
+				{"id": 1, "type": "graph", "gridPos": {"h": 8, "w": 30, "x": 0, "y": 0}},
+				{"id": 2, "type": "graph"}
+			]
+		}`)
+
+		messages := map[string]string{}
+		for _, err := range errs {
+			messages[err.Path] = err.Message
+		}
+		require.Equal(t, map[string]string{
+			"":                        "should not have property rows",
+			"title":                   "is required",
+			"tags":                    "should be array",
+			"graphTooltip":            "should be one of [0 1 2]",
+			"templating.list[0].name": "is required",
+			"panels[0].gridPos.w":     "should be <= 24",
+			"panels[1].gridPos":       "is required",
+		}, messages)
+		require.Equal(t, "Invalid dashboard: title: is required (and 6 more errors)", errs.Error())
+	})
+
+	t.Run("Should return duplicate panel ids", func(t *testing.T) {
+		errs := validate(t, `{
+			"title": "Duplicate",
+			"schemaVersion": 26,
+			"panels": [
+				{"id": 1, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 0}, "panels": [
+					{"id": 1, "gridPos": {"h": 8, "w": 24, "x": 0, "y": 1}}
+				]}
+			]
+		}`)
+		require.Equal(t, ValidationErrors{{Path: "panels[0].panels[0].id", Message: "duplicate panel id 1"}}, errs)
+	})
+
+	t.Run("Should validate dashboards with rows against the schema of their version", func(t *testing.T) {
+		errs := validate(t, `{
+			"title": "Rows",
+			"schemaVersion": 14,
+			"rows": [{"height": "250px", "panels": [{"id": 1, "type": "graph", "span": 14}]}]
+		}`)
+		require.Equal(t, ValidationErrors{{Path: "rows[0].panels[0].span", Message: "should be <= 12"}}, errs)
+	})
+}
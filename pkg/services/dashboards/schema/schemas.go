@@ -0,0 +1,157 @@
+package schema
+
+// dashboardSchemaJSON defines the schemas of the dashboard JSON model. Dashboards before schemaVersion 16 have
+// their panels in rows, later dashboards have panels positioned on a grid. The schemas only check the properties
+// Grafana relies on, panels and queries can have any other property.
+const dashboardSchemaJSON = `{
+  "definitions": {
+    "dashboard": {
+      "type": "object",
+      "required": ["title"],
+      "properties": {
+        "id": { "type": ["integer", "null"] },
+        "uid": { "type": ["string", "null"] },
+        "title": { "type": "string" },
+        "description": { "type": "string" },
+        "tags": { "type": "array", "items": { "type": "string" } },
+        "style": { "type": "string", "enum": ["dark", "light"] },
+        "timezone": { "type": "string" },
+        "editable": { "type": "boolean" },
+        "graphTooltip": { "type": "integer", "enum": [0, 1, 2] },
+        "time": {
+          "type": "object",
+          "required": ["from", "to"],
+          "properties": {
+            "from": { "type": "string" },
+            "to": { "type": "string" }
+          }
+        },
+        "timepicker": { "type": "object" },
+        "refresh": { "type": ["string", "boolean"] },
+        "schemaVersion": { "type": "integer", "minimum": 0 },
+        "version": { "type": "integer", "minimum": 0 },
+        "links": { "type": "array", "items": { "type": "object" } },
+        "templating": {
+          "type": "object",
+          "properties": {
+            "list": { "type": "array", "items": { "$ref": "#/definitions/variable" } }
+          }
+        },
+        "annotations": {
+          "type": "object",
+          "properties": {
+            "list": { "type": "array", "items": { "$ref": "#/definitions/annotation" } }
+          }
+        }
+      }
+    },
+    "variable": {
+      "type": "object",
+      "required": ["name", "type"],
+      "properties": {
+        "name": { "type": "string" },
+        "type": { "type": "string" },
+        "label": { "type": ["string", "null"] },
+        "hide": { "type": "integer", "enum": [0, 1, 2] },
+        "multi": { "type": "boolean" },
+        "includeAll": { "type": "boolean" },
+        "options": { "type": "array" },
+        "current": { "type": ["object", "null"] }
+      }
+    },
+    "annotation": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": { "type": "string" },
+        "enable": { "type": "boolean" },
+        "hide": { "type": "boolean" },
+        "iconColor": { "type": "string" }
+      }
+    },
+    "target": {
+      "type": "object",
+      "properties": {
+        "refId": { "type": "string" },
+        "hide": { "type": "boolean" }
+      }
+    },
+    "panel": {
+      "type": "object",
+      "properties": {
+        "id": { "type": "integer" },
+        "type": { "type": "string" },
+        "title": { "type": "string" },
+        "description": { "type": "string" },
+        "datasource": { "type": ["string", "null"] },
+        "targets": { "type": "array", "items": { "$ref": "#/definitions/target" } },
+        "links": { "type": "array", "items": { "type": "object" } },
+        "options": { "type": "object" },
+        "fieldConfig": { "type": "object" }
+      }
+    },
+    "rowsDashboard": {
+      "allOf": [
+        { "$ref": "#/definitions/dashboard" },
+        {
+          "properties": {
+            "rows": {
+              "type": "array",
+              "items": {
+                "type": "object",
+                "properties": {
+                  "title": { "type": "string" },
+                  "height": { "type": ["string", "integer"] },
+                  "collapse": { "type": "boolean" },
+                  "panels": { "type": "array", "items": { "$ref": "#/definitions/rowsPanel" } }
+                }
+              }
+            }
+          }
+        }
+      ]
+    },
+    "rowsPanel": {
+      "allOf": [
+        { "$ref": "#/definitions/panel" },
+        {
+          "properties": {
+            "span": { "type": "number", "minimum": 0, "maximum": 12 }
+          }
+        }
+      ]
+    },
+    "gridDashboard": {
+      "allOf": [
+        { "$ref": "#/definitions/dashboard" },
+        {
+          "not": { "required": ["rows"] },
+          "properties": {
+            "panels": { "type": "array", "items": { "$ref": "#/definitions/gridPanel" } }
+          }
+        }
+      ]
+    },
+    "gridPanel": {
+      "allOf": [
+        { "$ref": "#/definitions/panel" },
+        {
+          "required": ["gridPos"],
+          "properties": {
+            "gridPos": {
+              "type": "object",
+              "required": ["h", "w", "x", "y"],
+              "properties": {
+                "h": { "type": "integer", "minimum": 1 },
+                "w": { "type": "integer", "minimum": 1, "maximum": 24 },
+                "x": { "type": "integer", "minimum": 0, "maximum": 23 },
+                "y": { "type": "integer", "minimum": 0 }
+              }
+            },
+            "panels": { "type": "array", "items": { "$ref": "#/definitions/gridPanel" } }
+          }
+        }
+      ]
+    }
+  }
+}`
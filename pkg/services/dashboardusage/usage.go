@@ -0,0 +1,207 @@
+// Package dashboardusage counts the views of dashboards and records the views
+// and the queries of their panels for usage analytics.
+package dashboardusage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	flushInterval = time.Second * 10
+	// maxBufferedViews limits the views kept in memory when they can't be
+	// stored, the aggregated counts are always kept
+	maxBufferedViews = 10000
+)
+
+func init() {
+	registry.RegisterService(&UsageService{})
+}
+
+type usageKey struct {
+	dashboardId int64
+	userId      int64
+	day         string
+}
+
+// UsageService buffers dashboard views and panel queries and stores them
+// periodically, so that viewing dashboards doesn't wait for the database.
+// The view counts of the dashboards are always stored, the views and queries
+// by user and day only if usage analytics are enabled.
+type UsageService struct {
+	Bus bus.Bus `inject:""`
+
+	log        log.Logger
+	mutex      sync.Mutex
+	views      []*models.DashboardViewEvent
+	counts     map[usageKey]*models.DashboardUsageCount
+	viewCounts map[int64]*models.DashboardViewCount
+}
+
+func (s *UsageService) Init() error {
+	s.log = log.New("dashboard-usage")
+	s.counts = make(map[usageKey]*models.DashboardUsageCount)
+	s.viewCounts = make(map[int64]*models.DashboardViewCount)
+
+	s.Bus.AddEventListener(s.dashboardViewed)
+	s.Bus.AddEventListener(s.dashboardQueried)
+
+	return nil
+}
+
+func (s *UsageService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-ctx.Done():
+			s.flush()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *UsageService) dashboardViewed(event *events.DashboardViewed) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	viewCount, ok := s.viewCounts[event.DashboardId]
+	if !ok {
+		viewCount = &models.DashboardViewCount{OrgId: event.OrgId, DashboardId: event.DashboardId}
+		s.viewCounts[event.DashboardId] = viewCount
+	}
+	viewCount.Views++
+	if viewCount.LastViewed.Before(event.Timestamp) {
+		viewCount.LastViewed = event.Timestamp
+	}
+
+	if !setting.DashboardUsageAnalytics {
+		return nil
+	}
+
+	if len(s.views) < maxBufferedViews {
+		s.views = append(s.views, &models.DashboardViewEvent{
+			OrgId:       event.OrgId,
+			DashboardId: event.DashboardId,
+			UserId:      event.UserId,
+			Created:     event.Timestamp,
+		})
+	}
+
+	count := s.count(event.OrgId, event.DashboardId, event.UserId, event.Timestamp)
+	count.Views++
+	if count.LastViewed == nil || count.LastViewed.Before(event.Timestamp) {
+		viewed := event.Timestamp
+		count.LastViewed = &viewed
+	}
+
+	return nil
+}
+
+func (s *UsageService) dashboardQueried(event *events.DashboardQueried) error {
+	if !setting.DashboardUsageAnalytics {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := s.count(event.OrgId, event.DashboardId, event.UserId, event.Timestamp)
+	count.Queries += event.Queries
+	count.Errors += event.Errors
+
+	return nil
+}
+
+// count returns the buffered count of the dashboard, user and day. The
+// caller must hold the mutex.
+func (s *UsageService) count(orgId, dashboardId, userId int64, timestamp time.Time) *models.DashboardUsageCount {
+	key := usageKey{dashboardId: dashboardId, userId: userId, day: timestamp.UTC().Format(models.DashboardUsageDayFormat)}
+	count, ok := s.counts[key]
+	if !ok {
+		count = &models.DashboardUsageCount{OrgId: orgId, DashboardId: dashboardId, UserId: userId, Day: key.day}
+		s.counts[key] = count
+	}
+	return count
+}
+
+// flush stores the buffered usage. Usage that can't be stored is buffered
+// again and stored with the next flush.
+func (s *UsageService) flush() {
+	s.mutex.Lock()
+	views, counts, viewCounts := s.views, s.counts, s.viewCounts
+	s.views, s.counts, s.viewCounts = nil, make(map[usageKey]*models.DashboardUsageCount), make(map[int64]*models.DashboardViewCount)
+	s.mutex.Unlock()
+
+	if len(views) == 0 && len(counts) == 0 && len(viewCounts) == 0 {
+		return
+	}
+
+	cmd := &models.SaveDashboardUsageCommand{
+		Views:      views,
+		Counts:     make([]*models.DashboardUsageCount, 0, len(counts)),
+		ViewCounts: make([]*models.DashboardViewCount, 0, len(viewCounts)),
+	}
+	for _, count := range counts {
+		cmd.Counts = append(cmd.Counts, count)
+	}
+	for _, viewCount := range viewCounts {
+		cmd.ViewCounts = append(cmd.ViewCounts, viewCount)
+	}
+
+	if err := s.Bus.Dispatch(cmd); err != nil {
+		s.log.Error("Failed to store dashboard usage", "error", err)
+		s.restore(views, counts, viewCounts)
+	}
+}
+
+func (s *UsageService) restore(views []*models.DashboardViewEvent, counts map[usageKey]*models.DashboardUsageCount, viewCounts map[int64]*models.DashboardViewCount) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if free := maxBufferedViews - len(s.views); free > 0 {
+		if len(views) > free {
+			views = views[:free]
+		}
+		s.views = append(views, s.views...)
+	}
+
+	for key, count := range counts {
+		current, ok := s.counts[key]
+		if !ok {
+			s.counts[key] = count
+			continue
+		}
+
+		current.Views += count.Views
+		current.Queries += count.Queries
+		current.Errors += count.Errors
+		if count.LastViewed != nil && (current.LastViewed == nil || current.LastViewed.Before(*count.LastViewed)) {
+			current.LastViewed = count.LastViewed
+		}
+	}
+
+	for dashboardId, viewCount := range viewCounts {
+		current, ok := s.viewCounts[dashboardId]
+		if !ok {
+			s.viewCounts[dashboardId] = viewCount
+			continue
+		}
+
+		current.Views += viewCount.Views
+		if current.LastViewed.Before(viewCount.LastViewed) {
+			current.LastViewed = viewCount.LastViewed
+		}
+	}
+}
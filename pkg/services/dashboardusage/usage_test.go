@@ -0,0 +1,117 @@
+package dashboardusage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestUsageService(t *testing.T) {
+	setting.DashboardUsageAnalytics = true
+	defer func() { setting.DashboardUsageAnalytics = false }()
+
+	setup := func(t *testing.T) (*UsageService, *[]*models.SaveDashboardUsageCommand, *error) {
+		service := &UsageService{Bus: bus.New()}
+		require.NoError(t, service.Init())
+
+		saved := []*models.SaveDashboardUsageCommand{}
+		var saveErr error
+		service.Bus.AddHandler(func(cmd *models.SaveDashboardUsageCommand) error {
+			if saveErr != nil {
+				return saveErr
+			}
+			saved = append(saved, cmd)
+			return nil
+		})
+
+		return service, &saved, &saveErr
+	}
+
+	first := time.Date(2020, 10, 1, 23, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	nextDay := first.Add(2 * time.Hour)
+
+	t.Run("Should aggregate views and queries by dashboard, user and day", func(t *testing.T) {
+		service, saved, _ := setup(t)
+
+		for _, event := range []interface{}{
+			&events.DashboardViewed{Timestamp: first, OrgId: 1, DashboardId: 1, UserId: 2},
+			&events.DashboardViewed{Timestamp: second, OrgId: 1, DashboardId: 1, UserId: 2},
+			&events.DashboardQueried{Timestamp: second, OrgId: 1, DashboardId: 1, UserId: 2, Queries: 3, Errors: 1},
+			&events.DashboardQueried{Timestamp: nextDay, OrgId: 1, DashboardId: 1, UserId: 2, Queries: 2},
+		} {
+			require.NoError(t, service.Bus.Publish(event))
+		}
+
+		service.flush()
+
+		require.Len(t, *saved, 1)
+		cmd := (*saved)[0]
+		require.Len(t, cmd.Views, 2)
+		assert.Equal(t, first, cmd.Views[0].Created)
+
+		counts := map[string]*models.DashboardUsageCount{}
+		for _, count := range cmd.Counts {
+			counts[count.Day] = count
+		}
+		require.Len(t, counts, 2)
+		assert.Equal(t, int64(2), counts["2020-10-01"].Views)
+		assert.Equal(t, int64(3), counts["2020-10-01"].Queries)
+		assert.Equal(t, int64(1), counts["2020-10-01"].Errors)
+		assert.Equal(t, second, *counts["2020-10-01"].LastViewed)
+		assert.Equal(t, int64(2), counts["2020-10-02"].Queries)
+		assert.Nil(t, counts["2020-10-02"].LastViewed)
+
+		require.Len(t, cmd.ViewCounts, 1)
+		assert.Equal(t, int64(2), cmd.ViewCounts[0].Views)
+		assert.Equal(t, second, cmd.ViewCounts[0].LastViewed)
+
+		service.flush()
+		require.Len(t, *saved, 1)
+	})
+
+	t.Run("Should only count views if usage analytics are disabled", func(t *testing.T) {
+		setting.DashboardUsageAnalytics = false
+		defer func() { setting.DashboardUsageAnalytics = true }()
+		service, saved, _ := setup(t)
+
+		require.NoError(t, service.Bus.Publish(&events.DashboardViewed{Timestamp: first, OrgId: 1, DashboardId: 1, UserId: 2}))
+		require.NoError(t, service.Bus.Publish(&events.DashboardQueried{Timestamp: first, OrgId: 1, DashboardId: 1, UserId: 2, Queries: 3}))
+		service.flush()
+
+		require.Len(t, *saved, 1)
+		assert.Empty(t, (*saved)[0].Views)
+		assert.Empty(t, (*saved)[0].Counts)
+		require.Len(t, (*saved)[0].ViewCounts, 1)
+		assert.Equal(t, int64(1), (*saved)[0].ViewCounts[0].Views)
+	})
+
+	t.Run("Should keep usage that can't be stored", func(t *testing.T) {
+		service, saved, saveErr := setup(t)
+
+		require.NoError(t, service.Bus.Publish(&events.DashboardViewed{Timestamp: first, OrgId: 1, DashboardId: 1, UserId: 2}))
+		*saveErr = errors.New("database is locked")
+		service.flush()
+		require.Empty(t, *saved)
+
+		require.NoError(t, service.Bus.Publish(&events.DashboardViewed{Timestamp: second, OrgId: 1, DashboardId: 1, UserId: 2}))
+		*saveErr = nil
+		service.flush()
+
+		require.Len(t, *saved, 1)
+		require.Len(t, (*saved)[0].Views, 2)
+		require.Len(t, (*saved)[0].Counts, 1)
+		assert.Equal(t, int64(2), (*saved)[0].Counts[0].Views)
+		assert.Equal(t, second, *(*saved)[0].Counts[0].LastViewed)
+		require.Len(t, (*saved)[0].ViewCounts, 1)
+		assert.Equal(t, int64(2), (*saved)[0].ViewCounts[0].Views)
+	})
+}
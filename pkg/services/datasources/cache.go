@@ -38,7 +38,7 @@ func (dc *CacheServiceImpl) GetDatasource(datasourceID int64, user *models.Signe
 		if cached, found := dc.CacheService.Get(cacheKey); found {
 			ds := cached.(*models.DataSource)
 			if ds.OrgId == user.OrgId {
-				return ds, nil
+				return checkQueryPermission(user, ds)
 			}
 		}
 	}
@@ -49,5 +49,18 @@ func (dc *CacheServiceImpl) GetDatasource(datasourceID int64, user *models.Signe
 	}
 
 	dc.CacheService.Set(cacheKey, query.Result, time.Second*5)
-	return query.Result, nil
+	return checkQueryPermission(user, query.Result)
+}
+
+func checkQueryPermission(user *models.SignedInUser, ds *models.DataSource) (*models.DataSource, error) {
+	canQuery, err := HasPermission(user, ds, models.DsPermissionQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canQuery {
+		return nil, models.ErrDataSourceAccessDenied
+	}
+
+	return ds, nil
 }
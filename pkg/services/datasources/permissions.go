@@ -0,0 +1,95 @@
+package datasources
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("datasources", filterDatasourcesByQueryPermission)
+}
+
+// GetPermission returns the permission the user has on a data source. Org
+// admins can always administer data sources, and data sources without
+// permissions enabled can be queried by everyone.
+func GetPermission(user *models.SignedInUser, ds *models.DataSource) (models.DsPermissionType, error) {
+	if user.OrgRole == models.ROLE_ADMIN {
+		return models.DsPermissionAdmin, nil
+	}
+
+	if !ds.PermissionsEnabled {
+		return models.DsPermissionQuery, nil
+	}
+
+	permissions, err := getUserPermissions(user)
+	if err != nil {
+		return models.DsPermissionNoAccess, err
+	}
+
+	return permissions[ds.Id], nil
+}
+
+// HasPermission returns true if the user has at least the given permission
+// on the data source.
+func HasPermission(user *models.SignedInUser, ds *models.DataSource, permission models.DsPermissionType) (bool, error) {
+	granted, err := GetPermission(user, ds)
+	if err != nil {
+		return false, err
+	}
+
+	return granted >= permission, nil
+}
+
+// FilterDatasources returns the data sources on which the user has at least
+// the given permission.
+func FilterDatasources(user *models.SignedInUser, datasources []*models.DataSource, permission models.DsPermissionType) ([]*models.DataSource, error) {
+	if user.OrgRole == models.ROLE_ADMIN {
+		return datasources, nil
+	}
+
+	var permissions map[int64]models.DsPermissionType
+	result := make([]*models.DataSource, 0, len(datasources))
+
+	for _, ds := range datasources {
+		if !ds.PermissionsEnabled {
+			if permission <= models.DsPermissionQuery {
+				result = append(result, ds)
+			}
+			continue
+		}
+
+		// Only look up the permissions of the user once there is a data
+		// source that needs them.
+		if permissions == nil {
+			var err error
+			if permissions, err = getUserPermissions(user); err != nil {
+				return nil, err
+			}
+		}
+
+		if permissions[ds.Id] >= permission {
+			result = append(result, ds)
+		}
+	}
+
+	return result, nil
+}
+
+func filterDatasourcesByQueryPermission(query *models.DatasourcesPermissionFilterQuery) error {
+	result, err := FilterDatasources(query.User, query.Datasources, models.DsPermissionQuery)
+	if err != nil {
+		return err
+	}
+
+	query.Result = result
+	return nil
+}
+
+func getUserPermissions(user *models.SignedInUser) (map[int64]models.DsPermissionType, error) {
+	query := models.GetUserDataSourcePermissionsQuery{OrgId: user.OrgId, User: user}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	return query.Result, nil
+}
@@ -0,0 +1,88 @@
+package datasources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDatasourcePermissions(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	lookups := 0
+	bus.AddHandler("test", func(query *models.GetUserDataSourcePermissionsQuery) error {
+		lookups++
+		query.Result = map[int64]models.DsPermissionType{
+			2: models.DsPermissionQuery,
+			3: models.DsPermissionEdit,
+		}
+		return nil
+	})
+
+	unrestricted := &models.DataSource{Id: 1}
+	queryable := &models.DataSource{Id: 2, PermissionsEnabled: true}
+	editable := &models.DataSource{Id: 3, PermissionsEnabled: true}
+	restricted := &models.DataSource{Id: 4, PermissionsEnabled: true}
+	all := []*models.DataSource{unrestricted, queryable, editable, restricted}
+
+	viewer := &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+	admin := &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_ADMIN}
+
+	t.Run("Org admin should be admin of every data source", func(t *testing.T) {
+		permission, err := GetPermission(admin, restricted)
+		require.NoError(t, err)
+		require.Equal(t, models.DsPermissionAdmin, permission)
+
+		result, err := FilterDatasources(admin, all, models.DsPermissionAdmin)
+		require.NoError(t, err)
+		require.Equal(t, all, result)
+	})
+
+	t.Run("Data source without permissions enabled should be queryable by everyone", func(t *testing.T) {
+		lookups = 0
+		granted, err := HasPermission(viewer, unrestricted, models.DsPermissionQuery)
+		require.NoError(t, err)
+		require.True(t, granted)
+
+		granted, err = HasPermission(viewer, unrestricted, models.DsPermissionEdit)
+		require.NoError(t, err)
+		require.False(t, granted)
+		require.Zero(t, lookups)
+	})
+
+	t.Run("Data source with permissions enabled should require a permission", func(t *testing.T) {
+		granted, err := HasPermission(viewer, queryable, models.DsPermissionQuery)
+		require.NoError(t, err)
+		require.True(t, granted)
+
+		granted, err = HasPermission(viewer, queryable, models.DsPermissionEdit)
+		require.NoError(t, err)
+		require.False(t, granted)
+
+		granted, err = HasPermission(viewer, restricted, models.DsPermissionQuery)
+		require.NoError(t, err)
+		require.False(t, granted)
+	})
+
+	t.Run("Should filter data sources by permission", func(t *testing.T) {
+		lookups = 0
+		result, err := FilterDatasources(viewer, all, models.DsPermissionQuery)
+		require.NoError(t, err)
+		require.Equal(t, []*models.DataSource{unrestricted, queryable, editable}, result)
+		require.Equal(t, 1, lookups)
+
+		result, err = FilterDatasources(viewer, all, models.DsPermissionEdit)
+		require.NoError(t, err)
+		require.Equal(t, []*models.DataSource{editable}, result)
+	})
+
+	t.Run("Filter query should only return queryable data sources", func(t *testing.T) {
+		query := &models.DatasourcesPermissionFilterQuery{User: viewer, Datasources: all}
+		require.NoError(t, filterDatasourcesByQueryPermission(query))
+		require.Equal(t, []*models.DataSource{unrestricted, queryable, editable}, query.Result)
+	})
+}
@@ -0,0 +1,329 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	ErrCodeRequired        = errors.New("Two-factor authentication code required")
+	ErrInvalidCode         = errors.New("Invalid two-factor authentication code")
+	ErrEnrollmentRequired  = errors.New("Two-factor authentication enrolment required")
+	ErrNotEnabled          = errors.New("Two-factor authentication is not enabled for user")
+	ErrAlreadyEnabled      = errors.New("Two-factor authentication is already enabled for user")
+	ErrNoPendingEnrollment = errors.New("No pending two-factor authentication enrolment for user")
+	ErrEnforced            = errors.New("Two-factor authentication is enforced and cannot be disabled")
+)
+
+const recoveryCodeCount = 10
+
+var getTime = time.Now
+
+// IsEnforced returns true if the user is required to use two-factor
+// authentication, either server-wide or through membership of an org
+// listed in enforced_org_ids.
+func IsEnforced(userID int64) (bool, error) {
+	if setting.MFAEnforced {
+		return true, nil
+	}
+
+	if len(setting.MFAEnforcedOrgIds) == 0 {
+		return false, nil
+	}
+
+	query := models.GetUserOrgListQuery{UserId: userID}
+	if err := bus.Dispatch(&query); err != nil {
+		return false, err
+	}
+
+	for _, org := range query.Result {
+		for _, orgID := range setting.MFAEnforcedOrgIds {
+			if org.OrgId == orgID {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// GetUserMfa returns the two-factor configuration of the user, or nil if
+// the user has never started enrolment.
+func GetUserMfa(userID int64) (*models.UserMfa, error) {
+	query := models.GetUserMfaQuery{UserId: userID}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrUserMfaNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return query.Result, nil
+}
+
+// Enroll starts enrolment by generating a new pending secret, replacing any
+// previously pending one.
+func Enroll(userID int64, account string) (*models.UserMfaEnrollment, error) {
+	userMfa, err := GetUserMfa(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if userMfa != nil && userMfa.IsEnabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bus.Dispatch(&models.SaveUserMfaCommand{UserId: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	return newEnrollment(account, secret), nil
+}
+
+// Activate confirms a pending enrolment with a code from the authenticator
+// app and returns a fresh set of recovery codes.
+func Activate(userID int64, code string) ([]string, error) {
+	userMfa, err := GetUserMfa(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if userMfa == nil {
+		return nil, ErrNoPendingEnrollment
+	}
+
+	if userMfa.IsEnabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	if err := useCode(userMfa, code); err != nil {
+		return nil, err
+	}
+
+	return saveWithRecoveryCodes(userID, userMfa.Secret)
+}
+
+// Verify checks either a TOTP code or a recovery code for a user with
+// two-factor authentication enabled. A recovery code can only be used once.
+func Verify(userID int64, code, recoveryCode string) error {
+	userMfa, err := GetUserMfa(userID)
+	if err != nil {
+		return err
+	}
+
+	if userMfa == nil || !userMfa.IsEnabled {
+		return ErrNotEnabled
+	}
+
+	return verify(userMfa, code, recoveryCode)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user after
+// verifying a current TOTP code.
+func RegenerateRecoveryCodes(userID int64, code string) ([]string, error) {
+	userMfa, err := GetUserMfa(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if userMfa == nil || !userMfa.IsEnabled {
+		return nil, ErrNotEnabled
+	}
+
+	if err := useCode(userMfa, code); err != nil {
+		return nil, err
+	}
+
+	return saveWithRecoveryCodes(userID, userMfa.Secret)
+}
+
+// Disable turns off two-factor authentication for a user after verifying a
+// TOTP or recovery code. It's not possible when enrolment is enforced.
+func Disable(userID int64, code, recoveryCode string) error {
+	enforced, err := IsEnforced(userID)
+	if err != nil {
+		return err
+	}
+
+	if enforced {
+		return ErrEnforced
+	}
+
+	if err := Verify(userID, code, recoveryCode); err != nil {
+		return err
+	}
+
+	return Reset(userID)
+}
+
+// Reset removes the two-factor configuration of a user without any
+// verification, e.g. when an admin resets a user that lost their device.
+func Reset(userID int64) error {
+	return bus.Dispatch(&models.DeleteUserMfaCommand{UserId: userID})
+}
+
+// ValidateLogin is called after a successful password check of a built-in
+// Grafana user. It requires a valid code if two-factor authentication is
+// enabled for the user, and handles enrolment as part of the login if it's
+// enforced but the user hasn't enrolled yet.
+func ValidateLogin(query *models.LoginUserQuery, user *models.User) error {
+	if !setting.MFAEnabled {
+		return nil
+	}
+
+	userMfa, err := GetUserMfa(user.Id)
+	if err != nil {
+		return err
+	}
+
+	if userMfa != nil && userMfa.IsEnabled {
+		if query.MfaCode == "" && query.MfaRecoveryCode == "" {
+			return ErrCodeRequired
+		}
+		return verify(userMfa, query.MfaCode, query.MfaRecoveryCode)
+	}
+
+	enforced, err := IsEnforced(user.Id)
+	if err != nil {
+		return err
+	}
+
+	if !enforced {
+		return nil
+	}
+
+	// Enrolment is enforced, so the user has to confirm a pending secret
+	// before being logged in.
+	if userMfa != nil && query.MfaCode != "" {
+		if err := useCode(userMfa, query.MfaCode); err != nil {
+			return err
+		}
+
+		query.MfaRecoveryCodes, err = saveWithRecoveryCodes(user.Id, userMfa.Secret)
+		return err
+	}
+
+	if userMfa != nil {
+		query.MfaEnrollment = newEnrollment(user.Login, userMfa.Secret)
+		return ErrEnrollmentRequired
+	}
+
+	query.MfaEnrollment, err = Enroll(user.Id, user.Login)
+	if err != nil {
+		return err
+	}
+
+	return ErrEnrollmentRequired
+}
+
+func verify(userMfa *models.UserMfa, code, recoveryCode string) error {
+	if code != "" {
+		return useCode(userMfa, code)
+	}
+
+	if recoveryCode == "" {
+		return ErrCodeRequired
+	}
+
+	hashed := hashRecoveryCode(recoveryCode)
+	for i, existing := range userMfa.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(existing), []byte(hashed)) != 1 {
+			continue
+		}
+
+		remaining := make([]string, 0, len(userMfa.RecoveryCodes)-1)
+		remaining = append(remaining, userMfa.RecoveryCodes[:i]...)
+		remaining = append(remaining, userMfa.RecoveryCodes[i+1:]...)
+
+		err := bus.Dispatch(&models.UseUserMfaRecoveryCodeCommand{
+			UserId:        userMfa.UserId,
+			RecoveryCodes: userMfa.RecoveryCodes,
+			Remaining:     remaining,
+		})
+		if err == models.ErrUserMfaCodeUsed {
+			return ErrInvalidCode
+		}
+		return err
+	}
+
+	return ErrInvalidCode
+}
+
+// useCode checks a TOTP code and records its time step, so that neither it
+// nor an older code can be used again.
+func useCode(userMfa *models.UserMfa, code string) error {
+	step, ok := validateCode(userMfa.Secret, code, getTime())
+	if !ok || step <= userMfa.LastTotpStep {
+		return ErrInvalidCode
+	}
+
+	err := bus.Dispatch(&models.UseUserMfaTotpStepCommand{UserId: userMfa.UserId, Step: step})
+	if err == models.ErrUserMfaCodeUsed {
+		return ErrInvalidCode
+	}
+	return err
+}
+
+func saveWithRecoveryCodes(userID int64, secret string) ([]string, error) {
+	codes, hashed, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &models.SaveUserMfaCommand{
+		UserId:        userID,
+		Secret:        secret,
+		RecoveryCodes: hashed,
+		IsEnabled:     true,
+	}
+	if err := bus.Dispatch(cmd); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func newEnrollment(account, secret string) *models.UserMfaEnrollment {
+	return &models.UserMfaEnrollment{
+		Secret:          secret,
+		ProvisioningUri: provisioningURI(setting.MFAIssuer, account, secret),
+	}
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashed := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(secretEncoding.EncodeToString(buf))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashed[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashed, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,206 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestValidateLogin(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	setting.MFAEnabled = true
+	defer func() {
+		setting.MFAEnabled = false
+		setting.MFAEnforced = false
+		setting.MFAEnforcedOrgIds = nil
+	}()
+
+	user := &models.User{Id: 1, Login: "admin"}
+
+	t.Run("Should not require code if user is not enrolled", func(t *testing.T) {
+		setupStore()
+		setting.MFAEnforced = false
+
+		err := ValidateLogin(&models.LoginUserQuery{}, user)
+		require.NoError(t, err)
+	})
+
+	t.Run("Should require code if user is enrolled", func(t *testing.T) {
+		store := setupStore()
+		store[1] = &models.UserMfa{UserId: 1, Secret: rfcSecret, IsEnabled: true}
+
+		err := ValidateLogin(&models.LoginUserQuery{}, user)
+		assert.Equal(t, ErrCodeRequired, err)
+
+		err = ValidateLogin(&models.LoginUserQuery{MfaCode: "000000"}, user)
+		assert.Equal(t, ErrInvalidCode, err)
+
+		err = ValidateLogin(&models.LoginUserQuery{MfaCode: "081804"}, user)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1111111109/30), store[1].LastTotpStep)
+	})
+
+	t.Run("Should not accept a TOTP code twice", func(t *testing.T) {
+		store := setupStore()
+		store[1] = &models.UserMfa{UserId: 1, Secret: rfcSecret, IsEnabled: true}
+
+		err := ValidateLogin(&models.LoginUserQuery{MfaCode: "081804"}, user)
+		require.NoError(t, err)
+
+		err = ValidateLogin(&models.LoginUserQuery{MfaCode: "081804"}, user)
+		assert.Equal(t, ErrInvalidCode, err)
+
+		previous, err := generateCode(rfcSecret, now.Add(-totpPeriod*time.Second))
+		require.NoError(t, err)
+		err = ValidateLogin(&models.LoginUserQuery{MfaCode: previous}, user)
+		assert.Equal(t, ErrInvalidCode, err, "rejects codes of older time steps")
+	})
+
+	t.Run("Should accept recovery code only once", func(t *testing.T) {
+		store := setupStore()
+		store[1] = &models.UserMfa{UserId: 1, Secret: rfcSecret, IsEnabled: true}
+
+		codes, err := saveWithRecoveryCodes(1, rfcSecret)
+		require.NoError(t, err)
+		require.Len(t, codes, recoveryCodeCount)
+
+		err = ValidateLogin(&models.LoginUserQuery{MfaRecoveryCode: codes[3]}, user)
+		require.NoError(t, err)
+		assert.Len(t, store[1].RecoveryCodes, recoveryCodeCount-1)
+
+		err = ValidateLogin(&models.LoginUserQuery{MfaRecoveryCode: codes[3]}, user)
+		assert.Equal(t, ErrInvalidCode, err)
+	})
+
+	t.Run("Should not accept recovery code if the codes changed since they were read", func(t *testing.T) {
+		store := setupStore()
+		store[1] = &models.UserMfa{UserId: 1, Secret: rfcSecret, IsEnabled: true}
+
+		codes, err := saveWithRecoveryCodes(1, rfcSecret)
+		require.NoError(t, err)
+		stale := *store[1]
+
+		err = ValidateLogin(&models.LoginUserQuery{MfaRecoveryCode: codes[3]}, user)
+		require.NoError(t, err)
+
+		err = verify(&stale, "", codes[3])
+		assert.Equal(t, ErrInvalidCode, err)
+		assert.Len(t, store[1].RecoveryCodes, recoveryCodeCount-1)
+	})
+
+	t.Run("Should require enrolment during login if enforced", func(t *testing.T) {
+		store := setupStore()
+		setting.MFAEnforced = true
+		defer func() { setting.MFAEnforced = false }()
+
+		query := &models.LoginUserQuery{}
+		err := ValidateLogin(query, user)
+		assert.Equal(t, ErrEnrollmentRequired, err)
+		require.NotNil(t, query.MfaEnrollment)
+		require.NotNil(t, store[1])
+		assert.False(t, store[1].IsEnabled)
+
+		// the pending secret is reused until confirmed
+		secret := query.MfaEnrollment.Secret
+		query = &models.LoginUserQuery{}
+		err = ValidateLogin(query, user)
+		assert.Equal(t, ErrEnrollmentRequired, err)
+		assert.Equal(t, secret, query.MfaEnrollment.Secret)
+
+		code, err := generateCode(secret, now)
+		require.NoError(t, err)
+
+		query = &models.LoginUserQuery{MfaCode: code}
+		err = ValidateLogin(query, user)
+		require.NoError(t, err)
+		assert.Len(t, query.MfaRecoveryCodes, recoveryCodeCount)
+		assert.True(t, store[1].IsEnabled)
+	})
+
+	t.Run("Should enforce for members of configured orgs", func(t *testing.T) {
+		setupStore()
+		setting.MFAEnforcedOrgIds = []int64{2}
+		defer func() { setting.MFAEnforcedOrgIds = nil }()
+
+		bus.AddHandler("test", func(query *models.GetUserOrgListQuery) error {
+			query.Result = []*models.UserOrgDTO{{OrgId: 1}, {OrgId: 2}}
+			return nil
+		})
+
+		enforced, err := IsEnforced(1)
+		require.NoError(t, err)
+		assert.True(t, enforced)
+
+		err = Disable(1, "081804", "")
+		assert.Equal(t, ErrEnforced, err)
+	})
+
+	t.Run("Should skip checks if feature is disabled", func(t *testing.T) {
+		store := setupStore()
+		store[1] = &models.UserMfa{UserId: 1, Secret: rfcSecret, IsEnabled: true}
+		setting.MFAEnabled = false
+		defer func() { setting.MFAEnabled = true }()
+
+		err := ValidateLogin(&models.LoginUserQuery{}, user)
+		assert.NoError(t, err)
+	})
+}
+
+func setupStore() map[int64]*models.UserMfa {
+	store := map[int64]*models.UserMfa{}
+
+	bus.ClearBusHandlers()
+	bus.AddHandler("test", func(query *models.GetUserMfaQuery) error {
+		userMfa, ok := store[query.UserId]
+		if !ok {
+			return models.ErrUserMfaNotFound
+		}
+		query.Result = userMfa
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.SaveUserMfaCommand) error {
+		userMfa := &models.UserMfa{
+			UserId:        cmd.UserId,
+			Secret:        cmd.Secret,
+			RecoveryCodes: cmd.RecoveryCodes,
+			IsEnabled:     cmd.IsEnabled,
+		}
+		if existing, ok := store[cmd.UserId]; ok {
+			userMfa.LastTotpStep = existing.LastTotpStep
+		}
+		store[cmd.UserId] = userMfa
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.UseUserMfaTotpStepCommand) error {
+		userMfa, ok := store[cmd.UserId]
+		if !ok || userMfa.LastTotpStep >= cmd.Step {
+			return models.ErrUserMfaCodeUsed
+		}
+		userMfa.LastTotpStep = cmd.Step
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.UseUserMfaRecoveryCodeCommand) error {
+		userMfa, ok := store[cmd.UserId]
+		if !ok || strings.Join(userMfa.RecoveryCodes, ",") != strings.Join(cmd.RecoveryCodes, ",") {
+			return models.ErrUserMfaCodeUsed
+		}
+		userMfa.RecoveryCodes = cmd.Remaining
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.DeleteUserMfaCommand) error {
+		delete(store, cmd.UserId)
+		return nil
+	})
+
+	return store
+}
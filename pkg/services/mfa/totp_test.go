@@ -0,0 +1,82 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 test secret "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, test := range tests {
+		code, err := generateCode(rfcSecret, time.Unix(test.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, code, "time %d", test.unix)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := validateCode(rfcSecret, "081804", now)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1111111109/30), step)
+
+	_, ok = validateCode(rfcSecret, " 081804 ", now)
+	assert.True(t, ok)
+
+	step, ok = validateCode(rfcSecret, "081804", now.Add(30*time.Second))
+	assert.True(t, ok, "allows one step of clock skew")
+	assert.Equal(t, int64(1111111109/30), step, "returns the step of the code")
+
+	for _, test := range []struct {
+		secret string
+		code   string
+		t      time.Time
+	}{
+		{rfcSecret, "081804", now.Add(90 * time.Second)},
+		{rfcSecret, "000000", now},
+		{rfcSecret, "81804", now},
+		{"not base32!", "081804", now},
+	} {
+		_, ok := validateCode(test.secret, test.code, test.t)
+		assert.False(t, ok, "code %q at %d", test.code, test.t.Unix())
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := generateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	other, err := generateSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+
+	_, err = generateCode(secret, time.Now())
+	require.NoError(t, err)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := provisioningURI("Grafana", "admin@example.com", rfcSecret)
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Grafana:admin@example.com?"))
+	assert.Contains(t, uri, "secret="+rfcSecret)
+	assert.Contains(t, uri, "issuer=Grafana")
+}
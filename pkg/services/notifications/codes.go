@@ -95,7 +95,12 @@ func getLoginForEmailCode(code string) string {
 }
 
 func createUserEmailCode(u *models.User, startInf interface{}) (string, error) {
-	minutes := setting.EmailCodeValidMinutes
+	return createUserCode(u, setting.EmailCodeValidMinutes, startInf)
+}
+
+// createUserCode creates a code that is valid for the given minutes or until
+// the password of the user changes.
+func createUserCode(u *models.User, minutes int, startInf interface{}) (string, error) {
 	data := com.ToStr(u.Id) + u.Email + u.Login + u.Password + u.Rands
 	code, err := createTimeLimitCode(data, minutes, startInf)
 	if err != nil {
@@ -2,6 +2,7 @@ package notifications
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
@@ -27,6 +28,23 @@ func TestEmailCodes(t *testing.T) {
 			So(isValid, ShouldBeTrue)
 		})
 
+		Convey("Cannot verify code after its password changed", func() {
+			changed := *user
+			changed.Password = "3"
+			isValid, err := validateUserEmailCode(&changed, code)
+			So(err, ShouldBeNil)
+			So(isValid, ShouldBeFalse)
+		})
+
+		Convey("Cannot verify code after its minutes have passed", func() {
+			code, err := createUserCode(user, 10, time.Now().Add(-11*time.Minute).Format("200601021504"))
+			So(err, ShouldBeNil)
+
+			isValid, err := validateUserEmailCode(user, code)
+			So(err, ShouldBeNil)
+			So(isValid, ShouldBeFalse)
+		})
+
 		Convey("Cannot verify in-valid code", func() {
 			code = "ASD"
 			isValid, err := validateUserEmailCode(user, code)
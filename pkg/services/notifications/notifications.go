@@ -42,6 +42,7 @@ func (ns *NotificationService) Init() error {
 	ns.webhookQueue = make(chan *Webhook, 10)
 
 	ns.Bus.AddHandler(ns.sendResetPasswordEmail)
+	ns.Bus.AddHandler(ns.createResetPasswordCode)
 	ns.Bus.AddHandler(ns.validateResetPasswordCode)
 	ns.Bus.AddHandler(ns.sendEmailCommandHandler)
 
@@ -163,6 +164,16 @@ func (ns *NotificationService) sendResetPasswordEmail(cmd *models.SendResetPassw
 	})
 }
 
+func (ns *NotificationService) createResetPasswordCode(cmd *models.CreateResetPasswordCodeCommand) error {
+	code, err := createUserCode(cmd.User, cmd.ValidMinutes, nil)
+	if err != nil {
+		return err
+	}
+
+	cmd.Result = code
+	return nil
+}
+
 func (ns *NotificationService) validateResetPasswordCode(query *models.ValidateResetPasswordCodeQuery) error {
 	login := getLoginForEmailCode(query.Code)
 	if login == "" {
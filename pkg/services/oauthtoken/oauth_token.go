@@ -0,0 +1,129 @@
+package oauthtoken
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	logger = log.New("oauthtoken")
+
+	// refreshMargin is how long before the stored expiry a token is refreshed,
+	// so that it doesn't expire while a request is in flight.
+	refreshMargin = time.Minute
+
+	// refreshTimeout bounds a shared token refresh, which doesn't belong to
+	// any single request.
+	refreshTimeout = 30 * time.Second
+
+	// tokenRequests shares the token of a user between concurrent requests,
+	// so that a token is only refreshed once.
+	tokenRequests singleflight.Group
+)
+
+// IsOAuthPassThruEnabled returns true if the datasource is configured to
+// forward the signed in user's OAuth token.
+func IsOAuthPassThruEnabled(ds *models.DataSource) bool {
+	return ds != nil && ds.JsonData != nil && ds.JsonData.Get("oauthPassThru").MustBool()
+}
+
+// GetCurrentOAuthToken returns the OAuth token of the signed in user, refreshing
+// and persisting it first if it's about to expire. It returns nil if the user
+// did not log in with OAuth, the token could not be retrieved or ctx is done
+// before it was.
+func GetCurrentOAuthToken(ctx context.Context, user *models.SignedInUser) *oauth2.Token {
+	if user == nil || user.UserId == 0 {
+		return nil
+	}
+
+	// The refresh is shared with concurrent requests of the same user, so it
+	// must not be cancelled when the request that started it goes away.
+	result := tokenRequests.DoChan(strconv.FormatInt(user.UserId, 10), func() (interface{}, error) {
+		refreshCtx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+		return getCurrentOAuthToken(refreshCtx, user), nil
+	})
+
+	select {
+	case res := <-result:
+		return res.Val.(*oauth2.Token)
+	case <-ctx.Done():
+		logger.Debug("Request cancelled while waiting for oauth token", "userId", user.UserId, "error", ctx.Err())
+		return nil
+	}
+}
+
+func getCurrentOAuthToken(ctx context.Context, user *models.SignedInUser) *oauth2.Token {
+	authInfoQuery := &models.GetAuthInfoQuery{UserId: user.UserId}
+	if err := bus.Dispatch(authInfoQuery); err != nil {
+		if err == models.ErrUserNotFound {
+			logger.Debug("No oauth token for user found", "userId", user.UserId)
+		} else {
+			logger.Error("Error fetching oauth information for user", "userId", user.UserId, "error", err)
+		}
+		return nil
+	}
+
+	authInfo := authInfoQuery.Result
+
+	// The SocialMap keys don't have "oauth_" prefix, but everywhere else in the system does
+	connect, ok := social.SocialMap[strings.TrimPrefix(authInfo.AuthModule, "oauth_")]
+	if !ok {
+		logger.Error("Failed to find oauth provider with given name", "provider", authInfo.AuthModule)
+		return nil
+	}
+
+	persistedToken := &oauth2.Token{
+		AccessToken:  authInfo.OAuthAccessToken,
+		RefreshToken: authInfo.OAuthRefreshToken,
+		TokenType:    authInfo.OAuthTokenType,
+		Expiry:       authInfo.OAuthExpiry,
+	}
+
+	// Pull the expiry forward so that the token source refreshes the token
+	// before it actually expires.
+	sourceToken := *persistedToken
+	if !sourceToken.Expiry.IsZero() {
+		sourceToken.Expiry = sourceToken.Expiry.Add(-refreshMargin)
+	}
+
+	// TokenSource handles refreshing the token if it has expired
+	token, err := connect.TokenSource(ctx, &sourceToken).Token()
+	if err != nil {
+		logger.Error("Failed to retrieve access token from oauth provider", "provider", authInfo.AuthModule, "userId", user.UserId, "error", err)
+		return nil
+	}
+
+	// If the tokens are not the same, update the entry in the DB
+	if token.AccessToken == persistedToken.AccessToken {
+		return persistedToken
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = persistedToken.RefreshToken
+	}
+
+	updateAuthCommand := &models.UpdateAuthInfoCommand{
+		UserId:     authInfo.UserId,
+		AuthModule: authInfo.AuthModule,
+		AuthId:     authInfo.AuthId,
+		OAuthToken: token,
+	}
+	if err := bus.Dispatch(updateAuthCommand); err != nil {
+		logger.Error("Failed to update access token during token refresh", "userId", user.UserId, "error", err)
+		return nil
+	}
+
+	logger.Debug("Refreshed oauth token", "provider", authInfo.AuthModule, "userId", user.UserId, "expiry", token.Expiry)
+	return token
+}
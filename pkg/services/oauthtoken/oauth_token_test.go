@@ -0,0 +1,204 @@
+package oauthtoken
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestIsOAuthPassThruEnabled(t *testing.T) {
+	assert.False(t, IsOAuthPassThruEnabled(nil))
+	assert.False(t, IsOAuthPassThruEnabled(&models.DataSource{}))
+	assert.False(t, IsOAuthPassThruEnabled(&models.DataSource{JsonData: simplejson.New()}))
+	assert.True(t, IsOAuthPassThruEnabled(&models.DataSource{
+		JsonData: simplejson.NewFromAny(map[string]interface{}{"oauthPassThru": true}),
+	}))
+}
+
+func TestGetCurrentOAuthToken(t *testing.T) {
+	refreshCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, `{"access_token": "refreshed", "token_type": "Bearer", "expires_in": 3600}`)
+		require.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	social.SocialMap["generic_oauth"] = &social.SocialGenericOAuth{
+		SocialBase: &social.SocialBase{
+			Config: &oauth2.Config{
+				Endpoint: oauth2.Endpoint{TokenURL: ts.URL, AuthStyle: oauth2.AuthStyleInParams},
+			},
+		},
+	}
+	defer delete(social.SocialMap, "generic_oauth")
+
+	setup := func(expiry time.Time) *models.UpdateAuthInfoCommand {
+		bus.ClearBusHandlers()
+		refreshCalls = 0
+
+		updated := &models.UpdateAuthInfoCommand{}
+		bus.AddHandler("test", func(query *models.GetAuthInfoQuery) error {
+			query.Result = &models.UserAuth{
+				UserId:            1,
+				AuthModule:        "oauth_generic_oauth",
+				OAuthAccessToken:  "stored",
+				OAuthRefreshToken: "refreshtoken",
+				OAuthTokenType:    "Bearer",
+				OAuthExpiry:       expiry,
+			}
+			return nil
+		})
+		bus.AddHandler("test", func(cmd *models.UpdateAuthInfoCommand) error {
+			*updated = *cmd
+			return nil
+		})
+		return updated
+	}
+
+	t.Run("Should return stored token if not about to expire", func(t *testing.T) {
+		updated := setup(time.Now().Add(time.Hour))
+
+		token := GetCurrentOAuthToken(context.Background(), &models.SignedInUser{UserId: 1})
+		require.NotNil(t, token)
+		assert.Equal(t, "stored", token.AccessToken)
+		assert.Equal(t, 0, refreshCalls)
+		assert.Nil(t, updated.OAuthToken)
+	})
+
+	t.Run("Should refresh and persist token if about to expire", func(t *testing.T) {
+		updated := setup(time.Now().Add(30 * time.Second))
+
+		token := GetCurrentOAuthToken(context.Background(), &models.SignedInUser{UserId: 1})
+		require.NotNil(t, token)
+		assert.Equal(t, "refreshed", token.AccessToken)
+		assert.Equal(t, 1, refreshCalls)
+		require.NotNil(t, updated.OAuthToken)
+		assert.Equal(t, "refreshed", updated.OAuthToken.AccessToken)
+		assert.Equal(t, "refreshtoken", updated.OAuthToken.RefreshToken)
+		assert.Equal(t, "oauth_generic_oauth", updated.AuthModule)
+	})
+
+	t.Run("Should refresh token once for concurrent requests", func(t *testing.T) {
+		updated := setup(time.Now().Add(30 * time.Second))
+		bus.AddHandler("test", func(query *models.GetAuthInfoQuery) error {
+			query.Result = &models.UserAuth{
+				UserId:            1,
+				AuthModule:        "oauth_generic_oauth",
+				OAuthAccessToken:  "stored",
+				OAuthRefreshToken: "refreshtoken",
+				OAuthTokenType:    "Bearer",
+				OAuthExpiry:       time.Now().Add(30 * time.Second),
+			}
+			if updated.OAuthToken != nil {
+				query.Result.OAuthAccessToken = updated.OAuthToken.AccessToken
+				query.Result.OAuthExpiry = updated.OAuthToken.Expiry
+			}
+			return nil
+		})
+
+		var wg sync.WaitGroup
+		tokens := make([]*oauth2.Token, 5)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tokens[i] = GetCurrentOAuthToken(context.Background(), &models.SignedInUser{UserId: 1})
+			}(i)
+		}
+		wg.Wait()
+
+		for _, token := range tokens {
+			require.NotNil(t, token)
+			assert.Equal(t, "refreshed", token.AccessToken)
+		}
+		assert.Equal(t, 1, refreshCalls)
+	})
+
+	t.Run("Should finish a shared refresh when the request that started it is cancelled", func(t *testing.T) {
+		updated := setup(time.Now().Add(30 * time.Second))
+		bus.AddHandler("test", func(query *models.GetAuthInfoQuery) error {
+			query.Result = &models.UserAuth{
+				UserId:            1,
+				AuthModule:        "oauth_generic_oauth",
+				OAuthAccessToken:  "stored",
+				OAuthRefreshToken: "refreshtoken",
+				OAuthTokenType:    "Bearer",
+				OAuthExpiry:       time.Now().Add(30 * time.Second),
+			}
+			if updated.OAuthToken != nil {
+				query.Result.OAuthAccessToken = updated.OAuthToken.AccessToken
+				query.Result.OAuthExpiry = updated.OAuthToken.Expiry
+			}
+			return nil
+		})
+
+		var once sync.Once
+		received := make(chan struct{})
+		release := make(chan struct{})
+		blocking := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			refreshCalls++
+			once.Do(func() { close(received) })
+			<-release
+			w.Header().Set("Content-Type", "application/json")
+			_, err := io.WriteString(w, `{"access_token": "refreshed", "token_type": "Bearer", "expires_in": 3600}`)
+			require.NoError(t, err)
+		}))
+		defer blocking.Close()
+
+		connect := social.SocialMap["generic_oauth"]
+		social.SocialMap["generic_oauth"] = &social.SocialGenericOAuth{
+			SocialBase: &social.SocialBase{
+				Config: &oauth2.Config{
+					Endpoint: oauth2.Endpoint{TokenURL: blocking.URL, AuthStyle: oauth2.AuthStyleInParams},
+				},
+			},
+		}
+		defer func() { social.SocialMap["generic_oauth"] = connect }()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelled := make(chan *oauth2.Token)
+		go func() {
+			cancelled <- GetCurrentOAuthToken(ctx, &models.SignedInUser{UserId: 1})
+		}()
+		<-received
+		cancel()
+		assert.Nil(t, <-cancelled)
+
+		waiting := make(chan *oauth2.Token)
+		go func() {
+			waiting <- GetCurrentOAuthToken(context.Background(), &models.SignedInUser{UserId: 1})
+		}()
+		close(release)
+
+		token := <-waiting
+		require.NotNil(t, token)
+		assert.Equal(t, "refreshed", token.AccessToken)
+		assert.Equal(t, 1, refreshCalls)
+		require.NotNil(t, updated.OAuthToken)
+		assert.Equal(t, "refreshed", updated.OAuthToken.AccessToken)
+	})
+
+	t.Run("Should return nil if user has no auth info", func(t *testing.T) {
+		bus.ClearBusHandlers()
+		bus.AddHandler("test", func(query *models.GetAuthInfoQuery) error {
+			return models.ErrUserNotFound
+		})
+
+		assert.Nil(t, GetCurrentOAuthToken(context.Background(), &models.SignedInUser{UserId: 1}))
+	})
+}
@@ -0,0 +1,124 @@
+// Package passwordpolicy enforces the password rules configured in the
+// [password_policy] section for built-in Grafana users.
+package passwordpolicy
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	ErrWeakPassword    = errors.New("Password does not meet the password policy")
+	ErrPasswordReused  = errors.New("Password has been used recently, choose a different one")
+	ErrPasswordExpired = errors.New("Password has expired and has to be reset")
+)
+
+var getTime = time.Now
+
+// Validate checks the password against the length and character class
+// requirements. The returned error wraps ErrWeakPassword and describes the
+// first requirement that isn't met.
+func Validate(password string) error {
+	if len(password) < setting.PasswordMinLength {
+		return fmt.Errorf("%w: it must be at least %d characters long", ErrWeakPassword, setting.PasswordMinLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case setting.PasswordRequireUppercase && !hasUpper:
+		return fmt.Errorf("%w: it must contain an uppercase letter", ErrWeakPassword)
+	case setting.PasswordRequireLowercase && !hasLower:
+		return fmt.Errorf("%w: it must contain a lowercase letter", ErrWeakPassword)
+	case setting.PasswordRequireDigit && !hasDigit:
+		return fmt.Errorf("%w: it must contain a digit", ErrWeakPassword)
+	case setting.PasswordRequireSymbol && !hasSymbol:
+		return fmt.Errorf("%w: it must contain a symbol", ErrWeakPassword)
+	}
+
+	return nil
+}
+
+// ValidateForUser checks the new password of an existing user. Besides the
+// requirements checked by Validate, the password must differ from the
+// current one and the last history_count passwords.
+func ValidateForUser(user *models.User, password string) error {
+	if err := Validate(password); err != nil {
+		return err
+	}
+
+	if setting.PasswordHistoryCount < 1 {
+		return nil
+	}
+
+	hashed, err := util.EncodePassword(password, user.Salt)
+	if err != nil {
+		return err
+	}
+
+	if passwordEqual(hashed, user.Password) {
+		return ErrPasswordReused
+	}
+
+	query := models.GetUserPasswordHistoryQuery{UserId: user.Id, Limit: setting.PasswordHistoryCount}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	for _, entry := range query.Result {
+		if passwordEqual(hashed, entry.Password) {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// ValidateAge returns ErrPasswordExpired if max_age_days is set and the
+// password of the user was last changed, or else the user created, longer
+// ago than that.
+func ValidateAge(user *models.User) error {
+	if setting.PasswordMaxAgeDays < 1 {
+		return nil
+	}
+
+	query := models.GetUserPasswordHistoryQuery{UserId: user.Id, Limit: 1}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	changed := user.Created
+	if len(query.Result) > 0 {
+		changed = query.Result[0].Created
+	}
+
+	if getTime().After(changed.AddDate(0, 0, setting.PasswordMaxAgeDays)) {
+		return ErrPasswordExpired
+	}
+
+	return nil
+}
+
+func passwordEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
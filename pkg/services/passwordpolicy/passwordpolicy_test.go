@@ -0,0 +1,127 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestValidate(t *testing.T) {
+	setting.PasswordMinLength = 8
+	setting.PasswordRequireUppercase = true
+	setting.PasswordRequireLowercase = true
+	setting.PasswordRequireDigit = true
+	setting.PasswordRequireSymbol = true
+	defer resetSettings()
+
+	tests := []struct {
+		password string
+		message  string
+	}{
+		{password: "Ab1!", message: "it must be at least 8 characters long"},
+		{password: "abcdef1!", message: "it must contain an uppercase letter"},
+		{password: "ABCDEF1!", message: "it must contain a lowercase letter"},
+		{password: "Abcdefg!", message: "it must contain a digit"},
+		{password: "Abcdefg1", message: "it must contain a symbol"},
+		{password: "Abcdef1!"},
+		{password: "Ünïcode 1"},
+	}
+
+	for _, test := range tests {
+		err := Validate(test.password)
+		if test.message == "" {
+			assert.NoError(t, err, test.password)
+			continue
+		}
+
+		require.Error(t, err, test.password)
+		assert.True(t, errors.Is(err, ErrWeakPassword))
+		assert.Equal(t, ErrWeakPassword.Error()+": "+test.message, err.Error())
+	}
+}
+
+func TestValidateForUser(t *testing.T) {
+	setting.PasswordMinLength = 4
+	setting.PasswordHistoryCount = 2
+	defer resetSettings()
+
+	user := &models.User{Id: 1, Salt: "salt", Password: encode(t, "current")}
+	history := []*models.UserPasswordHistory{
+		{UserId: 1, Password: encode(t, "current")},
+		{UserId: 1, Password: encode(t, "previous")},
+	}
+
+	bus.AddHandler("test", func(query *models.GetUserPasswordHistoryQuery) error {
+		assert.Equal(t, 2, query.Limit)
+		query.Result = history
+		return nil
+	})
+	defer bus.ClearBusHandlers()
+
+	assert.Equal(t, ErrPasswordReused, ValidateForUser(user, "current"))
+	assert.Equal(t, ErrPasswordReused, ValidateForUser(user, "previous"))
+	assert.NoError(t, ValidateForUser(user, "different"))
+	assert.True(t, errors.Is(ValidateForUser(user, "new"), ErrWeakPassword))
+
+	setting.PasswordHistoryCount = 0
+	assert.NoError(t, ValidateForUser(user, "current"))
+}
+
+func TestValidateAge(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	setting.PasswordMaxAgeDays = 30
+	defer resetSettings()
+
+	var history []*models.UserPasswordHistory
+	bus.AddHandler("test", func(query *models.GetUserPasswordHistoryQuery) error {
+		query.Result = history
+		return nil
+	})
+	defer bus.ClearBusHandlers()
+
+	user := &models.User{Id: 1, Created: now.AddDate(0, 0, -31)}
+
+	t.Run("Should use the creation date if the password was never changed", func(t *testing.T) {
+		assert.Equal(t, ErrPasswordExpired, ValidateAge(user))
+	})
+
+	t.Run("Should use the date of the last password change", func(t *testing.T) {
+		history = []*models.UserPasswordHistory{{UserId: 1, Created: now.AddDate(0, 0, -29)}}
+		assert.NoError(t, ValidateAge(user))
+
+		history[0].Created = now.AddDate(0, 0, -30).Add(-time.Minute)
+		assert.Equal(t, ErrPasswordExpired, ValidateAge(user))
+	})
+
+	t.Run("Should not expire passwords if max age is not set", func(t *testing.T) {
+		setting.PasswordMaxAgeDays = 0
+		assert.NoError(t, ValidateAge(user))
+	})
+}
+
+func encode(t *testing.T, password string) string {
+	hashed, err := util.EncodePassword(password, "salt")
+	require.NoError(t, err)
+	return hashed
+}
+
+func resetSettings() {
+	setting.PasswordMinLength = 0
+	setting.PasswordRequireUppercase = false
+	setting.PasswordRequireLowercase = false
+	setting.PasswordRequireDigit = false
+	setting.PasswordRequireSymbol = false
+	setting.PasswordHistoryCount = 0
+	setting.PasswordMaxAgeDays = 0
+}
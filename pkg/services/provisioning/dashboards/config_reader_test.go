@@ -86,6 +86,7 @@ func validateDashboardAsConfig(t *testing.T, cfg []*config) {
 	require.Equal(t, ds.Options["path"], "/var/lib/grafana/dashboards")
 	require.True(t, ds.DisableDeletion)
 	require.Equal(t, ds.UpdateIntervalSeconds, int64(15))
+	require.True(t, ds.StrictSchemaValidation)
 
 	ds2 := cfg[1]
 	require.Equal(t, ds2.Name, "default")
@@ -98,4 +99,5 @@ func validateDashboardAsConfig(t *testing.T, cfg []*config) {
 	require.Equal(t, ds2.Options["path"], "/var/lib/grafana/dashboards")
 	require.False(t, ds2.DisableDeletion)
 	require.Equal(t, ds2.UpdateIntervalSeconds, int64(10))
+	require.False(t, ds2.StrictSchemaValidation)
 }
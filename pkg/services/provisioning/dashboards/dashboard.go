@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
@@ -16,16 +17,30 @@ type DashboardProvisioner interface {
 	PollChanges(ctx context.Context)
 	GetProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
+	SaveDashboardToSource(provisioning *models.DashboardProvisioning, dash *models.Dashboard, user *models.SignedInUser, message string) error
 }
 
 // DashboardProvisionerFactory creates DashboardProvisioners based on input
 type DashboardProvisionerFactory func(string) (DashboardProvisioner, error)
 
+// dashboardReader reads dashboards from a provisioning source and applies any change to the database.
+type dashboardReader interface {
+	getConfig() *config
+	provision() error
+	pollChanges(ctx context.Context)
+	resolvedPath() string
+}
+
+// dashboardWriter is implemented by readers whose source can be updated with dashboards saved in the UI.
+type dashboardWriter interface {
+	saveDashboardToSource(provisioning *models.DashboardProvisioning, dash *models.Dashboard, user *models.SignedInUser, message string) error
+}
+
 // Provisioner is responsible for syncing dashboard from disc to Grafanas database.
 type Provisioner struct {
-	log         log.Logger
-	fileReaders []*FileReader
-	configs     []*config
+	log     log.Logger
+	readers []dashboardReader
+	configs []*config
 }
 
 // New returns a new DashboardProvisioner
@@ -38,16 +53,16 @@ func New(configDirectory string) (*Provisioner, error) {
 		return nil, errutil.Wrap("Failed to read dashboards config", err)
 	}
 
-	fileReaders, err := getFileReaders(configs, logger)
+	readers, err := getReaders(configs, logger)
 
 	if err != nil {
-		return nil, errutil.Wrap("Failed to initialize file readers", err)
+		return nil, errutil.Wrap("Failed to initialize dashboard readers", err)
 	}
 
 	d := &Provisioner{
-		log:         logger,
-		fileReaders: fileReaders,
-		configs:     configs,
+		log:     logger,
+		readers: readers,
+		configs: configs,
 	}
 
 	return d, nil
@@ -56,15 +71,15 @@ func New(configDirectory string) (*Provisioner, error) {
 // Provision starts scanning the disc for dashboards and updates
 // the database with the latest versions of those dashboards
 func (provider *Provisioner) Provision() error {
-	for _, reader := range provider.fileReaders {
-		if err := reader.startWalkingDisk(); err != nil {
+	for _, reader := range provider.readers {
+		if err := reader.provision(); err != nil {
 			if os.IsNotExist(err) {
 				// don't stop the provisioning service in case the folder is missing. The folder can appear after the startup
-				provider.log.Warn("Failed to provision config", "name", reader.Cfg.Name, "error", err)
+				provider.log.Warn("Failed to provision config", "name", reader.getConfig().Name, "error", err)
 				return nil
 			}
 
-			return errutil.Wrapf(err, "Failed to provision config %v", reader.Cfg.Name)
+			return errutil.Wrapf(err, "Failed to provision config %v", reader.getConfig().Name)
 		}
 	}
 
@@ -74,7 +89,7 @@ func (provider *Provisioner) Provision() error {
 // PollChanges starts polling for changes in dashboard definition files. It creates goroutine for each provider
 // defined in the config.
 func (provider *Provisioner) PollChanges(ctx context.Context) {
-	for _, reader := range provider.fileReaders {
+	for _, reader := range provider.readers {
 		go reader.pollChanges(ctx)
 	}
 }
@@ -82,8 +97,8 @@ func (provider *Provisioner) PollChanges(ctx context.Context) {
 // GetProvisionerResolvedPath returns resolved path for the specified provisioner name. Can be used to generate
 // relative path to provisioning file from it's external_id.
 func (provider *Provisioner) GetProvisionerResolvedPath(name string) string {
-	for _, reader := range provider.fileReaders {
-		if reader.Cfg.Name == name {
+	for _, reader := range provider.readers {
+		if reader.getConfig().Name == name {
 			return reader.resolvedPath()
 		}
 	}
@@ -100,8 +115,23 @@ func (provider *Provisioner) GetAllowUIUpdatesFromConfig(name string) bool {
 	return false
 }
 
-func getFileReaders(configs []*config, logger log.Logger) ([]*FileReader, error) {
-	var readers []*FileReader
+// SaveDashboardToSource updates the source of a provisioned dashboard with the dashboard saved in the UI, if the
+// provisioner supports it.
+func (provider *Provisioner) SaveDashboardToSource(provisioning *models.DashboardProvisioning, dash *models.Dashboard, user *models.SignedInUser, message string) error {
+	for _, reader := range provider.readers {
+		if reader.getConfig().Name != provisioning.Name {
+			continue
+		}
+
+		if writer, ok := reader.(dashboardWriter); ok {
+			return writer.saveDashboardToSource(provisioning, dash, user, message)
+		}
+	}
+	return nil
+}
+
+func getReaders(configs []*config, logger log.Logger) ([]dashboardReader, error) {
+	var readers []dashboardReader
 
 	for _, config := range configs {
 		switch config.Type {
@@ -111,6 +141,12 @@ func getFileReaders(configs []*config, logger log.Logger) ([]*FileReader, error)
 				return nil, errutil.Wrapf(err, "Failed to create file reader for config %v", config.Name)
 			}
 			readers = append(readers, fileReader)
+		case "git":
+			gitReader, err := NewDashboardGitReader(config, logger.New("type", config.Type, "name", config.Name))
+			if err != nil {
+				return nil, errutil.Wrapf(err, "Failed to create git reader for config %v", config.Name)
+			}
+			readers = append(readers, gitReader)
 		default:
 			return nil, fmt.Errorf("type %s is not supported", config.Type)
 		}
@@ -1,6 +1,10 @@
 package dashboards
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+)
 
 // Calls is a mock implementation of the provisioner interface
 type calls struct {
@@ -8,6 +12,7 @@ type calls struct {
 	PollChanges                 []interface{}
 	GetProvisionerResolvedPath  []interface{}
 	GetAllowUIUpdatesFromConfig []interface{}
+	SaveDashboardToSource       []interface{}
 }
 
 // ProvisionerMock is a mock implementation of `Provisioner`
@@ -17,6 +22,7 @@ type ProvisionerMock struct {
 	PollChangesFunc                 func(ctx context.Context)
 	GetProvisionerResolvedPathFunc  func(name string) string
 	GetAllowUIUpdatesFromConfigFunc func(name string) bool
+	SaveDashboardToSourceFunc       func(provisioning *models.DashboardProvisioning, dash *models.Dashboard, user *models.SignedInUser, message string) error
 }
 
 // NewDashboardProvisionerMock returns a new dashboardprovisionermock
@@ -60,3 +66,12 @@ func (dpm *ProvisionerMock) GetAllowUIUpdatesFromConfig(name string) bool {
 	}
 	return false
 }
+
+// SaveDashboardToSource is a mock implementation of `Provisioner.SaveDashboardToSource`
+func (dpm *ProvisionerMock) SaveDashboardToSource(provisioning *models.DashboardProvisioning, dash *models.Dashboard, user *models.SignedInUser, message string) error {
+	dpm.Calls.SaveDashboardToSource = append(dpm.Calls.SaveDashboardToSource, provisioning)
+	if dpm.SaveDashboardToSourceFunc != nil {
+		return dpm.SaveDashboardToSourceFunc(provisioning, dash, user, message)
+	}
+	return nil
+}
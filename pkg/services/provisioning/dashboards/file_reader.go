@@ -63,6 +63,14 @@ func NewDashboardFileReader(cfg *config, log log.Logger) (*FileReader, error) {
 	}, nil
 }
 
+func (fr *FileReader) getConfig() *config {
+	return fr.Cfg
+}
+
+func (fr *FileReader) provision() error {
+	return fr.startWalkingDisk()
+}
+
 // pollChanges periodically runs startWalkingDisk based on interval specified in the config.
 func (fr *FileReader) pollChanges(ctx context.Context) {
 	ticker := time.NewTicker(time.Duration(int64(time.Second) * fr.Cfg.UpdateIntervalSeconds))
@@ -136,18 +144,13 @@ func (fr *FileReader) storeDashboardsInFolder(filesFoundOnDisk map[string]os.Fil
 }
 
 // storeDashboardsInFoldersFromFilesystemStructure saves dashboards from the filesystem on disk to the same folder
-// in grafana as they are in on the filesystem
+// in grafana as they are in on the filesystem. Subdirectories are saved as subfolders.
 func (fr *FileReader) storeDashboardsInFoldersFromFileStructure(filesFoundOnDisk map[string]os.FileInfo, dashboardRefs map[string]*models.DashboardProvisioning, resolvedPath string, sanityChecker *provisioningSanityChecker) error {
-	for path, fileInfo := range filesFoundOnDisk {
-		folderName := ""
+	folderIDs := map[string]int64{}
 
-		dashboardsFolder := filepath.Dir(path)
-		if dashboardsFolder != resolvedPath {
-			folderName = filepath.Base(dashboardsFolder)
-		}
-
-		folderID, err := getOrCreateFolderID(fr.Cfg, fr.dashboardProvisioningService, folderName)
-		if err != nil && err != ErrFolderNameMissing {
+	for path, fileInfo := range filesFoundOnDisk {
+		folderID, err := getOrCreateFolderIDFromPath(fr.Cfg, fr.dashboardProvisioningService, resolvedPath, filepath.Dir(path), folderIDs)
+		if err != nil {
 			return err
 		}
 
@@ -160,6 +163,35 @@ func (fr *FileReader) storeDashboardsInFoldersFromFileStructure(filesFoundOnDisk
 	return nil
 }
 
+// getOrCreateFolderIDFromPath returns the id of the folder for the directory dir, creating the folder and its
+// parent folders if needed. Dashboards in the resolved path are saved in the General folder.
+func getOrCreateFolderIDFromPath(cfg *config, service dashboards.DashboardProvisioningService, resolvedPath string, dir string, folderIDs map[string]int64) (int64, error) {
+	if dir == resolvedPath {
+		return 0, nil
+	}
+
+	if folderID, ok := folderIDs[dir]; ok {
+		return folderID, nil
+	}
+
+	var parentID int64
+	if parent := filepath.Dir(dir); parent != dir && strings.HasPrefix(parent, resolvedPath) {
+		var err error
+		parentID, err = getOrCreateFolderIDFromPath(cfg, service, resolvedPath, parent, folderIDs)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	folderID, err := getOrCreateSubfolderID(cfg, service, filepath.Base(dir), parentID)
+	if err != nil {
+		return 0, err
+	}
+
+	folderIDs[dir] = folderID
+	return folderID, nil
+}
+
 // handleMissingDashboardFiles will unprovision or delete dashboards which are missing on disk.
 func (fr *FileReader) handleMissingDashboardFiles(provisionedDashboardRefs map[string]*models.DashboardProvisioning, filesFoundOnDisk map[string]os.FileInfo) {
 	// find dashboards to delete since json file is missing
@@ -171,23 +203,29 @@ func (fr *FileReader) handleMissingDashboardFiles(provisionedDashboardRefs map[s
 		}
 	}
 
-	if fr.Cfg.DisableDeletion {
+	deleteMissingDashboards(fr.Cfg, fr.dashboardProvisioningService, fr.log, dashboardToDelete)
+}
+
+// deleteMissingDashboards will unprovision or, unless deletion is disabled for the provisioner, delete dashboards
+// whose definition file is missing.
+func deleteMissingDashboards(cfg *config, service dashboards.DashboardProvisioningService, logger log.Logger, dashboardToDelete []int64) {
+	if cfg.DisableDeletion {
 		// If deletion is disabled for the provisioner we just remove provisioning metadata about the dashboard
 		// so afterwards the dashboard is considered unprovisioned.
 		for _, dashboardID := range dashboardToDelete {
-			fr.log.Debug("unprovisioning provisioned dashboard. missing on disk", "id", dashboardID)
-			err := fr.dashboardProvisioningService.UnprovisionDashboard(dashboardID)
+			logger.Debug("unprovisioning provisioned dashboard. missing file", "id", dashboardID)
+			err := service.UnprovisionDashboard(dashboardID)
 			if err != nil {
-				fr.log.Error("failed to unprovision dashboard", "dashboard_id", dashboardID, "error", err)
+				logger.Error("failed to unprovision dashboard", "dashboard_id", dashboardID, "error", err)
 			}
 		}
 	} else {
 		// delete dashboard that are missing json file
 		for _, dashboardID := range dashboardToDelete {
-			fr.log.Debug("deleting provisioned dashboard. missing on disk", "id", dashboardID)
-			err := fr.dashboardProvisioningService.DeleteProvisionedDashboard(dashboardID, fr.Cfg.OrgID)
+			logger.Debug("deleting provisioned dashboard. missing file", "id", dashboardID)
+			err := service.DeleteProvisionedDashboard(dashboardID, cfg.OrgID)
 			if err != nil {
-				fr.log.Error("failed to delete dashboard", "id", dashboardID, "error", err)
+				logger.Error("failed to delete dashboard", "id", dashboardID, "error", err)
 			}
 		}
 	}
@@ -223,6 +261,14 @@ func (fr *FileReader) saveDashboard(path string, folderID int64, fileInfo os.Fil
 		return provisioningMetadata, nil
 	}
 
+	if errs := migrateAndValidateDashboard(dash); len(errs) > 0 {
+		if fr.Cfg.StrictSchemaValidation {
+			fr.log.Error("invalid dashboard, not saving it", "file", path, "error", errs)
+			return provisioningMetadata, nil
+		}
+		fr.log.Warn("saving invalid dashboard", "file", path, "error", errs)
+	}
+
 	if dash.Dashboard.Id != 0 {
 		dash.Dashboard.Data.Set("id", nil)
 		dash.Dashboard.Id = 0
@@ -259,39 +305,50 @@ func getProvisionedDashboardByPath(service dashboards.DashboardProvisioningServi
 }
 
 func getOrCreateFolderID(cfg *config, service dashboards.DashboardProvisioningService, folderName string) (int64, error) {
+	return getOrCreateSubfolderID(cfg, service, folderName, 0)
+}
+
+// getOrCreateSubfolderID returns the id of the folder with the given name in the parent folder, and creates it
+// if it does not exist. The folder uid from the config is only used for folders in the General folder.
+func getOrCreateSubfolderID(cfg *config, service dashboards.DashboardProvisioningService, folderName string, parentID int64) (int64, error) {
 	if folderName == "" {
 		return 0, ErrFolderNameMissing
 	}
 
-	cmd := &models.GetDashboardQuery{Slug: models.SlugifyTitle(folderName), OrgId: cfg.OrgID}
-	err := bus.Dispatch(cmd)
-
-	if err != nil && err != models.ErrDashboardNotFound {
+	cmd := &models.GetDashboardsBySlugQuery{Slug: models.SlugifyTitle(folderName), OrgId: cfg.OrgID}
+	if err := bus.Dispatch(cmd); err != nil {
 		return 0, err
 	}
 
-	// dashboard folder not found. create one.
-	if err == models.ErrDashboardNotFound {
-		dash := &dashboards.SaveDashboardDTO{}
-		dash.Dashboard = models.NewDashboardFolder(folderName)
-		dash.Dashboard.IsFolder = true
-		dash.Overwrite = true
-		dash.OrgId = cfg.OrgID
-		// set dashboard folderUid if given
-		dash.Dashboard.SetUid(cfg.FolderUID)
-		dbDash, err := service.SaveFolderForProvisionedDashboards(dash)
-		if err != nil {
-			return 0, err
+	for _, existing := range cmd.Result {
+		if existing.FolderId != parentID {
+			continue
+		}
+
+		if !existing.IsFolder {
+			return 0, fmt.Errorf("got invalid response. expected folder, found dashboard")
 		}
 
-		return dbDash.Id, nil
+		return existing.Id, nil
 	}
 
-	if !cmd.Result.IsFolder {
-		return 0, fmt.Errorf("got invalid response. expected folder, found dashboard")
+	// dashboard folder not found. create one.
+	dash := &dashboards.SaveDashboardDTO{}
+	dash.Dashboard = models.NewDashboardFolder(folderName)
+	dash.Dashboard.IsFolder = true
+	dash.Dashboard.FolderId = parentID
+	dash.Overwrite = true
+	dash.OrgId = cfg.OrgID
+	// set dashboard folderUid if given
+	if parentID == 0 {
+		dash.Dashboard.SetUid(cfg.FolderUID)
+	}
+	dbDash, err := service.SaveFolderForProvisionedDashboards(dash)
+	if err != nil {
+		return 0, err
 	}
 
-	return cmd.Result.Id, nil
+	return dbDash.Id, nil
 }
 
 func resolveSymlink(fileinfo os.FileInfo, path string) (os.FileInfo, error) {
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboards/schema"
 	"github.com/grafana/grafana/pkg/util"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -25,6 +26,8 @@ var (
 	containingID              = "testdata/test-dashboards/containing-id"
 	unprovision               = "testdata/test-dashboards/unprovision"
 	foldersFromFilesStructure = "testdata/test-dashboards/folders-from-files-structure"
+	nestedFolders             = "testdata/test-dashboards/nested-folders"
+	invalidSchema             = "testdata/test-dashboards/invalid-schema"
 
 	fakeService *fakeDashboardProvisioningService
 )
@@ -92,7 +95,7 @@ func TestDashboardFileReader(t *testing.T) {
 		origNewDashboardProvisioningService := dashboards.NewProvisioningService
 		fakeService = mockDashboardProvisioningService()
 
-		bus.AddHandler("test", mockGetDashboardQuery)
+		bus.AddHandler("test", mockGetDashboardsBySlugQuery)
 		logger := log.New("test.logger")
 
 		Convey("Reading dashboards from disk", func() {
@@ -200,6 +203,31 @@ func TestDashboardFileReader(t *testing.T) {
 				}
 			})
 
+			Convey("Get nested folders from files structure", func() {
+				cfg.Options["path"] = nestedFolders
+				cfg.Options["foldersFromFilesStructure"] = true
+
+				reader, err := NewDashboardFileReader(cfg, logger)
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk()
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 4)
+
+				byTitle := map[string]*models.Dashboard{}
+				for _, d := range fakeService.inserted {
+					byTitle[d.Dashboard.Title] = d.Dashboard
+				}
+
+				So(byTitle["team"].IsFolder, ShouldBeTrue)
+				So(byTitle["team"].FolderId, ShouldEqual, 0)
+				So(byTitle["services"].IsFolder, ShouldBeTrue)
+				So(byTitle["services"].FolderId, ShouldEqual, byTitle["team"].Id)
+				So(byTitle["Team overview"].FolderId, ShouldEqual, byTitle["team"].Id)
+				So(byTitle["Service overview"].FolderId, ShouldEqual, byTitle["services"].Id)
+			})
+
 			Convey("Invalid configuration should return error", func() {
 				cfg := &config{
 					Name:   "Default",
@@ -219,6 +247,36 @@ func TestDashboardFileReader(t *testing.T) {
 				So(err, ShouldBeNil)
 			})
 
+			Convey("Dashboards should be migrated to the latest schema version", func() {
+				cfg.Options["path"] = invalidSchema
+
+				reader, err := NewDashboardFileReader(cfg, logger)
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk()
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 2)
+				for _, i := range fakeService.inserted {
+					So(i.Dashboard.Data.Get("schemaVersion").MustInt(), ShouldEqual, schema.LatestVersion)
+				}
+			})
+
+			Convey("Invalid dashboards should not be saved with strict schema validation", func() {
+				cfg.Options["path"] = invalidSchema
+				cfg.StrictSchemaValidation = true
+
+				reader, err := NewDashboardFileReader(cfg, logger)
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk()
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.Uid, ShouldEqual, "rows")
+				So(fakeService.inserted[0].Dashboard.Data.Get("panels").GetIndex(0).Get("gridPos").Get("w").MustInt(), ShouldEqual, 24)
+			})
+
 			Convey("Two dashboard providers should be able to provisioned the same dashboard without uid", func() {
 				cfg1 := &config{Name: "1", Type: "file", OrgID: 1, Folder: "f1", Options: map[string]interface{}{"path": containingID}}
 				cfg2 := &config{Name: "2", Type: "file", OrgID: 1, Folder: "f2", Options: map[string]interface{}{"path": containingID}}
@@ -454,7 +512,12 @@ func (s *fakeDashboardProvisioningService) SaveProvisionedDashboard(dto *dashboa
 }
 
 func (s *fakeDashboardProvisioningService) SaveFolderForProvisionedDashboards(dto *dashboards.SaveDashboardDTO) (*models.Dashboard, error) {
+	if dto.Dashboard.Id == 0 {
+		dto.Dashboard.Id = rand.Int63n(1000000) + 1
+	}
+
 	s.inserted = append(s.inserted, dto)
+	s.getDashboard = append(s.getDashboard, dto.Dashboard)
 	return dto.Dashboard, nil
 }
 
@@ -487,13 +550,12 @@ func (s *fakeDashboardProvisioningService) GetProvisionedDashboardDataByDashboar
 	return nil, nil
 }
 
-func mockGetDashboardQuery(cmd *models.GetDashboardQuery) error {
+func mockGetDashboardsBySlugQuery(cmd *models.GetDashboardsBySlugQuery) error {
 	for _, d := range fakeService.getDashboard {
 		if d.Slug == cmd.Slug {
-			cmd.Result = d
-			return nil
+			cmd.Result = append(cmd.Result, d)
 		}
 	}
 
-	return models.ErrDashboardNotFound
+	return nil
 }
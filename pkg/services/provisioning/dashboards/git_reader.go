@@ -0,0 +1,488 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const gitZeroHash = "0000000000000000000000000000000000000000"
+
+// GitReader is responsible for reading dashboards from a branch of a local git
+// repository and insert/update dashboards to the Grafana database using
+// `dashboards.DashboardProvisioningService`. If a commit branch is configured,
+// dashboards saved in the UI are committed back to the repository on that branch.
+type GitReader struct {
+	Cfg                          *config
+	Repository                   string
+	Branch                       string
+	Path                         string
+	CommitBranch                 string
+	FoldersFromFilesStructure    bool
+	log                          log.Logger
+	dashboardProvisioningService dashboards.DashboardProvisioningService
+	lastCommit                   string
+	mutex                        sync.Mutex
+}
+
+// gitCommit is the metadata of a commit.
+type gitCommit struct {
+	hash    string
+	author  string
+	message string
+	time    time.Time
+}
+
+// NewDashboardGitReader returns a new git reader based on `config`
+func NewDashboardGitReader(cfg *config, log log.Logger) (*GitReader, error) {
+	repository, ok := cfg.Options["repository"].(string)
+	if !ok || repository == "" {
+		return nil, fmt.Errorf("Failed to load dashboards. repository param is not a string")
+	}
+
+	branch, _ := cfg.Options["branch"].(string)
+	if branch == "" {
+		branch = "master"
+	}
+
+	dir, _ := cfg.Options["path"].(string)
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+
+	commitBranch, _ := cfg.Options["commitBranch"].(string)
+	if commitBranch == branch {
+		return nil, fmt.Errorf("'commitBranch' should be different from 'branch'")
+	}
+	if commitBranch != "" && !cfg.AllowUIUpdates {
+		return nil, fmt.Errorf("'allowUiUpdates' should be enabled using 'commitBranch' option")
+	}
+
+	foldersFromFilesStructure, _ := cfg.Options["foldersFromFilesStructure"].(bool)
+	if foldersFromFilesStructure && cfg.Folder != "" && cfg.FolderUID != "" {
+		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
+	}
+
+	return &GitReader{
+		Cfg:                          cfg,
+		Repository:                   repository,
+		Branch:                       branch,
+		Path:                         dir,
+		CommitBranch:                 commitBranch,
+		FoldersFromFilesStructure:    foldersFromFilesStructure,
+		log:                          log,
+		dashboardProvisioningService: dashboards.NewProvisioningService(),
+	}, nil
+}
+
+func (gr *GitReader) getConfig() *config {
+	return gr.Cfg
+}
+
+func (gr *GitReader) provision() error {
+	return gr.readBranch()
+}
+
+// pollChanges periodically runs readBranch based on interval specified in the config.
+func (gr *GitReader) pollChanges(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(int64(time.Second) * gr.Cfg.UpdateIntervalSeconds))
+	for {
+		select {
+		case <-ticker.C:
+			if err := gr.readBranch(); err != nil {
+				gr.log.Error("failed to read dashboards from git repository", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// resolvedPath returns the directory in the repository the dashboards are read from. The external id of
+// the dashboards is their path in the repository.
+func (gr *GitReader) resolvedPath() string {
+	if gr.Path == "" {
+		return "."
+	}
+	return gr.Path
+}
+
+// readBranch reads the dashboard definition files of the head commit of the branch and applies any change
+// to the database. Nothing is done if the head commit has already been read successfully.
+func (gr *GitReader) readBranch() error {
+	if _, err := os.Stat(gr.Repository); err != nil {
+		return err
+	}
+
+	head, err := gr.git(nil, nil, "rev-parse", "--verify", "refs/heads/"+gr.Branch+"^{commit}")
+	if err != nil {
+		return err
+	}
+	head = strings.TrimSpace(head)
+
+	if head == gr.lastCommit {
+		return nil
+	}
+
+	gr.log.Debug("Start reading git branch", "repository", gr.Repository, "branch", gr.Branch, "commit", head)
+
+	provisionedDashboardRefs, err := getProvisionedDashboardByPath(gr.dashboardProvisioningService, gr.Cfg.Name)
+	if err != nil {
+		return err
+	}
+
+	files, err := gr.listFiles(head)
+	if err != nil {
+		return err
+	}
+
+	var dashboardToDelete []int64
+	for externalID, provisioningData := range provisionedDashboardRefs {
+		if _, exists := files[externalID]; !exists {
+			dashboardToDelete = append(dashboardToDelete, provisioningData.DashboardId)
+		}
+	}
+	deleteMissingDashboards(gr.Cfg, gr.dashboardProvisioningService, gr.log, dashboardToDelete)
+
+	folderID, err := getOrCreateFolderID(gr.Cfg, gr.dashboardProvisioningService, gr.Cfg.Folder)
+	if err != nil && err != ErrFolderNameMissing {
+		return err
+	}
+
+	sanityChecker := newProvisioningSanityChecker(gr.Cfg.Name)
+	folderIDs := map[string]int64{}
+	failed := false
+	for file, blob := range files {
+		if gr.FoldersFromFilesStructure {
+			folderID, err = getOrCreateFolderIDFromPath(gr.Cfg, gr.dashboardProvisioningService,
+				filepath.FromSlash(gr.resolvedPath()), filepath.FromSlash(path.Dir(file)), folderIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		provisioningMetadata, err := gr.saveDashboard(head, file, blob, folderID, provisionedDashboardRefs)
+		sanityChecker.track(provisioningMetadata)
+		if err != nil {
+			gr.log.Error("failed to save dashboard", "file", file, "error", err)
+			failed = true
+		}
+	}
+
+	sanityChecker.logWarnings(gr.log)
+
+	// read the branch again on the next poll if a dashboard could not be saved
+	if !failed {
+		gr.lastCommit = head
+	}
+	return nil
+}
+
+// listFiles returns the blob ids of the dashboard definition files in the commit, by path in the repository.
+func (gr *GitReader) listFiles(commit string) (map[string]string, error) {
+	args := []string{"ls-tree", "-r", "-z", "--full-tree", commit}
+	if gr.Path != "" {
+		args = append(args, "--", gr.Path)
+	}
+
+	out, err := gr.git(nil, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+	for _, entry := range strings.Split(out, "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		parts := strings.SplitN(entry, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		fields := strings.Fields(parts[0])
+		if len(fields) != 3 || fields[1] != "blob" || !isDashboardGitPath(parts[1]) {
+			continue
+		}
+
+		files[parts[1]] = fields[2]
+	}
+
+	return files, nil
+}
+
+// isDashboardGitPath returns true for json files that are not in a hidden directory.
+func isDashboardGitPath(file string) bool {
+	if !strings.HasSuffix(file, ".json") {
+		return false
+	}
+
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if strings.HasPrefix(dir, ".") && dir != "." {
+			return false
+		}
+	}
+
+	return true
+}
+
+// saveDashboard saves or updates the dashboard of the file in the repository.
+func (gr *GitReader) saveDashboard(head string, file string, blob string, folderID int64, provisionedDashboardRefs map[string]*models.DashboardProvisioning) (provisioningMetadata, error) {
+	provisioningMetadata := provisioningMetadata{}
+
+	content, err := gr.git(nil, nil, "cat-file", "blob", blob)
+	if err != nil {
+		return provisioningMetadata, err
+	}
+
+	checkSum, err := util.Md5SumString(content)
+	if err != nil {
+		return provisioningMetadata, err
+	}
+
+	provisionedData, alreadyProvisioned := provisionedDashboardRefs[file]
+	upToDate := alreadyProvisioned && provisionedData.CheckSum == checkSum
+
+	data, err := simplejson.NewJson([]byte(content))
+	if err != nil {
+		gr.log.Error("failed to load dashboard from ", "file", file, "error", err)
+		return provisioningMetadata, nil
+	}
+
+	dash, err := createDashboardJSON(data, time.Time{}, gr.Cfg, folderID)
+	if err != nil {
+		gr.log.Error("failed to load dashboard from ", "file", file, "error", err)
+		return provisioningMetadata, nil
+	}
+
+	// keeps track of what uid's and title's we have already provisioned
+	provisioningMetadata.uid = dash.Dashboard.Uid
+	provisioningMetadata.identity = dashboardIdentity{title: dash.Dashboard.Title, folderID: dash.Dashboard.FolderId}
+
+	if upToDate {
+		return provisioningMetadata, nil
+	}
+
+	if errs := migrateAndValidateDashboard(dash); len(errs) > 0 {
+		if gr.Cfg.StrictSchemaValidation {
+			gr.log.Error("invalid dashboard, not saving it", "file", file, "error", errs)
+			return provisioningMetadata, nil
+		}
+		gr.log.Warn("saving invalid dashboard", "file", file, "error", errs)
+	}
+
+	commit, err := gr.lastCommitOf(head, file)
+	if err != nil {
+		return provisioningMetadata, err
+	}
+	dash.UpdatedAt = commit.time
+
+	if dash.Dashboard.Id != 0 {
+		dash.Dashboard.Data.Set("id", nil)
+		dash.Dashboard.Id = 0
+	}
+
+	if alreadyProvisioned {
+		dash.Dashboard.SetId(provisionedData.DashboardId)
+	}
+
+	gr.log.Debug("saving new dashboard", "provisioner", gr.Cfg.Name, "file", file, "commit", commit.hash)
+	dp := &models.DashboardProvisioning{
+		ExternalId:    file,
+		Name:          gr.Cfg.Name,
+		Updated:       commit.time.Unix(),
+		CheckSum:      checkSum,
+		CommitHash:    commit.hash,
+		CommitAuthor:  commit.author,
+		CommitMessage: commit.message,
+		CommitTime:    commit.time.Unix(),
+	}
+
+	_, err = gr.dashboardProvisioningService.SaveProvisionedDashboard(dash, dp)
+	return provisioningMetadata, err
+}
+
+// lastCommitOf returns the last commit before head that changed the file.
+func (gr *GitReader) lastCommitOf(head string, file string) (*gitCommit, error) {
+	out, err := gr.git(nil, nil, "log", "-1", "--format=%H%x00%an <%ae>%x00%ct%x00%s", head, "--", file)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(out), "\x00", 4)
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("unexpected git log output for %s", file)
+	}
+
+	timestamp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitCommit{
+		hash:    parts[0],
+		author:  parts[1],
+		message: parts[3],
+		time:    time.Unix(timestamp, 0),
+	}, nil
+}
+
+// saveDashboardToSource commits the dashboard saved in the UI to the file it was provisioned from, on the
+// commit branch. The commit branch is created from the branch if it does not exist. Nothing is done if no
+// commit branch is configured.
+func (gr *GitReader) saveDashboardToSource(provisioning *models.DashboardProvisioning, dash *models.Dashboard, user *models.SignedInUser, message string) error {
+	if gr.CommitBranch == "" {
+		return nil
+	}
+
+	gr.mutex.Lock()
+	defer gr.mutex.Unlock()
+
+	content, err := dashboardFileContent(dash)
+	if err != nil {
+		return err
+	}
+
+	ref := "refs/heads/" + gr.CommitBranch
+	oldValue := gitZeroHash
+	parent, err := gr.git(nil, nil, "rev-parse", "--verify", "-q", ref+"^{commit}")
+	if err == nil {
+		oldValue = strings.TrimSpace(parent)
+	} else {
+		parent, err = gr.git(nil, nil, "rev-parse", "--verify", "refs/heads/"+gr.Branch+"^{commit}")
+		if err != nil {
+			return err
+		}
+	}
+	parent = strings.TrimSpace(parent)
+
+	blob, err := gr.git(nil, content, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return err
+	}
+
+	// build the tree in a temporary index so that the work tree of the repository, if any, is not changed
+	indexFile, err := ioutil.TempFile("", "grafana-git-index")
+	if err != nil {
+		return err
+	}
+	indexPath := indexFile.Name()
+	indexFile.Close()
+	os.Remove(indexPath)
+	defer os.Remove(indexPath)
+
+	indexEnv := []string{"GIT_INDEX_FILE=" + indexPath}
+	if _, err := gr.git(indexEnv, nil, "read-tree", parent); err != nil {
+		return err
+	}
+	cacheInfo := "100644," + strings.TrimSpace(blob) + "," + provisioning.ExternalId
+	if _, err := gr.git(indexEnv, nil, "update-index", "--add", "--cacheinfo", cacheInfo); err != nil {
+		return err
+	}
+	tree, err := gr.git(indexEnv, nil, "write-tree")
+	if err != nil {
+		return err
+	}
+
+	parentTree, err := gr.git(nil, nil, "rev-parse", parent+"^{tree}")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(tree) == strings.TrimSpace(parentTree) {
+		return nil
+	}
+
+	if message == "" {
+		message = "Update " + dash.Title
+	}
+
+	name, email := gitIdentity(user)
+	identityEnv := []string{
+		"GIT_AUTHOR_NAME=" + name,
+		"GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=" + name,
+		"GIT_COMMITTER_EMAIL=" + email,
+	}
+	commit, err := gr.git(identityEnv, nil, "commit-tree", strings.TrimSpace(tree), "-p", parent, "-m", message)
+	if err != nil {
+		return err
+	}
+	commit = strings.TrimSpace(commit)
+
+	if _, err := gr.git(nil, nil, "update-ref", "-m", "grafana: "+message, ref, commit, oldValue); err != nil {
+		return err
+	}
+
+	gr.log.Info("committed dashboard", "file", provisioning.ExternalId, "branch", gr.CommitBranch, "commit", commit)
+	return nil
+}
+
+// dashboardFileContent returns the json of the dashboard without its id, which is specific to the database.
+func dashboardFileContent(dash *models.Dashboard) ([]byte, error) {
+	raw, err := dash.Data.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := simplejson.NewJson(raw)
+	if err != nil {
+		return nil, err
+	}
+	data.Del("id")
+
+	content, err := data.EncodePretty()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
+
+// gitIdentity returns the name and email used as author of the commits of the user.
+func gitIdentity(user *models.SignedInUser) (string, string) {
+	if user == nil || user.UserId == 0 {
+		return "Grafana", "grafana@localhost"
+	}
+
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+
+	email := user.Email
+	if email == "" {
+		email = user.Login
+	}
+
+	return name, email
+}
+
+// git runs the git command in the repository and returns its output.
+func (gr *GitReader) git(env []string, stdin []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", gr.Repository}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
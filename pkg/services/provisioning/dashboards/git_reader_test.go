@@ -0,0 +1,192 @@
+package dashboards
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardGitReader(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	Convey("Dashboard git reader", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+
+		bus.AddHandler("test", mockGetDashboardsBySlugQuery)
+		logger := log.New("test.logger")
+
+		tmpDir, err := ioutil.TempDir("", "grafana-git-reader")
+		So(err, ShouldBeNil)
+
+		// a bare repository with a master branch, and a clone to push commits to it
+		repository := filepath.Join(tmpDir, "dashboards.git")
+		work := filepath.Join(tmpDir, "work")
+		runGit(tmpDir, "init", "--bare", repository)
+		runGit(tmpDir, "init", work)
+
+		commitFiles := func(message string, files map[string]string) string {
+			for name, content := range files {
+				file := filepath.Join(work, filepath.FromSlash(name))
+				if content == "" {
+					runGit(work, "rm", "-q", name)
+					continue
+				}
+				So(os.MkdirAll(filepath.Dir(file), 0750), ShouldBeNil)
+				So(ioutil.WriteFile(file, []byte(content), 0600), ShouldBeNil)
+				runGit(work, "add", name)
+			}
+			runGit(work, "-c", "user.name=Jane", "-c", "user.email=jane@example.com", "commit", "-q", "-m", message)
+			runGit(work, "push", "-q", repository, "HEAD:refs/heads/master")
+			return strings.TrimSpace(runGit(work, "rev-parse", "HEAD"))
+		}
+
+		cfg := &config{
+			Name:    "Git",
+			Type:    "git",
+			OrgID:   1,
+			Folder:  "Team A",
+			Options: map[string]interface{}{"repository": repository, "path": "dashboards"},
+		}
+
+		Convey("Should validate the options", func() {
+			_, err := NewDashboardGitReader(&config{Name: "Git", Options: map[string]interface{}{}}, logger)
+			So(err, ShouldNotBeNil)
+
+			cfg.Options["commitBranch"] = "master"
+			_, err = NewDashboardGitReader(cfg, logger)
+			So(err, ShouldNotBeNil)
+
+			cfg.Options["commitBranch"] = "grafana"
+			_, err = NewDashboardGitReader(cfg, logger)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Given dashboards committed on the branch", func() {
+			head := commitFiles("Add dashboards", map[string]string{
+				"dashboards/dashboard1.json":        `{"title": "Dashboard 1", "uid": "dash1"}`,
+				"dashboards/team/dashboard2.json":   `{"title": "Dashboard 2"}`,
+				"dashboards/.hidden/dashboard.json": `{"title": "Hidden"}`,
+				"other/dashboard3.json":             `{"title": "Dashboard 3"}`,
+			})
+
+			reader, err := NewDashboardGitReader(cfg, logger)
+			So(err, ShouldBeNil)
+
+			err = reader.readBranch()
+			So(err, ShouldBeNil)
+
+			Convey("Should provision the dashboards of the path with commit metadata", func() {
+				provisioned := fakeService.provisioned["Git"]
+				So(len(provisioned), ShouldEqual, 2)
+
+				byExternalID := map[string]*models.DashboardProvisioning{}
+				for _, p := range provisioned {
+					byExternalID[p.ExternalId] = p
+				}
+
+				dp := byExternalID["dashboards/dashboard1.json"]
+				So(dp, ShouldNotBeNil)
+				So(dp.CommitHash, ShouldEqual, head)
+				So(dp.CommitAuthor, ShouldEqual, "Jane <jane@example.com>")
+				So(dp.CommitMessage, ShouldEqual, "Add dashboards")
+				So(dp.CheckSum, ShouldNotBeEmpty)
+				So(byExternalID["dashboards/team/dashboard2.json"], ShouldNotBeNil)
+
+				So(reader.resolvedPath(), ShouldEqual, "dashboards")
+			})
+
+			Convey("Should update and delete dashboards on new commits", func() {
+				inserted := len(fakeService.inserted)
+
+				So(reader.readBranch(), ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, inserted)
+
+				head := commitFiles("Update dashboards", map[string]string{
+					"dashboards/dashboard1.json":      `{"title": "Dashboard 1 v2", "uid": "dash1"}`,
+					"dashboards/team/dashboard2.json": "",
+				})
+				So(reader.readBranch(), ShouldBeNil)
+
+				provisioned := fakeService.provisioned["Git"]
+				So(len(provisioned), ShouldEqual, 1)
+				So(provisioned[0].ExternalId, ShouldEqual, "dashboards/dashboard1.json")
+				So(provisioned[0].CommitHash, ShouldEqual, head)
+				So(provisioned[0].CommitMessage, ShouldEqual, "Update dashboards")
+			})
+		})
+
+		Convey("Given a commit branch", func() {
+			commitFiles("Add dashboard", map[string]string{
+				"dashboards/dashboard1.json": `{"title": "Dashboard 1", "uid": "dash1"}`,
+			})
+			master := strings.TrimSpace(runGit(repository, "rev-parse", "master"))
+
+			cfg.AllowUIUpdates = true
+			cfg.Options["commitBranch"] = "grafana"
+			reader, err := NewDashboardGitReader(cfg, logger)
+			So(err, ShouldBeNil)
+
+			dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+				"id":    42,
+				"uid":   "dash1",
+				"title": "Dashboard 1 edited",
+			}))
+			user := &models.SignedInUser{UserId: 2, Login: "john", Name: "John", Email: "john@example.com"}
+			dp := &models.DashboardProvisioning{Name: "Git", ExternalId: "dashboards/dashboard1.json"}
+
+			err = reader.saveDashboardToSource(dp, dash, user, "")
+			So(err, ShouldBeNil)
+
+			Convey("Should commit the dashboard on the commit branch", func() {
+				content := runGit(repository, "show", "grafana:dashboards/dashboard1.json")
+				data, err := simplejson.NewJson([]byte(content))
+				So(err, ShouldBeNil)
+				So(data.Get("title").MustString(), ShouldEqual, "Dashboard 1 edited")
+				_, hasID := data.CheckGet("id")
+				So(hasID, ShouldBeFalse)
+
+				So(runGit(repository, "log", "-1", "--format=%an <%ae> %s", "grafana"), ShouldEqual, "John <john@example.com> Update Dashboard 1 edited\n")
+				So(strings.TrimSpace(runGit(repository, "rev-parse", "grafana^")), ShouldEqual, master)
+				So(strings.TrimSpace(runGit(repository, "rev-parse", "master")), ShouldEqual, master)
+			})
+
+			Convey("Should add commits to the existing commit branch", func() {
+				dash.Data.Set("title", "Dashboard 1 edited twice")
+				So(reader.saveDashboardToSource(dp, dash, user, "Rename"), ShouldBeNil)
+
+				So(runGit(repository, "log", "-1", "--format=%s", "grafana"), ShouldEqual, "Rename\n")
+				So(strings.TrimSpace(runGit(repository, "rev-list", "--count", "grafana")), ShouldEqual, "3")
+
+				// saving the same dashboard again does not create a commit
+				So(reader.saveDashboardToSource(dp, dash, user, "Rename"), ShouldBeNil)
+				So(strings.TrimSpace(runGit(repository, "rev-list", "--count", "grafana")), ShouldEqual, "3")
+			})
+		})
+
+		Reset(func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+			os.RemoveAll(tmpDir)
+		})
+	})
+}
+
+func runGit(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	So(err, ShouldBeNil)
+	return string(out)
+}
@@ -7,33 +7,36 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboards/schema"
 	"github.com/grafana/grafana/pkg/services/provisioning/values"
 )
 
 type config struct {
-	Name                  string
-	Type                  string
-	OrgID                 int64
-	Folder                string
-	FolderUID             string
-	Editable              bool
-	Options               map[string]interface{}
-	DisableDeletion       bool
-	UpdateIntervalSeconds int64
-	AllowUIUpdates        bool
+	Name                   string
+	Type                   string
+	OrgID                  int64
+	Folder                 string
+	FolderUID              string
+	Editable               bool
+	Options                map[string]interface{}
+	DisableDeletion        bool
+	UpdateIntervalSeconds  int64
+	AllowUIUpdates         bool
+	StrictSchemaValidation bool
 }
 
 type configV0 struct {
-	Name                  string                 `json:"name" yaml:"name"`
-	Type                  string                 `json:"type" yaml:"type"`
-	OrgID                 int64                  `json:"org_id" yaml:"org_id"`
-	Folder                string                 `json:"folder" yaml:"folder"`
-	FolderUID             string                 `json:"folderUid" yaml:"folderUid"`
-	Editable              bool                   `json:"editable" yaml:"editable"`
-	Options               map[string]interface{} `json:"options" yaml:"options"`
-	DisableDeletion       bool                   `json:"disableDeletion" yaml:"disableDeletion"`
-	UpdateIntervalSeconds int64                  `json:"updateIntervalSeconds" yaml:"updateIntervalSeconds"`
-	AllowUIUpdates        bool                   `json:"allowUiUpdates" yaml:"allowUiUpdates"`
+	Name                   string                 `json:"name" yaml:"name"`
+	Type                   string                 `json:"type" yaml:"type"`
+	OrgID                  int64                  `json:"org_id" yaml:"org_id"`
+	Folder                 string                 `json:"folder" yaml:"folder"`
+	FolderUID              string                 `json:"folderUid" yaml:"folderUid"`
+	Editable               bool                   `json:"editable" yaml:"editable"`
+	Options                map[string]interface{} `json:"options" yaml:"options"`
+	DisableDeletion        bool                   `json:"disableDeletion" yaml:"disableDeletion"`
+	UpdateIntervalSeconds  int64                  `json:"updateIntervalSeconds" yaml:"updateIntervalSeconds"`
+	AllowUIUpdates         bool                   `json:"allowUiUpdates" yaml:"allowUiUpdates"`
+	StrictSchemaValidation bool                   `json:"strictSchemaValidation" yaml:"strictSchemaValidation"`
 }
 
 type configVersion struct {
@@ -45,16 +48,17 @@ type configV1 struct {
 }
 
 type configs struct {
-	Name                  values.StringValue `json:"name" yaml:"name"`
-	Type                  values.StringValue `json:"type" yaml:"type"`
-	OrgID                 values.Int64Value  `json:"orgId" yaml:"orgId"`
-	Folder                values.StringValue `json:"folder" yaml:"folder"`
-	FolderUID             values.StringValue `json:"folderUid" yaml:"folderUid"`
-	Editable              values.BoolValue   `json:"editable" yaml:"editable"`
-	Options               values.JSONValue   `json:"options" yaml:"options"`
-	DisableDeletion       values.BoolValue   `json:"disableDeletion" yaml:"disableDeletion"`
-	UpdateIntervalSeconds values.Int64Value  `json:"updateIntervalSeconds" yaml:"updateIntervalSeconds"`
-	AllowUIUpdates        values.BoolValue   `json:"allowUiUpdates" yaml:"allowUiUpdates"`
+	Name                   values.StringValue `json:"name" yaml:"name"`
+	Type                   values.StringValue `json:"type" yaml:"type"`
+	OrgID                  values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Folder                 values.StringValue `json:"folder" yaml:"folder"`
+	FolderUID              values.StringValue `json:"folderUid" yaml:"folderUid"`
+	Editable               values.BoolValue   `json:"editable" yaml:"editable"`
+	Options                values.JSONValue   `json:"options" yaml:"options"`
+	DisableDeletion        values.BoolValue   `json:"disableDeletion" yaml:"disableDeletion"`
+	UpdateIntervalSeconds  values.Int64Value  `json:"updateIntervalSeconds" yaml:"updateIntervalSeconds"`
+	AllowUIUpdates         values.BoolValue   `json:"allowUiUpdates" yaml:"allowUiUpdates"`
+	StrictSchemaValidation values.BoolValue   `json:"strictSchemaValidation" yaml:"strictSchemaValidation"`
 }
 
 func createDashboardJSON(data *simplejson.Json, lastModified time.Time, cfg *config, folderID int64) (*dashboards.SaveDashboardDTO, error) {
@@ -73,6 +77,14 @@ func createDashboardJSON(data *simplejson.Json, lastModified time.Time, cfg *con
 	return dash, nil
 }
 
+// migrateAndValidateDashboard migrates the dashboard JSON model to the latest schemaVersion and returns its
+// validation errors.
+func migrateAndValidateDashboard(dash *dashboards.SaveDashboardDTO) schema.ValidationErrors {
+	// dashboards too old to migrate are validated against the schema of their version
+	_ = schema.Migrate(dash.Dashboard.Data)
+	return schema.Validate(dash.Dashboard.Data)
+}
+
 func mapV0ToDashboardsAsConfig(v0 []*configV0) ([]*config, error) {
 	var r []*config
 	seen := make(map[string]bool)
@@ -84,16 +96,17 @@ func mapV0ToDashboardsAsConfig(v0 []*configV0) ([]*config, error) {
 		seen[v.Name] = true
 
 		r = append(r, &config{
-			Name:                  v.Name,
-			Type:                  v.Type,
-			OrgID:                 v.OrgID,
-			Folder:                v.Folder,
-			FolderUID:             v.FolderUID,
-			Editable:              v.Editable,
-			Options:               v.Options,
-			DisableDeletion:       v.DisableDeletion,
-			UpdateIntervalSeconds: v.UpdateIntervalSeconds,
-			AllowUIUpdates:        v.AllowUIUpdates,
+			Name:                   v.Name,
+			Type:                   v.Type,
+			OrgID:                  v.OrgID,
+			Folder:                 v.Folder,
+			FolderUID:              v.FolderUID,
+			Editable:               v.Editable,
+			Options:                v.Options,
+			DisableDeletion:        v.DisableDeletion,
+			UpdateIntervalSeconds:  v.UpdateIntervalSeconds,
+			AllowUIUpdates:         v.AllowUIUpdates,
+			StrictSchemaValidation: v.StrictSchemaValidation,
 		})
 	}
 
@@ -111,16 +124,17 @@ func (dc *configV1) mapToDashboardsAsConfig() ([]*config, error) {
 		seen[v.Name.Value()] = true
 
 		r = append(r, &config{
-			Name:                  v.Name.Value(),
-			Type:                  v.Type.Value(),
-			OrgID:                 v.OrgID.Value(),
-			Folder:                v.Folder.Value(),
-			FolderUID:             v.FolderUID.Value(),
-			Editable:              v.Editable.Value(),
-			Options:               v.Options.Value(),
-			DisableDeletion:       v.DisableDeletion.Value(),
-			UpdateIntervalSeconds: v.UpdateIntervalSeconds.Value(),
-			AllowUIUpdates:        v.AllowUIUpdates.Value(),
+			Name:                   v.Name.Value(),
+			Type:                   v.Type.Value(),
+			OrgID:                  v.OrgID.Value(),
+			Folder:                 v.Folder.Value(),
+			FolderUID:              v.FolderUID.Value(),
+			Editable:               v.Editable.Value(),
+			Options:                v.Options.Value(),
+			DisableDeletion:        v.DisableDeletion.Value(),
+			UpdateIntervalSeconds:  v.UpdateIntervalSeconds.Value(),
+			AllowUIUpdates:         v.AllowUIUpdates.Value(),
+			StrictSchemaValidation: v.StrictSchemaValidation.Value(),
 		})
 	}
 
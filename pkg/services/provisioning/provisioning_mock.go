@@ -1,5 +1,7 @@
 package provisioning
 
+import "github.com/grafana/grafana/pkg/models"
+
 type Calls struct {
 	ProvisionDatasources                []interface{}
 	ProvisionPlugins                    []interface{}
@@ -7,6 +9,7 @@ type Calls struct {
 	ProvisionDashboards                 []interface{}
 	GetDashboardProvisionerResolvedPath []interface{}
 	GetAllowUIUpdatesFromConfig         []interface{}
+	SaveDashboardToSource               []interface{}
 }
 
 type ProvisioningServiceMock struct {
@@ -17,6 +20,7 @@ type ProvisioningServiceMock struct {
 	ProvisionDashboardsFunc                 func() error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
+	SaveDashboardToSourceFunc               func(provisioning *models.DashboardProvisioning, dash *models.Dashboard, user *models.SignedInUser, message string) error
 }
 
 func NewProvisioningServiceMock() *ProvisioningServiceMock {
@@ -72,3 +76,11 @@ func (mock *ProvisioningServiceMock) GetAllowUIUpdatesFromConfig(name string) bo
 	}
 	return false
 }
+
+func (mock *ProvisioningServiceMock) SaveDashboardToSource(provisioning *models.DashboardProvisioning, dash *models.Dashboard, user *models.SignedInUser, message string) error {
+	mock.Calls.SaveDashboardToSource = append(mock.Calls.SaveDashboardToSource, provisioning)
+	if mock.SaveDashboardToSourceFunc != nil {
+		return mock.SaveDashboardToSourceFunc(provisioning, dash, user, message)
+	}
+	return nil
+}
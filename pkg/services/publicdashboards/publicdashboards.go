@@ -0,0 +1,78 @@
+// Package publicdashboards implements the tokens of public dashboard shares
+// and the checks that restrict the queries of share holders to the queries
+// of the shared dashboard.
+package publicdashboards
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const tokenLength = 32
+
+// queryKeysSetByClient are the keys of queries that the frontend sets when
+// it sends the queries of a panel, they aren't compared with the panel.
+var queryKeysSetByClient = map[string]bool{
+	"datasource":    true,
+	"datasourceId":  true,
+	"intervalMs":    true,
+	"maxDataPoints": true,
+	"panelId":       true,
+	"hide":          true,
+}
+
+// GenerateToken returns a new share token and its hash.
+func GenerateToken() (string, string, error) {
+	token, err := util.GetRandomString(tokenLength)
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hash a share token is stored and looked up by.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token + setting.SecretKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// QueryMatches returns true if the query sent by a client is the panel query.
+// Clients can send the query with or without the variables replaced. Keys
+// the panel query doesn't have are ignored, as only the panel query is run.
+func QueryMatches(panelQuery *dashboards.PanelQuery, query *simplejson.Json) bool {
+	return matches(panelQuery.Model, query) || matches(panelQuery.Raw, query)
+}
+
+func matches(target, query *simplejson.Json) bool {
+	targetMap := target.MustMap()
+	queryMap := query.MustMap()
+
+	for key, value := range targetMap {
+		if queryKeysSetByClient[key] {
+			continue
+		}
+		if !jsonEqual(value, queryMap[key]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func jsonEqual(a, b interface{}) bool {
+	aJson, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJson, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(aJson) == string(bJson)
+}
@@ -0,0 +1,64 @@
+package publicdashboards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+func TestQueryMatches(t *testing.T) {
+	dashboard := simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id":         1,
+				"datasource": "$ds",
+				"targets": []interface{}{
+					map[string]interface{}{"refId": "A", "rawSql": "SELECT * FROM ${table} WHERE host IN ([[host]])"},
+				},
+			},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "ds", "current": map[string]interface{}{"value": "MySQL"}},
+				map[string]interface{}{"name": "table", "current": map[string]interface{}{"value": "hosts"}},
+				map[string]interface{}{"name": "host", "current": map[string]interface{}{"value": []interface{}{"a", "b"}}},
+			},
+		},
+	})
+
+	t.Run("Should match the queries of the panel", func(t *testing.T) {
+		query, err := dashboards.FindPanelQuery(dashboard, 1, "A")
+		require.NoError(t, err)
+
+		interpolated := simplejson.NewFromAny(map[string]interface{}{
+			"refId": "A", "rawSql": "SELECT * FROM hosts WHERE host IN (a,b)", "datasourceId": 2, "intervalMs": 1000, "format": "table",
+		})
+		assert.True(t, QueryMatches(query, interpolated))
+
+		raw := simplejson.NewFromAny(map[string]interface{}{
+			"refId": "A", "rawSql": "SELECT * FROM ${table} WHERE host IN ([[host]])",
+		})
+		assert.True(t, QueryMatches(query, raw))
+
+		changed := simplejson.NewFromAny(map[string]interface{}{
+			"refId": "A", "rawSql": "SELECT * FROM users",
+		})
+		assert.False(t, QueryMatches(query, changed))
+
+		missing := simplejson.NewFromAny(map[string]interface{}{"refId": "A"})
+		assert.False(t, QueryMatches(query, missing))
+	})
+}
+
+func TestTokens(t *testing.T) {
+	token, hash, err := GenerateToken()
+	require.NoError(t, err)
+
+	assert.Len(t, token, tokenLength)
+	assert.Equal(t, hash, HashToken(token))
+	assert.NotEqual(t, token, hash)
+}
@@ -0,0 +1,380 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// attributes holds the values of a resource by lower case attribute path,
+// e.g. "emails.value". Sub-attributes of multi-valued attributes hold the
+// values of all entries.
+type attributes map[string][]string
+
+// caseExactAttributes are compared case sensitively, all other attributes
+// are case insensitive as defined by the core schema.
+var caseExactAttributes = map[string]bool{
+	"id":            true,
+	"externalid":    true,
+	"members.value": true,
+}
+
+// Filter is a parsed filter expression, see RFC 7644 section 3.4.2.2.
+type Filter interface {
+	matches(attrs attributes) bool
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right Filter
+}
+
+func (f *logicalFilter) matches(attrs attributes) bool {
+	if f.and {
+		return f.left.matches(attrs) && f.right.matches(attrs)
+	}
+	return f.left.matches(attrs) || f.right.matches(attrs)
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+func (f *notFilter) matches(attrs attributes) bool {
+	return !f.filter.matches(attrs)
+}
+
+type presentFilter struct {
+	path string
+}
+
+func (f *presentFilter) matches(attrs attributes) bool {
+	for _, value := range attrs[f.path] {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+type compareFilter struct {
+	path     string
+	operator string
+	value    string
+	isNull   bool
+}
+
+func (f *compareFilter) matches(attrs attributes) bool {
+	if f.isNull {
+		present := (&presentFilter{path: f.path}).matches(attrs)
+		return present == (f.operator == "ne")
+	}
+
+	values := attrs[f.path]
+	if f.operator == "ne" {
+		for _, value := range values {
+			if f.compare(value) == 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, value := range values {
+		if f.compareOperator(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *compareFilter) compareOperator(value string) bool {
+	a, b := value, f.value
+	if !caseExactAttributes[f.path] {
+		a, b = strings.ToLower(a), strings.ToLower(b)
+	}
+
+	switch f.operator {
+	case "eq":
+		return f.compare(value) == 0
+	case "co":
+		return strings.Contains(a, b)
+	case "sw":
+		return strings.HasPrefix(a, b)
+	case "ew":
+		return strings.HasSuffix(a, b)
+	case "gt":
+		return f.compare(value) > 0
+	case "ge":
+		return f.compare(value) >= 0
+	case "lt":
+		return f.compare(value) < 0
+	case "le":
+		return f.compare(value) <= 0
+	}
+	return false
+}
+
+// compare compares the attribute value to the filter value, as date times if
+// both can be parsed as such, otherwise as strings.
+func (f *compareFilter) compare(value string) int {
+	if t1, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		if t2, err := time.Parse(time.RFC3339Nano, f.value); err == nil {
+			switch {
+			case t1.Before(t2):
+				return -1
+			case t1.After(t2):
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	if caseExactAttributes[f.path] {
+		return strings.Compare(value, f.value)
+	}
+	return strings.Compare(strings.ToLower(value), strings.ToLower(f.value))
+}
+
+var compareOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// ParseFilter parses a filter expression. Attribute paths may be prefixed
+// with the schema URN, and filters on a multi-valued attribute such as
+// emails[type eq "work"] match if any entry matches.
+func ParseFilter(filter string) (Filter, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr("")
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, invalidFilter("unexpected %q", p.tokens[p.pos].text)
+	}
+
+	return f, nil
+}
+
+func invalidFilter(format string, args ...interface{}) *Error {
+	return NewError(http.StatusBadRequest, ErrorTypeInvalidFilter, "Invalid filter: "+fmt.Sprintf(format, args...))
+}
+
+type token struct {
+	text     string
+	isString bool
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("()[]", c) >= 0:
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return nil, invalidFilter("unterminated string")
+			}
+
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return nil, invalidFilter("invalid string %s", s[i:end+1])
+			}
+			tokens = append(tokens, token{text: value, isString: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(s) && strings.IndexByte(" \t()[]\"", s[end]) < 0 {
+				end++
+			}
+			tokens = append(tokens, token{text: s[i:end]})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) next() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, true
+}
+
+// peekKeyword returns true and consumes the next token if it's the keyword.
+func (p *filterParser) peekKeyword(keyword string) bool {
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].isString && strings.EqualFold(p.tokens[p.pos].text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(text string) error {
+	t, ok := p.next()
+	if !ok || t.isString || t.text != text {
+		return invalidFilter("expected %q", text)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr(prefix string) (Filter, error) {
+	left, err := p.parseAnd(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekKeyword("or") {
+		right, err := p.parseAnd(prefix)
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd(prefix string) (Filter, error) {
+	left, err := p.parseUnary(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekKeyword("and") {
+		right, err := p.parseUnary(prefix)
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{and: true, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseUnary(prefix string) (Filter, error) {
+	if p.peekKeyword("not") {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &notFilter{filter: f}, nil
+	}
+
+	t, ok := p.next()
+	if !ok {
+		return nil, invalidFilter("unexpected end of filter")
+	}
+
+	if t.text == "(" && !t.isString {
+		f, err := p.parseOr(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	if t.isString || strings.IndexByte("()[]", t.text[0]) >= 0 {
+		return nil, invalidFilter("expected attribute, got %q", t.text)
+	}
+
+	path := prefix + attributePath(t.text)
+
+	if p.pos < len(p.tokens) && p.tokens[p.pos].text == "[" && !p.tokens[p.pos].isString {
+		p.pos++
+		f, err := p.parseOr(path + ".")
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	op, ok := p.next()
+	if !ok || op.isString {
+		return nil, invalidFilter("expected operator after %q", t.text)
+	}
+	operator := strings.ToLower(op.text)
+
+	if operator == "pr" {
+		return &presentFilter{path: valueAttributePath(path)}, nil
+	}
+
+	if !compareOperators[operator] {
+		return nil, invalidFilter("unknown operator %q", op.text)
+	}
+
+	value, ok := p.next()
+	if !ok {
+		return nil, invalidFilter("expected value after %q", op.text)
+	}
+
+	f := &compareFilter{path: valueAttributePath(path), operator: operator, value: value.text}
+	if !value.isString {
+		switch strings.ToLower(value.text) {
+		case "null":
+			f.isNull = true
+		case "true", "false":
+			f.value = strings.ToLower(value.text)
+		}
+	}
+
+	return f, nil
+}
+
+// attributePath returns the lower case attribute path without schema URN.
+func attributePath(path string) string {
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		path = path[strings.LastIndex(path, ":")+1:]
+	}
+	return strings.ToLower(path)
+}
+
+// valueAttributePath returns the path of the value sub-attribute for
+// multi-valued attributes, since filtering on emails means emails.value.
+func valueAttributePath(path string) string {
+	switch path {
+	case "emails", "roles", "members", "groups":
+		return path + ".value"
+	}
+	return path
+}
@@ -0,0 +1,85 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	attrs := attributes{
+		"id":            {"12"},
+		"username":      {"Bjensen"},
+		"displayname":   {"Barbara Jensen"},
+		"active":        {"true"},
+		"externalid":    {""},
+		"emails.value":  {"bjensen@example.com", "babs@jensen.org"},
+		"emails.type":   {"work", "home"},
+		"meta.created":  {"2020-10-01T10:00:00Z"},
+		"members.value": {"1", "2"},
+	}
+
+	tcs := []struct {
+		filter  string
+		matches bool
+	}{
+		{filter: `userName eq "bjensen"`, matches: true},
+		{filter: `userName eq "jsmith"`, matches: false},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen"`, matches: true},
+		{filter: `userName ne "bjensen"`, matches: false},
+		{filter: `displayName co "jen"`, matches: true},
+		{filter: `displayName sw "barb"`, matches: true},
+		{filter: `displayName ew "sen"`, matches: true},
+		{filter: `id eq "12"`, matches: true},
+		{filter: `active eq true`, matches: true},
+		{filter: `externalId pr`, matches: false},
+		{filter: `externalId eq null`, matches: true},
+		{filter: `emails eq "babs@jensen.org"`, matches: true},
+		{filter: `emails[type eq "work" and value co "example.com"]`, matches: true},
+		{filter: `emails[type eq "other"]`, matches: false},
+		{filter: `meta.created gt "2020-09-30T00:00:00+02:00"`, matches: true},
+		{filter: `meta.created lt "2020-10-01T10:00:00Z"`, matches: false},
+		{filter: `meta.created ge "2020-10-01T10:00:00Z"`, matches: true},
+		{filter: `userName eq "jsmith" or displayName eq "barbara jensen"`, matches: true},
+		{filter: `userName eq "bjensen" and not (active eq true)`, matches: false},
+		{filter: `(userName eq "jsmith" or active eq true) and id eq "12"`, matches: true},
+		{filter: `members eq "2"`, matches: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.filter, func(t *testing.T) {
+			filter, err := ParseFilter(tc.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tc.matches, filter.matches(attrs))
+		})
+	}
+
+	t.Run("Should compare ids case sensitively", func(t *testing.T) {
+		filter, err := ParseFilter(`id eq "ABC"`)
+		require.NoError(t, err)
+		assert.False(t, filter.matches(attributes{"id": {"abc"}}))
+	})
+
+	invalid := []string{
+		`userName`,
+		`userName eq`,
+		`userName xx "bjensen"`,
+		`userName eq "bjensen`,
+		`(userName eq "bjensen"`,
+		`userName eq "bjensen" and`,
+		`emails[type eq "work"`,
+	}
+
+	for _, filter := range invalid {
+		t.Run("Should reject "+filter, func(t *testing.T) {
+			_, err := ParseFilter(filter)
+			require.Error(t, err)
+
+			scimErr, ok := err.(*Error)
+			require.True(t, ok)
+			assert.Equal(t, 400, scimErr.StatusCode())
+			assert.Equal(t, ErrorTypeInvalidFilter, scimErr.ScimType)
+		})
+	}
+}
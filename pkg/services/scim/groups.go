@@ -0,0 +1,361 @@
+package scim
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// ListGroups returns the teams of the organization matching the filter.
+func ListGroups(orgID int64, opts ListOptions) (*ListResponse, error) {
+	filter, err := parseFilter(opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.GetScimGroupsQuery{OrgId: orgID}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	// members are only loaded for all teams if they're filtered on,
+	// otherwise just for the teams in the requested page
+	filterMembers := strings.Contains(strings.ToLower(opts.Filter), "members")
+
+	groups := make([]*Group, 0)
+	for _, team := range query.Result {
+		group, err := toGroup(orgID, team, filterMembers)
+		if err != nil {
+			return nil, err
+		}
+
+		if filter == nil || filter.matches(groupAttributes(group)) {
+			groups = append(groups, group)
+		}
+	}
+
+	from, to, startIndex := opts.page(len(groups))
+	resources := make([]interface{}, 0, to-from)
+	for _, group := range groups[from:to] {
+		switch {
+		case opts.ExcludeMembers:
+			group.Members = nil
+		case !filterMembers:
+			if group.Members, err = getMembers(orgID, group.Id); err != nil {
+				return nil, err
+			}
+		}
+		resources = append(resources, group)
+	}
+
+	return newListResponse(len(groups), startIndex, resources), nil
+}
+
+// GetGroup returns the team of the organization with the id.
+func GetGroup(orgID int64, id string) (*Group, error) {
+	team, err := getTeam(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGroup(orgID, team, true)
+}
+
+// CreateGroup creates a team with the members of the group.
+func CreateGroup(orgID int64, input *Group) (*Group, error) {
+	if input.DisplayName == "" {
+		return nil, invalidValue("displayName is required")
+	}
+
+	memberIDs, err := parseMembers(orgID, input.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := models.CreateTeamCommand{OrgId: orgID, Name: input.DisplayName}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrTeamNameTaken {
+			return nil, errConflict("Group " + input.DisplayName + " already exists")
+		}
+		return nil, err
+	}
+
+	id := strconv.FormatInt(cmd.Result.Id, 10)
+	team, err := getTeam(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := updateGroup(orgID, team, nil, input, memberIDs); err != nil {
+		return nil, err
+	}
+
+	return GetGroup(orgID, id)
+}
+
+// ReplaceGroup updates the team with the attributes and members of the
+// input.
+func ReplaceGroup(orgID int64, id string, input *Group) (*Group, error) {
+	team, err := getTeam(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := getMembers(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := parseMembers(orgID, input.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := updateGroup(orgID, team, current, input, memberIDs); err != nil {
+		return nil, err
+	}
+
+	return GetGroup(orgID, id)
+}
+
+// PatchGroup updates the team with the PATCH operations.
+func PatchGroup(orgID int64, id string, patch *PatchRequest) (*Group, error) {
+	team, err := getTeam(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := toGroup(orgID, team, true)
+	if err != nil {
+		return nil, err
+	}
+	current := group.Members
+
+	if err := applyGroupPatch(group, patch); err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := parseMembers(orgID, group.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := updateGroup(orgID, team, current, group, memberIDs); err != nil {
+		return nil, err
+	}
+
+	return GetGroup(orgID, id)
+}
+
+// DeleteGroup deletes the team.
+func DeleteGroup(orgID int64, id string) error {
+	team, err := getTeam(orgID, id)
+	if err != nil {
+		return err
+	}
+
+	cmd := models.DeleteTeamCommand{OrgId: orgID, Id: team.Id}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrTeamNotFound {
+			return errNotFound("Group", id)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func getTeam(orgID int64, id string) (*models.ScimGroup, error) {
+	teamID, err := parseId("Group", id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.GetScimGroupsQuery{OrgId: orgID, TeamId: teamID}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	if len(query.Result) == 0 {
+		return nil, errNotFound("Group", id)
+	}
+
+	return query.Result[0], nil
+}
+
+func getMembers(orgID int64, id string) ([]MultiValued, error) {
+	teamID, err := parseId("Group", id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.GetTeamMembersQuery{OrgId: orgID, TeamId: teamID}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	members := make([]MultiValued, 0, len(query.Result))
+	seen := make(map[int64]bool)
+	for _, member := range query.Result {
+		if seen[member.UserId] {
+			continue
+		}
+		seen[member.UserId] = true
+
+		members = append(members, MultiValued{
+			Value:   strconv.FormatInt(member.UserId, 10),
+			Display: member.Login,
+			Ref:     location("Users", member.UserId),
+		})
+	}
+
+	return members, nil
+}
+
+// parseMembers returns the user ids of the members, which have to be members
+// of the organization.
+func parseMembers(orgID int64, members []MultiValued) ([]int64, error) {
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseInt(member.Value, 10, 64)
+		if err != nil {
+			return nil, invalidValue("Member %s is not a user of the organization", member.Value)
+		}
+		ids = append(ids, id)
+	}
+
+	query := models.GetScimUsersQuery{OrgId: orgID, UserIds: ids}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	orgUsers := make(map[int64]bool, len(query.Result))
+	for _, orgUser := range query.Result {
+		orgUsers[orgUser.Id] = true
+	}
+
+	for i, id := range ids {
+		if !orgUsers[id] {
+			return nil, invalidValue("Member %s is not a user of the organization", members[i].Value)
+		}
+	}
+
+	return ids, nil
+}
+
+func updateGroup(orgID int64, team *models.ScimGroup, current []MultiValued, input *Group, memberIDs []int64) error {
+	if input.DisplayName == "" {
+		return invalidValue("displayName is required")
+	}
+
+	if input.DisplayName != team.Name {
+		teamQuery := models.GetTeamByIdQuery{OrgId: orgID, Id: team.Id}
+		if err := bus.Dispatch(&teamQuery); err != nil {
+			return err
+		}
+
+		cmd := models.UpdateTeamCommand{OrgId: orgID, Id: team.Id, Name: input.DisplayName, Email: teamQuery.Result.Email}
+		if err := bus.Dispatch(&cmd); err != nil {
+			if err == models.ErrTeamNameTaken {
+				return errConflict("Group " + input.DisplayName + " already exists")
+			}
+			return err
+		}
+	}
+
+	if input.ExternalId != team.ExternalId {
+		cmd := models.SetScimExternalIdCommand{
+			OrgId:        orgID,
+			ResourceType: models.ScimResourceGroup,
+			ResourceId:   team.Id,
+			ExternalId:   input.ExternalId,
+		}
+		if err := bus.Dispatch(&cmd); err != nil {
+			return err
+		}
+	}
+
+	desired := make(map[int64]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		desired[id] = true
+	}
+
+	for _, member := range current {
+		id, _ := strconv.ParseInt(member.Value, 10, 64)
+		if desired[id] {
+			delete(desired, id)
+			continue
+		}
+
+		cmd := models.RemoveTeamMemberCommand{OrgId: orgID, TeamId: team.Id, UserId: id}
+		if err := bus.Dispatch(&cmd); err != nil && err != models.ErrTeamMemberNotFound {
+			return err
+		}
+	}
+
+	for _, id := range memberIDs {
+		if !desired[id] {
+			continue
+		}
+		delete(desired, id)
+
+		cmd := models.AddTeamMemberCommand{OrgId: orgID, TeamId: team.Id, UserId: id, External: true}
+		if err := bus.Dispatch(&cmd); err != nil && err != models.ErrTeamMemberAlreadyAdded {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func toGroup(orgID int64, team *models.ScimGroup, withMembers bool) (*Group, error) {
+	group := &Group{
+		Schemas:     []string{GroupSchema},
+		Id:          strconv.FormatInt(team.Id, 10),
+		ExternalId:  team.ExternalId,
+		DisplayName: team.Name,
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      team.Created.UTC(),
+			LastModified: team.Updated.UTC(),
+			Location:     location("Groups", team.Id),
+		},
+	}
+
+	if withMembers {
+		members, err := getMembers(orgID, group.Id)
+		if err != nil {
+			return nil, err
+		}
+		group.Members = members
+	}
+
+	return group, nil
+}
+
+func groupAttributes(group *Group) attributes {
+	attrs := attributes{
+		"id":                {group.Id},
+		"externalid":        {group.ExternalId},
+		"displayname":       {group.DisplayName},
+		"meta.resourcetype": {"Group"},
+	}
+
+	if group.Meta != nil {
+		attrs["meta.created"] = []string{formatTime(group.Meta.Created)}
+		attrs["meta.lastmodified"] = []string{formatTime(group.Meta.LastModified)}
+	}
+
+	for _, member := range group.Members {
+		attrs["members.value"] = append(attrs["members.value"], member.Value)
+		attrs["members.display"] = append(attrs["members.display"], member.Display)
+	}
+
+	return attrs
+}
@@ -0,0 +1,421 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// patchPath is a parsed PATCH path such as emails[type eq "work"].value,
+// see RFC 7644 section 3.5.2.
+type patchPath struct {
+	attr   string
+	filter Filter
+	sub    string
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	// strip the schema URN, but not from within a value filter
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		end := strings.IndexByte(path, '[')
+		if end < 0 {
+			end = len(path)
+		}
+		path = path[strings.LastIndex(path[:end], ":")+1:]
+	}
+
+	p := &patchPath{}
+	if start := strings.IndexByte(path, '['); start >= 0 {
+		end := strings.LastIndexByte(path, ']')
+		if end < start {
+			return nil, invalidPath(path)
+		}
+
+		filter, err := ParseFilter(path[start+1 : end])
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, ErrorTypeInvalidPath, "Invalid path "+path+": "+err.Error())
+		}
+
+		p.attr = strings.ToLower(path[:start])
+		p.filter = filter
+		rest := path[end+1:]
+		if rest != "" {
+			if rest[0] != '.' {
+				return nil, invalidPath(path)
+			}
+			p.sub = strings.ToLower(rest[1:])
+		}
+		return p, nil
+	}
+
+	parts := strings.SplitN(strings.ToLower(path), ".", 2)
+	p.attr = parts[0]
+	if len(parts) == 2 {
+		p.sub = parts[1]
+	}
+	return p, nil
+}
+
+func invalidPath(path string) *Error {
+	return NewError(http.StatusBadRequest, ErrorTypeInvalidPath, "Invalid path "+path)
+}
+
+func invalidValue(format string, args ...interface{}) *Error {
+	return NewError(http.StatusBadRequest, ErrorTypeInvalidValue, fmt.Sprintf(format, args...))
+}
+
+// operation returns the lower case operation, as some clients send Add,
+// Replace and Remove.
+func operation(op PatchOperation) (string, error) {
+	name := strings.ToLower(op.Op)
+	switch name {
+	case "add", "replace", "remove":
+		return name, nil
+	}
+	return "", NewError(http.StatusBadRequest, ErrorTypeInvalidSyntax, "Unknown operation "+op.Op)
+}
+
+// applyUserPatch applies the operations to the user resource.
+func applyUserPatch(user *User, patch *PatchRequest) error {
+	for _, op := range patch.Operations {
+		name, err := operation(op)
+		if err != nil {
+			return err
+		}
+
+		path, err := parsePatchPath(op.Path)
+		if err != nil {
+			return err
+		}
+
+		if path == nil {
+			err = applyPatchObject(name, op.Value, func(p *patchPath, value json.RawMessage) error {
+				return applyUserOperation(user, name, p, value)
+			})
+		} else {
+			err = applyUserOperation(user, name, path, op.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyGroupPatch applies the operations to the group resource.
+func applyGroupPatch(group *Group, patch *PatchRequest) error {
+	for _, op := range patch.Operations {
+		name, err := operation(op)
+		if err != nil {
+			return err
+		}
+
+		path, err := parsePatchPath(op.Path)
+		if err != nil {
+			return err
+		}
+
+		if path == nil {
+			err = applyPatchObject(name, op.Value, func(p *patchPath, value json.RawMessage) error {
+				return applyGroupOperation(group, name, p, value)
+			})
+		} else {
+			err = applyGroupOperation(group, name, path, op.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyPatchObject applies an operation without path, whose value is an
+// object with the attributes to add or replace.
+func applyPatchObject(op string, value json.RawMessage, apply func(*patchPath, json.RawMessage) error) error {
+	if op == "remove" {
+		return NewError(http.StatusBadRequest, ErrorTypeNoTarget, "Remove operation requires a path")
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(value, &values); err != nil {
+		return invalidValue("Operation without path requires an object value")
+	}
+
+	for key, value := range values {
+		path, err := parsePatchPath(key)
+		if err != nil {
+			return err
+		}
+
+		switch path.attr {
+		case "id", "schemas", "meta":
+			continue
+		}
+
+		if err := apply(path, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyUserOperation(user *User, op string, path *patchPath, value json.RawMessage) error {
+	switch path.attr {
+	case "username":
+		return patchString(op, path, value, &user.UserName)
+	case "displayname":
+		return patchString(op, path, value, &user.DisplayName)
+	case "externalid":
+		return patchString(op, path, value, &user.ExternalId)
+	case "active":
+		if op == "remove" {
+			user.Active = nil
+			return nil
+		}
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		user.Active = &active
+		return nil
+	case "name":
+		return patchName(user, op, path, value)
+	case "emails":
+		return patchMultiValued(op, path, value, &user.Emails)
+	case "roles":
+		return patchMultiValued(op, path, value, &user.Roles)
+	}
+
+	return NewError(http.StatusBadRequest, ErrorTypeInvalidPath, "Unsupported attribute "+path.attr)
+}
+
+func applyGroupOperation(group *Group, op string, path *patchPath, value json.RawMessage) error {
+	switch path.attr {
+	case "displayname":
+		return patchString(op, path, value, &group.DisplayName)
+	case "externalid":
+		return patchString(op, path, value, &group.ExternalId)
+	case "members":
+		return patchMultiValued(op, path, value, &group.Members)
+	}
+
+	return NewError(http.StatusBadRequest, ErrorTypeInvalidPath, "Unsupported attribute "+path.attr)
+}
+
+func patchString(op string, path *patchPath, value json.RawMessage, target *string) error {
+	if path.filter != nil || path.sub != "" {
+		return invalidPath(path.attr)
+	}
+
+	if op == "remove" {
+		*target = ""
+		return nil
+	}
+
+	if err := json.Unmarshal(value, target); err != nil {
+		return invalidValue("Value of %s must be a string", path.attr)
+	}
+	return nil
+}
+
+// patchName changes the name of the user. Grafana only stores one name, so
+// the display name is derived again from the changed name attributes.
+func patchName(user *User, op string, path *patchPath, value json.RawMessage) error {
+	if path.filter != nil {
+		return invalidPath(path.attr)
+	}
+
+	if user.Name == nil {
+		user.Name = &Name{}
+	}
+	user.DisplayName = ""
+
+	var target *string
+	switch path.sub {
+	case "":
+		if op == "remove" {
+			user.Name = nil
+			return nil
+		}
+		name := Name{}
+		if err := json.Unmarshal(value, &name); err != nil {
+			return invalidValue("Value of name must be an object")
+		}
+		user.Name = &name
+		return nil
+	case "formatted":
+		target = &user.Name.Formatted
+	case "givenname":
+		target = &user.Name.GivenName
+		user.Name.Formatted = ""
+	case "familyname":
+		target = &user.Name.FamilyName
+		user.Name.Formatted = ""
+	default:
+		return invalidPath("name." + path.sub)
+	}
+
+	return patchString(op, &patchPath{attr: "name." + path.sub}, value, target)
+}
+
+// patchMultiValued changes a multi-valued attribute. Entries can be added,
+// replaced or removed as a whole, or the sub-attributes of the entries
+// matching a filter can be changed.
+func patchMultiValued(op string, path *patchPath, value json.RawMessage, entries *[]MultiValued) error {
+	if path.sub != "" && path.sub != "value" && path.sub != "display" {
+		return invalidPath(path.attr + "." + path.sub)
+	}
+
+	if path.filter == nil && path.sub == "" {
+		if op == "remove" {
+			// some clients send the entries to remove as value
+			removed, err := parseEntries(value)
+			if err != nil || len(removed) == 0 {
+				*entries = nil
+				return nil
+			}
+			*entries = removeEntries(*entries, func(entry MultiValued) bool {
+				for _, r := range removed {
+					if r.Value == entry.Value {
+						return true
+					}
+				}
+				return false
+			})
+			return nil
+		}
+
+		values, err := parseEntries(value)
+		if err != nil {
+			return invalidValue("Value of %s must be a list of objects", path.attr)
+		}
+
+		if op == "replace" {
+			*entries = nil
+		}
+		for _, v := range values {
+			*entries = addEntry(*entries, v)
+		}
+		return nil
+	}
+
+	matches := func(entry MultiValued) bool {
+		return path.filter == nil || path.filter.matches(entryAttributes(entry))
+	}
+
+	if path.sub == "" {
+		if op != "remove" {
+			return invalidPath(path.attr)
+		}
+		*entries = removeEntries(*entries, matches)
+		return nil
+	}
+
+	var sub string
+	if op != "remove" {
+		if err := json.Unmarshal(value, &sub); err != nil {
+			return invalidValue("Value of %s.%s must be a string", path.attr, path.sub)
+		}
+	}
+
+	found := false
+	for i := range *entries {
+		if !matches((*entries)[i]) {
+			continue
+		}
+		found = true
+		setEntrySub(&(*entries)[i], path.sub, sub)
+	}
+
+	if !found && op != "remove" {
+		// add an entry, e.g. for emails[type eq "work"].value
+		entry := MultiValued{Primary: len(*entries) == 0}
+		setEntrySub(&entry, path.sub, sub)
+		*entries = append(*entries, entry)
+	}
+
+	if op == "remove" && path.sub == "value" {
+		*entries = removeEntries(*entries, func(entry MultiValued) bool { return entry.Value == "" })
+	}
+
+	return nil
+}
+
+func setEntrySub(entry *MultiValued, sub string, value string) {
+	if sub == "display" {
+		entry.Display = value
+		return
+	}
+	entry.Value = value
+}
+
+// parseEntries parses a list of entries, or a single entry as some clients
+// send for single values.
+func parseEntries(value json.RawMessage) ([]MultiValued, error) {
+	var entries []MultiValued
+	if err := json.Unmarshal(value, &entries); err == nil {
+		return entries, nil
+	}
+
+	var entry MultiValued
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil, err
+	}
+	return []MultiValued{entry}, nil
+}
+
+func addEntry(entries []MultiValued, entry MultiValued) []MultiValued {
+	for i := range entries {
+		if entries[i].Value == entry.Value {
+			entries[i] = entry
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+func removeEntries(entries []MultiValued, remove func(MultiValued) bool) []MultiValued {
+	result := make([]MultiValued, 0, len(entries))
+	for _, entry := range entries {
+		if !remove(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+func entryAttributes(entry MultiValued) attributes {
+	return attributes{
+		"value":   {entry.Value},
+		"display": {entry.Display},
+		"type":    {entry.Type},
+		"primary": {strconv.FormatBool(entry.Primary)},
+	}
+}
+
+// parseBool parses a boolean value, which some clients send as a string
+// such as "False".
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b, nil
+		}
+	}
+
+	return false, invalidValue("Value of active must be a boolean")
+}
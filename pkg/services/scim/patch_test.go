@@ -0,0 +1,165 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyUserPatch(t *testing.T) {
+	newUser := func() *User {
+		active := true
+		return &User{
+			UserName:    "bjensen",
+			DisplayName: "Barbara Jensen",
+			Name:        &Name{Formatted: "Barbara Jensen"},
+			Active:      &active,
+			Emails:      []MultiValued{{Value: "bjensen@example.com", Type: "work", Primary: true}},
+			Roles:       []MultiValued{{Value: "Viewer", Primary: true}},
+		}
+	}
+
+	patch := func(t *testing.T, user *User, body string) error {
+		request := PatchRequest{}
+		require.NoError(t, json.Unmarshal([]byte(body), &request))
+		return applyUserPatch(user, &request)
+	}
+
+	t.Run("Should replace attributes with a path", func(t *testing.T) {
+		user := newUser()
+		err := patch(t, user, `{"Operations": [
+			{"op": "Replace", "path": "userName", "value": "babs"},
+			{"op": "replace", "path": "active", "value": "False"},
+			{"op": "replace", "path": "roles[primary eq true].value", "value": "Editor"}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "babs", user.UserName)
+		assert.False(t, *user.Active)
+		assert.Equal(t, []MultiValued{{Value: "Editor", Primary: true}}, user.Roles)
+	})
+
+	t.Run("Should replace attributes without a path", func(t *testing.T) {
+		user := newUser()
+		err := patch(t, user, `{"Operations": [
+			{"op": "replace", "value": {"id": "1", "externalId": "ext-1", "active": false}}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "ext-1", user.ExternalId)
+		assert.False(t, *user.Active)
+	})
+
+	t.Run("Should change the name and derive the display name", func(t *testing.T) {
+		user := newUser()
+		err := patch(t, user, `{"Operations": [
+			{"op": "replace", "path": "name.givenName", "value": "Babs"},
+			{"op": "replace", "path": "name.familyName", "value": "Jensen"}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Babs Jensen", displayName(user))
+	})
+
+	t.Run("Should replace the value of a filtered email", func(t *testing.T) {
+		user := newUser()
+		err := patch(t, user, `{"Operations": [
+			{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "babs@example.com"}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "babs@example.com", primaryValue(user.Emails))
+		assert.Len(t, user.Emails, 1)
+	})
+
+	t.Run("Should remove filtered emails", func(t *testing.T) {
+		user := newUser()
+		err := patch(t, user, `{"Operations": [
+			{"op": "remove", "path": "emails[type eq \"work\"]"}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Empty(t, user.Emails)
+	})
+
+	t.Run("Should reject unknown operations and attributes", func(t *testing.T) {
+		err := patch(t, newUser(), `{"Operations": [{"op": "move", "path": "userName", "value": "babs"}]}`)
+		require.Error(t, err)
+		assert.Equal(t, ErrorTypeInvalidSyntax, err.(*Error).ScimType)
+
+		err = patch(t, newUser(), `{"Operations": [{"op": "replace", "path": "nickName", "value": "babs"}]}`)
+		require.Error(t, err)
+		assert.Equal(t, ErrorTypeInvalidPath, err.(*Error).ScimType)
+
+		err = patch(t, newUser(), `{"Operations": [{"op": "remove"}]}`)
+		require.Error(t, err)
+		assert.Equal(t, ErrorTypeNoTarget, err.(*Error).ScimType)
+	})
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	newGroup := func() *Group {
+		return &Group{
+			DisplayName: "Engineering",
+			Members:     []MultiValued{{Value: "1"}, {Value: "2"}},
+		}
+	}
+
+	patch := func(t *testing.T, group *Group, body string) error {
+		request := PatchRequest{}
+		require.NoError(t, json.Unmarshal([]byte(body), &request))
+		return applyGroupPatch(group, &request)
+	}
+
+	members := func(group *Group) []string {
+		values := make([]string, 0, len(group.Members))
+		for _, member := range group.Members {
+			values = append(values, member.Value)
+		}
+		return values
+	}
+
+	t.Run("Should add members", func(t *testing.T) {
+		group := newGroup()
+		err := patch(t, group, `{"Operations": [
+			{"op": "add", "path": "members", "value": [{"value": "2"}, {"value": "3"}]}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"1", "2", "3"}, members(group))
+	})
+
+	t.Run("Should remove a filtered member", func(t *testing.T) {
+		group := newGroup()
+		err := patch(t, group, `{"Operations": [
+			{"op": "remove", "path": "members[value eq \"1\"]"}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"2"}, members(group))
+	})
+
+	t.Run("Should remove members sent as value", func(t *testing.T) {
+		group := newGroup()
+		err := patch(t, group, `{"Operations": [
+			{"op": "remove", "path": "members", "value": [{"value": "2"}]}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"1"}, members(group))
+	})
+
+	t.Run("Should replace all members and the display name", func(t *testing.T) {
+		group := newGroup()
+		err := patch(t, group, `{"Operations": [
+			{"op": "replace", "path": "members", "value": [{"value": "5"}]},
+			{"op": "replace", "path": "displayName", "value": "Platform"}
+		]}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"5"}, members(group))
+		assert.Equal(t, "Platform", group.DisplayName)
+	})
+}
@@ -0,0 +1,102 @@
+// Package scim implements the SCIM 2.0 provisioning of users and groups
+// (RFC 7643, RFC 7644). SCIM users are the members of an organization and
+// SCIM groups its teams.
+package scim
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// DefaultCount is the page size if the client doesn't request one.
+	DefaultCount = 100
+	// MaxCount is the maximum page size.
+	MaxCount = 1000
+)
+
+// ListOptions are the query parameters of a list request.
+type ListOptions struct {
+	Filter     string
+	StartIndex int
+	Count      int
+	// ExcludeMembers is set if the members of groups are not requested
+	// with excludedAttributes=members.
+	ExcludeMembers bool
+}
+
+// page returns the range of the resources in the requested page, as well as
+// the normalized start index.
+func (o ListOptions) page(total int) (from int, to int, startIndex int) {
+	from, count, startIndex := o.limit()
+	if from > total {
+		from = total
+	}
+	to = from + count
+	if to > total {
+		to = total
+	}
+
+	return from, to, startIndex
+}
+
+// limit returns the offset and the size of the requested page, as well as
+// the normalized start index.
+func (o ListOptions) limit() (offset int, count int, startIndex int) {
+	startIndex = o.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	count = o.Count
+	if count < 0 {
+		count = 0
+	}
+	if count > MaxCount {
+		count = MaxCount
+	}
+
+	return startIndex - 1, count, startIndex
+}
+
+func newListResponse(total int, startIndex int, resources []interface{}) *ListResponse {
+	return &ListResponse{
+		Schemas:      []string{ListResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func parseFilter(filter string) (Filter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+	return ParseFilter(filter)
+}
+
+// parseId parses the id of a resource, which are Grafana's numeric ids.
+func parseId(resourceType string, id string) (int64, error) {
+	value, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, errNotFound(resourceType, id)
+	}
+	return value, nil
+}
+
+func location(endpoint string, id int64) string {
+	return setting.AppUrl + "scim/v2/" + endpoint + "/" + strconv.FormatInt(id, 10)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func errConflict(detail string) *Error {
+	return NewError(http.StatusConflict, ErrorTypeUniqueness, detail)
+}
@@ -0,0 +1,187 @@
+package scim
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestProvisioning(t *testing.T) {
+	sqlstore.InitTestDB(t)
+
+	createOrg := func(name string) int64 {
+		cmd := models.CreateOrgCommand{Name: name}
+		require.NoError(t, bus.Dispatch(&cmd))
+		return cmd.Result.Id
+	}
+
+	orgID := createOrg("provisioned")
+	otherOrgID := createOrg("other")
+
+	t.Run("Should create and update users of the organization", func(t *testing.T) {
+		user, err := CreateUser(orgID, &User{
+			UserName:   "bjensen",
+			ExternalId: "ext-bjensen",
+			Name:       &Name{GivenName: "Barbara", FamilyName: "Jensen"},
+			Emails:     []MultiValued{{Value: "bjensen@example.com", Primary: true}},
+			Roles:      []MultiValued{{Value: "editor"}},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "bjensen", user.UserName)
+		assert.Equal(t, "ext-bjensen", user.ExternalId)
+		assert.Equal(t, "Barbara Jensen", user.DisplayName)
+		assert.Equal(t, "bjensen@example.com", primaryValue(user.Emails))
+		assert.Equal(t, "Editor", primaryValue(user.Roles))
+		assert.True(t, *user.Active)
+
+		_, err = CreateUser(orgID, &User{UserName: "bjensen"})
+		require.Error(t, err)
+		assert.Equal(t, 409, err.(*Error).StatusCode())
+
+		list, err := ListUsers(orgID, ListOptions{Filter: `externalId eq "ext-bjensen"`, Count: DefaultCount})
+		require.NoError(t, err)
+		require.Equal(t, 1, list.TotalResults)
+
+		list, err = ListUsers(otherOrgID, ListOptions{Count: DefaultCount})
+		require.NoError(t, err)
+		require.Equal(t, 0, list.TotalResults)
+
+		active := false
+		user, err = ReplaceUser(orgID, user.Id, &User{
+			UserName: "babs",
+			Emails:   []MultiValued{{Value: "babs@example.com"}},
+			Active:   &active,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "babs", user.UserName)
+		assert.Equal(t, "", user.ExternalId)
+		assert.Equal(t, "Editor", primaryValue(user.Roles))
+		assert.False(t, *user.Active)
+	})
+
+	t.Run("Should only change the role of users of other organizations", func(t *testing.T) {
+		cmd := models.CreateUserCommand{Login: "shared", Email: "shared@example.com", OrgId: otherOrgID}
+		require.NoError(t, bus.Dispatch(&cmd))
+
+		user, err := CreateUser(orgID, &User{UserName: "shared", Roles: []MultiValued{{Value: "Viewer"}}})
+		require.NoError(t, err)
+		assert.Equal(t, cmd.Result.Id, mustParseId(t, user.Id))
+
+		active := false
+		user, err = ReplaceUser(orgID, user.Id, &User{
+			UserName: "renamed",
+			Active:   &active,
+			Roles:    []MultiValued{{Value: "Admin"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "shared", user.UserName)
+		assert.True(t, *user.Active)
+		assert.Equal(t, "Admin", primaryValue(user.Roles))
+
+		require.NoError(t, DeleteUser(orgID, user.Id))
+
+		query := models.GetUserByIdQuery{Id: cmd.Result.Id}
+		require.NoError(t, bus.Dispatch(&query))
+	})
+
+	t.Run("Should create teams with members", func(t *testing.T) {
+		member, err := CreateUser(orgID, &User{UserName: "member"})
+		require.NoError(t, err)
+		outsider := models.CreateUserCommand{Login: "outsider", OrgId: otherOrgID}
+		require.NoError(t, bus.Dispatch(&outsider))
+
+		for _, value := range []string{"999", "invalid", strconv.FormatInt(outsider.Result.Id, 10)} {
+			_, err = CreateGroup(orgID, &Group{
+				DisplayName: "Invalid",
+				Members:     []MultiValued{{Value: member.Id}, {Value: value}},
+			})
+			require.Error(t, err)
+			assert.Equal(t, 400, err.(*Error).StatusCode())
+		}
+
+		group, err := CreateGroup(orgID, &Group{
+			DisplayName: "Engineering",
+			ExternalId:  "ext-engineering",
+			Members:     []MultiValued{{Value: member.Id}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "ext-engineering", group.ExternalId)
+		require.Len(t, group.Members, 1)
+		assert.Equal(t, member.Id, group.Members[0].Value)
+
+		list, err := ListGroups(orgID, ListOptions{Filter: `members eq "` + member.Id + `"`, Count: DefaultCount})
+		require.NoError(t, err)
+		require.Equal(t, 1, list.TotalResults)
+
+		group, err = PatchGroup(orgID, group.Id, &PatchRequest{Operations: []PatchOperation{
+			{Op: "remove", Path: `members[value eq "` + member.Id + `"]`},
+			{Op: "replace", Path: "displayName", Value: []byte(`"Platform"`)},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "Platform", group.DisplayName)
+		assert.Empty(t, group.Members)
+
+		require.NoError(t, DeleteGroup(orgID, group.Id))
+		_, err = GetGroup(orgID, group.Id)
+		require.Error(t, err)
+		assert.Equal(t, 404, err.(*Error).StatusCode())
+	})
+
+	t.Run("Should delete users that are only members of the organization", func(t *testing.T) {
+		user, err := CreateUser(orgID, &User{UserName: "orphan"})
+		require.NoError(t, err)
+
+		require.NoError(t, DeleteUser(orgID, user.Id))
+
+		query := models.GetUserByIdQuery{Id: mustParseId(t, user.Id)}
+		require.Equal(t, models.ErrUserNotFound, bus.Dispatch(&query))
+	})
+
+	t.Run("Should filter and page users", func(t *testing.T) {
+		pagedOrgID := createOrg("paged")
+		for _, userName := range []string{"alice", "bob", "carol"} {
+			_, err := CreateUser(pagedOrgID, &User{UserName: userName, ExternalId: "ext-" + userName})
+			require.NoError(t, err)
+		}
+
+		list, err := ListUsers(pagedOrgID, ListOptions{StartIndex: 2, Count: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 3, list.TotalResults)
+		assert.Equal(t, 2, list.StartIndex)
+		require.Len(t, list.Resources, 1)
+		assert.Equal(t, "bob", list.Resources[0].(*User).UserName)
+
+		list, err = ListUsers(pagedOrgID, ListOptions{Count: 0})
+		require.NoError(t, err)
+		assert.Equal(t, 3, list.TotalResults)
+		assert.Empty(t, list.Resources)
+
+		list, err = ListUsers(pagedOrgID, ListOptions{Filter: `userName eq "CAROL"`, Count: DefaultCount})
+		require.NoError(t, err)
+		require.Equal(t, 1, list.TotalResults)
+		assert.Equal(t, "carol", list.Resources[0].(*User).UserName)
+
+		list, err = ListUsers(pagedOrgID, ListOptions{Filter: `externalId eq "EXT-carol"`, Count: DefaultCount})
+		require.NoError(t, err)
+		assert.Equal(t, 0, list.TotalResults)
+
+		list, err = ListUsers(pagedOrgID, ListOptions{Filter: `userName sw "a" or userName eq "bob"`, StartIndex: 2, Count: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 2, list.TotalResults)
+		require.Len(t, list.Resources, 1)
+		assert.Equal(t, "bob", list.Resources[0].(*User).UserName)
+	})
+}
+
+func mustParseId(t *testing.T, id string) int64 {
+	value, err := parseId("User", id)
+	require.NoError(t, err)
+	return value
+}
@@ -0,0 +1,468 @@
+package scim
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// ListUsers returns the members of the organization matching the filter.
+// Filters on userName or externalId with the eq operator, which identity
+// providers use to look up users, and the page are applied by the database.
+// Other filters are applied to all members of the organization.
+func ListUsers(orgID int64, opts ListOptions) (*ListResponse, error) {
+	filter, err := parseFilter(opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.GetScimUsersQuery{OrgId: orgID}
+	if !queryUserFilter(filter, &query) {
+		return filterUsers(query, filter, opts)
+	}
+
+	offset, count, startIndex := opts.limit()
+	query.Offset = offset
+	query.Limit = count
+	if count == 0 {
+		// count=0 only asks for the number of matching users
+		query.Limit = 1
+	}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	users := make([]interface{}, 0, len(query.Result))
+	if count > 0 {
+		for _, orgUser := range query.Result {
+			users = append(users, toUser(orgUser))
+		}
+	}
+
+	return newListResponse(int(query.TotalCount), startIndex, users), nil
+}
+
+// queryUserFilter sets the query condition of the filter and returns false
+// if the filter can't be applied by the database.
+func queryUserFilter(filter Filter, query *models.GetScimUsersQuery) bool {
+	if filter == nil {
+		return true
+	}
+
+	compare, ok := filter.(*compareFilter)
+	if !ok || compare.operator != "eq" || compare.isNull || compare.value == "" {
+		return false
+	}
+
+	switch compare.path {
+	case "username":
+		query.Login = compare.value
+	case "externalid":
+		query.ExternalId = compare.value
+	default:
+		return false
+	}
+
+	return true
+}
+
+func filterUsers(query models.GetScimUsersQuery, filter Filter, opts ListOptions) (*ListResponse, error) {
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	users := make([]interface{}, 0)
+	for _, orgUser := range query.Result {
+		user := toUser(orgUser)
+		if filter.matches(userAttributes(user)) {
+			users = append(users, user)
+		}
+	}
+
+	from, to, startIndex := opts.page(len(users))
+	return newListResponse(len(users), startIndex, users[from:to]), nil
+}
+
+// GetUser returns the member of the organization with the id.
+func GetUser(orgID int64, id string) (*User, error) {
+	orgUser, err := getOrgUser(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUser(orgUser), nil
+}
+
+// CreateUser creates the user and adds it to the organization. If a user
+// with the same login or email exists, e.g. because it has already logged in
+// or is a member of another organization, it's added to the organization
+// instead.
+func CreateUser(orgID int64, input *User) (*User, error) {
+	if input.UserName == "" {
+		return nil, invalidValue("userName is required")
+	}
+
+	role, err := parseRole(input.Roles, models.RoleType(setting.AutoAssignOrgRole))
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := findUser(input.UserName, primaryValue(input.Emails))
+	if err != nil {
+		return nil, err
+	}
+
+	var userID int64
+	if existing != nil {
+		userID = existing.Id
+	} else {
+		cmd := models.CreateUserCommand{
+			Login:        input.UserName,
+			Email:        primaryValue(input.Emails),
+			Name:         displayName(input),
+			IsDisabled:   input.Active != nil && !*input.Active,
+			SkipOrgSetup: true,
+		}
+		if err := bus.Dispatch(&cmd); err != nil {
+			return nil, err
+		}
+		userID = cmd.Result.Id
+	}
+
+	addCmd := models.AddOrgUserCommand{OrgId: orgID, UserId: userID, Role: role}
+	if err := bus.Dispatch(&addCmd); err != nil {
+		if err == models.ErrOrgUserAlreadyAdded {
+			return nil, errConflict("User " + input.UserName + " already exists")
+		}
+		return nil, err
+	}
+
+	orgUser, err := getOrgUser(orgID, strconv.FormatInt(userID, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := updateUser(orgID, orgUser, input); err != nil {
+		return nil, err
+	}
+
+	return GetUser(orgID, strconv.FormatInt(userID, 10))
+}
+
+// ReplaceUser updates the user with the attributes of the input.
+func ReplaceUser(orgID int64, id string, input *User) (*User, error) {
+	orgUser, err := getOrgUser(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := updateUser(orgID, orgUser, input); err != nil {
+		return nil, err
+	}
+
+	return GetUser(orgID, id)
+}
+
+// PatchUser updates the user with the PATCH operations.
+func PatchUser(orgID int64, id string, patch *PatchRequest) (*User, error) {
+	orgUser, err := getOrgUser(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user := toUser(orgUser)
+	if err := applyUserPatch(user, patch); err != nil {
+		return nil, err
+	}
+
+	if err := updateUser(orgID, orgUser, user); err != nil {
+		return nil, err
+	}
+
+	return GetUser(orgID, id)
+}
+
+// DeleteUser removes the user from the organization, and deletes it if it's
+// not a member of any other organization.
+func DeleteUser(orgID int64, id string) error {
+	orgUser, err := getOrgUser(orgID, id)
+	if err != nil {
+		return err
+	}
+
+	cmd := models.RemoveOrgUserCommand{
+		OrgId:                    orgID,
+		UserId:                   orgUser.Id,
+		ShouldDeleteOrphanedUser: !orgUser.IsAdmin,
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrLastOrgAdmin {
+			return NewError(http.StatusBadRequest, ErrorTypeMutability, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+func getOrgUser(orgID int64, id string) (*models.ScimUser, error) {
+	userID, err := parseId("User", id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.GetScimUsersQuery{OrgId: orgID, UserId: userID}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	if len(query.Result) == 0 {
+		return nil, errNotFound("User", id)
+	}
+
+	return query.Result[0], nil
+}
+
+func findUser(login string, email string) (*models.User, error) {
+	for _, loginOrEmail := range []string{login, email} {
+		if loginOrEmail == "" {
+			continue
+		}
+
+		query := models.GetUserByLoginQuery{LoginOrEmail: loginOrEmail}
+		if err := bus.Dispatch(&query); err != nil {
+			if err == models.ErrUserNotFound {
+				continue
+			}
+			return nil, err
+		}
+		return query.Result, nil
+	}
+
+	return nil, nil
+}
+
+// updateUser applies the attributes of the input to the member. The role and
+// external id are always updated, since they belong to the organization. The
+// attributes of the user itself are only updated if the user isn't a member
+// of other organizations or a server admin, so that an organization can't
+// change users it doesn't own.
+func updateUser(orgID int64, orgUser *models.ScimUser, input *User) error {
+	if len(input.Roles) > 0 {
+		role, err := parseRole(input.Roles, orgUser.Role)
+		if err != nil {
+			return err
+		}
+
+		if role != orgUser.Role {
+			cmd := models.UpdateOrgUserCommand{OrgId: orgID, UserId: orgUser.Id, Role: role}
+			if err := bus.Dispatch(&cmd); err != nil {
+				if err == models.ErrLastOrgAdmin {
+					return NewError(http.StatusBadRequest, ErrorTypeMutability, err.Error())
+				}
+				return err
+			}
+		}
+	}
+
+	if input.ExternalId != orgUser.ExternalId {
+		cmd := models.SetScimExternalIdCommand{
+			OrgId:        orgID,
+			ResourceType: models.ScimResourceUser,
+			ResourceId:   orgUser.Id,
+			ExternalId:   input.ExternalId,
+		}
+		if err := bus.Dispatch(&cmd); err != nil {
+			return err
+		}
+	}
+
+	owned, err := isOwnedUser(orgID, orgUser)
+	if err != nil || !owned {
+		return err
+	}
+
+	if input.UserName == "" {
+		return invalidValue("userName is required")
+	}
+
+	email := primaryValue(input.Emails)
+	name := displayName(input)
+	if input.UserName != orgUser.Login || email != orgUser.Email || name != orgUser.Name {
+		if err := updateUserAttributes(orgUser, input.UserName, email, name); err != nil {
+			return err
+		}
+	}
+
+	if input.Active != nil && *input.Active == orgUser.IsDisabled {
+		cmd := models.DisableUserCommand{UserId: orgUser.Id, IsDisabled: !*input.Active}
+		if err := bus.Dispatch(&cmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isOwnedUser(orgID int64, orgUser *models.ScimUser) (bool, error) {
+	if orgUser.IsAdmin {
+		return false, nil
+	}
+
+	query := models.GetUserOrgListQuery{UserId: orgUser.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		return false, err
+	}
+
+	for _, org := range query.Result {
+		if org.OrgId != orgID {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func updateUserAttributes(orgUser *models.ScimUser, login string, email string, name string) error {
+	for _, loginOrEmail := range []string{login, email} {
+		if loginOrEmail == "" {
+			continue
+		}
+
+		existing, err := findUser(loginOrEmail, "")
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.Id != orgUser.Id {
+			return errConflict("User with login or email " + loginOrEmail + " already exists")
+		}
+	}
+
+	userQuery := models.GetUserByIdQuery{Id: orgUser.Id}
+	if err := bus.Dispatch(&userQuery); err != nil {
+		return err
+	}
+
+	cmd := models.UpdateUserCommand{
+		UserId: orgUser.Id,
+		Login:  login,
+		Email:  email,
+		Name:   name,
+		Theme:  userQuery.Result.Theme,
+	}
+	return bus.Dispatch(&cmd)
+}
+
+func toUser(orgUser *models.ScimUser) *User {
+	active := !orgUser.IsDisabled
+	user := &User{
+		Schemas:     []string{UserSchema},
+		Id:          strconv.FormatInt(orgUser.Id, 10),
+		ExternalId:  orgUser.ExternalId,
+		UserName:    orgUser.Login,
+		DisplayName: orgUser.Name,
+		Active:      &active,
+		Roles:       []MultiValued{{Value: string(orgUser.Role), Primary: true}},
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      orgUser.Created.UTC(),
+			LastModified: orgUser.Updated.UTC(),
+			Location:     location("Users", orgUser.Id),
+		},
+	}
+
+	if orgUser.Name != "" {
+		user.Name = &Name{Formatted: orgUser.Name}
+	}
+
+	if orgUser.Email != "" {
+		user.Emails = []MultiValued{{Value: orgUser.Email, Type: "work", Primary: true}}
+	}
+
+	return user
+}
+
+func userAttributes(user *User) attributes {
+	attrs := attributes{
+		"id":                {user.Id},
+		"externalid":        {user.ExternalId},
+		"username":          {user.UserName},
+		"displayname":       {user.DisplayName},
+		"active":            {strconv.FormatBool(user.Active != nil && *user.Active)},
+		"meta.resourcetype": {"User"},
+	}
+
+	if user.Name != nil {
+		attrs["name.formatted"] = []string{user.Name.Formatted}
+	}
+
+	if user.Meta != nil {
+		attrs["meta.created"] = []string{formatTime(user.Meta.Created)}
+		attrs["meta.lastmodified"] = []string{formatTime(user.Meta.LastModified)}
+	}
+
+	for prefix, entries := range map[string][]MultiValued{"emails": user.Emails, "roles": user.Roles} {
+		for _, entry := range entries {
+			for key, values := range entryAttributes(entry) {
+				attrs[prefix+"."+key] = append(attrs[prefix+"."+key], values...)
+			}
+		}
+	}
+
+	return attrs
+}
+
+// displayName returns the name to store for the user, which is the display
+// name or else the formatted or given and family name.
+func displayName(user *User) string {
+	if user.DisplayName != "" {
+		return user.DisplayName
+	}
+
+	if user.Name == nil {
+		return ""
+	}
+
+	if user.Name.Formatted != "" {
+		return user.Name.Formatted
+	}
+
+	return strings.TrimSpace(user.Name.GivenName + " " + user.Name.FamilyName)
+}
+
+// primaryValue returns the value of the primary entry, or else the first.
+func primaryValue(entries []MultiValued) string {
+	for _, entry := range entries {
+		if entry.Primary {
+			return entry.Value
+		}
+	}
+
+	if len(entries) > 0 {
+		return entries[0].Value
+	}
+
+	return ""
+}
+
+// parseRole returns the organization role from the primary entry of roles,
+// or the fallback if there are no roles.
+func parseRole(roles []MultiValued, fallback models.RoleType) (models.RoleType, error) {
+	value := primaryValue(roles)
+	if value == "" {
+		return fallback, nil
+	}
+
+	for _, role := range []models.RoleType{models.ROLE_VIEWER, models.ROLE_EDITOR, models.ROLE_ADMIN} {
+		if strings.EqualFold(value, string(role)) {
+			return role, nil
+		}
+	}
+
+	return "", invalidValue("Invalid role %s, must be one of Viewer, Editor or Admin", value)
+}
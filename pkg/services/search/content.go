@@ -0,0 +1,205 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	maxContentNameLength = 255
+	maxContentTermLength = 190
+)
+
+// queryKeys are the keys of panel targets and annotations that hold query
+// expressions in the core data sources.
+var queryKeys = []string{
+	"expr",
+	"expression",
+	"query",
+	"queryText",
+	"rawSql",
+	"target",
+	"measurement",
+	"metric",
+	"metricName",
+}
+
+// ExtractContent returns the searchable content of a dashboard: the titles
+// and descriptions of its panels, the queries of its panels and annotations,
+// its template variables and the data sources they use. Panels and query
+// variables without a data source use defaultDataSource.
+func ExtractContent(data *simplejson.Json, defaultDataSource string) []*models.DashboardContent {
+	e := &contentExtractor{defaultDataSource: defaultDataSource, dataSources: map[string]bool{}}
+
+	if description := data.Get("description").MustString(); description != "" {
+		e.add(0, models.DashboardContentDescription, data.Get("title").MustString(), description)
+	}
+
+	e.panels(data.Get("panels").MustArray())
+
+	for _, item := range data.Get("templating").Get("list").MustArray() {
+		variable := simplejson.NewFromAny(item)
+		name := variable.Get("name").MustString()
+
+		definition := variable.Get("definition").MustString()
+		if definition == "" {
+			definition = variable.Get("query").MustString()
+		}
+		if definition == "" {
+			definition = variable.Get("query").Get("query").MustString()
+		}
+		e.add(0, models.DashboardContentVariable, name, definition)
+
+		dataSource := variable.Get("datasource").MustString()
+		if dataSource == "" && variable.Get("type").MustString() == "query" {
+			dataSource = defaultDataSource
+		}
+		e.dataSource(0, name, dataSource)
+	}
+
+	for _, item := range data.Get("annotations").Get("list").MustArray() {
+		annotation := simplejson.NewFromAny(item)
+		if annotation.Get("builtIn").MustInt() == 1 {
+			continue
+		}
+
+		name := annotation.Get("name").MustString()
+		e.queries(0, name, annotation)
+		e.dataSource(0, name, annotation.Get("datasource").MustString())
+	}
+
+	return e.content
+}
+
+type contentExtractor struct {
+	defaultDataSource string
+	// dataSources are the data sources already added, by panel and name
+	dataSources map[string]bool
+	content     []*models.DashboardContent
+}
+
+func (e *contentExtractor) add(panelId int64, kind, name, text string) {
+	e.content = append(e.content, &models.DashboardContent{
+		PanelId: panelId,
+		Kind:    kind,
+		Name:    truncate(name, maxContentNameLength),
+		Text:    text,
+	})
+}
+
+// panels extracts the content of the panels, including the panels of
+// collapsed rows.
+func (e *contentExtractor) panels(panels []interface{}) {
+	for _, item := range panels {
+		panel := simplejson.NewFromAny(item)
+		id := panel.Get("id").MustInt64()
+		title := panel.Get("title").MustString()
+
+		if title != "" {
+			e.add(id, models.DashboardContentPanelTitle, title, title)
+		}
+		if description := panel.Get("description").MustString(); description != "" {
+			e.add(id, models.DashboardContentDescription, title, description)
+		}
+
+		targets := panel.Get("targets").MustArray()
+		for _, target := range targets {
+			e.queries(id, title, simplejson.NewFromAny(target))
+		}
+
+		dataSource := panel.Get("datasource").MustString()
+		if (dataSource == "" || dataSource == "default") && len(targets) > 0 {
+			dataSource = e.defaultDataSource
+		}
+		e.dataSource(id, title, dataSource)
+		for _, target := range targets {
+			e.dataSource(id, title, simplejson.NewFromAny(target).Get("datasource").MustString())
+		}
+
+		e.panels(panel.Get("panels").MustArray())
+	}
+}
+
+func (e *contentExtractor) queries(panelId int64, name string, query *simplejson.Json) {
+	for _, key := range queryKeys {
+		if text := query.Get(key).MustString(); strings.TrimSpace(text) != "" {
+			e.add(panelId, models.DashboardContentQuery, name, text)
+		}
+	}
+}
+
+// dataSource adds a data source used by a panel, variable or annotation once.
+// References to data source variables and the mixed data source are skipped.
+func (e *contentExtractor) dataSource(panelId int64, name, dataSource string) {
+	if dataSource == "" || dataSource == "-- Mixed --" || strings.HasPrefix(dataSource, "$") {
+		return
+	}
+
+	key := fmt.Sprintf("%d/%s/%s", panelId, name, dataSource)
+	if e.dataSources[key] {
+		return
+	}
+	e.dataSources[key] = true
+
+	e.add(panelId, models.DashboardContentDataSource, name, dataSource)
+}
+
+// ContentTerms returns the terms the content is indexed by. Data sources are
+// indexed by their lower case name, the other content by the terms of its
+// text and, for variables, the variable name.
+func ContentTerms(content *models.DashboardContent) []string {
+	if content.Kind == models.DashboardContentDataSource {
+		return []string{truncate(strings.ToLower(content.Text), maxContentTermLength)}
+	}
+
+	text := content.Text
+	if content.Kind == models.DashboardContentVariable {
+		text = content.Name + " " + text
+	}
+
+	return QueryTerms(text)
+}
+
+// QueryTerms splits text into lower case terms. Terms are words, numbers and
+// identifiers like metric names. Dotted and colon separated identifiers, like
+// Graphite paths, are also split into their parts.
+func QueryTerms(text string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+
+	add := func(term string) {
+		term = truncate(strings.Trim(term, ".:"), maxContentTermLength)
+		if term == "" || seen[term] {
+			return
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' && r != ':'
+	})
+
+	for _, word := range words {
+		add(word)
+		if strings.ContainsAny(word, ".:") {
+			for _, part := range strings.FieldsFunc(word, func(r rune) bool { return r == '.' || r == ':' }) {
+				add(part)
+			}
+		}
+	}
+
+	return terms
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length])
+}
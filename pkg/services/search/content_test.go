@@ -0,0 +1,106 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractContent(t *testing.T) {
+	data := simplejson.NewFromAny(map[string]interface{}{
+		"title":       "Nodes",
+		"description": "Node exporter metrics",
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id":          1,
+				"title":       "CPU usage",
+				"description": "Busy CPU time",
+				"targets": []interface{}{
+					map[string]interface{}{"refId": "A", "expr": `rate(node_cpu_seconds_total{mode!="idle"}[5m])`},
+				},
+			},
+			map[string]interface{}{
+				"id":    2,
+				"type":  "row",
+				"title": "Disks",
+				"panels": []interface{}{
+					map[string]interface{}{
+						"id":         3,
+						"title":      "IOPS",
+						"datasource": "-- Mixed --",
+						"targets": []interface{}{
+							map[string]interface{}{"refId": "A", "datasource": "Graphite", "target": "servers.*.disk.iops"},
+							map[string]interface{}{"refId": "B", "datasource": "$ds", "target": "servers.*.disk.reads"},
+						},
+					},
+				},
+			},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "instance", "type": "query", "query": "label_values(node_uname_info, instance)"},
+				map[string]interface{}{"name": "ds", "type": "datasource", "query": "prometheus"},
+			},
+		},
+		"annotations": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "Annotations & Alerts", "builtIn": 1, "datasource": "-- Grafana --"},
+				map[string]interface{}{"name": "Deploys", "datasource": "Loki", "expr": `{app="deploy"}`},
+			},
+		},
+	})
+
+	content := ExtractContent(data, "Prometheus")
+
+	type item struct {
+		PanelId int64
+		Kind    string
+		Name    string
+		Text    string
+	}
+	items := make([]item, 0, len(content))
+	for _, c := range content {
+		items = append(items, item{c.PanelId, c.Kind, c.Name, c.Text})
+	}
+
+	require.Equal(t, []item{
+		{0, models.DashboardContentDescription, "Nodes", "Node exporter metrics"},
+		{1, models.DashboardContentPanelTitle, "CPU usage", "CPU usage"},
+		{1, models.DashboardContentDescription, "CPU usage", "Busy CPU time"},
+		{1, models.DashboardContentQuery, "CPU usage", `rate(node_cpu_seconds_total{mode!="idle"}[5m])`},
+		{1, models.DashboardContentDataSource, "CPU usage", "Prometheus"},
+		{2, models.DashboardContentPanelTitle, "Disks", "Disks"},
+		{3, models.DashboardContentPanelTitle, "IOPS", "IOPS"},
+		{3, models.DashboardContentQuery, "IOPS", "servers.*.disk.iops"},
+		{3, models.DashboardContentQuery, "IOPS", "servers.*.disk.reads"},
+		{3, models.DashboardContentDataSource, "IOPS", "Graphite"},
+		{0, models.DashboardContentVariable, "instance", "label_values(node_uname_info, instance)"},
+		{0, models.DashboardContentDataSource, "instance", "Prometheus"},
+		{0, models.DashboardContentVariable, "ds", "prometheus"},
+		{0, models.DashboardContentQuery, "Deploys", `{app="deploy"}`},
+		{0, models.DashboardContentDataSource, "Deploys", "Loki"},
+	}, items)
+}
+
+func TestContentTerms(t *testing.T) {
+	t.Run("Should split queries into terms and identifier parts", func(t *testing.T) {
+		terms := ContentTerms(&models.DashboardContent{
+			Kind: models.DashboardContentQuery,
+			Text: `sum(rate(node_cpu_seconds_total{job="node"}[5m])) by (instance) / servers.web1.cpu`,
+		})
+		assert.Equal(t, []string{"sum", "rate", "node_cpu_seconds_total", "job", "node", "5m", "by", "instance", "servers.web1.cpu", "servers", "web1", "cpu"}, terms)
+	})
+
+	t.Run("Should index variables by name and definition", func(t *testing.T) {
+		terms := ContentTerms(&models.DashboardContent{Kind: models.DashboardContentVariable, Name: "Instance", Text: "label_values(up, instance)"})
+		assert.Equal(t, []string{"instance", "label_values", "up"}, terms)
+	})
+
+	t.Run("Should index data sources by their name", func(t *testing.T) {
+		terms := ContentTerms(&models.DashboardContent{Kind: models.DashboardContentDataSource, Text: "Prometheus EU"})
+		assert.Equal(t, []string{"prometheus eu"}, terms)
+	})
+}
@@ -0,0 +1,40 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrInvalidCursor          = errors.New("Invalid search cursor")
+	ErrCursorSortNotSupported = errors.New("The sort option does not support cursor paging")
+)
+
+// cursor is the position of a page in the search result. It's passed to
+// the client as an opaque string.
+type cursor struct {
+	Sort    string `json:"sort"`
+	AfterId int64  `json:"afterId"`
+}
+
+func encodeCursor(sort string, afterId int64) string {
+	data, _ := json.Marshal(cursor{Sort: sort, AfterId: afterId})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the id of the last dashboard of the previous page.
+// Cursors are only valid for the sort order they were created with.
+func decodeCursor(value string, sort string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort || c.AfterId <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return c.AfterId, nil
+}
@@ -11,19 +11,69 @@ const (
 )
 
 type Hit struct {
-	Id          int64    `json:"id"`
-	Uid         string   `json:"uid"`
-	Title       string   `json:"title"`
-	Uri         string   `json:"uri"`
-	Url         string   `json:"url"`
-	Slug        string   `json:"slug"`
-	Type        HitType  `json:"type"`
-	Tags        []string `json:"tags"`
-	IsStarred   bool     `json:"isStarred"`
-	FolderId    int64    `json:"folderId,omitempty"`
-	FolderUid   string   `json:"folderUid,omitempty"`
-	FolderTitle string   `json:"folderTitle,omitempty"`
-	FolderUrl   string   `json:"folderUrl,omitempty"`
+	Id          int64        `json:"id"`
+	Uid         string       `json:"uid"`
+	Title       string       `json:"title"`
+	Uri         string       `json:"uri"`
+	Url         string       `json:"url"`
+	Slug        string       `json:"slug"`
+	Type        HitType      `json:"type"`
+	Tags        []string     `json:"tags"`
+	IsStarred   bool         `json:"isStarred"`
+	FolderId    int64        `json:"folderId,omitempty"`
+	FolderUid   string       `json:"folderUid,omitempty"`
+	FolderTitle string       `json:"folderTitle,omitempty"`
+	FolderUrl   string       `json:"folderUrl,omitempty"`
+	FolderPath  []*HitFolder `json:"folderPath,omitempty"`
+	// Matches is the dashboard content matched by a content search
+	Matches []*ContentMatch `json:"matches,omitempty"`
+}
+
+// HitFolder is a folder in the path of a hit. The path starts with the folder
+// in the General folder and ends with the folder of the hit.
+type HitFolder struct {
+	Id    int64  `json:"id"`
+	Uid   string `json:"uid"`
+	Title string `json:"title"`
+	Url   string `json:"url"`
+}
+
+// ContentMatch is a piece of indexed dashboard content matched by a content
+// search.
+type ContentMatch struct {
+	Kind    string `json:"kind"`
+	PanelId int64  `json:"panelId,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Search facets
+const (
+	FacetTag    = "tag"
+	FacetFolder = "folder"
+	FacetType   = "type"
+)
+
+// Facets are the number of hits by tag, folder and type of all pages of a
+// search.
+type Facets struct {
+	Tags    []*FacetCount       `json:"tags,omitempty"`
+	Folders []*FolderFacetCount `json:"folders,omitempty"`
+	Types   []*FacetCount       `json:"types,omitempty"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// FolderFacetCount is the number of hits directly in a folder. The General
+// folder has id 0.
+type FolderFacetCount struct {
+	Id    int64  `json:"id"`
+	Uid   string `json:"uid"`
+	Title string `json:"title"`
+	Count int64  `json:"count"`
 }
 
 type HitList []*Hit
@@ -27,8 +27,18 @@ type Query struct {
 	FolderIds    []int64
 	Permission   models.PermissionType
 	Sort         string
-
-	Result HitList
+	Content      string
+	ContentKinds []string
+	DataSource   string
+	// CursorPaging pages through the result with cursors instead of page
+	// numbers, Cursor is empty for the first page
+	CursorPaging bool
+	Cursor       string
+	Facets       []string
+
+	Result      HitList
+	NextCursor  string
+	FacetResult *Facets
 }
 
 type FindPersistedDashboardsQuery struct {
@@ -43,10 +53,22 @@ type FindPersistedDashboardsQuery struct {
 	Limit        int64
 	Page         int64
 	Permission   models.PermissionType
+	// Content and DataSource search the indexed dashboard content
+	Content      string
+	ContentKinds []string
+	DataSource   string
+	// CursorPaging pages through the result in the order of the sort
+	// filter, starting after the dashboard with the id AfterId
+	CursorPaging bool
+	AfterId      int64
+	Facets       []string
 
 	Filters []interface{}
 
 	Result HitList
+	// HasMore is set if there are hits after the page when paging with cursors
+	HasMore     bool
+	FacetResult *Facets
 }
 
 type SearchService struct {
@@ -59,8 +81,12 @@ type SearchService struct {
 func (s *SearchService) Init() error {
 	s.Bus.AddHandler(s.searchHandler)
 	s.sortOptions = map[string]SortOption{
-		sortAlphaAsc.Name:  sortAlphaAsc,
-		sortAlphaDesc.Name: sortAlphaDesc,
+		sortAlphaAsc.Name:    sortAlphaAsc,
+		sortAlphaDesc.Name:   sortAlphaDesc,
+		sortUpdatedAsc.Name:  sortUpdatedAsc,
+		sortUpdatedDesc.Name: sortUpdatedDesc,
+		sortViewsAsc.Name:    sortViewsAsc,
+		sortViewsDesc.Name:   sortViewsDesc,
 	}
 
 	return nil
@@ -78,23 +104,47 @@ func (s *SearchService) searchHandler(query *Query) error {
 		Limit:        query.Limit,
 		Page:         query.Page,
 		Permission:   query.Permission,
+		Content:      query.Content,
+		ContentKinds: query.ContentKinds,
+		DataSource:   query.DataSource,
+		CursorPaging: query.CursorPaging,
+		Facets:       query.Facets,
 	}
 
-	if sortOpt, exists := s.sortOptions[query.Sort]; exists {
+	// cursors need a sort order of the database
+	sortName := query.Sort
+	if query.CursorPaging && sortName == "" {
+		sortName = sortAlphaAsc.Name
+	}
+
+	if sortOpt, exists := s.sortOptions[sortName]; exists {
 		for _, filter := range sortOpt.Filter {
 			dashboardQuery.Filters = append(dashboardQuery.Filters, filter)
 		}
 	}
 
+	if query.CursorPaging && query.Cursor != "" {
+		afterId, err := decodeCursor(query.Cursor, sortName)
+		if err != nil {
+			return err
+		}
+		dashboardQuery.AfterId = afterId
+	}
+
 	if err := bus.Dispatch(&dashboardQuery); err != nil {
 		return err
 	}
 
 	hits := dashboardQuery.Result
-	if query.Sort == "" {
+	if query.Sort == "" && !query.CursorPaging {
 		hits = sortedHits(hits)
 	}
 
+	if dashboardQuery.HasMore && len(hits) > 0 {
+		query.NextCursor = encodeCursor(sortName, hits[len(hits)-1].Id)
+	}
+	query.FacetResult = dashboardQuery.FacetResult
+
 	if err := setStarredDashboards(query.SignedInUser.UserId, hits); err != nil {
 		return err
 	}
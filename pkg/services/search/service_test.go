@@ -55,3 +55,39 @@ func TestSearch_SortedResults(t *testing.T) {
 	assert.Equal(t, "BB", query.Result[3].Tags[1])
 	assert.Equal(t, "EE", query.Result[3].Tags[2])
 }
+
+func TestSearch_CursorPaging(t *testing.T) {
+	var dashboardQuery *FindPersistedDashboardsQuery
+	bus.AddHandler("test", func(query *FindPersistedDashboardsQuery) error {
+		dashboardQuery = query
+		query.Result = HitList{
+			&Hit{Id: 16, Title: "CCAA", Type: "dash-db"},
+			&Hit{Id: 17, Title: "FOLDER", Type: "dash-folder"},
+		}
+		query.HasMore = true
+		return nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetUserStarsQuery) error {
+		query.Result = map[int64]bool{}
+		return nil
+	})
+
+	svc := &SearchService{Bus: bus.New()}
+	require.NoError(t, svc.Init())
+
+	query := &Query{Limit: 2, CursorPaging: true, SignedInUser: &models.SignedInUser{}}
+	require.NoError(t, svc.searchHandler(query))
+
+	// hits keep the order of the sort option
+	assert.Equal(t, "CCAA", query.Result[0].Title)
+	assert.Equal(t, []interface{}{sortAlphaAsc.Filter[0]}, dashboardQuery.Filters)
+	require.NotEmpty(t, query.NextCursor)
+
+	next := &Query{Limit: 2, CursorPaging: true, Cursor: query.NextCursor, SignedInUser: &models.SignedInUser{}}
+	require.NoError(t, svc.searchHandler(next))
+	assert.Equal(t, int64(17), dashboardQuery.AfterId)
+
+	sorted := &Query{Limit: 2, CursorPaging: true, Cursor: query.NextCursor, Sort: sortViewsDesc.Name, SignedInUser: &models.SignedInUser{}}
+	assert.Equal(t, ErrInvalidCursor, svc.searchHandler(sorted))
+}
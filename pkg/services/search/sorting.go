@@ -23,6 +23,38 @@ var (
 			searchstore.TitleSorter{Descending: true},
 		},
 	}
+	sortUpdatedAsc = SortOption{
+		Name:        "updated-asc",
+		DisplayName: "Least recently updated",
+		Description: "Sort results by the time they were last updated, oldest first",
+		Filter: []SortOptionFilter{
+			searchstore.UpdatedSorter{},
+		},
+	}
+	sortUpdatedDesc = SortOption{
+		Name:        "updated-desc",
+		DisplayName: "Recently updated",
+		Description: "Sort results by the time they were last updated, newest first",
+		Filter: []SortOptionFilter{
+			searchstore.UpdatedSorter{Descending: true},
+		},
+	}
+	sortViewsAsc = SortOption{
+		Name:        "views-asc",
+		DisplayName: "Least viewed",
+		Description: "Sort results by the number of times they were viewed, least viewed first",
+		Filter: []SortOptionFilter{
+			searchstore.ViewsSorter{},
+		},
+	}
+	sortViewsDesc = SortOption{
+		Name:        "views-desc",
+		DisplayName: "Most viewed",
+		Description: "Sort results by the number of times they were viewed, most viewed first",
+		Filter: []SortOptionFilter{
+			searchstore.ViewsSorter{Descending: true},
+		},
+	}
 )
 
 type SortOption struct {
@@ -0,0 +1,130 @@
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+)
+
+// checkInterval is how often due snapshots are refreshed. Servers skip the
+// check when another server has run it within half of the interval.
+const checkInterval = time.Minute
+
+var (
+	errUserDisabled     = errors.New("user is disabled")
+	errUserNotOrgMember = errors.New("user is not a member of the organization")
+)
+
+func init() {
+	registry.RegisterService(&RefreshService{})
+}
+
+// RefreshService refreshes the snapshots generated by the server when their
+// refresh interval has passed.
+type RefreshService struct {
+	ServerLockService *serverlock.ServerLockService `inject:""`
+
+	log log.Logger
+}
+
+func (s *RefreshService) Init() error {
+	s.log = log.New("snapshots.refresh")
+	return nil
+}
+
+func (s *RefreshService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := s.ServerLockService.LockAndExecute(ctx, "refresh dashboard snapshots", checkInterval/2, func() {
+				s.refreshDueSnapshots(ctx)
+			})
+			if err != nil {
+				s.log.Error("failed to lock and execute refresh of dashboard snapshots", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *RefreshService) refreshDueSnapshots(ctx context.Context) {
+	query := models.GetDueDashboardSnapshotsQuery{Now: time.Now()}
+	if err := bus.Dispatch(&query); err != nil {
+		s.log.Error("failed to get due dashboard snapshots", "error", err)
+		return
+	}
+
+	for _, snapshot := range query.Result {
+		if ctx.Err() != nil {
+			return
+		}
+
+		cmd, err := s.refresh(ctx, snapshot)
+		if err != nil {
+			s.log.Warn("failed to refresh dashboard snapshot", "id", snapshot.Id, "error", err)
+			continue
+		}
+		if cmd.Error != "" {
+			s.log.Warn("dashboard snapshot refreshed with query errors", "id", snapshot.Id, "version", cmd.Result.Version, "errors", cmd.Error)
+			continue
+		}
+		s.log.Debug("dashboard snapshot refreshed", "id", snapshot.Id, "version", cmd.Result.Version)
+	}
+}
+
+// refresh refreshes the snapshot as the user that created it. The refreshes
+// stop when the user is disabled, deleted or not a member of the organization
+// anymore.
+func (s *RefreshService) refresh(ctx context.Context, snapshot *models.DashboardSnapshot) (*models.RefreshDashboardSnapshotCommand, error) {
+	user, err := snapshotUser(snapshot)
+	if err != nil {
+		// record the error, which also schedules the next refresh unless the
+		// user can't refresh the snapshot anymore
+		cmd := &models.RefreshDashboardSnapshotCommand{
+			Id:          snapshot.Id,
+			Error:       "failed to get the user of the snapshot: " + err.Error(),
+			StopRefresh: errors.Is(err, models.ErrUserNotFound) || errors.Is(err, errUserDisabled) || errors.Is(err, errUserNotOrgMember),
+		}
+		if dispatchErr := bus.Dispatch(cmd); dispatchErr != nil {
+			return nil, dispatchErr
+		}
+		return nil, err
+	}
+
+	return Refresh(ctx, snapshot, user)
+}
+
+// snapshotUser returns the user that created the snapshot, signed in to the
+// organization of the snapshot.
+func snapshotUser(snapshot *models.DashboardSnapshot) (*models.SignedInUser, error) {
+	userQuery := models.GetUserByIdQuery{Id: snapshot.UserId}
+	if err := bus.Dispatch(&userQuery); err != nil {
+		return nil, err
+	}
+	// deleted users are disabled until they're restored
+	if userQuery.Result.IsDisabled {
+		return nil, errUserDisabled
+	}
+
+	signedInQuery := models.GetSignedInUserQuery{OrgId: snapshot.OrgId, UserId: snapshot.UserId}
+	if err := bus.Dispatch(&signedInQuery); err != nil {
+		return nil, err
+	}
+	// the organization is missing when the user has been removed from it, or
+	// when it's deleted
+	if signedInQuery.Result.OrgId != snapshot.OrgId {
+		return nil, errUserNotOrgMember
+	}
+
+	return signedInQuery.Result, nil
+}
@@ -0,0 +1,396 @@
+// Package snapshots generates dashboard snapshots on the server by running the
+// queries of the dashboard panels, and refreshes them periodically.
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+const (
+	maxDataPoints = 1500
+	timeFormat    = "2006-01-02T15:04:05.000Z"
+)
+
+var (
+	ErrDashboardAccessDenied = errors.New("access denied to the dashboard of the snapshot")
+	ErrNotRefreshable        = errors.New("snapshot is not generated from a dashboard")
+)
+
+// Result is a snapshot dashboard generated by the server.
+type Result struct {
+	Dashboard *simplejson.Json
+	// Errors are the errors of the queries that failed, their panels are
+	// shown without their data.
+	Errors []string
+}
+
+// Generate returns the snapshot of the dashboard in the time range, with the
+// data of the panels queried as the user. Empty times default to the time
+// range saved in the dashboard.
+func Generate(ctx context.Context, dash *models.Dashboard, user *models.SignedInUser, timeFrom, timeTo string) (*Result, error) {
+	encoded, err := dash.Data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	dashboard, err := simplejson.NewJson(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeFrom == "" {
+		timeFrom = dashboard.Get("time").Get("from").MustString("now-6h")
+	}
+	if timeTo == "" {
+		timeTo = dashboard.Get("time").Get("to").MustString("now")
+	}
+	timeRange, err := absoluteTimeRange(timeFrom, timeTo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Dashboard: dashboard, Errors: []string{}}
+	panelData := runPanelQueries(ctx, dash.OrgId, user, dashboard, timeRange, result)
+
+	scrubDashboard(dashboard, panelData)
+	dashboard.Set("time", map[string]interface{}{
+		"from": timeRange.GetFromAsTimeUTC().Format(timeFormat),
+		"to":   timeRange.GetToAsTimeUTC().Format(timeFormat),
+		"raw":  map[string]interface{}{"from": timeFrom, "to": timeTo},
+	})
+	dashboard.Set("refresh", "")
+	dashboard.Set("snapshot", map[string]interface{}{
+		"timestamp":   time.Now().UTC().Format(timeFormat),
+		"originalUrl": models.GetFullDashboardUrl(dash.Uid, dash.Slug),
+	})
+
+	return result, nil
+}
+
+// Refresh generates the snapshot again from the current version of its
+// dashboard, as the user, and stores it as the next version of the snapshot.
+// Errors are stored with the snapshot, and returned.
+func Refresh(ctx context.Context, snapshot *models.DashboardSnapshot, user *models.SignedInUser) (*models.RefreshDashboardSnapshotCommand, error) {
+	if snapshot.DashboardId == 0 || snapshot.External {
+		return nil, ErrNotRefreshable
+	}
+
+	cmd := &models.RefreshDashboardSnapshotCommand{
+		Id:             snapshot.Id,
+		VersionsToKeep: setting.SnapshotVersionsToKeep,
+	}
+
+	result, err := generateFromDashboard(ctx, snapshot, user)
+	if err != nil {
+		cmd.Error = err.Error()
+	} else {
+		cmd.Dashboard = result.Dashboard
+		cmd.Error = strings.Join(result.Errors, "\n")
+	}
+
+	if dispatchErr := bus.Dispatch(cmd); dispatchErr != nil {
+		return nil, dispatchErr
+	}
+
+	return cmd, err
+}
+
+func generateFromDashboard(ctx context.Context, snapshot *models.DashboardSnapshot, user *models.SignedInUser) (*Result, error) {
+	query := models.GetDashboardQuery{Id: snapshot.DashboardId, OrgId: snapshot.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	canView, err := guardian.New(query.Result.Id, snapshot.OrgId, user).CanView()
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrDashboardAccessDenied
+	}
+
+	return Generate(ctx, query.Result, user, snapshot.TimeFrom, snapshot.TimeTo)
+}
+
+// absoluteTimeRange resolves the relative times once, so that all queries of
+// the snapshot use the same time range.
+func absoluteTimeRange(from, to string) (*tsdb.TimeRange, error) {
+	timeRange := tsdb.NewTimeRange(from, to)
+
+	fromTime, err := timeRange.ParseFrom()
+	if err != nil {
+		return nil, fmt.Errorf("invalid time range from %q: %w", from, err)
+	}
+	toTime, err := timeRange.ParseTo()
+	if err != nil {
+		return nil, fmt.Errorf("invalid time range to %q: %w", to, err)
+	}
+	if !fromTime.Before(toTime) {
+		return nil, fmt.Errorf("invalid time range %q to %q", from, to)
+	}
+
+	return tsdb.NewTimeRange(
+		strconv.FormatInt(fromTime.UnixNano()/int64(time.Millisecond), 10),
+		strconv.FormatInt(toTime.UnixNano()/int64(time.Millisecond), 10),
+	), nil
+}
+
+type queryGroup struct {
+	panelId int64
+	ds      *models.DataSource
+	queries []*tsdb.Query
+}
+
+// runPanelQueries runs the queries of the panels, one request per panel and
+// data source, and returns the snapshot data by panel id.
+func runPanelQueries(ctx context.Context, orgID int64, user *models.SignedInUser, dashboard *simplejson.Json, timeRange *tsdb.TimeRange, result *Result) map[int64][]interface{} {
+	calculator := tsdb.NewIntervalCalculator(nil)
+
+	var groups []*queryGroup
+	for _, panelQuery := range dashboards.GetPanelQueries(dashboard) {
+		if panelQuery.Model.Get("hide").MustBool() {
+			continue
+		}
+
+		refId := panelQuery.Model.Get("refId").MustString()
+		ds, err := panelQuery.GetDataSource(orgID)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("panel %d query %s: %s", panelQuery.PanelId, refId, err))
+			continue
+		}
+
+		if granted, err := datasources.HasPermission(user, ds, models.DsPermissionQuery); err != nil || !granted {
+			if err == nil {
+				err = models.ErrDataSourceAccessDenied
+			}
+			result.Errors = append(result.Errors, fmt.Sprintf("panel %d query %s: %s", panelQuery.PanelId, refId, err))
+			continue
+		}
+
+		minInterval, err := tsdb.GetIntervalFrom(ds, panelQuery.Model, time.Millisecond)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("panel %d query %s: %s", panelQuery.PanelId, refId, err))
+			continue
+		}
+		interval := calculator.Calculate(timeRange, minInterval)
+
+		query := &tsdb.Query{
+			RefId:         refId,
+			MaxDataPoints: maxDataPoints,
+			IntervalMs:    interval.Milliseconds(),
+			QueryType:     panelQuery.Model.Get("queryType").MustString(""),
+			Model:         panelQuery.Model,
+			DataSource:    ds,
+		}
+
+		var group *queryGroup
+		for _, g := range groups {
+			if g.panelId == panelQuery.PanelId && g.ds.Id == ds.Id {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &queryGroup{panelId: panelQuery.PanelId, ds: ds}
+			groups = append(groups, group)
+		}
+		group.queries = append(group.queries, query)
+	}
+
+	panelData := make(map[int64][]interface{})
+	for _, group := range groups {
+		resp, err := tsdb.HandleRequest(ctx, group.ds, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries:   group.queries,
+			User:      user,
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("panel %d: %s", group.panelId, err))
+			continue
+		}
+
+		for _, query := range group.queries {
+			queryResult, ok := resp.Results[query.RefId]
+			if !ok {
+				continue
+			}
+			if queryResult.Error != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("panel %d query %s: %s", group.panelId, query.RefId, queryResult.Error))
+				continue
+			}
+
+			frames, err := snapshotData(queryResult)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("panel %d query %s: %s", group.panelId, query.RefId, err))
+				continue
+			}
+			panelData[group.panelId] = append(panelData[group.panelId], frames...)
+		}
+	}
+
+	return panelData
+}
+
+// scrubDashboard sets the snapshot data of the panels and removes the queries
+// from the dashboard, like snapshots created by the frontend.
+func scrubDashboard(dashboard *simplejson.Json, panelData map[int64][]interface{}) {
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for _, item := range panels {
+			panel := simplejson.NewFromAny(item)
+			if _, ok := panel.CheckGet("targets"); ok {
+				data := panelData[panel.Get("id").MustInt64()]
+				if data == nil {
+					data = []interface{}{}
+				}
+				panel.Set("snapshotData", data)
+				panel.Set("targets", []interface{}{})
+				panel.Set("datasource", nil)
+			}
+			panel.Set("links", []interface{}{})
+			walk(panel.Get("panels").MustArray())
+		}
+	}
+	walk(dashboard.Get("panels").MustArray())
+
+	for _, item := range dashboard.Get("templating").Get("list").MustArray() {
+		variable := simplejson.NewFromAny(item)
+		variable.Set("query", "")
+		if current, ok := variable.CheckGet("current"); ok {
+			variable.Set("options", []interface{}{current.Interface()})
+		} else {
+			variable.Set("options", []interface{}{})
+		}
+	}
+
+	dashboard.Set("annotations", map[string]interface{}{"list": []interface{}{}})
+}
+
+// snapshotData returns the results of a query in the formats the frontend
+// reads snapshot data in.
+func snapshotData(result *tsdb.QueryResult) ([]interface{}, error) {
+	var frames []interface{}
+
+	for _, series := range result.Series {
+		datapoints := make([]interface{}, len(series.Points))
+		for i, point := range series.Points {
+			var value interface{}
+			if point[0].Valid {
+				value = point[0].Float64
+			}
+			datapoints[i] = []interface{}{value, point[1].Float64}
+		}
+
+		frames = append(frames, map[string]interface{}{
+			"target":     series.Name,
+			"refId":      result.RefId,
+			"tags":       series.Tags,
+			"datapoints": datapoints,
+		})
+	}
+
+	for _, table := range result.Tables {
+		columns := make([]interface{}, len(table.Columns))
+		for i, column := range table.Columns {
+			columns[i] = map[string]interface{}{"text": column.Text}
+		}
+		rows := make([]interface{}, len(table.Rows))
+		for i, row := range table.Rows {
+			rows[i] = []interface{}(row)
+		}
+
+		frames = append(frames, map[string]interface{}{
+			"type":    "table",
+			"refId":   result.RefId,
+			"columns": columns,
+			"rows":    rows,
+		})
+	}
+
+	if result.Dataframes != nil {
+		decoded, err := result.Dataframes.Decoded()
+		if err != nil {
+			return nil, err
+		}
+		for _, frame := range decoded {
+			frames = append(frames, frameData(frame, result.RefId))
+		}
+	}
+
+	return frames, nil
+}
+
+// frameData returns the data frame as a data frame DTO of the frontend.
+func frameData(frame *data.Frame, refId string) map[string]interface{} {
+	if frame.RefID != "" {
+		refId = frame.RefID
+	}
+
+	fields := make([]interface{}, len(frame.Fields))
+	for i, field := range frame.Fields {
+		values := make([]interface{}, field.Len())
+		for j := range values {
+			value, ok := field.ConcreteAt(j)
+			if !ok {
+				continue
+			}
+			if t, isTime := value.(time.Time); isTime {
+				value = t.UnixNano() / int64(time.Millisecond)
+			}
+			values[j] = value
+		}
+
+		dto := map[string]interface{}{
+			"name":   field.Name,
+			"type":   fieldType(field.Type()),
+			"values": values,
+		}
+		if field.Config != nil {
+			dto["config"] = field.Config
+		}
+		if len(field.Labels) > 0 {
+			dto["labels"] = field.Labels
+		}
+		fields[i] = dto
+	}
+
+	dto := map[string]interface{}{
+		"name":   frame.Name,
+		"refId":  refId,
+		"fields": fields,
+	}
+	if frame.Meta != nil {
+		dto["meta"] = frame.Meta
+	}
+	return dto
+}
+
+func fieldType(fieldType data.FieldType) string {
+	switch {
+	case fieldType.Time():
+		return "time"
+	case fieldType.Numeric():
+		return "number"
+	case fieldType == data.FieldTypeBool || fieldType == data.FieldTypeNullableBool:
+		return "boolean"
+	case fieldType == data.FieldTypeString || fieldType == data.FieldTypeNullableString:
+		return "string"
+	default:
+		return "other"
+	}
+}
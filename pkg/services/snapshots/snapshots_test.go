@@ -0,0 +1,317 @@
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+type fakeQueryEndpoint struct {
+	queries []*tsdb.TsdbQuery
+}
+
+func (e *fakeQueryEndpoint) Query(ctx context.Context, ds *models.DataSource, query *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	e.queries = append(e.queries, query)
+
+	results := map[string]*tsdb.QueryResult{}
+	for _, q := range query.Queries {
+		result := &tsdb.QueryResult{RefId: q.RefId}
+		switch q.Model.Get("scenarioId").MustString() {
+		case "series":
+			result.Series = tsdb.TimeSeriesSlice{{
+				Name:   q.Model.Get("alias").MustString(),
+				Points: tsdb.TimeSeriesPoints{{null.FloatFrom(1.5), null.FloatFrom(1000)}, {null.FloatFromPtr(nil), null.FloatFrom(2000)}},
+			}}
+		case "table":
+			result.Tables = []*tsdb.Table{{
+				Columns: []tsdb.TableColumn{{Text: "host"}, {Text: "value"}},
+				Rows:    []tsdb.RowValues{{"web1", 1}},
+			}}
+		case "frame":
+			value := 2.5
+			result.Dataframes = tsdb.NewDecodedDataFrames(data.Frames{data.NewFrame("cpu",
+				data.NewField("time", nil, []time.Time{time.Unix(1, 0), time.Unix(2, 0)}),
+				data.NewField("value", data.Labels{"host": "web1"}, []*float64{&value, nil}),
+			)})
+		case "error":
+			result.Error = errors.New("query failed")
+		}
+		results[q.RefId] = result
+	}
+
+	return &tsdb.Response{Results: results}, nil
+}
+
+func setupSnapshotTest(t *testing.T) (*fakeQueryEndpoint, *models.Dashboard) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	endpoint := &fakeQueryEndpoint{}
+	tsdb.RegisterTsdbQueryEndpoint("snapshot-test", func(dsInfo *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+		return endpoint, nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetDataSourcesQuery) error {
+		query.Result = []*models.DataSource{{Id: 1, OrgId: 1, Type: "snapshot-test", IsDefault: true}}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetDataSourceByNameQuery) error {
+		if query.Name != "Other" {
+			return models.ErrDataSourceNotFound
+		}
+		query.Result = &models.DataSource{Id: 2, OrgId: 1, Type: "snapshot-test", Name: "Other"}
+		return nil
+	})
+
+	dash := &models.Dashboard{Id: 10, Uid: "status", Slug: "status", OrgId: 1, Data: simplejson.NewFromAny(map[string]interface{}{
+		"title": "Status",
+		"time":  map[string]interface{}{"from": "now-6h", "to": "now"},
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id": 1,
+				"targets": []interface{}{
+					map[string]interface{}{"refId": "A", "scenarioId": "series", "alias": "$host"},
+					map[string]interface{}{"refId": "B", "scenarioId": "series", "hide": true},
+				},
+			},
+			map[string]interface{}{
+				"id":   2,
+				"type": "row",
+				"panels": []interface{}{
+					map[string]interface{}{
+						"id":         3,
+						"datasource": "-- Mixed --",
+						"targets": []interface{}{
+							map[string]interface{}{"refId": "A", "scenarioId": "table"},
+							map[string]interface{}{"refId": "B", "scenarioId": "frame", "datasource": "Other"},
+						},
+					},
+				},
+			},
+			map[string]interface{}{
+				"id":         4,
+				"datasource": "Unknown",
+				"targets":    []interface{}{map[string]interface{}{"refId": "A", "scenarioId": "series"}},
+			},
+			map[string]interface{}{
+				"id":      5,
+				"targets": []interface{}{map[string]interface{}{"refId": "A", "scenarioId": "error"}},
+			},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "host", "query": "label_values(host)", "current": map[string]interface{}{"value": "web1"}},
+			},
+		},
+	})}
+
+	return endpoint, dash
+}
+
+func TestGenerate(t *testing.T) {
+	endpoint, dash := setupSnapshotTest(t)
+
+	result, err := Generate(context.Background(), dash, &models.SignedInUser{OrgId: 1}, "", "")
+	require.NoError(t, err)
+	dashboard := result.Dashboard
+
+	t.Run("Should run the queries of the panels in one time range", func(t *testing.T) {
+		require.Len(t, endpoint.queries, 4)
+		for _, query := range endpoint.queries {
+			assert.Equal(t, endpoint.queries[0].TimeRange.From, query.TimeRange.From)
+		}
+		require.Len(t, endpoint.queries[0].Queries, 1)
+		assert.Equal(t, "web1", endpoint.queries[0].Queries[0].Model.Get("alias").MustString())
+
+		assert.Equal(t, "now-6h", dashboard.Get("time").Get("raw").Get("from").MustString())
+		assert.NotEqual(t, "now-6h", dashboard.Get("time").Get("from").MustString())
+	})
+
+	t.Run("Should store the results as snapshot data of the panels", func(t *testing.T) {
+		panel := dashboard.Get("panels").GetIndex(0)
+		assert.Empty(t, panel.Get("targets").MustArray())
+
+		data := panel.Get("snapshotData").MustArray()
+		require.Len(t, data, 1)
+		series := simplejson.NewFromAny(data[0])
+		assert.Equal(t, "web1", series.Get("target").MustString())
+		assert.Equal(t, []interface{}{1.5, float64(1000)}, series.Get("datapoints").GetIndex(0).Interface())
+		assert.Equal(t, []interface{}{nil, float64(2000)}, series.Get("datapoints").GetIndex(1).Interface())
+
+		nested := dashboard.Get("panels").GetIndex(1).Get("panels").GetIndex(0)
+		data = nested.Get("snapshotData").MustArray()
+		require.Len(t, data, 2)
+
+		table := simplejson.NewFromAny(data[0])
+		assert.Equal(t, "table", table.Get("type").MustString())
+		assert.Equal(t, "host", table.Get("columns").GetIndex(0).Get("text").MustString())
+
+		frame := simplejson.NewFromAny(data[1])
+		assert.Equal(t, "B", frame.Get("refId").MustString())
+		assert.Equal(t, "time", frame.Get("fields").GetIndex(0).Get("type").MustString())
+		assert.Equal(t, []interface{}{int64(1000), int64(2000)}, frame.Get("fields").GetIndex(0).Get("values").Interface())
+		assert.Equal(t, "number", frame.Get("fields").GetIndex(1).Get("type").MustString())
+		assert.Equal(t, []interface{}{2.5, nil}, frame.Get("fields").GetIndex(1).Get("values").Interface())
+	})
+
+	t.Run("Should return the errors of the queries", func(t *testing.T) {
+		require.Len(t, result.Errors, 2)
+		assert.Contains(t, result.Errors[0], "panel 4 query A")
+		assert.Contains(t, result.Errors[1], "panel 5 query A: query failed")
+		assert.Empty(t, dashboard.Get("panels").GetIndex(3).Get("snapshotData").MustArray())
+	})
+
+	t.Run("Should remove the queries of template variables", func(t *testing.T) {
+		variable := dashboard.Get("templating").Get("list").GetIndex(0)
+		assert.Equal(t, "", variable.Get("query").MustString())
+		assert.Len(t, variable.Get("options").MustArray(), 1)
+	})
+
+	t.Run("Should not change the dashboard", func(t *testing.T) {
+		assert.Len(t, dash.Data.Get("panels").GetIndex(0).Get("targets").MustArray(), 2)
+	})
+
+	t.Run("Should return an error for invalid time ranges", func(t *testing.T) {
+		_, err := Generate(context.Background(), dash, &models.SignedInUser{OrgId: 1}, "now", "now-1h")
+		assert.Error(t, err)
+	})
+}
+
+func TestGenerateWithDataSourcePermissions(t *testing.T) {
+	endpoint, dash := setupSnapshotTest(t)
+
+	bus.AddHandler("test", func(query *models.GetDataSourceByNameQuery) error {
+		if query.Name != "Other" {
+			return models.ErrDataSourceNotFound
+		}
+		query.Result = &models.DataSource{Id: 2, OrgId: 1, Type: "snapshot-test", Name: "Other", PermissionsEnabled: true}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserDataSourcePermissionsQuery) error {
+		query.Result = map[int64]models.DsPermissionType{}
+		return nil
+	})
+
+	result, err := Generate(context.Background(), dash, &models.SignedInUser{OrgId: 1, UserId: 2, OrgRole: models.ROLE_VIEWER}, "", "")
+	require.NoError(t, err)
+
+	t.Run("Should not run the queries of data sources the user can't query", func(t *testing.T) {
+		for _, query := range endpoint.queries {
+			assert.Equal(t, int64(1), query.Queries[0].DataSource.Id)
+		}
+		assert.Contains(t, result.Errors, "panel 3 query B: "+models.ErrDataSourceAccessDenied.Error())
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	_, dash := setupSnapshotTest(t)
+
+	bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+		query.Result = dash
+		return nil
+	})
+
+	var refreshed *models.RefreshDashboardSnapshotCommand
+	bus.AddHandler("test", func(cmd *models.RefreshDashboardSnapshotCommand) error {
+		refreshed = cmd
+		cmd.Result = &models.DashboardSnapshot{Id: cmd.Id, Version: 2}
+		return nil
+	})
+
+	snapshot := &models.DashboardSnapshot{Id: 5, OrgId: 1, DashboardId: 10, TimeFrom: "now-1h", TimeTo: "now"}
+	user := &models.SignedInUser{OrgId: 1, UserId: 2}
+
+	origNew := guardian.New
+	t.Cleanup(func() { guardian.New = origNew })
+
+	t.Run("Should store the generated dashboard", func(t *testing.T) {
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+
+		cmd, err := Refresh(context.Background(), snapshot, user)
+		require.NoError(t, err)
+		assert.Equal(t, 2, cmd.Result.Version)
+		require.NotNil(t, refreshed.Dashboard)
+		assert.Equal(t, "now-1h", refreshed.Dashboard.Get("time").Get("raw").Get("from").MustString())
+		assert.Contains(t, refreshed.Error, "panel 5 query A")
+	})
+
+	t.Run("Should store the error when the user can't view the dashboard", func(t *testing.T) {
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: false})
+
+		_, err := Refresh(context.Background(), snapshot, user)
+		assert.Equal(t, ErrDashboardAccessDenied, err)
+		assert.Nil(t, refreshed.Dashboard)
+		assert.Equal(t, ErrDashboardAccessDenied.Error(), refreshed.Error)
+	})
+
+	t.Run("Should not refresh snapshots created by clients", func(t *testing.T) {
+		_, err := Refresh(context.Background(), &models.DashboardSnapshot{Id: 6, OrgId: 1}, user)
+		assert.Equal(t, ErrNotRefreshable, err)
+	})
+}
+
+func TestRefreshService(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	var user *models.User
+	bus.AddHandler("test", func(query *models.GetUserByIdQuery) error {
+		if user == nil {
+			return models.ErrUserNotFound
+		}
+		query.Result = user
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+		query.Result = &models.SignedInUser{UserId: user.Id, OrgId: -1}
+		return nil
+	})
+
+	var refreshed *models.RefreshDashboardSnapshotCommand
+	bus.AddHandler("test", func(cmd *models.RefreshDashboardSnapshotCommand) error {
+		refreshed = cmd
+		return nil
+	})
+
+	s := &RefreshService{}
+	require.NoError(t, s.Init())
+	snapshot := &models.DashboardSnapshot{Id: 5, OrgId: 1, UserId: 2, DashboardId: 10, RefreshInterval: 60}
+
+	t.Run("Should stop refreshing snapshots of disabled users", func(t *testing.T) {
+		user = &models.User{Id: 2, IsDisabled: true}
+
+		_, err := s.refresh(context.Background(), snapshot)
+		assert.Equal(t, errUserDisabled, err)
+		require.NotNil(t, refreshed)
+		assert.True(t, refreshed.StopRefresh)
+		assert.Contains(t, refreshed.Error, errUserDisabled.Error())
+	})
+
+	t.Run("Should stop refreshing snapshots of users removed from the organization", func(t *testing.T) {
+		user = &models.User{Id: 2}
+
+		_, err := s.refresh(context.Background(), snapshot)
+		assert.Equal(t, errUserNotOrgMember, err)
+		assert.True(t, refreshed.StopRefresh)
+	})
+
+	t.Run("Should stop refreshing snapshots of deleted users", func(t *testing.T) {
+		user = nil
+
+		_, err := s.refresh(context.Background(), snapshot)
+		assert.Equal(t, models.ErrUserNotFound, err)
+		assert.True(t, refreshed.StopRefresh)
+	})
+}
@@ -0,0 +1,373 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateRole)
+	bus.AddHandler("sql", UpdateRole)
+	bus.AddHandler("sql", DeleteRole)
+	bus.AddHandler("sql", GetRoleByUid)
+	bus.AddHandler("sql", GetRoles)
+
+	bus.AddHandler("sql", AddUserRole)
+	bus.AddHandler("sql", RemoveUserRole)
+	bus.AddHandler("sql", GetUserRoles)
+	bus.AddHandler("sql", AddTeamRole)
+	bus.AddHandler("sql", RemoveTeamRole)
+	bus.AddHandler("sql", GetTeamRoles)
+}
+
+func CreateRole(cmd *models.CreateRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if isNameTaken, err := isRoleNameTaken(cmd.OrgId, cmd.Name, 0, sess); err != nil {
+			return err
+		} else if isNameTaken {
+			return models.ErrRoleNameTaken
+		}
+
+		uid := cmd.Uid
+		if uid == "" {
+			uid = util.GenerateShortUID()
+		}
+
+		role := &models.CustomRole{
+			OrgId:       cmd.OrgId,
+			Uid:         uid,
+			Name:        cmd.Name,
+			Description: cmd.Description,
+			Created:     time.Now(),
+			Updated:     time.Now(),
+		}
+
+		if _, err := sess.Insert(role); err != nil {
+			return err
+		}
+
+		if err := setRolePermissions(role.Id, cmd.Permissions, sess); err != nil {
+			return err
+		}
+
+		result, err := getRoleDTO(cmd.OrgId, uid, sess)
+		cmd.Result = result
+		return err
+	})
+}
+
+func UpdateRole(cmd *models.UpdateRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		existing, err := getRoleByUid(cmd.OrgId, cmd.Uid, sess)
+		if err != nil {
+			return err
+		}
+
+		if isNameTaken, err := isRoleNameTaken(cmd.OrgId, cmd.Name, existing.Id, sess); err != nil {
+			return err
+		} else if isNameTaken {
+			return models.ErrRoleNameTaken
+		}
+
+		role := &models.CustomRole{
+			Name:        cmd.Name,
+			Description: cmd.Description,
+			Updated:     time.Now(),
+		}
+
+		if _, err := sess.ID(existing.Id).MustCols("description").Update(role); err != nil {
+			return err
+		}
+
+		if _, err := sess.Exec("DELETE FROM role_permission WHERE role_id = ?", existing.Id); err != nil {
+			return err
+		}
+
+		if err := setRolePermissions(existing.Id, cmd.Permissions, sess); err != nil {
+			return err
+		}
+
+		result, err := getRoleDTO(cmd.OrgId, cmd.Uid, sess)
+		cmd.Result = result
+		return err
+	})
+}
+
+// DeleteRole will delete a role, its permissions and any assignments to users and teams
+func DeleteRole(cmd *models.DeleteRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		role, err := getRoleByUid(cmd.OrgId, cmd.Uid, sess)
+		if err != nil {
+			return err
+		}
+
+		deletes := []string{
+			"DELETE FROM role_permission WHERE role_id = ?",
+			"DELETE FROM user_role WHERE role_id = ?",
+			"DELETE FROM team_role WHERE role_id = ?",
+			"DELETE FROM custom_role WHERE id = ?",
+		}
+
+		for _, sql := range deletes {
+			if _, err := sess.Exec(sql, role.Id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func GetRoleByUid(query *models.GetRoleByUidQuery) error {
+	return withDbSession(context.Background(), func(sess *DBSession) error {
+		result, err := getRoleDTO(query.OrgId, query.Uid, sess)
+		query.Result = result
+		return err
+	})
+}
+
+func GetRoles(query *models.GetRolesQuery) error {
+	return withDbSession(context.Background(), func(sess *DBSession) error {
+		roles := make([]*models.CustomRole, 0)
+		if err := sess.Where("org_id = ?", query.OrgId).Asc("name").Find(&roles); err != nil {
+			return err
+		}
+
+		result, err := toRoleDTOs(roles, sess)
+		query.Result = result
+		return err
+	})
+}
+
+func AddUserRole(cmd *models.AddUserRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		role, err := getRoleByUid(cmd.OrgId, cmd.RoleUid, sess)
+		if err != nil {
+			return err
+		}
+
+		if res, err := sess.Query("SELECT 1 FROM user_role WHERE org_id = ? AND user_id = ? AND role_id = ?", cmd.OrgId, cmd.UserId, role.Id); err != nil {
+			return err
+		} else if len(res) == 1 {
+			return models.ErrRoleAlreadyAssigned
+		}
+
+		_, err = sess.Insert(&models.UserRole{
+			OrgId:   cmd.OrgId,
+			UserId:  cmd.UserId,
+			RoleId:  role.Id,
+			Created: time.Now(),
+		})
+		return err
+	})
+}
+
+func RemoveUserRole(cmd *models.RemoveUserRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		role, err := getRoleByUid(cmd.OrgId, cmd.RoleUid, sess)
+		if err != nil {
+			return err
+		}
+
+		res, err := sess.Exec("DELETE FROM user_role WHERE org_id = ? AND user_id = ? AND role_id = ?", cmd.OrgId, cmd.UserId, role.Id)
+		if err != nil {
+			return err
+		}
+
+		if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+			return models.ErrRoleNotAssigned
+		}
+
+		return err
+	})
+}
+
+// GetUserRoles returns the roles assigned directly to the user along with
+// the roles of all teams the user is a member of.
+func GetUserRoles(query *models.GetUserRolesQuery) error {
+	return withDbSession(context.Background(), func(sess *DBSession) error {
+		roles := make([]*models.CustomRole, 0)
+		err := sess.SQL(`SELECT custom_role.* FROM custom_role
+			WHERE custom_role.org_id = ? AND (
+				custom_role.id IN (SELECT role_id FROM user_role WHERE user_role.org_id = ? AND user_role.user_id = ?)
+				OR custom_role.id IN (
+					SELECT team_role.role_id FROM team_role
+					INNER JOIN team_member ON team_member.team_id = team_role.team_id
+					WHERE team_role.org_id = ? AND team_member.user_id = ? AND `+notInDeletedTeam("team_member.team_id")+`
+				)
+			)
+			ORDER BY custom_role.name ASC`,
+			query.OrgId, query.OrgId, query.UserId, query.OrgId, query.UserId).Find(&roles)
+		if err != nil {
+			return err
+		}
+
+		result, err := toRoleDTOs(roles, sess)
+		query.Result = result
+		return err
+	})
+}
+
+func AddTeamRole(cmd *models.AddTeamRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := teamExists(cmd.OrgId, cmd.TeamId, sess); err != nil {
+			return err
+		}
+
+		role, err := getRoleByUid(cmd.OrgId, cmd.RoleUid, sess)
+		if err != nil {
+			return err
+		}
+
+		if res, err := sess.Query("SELECT 1 FROM team_role WHERE org_id = ? AND team_id = ? AND role_id = ?", cmd.OrgId, cmd.TeamId, role.Id); err != nil {
+			return err
+		} else if len(res) == 1 {
+			return models.ErrRoleAlreadyAssigned
+		}
+
+		_, err = sess.Insert(&models.TeamRole{
+			OrgId:   cmd.OrgId,
+			TeamId:  cmd.TeamId,
+			RoleId:  role.Id,
+			Created: time.Now(),
+		})
+		return err
+	})
+}
+
+func RemoveTeamRole(cmd *models.RemoveTeamRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		role, err := getRoleByUid(cmd.OrgId, cmd.RoleUid, sess)
+		if err != nil {
+			return err
+		}
+
+		res, err := sess.Exec("DELETE FROM team_role WHERE org_id = ? AND team_id = ? AND role_id = ?", cmd.OrgId, cmd.TeamId, role.Id)
+		if err != nil {
+			return err
+		}
+
+		if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+			return models.ErrRoleNotAssigned
+		}
+
+		return err
+	})
+}
+
+func GetTeamRoles(query *models.GetTeamRolesQuery) error {
+	return withDbSession(context.Background(), func(sess *DBSession) error {
+		roles := make([]*models.CustomRole, 0)
+		err := sess.SQL(`SELECT custom_role.* FROM custom_role
+			INNER JOIN team_role ON team_role.role_id = custom_role.id
+			WHERE team_role.org_id = ? AND team_role.team_id = ?
+			ORDER BY custom_role.name ASC`, query.OrgId, query.TeamId).Find(&roles)
+		if err != nil {
+			return err
+		}
+
+		result, err := toRoleDTOs(roles, sess)
+		query.Result = result
+		return err
+	})
+}
+
+func getRoleByUid(orgId int64, uid string, sess *DBSession) (*models.CustomRole, error) {
+	role := &models.CustomRole{}
+	has, err := sess.Where("org_id = ? AND uid = ?", orgId, uid).Get(role)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, models.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func getRoleDTO(orgId int64, uid string, sess *DBSession) (*models.CustomRoleDTO, error) {
+	role, err := getRoleByUid(orgId, uid, sess)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := toRoleDTOs([]*models.CustomRole{role}, sess)
+	if err != nil {
+		return nil, err
+	}
+	return result[0], nil
+}
+
+func toRoleDTOs(roles []*models.CustomRole, sess *DBSession) ([]*models.CustomRoleDTO, error) {
+	result := make([]*models.CustomRoleDTO, 0, len(roles))
+	if len(roles) == 0 {
+		return result, nil
+	}
+
+	roleIds := make([]int64, 0, len(roles))
+	for _, role := range roles {
+		roleIds = append(roleIds, role.Id)
+	}
+
+	permissions := make([]*models.RolePermission, 0)
+	if err := sess.In("role_id", roleIds).Asc("action").Find(&permissions); err != nil {
+		return nil, err
+	}
+
+	actionsByRole := make(map[int64][]string)
+	for _, p := range permissions {
+		actionsByRole[p.RoleId] = append(actionsByRole[p.RoleId], p.Action)
+	}
+
+	for _, role := range roles {
+		actions := actionsByRole[role.Id]
+		if actions == nil {
+			actions = []string{}
+		}
+
+		result = append(result, &models.CustomRoleDTO{
+			Id:          role.Id,
+			OrgId:       role.OrgId,
+			Uid:         role.Uid,
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: actions,
+			Created:     role.Created,
+			Updated:     role.Updated,
+		})
+	}
+
+	return result, nil
+}
+
+func setRolePermissions(roleId int64, actions []string, sess *DBSession) error {
+	seen := make(map[string]bool)
+	for _, action := range actions {
+		if action == "" || seen[action] {
+			continue
+		}
+		seen[action] = true
+
+		permission := &models.RolePermission{
+			RoleId:  roleId,
+			Action:  action,
+			Created: time.Now(),
+		}
+		if _, err := sess.Insert(permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isRoleNameTaken(orgId int64, name string, existingId int64, sess *DBSession) (bool, error) {
+	var role models.CustomRole
+	exists, err := sess.Where("org_id = ? AND name = ?", orgId, name).Get(&role)
+	if err != nil {
+		return false, err
+	}
+
+	return exists && existingId != role.Id, nil
+}
@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestAccessControlRoles(t *testing.T) {
+	InitTestDB(t)
+
+	var orgId int64 = 1
+
+	userCmd := &models.CreateUserCommand{Login: "teamlead", Email: "teamlead@test.com"}
+	require.NoError(t, CreateUser(context.Background(), userCmd))
+	userId := userCmd.Result.Id
+
+	teamCmd := &models.CreateTeamCommand{OrgId: orgId, Name: "leads"}
+	require.NoError(t, CreateTeam(teamCmd))
+	teamId := teamCmd.Result.Id
+
+	createCmd := &models.CreateRoleCommand{
+		OrgId:       orgId,
+		Name:        "user manager",
+		Permissions: []string{"org.users:read", "org.users:add", "org.users:read"},
+	}
+	require.NoError(t, CreateRole(createCmd))
+	roleUid := createCmd.Result.Uid
+
+	t.Run("Should create role with unique permissions", func(t *testing.T) {
+		require.NotEmpty(t, roleUid)
+		require.Equal(t, []string{"org.users:add", "org.users:read"}, createCmd.Result.Permissions)
+
+		err := CreateRole(&models.CreateRoleCommand{OrgId: orgId, Name: "user manager"})
+		require.Equal(t, models.ErrRoleNameTaken, err)
+	})
+
+	t.Run("Should update role and replace permissions", func(t *testing.T) {
+		updateCmd := &models.UpdateRoleCommand{
+			OrgId:       orgId,
+			Uid:         roleUid,
+			Name:        "user manager",
+			Description: "Can manage org users",
+			Permissions: []string{"org.users:read", "org.users:remove"},
+		}
+		require.NoError(t, UpdateRole(updateCmd))
+		require.Equal(t, "Can manage org users", updateCmd.Result.Description)
+		require.Equal(t, []string{"org.users:read", "org.users:remove"}, updateCmd.Result.Permissions)
+
+		query := &models.GetRolesQuery{OrgId: orgId}
+		require.NoError(t, GetRoles(query))
+		require.Len(t, query.Result, 1)
+
+		err := GetRoleByUid(&models.GetRoleByUidQuery{OrgId: 2, Uid: roleUid})
+		require.Equal(t, models.ErrRoleNotFound, err)
+	})
+
+	t.Run("Should return roles assigned to user directly and through teams", func(t *testing.T) {
+		teamRoleCmd := &models.CreateRoleCommand{OrgId: orgId, Name: "team manager", Permissions: []string{"teams:write"}}
+		require.NoError(t, CreateRole(teamRoleCmd))
+
+		require.NoError(t, AddUserRole(&models.AddUserRoleCommand{OrgId: orgId, UserId: userId, RoleUid: roleUid}))
+		err := AddUserRole(&models.AddUserRoleCommand{OrgId: orgId, UserId: userId, RoleUid: roleUid})
+		require.Equal(t, models.ErrRoleAlreadyAssigned, err)
+
+		require.NoError(t, AddTeamRole(&models.AddTeamRoleCommand{OrgId: orgId, TeamId: teamId, RoleUid: teamRoleCmd.Result.Uid}))
+
+		query := &models.GetUserRolesQuery{OrgId: orgId, UserId: userId}
+		require.NoError(t, GetUserRoles(query))
+		require.Len(t, query.Result, 1)
+
+		require.NoError(t, AddTeamMember(&models.AddTeamMemberCommand{OrgId: orgId, TeamId: teamId, UserId: userId}))
+		require.NoError(t, GetUserRoles(query))
+		require.Len(t, query.Result, 2)
+		require.Equal(t, "team manager", query.Result[0].Name)
+		require.Equal(t, []string{"teams:write"}, query.Result[0].Permissions)
+
+		teamQuery := &models.GetTeamRolesQuery{OrgId: orgId, TeamId: teamId}
+		require.NoError(t, GetTeamRoles(teamQuery))
+		require.Len(t, teamQuery.Result, 1)
+
+		require.NoError(t, RemoveTeamRole(&models.RemoveTeamRoleCommand{OrgId: orgId, TeamId: teamId, RoleUid: teamRoleCmd.Result.Uid}))
+		err = RemoveTeamRole(&models.RemoveTeamRoleCommand{OrgId: orgId, TeamId: teamId, RoleUid: teamRoleCmd.Result.Uid})
+		require.Equal(t, models.ErrRoleNotAssigned, err)
+	})
+
+	t.Run("Should remove assignments when role is deleted", func(t *testing.T) {
+		require.NoError(t, DeleteRole(&models.DeleteRoleCommand{OrgId: orgId, Uid: roleUid}))
+
+		query := &models.GetUserRolesQuery{OrgId: orgId, UserId: userId}
+		require.NoError(t, GetUserRoles(query))
+		require.Len(t, query.Result, 0)
+
+		err := DeleteRole(&models.DeleteRoleCommand{OrgId: orgId, Uid: roleUid})
+		require.Equal(t, models.ErrRoleNotFound, err)
+	})
+}
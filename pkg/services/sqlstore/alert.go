@@ -40,7 +40,7 @@ func GetAlertById(query *models.GetAlertByIdQuery) error {
 
 func GetAllAlertQueryHandler(query *models.GetAllAlertsQuery) error {
 	var alerts []*models.Alert
-	err := x.SQL("select * from alert").Find(&alerts)
+	err := x.SQL("select * from alert where " + notInDeletedOrg("alert.org_id")).Find(&alerts)
 	if err != nil {
 		return err
 	}
@@ -223,6 +223,10 @@ func updateAlerts(existingAlerts []*models.Alert, cmd *models.SaveAlertsCommand,
 				return err
 			}
 
+			if err := checkOrgQuota(sess, alert.OrgId, "alert"); err != nil {
+				return err
+			}
+
 			sqlog.Debug("Alert inserted", "name", alert.Name, "id", alert.Id)
 		}
 		tags := alert.GetTagsFromSettings()
@@ -50,6 +50,10 @@ func (r *SqlAnnotationRepo) Save(item *annotations.Item) error {
 			return err
 		}
 
+		if err := checkOrgQuota(sess, item.OrgId, "annotation"); err != nil {
+			return err
+		}
+
 		if item.Tags != nil {
 			tags, err := EnsureTagsExist(sess, tags)
 			if err != nil {
@@ -85,7 +85,7 @@ func GetApiKeyById(query *models.GetApiKeyByIdQuery) error {
 
 func GetApiKeyByName(query *models.GetApiKeyByNameQuery) error {
 	var apikey models.ApiKey
-	has, err := x.Where("org_id=? AND name=?", query.OrgId, query.KeyName).Get(&apikey)
+	has, err := x.Where("org_id=? AND name=? AND "+notInDeletedOrg("api_key.org_id"), query.OrgId, query.KeyName).Get(&apikey)
 
 	if err != nil {
 		return err
@@ -0,0 +1,194 @@
+package sqlstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", ImportBundle)
+}
+
+// ImportBundle saves the content of a bundle in one transaction. The org
+// quotas are checked for every created data source, folder and dashboard, as
+// the quota middleware doesn't apply to the items of a bundle.
+func ImportBundle(cmd *models.ImportBundleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		for _, ds := range cmd.DataSources {
+			ds.OrgId = cmd.OrgId
+			if err := addDataSource(sess, ds); err != nil {
+				return fmt.Errorf("failed to create data source %q: %w", ds.Name, err)
+			}
+			if err := checkOrgQuota(sess, cmd.OrgId, "data_source"); err != nil {
+				return err
+			}
+		}
+
+		folderIds := map[string]int64{"": 0}
+		folderId := func(uid string) (int64, error) {
+			if id, ok := folderIds[uid]; ok {
+				return id, nil
+			}
+
+			var folder models.Dashboard
+			exists, err := sess.Where("org_id=? AND uid=? AND is_folder=?", cmd.OrgId, uid, dialect.BooleanStr(true)).Get(&folder)
+			if err != nil {
+				return 0, err
+			}
+			if !exists {
+				return 0, models.ErrFolderNotFound
+			}
+
+			folderIds[uid] = folder.Id
+			return folder.Id, nil
+		}
+
+		for _, folder := range cmd.Folders {
+			parentId, err := folderId(folder.ParentUid)
+			if err != nil {
+				return fmt.Errorf("failed to import folder %q: %w", folder.Title, err)
+			}
+
+			var existing models.Dashboard
+			exists, err := sess.Where("org_id=? AND uid=?", cmd.OrgId, folder.Uid).Get(&existing)
+			if err != nil {
+				return err
+			}
+
+			data := models.NewDashboardFolder(folder.Title).Data
+			if exists {
+				data = existing.Data
+				data.Set("title", folder.Title)
+			}
+			data.Set("uid", folder.Uid)
+
+			dash, err := importBundleDashboard(sess, cmd, data, parentId, true)
+			if err != nil {
+				return fmt.Errorf("failed to import folder %q: %w", folder.Title, err)
+			}
+			folderIds[folder.Uid] = dash.Id
+
+			if err := importBundleAcl(sess, dash.Id, folder.Acl); err != nil {
+				return err
+			}
+		}
+
+		for _, libraryPanel := range cmd.LibraryPanels {
+			id, err := folderId(libraryPanel.FolderUid)
+			if err != nil {
+				return fmt.Errorf("failed to import library panel %q: %w", libraryPanel.Name, err)
+			}
+
+			existing, err := getLibraryPanel(sess, cmd.OrgId, libraryPanel.Uid)
+			switch {
+			case err == models.ErrLibraryPanelNotFound:
+				err = createLibraryPanel(sess, &models.CreateLibraryPanelCommand{
+					OrgId:    cmd.OrgId,
+					UserId:   cmd.UserId,
+					FolderId: id,
+					Uid:      libraryPanel.Uid,
+					Name:     libraryPanel.Name,
+					Model:    libraryPanel.Model,
+				})
+			case err == nil:
+				err = updateLibraryPanel(sess, &models.UpdateLibraryPanelCommand{
+					OrgId:    cmd.OrgId,
+					UserId:   cmd.UserId,
+					Uid:      libraryPanel.Uid,
+					FolderId: &id,
+					Name:     libraryPanel.Name,
+					Model:    libraryPanel.Model,
+					Version:  existing.Version,
+				})
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import library panel %q: %w", libraryPanel.Name, err)
+			}
+		}
+
+		for _, dashboard := range cmd.Dashboards {
+			id, err := folderId(dashboard.FolderUid)
+			if err != nil {
+				return fmt.Errorf("failed to import dashboard %q: %w", dashboard.Dashboard.Get("title").MustString(), err)
+			}
+
+			dash, err := importBundleDashboard(sess, cmd, dashboard.Dashboard, id, false)
+			if err != nil {
+				return fmt.Errorf("failed to import dashboard %q: %w", dashboard.Dashboard.Get("title").MustString(), err)
+			}
+			dashboard.Result = dash
+
+			if err := importBundleAcl(sess, dash.Id, dashboard.Acl); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// importBundleDashboard validates and saves a folder or dashboard as the
+// dashboard service does, overwriting the existing one with the same uid.
+// Provisioned dashboards can't be overwritten.
+func importBundleDashboard(sess *DBSession, cmd *models.ImportBundleCommand, data *simplejson.Json, folderId int64, isFolder bool) (*models.Dashboard, error) {
+	dash := models.NewDashboardFromJson(data)
+	dash.OrgId = cmd.OrgId
+	dash.FolderId = folderId
+	dash.IsFolder = isFolder
+
+	validateCmd := models.ValidateDashboardBeforeSaveCommand{OrgId: cmd.OrgId, Dashboard: dash, Overwrite: true}
+	if err := validateDashboardBeforeSave(sess, &validateCmd); err != nil {
+		return nil, err
+	}
+
+	created := dash.Id == 0
+	if !created {
+		provisioned, err := sess.Where("dashboard_id=?", dash.Id).Exist(&models.DashboardProvisioning{})
+		if err != nil {
+			return nil, err
+		}
+		if provisioned {
+			return nil, models.ErrDashboardCannotSaveProvisionedDashboard
+		}
+	}
+
+	saveCmd := models.SaveDashboardCommand{
+		Dashboard: dash.Data,
+		OrgId:     cmd.OrgId,
+		UserId:    cmd.UserId,
+		FolderId:  folderId,
+		IsFolder:  isFolder,
+		Overwrite: true,
+		Message:   cmd.Message,
+	}
+	if err := saveDashboard(sess, &saveCmd); err != nil {
+		return nil, err
+	}
+
+	if created {
+		if err := checkOrgQuota(sess, cmd.OrgId, "dashboard"); err != nil {
+			return nil, err
+		}
+	}
+
+	return saveCmd.Result, nil
+}
+
+func importBundleAcl(sess *DBSession, dashboardId int64, items []*models.DashboardAcl) error {
+	if items == nil {
+		return nil
+	}
+
+	now := time.Now()
+	for _, item := range items {
+		item.DashboardId = dashboardId
+		item.Created = now
+		item.Updated = now
+	}
+
+	return updateDashboardAcl(sess, &models.UpdateDashboardAclCommand{DashboardId: dashboardId, Items: items})
+}
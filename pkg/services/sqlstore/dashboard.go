@@ -9,9 +9,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -27,6 +29,8 @@ func init() {
 	bus.AddHandler("sql", SaveDashboard)
 	bus.AddHandler("sql", GetDashboard)
 	bus.AddHandler("sql", GetDashboards)
+	bus.AddHandler("sql", GetAllDashboards)
+	bus.AddHandler("sql", GetDashboardsByOrg)
 	bus.AddHandler("sql", DeleteDashboard)
 	bus.AddHandler("sql", SearchDashboards)
 	bus.AddHandler("sql", GetDashboardTags)
@@ -59,8 +63,8 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		userId = -1
 	}
 
+	var existing models.Dashboard
 	if dash.Id > 0 {
-		var existing models.Dashboard
 		dashWithIdExists, err := sess.Where("id=? AND org_id=?", dash.Id, dash.OrgId).Get(&existing)
 		if err != nil {
 			return err
@@ -92,9 +96,13 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		dash.SetUid(uid)
 	}
 
+	libraryPanelIds, err := linkLibraryPanels(sess, dash)
+	if err != nil {
+		return err
+	}
+
 	parentVersion := dash.Version
 	var affectedRows int64
-	var err error
 
 	if dash.Id == 0 {
 		dash.SetVersion(1)
@@ -135,6 +143,7 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		CreatedBy:     dash.UpdatedBy,
 		Message:       cmd.Message,
 		Data:          dash.Data,
+		FolderId:      dash.FolderId,
 	}
 
 	// insert version entry
@@ -144,6 +153,18 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		return models.ErrDashboardNotFound
 	}
 
+	if err := checkDashboardQuotas(sess, dash, &existing); err != nil {
+		return err
+	}
+
+	if err := connectLibraryPanels(sess, dash.Id, libraryPanelIds); err != nil {
+		return err
+	}
+
+	if err := updateDashboardAncestors(sess, dash, &existing); err != nil {
+		return err
+	}
+
 	// delete existing tags
 	_, err = sess.Exec("DELETE FROM dashboard_tag WHERE dashboard_id=?", dash.Id)
 	if err != nil {
@@ -160,11 +181,54 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		}
 	}
 
+	if err := updateDashboardContent(sess, dash); err != nil {
+		return err
+	}
+
 	cmd.Result = dash
 
 	return err
 }
 
+// checkDashboardQuotas checks the dashboard version quota and, if the
+// dashboard has grown, the dashboard storage quota of the org.
+func checkDashboardQuotas(sess *DBSession, dash *models.Dashboard, existing *models.Dashboard) error {
+	if err := checkOrgQuota(sess, dash.OrgId, "dashboard_version"); err != nil {
+		return err
+	}
+
+	if !setting.Quota.Enabled {
+		return nil
+	}
+
+	size, err := dashboardDataSize(dash.Data)
+	if err != nil {
+		return err
+	}
+	existingSize, err := dashboardDataSize(existing.Data)
+	if err != nil {
+		return err
+	}
+	if size <= existingSize {
+		return nil
+	}
+
+	return checkOrgQuota(sess, dash.OrgId, "dashboard_bytes")
+}
+
+func dashboardDataSize(data *simplejson.Json) (int, error) {
+	if data == nil {
+		return 0, nil
+	}
+
+	bytes, err := data.MarshalJSON()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(bytes), nil
+}
+
 func generateNewDashboardUid(sess *DBSession, orgId int64) (string, error) {
 	for i := 0; i < 3; i++ {
 		uid := generateNewUid()
@@ -198,6 +262,11 @@ func GetDashboard(query *models.GetDashboardQuery) error {
 
 	dashboard.SetId(dashboard.Id)
 	dashboard.SetUid(dashboard.Uid)
+
+	if err := loadLibraryPanels(newSession(), &dashboard); err != nil {
+		return err
+	}
+
 	query.Result = &dashboard
 	return nil
 }
@@ -215,7 +284,9 @@ type DashboardSearchProjection struct {
 	FolderTitle string
 }
 
-func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSearchProjection, error) {
+// searchFilters returns the filters of the search query, without the
+// filter of the cursor.
+func searchFilters(query *search.FindPersistedDashboardsQuery) []interface{} {
 	filters := []interface{}{
 		permissions.DashboardPermissionFilter{
 			OrgRole:         query.SignedInUser.OrgRole,
@@ -258,19 +329,44 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 		filters = append(filters, searchstore.FolderFilter{IDs: query.FolderIds})
 	}
 
-	var res []DashboardSearchProjection
-	sb := &searchstore.Builder{Dialect: dialect, Filters: filters}
+	for _, filter := range contentFilters(query) {
+		filters = append(filters, filter)
+	}
+
+	return filters
+}
 
-	limit := query.Limit
-	if limit < 1 {
-		limit = 1000
+func searchLimit(query *search.FindPersistedDashboardsQuery) int64 {
+	if query.Limit < 1 {
+		return 1000
 	}
+	return query.Limit
+}
+
+func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSearchProjection, error) {
+	filters := searchFilters(query)
+	limit := searchLimit(query)
 
 	page := query.Page
 	if page < 1 {
 		page = 1
 	}
 
+	if query.CursorPaging {
+		cursor, err := cursorFilters(query)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, cursor...)
+
+		// one more dashboard tells if there is a next page
+		limit++
+		page = 1
+	}
+
+	var res []DashboardSearchProjection
+	sb := &searchstore.Builder{Dialect: dialect, Filters: filters}
+
 	sql, params := sb.ToSql(limit, page)
 	err := x.SQL(sql, params...).Find(&res)
 	if err != nil {
@@ -288,7 +384,25 @@ func SearchDashboards(query *search.FindPersistedDashboardsQuery) error {
 
 	makeQueryResult(query, res)
 
-	return nil
+	if limit := searchLimit(query); query.CursorPaging && int64(len(query.Result)) > limit {
+		query.Result = query.Result[:limit]
+		query.HasMore = true
+	}
+
+	if err := setSearchFacets(query); err != nil {
+		return err
+	}
+
+	orgId := query.OrgId
+	if orgId == 0 {
+		orgId = query.SignedInUser.OrgId
+	}
+
+	if err := setHitContentMatches(query.Result, contentFilters(query)); err != nil {
+		return err
+	}
+
+	return setHitFolderPaths(orgId, query.Result)
 }
 
 func getHitType(item DashboardSearchProjection) search.HitType {
@@ -361,49 +475,87 @@ func DeleteDashboard(cmd *models.DeleteDashboardCommand) error {
 			return models.ErrDashboardNotFound
 		}
 
-		deletes := []string{
-			"DELETE FROM dashboard_tag WHERE dashboard_id = ? ",
-			"DELETE FROM star WHERE dashboard_id = ? ",
-			"DELETE FROM dashboard WHERE id = ?",
-			"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
-			"DELETE FROM dashboard_version WHERE dashboard_id = ?",
-			"DELETE FROM annotation WHERE dashboard_id = ?",
-			"DELETE FROM dashboard_provisioning WHERE dashboard_id = ?",
-		}
+		return deleteDashboard(sess, &dashboard)
+	})
+}
 
-		if dashboard.IsFolder {
-			deletes = append(deletes, "DELETE FROM dashboard_provisioning WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
-			deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
+// deleteDashboard deletes a dashboard or, for folders, the folder and its
+// content including subfolders.
+func deleteDashboard(sess *DBSession, dashboard *models.Dashboard) error {
+	deletes := []string{
+		"DELETE FROM dashboard_tag WHERE dashboard_id = ? ",
+		"DELETE FROM star WHERE dashboard_id = ? ",
+		"DELETE FROM dashboard WHERE id = ?",
+		"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
+		"DELETE FROM dashboard_version WHERE dashboard_id = ?",
+		"DELETE FROM annotation WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_provisioning WHERE dashboard_id = ?",
+		"DELETE FROM library_panel_dashboard WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_ancestor WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_content_term WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_content WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_view_count WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_usage WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_view_event WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_public_share WHERE dashboard_id = ?",
+		"UPDATE dashboard_snapshot SET refresh_interval = 0 WHERE dashboard_id = ?",
+	}
+
+	if dashboard.IsFolder {
+		subfolders := make([]*models.Dashboard, 0)
+		err := sess.Where("folder_id = ? AND is_folder = ?", dashboard.Id, dialect.BooleanStr(true)).Find(&subfolders)
+		if err != nil {
+			return err
+		}
 
-			dashIds := []struct {
-				Id int64
-			}{}
-			err := sess.SQL("select id from dashboard where folder_id = ?", dashboard.Id).Find(&dashIds)
-			if err != nil {
+		for _, subfolder := range subfolders {
+			if err := deleteDashboard(sess, subfolder); err != nil {
 				return err
 			}
-
-			for _, id := range dashIds {
-				if err := deleteAlertDefinition(id.Id, sess); err != nil {
-					return err
-				}
-			}
 		}
 
-		if err := deleteAlertDefinition(dashboard.Id, sess); err != nil {
+		deletes = append(deletes, "DELETE FROM dashboard_provisioning WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM library_panel_dashboard WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM library_panel_dashboard WHERE library_panel_id in (select id from library_panel where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM library_panel WHERE folder_id = ?")
+		deletes = append(deletes, "DELETE FROM dashboard_ancestor WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_content_term WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_content WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_view_count WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_usage WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_view_event WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_public_share WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "UPDATE dashboard_snapshot SET refresh_interval = 0 WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
+
+		dashIds := []struct {
+			Id int64
+		}{}
+		err = sess.SQL("select id from dashboard where folder_id = ?", dashboard.Id).Find(&dashIds)
+		if err != nil {
 			return err
 		}
 
-		for _, sql := range deletes {
-			_, err := sess.Exec(sql, dashboard.Id)
-
-			if err != nil {
+		for _, id := range dashIds {
+			if err := deleteAlertDefinition(id.Id, sess); err != nil {
 				return err
 			}
 		}
+	}
 
-		return nil
-	})
+	if err := deleteAlertDefinition(dashboard.Id, sess); err != nil {
+		return err
+	}
+
+	for _, sql := range deletes {
+		_, err := sess.Exec(sql, dashboard.Id)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func GetDashboards(query *models.GetDashboardsQuery) error {
@@ -418,6 +570,26 @@ func GetDashboards(query *models.GetDashboardsQuery) error {
 	return err
 }
 
+func GetAllDashboards(query *models.GetAllDashboardsQuery) error {
+	if query.Limit <= 0 {
+		return models.ErrCommandValidationFailed
+	}
+
+	var dashboards = make([]*models.Dashboard, 0)
+	whereExpr := "id > ? AND is_folder=" + dialect.BooleanStr(false)
+
+	err := x.Where(whereExpr, query.AfterId).OrderBy("id").Limit(query.Limit).Find(&dashboards)
+	query.Result = dashboards
+	return err
+}
+
+func GetDashboardsByOrg(query *models.GetDashboardsByOrgQuery) error {
+	var dashboards = make([]*models.Dashboard, 0)
+	err := x.Where("org_id=?", query.OrgId).OrderBy("id").Find(&dashboards)
+	query.Result = dashboards
+	return err
+}
+
 // GetDashboardPermissionsForUser returns the maximum permission the specified user has for a dashboard(s)
 // The function takes in a list of dashboard ids and the user id and role
 func GetDashboardPermissionsForUser(query *models.GetDashboardPermissionsForUserQuery) error {
@@ -444,8 +616,8 @@ func GetDashboardPermissionsForUser(query *models.GetDashboardPermissionsForUser
 	// check dashboards that have ACLs via user id, team id or role
 	sql := `SELECT d.id AS dashboard_id, MAX(COALESCE(da.permission, pt.permission)) AS permission
 	FROM dashboard AS d
-		LEFT JOIN dashboard_acl as da on d.folder_id = da.dashboard_id or d.id = da.dashboard_id
-		LEFT JOIN team_member as ugm on ugm.team_id =  da.team_id
+		LEFT JOIN dashboard_acl as da on d.id = da.dashboard_id or da.dashboard_id IN (SELECT ancestor_id FROM dashboard_ancestor WHERE dashboard_id = d.id)
+		LEFT JOIN team_member as ugm on ugm.team_id =  da.team_id AND ` + notInDeletedTeam("ugm.team_id") + `
 		LEFT JOIN org_user ou ON ou.role = da.role AND ou.user_id = ?
 	`
 	params = append(params, query.UserId)
@@ -613,7 +785,7 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, cmd *models.Validat
 		return models.ErrDashboardTypeMismatch
 	}
 
-	if !dash.IsFolder && dash.FolderId != existing.FolderId {
+	if dash.FolderId != existing.FolderId {
 		cmd.Result.IsParentFolderChanged = true
 	}
 
@@ -638,7 +810,7 @@ func getExistingDashboardByTitleAndFolder(sess *DBSession, cmd *models.ValidateD
 	dash := cmd.Dashboard
 	var existing models.Dashboard
 
-	exists, err := sess.Where("org_id=? AND slug=? AND (is_folder=? OR folder_id=?)", dash.OrgId, dash.Slug, dialect.BooleanStr(true), dash.FolderId).Get(&existing)
+	exists, err := sess.Where("org_id=? AND slug=? AND folder_id=?", dash.OrgId, dash.Slug, dash.FolderId).Get(&existing)
 	if err != nil {
 		return err
 	}
@@ -669,19 +841,19 @@ func getExistingDashboardByTitleAndFolder(sess *DBSession, cmd *models.ValidateD
 }
 
 func ValidateDashboardBeforeSave(cmd *models.ValidateDashboardBeforeSaveCommand) (err error) {
-	cmd.Result = &models.ValidateDashboardBeforeSaveResult{}
-
 	return inTransaction(func(sess *DBSession) error {
-		if err = getExistingDashboardByIdOrUidForUpdate(sess, cmd); err != nil {
-			return err
-		}
+		return validateDashboardBeforeSave(sess, cmd)
+	})
+}
 
-		if err = getExistingDashboardByTitleAndFolder(sess, cmd); err != nil {
-			return err
-		}
+func validateDashboardBeforeSave(sess *DBSession, cmd *models.ValidateDashboardBeforeSaveCommand) error {
+	cmd.Result = &models.ValidateDashboardBeforeSaveResult{}
 
-		return nil
-	})
+	if err := getExistingDashboardByIdOrUidForUpdate(sess, cmd); err != nil {
+		return err
+	}
+
+	return getExistingDashboardByTitleAndFolder(sess, cmd)
 }
 
 func HasEditPermissionInFolders(query *models.HasEditPermissionInFoldersQuery) error {
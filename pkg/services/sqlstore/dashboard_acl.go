@@ -3,6 +3,7 @@ package sqlstore
 import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 )
 
 func init() {
@@ -12,39 +13,43 @@ func init() {
 
 func UpdateDashboardAcl(cmd *models.UpdateDashboardAclCommand) error {
 	return inTransaction(func(sess *DBSession) error {
-		// delete existing items
-		_, err := sess.Exec("DELETE FROM dashboard_acl WHERE dashboard_id=?", cmd.DashboardId)
-		if err != nil {
-			return err
-		}
+		return updateDashboardAcl(sess, cmd)
+	})
+}
+
+func updateDashboardAcl(sess *DBSession, cmd *models.UpdateDashboardAclCommand) error {
+	// delete existing items
+	_, err := sess.Exec("DELETE FROM dashboard_acl WHERE dashboard_id=?", cmd.DashboardId)
+	if err != nil {
+		return err
+	}
 
-		for _, item := range cmd.Items {
-			if item.UserId == 0 && item.TeamId == 0 && (item.Role == nil || !item.Role.IsValid()) {
-				return models.ErrDashboardAclInfoMissing
-			}
+	for _, item := range cmd.Items {
+		if item.UserId == 0 && item.TeamId == 0 && (item.Role == nil || !item.Role.IsValid()) {
+			return models.ErrDashboardAclInfoMissing
+		}
 
-			if item.DashboardId == 0 {
-				return models.ErrDashboardPermissionDashboardEmpty
-			}
+		if item.DashboardId == 0 {
+			return models.ErrDashboardPermissionDashboardEmpty
+		}
 
-			sess.Nullable("user_id", "team_id")
-			if _, err := sess.Insert(item); err != nil {
-				return err
-			}
+		sess.Nullable("user_id", "team_id")
+		if _, err := sess.Insert(item); err != nil {
+			return err
 		}
+	}
 
-		// Update dashboard HasAcl flag
-		dashboard := models.Dashboard{HasAcl: true}
-		_, err = sess.Cols("has_acl").Where("id=?", cmd.DashboardId).Update(&dashboard)
-		return err
-	})
+	// Update dashboard HasAcl flag
+	dashboard := models.Dashboard{HasAcl: true}
+	_, err = sess.Cols("has_acl").Where("id=?", cmd.DashboardId).Update(&dashboard)
+	return err
 }
 
 // GetDashboardAclInfoList returns a list of permissions for a dashboard. They can be fetched from three
 // different places.
 // 1) Permissions for the dashboard
-// 2) permissions for its parent folder
-// 3) if no specific permissions have been set for the dashboard or its parent folder then get the default permissions
+// 2) permissions for the nearest folder above it that has permissions set
+// 3) if no specific permissions have been set for the folders above the dashboard, or for the dashboard in the General folder, then get the default permissions
 func GetDashboardAclInfoList(query *models.GetDashboardAclInfoListQuery) error {
 	var err error
 
@@ -94,19 +99,12 @@ func GetDashboardAclInfoList(query *models.GetDashboardAclInfoListQuery) error {
 				d.slug,
 				d.uid,
 				d.is_folder,
-				CASE WHEN (da.dashboard_id = -1 AND d.folder_id > 0) OR da.dashboard_id = d.folder_id THEN ` + dialect.BooleanStr(true) + ` ELSE ` + falseStr + ` END AS inherited
+				CASE WHEN (da.dashboard_id = -1 AND d.folder_id > 0) OR (da.dashboard_id <> -1 AND da.dashboard_id <> d.id) THEN ` + dialect.BooleanStr(true) + ` ELSE ` + falseStr + ` END AS inherited
 			FROM dashboard as d
-				LEFT JOIN dashboard folder on folder.id = d.folder_id
 				LEFT JOIN dashboard_acl AS da ON
-				da.dashboard_id = d.id OR
-				da.dashboard_id = d.folder_id OR
-				(
-					-- include default permissions -->
-					da.org_id = -1 AND (
-					  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-					  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-					)
-				)
+				` + permissions.AclCondition(dialect) + ` OR
+				-- include default permissions -->
+				` + permissions.DefaultAclCondition(dialect) + `
 				LEFT JOIN ` + dialect.Quote("user") + ` AS u ON u.id = da.user_id
 				LEFT JOIN team ug on ug.id = da.team_id
 			WHERE d.org_id = ? AND d.id = ? AND da.id IS NOT NULL
@@ -0,0 +1,149 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+// DashboardAncestor stores that a dashboard or folder is in a folder, either
+// directly (depth 1) or through subfolders.
+type DashboardAncestor struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	AncestorId  int64
+	Depth       int
+}
+
+// updateDashboardAncestors updates the ancestors of a new or moved dashboard
+// and, if it is a folder, of the dashboards and folders it contains.
+func updateDashboardAncestors(sess *DBSession, dash *models.Dashboard, existing *models.Dashboard) error {
+	if existing.Id != 0 && existing.FolderId == dash.FolderId {
+		return nil
+	}
+
+	ancestors := make([]*DashboardAncestor, 0)
+	if dash.FolderId > 0 {
+		if err := sess.Where("dashboard_id = ?", dash.FolderId).Find(&ancestors); err != nil {
+			return err
+		}
+
+		if dash.IsFolder && dash.Id != 0 {
+			if dash.FolderId == dash.Id {
+				return models.ErrFolderCircularReference
+			}
+
+			for _, ancestor := range ancestors {
+				if ancestor.AncestorId == dash.Id {
+					return models.ErrFolderCircularReference
+				}
+			}
+		}
+
+		ancestors = append(ancestors, &DashboardAncestor{AncestorId: dash.FolderId})
+	}
+
+	subtree := []*DashboardAncestor{{DashboardId: dash.Id}}
+	if existing.Id != 0 {
+		if dash.IsFolder {
+			descendants := make([]*DashboardAncestor, 0)
+			if err := sess.Where("ancestor_id = ?", dash.Id).Find(&descendants); err != nil {
+				return err
+			}
+			subtree = append(subtree, descendants...)
+		}
+
+		oldAncestorIds := make([]int64, 0)
+		if err := sess.Table("dashboard_ancestor").Where("dashboard_id = ?", dash.Id).Cols("ancestor_id").Find(&oldAncestorIds); err != nil {
+			return err
+		}
+
+		if len(oldAncestorIds) > 0 {
+			subtreeIds := make([]int64, 0, len(subtree))
+			for _, item := range subtree {
+				subtreeIds = append(subtreeIds, item.DashboardId)
+			}
+
+			if _, err := sess.In("dashboard_id", subtreeIds).In("ancestor_id", oldAncestorIds).Delete(&DashboardAncestor{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, item := range subtree {
+		for _, ancestor := range ancestors {
+			row := &DashboardAncestor{
+				OrgId:       dash.OrgId,
+				DashboardId: item.DashboardId,
+				AncestorId:  ancestor.AncestorId,
+				Depth:       item.Depth + ancestor.Depth + 1,
+			}
+			if _, err := sess.Insert(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type folderPathProjection struct {
+	Id       int64
+	Uid      string
+	Title    string
+	Slug     string
+	FolderId int64
+}
+
+// setHitFolderPaths sets the path from the General folder to the folder of
+// each hit.
+func setHitFolderPaths(orgId int64, hits []*search.Hit) error {
+	nested := false
+	for _, hit := range hits {
+		if hit.FolderId > 0 {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return nil
+	}
+
+	folders := make([]*folderPathProjection, 0)
+	err := x.Table("dashboard").
+		Where("org_id = ? AND is_folder = ?", orgId, dialect.BooleanStr(true)).
+		Cols("id", "uid", "title", "slug", "folder_id").
+		Find(&folders)
+	if err != nil {
+		return err
+	}
+
+	byId := make(map[int64]*folderPathProjection, len(folders))
+	for _, folder := range folders {
+		byId[folder.Id] = folder
+	}
+
+	for _, hit := range hits {
+		path := make([]*search.HitFolder, 0)
+		for id := hit.FolderId; id > 0 && len(path) <= len(folders); {
+			folder, ok := byId[id]
+			if !ok {
+				break
+			}
+
+			path = append([]*search.HitFolder{{
+				Id:    folder.Id,
+				Uid:   folder.Uid,
+				Title: folder.Title,
+				Url:   models.GetFolderUrl(folder.Uid, folder.Slug),
+			}}, path...)
+			id = folder.FolderId
+		}
+
+		if len(path) > 0 {
+			hit.FolderPath = path
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package sqlstore
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+func TestNestedFolders(t *testing.T) {
+	Convey("Testing DB", t, func() {
+		InitTestDB(t)
+
+		Convey("Given a folder with a subfolder that contains a dashboard", func() {
+			folder := insertTestDashboard("parent", 1, 0, true)
+			subfolder := insertTestDashboard("child", 1, folder.Id, true)
+			dash := insertTestDashboard("nested dash", 1, subfolder.Id, false)
+
+			viewer := createUser("viewer", "Viewer", false)
+			signedInViewer := &models.SignedInUser{UserId: viewer.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+
+			getAncestors := func(dashboardId int64) []int64 {
+				ancestors := make([]*DashboardAncestor, 0)
+				err := x.Where("dashboard_id = ?", dashboardId).OrderBy("depth").Find(&ancestors)
+				So(err, ShouldBeNil)
+
+				ids := make([]int64, 0)
+				for _, a := range ancestors {
+					ids = append(ids, a.AncestorId)
+				}
+				return ids
+			}
+
+			searchDashboard := func(user *models.SignedInUser) []*search.Hit {
+				query := &search.FindPersistedDashboardsQuery{
+					SignedInUser: user,
+					OrgId:        1,
+					DashboardIds: []int64{dash.Id},
+					Permission:   models.PERMISSION_VIEW,
+				}
+				err := SearchDashboards(query)
+				So(err, ShouldBeNil)
+				return query.Result
+			}
+
+			Convey("should store the ancestors of the dashboard", func() {
+				So(getAncestors(dash.Id), ShouldResemble, []int64{subfolder.Id, folder.Id})
+				So(getAncestors(subfolder.Id), ShouldResemble, []int64{folder.Id})
+			})
+
+			Convey("should return the folder path in search hits", func() {
+				hits := searchDashboard(signedInViewer)
+				So(len(hits), ShouldEqual, 1)
+				So(len(hits[0].FolderPath), ShouldEqual, 2)
+				So(hits[0].FolderPath[0].Uid, ShouldEqual, folder.Uid)
+				So(hits[0].FolderPath[1].Uid, ShouldEqual, subfolder.Uid)
+				So(hits[0].FolderPath[1].Title, ShouldEqual, "child")
+			})
+
+			Convey("when moving the subfolder to the General folder", func() {
+				cmd := models.SaveDashboardCommand{
+					OrgId:     1,
+					IsFolder:  true,
+					Dashboard: subfolder.Data,
+					Overwrite: true,
+				}
+				err := SaveDashboard(&cmd)
+				So(err, ShouldBeNil)
+
+				Convey("should update the ancestors of its content", func() {
+					So(getAncestors(subfolder.Id), ShouldResemble, []int64{})
+					So(getAncestors(dash.Id), ShouldResemble, []int64{subfolder.Id})
+				})
+			})
+
+			Convey("when moving the folder into its subfolder", func() {
+				cmd := models.SaveDashboardCommand{
+					OrgId:     1,
+					FolderId:  subfolder.Id,
+					IsFolder:  true,
+					Dashboard: folder.Data,
+					Overwrite: true,
+				}
+				err := SaveDashboard(&cmd)
+
+				Convey("should return circular reference error", func() {
+					So(err, ShouldEqual, models.ErrFolderCircularReference)
+				})
+			})
+
+			Convey("and no acls are set", func() {
+				Convey("should inherit the default permissions", func() {
+					So(len(searchDashboard(signedInViewer)), ShouldEqual, 1)
+
+					query := models.GetDashboardAclInfoListQuery{DashboardId: dash.Id, OrgId: 1}
+					err := GetDashboardAclInfoList(&query)
+					So(err, ShouldBeNil)
+					So(len(query.Result), ShouldEqual, 2)
+					So(query.Result[0].Inherited, ShouldBeTrue)
+				})
+			})
+
+			Convey("and acl is set for the top level folder", func() {
+				err := testHelperUpdateDashboardAcl(folder.Id, models.DashboardAcl{
+					DashboardId: folder.Id,
+					OrgId:       1,
+					UserId:      viewer.Id,
+					Permission:  models.PERMISSION_EDIT,
+				})
+				So(err, ShouldBeNil)
+
+				Convey("should inherit the permissions of the top level folder", func() {
+					So(len(searchDashboard(signedInViewer)), ShouldEqual, 1)
+
+					query := models.GetDashboardAclInfoListQuery{DashboardId: dash.Id, OrgId: 1}
+					err := GetDashboardAclInfoList(&query)
+					So(err, ShouldBeNil)
+					So(len(query.Result), ShouldEqual, 1)
+					So(query.Result[0].DashboardId, ShouldEqual, folder.Id)
+					So(query.Result[0].UserId, ShouldEqual, viewer.Id)
+					So(query.Result[0].Inherited, ShouldBeTrue)
+				})
+
+				Convey("should not return the dashboard to other users", func() {
+					other := createUser("other", "Viewer", false)
+					hits := searchDashboard(&models.SignedInUser{UserId: other.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER})
+					So(len(hits), ShouldEqual, 0)
+				})
+			})
+
+			Convey("and the middle folder restricts viewers", func() {
+				err := testHelperUpdateDashboardAcl(folder.Id, models.DashboardAcl{
+					DashboardId: folder.Id,
+					OrgId:       1,
+					UserId:      viewer.Id,
+					Permission:  models.PERMISSION_EDIT,
+				})
+				So(err, ShouldBeNil)
+				editorRole := models.ROLE_EDITOR
+				err = testHelperUpdateDashboardAcl(subfolder.Id, models.DashboardAcl{
+					DashboardId: subfolder.Id,
+					OrgId:       1,
+					Role:        &editorRole,
+					Permission:  models.PERMISSION_VIEW,
+				})
+				So(err, ShouldBeNil)
+
+				Convey("should only inherit the permissions of the middle folder", func() {
+					query := models.GetDashboardAclInfoListQuery{DashboardId: dash.Id, OrgId: 1}
+					err := GetDashboardAclInfoList(&query)
+					So(err, ShouldBeNil)
+					So(len(query.Result), ShouldEqual, 1)
+					So(query.Result[0].DashboardId, ShouldEqual, subfolder.Id)
+					So(*query.Result[0].Role, ShouldEqual, models.ROLE_EDITOR)
+					So(query.Result[0].Inherited, ShouldBeTrue)
+				})
+
+				Convey("should not give viewers access to the dashboard", func() {
+					So(len(searchDashboard(signedInViewer)), ShouldEqual, 0)
+
+					canView, err := guardian.New(dash.Id, 1, signedInViewer).CanView()
+					So(err, ShouldBeNil)
+					So(canView, ShouldBeFalse)
+				})
+
+				Convey("should give editors access to the dashboard", func() {
+					editor := createUser("editor", "Editor", false)
+					signedInEditor := &models.SignedInUser{UserId: editor.Id, OrgId: 1, OrgRole: models.ROLE_EDITOR}
+					So(len(searchDashboard(signedInEditor)), ShouldEqual, 1)
+
+					canView, err := guardian.New(dash.Id, 1, signedInEditor).CanView()
+					So(err, ShouldBeNil)
+					So(canView, ShouldBeTrue)
+				})
+
+				Convey("should keep the permissions of the top level folder for the middle folder", func() {
+					canEdit, err := guardian.New(subfolder.Id, 1, signedInViewer).CanEdit()
+					So(err, ShouldBeNil)
+					So(canEdit, ShouldBeTrue)
+				})
+			})
+
+			Convey("when deleting the top level folder", func() {
+				err := DeleteDashboard(&models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1})
+				So(err, ShouldBeNil)
+
+				Convey("should delete the subfolder and its dashboards", func() {
+					err := GetDashboard(&models.GetDashboardQuery{Id: subfolder.Id, OrgId: 1})
+					So(err, ShouldEqual, models.ErrDashboardNotFound)
+					err = GetDashboard(&models.GetDashboardQuery{Id: dash.Id, OrgId: 1})
+					So(err, ShouldEqual, models.ErrDashboardNotFound)
+
+					So(getAncestors(dash.Id), ShouldResemble, []int64{})
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,211 @@
+package sqlstore
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+)
+
+const (
+	dashboardContentRebuildBatchSize = 100
+	// dashboardContentTermBatchSize keeps multi row inserts below the
+	// parameter limits of the databases
+	dashboardContentTermBatchSize = 200
+)
+
+func init() {
+	bus.AddHandler("sql", RebuildDashboardContentIndex)
+}
+
+// RebuildDashboardContentIndex indexes the content of all dashboards again
+// and removes the content of dashboards that no longer exist.
+func RebuildDashboardContentIndex(cmd *models.RebuildDashboardContentIndexCommand) error {
+	err := inTransaction(func(sess *DBSession) error {
+		for _, sql := range []string{
+			"DELETE FROM dashboard_content_term WHERE dashboard_id NOT IN (SELECT id FROM dashboard WHERE is_folder = " + dialect.BooleanStr(false) + ")",
+			"DELETE FROM dashboard_content WHERE dashboard_id NOT IN (SELECT id FROM dashboard WHERE is_folder = " + dialect.BooleanStr(false) + ")",
+		} {
+			if _, err := sess.Exec(sql); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var afterId int64
+	for {
+		dashboards := make([]*models.Dashboard, 0)
+		err := x.Where("id > ? AND is_folder = "+dialect.BooleanStr(false), afterId).OrderBy("id").Limit(dashboardContentRebuildBatchSize).Find(&dashboards)
+		if err != nil {
+			return err
+		}
+
+		err = inTransaction(func(sess *DBSession) error {
+			for _, dash := range dashboards {
+				if err := updateDashboardContent(sess, dash); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.Result += int64(len(dashboards))
+		if len(dashboards) < dashboardContentRebuildBatchSize {
+			return nil
+		}
+		afterId = dashboards[len(dashboards)-1].Id
+	}
+}
+
+// updateDashboardContent replaces the indexed content of the dashboard.
+// Library panels are indexed with the model they have when the dashboard is
+// indexed.
+func updateDashboardContent(sess *DBSession, dash *models.Dashboard) error {
+	for _, sql := range []string{
+		"DELETE FROM dashboard_content_term WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_content WHERE dashboard_id = ?",
+	} {
+		if _, err := sess.Exec(sql, dash.Id); err != nil {
+			return err
+		}
+	}
+
+	if dash.IsFolder || dash.Data == nil {
+		return nil
+	}
+
+	// library panels are resolved in a copy, the saved dashboard keeps the references
+	data, err := dash.Data.Encode()
+	if err != nil {
+		return err
+	}
+	resolved := &models.Dashboard{OrgId: dash.OrgId}
+	if resolved.Data, err = simplejson.NewJson(data); err != nil {
+		return err
+	}
+	if err := loadLibraryPanels(sess, resolved); err != nil {
+		return err
+	}
+
+	var defaultDataSource string
+	if _, err := sess.Table("data_source").Where("org_id=? AND is_default=?", dash.OrgId, true).Cols("name").Get(&defaultDataSource); err != nil {
+		return err
+	}
+
+	terms := make([]*DashboardContentTerm, 0)
+	for _, content := range search.ExtractContent(resolved.Data, defaultDataSource) {
+		content.OrgId = dash.OrgId
+		content.DashboardId = dash.Id
+		if _, err := sess.Insert(content); err != nil {
+			return err
+		}
+
+		for _, term := range search.ContentTerms(content) {
+			terms = append(terms, &DashboardContentTerm{
+				OrgId:       dash.OrgId,
+				DashboardId: dash.Id,
+				ContentId:   content.Id,
+				Term:        term,
+			})
+		}
+	}
+
+	for len(terms) > 0 {
+		batch := terms
+		if len(batch) > dashboardContentTermBatchSize {
+			batch = batch[:dashboardContentTermBatchSize]
+		}
+		if _, err := sess.Insert(&batch); err != nil {
+			return err
+		}
+		terms = terms[len(batch):]
+	}
+
+	return nil
+}
+
+// updateLibraryPanelDashboardsContent indexes the content of the dashboards
+// connected to the library panel again.
+func updateLibraryPanelDashboardsContent(sess *DBSession, libraryPanelID int64) error {
+	dashboards := make([]*models.Dashboard, 0)
+	err := sess.Where("id IN (SELECT dashboard_id FROM library_panel_dashboard WHERE library_panel_id = ?)", libraryPanelID).Find(&dashboards)
+	if err != nil {
+		return err
+	}
+
+	for _, dash := range dashboards {
+		if err := updateDashboardContent(sess, dash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// contentFilters returns the filters of a content search, or nil if the query
+// doesn't search the dashboard content.
+func contentFilters(query *search.FindPersistedDashboardsQuery) []searchstore.FilterContent {
+	filters := []searchstore.FilterContent{}
+
+	if terms := search.QueryTerms(query.Content); len(terms) > 0 {
+		filters = append(filters, searchstore.ContentFilter{Terms: terms, Kinds: query.ContentKinds})
+	}
+
+	if query.DataSource != "" {
+		filters = append(filters, searchstore.DataSourceFilter{Name: query.DataSource})
+	}
+
+	return filters
+}
+
+// setHitContentMatches sets the content of the hits matched by the filters.
+func setHitContentMatches(hits []*search.Hit, filters []searchstore.FilterContent) error {
+	if len(hits) == 0 || len(filters) == 0 {
+		return nil
+	}
+
+	byId := make(map[int64]*search.Hit, len(hits))
+	params := make([]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		byId[hit.Id] = hit
+		params = append(params, hit.Id)
+	}
+
+	wheres := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		where, filterParams := filter.ContentWhere()
+		wheres = append(wheres, "("+where+")")
+		params = append(params, filterParams...)
+	}
+
+	sql := `SELECT * FROM dashboard_content
+		WHERE dashboard_content.dashboard_id IN (?` + strings.Repeat(",?", len(hits)-1) + `) AND (` + strings.Join(wheres, " OR ") + `)
+		ORDER BY dashboard_content.id`
+
+	content := make([]*models.DashboardContent, 0)
+	if err := x.SQL(sql, params...).Find(&content); err != nil {
+		return err
+	}
+
+	for _, item := range content {
+		hit := byId[item.DashboardId]
+		hit.Matches = append(hit.Matches, &search.ContentMatch{
+			Kind:    item.Kind,
+			PanelId: item.PanelId,
+			Name:    item.Name,
+			Text:    item.Text,
+		})
+	}
+
+	return nil
+}
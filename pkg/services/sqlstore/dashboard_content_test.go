@@ -0,0 +1,156 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+func TestDashboardContentIndex(t *testing.T) {
+	InitTestDB(t)
+
+	require.NoError(t, AddDataSource(&models.AddDataSourceCommand{OrgId: 1, Name: "Prometheus", Type: "prometheus", Access: models.DS_ACCESS_PROXY, IsDefault: true}))
+
+	saveDashboard := func(t *testing.T, folderId int64, data map[string]interface{}) *models.Dashboard {
+		cmd := &models.SaveDashboardCommand{OrgId: 1, FolderId: folderId, Dashboard: simplejson.NewFromAny(data), Overwrite: true}
+		require.NoError(t, SaveDashboard(cmd))
+		return cmd.Result
+	}
+
+	searchContent := func(t *testing.T, query search.FindPersistedDashboardsQuery) search.HitList {
+		query.SignedInUser = &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN}
+		query.Permission = models.PERMISSION_VIEW
+		require.NoError(t, SearchDashboards(&query))
+		return query.Result
+	}
+
+	cpuPanel := map[string]interface{}{
+		"id":    1,
+		"title": "CPU usage",
+		"targets": []interface{}{
+			map[string]interface{}{"refId": "A", "expr": "rate(node_cpu_seconds_total[5m])"},
+		},
+	}
+
+	folderCmd := &models.SaveDashboardCommand{OrgId: 1, IsFolder: true, Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Infrastructure"})}
+	require.NoError(t, SaveDashboard(folderCmd))
+	folder := folderCmd.Result
+
+	nodes := saveDashboard(t, folder.Id, map[string]interface{}{"title": "Nodes", "panels": []interface{}{cpuPanel}})
+	web := saveDashboard(t, 0, map[string]interface{}{
+		"title": "Web",
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id":         2,
+				"title":      "Requests",
+				"datasource": "Graphite",
+				"targets": []interface{}{
+					map[string]interface{}{"refId": "A", "target": "servers.web1.requests"},
+				},
+			},
+		},
+	})
+
+	t.Run("Should find dashboards by query expression", func(t *testing.T) {
+		hits := searchContent(t, search.FindPersistedDashboardsQuery{Content: "node_cpu_seconds"})
+		require.Len(t, hits, 1)
+		require.Equal(t, nodes.Id, hits[0].Id)
+		require.Equal(t, []*search.ContentMatch{
+			{Kind: models.DashboardContentQuery, PanelId: 1, Name: "CPU usage", Text: "rate(node_cpu_seconds_total[5m])"},
+		}, hits[0].Matches)
+
+		hits = searchContent(t, search.FindPersistedDashboardsQuery{Content: "web1 requests"})
+		require.Len(t, hits, 1)
+		require.Equal(t, web.Id, hits[0].Id)
+	})
+
+	t.Run("Should limit content search to the content kinds", func(t *testing.T) {
+		hits := searchContent(t, search.FindPersistedDashboardsQuery{Content: "requests", ContentKinds: []string{models.DashboardContentPanelTitle}})
+		require.Len(t, hits, 1)
+		require.Equal(t, models.DashboardContentPanelTitle, hits[0].Matches[0].Kind)
+
+		hits = searchContent(t, search.FindPersistedDashboardsQuery{Content: "web1", ContentKinds: []string{models.DashboardContentPanelTitle}})
+		require.Len(t, hits, 0)
+	})
+
+	t.Run("Should find dashboards by data source, including the default data source", func(t *testing.T) {
+		hits := searchContent(t, search.FindPersistedDashboardsQuery{DataSource: "prometheus"})
+		require.Len(t, hits, 1)
+		require.Equal(t, nodes.Id, hits[0].Id)
+
+		hits = searchContent(t, search.FindPersistedDashboardsQuery{DataSource: "Graphite", Content: "node"})
+		require.Len(t, hits, 0)
+	})
+
+	t.Run("Should index library panels and update dashboards when they change", func(t *testing.T) {
+		createCmd := &models.CreateLibraryPanelCommand{
+			OrgId: 1,
+			Name:  "Memory",
+			Model: simplejson.NewFromAny(map[string]interface{}{
+				"type":    "graph",
+				"title":   "Memory usage",
+				"targets": []interface{}{map[string]interface{}{"refId": "A", "expr": "node_memory_MemFree_bytes"}},
+			}),
+		}
+		require.NoError(t, CreateLibraryPanel(createCmd))
+
+		dash := saveDashboard(t, 0, map[string]interface{}{
+			"title": "Memory",
+			"panels": []interface{}{
+				map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": createCmd.Result.Uid, "name": "Memory"}},
+			},
+		})
+
+		hits := searchContent(t, search.FindPersistedDashboardsQuery{Content: "node_memory_memfree"})
+		require.Len(t, hits, 1)
+		require.Equal(t, dash.Id, hits[0].Id)
+
+		require.NoError(t, UpdateLibraryPanel(&models.UpdateLibraryPanelCommand{
+			OrgId:   1,
+			Uid:     createCmd.Result.Uid,
+			Version: 1,
+			Model: simplejson.NewFromAny(map[string]interface{}{
+				"type":    "graph",
+				"title":   "Memory usage",
+				"targets": []interface{}{map[string]interface{}{"refId": "A", "expr": "node_memory_MemAvailable_bytes"}},
+			}),
+		}))
+
+		require.Len(t, searchContent(t, search.FindPersistedDashboardsQuery{Content: "node_memory_memfree"}), 0)
+		require.Len(t, searchContent(t, search.FindPersistedDashboardsQuery{Content: "node_memory_memavailable"}), 1)
+	})
+
+	t.Run("Should remove the content of deleted dashboards and folders", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+
+		count, err := x.Table("dashboard_content").Where("dashboard_id = ?", nodes.Id).Count()
+		require.NoError(t, err)
+		require.Zero(t, count)
+		count, err = x.Table("dashboard_content_term").Where("dashboard_id = ?", nodes.Id).Count()
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+
+	t.Run("Should rebuild the index", func(t *testing.T) {
+		_, err := x.Exec("DELETE FROM dashboard_content_term")
+		require.NoError(t, err)
+		_, err = x.Exec("INSERT INTO dashboard_content (org_id, dashboard_id, panel_id, kind, name, text) VALUES (1, 9999, 0, 'query', '', 'up')")
+		require.NoError(t, err)
+
+		cmd := &models.RebuildDashboardContentIndexCommand{}
+		require.NoError(t, RebuildDashboardContentIndex(cmd))
+		require.Equal(t, int64(2), cmd.Result)
+
+		count, err := x.Table("dashboard_content").Where("dashboard_id = ?", 9999).Count()
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		hits := searchContent(t, search.FindPersistedDashboardsQuery{Content: "web1"})
+		require.Len(t, hits, 1)
+		require.Equal(t, web.Id, hits[0].Id)
+	})
+}
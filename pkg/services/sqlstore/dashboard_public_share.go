@@ -0,0 +1,93 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateDashboardPublicShare)
+	bus.AddHandler("sql", RevokeDashboardPublicShare)
+	bus.AddHandler("sql", GetDashboardPublicShares)
+	bus.AddHandler("sql", GetDashboardPublicShareByToken)
+}
+
+func CreateDashboardPublicShare(cmd *models.CreateDashboardPublicShareCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		now := time.Now()
+
+		// never
+		expires := now.Add(time.Hour * 24 * 365 * 50)
+		if cmd.Expires > 0 {
+			expires = now.Add(time.Second * time.Duration(cmd.Expires))
+		}
+
+		share := &models.DashboardPublicShare{
+			Uid:         util.GenerateShortUID(),
+			OrgId:       cmd.OrgId,
+			DashboardId: cmd.DashboardId,
+			TokenHash:   cmd.TokenHash,
+			TimeLocked:  cmd.TimeLocked,
+			TimeFrom:    cmd.TimeFrom,
+			TimeTo:      cmd.TimeTo,
+			CreatedBy:   cmd.UserId,
+			Expires:     expires,
+			Created:     now,
+			Updated:     now,
+		}
+
+		if _, err := sess.Insert(share); err != nil {
+			return err
+		}
+
+		cmd.Result = share
+		return nil
+	})
+}
+
+func RevokeDashboardPublicShare(cmd *models.RevokeDashboardPublicShareCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("UPDATE dashboard_public_share SET revoked = "+dialect.BooleanStr(true)+", updated = ? WHERE org_id = ? AND dashboard_id = ? AND uid = ?",
+			time.Now(), cmd.OrgId, cmd.DashboardId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		if rows, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rows == 0 {
+			return models.ErrDashboardPublicShareNotFound
+		}
+
+		return nil
+	})
+}
+
+func GetDashboardPublicShares(query *models.GetDashboardPublicSharesQuery) error {
+	query.Result = make([]*models.DashboardPublicShare, 0)
+	return x.Where("org_id = ? AND dashboard_id = ?", query.OrgId, query.DashboardId).Desc("created").Find(&query.Result)
+}
+
+// GetDashboardPublicShareByToken returns the share of the token hash, unless
+// its dashboard or organization has been deleted.
+func GetDashboardPublicShareByToken(query *models.GetDashboardPublicShareByTokenQuery) error {
+	share := &models.DashboardPublicShare{}
+	has, err := x.Table("dashboard_public_share").
+		Join("INNER", "dashboard", "dashboard.id = dashboard_public_share.dashboard_id AND dashboard.org_id = dashboard_public_share.org_id").
+		Where("dashboard_public_share.token_hash = ? AND dashboard.is_folder = "+dialect.BooleanStr(false), query.TokenHash).
+		And(notInDeletedOrg("dashboard_public_share.org_id")).
+		Select("dashboard_public_share.*").
+		Get(share)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return models.ErrDashboardPublicShareNotFound
+	}
+
+	query.Result = share
+	return nil
+}
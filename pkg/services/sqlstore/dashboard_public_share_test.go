@@ -0,0 +1,123 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDashboardPublicShares(t *testing.T) {
+	InitTestDB(t)
+
+	saveCmd := &models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Status"}),
+	}
+	require.NoError(t, SaveDashboard(saveCmd))
+	dash := saveCmd.Result
+
+	create := func(t *testing.T, tokenHash string, expires int64) *models.DashboardPublicShare {
+		cmd := &models.CreateDashboardPublicShareCommand{
+			OrgId:       1,
+			DashboardId: dash.Id,
+			UserId:      2,
+			TokenHash:   tokenHash,
+			TimeLocked:  true,
+			TimeFrom:    "now-7d",
+			TimeTo:      "now",
+			Expires:     expires,
+		}
+		require.NoError(t, CreateDashboardPublicShare(cmd))
+		return cmd.Result
+	}
+
+	never := create(t, "hash-1", 0)
+	hour := create(t, "hash-2", 3600)
+
+	t.Run("Should get shares by token hash", func(t *testing.T) {
+		query := &models.GetDashboardPublicShareByTokenQuery{TokenHash: "hash-1"}
+		require.NoError(t, GetDashboardPublicShareByToken(query))
+
+		assert.Equal(t, never.Uid, query.Result.Uid)
+		assert.Equal(t, dash.Id, query.Result.DashboardId)
+		assert.True(t, query.Result.TimeLocked)
+		assert.Equal(t, "now-7d", query.Result.TimeFrom)
+		assert.True(t, query.Result.IsActive(time.Now().AddDate(10, 0, 0)))
+
+		query = &models.GetDashboardPublicShareByTokenQuery{TokenHash: "hash-2"}
+		require.NoError(t, GetDashboardPublicShareByToken(query))
+		assert.True(t, query.Result.IsActive(time.Now()))
+		assert.False(t, query.Result.IsActive(time.Now().Add(2*time.Hour)))
+
+		query = &models.GetDashboardPublicShareByTokenQuery{TokenHash: "unknown"}
+		assert.Equal(t, models.ErrDashboardPublicShareNotFound, GetDashboardPublicShareByToken(query))
+	})
+
+	t.Run("Should list the shares of a dashboard", func(t *testing.T) {
+		query := &models.GetDashboardPublicSharesQuery{OrgId: 1, DashboardId: dash.Id}
+		require.NoError(t, GetDashboardPublicShares(query))
+		assert.Len(t, query.Result, 2)
+
+		query = &models.GetDashboardPublicSharesQuery{OrgId: 2, DashboardId: dash.Id}
+		require.NoError(t, GetDashboardPublicShares(query))
+		assert.Empty(t, query.Result)
+	})
+
+	t.Run("Should revoke shares", func(t *testing.T) {
+		err := RevokeDashboardPublicShare(&models.RevokeDashboardPublicShareCommand{OrgId: 2, DashboardId: dash.Id, Uid: hour.Uid})
+		assert.Equal(t, models.ErrDashboardPublicShareNotFound, err)
+
+		require.NoError(t, RevokeDashboardPublicShare(&models.RevokeDashboardPublicShareCommand{OrgId: 1, DashboardId: dash.Id, Uid: hour.Uid}))
+
+		query := &models.GetDashboardPublicShareByTokenQuery{TokenHash: "hash-2"}
+		require.NoError(t, GetDashboardPublicShareByToken(query))
+		assert.True(t, query.Result.Revoked)
+		assert.False(t, query.Result.IsActive(time.Now()))
+	})
+
+	t.Run("Should not get shares whose dashboard is missing", func(t *testing.T) {
+		require.NoError(t, CreateDashboardPublicShare(&models.CreateDashboardPublicShareCommand{
+			OrgId:       1,
+			DashboardId: 12345,
+			TokenHash:   "hash-4",
+		}))
+
+		query := &models.GetDashboardPublicShareByTokenQuery{TokenHash: "hash-4"}
+		assert.Equal(t, models.ErrDashboardPublicShareNotFound, GetDashboardPublicShareByToken(query))
+	})
+
+	t.Run("Should delete the shares of deleted dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1}))
+
+		query := &models.GetDashboardPublicShareByTokenQuery{TokenHash: "hash-1"}
+		assert.Equal(t, models.ErrDashboardPublicShareNotFound, GetDashboardPublicShareByToken(query))
+	})
+
+	t.Run("Should not get shares of deleted organizations", func(t *testing.T) {
+		orgCmd := &models.CreateOrgCommand{Name: "deleted", UserId: 1}
+		require.NoError(t, CreateOrg(orgCmd))
+		orgDash := &models.SaveDashboardCommand{
+			OrgId:     orgCmd.Result.Id,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Other"}),
+		}
+		require.NoError(t, SaveDashboard(orgDash))
+		require.NoError(t, CreateDashboardPublicShare(&models.CreateDashboardPublicShareCommand{
+			OrgId:       orgCmd.Result.Id,
+			DashboardId: orgDash.Result.Id,
+			TokenHash:   "hash-3",
+		}))
+
+		query := &models.GetDashboardPublicShareByTokenQuery{TokenHash: "hash-3"}
+		require.NoError(t, GetDashboardPublicShareByToken(query))
+
+		require.NoError(t, inTransaction(func(sess *DBSession) error {
+			return softDeleteOrg(sess, orgCmd.Result.Id)
+		}))
+		assert.Equal(t, models.ErrDashboardPublicShareNotFound, GetDashboardPublicShareByToken(query))
+	})
+}
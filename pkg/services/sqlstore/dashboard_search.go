@@ -0,0 +1,107 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+)
+
+// cursorFilters returns the filters paging through the result in the order
+// of the sort filter of the query, or by title if the query isn't sorted.
+func cursorFilters(query *search.FindPersistedDashboardsQuery) ([]interface{}, error) {
+	var sorter searchstore.FilterSortColumn
+	for _, filter := range query.Filters {
+		if _, ok := filter.(searchstore.FilterOrderBy); !ok {
+			continue
+		}
+
+		// keyset paging needs a single sort column
+		sortColumn, ok := filter.(searchstore.FilterSortColumn)
+		if !ok || sorter != nil {
+			return nil, search.ErrCursorSortNotSupported
+		}
+		sorter = sortColumn
+	}
+
+	filters := []interface{}{}
+	if sorter == nil {
+		sorter = searchstore.TitleSorter{}
+		filters = append(filters, sorter)
+	}
+
+	return append(filters, searchstore.CursorFilter{Sorter: sorter, AfterId: query.AfterId}), nil
+}
+
+type facetCount struct {
+	Term     string
+	IsFolder bool
+	FolderId int64
+	Uid      string
+	Title    string
+	Count    int64
+}
+
+// setSearchFacets counts the hits of all pages of the query by the requested
+// facets.
+func setSearchFacets(query *search.FindPersistedDashboardsQuery) error {
+	if len(query.Facets) == 0 {
+		return nil
+	}
+
+	sb := &searchstore.Builder{Dialect: dialect, Filters: searchFilters(query)}
+	idSql, params := sb.ToIdSql()
+
+	facets := &search.Facets{}
+	for _, facet := range query.Facets {
+		var sql string
+		switch facet {
+		case search.FacetTag:
+			sql = `SELECT dashboard_tag.term AS term, COUNT(*) AS count
+				FROM dashboard_tag
+				WHERE dashboard_tag.dashboard_id IN (` + idSql + `)
+				GROUP BY dashboard_tag.term
+				ORDER BY count DESC, term`
+		case search.FacetFolder:
+			sql = `SELECT dashboard.folder_id AS folder_id, folder.uid AS uid, folder.title AS title, COUNT(*) AS count
+				FROM dashboard
+				LEFT OUTER JOIN dashboard AS folder ON folder.id = dashboard.folder_id
+				WHERE dashboard.id IN (` + idSql + `)
+				GROUP BY dashboard.folder_id, folder.uid, folder.title
+				ORDER BY count DESC, title`
+		case search.FacetType:
+			sql = `SELECT dashboard.is_folder AS is_folder, COUNT(*) AS count
+				FROM dashboard
+				WHERE dashboard.id IN (` + idSql + `)
+				GROUP BY dashboard.is_folder
+				ORDER BY count DESC`
+		default:
+			continue
+		}
+
+		counts := make([]*facetCount, 0)
+		if err := x.SQL(sql, params...).Find(&counts); err != nil {
+			return err
+		}
+
+		for _, count := range counts {
+			switch facet {
+			case search.FacetTag:
+				facets.Tags = append(facets.Tags, &search.FacetCount{Value: count.Term, Count: count.Count})
+			case search.FacetFolder:
+				folder := &search.FolderFacetCount{Id: count.FolderId, Uid: count.Uid, Title: count.Title, Count: count.Count}
+				if folder.Id == 0 {
+					folder.Title = "General"
+				}
+				facets.Folders = append(facets.Folders, folder)
+			case search.FacetType:
+				hitType := search.DashHitDB
+				if count.IsFolder {
+					hitType = search.DashHitFolder
+				}
+				facets.Types = append(facets.Types, &search.FacetCount{Value: string(hitType), Count: count.Count})
+			}
+		}
+	}
+
+	query.FacetResult = facets
+	return nil
+}
@@ -0,0 +1,132 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+)
+
+func TestDashboardSearchPagingAndFacets(t *testing.T) {
+	InitTestDB(t)
+
+	save := func(t *testing.T, title string, folderId int64, isFolder bool, tags ...interface{}) *models.Dashboard {
+		cmd := &models.SaveDashboardCommand{
+			OrgId:     1,
+			FolderId:  folderId,
+			IsFolder:  isFolder,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title, "tags": tags}),
+		}
+		require.NoError(t, SaveDashboard(cmd))
+		return cmd.Result
+	}
+
+	find := func(t *testing.T, query search.FindPersistedDashboardsQuery) *search.FindPersistedDashboardsQuery {
+		query.SignedInUser = &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN}
+		query.Permission = models.PERMISSION_VIEW
+		require.NoError(t, SearchDashboards(&query))
+		return &query
+	}
+
+	titles := func(hits search.HitList) []string {
+		result := []string{}
+		for _, hit := range hits {
+			result = append(result, hit.Title)
+		}
+		return result
+	}
+
+	folder := save(t, "Folder", 0, true)
+	a := save(t, "A", 0, false, "prod")
+	save(t, "B", folder.Id, false, "prod", "web")
+	c := save(t, "C", folder.Id, false, "web")
+	d := save(t, "D", 0, false)
+
+	t.Run("Should page through the result with cursors", func(t *testing.T) {
+		result := []string{}
+		query := search.FindPersistedDashboardsQuery{Limit: 2, CursorPaging: true}
+		for {
+			page := find(t, query)
+			result = append(result, titles(page.Result)...)
+			if !page.HasMore {
+				break
+			}
+			query.AfterId = page.Result[len(page.Result)-1].Id
+		}
+		require.Equal(t, []string{"A", "B", "C", "D", "Folder"}, result)
+	})
+
+	t.Run("Should page through the result in the order of the sort filter", func(t *testing.T) {
+		for _, dash := range []*models.Dashboard{c, c, c, a, d, d} {
+			viewCount := &models.DashboardViewCount{OrgId: 1, DashboardId: dash.Id, Views: 1, LastViewed: time.Now()}
+			err := inTransaction(func(sess *DBSession) error {
+				return addDashboardViewCount(sess, viewCount)
+			})
+			require.NoError(t, err)
+		}
+
+		query := search.FindPersistedDashboardsQuery{
+			Type:         searchstore.TypeDashboard,
+			Limit:        2,
+			CursorPaging: true,
+			Filters:      []interface{}{searchstore.ViewsSorter{Descending: true}},
+		}
+		page := find(t, query)
+		require.Equal(t, []string{"C", "D"}, titles(page.Result))
+		require.True(t, page.HasMore)
+
+		query.AfterId = d.Id
+		page = find(t, query)
+		require.Equal(t, []string{"A", "B"}, titles(page.Result))
+		require.False(t, page.HasMore)
+	})
+
+	t.Run("Should reject sort filters without a sort column", func(t *testing.T) {
+		query := search.FindPersistedDashboardsQuery{
+			SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+			CursorPaging: true,
+			Filters:      []interface{}{searchstore.TitleSorter{}, searchstore.UpdatedSorter{}},
+		}
+		require.Equal(t, search.ErrCursorSortNotSupported, SearchDashboards(&query))
+	})
+
+	t.Run("Should count the hits of all pages by facet", func(t *testing.T) {
+		page := find(t, search.FindPersistedDashboardsQuery{
+			Limit:  1,
+			Tags:   []string{"web"},
+			Facets: []string{search.FacetTag, search.FacetFolder, search.FacetType},
+		})
+		require.Len(t, page.Result, 1)
+		require.Equal(t, &search.Facets{
+			Tags: []*search.FacetCount{
+				{Value: "web", Count: 2},
+				{Value: "prod", Count: 1},
+			},
+			Folders: []*search.FolderFacetCount{
+				{Id: folder.Id, Uid: folder.Uid, Title: "Folder", Count: 2},
+			},
+			Types: []*search.FacetCount{
+				{Value: string(search.DashHitDB), Count: 2},
+			},
+		}, page.FacetResult)
+
+		page = find(t, search.FindPersistedDashboardsQuery{Facets: []string{search.FacetFolder}})
+		require.Equal(t, []*search.FolderFacetCount{
+			{Id: 0, Title: "General", Count: 3},
+			{Id: folder.Id, Uid: folder.Uid, Title: "Folder", Count: 2},
+		}, page.FacetResult.Folders)
+	})
+
+	t.Run("Should remove the view count of deleted dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+
+		count, err := x.Table("dashboard_view_count").Where("dashboard_id = ?", c.Id).Count()
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+}
@@ -14,6 +14,10 @@ func init() {
 	bus.AddHandler("sql", DeleteDashboardSnapshot)
 	bus.AddHandler("sql", SearchDashboardSnapshots)
 	bus.AddHandler("sql", DeleteExpiredSnapshots)
+	bus.AddHandler("sql", RefreshDashboardSnapshot)
+	bus.AddHandler("sql", GetDueDashboardSnapshots)
+	bus.AddHandler("sql", GetDashboardSnapshotVersions)
+	bus.AddHandler("sql", GetDashboardSnapshotVersion)
 }
 
 // DeleteExpiredSnapshots removes snapshots with old expiry dates.
@@ -26,8 +30,14 @@ func DeleteExpiredSnapshots(cmd *models.DeleteExpiredSnapshotsCommand) error {
 			return nil
 		}
 
+		now := time.Now()
+		deleteVersionsSql := "DELETE FROM dashboard_snapshot_version WHERE snapshot_id IN (SELECT id FROM dashboard_snapshot WHERE expires < ?)"
+		if _, err := sess.Exec(deleteVersionsSql, now); err != nil {
+			return err
+		}
+
 		deleteExpiredSql := "DELETE FROM dashboard_snapshot WHERE expires < ?"
-		expiredResponse, err := sess.Exec(deleteExpiredSql, time.Now())
+		expiredResponse, err := sess.Exec(deleteExpiredSql, now)
 		if err != nil {
 			return err
 		}
@@ -45,6 +55,12 @@ func CreateDashboardSnapshot(cmd *models.CreateDashboardSnapshotCommand) error {
 			expires = time.Now().Add(time.Second * time.Duration(cmd.Expires))
 		}
 
+		now := time.Now()
+		var nextRefresh time.Time
+		if cmd.RefreshInterval > 0 {
+			nextRefresh = now.Add(time.Second * time.Duration(cmd.RefreshInterval))
+		}
+
 		snapshot := &models.DashboardSnapshot{
 			Name:              cmd.Name,
 			Key:               cmd.Key,
@@ -55,20 +71,33 @@ func CreateDashboardSnapshot(cmd *models.CreateDashboardSnapshotCommand) error {
 			ExternalUrl:       cmd.ExternalUrl,
 			ExternalDeleteUrl: cmd.ExternalDeleteUrl,
 			Dashboard:         cmd.Dashboard,
+			DashboardId:       cmd.DashboardId,
+			TimeFrom:          cmd.TimeFrom,
+			TimeTo:            cmd.TimeTo,
+			RefreshInterval:   cmd.RefreshInterval,
+			NextRefresh:       nextRefresh,
+			Version:           1,
 			Expires:           expires,
-			Created:           time.Now(),
-			Updated:           time.Now(),
+			Created:           now,
+			Updated:           now,
 		}
 
-		_, err := sess.Insert(snapshot)
+		if _, err := sess.Insert(snapshot); err != nil {
+			return err
+		}
 		cmd.Result = snapshot
 
-		return err
+		return checkOrgQuota(sess, cmd.OrgId, "dashboard_snapshot")
 	})
 }
 
 func DeleteDashboardSnapshot(cmd *models.DeleteDashboardSnapshotCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		var versionsSql = "DELETE FROM dashboard_snapshot_version WHERE snapshot_id IN (SELECT id FROM dashboard_snapshot WHERE delete_key=?)"
+		if _, err := sess.Exec(versionsSql, cmd.DeleteKey); err != nil {
+			return err
+		}
+
 		var rawSql = "DELETE FROM dashboard_snapshot WHERE delete_key=?"
 		_, err := sess.Exec(rawSql, cmd.DeleteKey)
 		return err
@@ -116,3 +145,94 @@ func SearchDashboardSnapshots(query *models.GetDashboardSnapshotsQuery) error {
 	query.Result = snapshots
 	return err
 }
+
+// RefreshDashboardSnapshot keeps the current dashboard of the snapshot as a
+// previous version and replaces it with the refreshed dashboard. Previous
+// versions beyond VersionsToKeep are deleted.
+func RefreshDashboardSnapshot(cmd *models.RefreshDashboardSnapshotCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		snapshot := &models.DashboardSnapshot{}
+		has, err := sess.ID(cmd.Id).Get(snapshot)
+		if err != nil {
+			return err
+		} else if !has {
+			return models.ErrDashboardSnapshotNotFound
+		}
+
+		now := time.Now()
+		cols := []string{"refresh_error"}
+		snapshot.RefreshError = cmd.Error
+
+		if cmd.StopRefresh {
+			snapshot.RefreshInterval = 0
+			cols = append(cols, "refresh_interval")
+		} else if snapshot.RefreshInterval > 0 {
+			snapshot.NextRefresh = now.Add(time.Second * time.Duration(snapshot.RefreshInterval))
+			cols = append(cols, "next_refresh")
+		}
+
+		if cmd.Dashboard != nil {
+			previous := &models.DashboardSnapshotVersion{
+				SnapshotId: snapshot.Id,
+				Version:    snapshot.Version,
+				Created:    snapshot.Updated,
+				Dashboard:  snapshot.Dashboard,
+			}
+			if _, err := sess.Insert(previous); err != nil {
+				return err
+			}
+
+			snapshot.Version++
+			snapshot.Dashboard = cmd.Dashboard
+			snapshot.Updated = now
+			cols = append(cols, "version", "dashboard", "updated")
+
+			if cmd.VersionsToKeep > 0 {
+				deleteSql := "DELETE FROM dashboard_snapshot_version WHERE snapshot_id = ? AND version <= ?"
+				if _, err := sess.Exec(deleteSql, snapshot.Id, previous.Version-cmd.VersionsToKeep); err != nil {
+					return err
+				}
+			}
+		}
+
+		if _, err := sess.ID(snapshot.Id).Cols(cols...).Update(snapshot); err != nil {
+			return err
+		}
+
+		cmd.Result = snapshot
+		return nil
+	})
+}
+
+// GetDueDashboardSnapshots returns the snapshots generated by the server
+// that are due to be refreshed, without their dashboards.
+func GetDueDashboardSnapshots(query *models.GetDueDashboardSnapshotsQuery) error {
+	query.Result = make([]*models.DashboardSnapshot, 0)
+	return x.Omit("dashboard").
+		Where("dashboard_id > 0 AND refresh_interval > 0 AND external = "+dialect.BooleanStr(false)).
+		And("next_refresh <= ? AND expires > ?", query.Now, query.Now).
+		Asc("next_refresh").
+		Find(&query.Result)
+}
+
+func GetDashboardSnapshotVersions(query *models.GetDashboardSnapshotVersionsQuery) error {
+	query.Result = make([]*models.DashboardSnapshotVersionDTO, 0)
+	return x.Table("dashboard_snapshot_version").
+		Cols("version", "created").
+		Where("snapshot_id = ?", query.SnapshotId).
+		Desc("version").
+		Find(&query.Result)
+}
+
+func GetDashboardSnapshotVersion(query *models.GetDashboardSnapshotVersionQuery) error {
+	version := models.DashboardSnapshotVersion{}
+	has, err := x.Where("snapshot_id = ? AND version = ?", query.SnapshotId, query.Version).Get(&version)
+	if err != nil {
+		return err
+	} else if !has {
+		return models.ErrDashboardSnapshotVersionNotFound
+	}
+
+	query.Result = &version
+	return nil
+}
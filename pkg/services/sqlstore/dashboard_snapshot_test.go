@@ -5,6 +5,8 @@ import (
 	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
@@ -145,6 +147,127 @@ func TestDeleteExpiredSnapshots(t *testing.T) {
 	})
 }
 
+func TestRefreshDashboardSnapshots(t *testing.T) {
+	InitTestDB(t)
+
+	saveCmd := &models.SaveDashboardCommand{OrgId: 1, Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Status"})}
+	require.NoError(t, SaveDashboard(saveCmd))
+	dash := saveCmd.Result
+
+	create := func(t *testing.T, key string, dashboardId, refreshInterval int64) *models.DashboardSnapshot {
+		cmd := &models.CreateDashboardSnapshotCommand{
+			Key:             key,
+			DeleteKey:       "delete" + key,
+			Dashboard:       simplejson.NewFromAny(map[string]interface{}{"title": "v1"}),
+			DashboardId:     dashboardId,
+			TimeFrom:        "now-1h",
+			TimeTo:          "now",
+			RefreshInterval: refreshInterval,
+			UserId:          1000,
+			OrgId:           1,
+		}
+		require.NoError(t, CreateDashboardSnapshot(cmd))
+		return cmd.Result
+	}
+
+	scheduled := create(t, "scheduled", dash.Id, 60)
+	create(t, "generated", dash.Id, 0)
+	create(t, "client", 0, 0)
+
+	refresh := func(t *testing.T, title string) *models.DashboardSnapshot {
+		cmd := &models.RefreshDashboardSnapshotCommand{
+			Id:             scheduled.Id,
+			Dashboard:      simplejson.NewFromAny(map[string]interface{}{"title": title}),
+			VersionsToKeep: 2,
+		}
+		require.NoError(t, RefreshDashboardSnapshot(cmd))
+		return cmd.Result
+	}
+
+	t.Run("Should get the snapshots due to be refreshed", func(t *testing.T) {
+		assert.Equal(t, 1, scheduled.Version)
+		assert.Equal(t, "now-1h", scheduled.TimeFrom)
+
+		query := &models.GetDueDashboardSnapshotsQuery{Now: time.Now()}
+		require.NoError(t, GetDueDashboardSnapshots(query))
+		assert.Empty(t, query.Result)
+
+		query = &models.GetDueDashboardSnapshotsQuery{Now: time.Now().Add(2 * time.Minute)}
+		require.NoError(t, GetDueDashboardSnapshots(query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, scheduled.Id, query.Result[0].Id)
+		assert.Equal(t, dash.Id, query.Result[0].DashboardId)
+	})
+
+	t.Run("Should keep the previous versions of refreshed snapshots", func(t *testing.T) {
+		result := refresh(t, "v2")
+		assert.Equal(t, 2, result.Version)
+		assert.True(t, result.NextRefresh.After(time.Now().Add(50*time.Second)))
+
+		refresh(t, "v3")
+		result = refresh(t, "v4")
+		assert.Equal(t, 4, result.Version)
+
+		query := &models.GetDashboardSnapshotQuery{Key: "scheduled"}
+		require.NoError(t, GetDashboardSnapshot(query))
+		assert.Equal(t, "v4", query.Result.Dashboard.Get("title").MustString())
+		assert.Equal(t, 4, query.Result.Version)
+
+		versions := &models.GetDashboardSnapshotVersionsQuery{SnapshotId: scheduled.Id}
+		require.NoError(t, GetDashboardSnapshotVersions(versions))
+		require.Len(t, versions.Result, 2)
+		assert.Equal(t, 3, versions.Result[0].Version)
+		assert.Equal(t, 2, versions.Result[1].Version)
+
+		version := &models.GetDashboardSnapshotVersionQuery{SnapshotId: scheduled.Id, Version: 2}
+		require.NoError(t, GetDashboardSnapshotVersion(version))
+		assert.Equal(t, "v2", version.Result.Dashboard.Get("title").MustString())
+
+		version = &models.GetDashboardSnapshotVersionQuery{SnapshotId: scheduled.Id, Version: 1}
+		assert.Equal(t, models.ErrDashboardSnapshotVersionNotFound, GetDashboardSnapshotVersion(version))
+	})
+
+	t.Run("Should store refresh errors without a new version", func(t *testing.T) {
+		cmd := &models.RefreshDashboardSnapshotCommand{Id: scheduled.Id, Error: "query failed"}
+		require.NoError(t, RefreshDashboardSnapshot(cmd))
+		assert.Equal(t, 4, cmd.Result.Version)
+
+		query := &models.GetDashboardSnapshotQuery{Key: "scheduled"}
+		require.NoError(t, GetDashboardSnapshot(query))
+		assert.Equal(t, "query failed", query.Result.RefreshError)
+		assert.Equal(t, "v4", query.Result.Dashboard.Get("title").MustString())
+	})
+
+	t.Run("Should stop refreshing snapshots that can't be refreshed anymore", func(t *testing.T) {
+		stopped := create(t, "stopped", dash.Id, 60)
+		cmd := &models.RefreshDashboardSnapshotCommand{Id: stopped.Id, Error: "user is disabled", StopRefresh: true}
+		require.NoError(t, RefreshDashboardSnapshot(cmd))
+		assert.Equal(t, int64(0), cmd.Result.RefreshInterval)
+
+		query := &models.GetDueDashboardSnapshotsQuery{Now: time.Now().Add(time.Hour)}
+		require.NoError(t, GetDueDashboardSnapshots(query))
+		for _, snapshot := range query.Result {
+			assert.NotEqual(t, stopped.Id, snapshot.Id)
+		}
+	})
+
+	t.Run("Should stop refreshing snapshots of deleted dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1}))
+
+		query := &models.GetDueDashboardSnapshotsQuery{Now: time.Now().Add(time.Hour)}
+		require.NoError(t, GetDueDashboardSnapshots(query))
+		assert.Empty(t, query.Result)
+	})
+
+	t.Run("Should delete the versions of deleted snapshots", func(t *testing.T) {
+		require.NoError(t, DeleteDashboardSnapshot(&models.DeleteDashboardSnapshotCommand{DeleteKey: "deletescheduled"}))
+
+		versions := &models.GetDashboardSnapshotVersionsQuery{SnapshotId: scheduled.Id}
+		require.NoError(t, GetDashboardSnapshotVersions(versions))
+		assert.Empty(t, versions.Result)
+	})
+}
+
 func createTestSnapshot(sqlstore *SqlStore, key string, expires int64) *models.DashboardSnapshot {
 	cmd := models.CreateDashboardSnapshotCommand{
 		Key:       key,
@@ -336,6 +336,23 @@ func TestDashboardDataAccess(t *testing.T) {
 				So(hit.FolderUrl, ShouldEqual, fmt.Sprintf("/dashboards/f/%s/%s", savedFolder.Uid, savedFolder.Slug))
 			})
 
+			Convey("Should be able to get the dashboards of all orgs in batches", func() {
+				otherOrgDash := insertTestDashboard("test dash other org", 2, 0, false)
+
+				query := models.GetAllDashboardsQuery{Limit: 2}
+				err := GetAllDashboards(&query)
+				So(err, ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 2)
+				So(query.Result[0].Id, ShouldEqual, savedDash.Id)
+
+				query = models.GetAllDashboardsQuery{AfterId: query.Result[1].Id, Limit: 2}
+				err = GetAllDashboards(&query)
+				So(err, ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 2)
+				So(query.Result[1].Id, ShouldEqual, otherOrgDash.Id)
+				So(query.Result[1].OrgId, ShouldEqual, 2)
+			})
+
 			Convey("Should be able to search for dashboard by dashboard ids", func() {
 				Convey("should be able to find two dashboards by id", func() {
 					query := search.FindPersistedDashboardsQuery{
@@ -0,0 +1,368 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// dashboardViewEventBatchSize keeps multi row inserts below the parameter
+// limits of the databases
+const dashboardViewEventBatchSize = 200
+
+func init() {
+	bus.AddHandler("sql", SaveDashboardUsage)
+	bus.AddHandler("sql", DeleteOldDashboardViewEvents)
+	bus.AddHandler("sql", GetDashboardUsage)
+	bus.AddHandler("sql", GetUserDashboardUsage)
+	bus.AddHandler("sql", GetFolderUsage)
+	bus.AddHandler("sql", GetDashboardViews)
+}
+
+func SaveDashboardUsage(cmd *models.SaveDashboardUsageCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		ids := make([]interface{}, 0)
+		for _, view := range cmd.Views {
+			ids = append(ids, view.DashboardId)
+		}
+		for _, count := range cmd.Counts {
+			ids = append(ids, count.DashboardId)
+		}
+		for _, viewCount := range cmd.ViewCounts {
+			ids = append(ids, viewCount.DashboardId)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// the dashboard ids are sent by the clients, counts of dashboards of
+		// other organizations are dropped
+		dashboards := make([]*models.Dashboard, 0)
+		err := sess.Table("dashboard").Cols("id", "org_id").Where("is_folder = "+dialect.BooleanStr(false)).In("id", ids...).Find(&dashboards)
+		if err != nil {
+			return err
+		}
+		orgIds := make(map[int64]int64, len(dashboards))
+		for _, dash := range dashboards {
+			orgIds[dash.Id] = dash.OrgId
+		}
+
+		views := make([]*models.DashboardViewEvent, 0, len(cmd.Views))
+		for _, view := range cmd.Views {
+			if orgId, ok := orgIds[view.DashboardId]; ok && orgId == view.OrgId {
+				views = append(views, view)
+			}
+		}
+		for len(views) > 0 {
+			batch := views
+			if len(batch) > dashboardViewEventBatchSize {
+				batch = batch[:dashboardViewEventBatchSize]
+			}
+			if _, err := sess.Insert(&batch); err != nil {
+				return err
+			}
+			views = views[len(batch):]
+		}
+
+		for _, count := range cmd.Counts {
+			if orgId, ok := orgIds[count.DashboardId]; !ok || orgId != count.OrgId {
+				continue
+			}
+			if err := addDashboardUsageCount(sess, count); err != nil {
+				return err
+			}
+		}
+
+		for _, viewCount := range cmd.ViewCounts {
+			if orgId, ok := orgIds[viewCount.DashboardId]; !ok || orgId != viewCount.OrgId {
+				continue
+			}
+			if err := addDashboardViewCount(sess, viewCount); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func addDashboardUsageCount(sess *DBSession, count *models.DashboardUsageCount) error {
+	sql := "UPDATE dashboard_usage SET views = views + ?, queries = queries + ?, errors = errors + ?"
+	params := []interface{}{count.Views, count.Queries, count.Errors}
+	if count.LastViewed != nil {
+		sql += ", last_viewed = ?"
+		params = append(params, *count.LastViewed)
+	}
+	sql += " WHERE dashboard_id = ? AND user_id = ? AND day = ?"
+	params = append(params, count.DashboardId, count.UserId, count.Day)
+
+	result, err := sess.Exec(append([]interface{}{sql}, params...)...)
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil || rows > 0 {
+		return err
+	}
+
+	columns := "org_id, dashboard_id, user_id, day, views, queries, errors"
+	values := "?, ?, ?, ?, ?, ?, ?"
+	params = []interface{}{count.OrgId, count.DashboardId, count.UserId, count.Day, count.Views, count.Queries, count.Errors}
+	if count.LastViewed != nil {
+		columns += ", last_viewed"
+		values += ", ?"
+		params = append(params, *count.LastViewed)
+	}
+
+	sql = "INSERT INTO dashboard_usage (" + columns + ") VALUES (" + values + ")"
+	_, err = sess.Exec(append([]interface{}{sql}, params...)...)
+	return err
+}
+
+func DeleteOldDashboardViewEvents(cmd *models.DeleteOldDashboardViewEventsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("DELETE FROM dashboard_view_event WHERE created < ?", cmd.OlderThan)
+		if err != nil {
+			return err
+		}
+
+		cmd.DeletedRows, err = result.RowsAffected()
+		return err
+	})
+}
+
+type dashboardUsageRow struct {
+	DashboardId  int64
+	DashboardUid string
+	Title        string
+	FolderId     int64
+	FolderUid    string
+	FolderTitle  string
+	Views        int64
+	Queries      int64
+	Errors       int64
+	Users        int64
+	LastViewed   string
+}
+
+func GetDashboardUsage(query *models.GetDashboardUsageQuery) error {
+	usageFilter := "dashboard_usage.day >= ? AND dashboard_usage.day <= ?"
+	params := []interface{}{query.From, query.To}
+	if query.UserId != 0 {
+		usageFilter += " AND dashboard_usage.user_id = ?"
+		params = append(params, query.UserId)
+	}
+
+	var sql strings.Builder
+	sql.WriteString(`SELECT
+			dashboard.id AS dashboard_id,
+			dashboard.uid AS dashboard_uid,
+			dashboard.title AS title,
+			dashboard.folder_id AS folder_id,
+			folder.uid AS folder_uid,
+			folder.title AS folder_title,
+			COALESCE(SUM(dashboard_usage.views), 0) AS views,
+			COALESCE(SUM(dashboard_usage.queries), 0) AS queries,
+			COALESCE(SUM(dashboard_usage.errors), 0) AS errors,
+			COUNT(DISTINCT CASE WHEN dashboard_usage.views > 0 AND dashboard_usage.user_id > 0 THEN dashboard_usage.user_id END) AS users,
+			MAX(dashboard_usage.last_viewed) AS last_viewed
+		FROM dashboard
+		LEFT OUTER JOIN dashboard AS folder ON folder.id = dashboard.folder_id
+		LEFT OUTER JOIN dashboard_usage ON dashboard_usage.dashboard_id = dashboard.id AND ` + usageFilter + `
+		WHERE dashboard.org_id = ? AND dashboard.is_folder = ` + dialect.BooleanStr(false))
+	params = append(params, query.OrgId)
+
+	if query.FolderId != nil {
+		sql.WriteString(" AND dashboard.folder_id = ?")
+		params = append(params, *query.FolderId)
+	}
+
+	sql.WriteString(" GROUP BY dashboard.id, dashboard.uid, dashboard.title, dashboard.folder_id, folder.uid, folder.title")
+	if query.Sort == models.DashboardUsageSortViewsAsc {
+		sql.WriteString(" ORDER BY views ASC, dashboard.title ASC")
+	} else {
+		sql.WriteString(" ORDER BY views DESC, dashboard.title ASC")
+	}
+	sql.WriteString(" " + dialect.Limit(int64(query.Limit)))
+
+	rows := make([]*dashboardUsageRow, 0)
+	if err := x.SQL(sql.String(), params...).Find(&rows); err != nil {
+		return err
+	}
+
+	query.Result = make([]*models.DashboardUsage, 0, len(rows))
+	for _, row := range rows {
+		usage := &models.DashboardUsage{
+			DashboardId:  row.DashboardId,
+			DashboardUid: row.DashboardUid,
+			Title:        row.Title,
+			FolderId:     row.FolderId,
+			FolderUid:    row.FolderUid,
+			FolderTitle:  row.FolderTitle,
+			Views:        row.Views,
+			Queries:      row.Queries,
+			Errors:       row.Errors,
+			Users:        row.Users,
+			LastViewed:   parseUsageTime(row.LastViewed),
+		}
+		if usage.FolderId == 0 {
+			usage.FolderTitle = "General"
+		}
+		query.Result = append(query.Result, usage)
+	}
+
+	return nil
+}
+
+type userDashboardUsageRow struct {
+	UserId     int64
+	Login      string
+	Name       string
+	Views      int64
+	Queries    int64
+	Errors     int64
+	Dashboards int64
+	LastViewed string
+}
+
+func GetUserDashboardUsage(query *models.GetUserDashboardUsageQuery) error {
+	user := dialect.Quote("user")
+	sql := `SELECT
+			dashboard_usage.user_id AS user_id,
+			` + user + `.login AS login,
+			` + user + `.name AS name,
+			SUM(dashboard_usage.views) AS views,
+			SUM(dashboard_usage.queries) AS queries,
+			SUM(dashboard_usage.errors) AS errors,
+			COUNT(DISTINCT CASE WHEN dashboard_usage.views > 0 THEN dashboard_usage.dashboard_id END) AS dashboards,
+			MAX(dashboard_usage.last_viewed) AS last_viewed
+		FROM dashboard_usage
+		LEFT OUTER JOIN ` + user + ` ON ` + user + `.id = dashboard_usage.user_id
+		WHERE dashboard_usage.org_id = ? AND dashboard_usage.day >= ? AND dashboard_usage.day <= ?`
+	params := []interface{}{query.OrgId, query.From, query.To}
+
+	if query.DashboardId != 0 {
+		sql += " AND dashboard_usage.dashboard_id = ?"
+		params = append(params, query.DashboardId)
+	}
+
+	sql += ` GROUP BY dashboard_usage.user_id, ` + user + `.login, ` + user + `.name
+		ORDER BY views DESC, login ASC ` + dialect.Limit(int64(query.Limit))
+
+	rows := make([]*userDashboardUsageRow, 0)
+	if err := x.SQL(sql, params...).Find(&rows); err != nil {
+		return err
+	}
+
+	query.Result = make([]*models.UserDashboardUsage, 0, len(rows))
+	for _, row := range rows {
+		query.Result = append(query.Result, &models.UserDashboardUsage{
+			UserId:     row.UserId,
+			Login:      row.Login,
+			Name:       row.Name,
+			Views:      row.Views,
+			Queries:    row.Queries,
+			Errors:     row.Errors,
+			Dashboards: row.Dashboards,
+			LastViewed: parseUsageTime(row.LastViewed),
+		})
+	}
+
+	return nil
+}
+
+type folderUsageRow struct {
+	FolderId         int64
+	FolderUid        string
+	FolderTitle      string
+	Dashboards       int64
+	UnusedDashboards int64
+	Views            int64
+	Queries          int64
+	Errors           int64
+	LastViewed       string
+}
+
+func GetFolderUsage(query *models.GetFolderUsageQuery) error {
+	sql := `SELECT
+			dashboard.folder_id AS folder_id,
+			folder.uid AS folder_uid,
+			folder.title AS folder_title,
+			COUNT(*) AS dashboards,
+			SUM(CASE WHEN dashboard_totals.views > 0 THEN 0 ELSE 1 END) AS unused_dashboards,
+			COALESCE(SUM(dashboard_totals.views), 0) AS views,
+			COALESCE(SUM(dashboard_totals.queries), 0) AS queries,
+			COALESCE(SUM(dashboard_totals.errors), 0) AS errors,
+			MAX(dashboard_totals.last_viewed) AS last_viewed
+		FROM dashboard
+		LEFT OUTER JOIN dashboard AS folder ON folder.id = dashboard.folder_id
+		LEFT OUTER JOIN (
+			SELECT dashboard_id, SUM(views) AS views, SUM(queries) AS queries, SUM(errors) AS errors, MAX(last_viewed) AS last_viewed
+			FROM dashboard_usage
+			WHERE org_id = ? AND day >= ? AND day <= ?
+			GROUP BY dashboard_id
+		) AS dashboard_totals ON dashboard_totals.dashboard_id = dashboard.id
+		WHERE dashboard.org_id = ? AND dashboard.is_folder = ` + dialect.BooleanStr(false) + `
+		GROUP BY dashboard.folder_id, folder.uid, folder.title
+		ORDER BY views DESC, folder_title ASC`
+
+	rows := make([]*folderUsageRow, 0)
+	if err := x.SQL(sql, query.OrgId, query.From, query.To, query.OrgId).Find(&rows); err != nil {
+		return err
+	}
+
+	query.Result = make([]*models.FolderUsage, 0, len(rows))
+	for _, row := range rows {
+		usage := &models.FolderUsage{
+			FolderId:         row.FolderId,
+			FolderUid:        row.FolderUid,
+			FolderTitle:      row.FolderTitle,
+			Dashboards:       row.Dashboards,
+			UnusedDashboards: row.UnusedDashboards,
+			Views:            row.Views,
+			Queries:          row.Queries,
+			Errors:           row.Errors,
+			LastViewed:       parseUsageTime(row.LastViewed),
+		}
+		if usage.FolderId == 0 {
+			usage.FolderTitle = "General"
+		}
+		query.Result = append(query.Result, usage)
+	}
+
+	return nil
+}
+
+func GetDashboardViews(query *models.GetDashboardViewsQuery) error {
+	user := dialect.Quote("user")
+	sql := `SELECT
+			dashboard_view_event.user_id AS user_id,
+			` + user + `.login AS login,
+			` + user + `.name AS name,
+			dashboard_view_event.created AS viewed
+		FROM dashboard_view_event
+		LEFT OUTER JOIN ` + user + ` ON ` + user + `.id = dashboard_view_event.user_id
+		WHERE dashboard_view_event.org_id = ? AND dashboard_view_event.dashboard_id = ?
+		ORDER BY dashboard_view_event.created DESC, dashboard_view_event.id DESC ` + dialect.Limit(int64(query.Limit))
+
+	query.Result = make([]*models.DashboardView, 0)
+	return x.SQL(sql, query.OrgId, query.DashboardId).Find(&query.Result)
+}
+
+// parseUsageTime parses the aggregated last view time. Aggregates lose the
+// column type in some databases, so the time is read as a string.
+func parseUsageTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDashboardUsage(t *testing.T) {
+	InitTestDB(t)
+
+	save := func(t *testing.T, title string, orgId, folderId int64, isFolder bool) *models.Dashboard {
+		cmd := &models.SaveDashboardCommand{
+			OrgId:     orgId,
+			FolderId:  folderId,
+			IsFolder:  isFolder,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title}),
+		}
+		require.NoError(t, SaveDashboard(cmd))
+		return cmd.Result
+	}
+
+	userCmd := &models.CreateUserCommand{Login: "viewer", Email: "viewer@test.com", Name: "Viewer"}
+	require.NoError(t, CreateUser(context.Background(), userCmd))
+	user := userCmd.Result
+
+	folder := save(t, "Folder", 1, 0, true)
+	a := save(t, "A", 1, 0, false)
+	b := save(t, "B", 1, folder.Id, false)
+	save(t, "C", 1, folder.Id, false)
+	other := save(t, "Other org", 2, 0, false)
+
+	viewed := time.Date(2020, 10, 2, 10, 0, 0, 0, time.UTC)
+	err := SaveDashboardUsage(&models.SaveDashboardUsageCommand{
+		Views: []*models.DashboardViewEvent{
+			{OrgId: 1, DashboardId: a.Id, UserId: user.Id, Created: viewed.Add(-time.Hour)},
+			{OrgId: 1, DashboardId: a.Id, UserId: user.Id, Created: viewed},
+			// dashboard of another organization
+			{OrgId: 1, DashboardId: other.Id, UserId: user.Id, Created: viewed},
+		},
+		Counts: []*models.DashboardUsageCount{
+			{OrgId: 1, DashboardId: a.Id, UserId: user.Id, Day: "2020-10-01", Views: 1, Queries: 4, Errors: 1, LastViewed: &viewed},
+			{OrgId: 1, DashboardId: a.Id, UserId: 0, Day: "2020-10-02", Views: 1, Queries: 2},
+			{OrgId: 1, DashboardId: b.Id, UserId: user.Id, Day: "2020-10-02", Queries: 3},
+			{OrgId: 1, DashboardId: other.Id, UserId: user.Id, Day: "2020-10-02", Views: 5},
+		},
+	})
+	require.NoError(t, err)
+
+	// counts of the same dashboard, user and day are added up
+	err = SaveDashboardUsage(&models.SaveDashboardUsageCommand{
+		Counts: []*models.DashboardUsageCount{
+			{OrgId: 1, DashboardId: a.Id, UserId: user.Id, Day: "2020-10-01", Views: 1, Queries: 1},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("Should return the usage of all dashboards", func(t *testing.T) {
+		query := &models.GetDashboardUsageQuery{OrgId: 1, From: "2020-10-01", To: "2020-10-31", Limit: 10}
+		require.NoError(t, GetDashboardUsage(query))
+
+		require.Len(t, query.Result, 3)
+		usage := query.Result[0]
+		assert.Equal(t, a.Id, usage.DashboardId)
+		assert.Equal(t, int64(3), usage.Views)
+		assert.Equal(t, int64(7), usage.Queries)
+		assert.Equal(t, int64(1), usage.Errors)
+		assert.Equal(t, int64(1), usage.Users)
+		assert.Equal(t, "General", usage.FolderTitle)
+		require.NotNil(t, usage.LastViewed)
+		assert.True(t, viewed.Equal(*usage.LastViewed), "last viewed %v", usage.LastViewed)
+
+		assert.Equal(t, "B", query.Result[1].Title)
+		assert.Equal(t, int64(3), query.Result[1].Queries)
+		assert.Equal(t, "Folder", query.Result[1].FolderTitle)
+		assert.Equal(t, "C", query.Result[2].Title)
+		assert.Equal(t, int64(0), query.Result[2].Views)
+		assert.Nil(t, query.Result[2].LastViewed)
+	})
+
+	t.Run("Should filter the usage by time range, folder and user", func(t *testing.T) {
+		query := &models.GetDashboardUsageQuery{OrgId: 1, From: "2020-10-02", To: "2020-10-02", Limit: 10}
+		require.NoError(t, GetDashboardUsage(query))
+		assert.Equal(t, int64(1), query.Result[0].Views)
+
+		folderId := folder.Id
+		query = &models.GetDashboardUsageQuery{OrgId: 1, From: "2020-10-01", To: "2020-10-31", FolderId: &folderId, Limit: 10}
+		require.NoError(t, GetDashboardUsage(query))
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, "B", query.Result[0].Title)
+
+		query = &models.GetDashboardUsageQuery{OrgId: 1, From: "2020-10-01", To: "2020-10-31", UserId: user.Id,
+			Sort: models.DashboardUsageSortViewsAsc, Limit: 10}
+		require.NoError(t, GetDashboardUsage(query))
+		require.Len(t, query.Result, 3)
+		assert.Equal(t, a.Id, query.Result[2].DashboardId)
+		assert.Equal(t, int64(2), query.Result[2].Views)
+	})
+
+	t.Run("Should return the usage by user", func(t *testing.T) {
+		query := &models.GetUserDashboardUsageQuery{OrgId: 1, From: "2020-10-01", To: "2020-10-31", Limit: 10}
+		require.NoError(t, GetUserDashboardUsage(query))
+
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, user.Id, query.Result[0].UserId)
+		assert.Equal(t, "viewer", query.Result[0].Login)
+		assert.Equal(t, int64(2), query.Result[0].Views)
+		assert.Equal(t, int64(8), query.Result[0].Queries)
+		assert.Equal(t, int64(1), query.Result[0].Dashboards)
+		assert.Equal(t, int64(0), query.Result[1].UserId)
+
+		query = &models.GetUserDashboardUsageQuery{OrgId: 1, From: "2020-10-01", To: "2020-10-31", DashboardId: b.Id, Limit: 10}
+		require.NoError(t, GetUserDashboardUsage(query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, int64(0), query.Result[0].Views)
+	})
+
+	t.Run("Should return the usage by folder", func(t *testing.T) {
+		query := &models.GetFolderUsageQuery{OrgId: 1, From: "2020-10-01", To: "2020-10-31"}
+		require.NoError(t, GetFolderUsage(query))
+
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, "General", query.Result[0].FolderTitle)
+		assert.Equal(t, int64(3), query.Result[0].Views)
+		assert.Equal(t, int64(0), query.Result[0].UnusedDashboards)
+		assert.Equal(t, "Folder", query.Result[1].FolderTitle)
+		assert.Equal(t, int64(2), query.Result[1].Dashboards)
+		assert.Equal(t, int64(2), query.Result[1].UnusedDashboards)
+		assert.Equal(t, int64(3), query.Result[1].Queries)
+	})
+
+	t.Run("Should return the recorded views of a dashboard", func(t *testing.T) {
+		query := &models.GetDashboardViewsQuery{OrgId: 1, DashboardId: a.Id, Limit: 10}
+		require.NoError(t, GetDashboardViews(query))
+
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, "viewer", query.Result[0].Login)
+		assert.True(t, viewed.Equal(query.Result[0].Viewed))
+
+		query = &models.GetDashboardViewsQuery{OrgId: 2, DashboardId: other.Id, Limit: 10}
+		require.NoError(t, GetDashboardViews(query))
+		assert.Empty(t, query.Result)
+	})
+
+	t.Run("Should add the view counts of dashboards of the organization", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			err := SaveDashboardUsage(&models.SaveDashboardUsageCommand{
+				ViewCounts: []*models.DashboardViewCount{
+					{OrgId: 1, DashboardId: a.Id, Views: 2, LastViewed: viewed},
+					{OrgId: 1, DashboardId: other.Id, Views: 5, LastViewed: viewed},
+				},
+			})
+			require.NoError(t, err)
+		}
+
+		var views int64
+		_, err := x.SQL("SELECT views FROM dashboard_view_count WHERE dashboard_id = ?", a.Id).Get(&views)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), views)
+
+		count, err := x.Table("dashboard_view_count").Where("dashboard_id = ?", other.Id).Count()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Should delete old view events", func(t *testing.T) {
+		cmd := &models.DeleteOldDashboardViewEventsCommand{OlderThan: viewed.Add(-time.Minute)}
+		require.NoError(t, DeleteOldDashboardViewEvents(cmd))
+		assert.Equal(t, int64(1), cmd.DeletedRows)
+
+		// the aggregated usage is kept
+		query := &models.GetDashboardUsageQuery{OrgId: 1, From: "2020-10-01", To: "2020-10-31", Limit: 1}
+		require.NoError(t, GetDashboardUsage(query))
+		assert.Equal(t, int64(3), query.Result[0].Views)
+	})
+
+	t.Run("Should delete the usage of deleted dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: a.Id, OrgId: 1}))
+
+		var count int64
+		_, err := x.SQL("SELECT COUNT(*) FROM dashboard_usage").Get(&count)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		_, err = x.SQL("SELECT COUNT(*) FROM dashboard_view_event").Get(&count)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
@@ -2,6 +2,7 @@ package sqlstore
 
 import (
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
@@ -12,6 +13,8 @@ func init() {
 	bus.AddHandler("sql", GetDashboardVersion)
 	bus.AddHandler("sql", GetDashboardVersions)
 	bus.AddHandler("sql", DeleteExpiredVersions)
+	bus.AddHandler("sql", GetDashboardVersionHistory)
+	bus.AddHandler("sql", PinDashboardVersion)
 }
 
 // GetDashboardVersion gets the dashboard version for the given dashboard ID and version number.
@@ -49,7 +52,10 @@ func GetDashboardVersions(query *models.GetDashboardVersionsQuery) error {
 				dashboard_version.created,
 				dashboard_version.created_by as created_by_id,
 				dashboard_version.message,
-				dashboard_version.data,`+
+				dashboard_version.data,
+				dashboard_version.folder_id,
+				dashboard_version.pinned,
+				dashboard_version.label,`+
 			dialect.Quote("user")+`.login as created_by`).
 		Join("LEFT", dialect.Quote("user"), `dashboard_version.created_by = `+dialect.Quote("user")+`.id`).
 		Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
@@ -67,6 +73,59 @@ func GetDashboardVersions(query *models.GetDashboardVersionsQuery) error {
 	return nil
 }
 
+// GetDashboardVersionHistory gets all dashboard versions with their data for the given dashboard ID.
+func GetDashboardVersionHistory(query *models.GetDashboardVersionHistoryQuery) error {
+	query.Result = make([]*models.DashboardVersionMeta, 0)
+	err := x.Table("dashboard_version").
+		Select(`dashboard_version.id,
+				dashboard_version.dashboard_id,
+				dashboard_version.parent_version,
+				dashboard_version.restored_from,
+				dashboard_version.version,
+				dashboard_version.created,
+				dashboard_version.message,
+				dashboard_version.data,
+				dashboard_version.folder_id,
+				dashboard_version.pinned,
+				dashboard_version.label,`+
+			dialect.Quote("user")+`.login as created_by`).
+		Join("LEFT", dialect.Quote("user"), `dashboard_version.created_by = `+dialect.Quote("user")+`.id`).
+		Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
+		Where("dashboard_version.dashboard_id=? AND dashboard.org_id=?", query.DashboardId, query.OrgId).
+		OrderBy("dashboard_version.version ASC").
+		Find(&query.Result)
+	if err != nil {
+		return err
+	}
+
+	if len(query.Result) < 1 {
+		return models.ErrNoVersionsForDashboardId
+	}
+	return nil
+}
+
+// PinDashboardVersion pins or unpins a dashboard version. The label of unpinned versions is removed.
+func PinDashboardVersion(cmd *models.PinDashboardVersionCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		var version models.DashboardVersion
+		has, err := sess.Where("dashboard_id=? AND version=?", cmd.DashboardId, cmd.Version).Cols("id").Get(&version)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrDashboardVersionNotFound
+		}
+
+		update := models.DashboardVersion{Pinned: cmd.Pinned}
+		if cmd.Pinned {
+			update.Label = cmd.Label
+		}
+
+		_, err = sess.ID(version.Id).Cols("pinned", "label").Update(&update)
+		return err
+	})
+}
+
 const MAX_VERSIONS_TO_DELETE_PER_BATCH = 100
 const MAX_VERSION_DELETION_BATCHES = 50
 
@@ -80,26 +139,45 @@ func deleteExpiredVersions(cmd *models.DeleteExpiredVersionsCommand, perBatch in
 		versionsToKeep = 1
 	}
 
+	// Idea of this query is finding version IDs to delete based on formula:
+	// min_version_to_keep = min_version + (versions_count - versions_to_keep)
+	// where version stats is processed for each dashboard. This guarantees that we keep at least versions_to_keep
+	// versions, but in some cases (when versions are sparse) this number may be more.
+	// Pinned versions are never deleted and are not counted. If versions_max_age is set, the versions beyond
+	// versions_to_keep are only deleted once they are older than that.
+	versionIdsToDeleteQuery := `SELECT id
+		FROM dashboard_version, (
+			SELECT dashboard_id, count(version) as count, min(version) as min
+			FROM dashboard_version
+			WHERE pinned = ` + dialect.BooleanStr(false) + `
+			GROUP BY dashboard_id
+		) AS vtd
+		WHERE dashboard_version.dashboard_id=vtd.dashboard_id
+		AND pinned = ` + dialect.BooleanStr(false) + `
+		AND version < vtd.min + vtd.count - ?`
+	args := []interface{}{versionsToKeep}
+
+	if setting.DashboardVersionsMaxAge > 0 {
+		versionIdsToDeleteQuery += `
+		AND created < ?`
+		args = append(args, time.Now().Add(-setting.DashboardVersionsMaxAge))
+	}
+
+	versionIdsToDeleteQuery += `
+		LIMIT ?`
+
+	return deleteVersionsInBatches(cmd, perBatch, maxBatches, versionIdsToDeleteQuery, args...)
+}
+
+// deleteVersionsInBatches deletes the versions with the IDs returned by the query, which is called with the args
+// and the batch size.
+func deleteVersionsInBatches(cmd *models.DeleteExpiredVersionsCommand, perBatch int, maxBatches int, query string, args ...interface{}) error {
 	for batch := 0; batch < maxBatches; batch++ {
 		deleted := int64(0)
 
 		batchErr := inTransaction(func(sess *DBSession) error {
-			// Idea of this query is finding version IDs to delete based on formula:
-			// min_version_to_keep = min_version + (versions_count - versions_to_keep)
-			// where version stats is processed for each dashboard. This guarantees that we keep at least versions_to_keep
-			// versions, but in some cases (when versions are sparse) this number may be more.
-			versionIdsToDeleteQuery := `SELECT id
-				FROM dashboard_version, (
-					SELECT dashboard_id, count(version) as count, min(version) as min
-					FROM dashboard_version
-					GROUP BY dashboard_id
-				) AS vtd
-				WHERE dashboard_version.dashboard_id=vtd.dashboard_id
-				AND version < vtd.min + vtd.count - ?
-				LIMIT ?`
-
 			var versionIdsToDelete []interface{}
-			err := sess.SQL(versionIdsToDeleteQuery, versionsToKeep, perBatch).Find(&versionIdsToDelete)
+			err := sess.SQL(query, append(args, perBatch)...).Find(&versionIdsToDelete)
 			if err != nil {
 				return err
 			}
@@ -3,6 +3,7 @@ package sqlstore
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 
@@ -167,5 +168,108 @@ func TestDeleteExpiredVersions(t *testing.T) {
 			// Ensure we haven't deleted more than perBatch * maxBatches rows
 			So(versionsToWriteBigNumber-len(query.Result), ShouldBeLessThanOrEqualTo, perBatch*maxBatches)
 		})
+
+		Convey("Don't delete or count pinned versions", func() {
+			err := PinDashboardVersion(&models.PinDashboardVersionCommand{DashboardId: savedDash.Id, Version: 1, Pinned: true, Label: "release 3.2"})
+			So(err, ShouldBeNil)
+
+			err = DeleteExpiredVersions(&models.DeleteExpiredVersionsCommand{})
+			So(err, ShouldBeNil)
+
+			query := models.GetDashboardVersionsQuery{DashboardId: savedDash.Id, OrgId: 1}
+			err = GetDashboardVersions(&query)
+			So(err, ShouldBeNil)
+
+			So(len(query.Result), ShouldEqual, versionsToKeep+1)
+			So(query.Result[versionsToKeep-1].Version, ShouldEqual, versionsToWrite-versionsToKeep+1)
+			So(query.Result[versionsToKeep].Version, ShouldEqual, 1)
+			So(query.Result[versionsToKeep].Pinned, ShouldBeTrue)
+			So(query.Result[versionsToKeep].Label, ShouldEqual, "release 3.2")
+		})
+
+		Convey("Don't delete versions older than the max age within versions_to_keep", func() {
+			setting.DashboardVersionsToKeep = versionsToWrite
+			setting.DashboardVersionsMaxAge = 24 * time.Hour
+			defer func() { setting.DashboardVersionsMaxAge = 0 }()
+
+			_, err := x.Exec("UPDATE dashboard_version SET created = ?", time.Now().Add(-48*time.Hour))
+			So(err, ShouldBeNil)
+
+			cmd := models.DeleteExpiredVersionsCommand{}
+			err = DeleteExpiredVersions(&cmd)
+			So(err, ShouldBeNil)
+			So(cmd.DeletedRows, ShouldEqual, 0)
+		})
+
+		Convey("Only delete versions beyond versions_to_keep that are older than the max age", func() {
+			setting.DashboardVersionsMaxAge = 24 * time.Hour
+			defer func() { setting.DashboardVersionsMaxAge = 0 }()
+
+			_, err := x.Exec("UPDATE dashboard_version SET created = ? WHERE version <= 3", time.Now().Add(-48*time.Hour))
+			So(err, ShouldBeNil)
+			err = PinDashboardVersion(&models.PinDashboardVersionCommand{DashboardId: savedDash.Id, Version: 2, Pinned: true})
+			So(err, ShouldBeNil)
+
+			cmd := models.DeleteExpiredVersionsCommand{}
+			err = DeleteExpiredVersions(&cmd)
+			So(err, ShouldBeNil)
+			So(cmd.DeletedRows, ShouldEqual, 2)
+
+			query := models.GetDashboardVersionsQuery{DashboardId: savedDash.Id, OrgId: 1}
+			err = GetDashboardVersions(&query)
+			So(err, ShouldBeNil)
+
+			// Version 4 is beyond versions_to_keep but too new, version 2 is pinned
+			So(len(query.Result), ShouldEqual, versionsToWrite-2)
+			So(query.Result[versionsToWrite-4].Version, ShouldEqual, 4)
+			So(query.Result[versionsToWrite-3].Version, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestPinDashboardVersion(t *testing.T) {
+	Convey("Testing pinning of dashboard versions", t, func() {
+		InitTestDB(t)
+		savedDash := insertTestDashboard("test dash 61", 1, 0, false, "pin")
+		updateTestDashboard(savedDash, map[string]interface{}{
+			"tags": "different-tag",
+		})
+
+		Convey("Pin a version with a label", func() {
+			err := PinDashboardVersion(&models.PinDashboardVersionCommand{DashboardId: savedDash.Id, Version: 1, Pinned: true, Label: "release 3.2"})
+			So(err, ShouldBeNil)
+
+			query := models.GetDashboardVersionQuery{DashboardId: savedDash.Id, Version: 1, OrgId: 1}
+			err = GetDashboardVersion(&query)
+			So(err, ShouldBeNil)
+			So(query.Result.Pinned, ShouldBeTrue)
+			So(query.Result.Label, ShouldEqual, "release 3.2")
+
+			Convey("Unpin removes the label", func() {
+				err := PinDashboardVersion(&models.PinDashboardVersionCommand{DashboardId: savedDash.Id, Version: 1, Pinned: false})
+				So(err, ShouldBeNil)
+
+				query := models.GetDashboardVersionQuery{DashboardId: savedDash.Id, Version: 1, OrgId: 1}
+				err = GetDashboardVersion(&query)
+				So(err, ShouldBeNil)
+				So(query.Result.Pinned, ShouldBeFalse)
+				So(query.Result.Label, ShouldEqual, "")
+			})
+		})
+
+		Convey("Attempt to pin a version that doesn't exist", func() {
+			err := PinDashboardVersion(&models.PinDashboardVersionCommand{DashboardId: savedDash.Id, Version: 123, Pinned: true})
+			So(err, ShouldEqual, models.ErrDashboardVersionNotFound)
+		})
+
+		Convey("Get the version history oldest first", func() {
+			query := models.GetDashboardVersionHistoryQuery{DashboardId: savedDash.Id, OrgId: 1}
+			err := GetDashboardVersionHistory(&query)
+			So(err, ShouldBeNil)
+			So(len(query.Result), ShouldEqual, 2)
+			So(query.Result[0].Version, ShouldEqual, 1)
+			So(query.Result[1].Version, ShouldEqual, 2)
+			So(query.Result[1].Data.Get("tags").MustString(), ShouldEqual, "different-tag")
+		})
 	})
 }
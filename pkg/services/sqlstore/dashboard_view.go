@@ -0,0 +1,26 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// addDashboardViewCount adds the views to the view count of the dashboard
+// that search results are sorted by.
+func addDashboardViewCount(sess *DBSession, viewCount *models.DashboardViewCount) error {
+	_, err := sess.Exec(dashboardViewCountUpsertSql(), viewCount.OrgId, viewCount.DashboardId, viewCount.Views, viewCount.LastViewed)
+	return err
+}
+
+// dashboardViewCountUpsertSql returns the statement adding views to the view
+// count of a dashboard, inserting the count if there is none yet. Unlike an
+// update followed by an insert, it doesn't fail when another server inserts
+// the count of the same dashboard at the same time.
+func dashboardViewCountUpsertSql() string {
+	insert := "INSERT INTO dashboard_view_count (org_id, dashboard_id, views, last_viewed) VALUES (?, ?, ?, ?)"
+	if dialect.DriverName() == migrator.MYSQL {
+		return insert + " ON DUPLICATE KEY UPDATE views = views + VALUES(views), last_viewed = VALUES(last_viewed)"
+	}
+
+	return insert + " ON CONFLICT (dashboard_id) DO UPDATE SET views = dashboard_view_count.views + excluded.views, last_viewed = excluded.last_viewed"
+}
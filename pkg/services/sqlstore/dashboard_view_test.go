@@ -0,0 +1,32 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDashboardViewCount(t *testing.T) {
+	InitTestDB(t)
+
+	viewed := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, views := range []int64{2, 3} {
+		err := inTransaction(func(sess *DBSession) error {
+			return addDashboardViewCount(sess, &models.DashboardViewCount{OrgId: 1, DashboardId: 10, Views: views, LastViewed: viewed})
+		})
+		require.NoError(t, err)
+		viewed = viewed.Add(time.Minute)
+	}
+
+	viewCount := &models.DashboardViewCount{}
+	has, err := x.Table("dashboard_view_count").Where("dashboard_id = ?", 10).Get(viewCount)
+	require.NoError(t, err)
+	require.True(t, has)
+	assert.Equal(t, int64(5), viewCount.Views)
+	assert.Equal(t, int64(1), viewCount.OrgId)
+	assert.True(t, viewCount.LastViewed.Equal(viewed.Add(-time.Minute)), "last viewed %s", viewCount.LastViewed)
+}
@@ -75,79 +75,96 @@ func DeleteDataSourceById(cmd *models.DeleteDataSourceByIdCommand) error {
 	return inTransaction(func(sess *DBSession) error {
 		var rawSql = "DELETE FROM data_source WHERE id=? and org_id=?"
 		result, err := sess.Exec(rawSql, cmd.Id, cmd.OrgId)
+		if err != nil {
+			return err
+		}
 		affected, _ := result.RowsAffected()
 		cmd.DeletedDatasourcesCount = affected
+
+		_, err = sess.Exec("DELETE FROM data_source_permission WHERE datasource_id=? and org_id=?", cmd.Id, cmd.OrgId)
 		return err
 	})
 }
 
 func DeleteDataSourceByName(cmd *models.DeleteDataSourceByNameCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		_, err := sess.Exec("DELETE FROM data_source_permission WHERE org_id=? AND datasource_id IN (SELECT id FROM data_source WHERE name=? and org_id=?)", cmd.OrgId, cmd.Name, cmd.OrgId)
+		if err != nil {
+			return err
+		}
+
 		var rawSql = "DELETE FROM data_source WHERE name=? and org_id=?"
 		result, err := sess.Exec(rawSql, cmd.Name, cmd.OrgId)
+		if err != nil {
+			return err
+		}
 		affected, _ := result.RowsAffected()
 		cmd.DeletedDatasourcesCount = affected
-		return err
+		return nil
 	})
 }
 
 func AddDataSource(cmd *models.AddDataSourceCommand) error {
 	return inTransaction(func(sess *DBSession) error {
-		existing := models.DataSource{OrgId: cmd.OrgId, Name: cmd.Name}
-		has, _ := sess.Get(&existing)
+		return addDataSource(sess, cmd)
+	})
+}
 
-		if has {
-			return models.ErrDataSourceNameExists
-		}
+func addDataSource(sess *DBSession, cmd *models.AddDataSourceCommand) error {
+	existing := models.DataSource{OrgId: cmd.OrgId, Name: cmd.Name}
+	has, _ := sess.Get(&existing)
 
-		if cmd.JsonData == nil {
-			cmd.JsonData = simplejson.New()
-		}
+	if has {
+		return models.ErrDataSourceNameExists
+	}
 
-		if cmd.Uid == "" {
-			uid, err := generateNewDatasourceUid(sess, cmd.OrgId)
-			if err != nil {
-				return errutil.Wrapf(err, "Failed to generate UID for datasource %q", cmd.Name)
-			}
-			cmd.Uid = uid
-		}
+	if cmd.JsonData == nil {
+		cmd.JsonData = simplejson.New()
+	}
 
-		ds := &models.DataSource{
-			OrgId:             cmd.OrgId,
-			Name:              cmd.Name,
-			Type:              cmd.Type,
-			Access:            cmd.Access,
-			Url:               cmd.Url,
-			User:              cmd.User,
-			Password:          cmd.Password,
-			Database:          cmd.Database,
-			IsDefault:         cmd.IsDefault,
-			BasicAuth:         cmd.BasicAuth,
-			BasicAuthUser:     cmd.BasicAuthUser,
-			BasicAuthPassword: cmd.BasicAuthPassword,
-			WithCredentials:   cmd.WithCredentials,
-			JsonData:          cmd.JsonData,
-			SecureJsonData:    securejsondata.GetEncryptedJsonData(cmd.SecureJsonData),
-			Created:           time.Now(),
-			Updated:           time.Now(),
-			Version:           1,
-			ReadOnly:          cmd.ReadOnly,
-			Uid:               cmd.Uid,
+	if cmd.Uid == "" {
+		uid, err := generateNewDatasourceUid(sess, cmd.OrgId)
+		if err != nil {
+			return errutil.Wrapf(err, "Failed to generate UID for datasource %q", cmd.Name)
 		}
+		cmd.Uid = uid
+	}
 
-		if _, err := sess.Insert(ds); err != nil {
-			if dialect.IsUniqueConstraintViolation(err) && strings.Contains(strings.ToLower(dialect.ErrorMessage(err)), "uid") {
-				return models.ErrDataSourceUidExists
-			}
-			return err
-		}
-		if err := updateIsDefaultFlag(ds, sess); err != nil {
-			return err
+	ds := &models.DataSource{
+		OrgId:             cmd.OrgId,
+		Name:              cmd.Name,
+		Type:              cmd.Type,
+		Access:            cmd.Access,
+		Url:               cmd.Url,
+		User:              cmd.User,
+		Password:          cmd.Password,
+		Database:          cmd.Database,
+		IsDefault:         cmd.IsDefault,
+		BasicAuth:         cmd.BasicAuth,
+		BasicAuthUser:     cmd.BasicAuthUser,
+		BasicAuthPassword: cmd.BasicAuthPassword,
+		WithCredentials:   cmd.WithCredentials,
+		JsonData:          cmd.JsonData,
+		SecureJsonData:    securejsondata.GetEncryptedJsonData(cmd.SecureJsonData),
+		Created:           time.Now(),
+		Updated:           time.Now(),
+		Version:           1,
+		ReadOnly:          cmd.ReadOnly,
+		Uid:               cmd.Uid,
+	}
+
+	if _, err := sess.Insert(ds); err != nil {
+		if dialect.IsUniqueConstraintViolation(err) && strings.Contains(strings.ToLower(dialect.ErrorMessage(err)), "uid") {
+			return models.ErrDataSourceUidExists
 		}
+		return err
+	}
+	if err := updateIsDefaultFlag(ds, sess); err != nil {
+		return err
+	}
 
-		cmd.Result = ds
-		return nil
-	})
+	cmd.Result = ds
+	return nil
 }
 
 func updateIsDefaultFlag(ds *models.DataSource, sess *DBSession) error {
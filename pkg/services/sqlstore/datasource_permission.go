@@ -0,0 +1,204 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", SetDataSourcePermissionsEnabled)
+	bus.AddHandler("sql", AddDataSourcePermission)
+	bus.AddHandler("sql", RemoveDataSourcePermission)
+	bus.AddHandler("sql", GetDataSourcePermissions)
+	bus.AddHandler("sql", GetUserDataSourcePermissions)
+}
+
+// SetDataSourcePermissionsEnabled enables or disables permissions for a data
+// source. Disabling removes all existing permissions.
+func SetDataSourcePermissionsEnabled(cmd *models.SetDataSourcePermissionsEnabledCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		ds := &models.DataSource{PermissionsEnabled: cmd.Enabled}
+		affected, err := sess.Where("id=? and org_id=?", cmd.DatasourceId, cmd.OrgId).UseBool("permissions_enabled").Cols("permissions_enabled").Update(ds)
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return models.ErrDataSourceNotFound
+		}
+
+		if !cmd.Enabled {
+			_, err = sess.Exec("DELETE FROM data_source_permission WHERE datasource_id=? and org_id=?", cmd.DatasourceId, cmd.OrgId)
+		}
+
+		return err
+	})
+}
+
+func AddDataSourcePermission(cmd *models.AddDataSourcePermissionCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		subjects := 0
+		if cmd.UserId > 0 {
+			subjects++
+		}
+		if cmd.TeamId > 0 {
+			subjects++
+		}
+		if cmd.Role != nil {
+			subjects++
+		}
+		if subjects != 1 {
+			return models.ErrDataSourcePermissionInvalid
+		}
+
+		if res, err := sess.Query("SELECT 1 FROM data_source WHERE id=? and org_id=?", cmd.DatasourceId, cmd.OrgId); err != nil {
+			return err
+		} else if len(res) != 1 {
+			return models.ErrDataSourceNotFound
+		}
+
+		existing := &models.DataSourcePermission{}
+		where := sess.Where("org_id=? and datasource_id=?", cmd.OrgId, cmd.DatasourceId)
+		switch {
+		case cmd.UserId > 0:
+			where = where.And("user_id=?", cmd.UserId)
+		case cmd.TeamId > 0:
+			where = where.And("team_id=?", cmd.TeamId)
+		default:
+			where = where.And("role=?", *cmd.Role)
+		}
+
+		has, err := where.Get(existing)
+		if err != nil {
+			return err
+		}
+
+		if has {
+			existing.Permission = cmd.Permission
+			existing.Updated = time.Now()
+			if _, err := sess.ID(existing.Id).Cols("permission", "updated").Update(existing); err != nil {
+				return err
+			}
+
+			cmd.Result = existing
+			return nil
+		}
+
+		permission := &models.DataSourcePermission{
+			OrgId:        cmd.OrgId,
+			DatasourceId: cmd.DatasourceId,
+			UserId:       cmd.UserId,
+			TeamId:       cmd.TeamId,
+			Role:         cmd.Role,
+			Permission:   cmd.Permission,
+			Created:      time.Now(),
+			Updated:      time.Now(),
+		}
+
+		if _, err := sess.Insert(permission); err != nil {
+			return err
+		}
+
+		cmd.Result = permission
+		return nil
+	})
+}
+
+func RemoveDataSourcePermission(cmd *models.RemoveDataSourcePermissionCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("DELETE FROM data_source_permission WHERE id=? and datasource_id=? and org_id=?", cmd.Id, cmd.DatasourceId, cmd.OrgId)
+		if err != nil {
+			return err
+		}
+
+		if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+			return models.ErrDataSourcePermissionNotFound
+		}
+
+		return err
+	})
+}
+
+func GetDataSourcePermissions(query *models.GetDataSourcePermissionsQuery) error {
+	rawSQL := `SELECT
+		dp.id,
+		dp.datasource_id,
+		dp.user_id,
+		dp.team_id,
+		dp.role,
+		dp.permission,
+		dp.created,
+		dp.updated,
+		u.login AS user_login,
+		u.email AS user_email,
+		t.name AS team,
+		t.email AS team_email
+		FROM data_source_permission AS dp
+		LEFT OUTER JOIN ` + dialect.Quote("user") + ` AS u ON u.id = dp.user_id
+		LEFT OUTER JOIN team AS t ON t.id = dp.team_id
+		WHERE dp.org_id = ? AND dp.datasource_id = ?
+		ORDER BY dp.id ASC`
+
+	query.Result = make([]*models.DataSourcePermissionDTO, 0)
+	if err := x.SQL(rawSQL, query.OrgId, query.DatasourceId).Find(&query.Result); err != nil {
+		return err
+	}
+
+	for _, p := range query.Result {
+		p.PermissionName = p.Permission.String()
+	}
+
+	return nil
+}
+
+func GetUserDataSourcePermissions(query *models.GetUserDataSourcePermissionsQuery) error {
+	filters := []string{}
+	params := []interface{}{query.OrgId}
+
+	// Role grants apply to the role and the roles above it, e.g. a grant to
+	// viewers applies to editors too.
+	roles := []string{}
+	for _, role := range []models.RoleType{models.ROLE_VIEWER, models.ROLE_EDITOR, models.ROLE_ADMIN} {
+		if query.User.OrgRole.Includes(role) {
+			roles = append(roles, "?")
+			params = append(params, string(role))
+		}
+	}
+	if len(roles) > 0 {
+		filters = append(filters, "dp.role IN ("+strings.Join(roles, ",")+")")
+	}
+
+	// API keys and anonymous users don't have a user id or teams.
+	if query.User.UserId > 0 {
+		filters = append(filters,
+			"dp.user_id = ?",
+			"dp.team_id IN (SELECT team_id FROM team_member WHERE team_member.user_id = ? AND "+notInDeletedTeam("team_member.team_id")+")",
+		)
+		params = append(params, query.User.UserId, query.User.UserId)
+	}
+
+	query.Result = make(map[int64]models.DsPermissionType)
+	if len(filters) == 0 {
+		return nil
+	}
+
+	rawSQL := `SELECT dp.datasource_id, dp.permission
+		FROM data_source_permission AS dp
+		WHERE dp.org_id = ? AND (` + strings.Join(filters, " OR ") + `)`
+
+	permissions := make([]*models.DataSourcePermission, 0)
+	if err := x.SQL(rawSQL, params...).Find(&permissions); err != nil {
+		return err
+	}
+
+	for _, p := range permissions {
+		if p.Permission > query.Result[p.DatasourceId] {
+			query.Result[p.DatasourceId] = p.Permission
+		}
+	}
+
+	return nil
+}
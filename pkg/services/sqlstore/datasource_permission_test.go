@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDataSourcePermissions(t *testing.T) {
+	InitTestDB(t)
+
+	var orgId int64 = 1
+
+	dsCmd := &models.AddDataSourceCommand{OrgId: orgId, Name: "billing", Type: models.DS_POSTGRES, Access: models.DS_ACCESS_PROXY}
+	require.NoError(t, AddDataSource(dsCmd))
+	dsId := dsCmd.Result.Id
+
+	userCmd := &models.CreateUserCommand{Login: "analyst", Email: "analyst@test.com"}
+	require.NoError(t, CreateUser(context.Background(), userCmd))
+	user := &models.SignedInUser{UserId: userCmd.Result.Id, OrgId: orgId, OrgRole: models.ROLE_VIEWER}
+
+	teamCmd := &models.CreateTeamCommand{OrgId: orgId, Name: "finance"}
+	require.NoError(t, CreateTeam(teamCmd))
+
+	t.Run("Should enable permissions", func(t *testing.T) {
+		require.NoError(t, SetDataSourcePermissionsEnabled(&models.SetDataSourcePermissionsEnabledCommand{OrgId: orgId, DatasourceId: dsId, Enabled: true}))
+
+		query := &models.GetDataSourceByIdQuery{Id: dsId, OrgId: orgId}
+		require.NoError(t, GetDataSourceById(query))
+		require.True(t, query.Result.PermissionsEnabled)
+
+		err := SetDataSourcePermissionsEnabled(&models.SetDataSourcePermissionsEnabledCommand{OrgId: 2, DatasourceId: dsId, Enabled: true})
+		require.Equal(t, models.ErrDataSourceNotFound, err)
+	})
+
+	t.Run("Should require exactly one of user, team or role", func(t *testing.T) {
+		err := AddDataSourcePermission(&models.AddDataSourcePermissionCommand{OrgId: orgId, DatasourceId: dsId, Permission: models.DsPermissionQuery})
+		require.Equal(t, models.ErrDataSourcePermissionInvalid, err)
+
+		err = AddDataSourcePermission(&models.AddDataSourcePermissionCommand{OrgId: orgId, DatasourceId: dsId, UserId: 1, TeamId: 1, Permission: models.DsPermissionQuery})
+		require.Equal(t, models.ErrDataSourcePermissionInvalid, err)
+	})
+
+	t.Run("Should return highest permission of user, teams and role", func(t *testing.T) {
+		query := &models.GetUserDataSourcePermissionsQuery{OrgId: orgId, User: user}
+		require.NoError(t, GetUserDataSourcePermissions(query))
+		require.Empty(t, query.Result)
+
+		viewer := models.ROLE_VIEWER
+		require.NoError(t, AddDataSourcePermission(&models.AddDataSourcePermissionCommand{OrgId: orgId, DatasourceId: dsId, Role: &viewer, Permission: models.DsPermissionQuery}))
+		require.NoError(t, GetUserDataSourcePermissions(query))
+		require.Equal(t, models.DsPermissionQuery, query.Result[dsId])
+
+		require.NoError(t, AddDataSourcePermission(&models.AddDataSourcePermissionCommand{OrgId: orgId, DatasourceId: dsId, TeamId: teamCmd.Result.Id, Permission: models.DsPermissionAdmin}))
+		require.NoError(t, GetUserDataSourcePermissions(query))
+		require.Equal(t, models.DsPermissionQuery, query.Result[dsId])
+
+		require.NoError(t, AddTeamMember(&models.AddTeamMemberCommand{OrgId: orgId, TeamId: teamCmd.Result.Id, UserId: user.UserId}))
+		require.NoError(t, GetUserDataSourcePermissions(query))
+		require.Equal(t, models.DsPermissionAdmin, query.Result[dsId])
+	})
+
+	t.Run("Should apply role permissions to higher roles", func(t *testing.T) {
+		editor := &models.SignedInUser{OrgId: orgId, OrgRole: models.ROLE_EDITOR}
+		query := &models.GetUserDataSourcePermissionsQuery{OrgId: orgId, User: editor}
+		require.NoError(t, GetUserDataSourcePermissions(query))
+		require.Equal(t, models.DsPermissionQuery, query.Result[dsId])
+
+		otherCmd := &models.AddDataSourceCommand{OrgId: orgId, Name: "payroll", Type: models.DS_POSTGRES, Access: models.DS_ACCESS_PROXY}
+		require.NoError(t, AddDataSource(otherCmd))
+		editorRole := models.ROLE_EDITOR
+		require.NoError(t, AddDataSourcePermission(&models.AddDataSourcePermissionCommand{OrgId: orgId, DatasourceId: otherCmd.Result.Id, Role: &editorRole, Permission: models.DsPermissionEdit}))
+
+		require.NoError(t, GetUserDataSourcePermissions(query))
+		require.Equal(t, models.DsPermissionEdit, query.Result[otherCmd.Result.Id])
+
+		viewerQuery := &models.GetUserDataSourcePermissionsQuery{OrgId: orgId, User: &models.SignedInUser{OrgId: orgId, OrgRole: models.ROLE_VIEWER}}
+		require.NoError(t, GetUserDataSourcePermissions(viewerQuery))
+		require.Equal(t, models.DsPermissionNoAccess, viewerQuery.Result[otherCmd.Result.Id])
+	})
+
+	t.Run("Should update permission of existing user entry", func(t *testing.T) {
+		addCmd := &models.AddDataSourcePermissionCommand{OrgId: orgId, DatasourceId: dsId, UserId: user.UserId, Permission: models.DsPermissionQuery}
+		require.NoError(t, AddDataSourcePermission(addCmd))
+
+		updateCmd := &models.AddDataSourcePermissionCommand{OrgId: orgId, DatasourceId: dsId, UserId: user.UserId, Permission: models.DsPermissionEdit}
+		require.NoError(t, AddDataSourcePermission(updateCmd))
+		require.Equal(t, addCmd.Result.Id, updateCmd.Result.Id)
+
+		query := &models.GetDataSourcePermissionsQuery{OrgId: orgId, DatasourceId: dsId}
+		require.NoError(t, GetDataSourcePermissions(query))
+		require.Len(t, query.Result, 3)
+		require.Equal(t, "analyst", query.Result[2].UserLogin)
+		require.Equal(t, "Edit", query.Result[2].PermissionName)
+		require.Equal(t, "finance", query.Result[1].Team)
+
+		require.NoError(t, RemoveDataSourcePermission(&models.RemoveDataSourcePermissionCommand{OrgId: orgId, DatasourceId: dsId, Id: addCmd.Result.Id}))
+		err := RemoveDataSourcePermission(&models.RemoveDataSourcePermissionCommand{OrgId: orgId, DatasourceId: dsId, Id: addCmd.Result.Id})
+		require.Equal(t, models.ErrDataSourcePermissionNotFound, err)
+	})
+
+	t.Run("Should remove permissions when disabled", func(t *testing.T) {
+		require.NoError(t, SetDataSourcePermissionsEnabled(&models.SetDataSourcePermissionsEnabledCommand{OrgId: orgId, DatasourceId: dsId, Enabled: false}))
+
+		query := &models.GetDataSourcePermissionsQuery{OrgId: orgId, DatasourceId: dsId}
+		require.NoError(t, GetDataSourcePermissions(query))
+		require.Empty(t, query.Result)
+	})
+}
@@ -0,0 +1,247 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	bus.AddHandler("sql", GetDeletedResources)
+	bus.AddHandler("sql", RestoreUser)
+	bus.AddHandler("sql", RestoreOrg)
+	bus.AddHandler("sql", RestoreTeam)
+	bus.AddHandler("sql", PurgeDeletedResources)
+}
+
+// softDeleteEnabled returns true if deleted users, organizations and teams
+// are kept for the retention period instead of being deleted right away.
+func softDeleteEnabled() bool {
+	return setting.DeletedRetentionDays > 0
+}
+
+func purgeTime(deleted time.Time) time.Time {
+	return deleted.AddDate(0, 0, setting.DeletedRetentionDays)
+}
+
+// softDeleteUser disables the user and signs it out, but keeps it and its
+// memberships so that it can be restored. Whether the user was disabled is
+// kept to restore it.
+func softDeleteUser(sess *DBSession, userID int64) error {
+	result, err := sess.Exec("UPDATE "+dialect.Quote("user")+" SET deleted = ?, disabled_before_delete = is_disabled, is_disabled = ? WHERE id = ? AND deleted IS NULL",
+		time.Now(), true, userID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return models.ErrUserNotFound
+	}
+
+	_, err = sess.Exec("DELETE FROM user_auth_token WHERE user_id = ?", userID)
+	return err
+}
+
+func softDeleteOrg(sess *DBSession, orgID int64) error {
+	result, err := sess.Exec("UPDATE org SET deleted = ? WHERE id = ? AND deleted IS NULL", time.Now(), orgID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return models.ErrOrgNotFound
+	}
+
+	return nil
+}
+
+func softDeleteTeam(sess *DBSession, orgID int64, teamID int64) error {
+	result, err := sess.Exec("UPDATE team SET deleted = ? WHERE org_id = ? AND id = ? AND deleted IS NULL", time.Now(), orgID, teamID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return models.ErrTeamNotFound
+	}
+
+	return nil
+}
+
+func RestoreUser(cmd *models.RestoreUserCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("UPDATE "+dialect.Quote("user")+" SET deleted = NULL, is_disabled = disabled_before_delete, disabled_before_delete = ? WHERE id = ? AND deleted IS NOT NULL",
+			false, cmd.UserId)
+		if err != nil {
+			return err
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rows == 0 {
+			return models.ErrUserNotFound
+		}
+
+		return nil
+	})
+}
+
+func RestoreOrg(cmd *models.RestoreOrgCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("UPDATE org SET deleted = NULL WHERE id = ? AND deleted IS NOT NULL", cmd.Id)
+		if err != nil {
+			return err
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rows == 0 {
+			return models.ErrOrgNotFound
+		}
+
+		return nil
+	})
+}
+
+func RestoreTeam(cmd *models.RestoreTeamCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("UPDATE team SET deleted = NULL WHERE org_id = ? AND id = ? AND deleted IS NOT NULL", cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rows == 0 {
+			return models.ErrTeamNotFound
+		}
+
+		return nil
+	})
+}
+
+func GetDeletedResources(query *models.GetDeletedResourcesQuery) error {
+	query.Result = make([]*models.DeletedResourceDTO, 0)
+
+	var err error
+	switch query.Type {
+	case models.DeletedResourceUser:
+		err = x.SQL(`SELECT id, org_id, name, login, email, deleted FROM ` + dialect.Quote("user") +
+			` WHERE deleted IS NOT NULL ORDER BY deleted DESC`).Find(&query.Result)
+	case models.DeletedResourceOrg:
+		err = x.SQL(`SELECT id, id AS org_id, name, deleted FROM org WHERE deleted IS NOT NULL ORDER BY deleted DESC`).Find(&query.Result)
+	case models.DeletedResourceTeam:
+		err = x.SQL(`SELECT id, org_id, name, email, deleted FROM team WHERE org_id = ? AND deleted IS NOT NULL ORDER BY deleted DESC`,
+			query.OrgId).Find(&query.Result)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range query.Result {
+		resource.Type = query.Type
+		resource.PurgeAt = purgeTime(resource.Deleted)
+	}
+
+	return nil
+}
+
+// PurgeDeletedResources permanently deletes the teams, organizations and
+// users that have been deleted before the time.
+func PurgeDeletedResources(cmd *models.PurgeDeletedResourcesCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		cmd.Result = 0
+
+		for _, resourceType := range []string{models.DeletedResourceTeam, models.DeletedResourceOrg, models.DeletedResourceUser} {
+			expired, err := getExpiredResources(sess, resourceType, cmd.DeletedBefore)
+			if err != nil {
+				return err
+			}
+
+			for _, resource := range expired {
+				switch resourceType {
+				case models.DeletedResourceTeam:
+					err = deleteTeamInTransaction(sess, resource.OrgId, resource.Id)
+				case models.DeletedResourceOrg:
+					err = deleteOrgInTransaction(sess, resource.Id)
+				case models.DeletedResourceUser:
+					err = deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: resource.Id})
+				}
+				if err != nil {
+					return err
+				}
+				cmd.Result++
+			}
+		}
+
+		return nil
+	})
+}
+
+func getExpiredResources(sess *DBSession, resourceType string, deletedBefore time.Time) ([]*models.DeletedResourceDTO, error) {
+	table := resourceType
+	if resourceType == models.DeletedResourceUser {
+		table = dialect.Quote("user")
+	}
+
+	orgID := "org_id"
+	if resourceType == models.DeletedResourceOrg {
+		orgID = "id AS org_id"
+	}
+
+	// the deleted times are compared here, since they're stored as local
+	// times that can't be compared reliably in SQL on all databases
+	resources := make([]*models.DeletedResourceDTO, 0)
+	if err := sess.SQL("SELECT id, " + orgID + ", deleted FROM " + table + " WHERE deleted IS NOT NULL").Find(&resources); err != nil {
+		return nil, err
+	}
+
+	expired := make([]*models.DeletedResourceDTO, 0, len(resources))
+	for _, resource := range resources {
+		if resource.Deleted.Before(deletedBefore) {
+			expired = append(expired, resource)
+		}
+	}
+
+	return expired, nil
+}
+
+// reassignUserContent makes another user the creator of the dashboards,
+// folders, dashboard versions, annotations and snapshots of a user.
+func reassignUserContent(sess *DBSession, fromUserID int64, toUserID int64) error {
+	if res, err := sess.Query("SELECT 1 FROM "+dialect.Quote("user")+" WHERE id = ? AND deleted IS NULL", toUserID); err != nil {
+		return err
+	} else if len(res) != 1 {
+		return models.ErrReassignUserNotFound
+	}
+
+	updates := []string{
+		"UPDATE dashboard SET created_by = ? WHERE created_by = ?",
+		"UPDATE dashboard SET updated_by = ? WHERE updated_by = ?",
+		"UPDATE dashboard_version SET created_by = ? WHERE created_by = ?",
+		"UPDATE annotation SET user_id = ? WHERE user_id = ?",
+		"UPDATE dashboard_snapshot SET user_id = ? WHERE user_id = ?",
+	}
+
+	for _, sql := range updates {
+		if _, err := sess.Exec(sql, toUserID, fromUserID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notInDeletedOrg returns a condition that excludes the rows of deleted
+// organizations.
+func notInDeletedOrg(orgIDColumn string) string {
+	return "NOT EXISTS (SELECT 1 FROM org WHERE org.id = " + orgIDColumn + " AND org.deleted IS NOT NULL)"
+}
+
+// notInDeletedTeam returns a condition that excludes the rows of deleted
+// teams, such as memberships that don't grant permissions anymore.
+func notInDeletedTeam(teamIDColumn string) string {
+	return "NOT EXISTS (SELECT 1 FROM team WHERE team.id = " + teamIDColumn + " AND team.deleted IS NOT NULL)"
+}
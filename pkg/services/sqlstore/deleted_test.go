@@ -0,0 +1,149 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestSoftDelete(t *testing.T) {
+	InitTestDB(t)
+
+	setting.DeletedRetentionDays = 30
+	defer func() { setting.DeletedRetentionDays = 0 }()
+
+	userCmd := &models.CreateUserCommand{Login: "deleted", Email: "deleted@example.com"}
+	require.NoError(t, CreateUser(context.Background(), userCmd))
+	userID := userCmd.Result.Id
+	orgID := userCmd.Result.OrgId
+
+	otherCmd := &models.CreateUserCommand{Login: "other", Email: "other@example.com"}
+	require.NoError(t, CreateUser(context.Background(), otherCmd))
+
+	t.Run("Should hide and restore deleted users", func(t *testing.T) {
+		require.NoError(t, DeleteUser(&models.DeleteUserCommand{UserId: userID}))
+		require.Equal(t, models.ErrUserNotFound, DeleteUser(&models.DeleteUserCommand{UserId: userID}))
+
+		searchQuery := &models.SearchUsersQuery{Query: "deleted", Limit: 10, Page: 1}
+		require.NoError(t, SearchUsers(searchQuery))
+		require.Len(t, searchQuery.Result.Users, 0)
+
+		userQuery := &models.GetUserByIdQuery{Id: userID}
+		require.NoError(t, GetUserById(userQuery))
+		require.True(t, userQuery.Result.IsDisabled)
+
+		deletedQuery := &models.GetDeletedResourcesQuery{Type: models.DeletedResourceUser}
+		require.NoError(t, GetDeletedResources(deletedQuery))
+		require.Len(t, deletedQuery.Result, 1)
+		require.Equal(t, "deleted", deletedQuery.Result[0].Login)
+		require.False(t, deletedQuery.Result[0].Deleted.IsZero())
+		require.Equal(t, deletedQuery.Result[0].Deleted.AddDate(0, 0, 30), deletedQuery.Result[0].PurgeAt)
+
+		require.NoError(t, RestoreUser(&models.RestoreUserCommand{UserId: userID}))
+		require.Equal(t, models.ErrUserNotFound, RestoreUser(&models.RestoreUserCommand{UserId: userID}))
+
+		require.NoError(t, SearchUsers(searchQuery))
+		require.Len(t, searchQuery.Result.Users, 1)
+		require.NoError(t, GetUserById(userQuery))
+		require.False(t, userQuery.Result.IsDisabled)
+	})
+
+	t.Run("Should keep disabled users disabled when restoring them", func(t *testing.T) {
+		disabledCmd := &models.CreateUserCommand{Login: "disabled", Email: "disabled@example.com"}
+		require.NoError(t, CreateUser(context.Background(), disabledCmd))
+		disabledID := disabledCmd.Result.Id
+		require.NoError(t, DisableUser(&models.DisableUserCommand{UserId: disabledID, IsDisabled: true}))
+
+		require.NoError(t, DeleteUser(&models.DeleteUserCommand{UserId: disabledID}))
+		require.NoError(t, RestoreUser(&models.RestoreUserCommand{UserId: disabledID}))
+
+		userQuery := &models.GetUserByIdQuery{Id: disabledID}
+		require.NoError(t, GetUserById(userQuery))
+		require.True(t, userQuery.Result.IsDisabled)
+
+		require.NoError(t, DisableUser(&models.DisableUserCommand{UserId: disabledID, IsDisabled: false}))
+		require.NoError(t, DeleteUser(&models.DeleteUserCommand{UserId: disabledID}))
+		require.NoError(t, RestoreUser(&models.RestoreUserCommand{UserId: disabledID}))
+		require.NoError(t, GetUserById(userQuery))
+		require.False(t, userQuery.Result.IsDisabled)
+	})
+
+	t.Run("Should hide and restore deleted teams", func(t *testing.T) {
+		teamCmd := &models.CreateTeamCommand{OrgId: orgID, Name: "deleted team"}
+		require.NoError(t, CreateTeam(teamCmd))
+		teamID := teamCmd.Result.Id
+
+		require.NoError(t, DeleteTeam(&models.DeleteTeamCommand{OrgId: orgID, Id: teamID}))
+		require.Equal(t, models.ErrTeamNotFound, GetTeamById(&models.GetTeamByIdQuery{OrgId: orgID, Id: teamID}))
+
+		deletedQuery := &models.GetDeletedResourcesQuery{Type: models.DeletedResourceTeam, OrgId: orgID}
+		require.NoError(t, GetDeletedResources(deletedQuery))
+		require.Len(t, deletedQuery.Result, 1)
+
+		require.NoError(t, RestoreTeam(&models.RestoreTeamCommand{OrgId: orgID, Id: teamID}))
+		require.NoError(t, GetTeamById(&models.GetTeamByIdQuery{OrgId: orgID, Id: teamID}))
+	})
+
+	t.Run("Should hide and restore deleted orgs", func(t *testing.T) {
+		orgCmd := &models.CreateOrgCommand{Name: "deleted org", UserId: otherCmd.Result.Id}
+		require.NoError(t, CreateOrg(orgCmd))
+
+		require.NoError(t, DeleteOrg(&models.DeleteOrgCommand{Id: orgCmd.Result.Id}))
+		require.Equal(t, models.ErrOrgNotFound, GetOrgById(&models.GetOrgByIdQuery{Id: orgCmd.Result.Id}))
+
+		orgsQuery := &models.GetUserOrgListQuery{UserId: otherCmd.Result.Id}
+		require.NoError(t, GetUserOrgList(orgsQuery))
+		for _, org := range orgsQuery.Result {
+			require.NotEqual(t, orgCmd.Result.Id, org.OrgId)
+		}
+
+		require.NoError(t, RestoreOrg(&models.RestoreOrgCommand{Id: orgCmd.Result.Id}))
+		require.NoError(t, GetOrgById(&models.GetOrgByIdQuery{Id: orgCmd.Result.Id}))
+	})
+
+	t.Run("Should reassign content of a deleted user", func(t *testing.T) {
+		saveCmd := &models.SaveDashboardCommand{
+			OrgId:     orgID,
+			UserId:    userID,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "reassigned"}),
+		}
+		require.NoError(t, SaveDashboard(saveCmd))
+		dash := saveCmd.Result
+
+		err := DeleteUser(&models.DeleteUserCommand{UserId: userID, ReassignToUserId: 9999})
+		require.Equal(t, models.ErrReassignUserNotFound, err)
+		deletedQuery := &models.GetDeletedResourcesQuery{Type: models.DeletedResourceUser}
+		require.NoError(t, GetDeletedResources(deletedQuery))
+		require.Empty(t, deletedQuery.Result)
+
+		err = DeleteUser(&models.DeleteUserCommand{UserId: userID, ReassignToUserId: otherCmd.Result.Id})
+		require.NoError(t, err)
+
+		query := &models.GetDashboardQuery{OrgId: orgID, Id: dash.Id}
+		require.NoError(t, GetDashboard(query))
+		require.Equal(t, otherCmd.Result.Id, query.Result.CreatedBy)
+		require.Equal(t, otherCmd.Result.Id, query.Result.UpdatedBy)
+
+		require.NoError(t, RestoreUser(&models.RestoreUserCommand{UserId: userID}))
+	})
+
+	t.Run("Should purge expired deleted resources", func(t *testing.T) {
+		require.NoError(t, DeleteUser(&models.DeleteUserCommand{UserId: userID}))
+
+		cmd := &models.PurgeDeletedResourcesCommand{DeletedBefore: time.Now().AddDate(0, 0, -30)}
+		require.NoError(t, PurgeDeletedResources(cmd))
+		require.Equal(t, int64(0), cmd.Result)
+
+		cmd = &models.PurgeDeletedResourcesCommand{DeletedBefore: time.Now().Add(time.Minute)}
+		require.NoError(t, PurgeDeletedResources(cmd))
+		require.Equal(t, int64(1), cmd.Result)
+
+		require.Equal(t, models.ErrUserNotFound, GetUserById(&models.GetUserByIdQuery{Id: userID}))
+	})
+}
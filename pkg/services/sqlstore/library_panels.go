@@ -0,0 +1,452 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateLibraryPanel)
+	bus.AddHandler("sql", UpdateLibraryPanel)
+	bus.AddHandler("sql", DeleteLibraryPanel)
+	bus.AddHandler("sql", GetLibraryPanel)
+	bus.AddHandler("sql", GetLibraryPanels)
+	bus.AddHandler("sql", GetAllLibraryPanels)
+	bus.AddHandler("sql", GetLibraryPanelDashboards)
+}
+
+func CreateLibraryPanel(cmd *models.CreateLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		return createLibraryPanel(sess, cmd)
+	})
+}
+
+func createLibraryPanel(sess *DBSession, cmd *models.CreateLibraryPanelCommand) error {
+	name := strings.TrimSpace(cmd.Name)
+	if name == "" {
+		return models.ErrLibraryPanelNameEmpty
+	}
+
+	model, err := cleanLibraryPanelModel(cmd.Model)
+	if err != nil {
+		return err
+	}
+
+	uid := strings.TrimSpace(cmd.Uid)
+	if uid == "" {
+		if uid, err = generateNewLibraryPanelUid(sess, cmd.OrgId); err != nil {
+			return err
+		}
+	} else if !util.IsValidShortUID(uid) || len(uid) > 40 {
+		return models.ErrLibraryPanelInvalidUid
+	} else if exists, err := sess.Where("org_id=? AND uid=?", cmd.OrgId, uid).Get(&models.LibraryPanel{}); err != nil {
+		return err
+	} else if exists {
+		return models.ErrLibraryPanelWithSameUIDExists
+	}
+
+	if err := checkLibraryPanelName(sess, cmd.OrgId, cmd.FolderId, name, 0); err != nil {
+		return err
+	}
+
+	libraryPanel := &models.LibraryPanel{
+		OrgId:     cmd.OrgId,
+		FolderId:  cmd.FolderId,
+		Uid:       uid,
+		Name:      name,
+		Model:     model,
+		Version:   1,
+		Created:   time.Now(),
+		CreatedBy: cmd.UserId,
+		Updated:   time.Now(),
+		UpdatedBy: cmd.UserId,
+	}
+
+	if _, err := sess.Insert(libraryPanel); err != nil {
+		return err
+	}
+
+	cmd.Result = libraryPanel
+	return nil
+}
+
+func UpdateLibraryPanel(cmd *models.UpdateLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		return updateLibraryPanel(sess, cmd)
+	})
+}
+
+func updateLibraryPanel(sess *DBSession, cmd *models.UpdateLibraryPanelCommand) error {
+	libraryPanel, err := getLibraryPanel(sess, cmd.OrgId, cmd.Uid)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Version != libraryPanel.Version {
+		return models.ErrLibraryPanelVersionMismatch
+	}
+
+	if cmd.FolderId != nil {
+		libraryPanel.FolderId = *cmd.FolderId
+	}
+
+	if cmd.Name != "" {
+		libraryPanel.Name = strings.TrimSpace(cmd.Name)
+		if libraryPanel.Name == "" {
+			return models.ErrLibraryPanelNameEmpty
+		}
+	}
+
+	if cmd.Model != nil {
+		if libraryPanel.Model, err = cleanLibraryPanelModel(cmd.Model); err != nil {
+			return err
+		}
+	}
+
+	if err := checkLibraryPanelName(sess, cmd.OrgId, libraryPanel.FolderId, libraryPanel.Name, libraryPanel.Id); err != nil {
+		return err
+	}
+
+	libraryPanel.Version++
+	libraryPanel.Updated = time.Now()
+	libraryPanel.UpdatedBy = cmd.UserId
+
+	if _, err := sess.ID(libraryPanel.Id).AllCols().Update(libraryPanel); err != nil {
+		return err
+	}
+
+	if err := updateLibraryPanelDashboardsContent(sess, libraryPanel.Id); err != nil {
+		return err
+	}
+
+	cmd.Result = libraryPanel
+	return nil
+}
+
+func DeleteLibraryPanel(cmd *models.DeleteLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		libraryPanel, err := getLibraryPanel(sess, cmd.OrgId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		connections, err := sess.Table("library_panel_dashboard").Where("library_panel_id=?", libraryPanel.Id).Count()
+		if err != nil {
+			return err
+		}
+		if connections > 0 {
+			return models.ErrLibraryPanelHasConnectedDashboards
+		}
+
+		_, err = sess.Exec("DELETE FROM library_panel WHERE id = ?", libraryPanel.Id)
+		return err
+	})
+}
+
+func GetLibraryPanel(query *models.GetLibraryPanelQuery) error {
+	sess := newSession()
+	libraryPanel, err := getLibraryPanel(sess, query.OrgId, query.Uid)
+	if err != nil {
+		return err
+	}
+
+	connections, err := sess.Table("library_panel_dashboard").Where("library_panel_id=?", libraryPanel.Id).Count()
+	if err != nil {
+		return err
+	}
+
+	query.Result = &models.LibraryPanelWithConnections{
+		LibraryPanel:        *libraryPanel,
+		ConnectedDashboards: connections,
+	}
+	return nil
+}
+
+func GetLibraryPanels(query *models.GetLibraryPanelsQuery) error {
+	if query.PerPage <= 0 {
+		query.PerPage = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	where := SqlBuilder{}
+	where.Write(` FROM library_panel
+		LEFT JOIN dashboard ON dashboard.id = library_panel.folder_id
+		WHERE library_panel.org_id = ? AND (library_panel.folder_id = 0 OR (dashboard.id IS NOT NULL`, query.OrgId)
+	where.writeDashboardPermissionFilter(query.SignedInUser, models.PERMISSION_VIEW)
+	where.Write(`))`)
+
+	if len(query.FolderIds) > 0 {
+		where.Write(` AND library_panel.folder_id IN (?` + strings.Repeat(",?", len(query.FolderIds)-1) + `)`)
+		for _, folderID := range query.FolderIds {
+			where.AddParams(folderID)
+		}
+	}
+
+	if query.Query != "" {
+		where.Write(` AND library_panel.name `+dialect.LikeStr()+` ?`, "%"+query.Query+"%")
+	}
+
+	count := make([]*targetCount, 0)
+	if err := x.SQL("SELECT COUNT(*) AS count"+where.GetSqlString(), where.params...).Find(&count); err != nil {
+		return err
+	}
+
+	sql := `SELECT library_panel.*,
+		(SELECT COUNT(*) FROM library_panel_dashboard WHERE library_panel_dashboard.library_panel_id = library_panel.id) AS connected_dashboards` +
+		where.GetSqlString() +
+		` ORDER BY library_panel.name ASC ` +
+		dialect.LimitOffset(int64(query.PerPage), int64((query.Page-1)*query.PerPage))
+
+	query.Result = models.LibraryPanelSearchResult{
+		TotalCount:    count[0].Count,
+		LibraryPanels: make([]*models.LibraryPanelWithConnections, 0),
+	}
+
+	return x.SQL(sql, where.params...).Find(&query.Result.LibraryPanels)
+}
+
+func GetAllLibraryPanels(query *models.GetAllLibraryPanelsQuery) error {
+	query.Result = make([]*models.LibraryPanel, 0)
+	return x.Where("org_id=?", query.OrgId).OrderBy("id").Find(&query.Result)
+}
+
+func GetLibraryPanelDashboards(query *models.GetLibraryPanelDashboardsQuery) error {
+	libraryPanel, err := getLibraryPanel(newSession(), query.OrgId, query.Uid)
+	if err != nil {
+		return err
+	}
+
+	sb := SqlBuilder{}
+	sb.Write(`SELECT dashboard.id, dashboard.uid, dashboard.title, dashboard.slug, dashboard.folder_id
+		FROM library_panel_dashboard
+		INNER JOIN dashboard ON dashboard.id = library_panel_dashboard.dashboard_id
+		WHERE library_panel_dashboard.library_panel_id = ?`, libraryPanel.Id)
+	sb.writeDashboardPermissionFilter(query.SignedInUser, models.PERMISSION_VIEW)
+	sb.Write(` ORDER BY dashboard.title ASC`)
+
+	query.Result = make([]*models.LibraryPanelDashboard, 0)
+	return x.SQL(sb.GetSqlString(), sb.params...).Find(&query.Result)
+}
+
+func getLibraryPanel(sess *DBSession, orgID int64, uid string) (*models.LibraryPanel, error) {
+	libraryPanel := models.LibraryPanel{}
+	has, err := sess.Where("org_id=? AND uid=?", orgID, uid).Get(&libraryPanel)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, models.ErrLibraryPanelNotFound
+	}
+
+	return &libraryPanel, nil
+}
+
+func checkLibraryPanelName(sess *DBSession, orgID int64, folderID int64, name string, id int64) error {
+	exists, err := sess.Where("org_id=? AND folder_id=? AND name=? AND id<>?", orgID, folderID, name, id).Get(&models.LibraryPanel{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return models.ErrLibraryPanelWithSameNameExists
+	}
+	return nil
+}
+
+func generateNewLibraryPanelUid(sess *DBSession, orgID int64) (string, error) {
+	for i := 0; i < 3; i++ {
+		uid := generateNewUid()
+
+		exists, err := sess.Where("org_id=? AND uid=?", orgID, uid).Get(&models.LibraryPanel{})
+		if err != nil {
+			return "", err
+		}
+
+		if !exists {
+			return uid, nil
+		}
+	}
+
+	return "", models.ErrDashboardFailedGenerateUniqueUid
+}
+
+// cleanLibraryPanelModel validates the panel model of a library panel and
+// removes the properties that belong to the dashboards using it.
+func cleanLibraryPanelModel(model *simplejson.Json) (*simplejson.Json, error) {
+	if model == nil {
+		return nil, models.ErrLibraryPanelInvalidModel
+	}
+	if _, err := model.Map(); err != nil {
+		return nil, models.ErrLibraryPanelInvalidModel
+	}
+	if model.Get("type").MustString() == "row" {
+		return nil, models.ErrLibraryPanelInvalidModel
+	}
+	for _, key := range []string{"alert", "panels"} {
+		if _, exists := model.CheckGet(key); exists {
+			return nil, models.ErrLibraryPanelInvalidModel
+		}
+	}
+
+	for _, key := range []string{"id", "gridPos", "libraryPanel"} {
+		model.Del(key)
+	}
+
+	return model, nil
+}
+
+// linkLibraryPanels replaces the library panels of the dashboard with
+// references, so that the dashboard and its versions don't store copies of
+// them, and returns the ids of the library panels. Panels referencing
+// unknown library panels are stored as they are.
+func linkLibraryPanels(sess *DBSession, dash *models.Dashboard) ([]int64, error) {
+	libraryPanels, err := getDashboardLibraryPanels(sess, dash)
+	if err != nil || len(libraryPanels) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(libraryPanels))
+	for _, libraryPanel := range libraryPanels {
+		ids = append(ids, libraryPanel.Id)
+	}
+
+	walkLibraryPanels(dash.Data, func(panel map[string]interface{}, uid string) map[string]interface{} {
+		libraryPanel, ok := libraryPanels[uid]
+		if !ok {
+			return panel
+		}
+
+		return map[string]interface{}{
+			"id":      panel["id"],
+			"gridPos": panel["gridPos"],
+			"libraryPanel": map[string]interface{}{
+				"uid":  libraryPanel.Uid,
+				"name": libraryPanel.Name,
+			},
+		}
+	})
+
+	return ids, nil
+}
+
+// connectLibraryPanels replaces the library panels connected to the
+// dashboard.
+func connectLibraryPanels(sess *DBSession, dashboardID int64, libraryPanelIDs []int64) error {
+	if _, err := sess.Exec("DELETE FROM library_panel_dashboard WHERE dashboard_id = ?", dashboardID); err != nil {
+		return err
+	}
+
+	for _, libraryPanelID := range libraryPanelIDs {
+		if _, err := sess.Exec("INSERT INTO library_panel_dashboard (library_panel_id, dashboard_id, created) VALUES (?, ?, ?)",
+			libraryPanelID, dashboardID, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadLibraryPanels replaces the library panel references of the dashboard
+// with the library panels, keeping the id and position of the panels.
+func loadLibraryPanels(sess *DBSession, dash *models.Dashboard) error {
+	libraryPanels, err := getDashboardLibraryPanels(sess, dash)
+	if err != nil || len(libraryPanels) == 0 {
+		return err
+	}
+
+	var walkErr error
+	walkLibraryPanels(dash.Data, func(panel map[string]interface{}, uid string) map[string]interface{} {
+		libraryPanel, ok := libraryPanels[uid]
+		if !ok || walkErr != nil {
+			return panel
+		}
+
+		// every panel gets its own copy of the model
+		data, err := libraryPanel.Model.Encode()
+		if err != nil {
+			walkErr = err
+			return panel
+		}
+		model, err := simplejson.NewJson(data)
+		if err != nil {
+			walkErr = err
+			return panel
+		}
+
+		resolved := model.MustMap()
+		resolved["id"] = panel["id"]
+		resolved["gridPos"] = panel["gridPos"]
+		resolved["libraryPanel"] = map[string]interface{}{
+			"uid":     libraryPanel.Uid,
+			"name":    libraryPanel.Name,
+			"version": libraryPanel.Version,
+		}
+		return resolved
+	})
+
+	return walkErr
+}
+
+// getDashboardLibraryPanels returns the library panels referenced by the
+// dashboard by uid.
+func getDashboardLibraryPanels(sess *DBSession, dash *models.Dashboard) (map[string]*models.LibraryPanel, error) {
+	if dash.IsFolder || dash.Data == nil {
+		return nil, nil
+	}
+
+	uids := make([]interface{}, 0)
+	walkLibraryPanels(dash.Data, func(panel map[string]interface{}, uid string) map[string]interface{} {
+		uids = append(uids, uid)
+		return panel
+	})
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	libraryPanels := make([]*models.LibraryPanel, 0)
+	err := sess.Where("org_id=?", dash.OrgId).In("uid", uids...).Find(&libraryPanels)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*models.LibraryPanel, len(libraryPanels))
+	for _, libraryPanel := range libraryPanels {
+		result[libraryPanel.Uid] = libraryPanel
+	}
+
+	return result, nil
+}
+
+// walkLibraryPanels calls fn for the panels of the dashboard that reference a
+// library panel, including the panels of collapsed rows, and replaces them
+// with the returned panels.
+func walkLibraryPanels(data *simplejson.Json, fn func(panel map[string]interface{}, uid string) map[string]interface{}) {
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for i, p := range panels {
+			panel, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if nested, ok := panel["panels"].([]interface{}); ok {
+				walk(nested)
+			}
+
+			libraryPanel, ok := panel["libraryPanel"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if uid, ok := libraryPanel["uid"].(string); ok && uid != "" {
+				panels[i] = fn(panel, uid)
+			}
+		}
+	}
+
+	walk(data.Get("panels").MustArray())
+}
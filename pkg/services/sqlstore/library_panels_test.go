@@ -0,0 +1,159 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestLibraryPanels(t *testing.T) {
+	InitTestDB(t)
+
+	createLibraryPanel := func(t *testing.T, folderID int64, name string) *models.LibraryPanel {
+		cmd := &models.CreateLibraryPanelCommand{
+			OrgId:    1,
+			FolderId: folderID,
+			Name:     name,
+			Model: simplejson.NewFromAny(map[string]interface{}{
+				"id":      3,
+				"type":    "graph",
+				"title":   "Service health",
+				"gridPos": map[string]interface{}{"x": 0, "y": 0, "w": 12, "h": 8},
+			}),
+		}
+		require.NoError(t, CreateLibraryPanel(cmd))
+		return cmd.Result
+	}
+
+	saveDashboard := func(t *testing.T, data map[string]interface{}) *models.Dashboard {
+		cmd := &models.SaveDashboardCommand{OrgId: 1, Dashboard: simplejson.NewFromAny(data), Overwrite: true}
+		require.NoError(t, SaveDashboard(cmd))
+		return cmd.Result
+	}
+
+	t.Run("Should create, update and delete library panels", func(t *testing.T) {
+		libraryPanel := createLibraryPanel(t, 0, "Service health")
+		require.NotEmpty(t, libraryPanel.Uid)
+		require.Equal(t, int64(1), libraryPanel.Version)
+
+		_, hasID := libraryPanel.Model.CheckGet("id")
+		require.False(t, hasID)
+		_, hasGridPos := libraryPanel.Model.CheckGet("gridPos")
+		require.False(t, hasGridPos)
+
+		err := CreateLibraryPanel(&models.CreateLibraryPanelCommand{
+			OrgId: 1, Name: "Service health", Model: simplejson.NewFromAny(map[string]interface{}{"type": "graph"}),
+		})
+		require.Equal(t, models.ErrLibraryPanelWithSameNameExists, err)
+
+		err = CreateLibraryPanel(&models.CreateLibraryPanelCommand{
+			OrgId: 1, Name: "Row", Model: simplejson.NewFromAny(map[string]interface{}{"type": "row"}),
+		})
+		require.Equal(t, models.ErrLibraryPanelInvalidModel, err)
+
+		updateCmd := &models.UpdateLibraryPanelCommand{
+			OrgId:   1,
+			Uid:     libraryPanel.Uid,
+			Name:    "Service health v2",
+			Version: 1,
+		}
+		require.NoError(t, UpdateLibraryPanel(updateCmd))
+		require.Equal(t, int64(2), updateCmd.Result.Version)
+		require.Equal(t, "Service health v2", updateCmd.Result.Name)
+		require.Equal(t, "graph", updateCmd.Result.Model.Get("type").MustString())
+
+		require.Equal(t, models.ErrLibraryPanelVersionMismatch, UpdateLibraryPanel(updateCmd))
+
+		require.NoError(t, DeleteLibraryPanel(&models.DeleteLibraryPanelCommand{OrgId: 1, Uid: libraryPanel.Uid}))
+		err = GetLibraryPanel(&models.GetLibraryPanelQuery{OrgId: 1, Uid: libraryPanel.Uid})
+		require.Equal(t, models.ErrLibraryPanelNotFound, err)
+	})
+
+	t.Run("Should link library panels on save and load them with the dashboard", func(t *testing.T) {
+		libraryPanel := createLibraryPanel(t, 0, "Linked")
+
+		dash := saveDashboard(t, map[string]interface{}{
+			"title": "Uses library panel",
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id":           1,
+					"type":         "graph",
+					"title":        "Stale copy",
+					"gridPos":      map[string]interface{}{"x": 0, "y": 0, "w": 6, "h": 4},
+					"libraryPanel": map[string]interface{}{"uid": libraryPanel.Uid, "name": libraryPanel.Name},
+				},
+				map[string]interface{}{
+					"id":           2,
+					"type":         "graph",
+					"libraryPanel": map[string]interface{}{"uid": "unknown"},
+				},
+				map[string]interface{}{"id": 3, "type": "text"},
+			},
+		})
+
+		// the dashboard and its version only store the reference
+		version := &models.GetDashboardVersionQuery{OrgId: 1, DashboardId: dash.Id, Version: dash.Version}
+		require.NoError(t, GetDashboardVersion(version))
+		stored := version.Result.Data.Get("panels").GetIndex(0)
+		_, hasTitle := stored.CheckGet("title")
+		require.False(t, hasTitle)
+		require.Equal(t, libraryPanel.Uid, stored.GetPath("libraryPanel", "uid").MustString())
+		require.Equal(t, "graph", version.Result.Data.Get("panels").GetIndex(1).Get("type").MustString())
+
+		query := &models.GetDashboardQuery{OrgId: 1, Id: dash.Id}
+		require.NoError(t, GetDashboard(query))
+		loaded := query.Result.Data.Get("panels").GetIndex(0)
+		require.Equal(t, "Service health", loaded.Get("title").MustString())
+		require.Equal(t, 1, loaded.Get("id").MustInt())
+		require.Equal(t, 6, loaded.GetPath("gridPos", "w").MustInt())
+		require.Equal(t, int64(1), loaded.GetPath("libraryPanel", "version").MustInt64())
+
+		dashboardsQuery := &models.GetLibraryPanelDashboardsQuery{
+			OrgId:        1,
+			Uid:          libraryPanel.Uid,
+			SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+		}
+		require.NoError(t, GetLibraryPanelDashboards(dashboardsQuery))
+		require.Len(t, dashboardsQuery.Result, 1)
+		require.Equal(t, dash.Uid, dashboardsQuery.Result[0].Uid)
+
+		err := DeleteLibraryPanel(&models.DeleteLibraryPanelCommand{OrgId: 1, Uid: libraryPanel.Uid})
+		require.Equal(t, models.ErrLibraryPanelHasConnectedDashboards, err)
+
+		// saving the loaded dashboard keeps the reference
+		query.Result.Data.Set("id", dash.Id)
+		saveDashboard(t, query.Result.Data.MustMap())
+		getQuery := &models.GetLibraryPanelQuery{OrgId: 1, Uid: libraryPanel.Uid}
+		require.NoError(t, GetLibraryPanel(getQuery))
+		require.Equal(t, int64(1), getQuery.Result.ConnectedDashboards)
+
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{OrgId: 1, Id: dash.Id}))
+		require.NoError(t, DeleteLibraryPanel(&models.DeleteLibraryPanelCommand{OrgId: 1, Uid: libraryPanel.Uid}))
+	})
+
+	t.Run("Should only list library panels in folders the user can view", func(t *testing.T) {
+		folder := saveDashboard(t, map[string]interface{}{"title": "Restricted"})
+		_, err := x.Exec("UPDATE dashboard SET is_folder = ?, has_acl = ? WHERE id = ?", true, true, folder.Id)
+		require.NoError(t, err)
+
+		createLibraryPanel(t, 0, "General panel")
+		createLibraryPanel(t, folder.Id, "Restricted panel")
+
+		query := &models.GetLibraryPanelsQuery{
+			OrgId:        1,
+			SignedInUser: &models.SignedInUser{OrgId: 1, UserId: 42, OrgRole: models.ROLE_VIEWER},
+		}
+		require.NoError(t, GetLibraryPanels(query))
+		require.Equal(t, int64(1), query.Result.TotalCount)
+		require.Equal(t, "General panel", query.Result.LibraryPanels[0].Name)
+
+		query.SignedInUser = &models.SignedInUser{OrgId: 1, UserId: 1, OrgRole: models.ROLE_ADMIN}
+		query.Query = "restricted"
+		require.NoError(t, GetLibraryPanels(query))
+		require.Equal(t, int64(1), query.Result.TotalCount)
+		require.Equal(t, "Restricted panel", query.Result.LibraryPanels[0].Name)
+	})
+}
@@ -14,6 +14,8 @@ func init() {
 	bus.AddHandler("sql", CreateLoginAttempt)
 	bus.AddHandler("sql", DeleteOldLoginAttempts)
 	bus.AddHandler("sql", GetUserLoginAttemptCount)
+	bus.AddHandler("sql", DeleteUserLoginAttempts)
+	bus.AddHandler("sql", GetLoginAttempts)
 }
 
 func CreateLoginAttempt(cmd *models.CreateLoginAttemptCommand) error {
@@ -81,6 +83,22 @@ func GetUserLoginAttemptCount(query *models.GetUserLoginAttemptCountQuery) error
 	return nil
 }
 
+func DeleteUserLoginAttempts(cmd *models.DeleteUserLoginAttemptsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		_, err := sess.Exec("DELETE FROM login_attempt WHERE username = ?", cmd.Username)
+		return err
+	})
+}
+
+func GetLoginAttempts(query *models.GetLoginAttemptsQuery) error {
+	query.Result = make([]*models.LoginAttempt, 0)
+	return x.
+		Where("created >= ?", query.Since.Unix()).
+		Asc("username").
+		Desc("created").
+		Find(&query.Result)
+}
+
 func toInt64(i interface{}) int64 {
 	switch i := i.(type) {
 	case []byte:
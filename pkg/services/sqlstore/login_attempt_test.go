@@ -121,5 +121,36 @@ func TestLoginAttempts(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(cmd.DeletedRows, ShouldEqual, 3)
 		})
+
+		Convey("Should return login attempts of all users newest first", func() {
+			mockTime(timePlusTwoMinutes)
+			err := CreateLoginAttempt(&models.CreateLoginAttemptCommand{
+				Username:  "another",
+				IpAddress: "192.168.0.2",
+			})
+			So(err, ShouldBeNil)
+
+			query := models.GetLoginAttemptsQuery{Since: timePlusOneMinute}
+			err = GetLoginAttempts(&query)
+			So(err, ShouldBeNil)
+			So(query.Result, ShouldHaveLength, 3)
+			So(query.Result[0].Username, ShouldEqual, "another")
+			So(query.Result[1].Username, ShouldEqual, user)
+			So(query.Result[1].Created, ShouldEqual, timePlusTwoMinutes.Unix())
+			So(query.Result[2].Created, ShouldEqual, timePlusOneMinute.Unix())
+		})
+
+		Convey("Should delete the login attempts of the user", func() {
+			err := DeleteUserLoginAttempts(&models.DeleteUserLoginAttemptsCommand{Username: user})
+			So(err, ShouldBeNil)
+
+			query := models.GetUserLoginAttemptCountQuery{
+				Username: user,
+				Since:    beginningOfTime,
+			}
+			err = GetUserLoginAttemptCount(&query)
+			So(err, ShouldBeNil)
+			So(query.Result, ShouldEqual, 0)
+		})
 	})
 }
@@ -0,0 +1,76 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addAccessControlMigrations(mg *Migrator) {
+	customRoleV1 := Table{
+		Name: "custom_role",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create custom_role table", NewAddTableMigration(customRoleV1))
+	mg.AddMigration("add unique index custom_role.org_id_uid", NewAddIndexMigration(customRoleV1, customRoleV1.Indices[0]))
+	mg.AddMigration("add unique index custom_role.org_id_name", NewAddIndexMigration(customRoleV1, customRoleV1.Indices[1]))
+
+	rolePermissionV1 := Table{
+		Name: "role_permission",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "role_id", Type: DB_BigInt, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"role_id", "action"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create role_permission table", NewAddTableMigration(rolePermissionV1))
+	mg.AddMigration("add unique index role_permission.role_id_action", NewAddIndexMigration(rolePermissionV1, rolePermissionV1.Indices[0]))
+
+	userRoleV1 := Table{
+		Name: "user_role",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "role_id", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "user_id", "role_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create user_role table", NewAddTableMigration(userRoleV1))
+	mg.AddMigration("add unique index user_role.org_id_user_id_role_id", NewAddIndexMigration(userRoleV1, userRoleV1.Indices[0]))
+
+	teamRoleV1 := Table{
+		Name: "team_role",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "team_id", Type: DB_BigInt, Nullable: false},
+			{Name: "role_id", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "team_id", "role_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create team_role table", NewAddTableMigration(teamRoleV1))
+	mg.AddMigration("add unique index team_role.org_id_team_id_role_id", NewAddIndexMigration(teamRoleV1, teamRoleV1.Indices[0]))
+}
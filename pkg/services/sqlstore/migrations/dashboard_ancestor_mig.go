@@ -0,0 +1,38 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardAncestorMigrations(mg *Migrator) {
+	dashboardAncestorV1 := Table{
+		Name: "dashboard_ancestor",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "ancestor_id", Type: DB_BigInt, Nullable: false},
+			{Name: "depth", Type: DB_Int, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "ancestor_id"}, Type: UniqueIndex},
+			{Cols: []string{"ancestor_id"}},
+			{Cols: []string{"org_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_ancestor table v1", NewAddTableMigration(dashboardAncestorV1))
+	addTableIndicesMigrations(mg, "v1", dashboardAncestorV1)
+
+	// before nested folders the parent folder was the only ancestor
+	const rawSQL = `
+INSERT INTO dashboard_ancestor
+	(
+		org_id,
+		dashboard_id,
+		ancestor_id,
+		depth
+	)
+	SELECT org_id, id, folder_id, 1 FROM dashboard WHERE folder_id > 0
+	`
+
+	mg.AddMigration("copy parent folders to dashboard_ancestor table", NewRawSqlMigration(rawSQL))
+}
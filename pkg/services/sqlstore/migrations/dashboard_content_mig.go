@@ -0,0 +1,44 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardContentMigrations(mg *Migrator) {
+	dashboardContentV1 := Table{
+		Name: "dashboard_content",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "panel_id", Type: DB_BigInt, Nullable: false},
+			{Name: "kind", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "text", Type: DB_Text, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id"}},
+			{Cols: []string{"org_id", "kind"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_content table v1", NewAddTableMigration(dashboardContentV1))
+	addTableIndicesMigrations(mg, "v1", dashboardContentV1)
+
+	dashboardContentTermV1 := Table{
+		Name: "dashboard_content_term",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "content_id", Type: DB_BigInt, Nullable: false},
+			{Name: "term", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "term"}},
+			{Cols: []string{"content_id"}},
+			{Cols: []string{"dashboard_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_content_term table v1", NewAddTableMigration(dashboardContentTermV1))
+	addTableIndicesMigrations(mg, "v1", dashboardContentTermV1)
+}
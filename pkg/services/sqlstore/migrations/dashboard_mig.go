@@ -219,4 +219,17 @@ func addDashboardMigration(mg *Migrator) {
 		Cols: []string{"title"},
 		Type: IndexType,
 	}))
+
+	mg.AddMigration("Add commit_hash column to dashboard_provisioning", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "commit_hash", Type: DB_NVarchar, Length: 40, Nullable: true,
+	}))
+	mg.AddMigration("Add commit_author column to dashboard_provisioning", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "commit_author", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+	mg.AddMigration("Add commit_message column to dashboard_provisioning", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "commit_message", Type: DB_Text, Nullable: true,
+	}))
+	mg.AddMigration("Add commit_time column to dashboard_provisioning", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "commit_time", Type: DB_BigInt, Nullable: true,
+	}))
 }
@@ -64,4 +64,47 @@ func addDashboardSnapshotMigrations(mg *Migrator) {
 	mg.AddMigration("Add column external_delete_url to dashboard_snapshots table", NewAddColumnMigration(snapshotV5, &Column{
 		Name: "external_delete_url", Type: DB_NVarchar, Length: 255, Nullable: true,
 	}))
+
+	// columns of snapshots generated and refreshed by the server
+	mg.AddMigration("Add column dashboard_id to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "dashboard_id", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("Add column time_from to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "time_from", Type: DB_NVarchar, Length: 100, Nullable: true,
+	}))
+	mg.AddMigration("Add column time_to to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "time_to", Type: DB_NVarchar, Length: 100, Nullable: true,
+	}))
+	mg.AddMigration("Add column refresh_interval to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "refresh_interval", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("Add column next_refresh to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "next_refresh", Type: DB_DateTime, Nullable: true,
+	}))
+	mg.AddMigration("Add column refresh_error to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "refresh_error", Type: DB_Text, Nullable: true,
+	}))
+	mg.AddMigration("Add column version to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "version", Type: DB_Int, Nullable: false, Default: "1",
+	}))
+	mg.AddMigration("Add index dashboard_snapshot.dashboard_id", NewAddIndexMigration(snapshotV5, &Index{
+		Cols: []string{"dashboard_id"},
+	}))
+
+	snapshotVersionV1 := Table{
+		Name: "dashboard_snapshot_version",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "snapshot_id", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_Int, Nullable: false},
+			{Name: "dashboard", Type: DB_MediumText, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"snapshot_id", "version"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create dashboard_snapshot_version table v1", NewAddTableMigration(snapshotVersionV1))
+	addTableIndicesMigrations(mg, "v1", snapshotVersionV1)
 }
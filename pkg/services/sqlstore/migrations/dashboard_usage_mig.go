@@ -0,0 +1,46 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardUsageMigrations(mg *Migrator) {
+	dashboardUsageV1 := Table{
+		Name: "dashboard_usage",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "day", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "views", Type: DB_BigInt, Nullable: false},
+			{Name: "queries", Type: DB_BigInt, Nullable: false},
+			{Name: "errors", Type: DB_BigInt, Nullable: false},
+			{Name: "last_viewed", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "user_id", "day"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "day"}},
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_usage table v1", NewAddTableMigration(dashboardUsageV1))
+	addTableIndicesMigrations(mg, "v1", dashboardUsageV1)
+
+	dashboardViewEventV1 := Table{
+		Name: "dashboard_view_event",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "created"}},
+			{Cols: []string{"created"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_view_event table v1", NewAddTableMigration(dashboardViewEventV1))
+	addTableIndicesMigrations(mg, "v1", dashboardViewEventV1)
+}
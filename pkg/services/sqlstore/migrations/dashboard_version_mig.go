@@ -56,4 +56,15 @@ FROM dashboard;`
 	// change column type of dashboard_version.data
 	mg.AddMigration("alter dashboard_version.data to mediumtext v1", NewRawSqlMigration("").
 		Mysql("ALTER TABLE dashboard_version MODIFY data MEDIUMTEXT;"))
+
+	// add columns to pin and label versions and to restore versions to their folder
+	mg.AddMigration("Add column pinned in dashboard_version", NewAddColumnMigration(dashboardVersionV1, &Column{
+		Name: "pinned", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("Add column label in dashboard_version", NewAddColumnMigration(dashboardVersionV1, &Column{
+		Name: "label", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+	mg.AddMigration("Add column folder_id in dashboard_version", NewAddColumnMigration(dashboardVersionV1, &Column{
+		Name: "folder_id", Type: DB_BigInt, Nullable: true,
+	}))
 }
@@ -151,4 +151,31 @@ func addDataSourceMigration(mg *Migrator) {
 	mg.AddMigration("Add unique index datasource_org_id_uid", NewAddIndexMigration(tableV2, &Index{
 		Cols: []string{"org_id", "uid"}, Type: UniqueIndex,
 	}))
+
+	mg.AddMigration("Add permissions_enabled column", NewAddColumnMigration(tableV2, &Column{
+		Name: "permissions_enabled", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+
+	permissionV1 := Table{
+		Name: "data_source_permission",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "datasource_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: true},
+			{Name: "team_id", Type: DB_BigInt, Nullable: true},
+			{Name: "role", Type: DB_NVarchar, Length: 20, Nullable: true},
+			{Name: "permission", Type: DB_SmallInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "datasource_id"}},
+			{Cols: []string{"user_id"}},
+			{Cols: []string{"team_id"}},
+		},
+	}
+
+	mg.AddMigration("create data_source_permission table", NewAddTableMigration(permissionV1))
+	addTableIndicesMigrations(mg, "v1", permissionV1)
 }
@@ -34,6 +34,16 @@ func AddMigrations(mg *Migrator) {
 	addServerlockMigrations(mg)
 	addUserAuthTokenMigrations(mg)
 	addCacheMigration(mg)
+	addUserMfaMigrations(mg)
+	addAccessControlMigrations(mg)
+	addUserPasswordHistoryMigrations(mg)
+	addScimMigrations(mg)
+	addLibraryPanelMigrations(mg)
+	addDashboardAncestorMigrations(mg)
+	addDashboardContentMigrations(mg)
+	addDashboardViewMigrations(mg)
+	addDashboardUsageMigrations(mg)
+	addDashboardPublicShareMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
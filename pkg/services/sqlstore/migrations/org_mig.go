@@ -65,4 +65,12 @@ func addOrgMigrations(mg *Migrator) {
 
 	const migrateReadOnlyViewersToViewers = `UPDATE org_user SET role = 'Viewer' WHERE role = 'Read Only Editor'`
 	mg.AddMigration("Migrate all Read Only Viewers to Viewers", NewRawSqlMigration(migrateReadOnlyViewersToViewers))
+
+	mg.AddMigration("Add column session_idle_timeout to org", NewAddColumnMigration(orgV1, &Column{
+		Name: "session_idle_timeout", Type: DB_Int, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("Add column deleted to org", NewAddColumnMigration(orgV1, &Column{
+		Name: "deleted", Type: DB_DateTime, Nullable: true,
+	}))
 }
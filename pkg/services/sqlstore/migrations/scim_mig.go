@@ -0,0 +1,42 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addScimMigrations(mg *Migrator) {
+	scimTokenV1 := Table{
+		Name: "scim_token",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "key", Type: DB_Varchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+			{Cols: []string{"key"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create scim_token table", NewAddTableMigration(scimTokenV1))
+	addTableIndicesMigrations(mg, "v1", scimTokenV1)
+
+	scimExternalIdV1 := Table{
+		Name: "scim_external_id",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "resource_type", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "resource_id", Type: DB_BigInt, Nullable: false},
+			{Name: "external_id", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "resource_type", "resource_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create scim_external_id table", NewAddTableMigration(scimExternalIdV1))
+	addTableIndicesMigrations(mg, "v1", scimExternalIdV1)
+}
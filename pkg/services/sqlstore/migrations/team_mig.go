@@ -60,4 +60,8 @@ func addTeamMigrations(mg *Migrator) {
 	mg.AddMigration("Add column permission to team_member table", NewAddColumnMigration(teamMemberV1, &Column{
 		Name: "permission", Type: DB_SmallInt, Nullable: true,
 	}))
+
+	mg.AddMigration("Add column deleted to team table", NewAddColumnMigration(teamV1, &Column{
+		Name: "deleted", Type: DB_DateTime, Nullable: true,
+	}))
 }
@@ -29,4 +29,11 @@ func addUserAuthTokenMigrations(mg *Migrator) {
 	mg.AddMigration("create user auth token table", NewAddTableMigration(userAuthTokenV1))
 	mg.AddMigration("add unique index user_auth_token.auth_token", NewAddIndexMigration(userAuthTokenV1, userAuthTokenV1.Indices[0]))
 	mg.AddMigration("add unique index user_auth_token.prev_auth_token", NewAddIndexMigration(userAuthTokenV1, userAuthTokenV1.Indices[1]))
+
+	mg.AddMigration("add column auth_module to user_auth_token", NewAddColumnMigration(userAuthTokenV1, &Column{
+		Name: "auth_module", Type: DB_NVarchar, Length: 190, Nullable: true,
+	}))
+	mg.AddMigration("add index user_auth_token.user_id", NewAddIndexMigration(userAuthTokenV1, &Index{
+		Cols: []string{"user_id"},
+	}))
 }
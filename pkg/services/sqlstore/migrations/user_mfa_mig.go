@@ -0,0 +1,28 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addUserMfaMigrations(mg *Migrator) {
+	userMfaV1 := Table{
+		Name: "user_mfa",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "secret", Type: DB_Text, Nullable: false},
+			{Name: "recovery_codes", Type: DB_Text, Nullable: true},
+			{Name: "is_enabled", Type: DB_Bool, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create user_mfa table", NewAddTableMigration(userMfaV1))
+	mg.AddMigration("add unique index user_mfa.user_id", NewAddIndexMigration(userMfaV1, userMfaV1.Indices[0]))
+
+	mg.AddMigration("Add last_totp_step column to user_mfa", NewAddColumnMigration(userMfaV1, &Column{
+		Name: "last_totp_step", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+}
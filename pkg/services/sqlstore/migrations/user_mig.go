@@ -126,6 +126,18 @@ func addUserMigrations(mg *Migrator) {
 	mg.AddMigration("Add index user.login/user.email", NewAddIndexMigration(userV2, &Index{
 		Cols: []string{"login", "email"},
 	}))
+
+	// deleted is set for users that have been deleted but can still be restored until
+	// the cleanup service purges them after the retention period.
+	mg.AddMigration("Add deleted column to user", NewAddColumnMigration(userV2, &Column{
+		Name: "deleted", Type: DB_DateTime, Nullable: true,
+	}))
+
+	// disabled_before_delete keeps whether deleted users were disabled, since
+	// they're disabled until they're restored.
+	mg.AddMigration("Add disabled_before_delete column to user", NewAddColumnMigration(userV2, &Column{
+		Name: "disabled_before_delete", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
 }
 
 type AddMissingUserSaltAndRandsMigration struct {
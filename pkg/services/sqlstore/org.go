@@ -18,6 +18,7 @@ func init() {
 	bus.AddHandler("sql", CreateOrg)
 	bus.AddHandler("sql", UpdateOrg)
 	bus.AddHandler("sql", UpdateOrgAddress)
+	bus.AddHandler("sql", UpdateOrgSessionSettings)
 	bus.AddHandler("sql", GetOrgByName)
 	bus.AddHandler("sql", SearchOrgs)
 	bus.AddHandler("sql", DeleteOrg)
@@ -26,6 +27,7 @@ func init() {
 func SearchOrgs(query *models.SearchOrgsQuery) error {
 	query.Result = make([]*models.OrgDTO, 0)
 	sess := x.Table("org")
+	sess.Where("deleted IS NULL")
 	if query.Query != "" {
 		sess.Where("name LIKE ?", query.Query+"%")
 	}
@@ -45,7 +47,7 @@ func SearchOrgs(query *models.SearchOrgsQuery) error {
 
 func GetOrgById(query *models.GetOrgByIdQuery) error {
 	var org models.Org
-	exists, err := x.Id(query.Id).Get(&org)
+	exists, err := x.Where("id=? AND deleted IS NULL", query.Id).Get(&org)
 	if err != nil {
 		return err
 	}
@@ -60,7 +62,7 @@ func GetOrgById(query *models.GetOrgByIdQuery) error {
 
 func GetOrgByName(query *models.GetOrgByNameQuery) error {
 	var org models.Org
-	exists, err := x.Where("name=?", query.Name).Get(&org)
+	exists, err := x.Where("name=? AND deleted IS NULL", query.Name).Get(&org)
 	if err != nil {
 		return err
 	}
@@ -188,34 +190,72 @@ func UpdateOrgAddress(cmd *models.UpdateOrgAddressCommand) error {
 	})
 }
 
+func UpdateOrgSessionSettings(cmd *models.UpdateOrgSessionSettingsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		org := models.Org{
+			SessionIdleTimeout: cmd.IdleTimeoutMinutes,
+			Updated:            time.Now(),
+		}
+
+		affected, err := sess.ID(cmd.OrgId).Cols("session_idle_timeout", "updated").Update(&org)
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return models.ErrOrgNotFound
+		}
+
+		return nil
+	})
+}
+
+// DeleteOrg deletes the organization, or marks it as deleted if deleted
+// organizations are kept for a retention period.
 func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		if softDeleteEnabled() {
+			return softDeleteOrg(sess, cmd.Id)
+		}
+
 		if res, err := sess.Query("SELECT 1 from org WHERE id=?", cmd.Id); err != nil {
 			return err
 		} else if len(res) != 1 {
 			return models.ErrOrgNotFound
 		}
 
-		deletes := []string{
-			"DELETE FROM star WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND star.dashboard_id = dashboard.id)",
-			"DELETE FROM dashboard_tag WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_tag.dashboard_id = dashboard.id)",
-			"DELETE FROM dashboard WHERE org_id = ?",
-			"DELETE FROM api_key WHERE org_id = ?",
-			"DELETE FROM data_source WHERE org_id = ?",
-			"DELETE FROM org_user WHERE org_id = ?",
-			"DELETE FROM org WHERE id = ?",
-			"DELETE FROM temp_user WHERE org_id = ?",
-		}
+		return deleteOrgInTransaction(sess, cmd.Id)
+	})
+}
+
+func deleteOrgInTransaction(sess *DBSession, orgID int64) error {
+	deletes := []string{
+		"DELETE FROM star WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND star.dashboard_id = dashboard.id)",
+		"DELETE FROM dashboard_tag WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_tag.dashboard_id = dashboard.id)",
+		"DELETE FROM dashboard_ancestor WHERE org_id = ?",
+		"DELETE FROM dashboard WHERE org_id = ?",
+		"DELETE FROM api_key WHERE org_id = ?",
+		"DELETE FROM data_source WHERE org_id = ?",
+		"DELETE FROM data_source_permission WHERE org_id = ?",
+		"DELETE FROM org_user WHERE org_id = ?",
+		"DELETE FROM org WHERE id = ?",
+		"DELETE FROM temp_user WHERE org_id = ?",
+		"DELETE FROM role_permission WHERE EXISTS (SELECT 1 FROM custom_role WHERE org_id = ? AND role_permission.role_id = custom_role.id)",
+		"DELETE FROM user_role WHERE org_id = ?",
+		"DELETE FROM team_role WHERE org_id = ?",
+		"DELETE FROM custom_role WHERE org_id = ?",
+		"DELETE FROM scim_token WHERE org_id = ?",
+		"DELETE FROM scim_external_id WHERE org_id = ?",
+	}
 
-		for _, sql := range deletes {
-			_, err := sess.Exec(sql, cmd.Id)
-			if err != nil {
-				return err
-			}
+	for _, sql := range deletes {
+		_, err := sess.Exec(sql, orgID)
+		if err != nil {
+			return err
 		}
+	}
 
-		return nil
-	})
+	return nil
 }
 
 func verifyExistingOrg(sess *DBSession, orgId int64) error {
@@ -130,6 +130,31 @@ func TestAccountDataAccess(t *testing.T) {
 					So(query.Result.IsGrafanaAdmin, ShouldBeTrue)
 				})
 
+				Convey("Can update org session settings", func() {
+					err := UpdateOrgSessionSettings(&models.UpdateOrgSessionSettingsCommand{OrgId: ac1.OrgId, IdleTimeoutMinutes: 30})
+					So(err, ShouldBeNil)
+
+					orgQuery := models.GetOrgByIdQuery{Id: ac1.OrgId}
+					err = GetOrgById(&orgQuery)
+					So(err, ShouldBeNil)
+					So(orgQuery.Result.SessionIdleTimeout, ShouldEqual, 30)
+
+					query := models.GetSignedInUserQuery{UserId: ac2.Id, OrgId: ac1.OrgId}
+					err = GetSignedInUser(&query)
+					So(err, ShouldBeNil)
+					So(query.Result.OrgSessionIdleTimeout, ShouldEqual, 30)
+
+					err = UpdateOrgSessionSettings(&models.UpdateOrgSessionSettingsCommand{OrgId: ac1.OrgId, IdleTimeoutMinutes: 0})
+					So(err, ShouldBeNil)
+
+					err = GetOrgById(&orgQuery)
+					So(err, ShouldBeNil)
+					So(orgQuery.Result.SessionIdleTimeout, ShouldEqual, 0)
+
+					err = UpdateOrgSessionSettings(&models.UpdateOrgSessionSettingsCommand{OrgId: 999, IdleTimeoutMinutes: 30})
+					So(err, ShouldEqual, models.ErrOrgNotFound)
+				})
+
 				Convey("Can get user organizations", func() {
 					query := models.GetUserOrgListQuery{UserId: ac2.Id}
 					err := GetUserOrgList(&query)
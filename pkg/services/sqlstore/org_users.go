@@ -105,6 +105,7 @@ func GetOrgUsers(query *models.GetOrgUsersQuery) error {
 
 	whereConditions = append(whereConditions, "org_user.org_id = ?")
 	whereParams = append(whereParams, query.OrgId)
+	whereConditions = append(whereConditions, x.Dialect().Quote("user")+".deleted IS NULL")
 
 	if query.Query != "" {
 		queryWithWildcards := "%" + query.Query + "%"
@@ -156,6 +157,9 @@ func RemoveOrgUser(cmd *models.RemoveOrgUserCommand) error {
 			"DELETE FROM org_user WHERE org_id=? and user_id=?",
 			"DELETE FROM dashboard_acl WHERE org_id=? and user_id = ?",
 			"DELETE FROM team_member WHERE org_id=? and user_id = ?",
+			"DELETE FROM user_role WHERE org_id=? and user_id = ?",
+			"DELETE FROM data_source_permission WHERE org_id=? and user_id = ?",
+			"DELETE FROM scim_external_id WHERE org_id=? and resource_type = 'User' and resource_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -174,7 +178,7 @@ func RemoveOrgUser(cmd *models.RemoveOrgUserCommand) error {
 		var userOrgs []*models.UserOrgDTO
 		sess.Table("org_user")
 		sess.Join("INNER", "org", "org_user.org_id=org.id")
-		sess.Where("org_user.user_id=?", user.Id)
+		sess.Where("org_user.user_id=? AND org.deleted IS NULL", user.Id)
 		sess.Cols("org.name", "org_user.role", "org_user.org_id")
 		err := sess.Find(&userOrgs)
 
@@ -199,7 +203,12 @@ func RemoveOrgUser(cmd *models.RemoveOrgUserCommand) error {
 			}
 		} else if cmd.ShouldDeleteOrphanedUser {
 			// no other orgs, delete the full user
-			if err := deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: user.Id}); err != nil {
+			if softDeleteEnabled() {
+				err = softDeleteUser(sess, user.Id)
+			} else {
+				err = deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: user.Id})
+			}
+			if err != nil {
 				return err
 			}
 
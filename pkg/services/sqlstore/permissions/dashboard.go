@@ -25,18 +25,13 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 		okRoles = append(okRoles, models.ROLE_VIEWER)
 	}
 
-	falseStr := d.Dialect.BooleanStr(false)
-
 	sql := `(
 		dashboard.id IN (
 			SELECT distinct DashboardId from (
 				SELECT d.id AS DashboardId
 					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
-					LEFT JOIN dashboard_acl AS da ON
-						da.dashboard_id = d.id OR
-						da.dashboard_id = d.folder_id
-					LEFT JOIN team_member as ugm on ugm.team_id = da.team_id
+					LEFT JOIN dashboard_acl AS da ON ` + AclCondition(d.Dialect) + `
+					LEFT JOIN team_member as ugm on ugm.team_id = da.team_id AND NOT EXISTS (SELECT 1 FROM team WHERE team.id = ugm.team_id AND team.deleted IS NOT NULL)
 					WHERE
 						d.org_id = ? AND
 						da.permission >= ? AND
@@ -48,15 +43,7 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 				UNION
 				SELECT d.id AS DashboardId
 					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
-					LEFT JOIN dashboard_acl AS da ON
-						(
-							-- include default permissions -->
-							da.org_id = -1 AND (
-							  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-							  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-							)
-						)
+					LEFT JOIN dashboard_acl AS da ON ` + DefaultAclCondition(d.Dialect) + `
 					WHERE
 						d.org_id = ? AND
 						da.permission >= ? AND
@@ -75,3 +62,39 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 	params = append(params, okRoles...)
 	return sql, params
 }
+
+// AclCondition returns the join condition of the ACL items (da) of a dashboard
+// or folder (d): its own items and the items of the nearest folder above it
+// that has permissions set. Folders further up are overridden by that folder.
+func AclCondition(dialect migrator.Dialect) string {
+	trueStr := dialect.BooleanStr(true)
+
+	return `(
+		da.dashboard_id = d.id OR
+		da.dashboard_id IN (
+			SELECT anc.ancestor_id FROM dashboard_ancestor AS anc INNER JOIN dashboard AS f ON f.id = anc.ancestor_id
+			WHERE anc.dashboard_id = d.id AND f.has_acl = ` + trueStr + ` AND NOT EXISTS (
+				SELECT 1 FROM dashboard_ancestor AS closer INNER JOIN dashboard AS cf ON cf.id = closer.ancestor_id
+				WHERE closer.dashboard_id = d.id AND closer.depth < anc.depth AND cf.has_acl = ` + trueStr + `
+			)
+		)
+	)`
+}
+
+// DefaultAclCondition returns the join condition of the default ACL items (da)
+// of a dashboard or folder (d), which apply unless a folder above it has
+// permissions set, or, in the General folder, the dashboard itself.
+func DefaultAclCondition(dialect migrator.Dialect) string {
+	return `(
+		da.org_id = -1 AND (
+			(
+				EXISTS (SELECT 1 FROM dashboard_ancestor WHERE dashboard_id = d.id) AND
+				NOT EXISTS (
+					SELECT 1 FROM dashboard_ancestor AS anc INNER JOIN dashboard AS f ON f.id = anc.ancestor_id
+					WHERE anc.dashboard_id = d.id AND f.has_acl = ` + dialect.BooleanStr(true) + `
+				)
+			) OR
+			(NOT EXISTS (SELECT 1 FROM dashboard_ancestor WHERE dashboard_id = d.id) AND d.has_acl = ` + dialect.BooleanStr(false) + `)
+		)
+	)`
+}
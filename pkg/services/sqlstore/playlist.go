@@ -15,32 +15,37 @@ func init() {
 }
 
 func CreatePlaylist(cmd *models.CreatePlaylistCommand) error {
-	playlist := models.Playlist{
-		Name:     cmd.Name,
-		Interval: cmd.Interval,
-		OrgId:    cmd.OrgId,
-	}
+	return inTransaction(func(sess *DBSession) error {
+		playlist := models.Playlist{
+			Name:     cmd.Name,
+			Interval: cmd.Interval,
+			OrgId:    cmd.OrgId,
+		}
 
-	_, err := x.Insert(&playlist)
-	if err != nil {
-		return err
-	}
+		if _, err := sess.Insert(&playlist); err != nil {
+			return err
+		}
 
-	playlistItems := make([]models.PlaylistItem, 0)
-	for _, item := range cmd.Items {
-		playlistItems = append(playlistItems, models.PlaylistItem{
-			PlaylistId: playlist.Id,
-			Type:       item.Type,
-			Value:      item.Value,
-			Order:      item.Order,
-			Title:      item.Title,
-		})
-	}
+		if err := checkOrgQuota(sess, cmd.OrgId, "playlist"); err != nil {
+			return err
+		}
 
-	_, err = x.Insert(&playlistItems)
+		playlistItems := make([]models.PlaylistItem, 0)
+		for _, item := range cmd.Items {
+			playlistItems = append(playlistItems, models.PlaylistItem{
+				PlaylistId: playlist.Id,
+				Type:       item.Type,
+				Value:      item.Value,
+				Order:      item.Order,
+				Title:      item.Title,
+			})
+		}
 
-	cmd.Result = &playlist
-	return err
+		_, err := sess.Insert(&playlistItems)
+
+		cmd.Result = &playlist
+		return err
+	})
 }
 
 func UpdatePlaylist(cmd *models.UpdatePlaylistCommand) error {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -36,9 +37,8 @@ func GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
 	}
 
 	//get quota used.
-	rawSql := fmt.Sprintf("SELECT COUNT(*) as count from %s where org_id=?", dialect.Quote(query.Target))
 	resp := make([]*targetCount, 0)
-	if err := x.SQL(rawSql, query.OrgId).Find(&resp); err != nil {
+	if err := x.SQL(orgQuotaUsedSql(query.Target), query.OrgId).Find(&resp); err != nil {
 		return err
 	}
 
@@ -52,6 +52,67 @@ func GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
 	return nil
 }
 
+// orgQuotaUsedSql returns the query for the used amount of an org quota
+// target, which is the number of rows of the target table unless the target
+// needs to be counted differently.
+func orgQuotaUsedSql(target string) string {
+	switch target {
+	case "dashboard_version":
+		return "SELECT COUNT(*) as count from dashboard_version INNER JOIN dashboard ON dashboard.id = dashboard_version.dashboard_id where dashboard.org_id=?"
+	case "dashboard_bytes":
+		return fmt.Sprintf("SELECT COALESCE(SUM(%s), 0) as count from dashboard where org_id=?", byteLengthSql("data"))
+	default:
+		return fmt.Sprintf("SELECT COUNT(*) as count from %s where org_id=?", dialect.Quote(target))
+	}
+}
+
+// byteLengthSql returns the expression for the size in bytes of a text column.
+func byteLengthSql(column string) string {
+	switch dialect.DriverName() {
+	case migrator.POSTGRES:
+		return "OCTET_LENGTH(" + column + ")"
+	case migrator.SQLITE:
+		return "LENGTH(CAST(" + column + " AS BLOB))"
+	default:
+		return "LENGTH(" + column + ")"
+	}
+}
+
+// checkOrgQuota returns a QuotaReachedError if the org uses more of the target
+// than its quota allows. It's called after the rows have been written, so that
+// the transaction is rolled back when the quota is exceeded.
+func checkOrgQuota(sess *DBSession, orgID int64, target string) error {
+	if !setting.Quota.Enabled {
+		return nil
+	}
+
+	quota := models.Quota{
+		Target: target,
+		OrgId:  orgID,
+	}
+	has, err := sess.Where("user_id=0").Get(&quota)
+	if err != nil {
+		return err
+	} else if !has {
+		quota.Limit = setting.Quota.Org.ToMap()[target]
+	}
+
+	if quota.Limit < 0 {
+		return nil
+	}
+
+	resp := make([]*targetCount, 0)
+	if err := sess.SQL(orgQuotaUsedSql(target), orgID).Find(&resp); err != nil {
+		return err
+	}
+
+	if resp[0].Count > quota.Limit {
+		return models.QuotaReachedError{Target: target}
+	}
+
+	return nil
+}
+
 func GetOrgQuotas(query *models.GetOrgQuotasQuery) error {
 	quotas := make([]*models.Quota, 0)
 	sess := x.Table("quota")
@@ -79,9 +140,8 @@ func GetOrgQuotas(query *models.GetOrgQuotasQuery) error {
 	result := make([]*models.OrgQuotaDTO, len(quotas))
 	for i, q := range quotas {
 		//get quota used.
-		rawSql := fmt.Sprintf("SELECT COUNT(*) as count from %s where org_id=?", dialect.Quote(q.Target))
 		resp := make([]*targetCount, 0)
-		if err := x.SQL(rawSql, q.OrgId).Find(&resp); err != nil {
+		if err := x.SQL(orgQuotaUsedSql(q.Target), q.OrgId).Find(&resp); err != nil {
 			return err
 		}
 		result[i] = &models.OrgQuotaDTO{
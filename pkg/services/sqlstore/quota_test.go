@@ -1,9 +1,11 @@
 package sqlstore
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
@@ -15,13 +17,22 @@ func TestQuotaCommandsAndQueries(t *testing.T) {
 		userId := int64(1)
 		orgId := int64(0)
 
+		origQuota := setting.Quota
+		Reset(func() { setting.Quota = origQuota })
+
 		setting.Quota = setting.QuotaSettings{
 			Enabled: true,
 			Org: &setting.OrgQuota{
-				User:       5,
-				Dashboard:  5,
-				DataSource: 5,
-				ApiKey:     5,
+				User:             5,
+				Dashboard:        5,
+				DataSource:       5,
+				ApiKey:           5,
+				Alert:            5,
+				Annotation:       5,
+				DashboardVersion: 5,
+				Snapshot:         5,
+				Playlist:         5,
+				DashboardBytes:   5,
 			},
 			User: &setting.UserQuota{
 				Org: 5,
@@ -90,7 +101,7 @@ func TestQuotaCommandsAndQueries(t *testing.T) {
 				err = GetOrgQuotas(&query)
 
 				So(err, ShouldBeNil)
-				So(len(query.Result), ShouldEqual, 4)
+				So(len(query.Result), ShouldEqual, 10)
 				for _, res := range query.Result {
 					limit := 5 //default quota limit
 					used := 0
@@ -231,5 +242,70 @@ func TestQuotaCommandsAndQueries(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(query.Result.Limit, ShouldEqual, 10)
 		})
+
+		Convey("Should reject playlists over the org quota", func() {
+			for i := 0; i < 5; i++ {
+				err := CreatePlaylist(&models.CreatePlaylistCommand{OrgId: orgId, Name: "playlist", Interval: "5m"})
+				So(err, ShouldBeNil)
+			}
+
+			err := CreatePlaylist(&models.CreatePlaylistCommand{OrgId: orgId, Name: "playlist", Interval: "5m"})
+			So(err, ShouldResemble, models.QuotaReachedError{Target: "playlist"})
+
+			query := models.GetOrgQuotaByTargetQuery{OrgId: orgId, Target: "playlist", Default: 5}
+			err = GetOrgQuotaByTarget(&query)
+			So(err, ShouldBeNil)
+			So(query.Result.Used, ShouldEqual, 5)
+		})
+
+		Convey("Should reject dashboards over the org version quota", func() {
+			err := UpdateOrgQuota(&models.UpdateOrgQuotaCmd{OrgId: orgId, Target: "dashboard_bytes", Limit: -1})
+			So(err, ShouldBeNil)
+
+			cmd := models.SaveDashboardCommand{
+				OrgId:     orgId,
+				Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "versions"}),
+			}
+			So(SaveDashboard(&cmd), ShouldBeNil)
+			cmd.Result.Data.Set("id", cmd.Result.Id)
+
+			for i := 0; i < 4; i++ {
+				cmd.Dashboard = cmd.Result.Data
+				So(SaveDashboard(&cmd), ShouldBeNil)
+			}
+
+			cmd.Dashboard = cmd.Result.Data
+			err = SaveDashboard(&cmd)
+			So(err, ShouldResemble, models.QuotaReachedError{Target: "dashboard_version"})
+
+			query := models.GetOrgQuotaByTargetQuery{OrgId: orgId, Target: "dashboard_version", Default: 5}
+			err = GetOrgQuotaByTarget(&query)
+			So(err, ShouldBeNil)
+			So(query.Result.Used, ShouldEqual, 5)
+		})
+
+		Convey("Should count dashboard bytes and reject dashboards that grow over the quota", func() {
+			err := UpdateOrgQuota(&models.UpdateOrgQuotaCmd{OrgId: orgId, Target: "dashboard_bytes", Limit: 100})
+			So(err, ShouldBeNil)
+
+			cmd := models.SaveDashboardCommand{
+				OrgId:     orgId,
+				Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "bytes"}),
+			}
+			So(SaveDashboard(&cmd), ShouldBeNil)
+
+			query := models.GetOrgQuotaByTargetQuery{OrgId: orgId, Target: "dashboard_bytes", Default: 5}
+			err = GetOrgQuotaByTarget(&query)
+			So(err, ShouldBeNil)
+			data, err := cmd.Result.Data.MarshalJSON()
+			So(err, ShouldBeNil)
+			So(query.Result.Used, ShouldEqual, len(data))
+
+			cmd.Result.Data.Set("id", cmd.Result.Id)
+			cmd.Dashboard = cmd.Result.Data
+			cmd.Dashboard.Set("description", strings.Repeat("a", 100))
+			err = SaveDashboard(&cmd)
+			So(err, ShouldResemble, models.QuotaReachedError{Target: "dashboard_bytes"})
+		})
 	})
 }
@@ -0,0 +1,218 @@
+package sqlstore
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", AddScimToken)
+	bus.AddHandler("sql", DeleteScimToken)
+	bus.AddHandler("sql", GetScimTokens)
+	bus.AddHandler("sql", GetScimTokenByName)
+	bus.AddHandler("sql", SetScimExternalId)
+	bus.AddHandler("sql", GetScimUsers)
+	bus.AddHandler("sql", GetScimGroups)
+}
+
+func AddScimToken(cmd *models.AddScimTokenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		existing := models.ScimToken{OrgId: cmd.OrgId, Name: cmd.Name}
+		exists, err := sess.Get(&existing)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return models.ErrDuplicateScimToken
+		}
+
+		token := models.ScimToken{
+			OrgId:   cmd.OrgId,
+			Name:    cmd.Name,
+			Key:     cmd.Key,
+			Created: time.Now(),
+		}
+
+		if _, err := sess.Insert(&token); err != nil {
+			return err
+		}
+
+		cmd.Result = &token
+		return nil
+	})
+}
+
+func DeleteScimToken(cmd *models.DeleteScimTokenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("DELETE FROM scim_token WHERE org_id = ? AND id = ?", cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return models.ErrScimTokenNotFound
+		}
+
+		return nil
+	})
+}
+
+func GetScimTokens(query *models.GetScimTokensQuery) error {
+	query.Result = make([]*models.ScimToken, 0)
+	return x.Where("org_id = ?", query.OrgId).Asc("name").Find(&query.Result)
+}
+
+func GetScimTokenByName(query *models.GetScimTokenByNameQuery) error {
+	var token models.ScimToken
+	exists, err := x.Where("org_id = ? AND name = ? AND "+notInDeletedOrg("scim_token.org_id"), query.OrgId, query.Name).Get(&token)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrScimTokenNotFound
+	}
+
+	query.Result = &token
+	return nil
+}
+
+func SetScimExternalId(cmd *models.SetScimExternalIdCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		var existing models.ScimExternalId
+		exists, err := sess.Where("org_id = ? AND resource_type = ? AND resource_id = ?", cmd.OrgId, cmd.ResourceType, cmd.ResourceId).Get(&existing)
+		if err != nil {
+			return err
+		}
+
+		if cmd.ExternalId == "" {
+			if exists {
+				_, err = sess.ID(existing.Id).Delete(&models.ScimExternalId{})
+			}
+			return err
+		}
+
+		if exists {
+			if existing.ExternalId == cmd.ExternalId {
+				return nil
+			}
+
+			existing.ExternalId = cmd.ExternalId
+			existing.Updated = time.Now()
+			_, err = sess.ID(existing.Id).Cols("external_id", "updated").Update(&existing)
+			return err
+		}
+
+		_, err = sess.Insert(&models.ScimExternalId{
+			OrgId:        cmd.OrgId,
+			ResourceType: cmd.ResourceType,
+			ResourceId:   cmd.ResourceId,
+			ExternalId:   cmd.ExternalId,
+			Created:      time.Now(),
+			Updated:      time.Now(),
+		})
+		return err
+	})
+}
+
+func GetScimUsers(query *models.GetScimUsersQuery) error {
+	var sql bytes.Buffer
+	var where bytes.Buffer
+	params := []interface{}{models.ScimResourceUser, query.OrgId}
+
+	sql.WriteString(`SELECT
+		u.id,
+		u.login,
+		u.email,
+		u.name,
+		u.is_admin,
+		u.is_disabled,
+		org_user.role,
+		scim_external_id.external_id,
+		u.created,
+		u.updated`)
+
+	where.WriteString(` FROM org_user
+		INNER JOIN ` + dialect.Quote("user") + ` AS u ON u.id = org_user.user_id
+		LEFT JOIN scim_external_id ON scim_external_id.org_id = org_user.org_id AND scim_external_id.resource_type = ? AND scim_external_id.resource_id = u.id
+		WHERE org_user.org_id = ? AND u.deleted IS NULL`)
+
+	if query.UserId > 0 {
+		where.WriteString(` AND u.id = ?`)
+		params = append(params, query.UserId)
+	}
+
+	if len(query.UserIds) > 0 {
+		where.WriteString(` AND u.id IN (?` + strings.Repeat(",?", len(query.UserIds)-1) + `)`)
+		for _, id := range query.UserIds {
+			params = append(params, id)
+		}
+	}
+
+	if query.Login != "" {
+		where.WriteString(` AND LOWER(u.login) = ?`)
+		params = append(params, strings.ToLower(query.Login))
+	}
+
+	if query.ExternalId != "" {
+		where.WriteString(` AND scim_external_id.external_id = ?`)
+		params = append(params, query.ExternalId)
+	}
+
+	sql.Write(where.Bytes())
+	sql.WriteString(` ORDER BY u.id`)
+
+	if query.Limit > 0 {
+		sql.WriteString(dialect.LimitOffset(int64(query.Limit), int64(query.Offset)))
+	}
+
+	query.Result = make([]*models.ScimUser, 0)
+	if err := x.SQL(sql.String(), params...).Find(&query.Result); err != nil {
+		return err
+	}
+
+	if query.Limit <= 0 {
+		query.TotalCount = int64(len(query.Result))
+		return nil
+	}
+
+	count := struct {
+		Count int64
+	}{}
+	if _, err := x.SQL("SELECT COUNT(*) AS count"+where.String(), params...).Get(&count); err != nil {
+		return err
+	}
+	query.TotalCount = count.Count
+
+	return nil
+}
+
+func GetScimGroups(query *models.GetScimGroupsQuery) error {
+	var sql bytes.Buffer
+	params := []interface{}{models.ScimResourceGroup, query.OrgId}
+
+	sql.WriteString(`SELECT
+		team.id,
+		team.name,
+		scim_external_id.external_id,
+		team.created,
+		team.updated
+		FROM team
+		LEFT JOIN scim_external_id ON scim_external_id.org_id = team.org_id AND scim_external_id.resource_type = ? AND scim_external_id.resource_id = team.id
+		WHERE team.org_id = ? AND team.deleted IS NULL`)
+
+	if query.TeamId > 0 {
+		sql.WriteString(` AND team.id = ?`)
+		params = append(params, query.TeamId)
+	}
+
+	sql.WriteString(` ORDER BY team.id`)
+
+	query.Result = make([]*models.ScimGroup, 0)
+	return x.SQL(sql.String(), params...).Find(&query.Result)
+}
@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestScimTokens(t *testing.T) {
+	InitTestDB(t)
+
+	t.Run("Should add, get and delete tokens", func(t *testing.T) {
+		cmd := &models.AddScimTokenCommand{OrgId: 1, Name: "idp", Key: "hashed"}
+		require.NoError(t, AddScimToken(cmd))
+
+		err := AddScimToken(&models.AddScimTokenCommand{OrgId: 1, Name: "idp", Key: "other"})
+		require.Equal(t, models.ErrDuplicateScimToken, err)
+
+		query := &models.GetScimTokenByNameQuery{OrgId: 1, Name: "idp"}
+		require.NoError(t, GetScimTokenByName(query))
+		require.Equal(t, "hashed", query.Result.Key)
+
+		err = GetScimTokenByName(&models.GetScimTokenByNameQuery{OrgId: 2, Name: "idp"})
+		require.Equal(t, models.ErrScimTokenNotFound, err)
+
+		listQuery := &models.GetScimTokensQuery{OrgId: 1}
+		require.NoError(t, GetScimTokens(listQuery))
+		require.Len(t, listQuery.Result, 1)
+
+		err = DeleteScimToken(&models.DeleteScimTokenCommand{OrgId: 2, Id: cmd.Result.Id})
+		require.Equal(t, models.ErrScimTokenNotFound, err)
+		require.NoError(t, DeleteScimToken(&models.DeleteScimTokenCommand{OrgId: 1, Id: cmd.Result.Id}))
+	})
+}
+
+func TestScimExternalIds(t *testing.T) {
+	InitTestDB(t)
+
+	userCmd := &models.CreateUserCommand{Login: "bjensen", Email: "bjensen@example.com"}
+	require.NoError(t, CreateUser(context.Background(), userCmd))
+	userID := userCmd.Result.Id
+
+	teamCmd := &models.CreateTeamCommand{OrgId: userCmd.Result.OrgId, Name: "team"}
+	require.NoError(t, CreateTeam(teamCmd))
+	orgID := teamCmd.OrgId
+
+	getUser := func() *models.ScimUser {
+		query := &models.GetScimUsersQuery{OrgId: orgID, UserId: userID}
+		require.NoError(t, GetScimUsers(query))
+		if len(query.Result) == 0 {
+			return nil
+		}
+		return query.Result[0]
+	}
+
+	t.Run("Should set and remove external ids", func(t *testing.T) {
+		for _, externalID := range []string{"first", "second"} {
+			err := SetScimExternalId(&models.SetScimExternalIdCommand{
+				OrgId: orgID, ResourceType: models.ScimResourceUser, ResourceId: userID, ExternalId: externalID,
+			})
+			require.NoError(t, err)
+			require.Equal(t, externalID, getUser().ExternalId)
+		}
+
+		err := SetScimExternalId(&models.SetScimExternalIdCommand{
+			OrgId: orgID, ResourceType: models.ScimResourceUser, ResourceId: userID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "", getUser().ExternalId)
+	})
+
+	t.Run("Should list teams with external ids", func(t *testing.T) {
+		err := SetScimExternalId(&models.SetScimExternalIdCommand{
+			OrgId: orgID, ResourceType: models.ScimResourceGroup, ResourceId: teamCmd.Result.Id, ExternalId: "ext-team",
+		})
+		require.NoError(t, err)
+
+		query := &models.GetScimGroupsQuery{OrgId: orgID}
+		require.NoError(t, GetScimGroups(query))
+		require.Len(t, query.Result, 1)
+		require.Equal(t, "ext-team", query.Result[0].ExternalId)
+	})
+
+	t.Run("Should remove external ids of deleted teams and users", func(t *testing.T) {
+		err := SetScimExternalId(&models.SetScimExternalIdCommand{
+			OrgId: orgID, ResourceType: models.ScimResourceUser, ResourceId: userID, ExternalId: "ext-user",
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, DeleteTeam(&models.DeleteTeamCommand{OrgId: orgID, Id: teamCmd.Result.Id}))
+		require.NoError(t, DeleteUser(&models.DeleteUserCommand{UserId: userID}))
+
+		count, err := x.Count(&models.ScimExternalId{})
+		require.NoError(t, err)
+		require.Equal(t, int64(0), count)
+	})
+}
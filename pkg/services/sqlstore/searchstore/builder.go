@@ -29,6 +29,7 @@ func (b *Builder) ToSql(limit, page int64) (string, []interface{}) {
 
 	b.sql.WriteString("( ")
 	orderQuery := b.applyFilters()
+	b.sql.WriteString(orderQuery.inner)
 
 	b.sql.WriteString(b.Dialect.LimitOffset(limit, (page-1)*limit) + `) AS ids
 		INNER JOIN dashboard ON ids.id = dashboard.id`)
@@ -38,7 +39,19 @@ func (b *Builder) ToSql(limit, page int64) (string, []interface{}) {
 		`LEFT OUTER JOIN dashboard AS folder ON folder.id = dashboard.folder_id
 		LEFT OUTER JOIN dashboard_tag ON dashboard.id = dashboard_tag.dashboard_id`)
 	b.sql.WriteString("\n")
-	b.sql.WriteString(orderQuery)
+	b.sql.WriteString(orderQuery.outer)
+
+	return b.sql.String(), b.params
+}
+
+// ToIdSql builds a SQL query returning the ids of all dashboards matching
+// the filters, without ordering or pagination, together with the SQL
+// parameters. It's used to aggregate over the search result.
+func (b *Builder) ToIdSql() (string, []interface{}) {
+	b.params = make([]interface{}, 0)
+	b.sql = bytes.Buffer{}
+
+	b.applyFilters()
 
 	return b.sql.String(), b.params
 }
@@ -59,7 +72,14 @@ func (b *Builder) buildSelect() {
 		FROM `)
 }
 
-func (b *Builder) applyFilters() (ordering string) {
+// ordering is the ORDER BY clause of the filtered ids query and the joins
+// and ORDER BY clause of the outer query.
+type ordering struct {
+	inner string
+	outer string
+}
+
+func (b *Builder) applyFilters() ordering {
 	joins := []string{}
 	orderJoins := []string{}
 
@@ -118,9 +138,9 @@ func (b *Builder) applyFilters() (ordering string) {
 	}
 
 	orderBy := fmt.Sprintf(" ORDER BY %s", strings.Join(orders, ", "))
-	b.sql.WriteString(orderBy)
 
-	order := strings.Join(orderJoins, "")
-	order += orderBy
-	return order
+	return ordering{
+		inner: orderBy,
+		outer: strings.Join(orderJoins, "") + orderBy,
+	}
 }
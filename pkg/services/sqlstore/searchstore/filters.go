@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
@@ -26,6 +27,15 @@ type FilterOrderBy interface {
 	OrderBy() string
 }
 
+// FilterSortColumn is an ordering by a single column that supports
+// paging through the search result with a CursorFilter. SortColumn
+// returns the column of the dashboard table with the given alias and
+// whether the ordering is descending.
+type FilterSortColumn interface {
+	FilterOrderBy
+	SortColumn(alias string) (string, bool)
+}
+
 // FilterLeftJoin adds the returned string as a "LEFT OUTER JOIN" to
 // allow for fetching extra columns from a table outside of the
 // dashboard column.
@@ -118,6 +128,65 @@ func (f TagsFilter) Where() (string, []interface{}) {
 	return `dashboard_tag.term IN (?` + strings.Repeat(",?", len(f.Tags)-1) + `)`, params
 }
 
+// FilterContent limits the indexed dashboard content matched by a content
+// search. The condition is on the dashboard_content table.
+type FilterContent interface {
+	ContentWhere() (string, []interface{})
+}
+
+// ContentFilter limits the result to dashboards with indexed content that
+// has a term starting with each of the terms, optionally of one of the kinds.
+type ContentFilter struct {
+	Terms []string
+	Kinds []string
+}
+
+func (f ContentFilter) Where() (string, []interface{}) {
+	return contentWhere(f)
+}
+
+func (f ContentFilter) ContentWhere() (string, []interface{}) {
+	wheres := []string{}
+	params := []interface{}{}
+
+	if len(f.Kinds) > 0 {
+		wheres = append(wheres, `dashboard_content.kind IN (?`+strings.Repeat(",?", len(f.Kinds)-1)+`)`)
+		for _, kind := range f.Kinds {
+			params = append(params, kind)
+		}
+	}
+
+	for _, term := range f.Terms {
+		wheres = append(wheres, `dashboard_content.id IN (SELECT content_id FROM dashboard_content_term WHERE term LIKE ?)`)
+		params = append(params, term+"%")
+	}
+
+	return strings.Join(wheres, " AND "), params
+}
+
+// DataSourceFilter limits the result to dashboards that use the data source.
+type DataSourceFilter struct {
+	Name string
+}
+
+func (f DataSourceFilter) Where() (string, []interface{}) {
+	return contentWhere(f)
+}
+
+func (f DataSourceFilter) ContentWhere() (string, []interface{}) {
+	return `dashboard_content.kind = ? AND dashboard_content.id IN (SELECT content_id FROM dashboard_content_term WHERE term = ?)`,
+		[]interface{}{models.DashboardContentDataSource, strings.ToLower(f.Name)}
+}
+
+func contentWhere(f FilterContent) (string, []interface{}) {
+	sql, params := f.ContentWhere()
+	if sql == "" {
+		return "", nil
+	}
+
+	return "dashboard.id IN (SELECT dashboard_content.dashboard_id FROM dashboard_content WHERE " + sql + ")", params
+}
+
 type TitleSorter struct {
 	Descending bool
 }
@@ -130,6 +199,75 @@ func (s TitleSorter) OrderBy() string {
 	return "dashboard.title ASC"
 }
 
+func (s TitleSorter) SortColumn(alias string) (string, bool) {
+	return alias + ".title", s.Descending
+}
+
+type UpdatedSorter struct {
+	Descending bool
+}
+
+func (s UpdatedSorter) OrderBy() string {
+	return sortColumnOrderBy(s)
+}
+
+func (s UpdatedSorter) SortColumn(alias string) (string, bool) {
+	return alias + ".updated", s.Descending
+}
+
+// ViewsSorter orders by the number of times the dashboards were viewed.
+type ViewsSorter struct {
+	Descending bool
+}
+
+func (s ViewsSorter) OrderBy() string {
+	return sortColumnOrderBy(s)
+}
+
+func (s ViewsSorter) SortColumn(alias string) (string, bool) {
+	return "COALESCE((SELECT views FROM dashboard_view_count WHERE dashboard_view_count.dashboard_id = " + alias + ".id), 0)", s.Descending
+}
+
+func sortColumnOrderBy(s FilterSortColumn) string {
+	column, descending := s.SortColumn("dashboard")
+	if descending {
+		return column + " DESC"
+	}
+
+	return column + " ASC"
+}
+
+// CursorFilter pages through a result ordered by Sorter, ordering ties by
+// dashboard id. If AfterId is set, only the dashboards after the dashboard
+// with that id are returned. Sorter must be one of the filters of the
+// query and CursorFilter must follow it.
+type CursorFilter struct {
+	Sorter  FilterSortColumn
+	AfterId int64
+}
+
+func (f CursorFilter) Where() (string, []interface{}) {
+	if f.AfterId == 0 {
+		return "", nil
+	}
+
+	column, descending := f.Sorter.SortColumn("dashboard")
+	cursorColumn, _ := f.Sorter.SortColumn("cursor_dashboard")
+	cursorValue := "(SELECT " + cursorColumn + " FROM dashboard AS cursor_dashboard WHERE cursor_dashboard.id = ?)"
+
+	operator := ">"
+	if descending {
+		operator = "<"
+	}
+
+	sql := fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND dashboard.id > ?))", column, operator, cursorValue)
+	return sql, []interface{}{f.AfterId, f.AfterId, f.AfterId}
+}
+
+func (f CursorFilter) OrderBy() string {
+	return "dashboard.id ASC"
+}
+
 func sqlIDin(column string, ids []int64) (string, []interface{}) {
 	length := len(ids)
 	if length < 1 {
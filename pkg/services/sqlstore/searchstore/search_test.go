@@ -114,6 +114,45 @@ func TestBuilder_Pagination(t *testing.T) {
 	assert.Equal(t, "P", resPg2[0].Title, "page 2 should start with the 16th dashboard")
 }
 
+func TestBuilder_CursorPagination(t *testing.T) {
+	db := setupTestEnvironment(t)
+	err := createDashboards(0, 25, 1)
+	require.NoError(t, err)
+
+	sorter := searchstore.TitleSorter{Descending: true}
+	titles := []string{}
+	var afterID int64
+	for {
+		builder := &searchstore.Builder{
+			Filters: []interface{}{
+				searchstore.OrgFilter{OrgId: 1},
+				sorter,
+				searchstore.CursorFilter{Sorter: sorter, AfterId: afterID},
+			},
+			Dialect: dialect,
+		}
+
+		res := []sqlstore.DashboardSearchProjection{}
+		err = db.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			sql, params := builder.ToSql(10, 1)
+			return sess.SQL(sql, params...).Find(&res)
+		})
+		require.NoError(t, err)
+
+		if len(res) == 0 {
+			break
+		}
+		for _, r := range res {
+			titles = append(titles, r.Title)
+		}
+		afterID = res[len(res)-1].Id
+	}
+
+	require.Len(t, titles, 25)
+	assert.Equal(t, "Y", titles[0], "first page should start with the last dashboard")
+	assert.Equal(t, "A", titles[24], "last page should end with the first dashboard")
+}
+
 func TestBuilder_Permissions(t *testing.T) {
 	user := &models.SignedInUser{
 		UserId:  1,
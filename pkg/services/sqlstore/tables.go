@@ -7,3 +7,11 @@ type DashboardTag struct {
 	DashboardId int64
 	Term        string
 }
+
+type DashboardContentTerm struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	ContentId   int64
+	Term        string
+}
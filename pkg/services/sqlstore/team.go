@@ -100,27 +100,39 @@ func UpdateTeam(cmd *models.UpdateTeamCommand) error {
 	})
 }
 
-// DeleteTeam will delete a team, its member and any permissions connected to the team
+// DeleteTeam will delete a team, its member and any permissions connected to the team,
+// or mark it as deleted if deleted teams are kept for a retention period
 func DeleteTeam(cmd *models.DeleteTeamCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		if softDeleteEnabled() {
+			return softDeleteTeam(sess, cmd.OrgId, cmd.Id)
+		}
+
 		if _, err := teamExists(cmd.OrgId, cmd.Id, sess); err != nil {
 			return err
 		}
 
-		deletes := []string{
-			"DELETE FROM team_member WHERE org_id=? and team_id = ?",
-			"DELETE FROM team WHERE org_id=? and id = ?",
-			"DELETE FROM dashboard_acl WHERE org_id=? and team_id = ?",
-		}
+		return deleteTeamInTransaction(sess, cmd.OrgId, cmd.Id)
+	})
+}
 
-		for _, sql := range deletes {
-			_, err := sess.Exec(sql, cmd.OrgId, cmd.Id)
-			if err != nil {
-				return err
-			}
+func deleteTeamInTransaction(sess *DBSession, orgID int64, teamID int64) error {
+	deletes := []string{
+		"DELETE FROM team_member WHERE org_id=? and team_id = ?",
+		"DELETE FROM team WHERE org_id=? and id = ?",
+		"DELETE FROM dashboard_acl WHERE org_id=? and team_id = ?",
+		"DELETE FROM team_role WHERE org_id=? and team_id = ?",
+		"DELETE FROM data_source_permission WHERE org_id=? and team_id = ?",
+		"DELETE FROM scim_external_id WHERE org_id=? and resource_type = 'Group' and resource_id = ?",
+	}
+
+	for _, sql := range deletes {
+		_, err := sess.Exec(sql, orgID, teamID)
+		if err != nil {
+			return err
 		}
-		return nil
-	})
+	}
+	return nil
 }
 
 func teamExists(orgId int64, teamId int64, sess *DBSession) (bool, error) {
@@ -163,7 +175,7 @@ func SearchTeams(query *models.SearchTeamsQuery) error {
 	} else {
 		sql.WriteString(getTeamSelectSqlBase())
 	}
-	sql.WriteString(` WHERE team.org_id = ?`)
+	sql.WriteString(` WHERE team.org_id = ? AND team.deleted IS NULL`)
 
 	params = append(params, query.OrgId)
 
@@ -190,6 +202,7 @@ func SearchTeams(query *models.SearchTeamsQuery) error {
 
 	team := models.Team{}
 	countSess := x.Table("team")
+	countSess.Where("deleted IS NULL")
 	if query.Query != "" {
 		countSess.Where(`name `+dialect.LikeStr()+` ?`, queryWithWildcards)
 	}
@@ -208,7 +221,7 @@ func GetTeamById(query *models.GetTeamByIdQuery) error {
 	var sql bytes.Buffer
 
 	sql.WriteString(getTeamSelectSqlBase())
-	sql.WriteString(` WHERE team.org_id = ? and team.id = ?`)
+	sql.WriteString(` WHERE team.org_id = ? and team.id = ? and team.deleted IS NULL`)
 
 	var team models.TeamDTO
 	exists, err := x.SQL(sql.String(), query.OrgId, query.Id).Get(&team)
@@ -233,7 +246,7 @@ func GetTeamsByUser(query *models.GetTeamsByUserQuery) error {
 
 	sql.WriteString(getTeamSelectSqlBase())
 	sql.WriteString(` INNER JOIN team_member on team.id = team_member.team_id`)
-	sql.WriteString(` WHERE team.org_id = ? and team_member.user_id = ?`)
+	sql.WriteString(` WHERE team.org_id = ? and team_member.user_id = ? and team.deleted IS NULL`)
 
 	err := x.SQL(sql.String(), query.OrgId, query.UserId).Find(&query.Result)
 	return err
@@ -373,6 +386,7 @@ func GetTeamMembers(query *models.GetTeamMembersQuery) error {
 	authJoinCondition = "user_auth.id=" + authJoinCondition + dialect.Limit(1) + ")"
 	sess.Join("LEFT", "user_auth", authJoinCondition)
 
+	sess.Where(x.Dialect().Quote("user") + ".deleted IS NULL")
 	if query.OrgId != 0 {
 		sess.Where("team_member.org_id=?", query.OrgId)
 	}
@@ -404,7 +418,7 @@ func GetTeamMembers(query *models.GetTeamMembersQuery) error {
 
 func IsAdminOfTeams(query *models.IsAdminOfTeamsQuery) error {
 	builder := &SqlBuilder{}
-	builder.Write("SELECT COUNT(team.id) AS count FROM team INNER JOIN team_member ON team_member.team_id = team.id WHERE team.org_id = ? AND team_member.user_id = ? AND team_member.permission = ?", query.SignedInUser.OrgId, query.SignedInUser.UserId, models.PERMISSION_ADMIN)
+	builder.Write("SELECT COUNT(team.id) AS count FROM team INNER JOIN team_member ON team_member.team_id = team.id WHERE team.org_id = ? AND team_member.user_id = ? AND team_member.permission = ? AND team.deleted IS NULL", query.SignedInUser.OrgId, query.SignedInUser.UserId, models.PERMISSION_ADMIN)
 
 	type teamCount struct {
 		Count int64
@@ -244,8 +244,11 @@ func ChangeUserPassword(cmd *models.ChangeUserPasswordCommand) error {
 			Updated:  time.Now(),
 		}
 
-		_, err := sess.ID(cmd.UserId).Update(&user)
-		return err
+		if _, err := sess.ID(cmd.UserId).Update(&user); err != nil {
+			return err
+		}
+
+		return addUserPasswordHistory(sess, cmd.UserId, cmd.NewPassword)
 	})
 }
 
@@ -343,7 +346,7 @@ func GetUserOrgList(query *models.GetUserOrgListQuery) error {
 	query.Result = make([]*models.UserOrgDTO, 0)
 	sess := x.Table("org_user")
 	sess.Join("INNER", "org", "org_user.org_id=org.id")
-	sess.Where("org_user.user_id=?", query.UserId)
+	sess.Where("org_user.user_id=? AND org.deleted IS NULL", query.UserId)
 	sess.Cols("org.name", "org_user.role", "org_user.org_id")
 	sess.OrderBy("org.name")
 	err := sess.Find(&query.Result)
@@ -389,9 +392,10 @@ func GetSignedInUser(query *models.GetSignedInUserQuery) error {
 		(SELECT COUNT(*) FROM org_user where org_user.user_id = u.id) as org_count,
 		org.name         as org_name,
 		org_user.role    as org_role,
-		org.id           as org_id
+		org.id           as org_id,
+		org.session_idle_timeout as org_session_idle_timeout
 		FROM ` + dialect.Quote("user") + ` as u
-		LEFT OUTER JOIN org_user on org_user.org_id = ` + orgId + ` and org_user.user_id = u.id
+		LEFT OUTER JOIN org_user on org_user.org_id = ` + orgId + ` and org_user.user_id = u.id and ` + notInDeletedOrg("org_user.org_id") + `
 		LEFT OUTER JOIN org on org.id = org_user.org_id `
 
 	sess := x.Table("user")
@@ -439,7 +443,7 @@ func SearchUsers(query *models.SearchUsersQuery) error {
 
 	queryWithWildcards := "%" + query.Query + "%"
 
-	whereConditions := make([]string, 0)
+	whereConditions := []string{"u.deleted IS NULL"}
 	whereParams := make([]interface{}, 0)
 	sess := x.Table("user").Alias("u")
 
@@ -510,7 +514,8 @@ func DisableUser(cmd *models.DisableUserCommand) error {
 	user := models.User{}
 	sess := x.Table("user")
 
-	if has, err := sess.ID(cmd.UserId).Get(&user); err != nil {
+	// deleted users stay disabled until they're restored
+	if has, err := sess.ID(cmd.UserId).Where("deleted IS NULL").Get(&user); err != nil {
 		return err
 	} else if !has {
 		return models.ErrUserNotFound
@@ -548,8 +553,19 @@ func BatchDisableUsers(cmd *models.BatchDisableUsersCommand) error {
 	})
 }
 
+// DeleteUser deletes the user, or disables it and marks it as deleted if
+// deleted users are kept for a retention period.
 func DeleteUser(cmd *models.DeleteUserCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		if cmd.ReassignToUserId > 0 {
+			if err := reassignUserContent(sess, cmd.UserId, cmd.ReassignToUserId); err != nil {
+				return err
+			}
+		}
+
+		if softDeleteEnabled() {
+			return softDeleteUser(sess, cmd.UserId)
+		}
 		return deleteUserInTransaction(sess, cmd)
 	})
 }
@@ -574,6 +590,11 @@ func deleteUserInTransaction(sess *DBSession, cmd *models.DeleteUserCommand) err
 		"DELETE FROM team_member WHERE user_id = ?",
 		"DELETE FROM user_auth WHERE user_id = ?",
 		"DELETE FROM user_auth_token WHERE user_id = ?",
+		"DELETE FROM user_mfa WHERE user_id = ?",
+		"DELETE FROM user_password_history WHERE user_id = ?",
+		"DELETE FROM scim_external_id WHERE resource_type = 'User' AND resource_id = ?",
+		"DELETE FROM user_role WHERE user_id = ?",
+		"DELETE FROM data_source_permission WHERE user_id = ?",
 		"DELETE FROM quota WHERE user_id = ?",
 	}
 
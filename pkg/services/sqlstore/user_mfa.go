@@ -0,0 +1,121 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", GetUserMfa)
+	bus.AddHandler("sql", SaveUserMfa)
+	bus.AddHandler("sql", UseUserMfaTotpStep)
+	bus.AddHandler("sql", UseUserMfaRecoveryCode)
+	bus.AddHandler("sql", DeleteUserMfa)
+}
+
+func GetUserMfa(query *models.GetUserMfaQuery) error {
+	userMfa := &models.UserMfa{}
+	has, err := x.Where("user_id = ?", query.UserId).Get(userMfa)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return models.ErrUserMfaNotFound
+	}
+
+	secret, err := decodeAndDecrypt(userMfa.Secret)
+	if err != nil {
+		return err
+	}
+	userMfa.Secret = secret
+
+	query.Result = userMfa
+	return nil
+}
+
+func SaveUserMfa(cmd *models.SaveUserMfaCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		secret, err := encryptAndEncode(cmd.Secret)
+		if err != nil {
+			return err
+		}
+
+		existing := &models.UserMfa{}
+		has, err := sess.Where("user_id = ?", cmd.UserId).Get(existing)
+		if err != nil {
+			return err
+		}
+
+		userMfa := &models.UserMfa{
+			UserId:        cmd.UserId,
+			Secret:        secret,
+			RecoveryCodes: cmd.RecoveryCodes,
+			IsEnabled:     cmd.IsEnabled,
+			Updated:       time.Now(),
+		}
+
+		if !has {
+			userMfa.Created = userMfa.Updated
+			_, err = sess.Insert(userMfa)
+			return err
+		}
+
+		_, err = sess.ID(existing.Id).Cols("secret", "recovery_codes", "is_enabled", "updated").Update(userMfa)
+		return err
+	})
+}
+
+func UseUserMfaTotpStep(cmd *models.UseUserMfaTotpStepCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("UPDATE user_mfa SET last_totp_step = ?, updated = ? WHERE user_id = ? AND last_totp_step < ?",
+			cmd.Step, time.Now(), cmd.UserId, cmd.Step)
+		if err != nil {
+			return err
+		}
+
+		return requireUpdatedUserMfa(res.RowsAffected())
+	})
+}
+
+func UseUserMfaRecoveryCode(cmd *models.UseUserMfaRecoveryCodeCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		// the recovery codes are stored as JSON, so the update only matches
+		// if nobody else has used a code since they were read
+		previous, err := json.Marshal(cmd.RecoveryCodes)
+		if err != nil {
+			return err
+		}
+		remaining, err := json.Marshal(cmd.Remaining)
+		if err != nil {
+			return err
+		}
+
+		res, err := sess.Exec("UPDATE user_mfa SET recovery_codes = ?, updated = ? WHERE user_id = ? AND recovery_codes = ?",
+			string(remaining), time.Now(), cmd.UserId, string(previous))
+		if err != nil {
+			return err
+		}
+
+		return requireUpdatedUserMfa(res.RowsAffected())
+	})
+}
+
+func requireUpdatedUserMfa(affected int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrUserMfaCodeUsed
+	}
+	return nil
+}
+
+func DeleteUserMfa(cmd *models.DeleteUserMfaCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		_, err := sess.Exec("DELETE FROM user_mfa WHERE user_id = ?", cmd.UserId)
+		return err
+	})
+}
@@ -0,0 +1,98 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestUserMfa(t *testing.T) {
+	InitTestDB(t)
+
+	t.Run("Should return not found for user without MFA", func(t *testing.T) {
+		err := GetUserMfa(&models.GetUserMfaQuery{UserId: 1})
+		require.Equal(t, models.ErrUserMfaNotFound, err)
+	})
+
+	t.Run("Should save, update and delete MFA configuration", func(t *testing.T) {
+		err := SaveUserMfa(&models.SaveUserMfaCommand{UserId: 1, Secret: "JBSWY3DPEHPK3PXP"})
+		require.NoError(t, err)
+
+		query := &models.GetUserMfaQuery{UserId: 1}
+		err = GetUserMfa(query)
+		require.NoError(t, err)
+		require.Equal(t, "JBSWY3DPEHPK3PXP", query.Result.Secret)
+		require.False(t, query.Result.IsEnabled)
+
+		err = SaveUserMfa(&models.SaveUserMfaCommand{
+			UserId:        1,
+			Secret:        "JBSWY3DPEHPK3PXP",
+			RecoveryCodes: []string{"a", "b"},
+			IsEnabled:     true,
+		})
+		require.NoError(t, err)
+
+		err = GetUserMfa(query)
+		require.NoError(t, err)
+		require.True(t, query.Result.IsEnabled)
+		require.Equal(t, []string{"a", "b"}, query.Result.RecoveryCodes)
+
+		var secret string
+		_, err = x.SQL("SELECT secret FROM user_mfa WHERE user_id = 1").Get(&secret)
+		require.NoError(t, err)
+		require.NotEqual(t, "JBSWY3DPEHPK3PXP", secret)
+
+		err = DeleteUserMfa(&models.DeleteUserMfaCommand{UserId: 1})
+		require.NoError(t, err)
+
+		err = GetUserMfa(query)
+		require.Equal(t, models.ErrUserMfaNotFound, err)
+	})
+	t.Run("Should only accept newer TOTP time steps", func(t *testing.T) {
+		err := SaveUserMfa(&models.SaveUserMfaCommand{UserId: 2, Secret: "JBSWY3DPEHPK3PXP", IsEnabled: true})
+		require.NoError(t, err)
+
+		err = UseUserMfaTotpStep(&models.UseUserMfaTotpStepCommand{UserId: 2, Step: 100})
+		require.NoError(t, err)
+
+		err = UseUserMfaTotpStep(&models.UseUserMfaTotpStepCommand{UserId: 2, Step: 100})
+		require.Equal(t, models.ErrUserMfaCodeUsed, err)
+		err = UseUserMfaTotpStep(&models.UseUserMfaTotpStepCommand{UserId: 2, Step: 99})
+		require.Equal(t, models.ErrUserMfaCodeUsed, err)
+
+		query := &models.GetUserMfaQuery{UserId: 2}
+		err = GetUserMfa(query)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), query.Result.LastTotpStep)
+
+		err = SaveUserMfa(&models.SaveUserMfaCommand{UserId: 2, Secret: "JBSWY3DPEHPK3PXP", RecoveryCodes: []string{"a"}, IsEnabled: true})
+		require.NoError(t, err)
+		err = GetUserMfa(query)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), query.Result.LastTotpStep, "saving keeps the last step")
+	})
+
+	t.Run("Should only use recovery code if codes are unchanged", func(t *testing.T) {
+		err := SaveUserMfa(&models.SaveUserMfaCommand{
+			UserId:        3,
+			Secret:        "JBSWY3DPEHPK3PXP",
+			RecoveryCodes: []string{"a", "b", "c"},
+			IsEnabled:     true,
+		})
+		require.NoError(t, err)
+
+		cmd := &models.UseUserMfaRecoveryCodeCommand{UserId: 3, RecoveryCodes: []string{"a", "b", "c"}, Remaining: []string{"a", "c"}}
+		err = UseUserMfaRecoveryCode(cmd)
+		require.NoError(t, err)
+
+		err = UseUserMfaRecoveryCode(cmd)
+		require.Equal(t, models.ErrUserMfaCodeUsed, err)
+
+		query := &models.GetUserMfaQuery{UserId: 3}
+		err = GetUserMfa(query)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "c"}, query.Result.RecoveryCodes)
+	})
+}
@@ -0,0 +1,53 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	bus.AddHandler("sql", GetUserPasswordHistory)
+}
+
+func GetUserPasswordHistory(query *models.GetUserPasswordHistoryQuery) error {
+	query.Result = make([]*models.UserPasswordHistory, 0)
+	sess := x.Where("user_id = ?", query.UserId).Desc("created").Desc("id")
+	if query.Limit > 0 {
+		sess.Limit(query.Limit)
+	}
+
+	return sess.Find(&query.Result)
+}
+
+// addUserPasswordHistory records a password change and removes the entries
+// that are no longer needed by the password policy. The latest entry is
+// always kept, since it's when the password was last changed.
+func addUserPasswordHistory(sess *DBSession, userID int64, password string) error {
+	entry := models.UserPasswordHistory{
+		UserId:   userID,
+		Password: password,
+		Created:  time.Now(),
+	}
+	if _, err := sess.Insert(&entry); err != nil {
+		return err
+	}
+
+	keep := setting.PasswordHistoryCount
+	if keep < 1 {
+		keep = 1
+	}
+
+	var ids []int64
+	if err := sess.Table("user_password_history").Where("user_id = ?", userID).Desc("created").Desc("id").Cols("id").Find(&ids); err != nil {
+		return err
+	}
+	if len(ids) <= keep {
+		return nil
+	}
+
+	_, err := sess.In("id", ids[keep:]).Delete(&models.UserPasswordHistory{})
+	return err
+}
@@ -0,0 +1,58 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestUserPasswordHistory(t *testing.T) {
+	InitTestDB(t)
+
+	setting.PasswordHistoryCount = 2
+	defer func() { setting.PasswordHistoryCount = 0 }()
+
+	getHistory := func(userID int64) []string {
+		query := &models.GetUserPasswordHistoryQuery{UserId: userID}
+		err := GetUserPasswordHistory(query)
+		require.NoError(t, err)
+
+		passwords := make([]string, 0, len(query.Result))
+		for _, entry := range query.Result {
+			passwords = append(passwords, entry.Password)
+		}
+		return passwords
+	}
+
+	t.Run("Should keep the configured number of passwords", func(t *testing.T) {
+		for _, password := range []string{"first", "second", "third"} {
+			err := ChangeUserPassword(&models.ChangeUserPasswordCommand{UserId: 1, NewPassword: password})
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, []string{"third", "second"}, getHistory(1))
+	})
+
+	t.Run("Should limit the returned passwords", func(t *testing.T) {
+		query := &models.GetUserPasswordHistoryQuery{UserId: 1, Limit: 1}
+		err := GetUserPasswordHistory(query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 1)
+		require.Equal(t, "third", query.Result[0].Password)
+	})
+
+	t.Run("Should keep the last password if history is disabled", func(t *testing.T) {
+		setting.PasswordHistoryCount = 0
+
+		err := ChangeUserPassword(&models.ChangeUserPasswordCommand{UserId: 2, NewPassword: "first"})
+		require.NoError(t, err)
+		err = ChangeUserPassword(&models.ChangeUserPasswordCommand{UserId: 2, NewPassword: "second"})
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"second"}, getHistory(2))
+		require.Equal(t, []string{"third", "second"}, getHistory(1))
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/go-macaron/session"
 	ini "gopkg.in/ini.v1"
 
+	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -98,6 +99,8 @@ var (
 	EmailCodeValidMinutes             int
 	DataProxyWhiteList                map[string]bool
 	DisableBruteForceLoginProtection  bool
+	LoginLockoutAttempts              int64
+	LoginLockoutDuration              time.Duration
 	CookieSecure                      bool
 	CookieSameSiteDisabled            bool
 	CookieSameSiteMode                http.SameSite
@@ -116,10 +119,25 @@ var (
 	SnapShotRemoveExpired bool
 	SnapshotPublicMode    bool
 
+	// Snapshots generated by the server
+	SnapshotMinRefreshInterval time.Duration
+	SnapshotVersionsToKeep     int
+
 	// Dashboard history
 	DashboardVersionsToKeep int
+	DashboardVersionsMaxAge time.Duration
 	MinRefreshInterval      string
 
+	// Dashboard usage analytics
+	DashboardUsageAnalytics    bool
+	DashboardUsageEventsMaxAge time.Duration
+
+	// Public dashboard shares
+	DashboardPublicSharesEnabled bool
+
+	// Maximum uncompressed size of imported dashboard bundle archives
+	DashboardBundleMaxSize int64
+
 	// User settings
 	AllowUserSignUp         bool
 	AllowUserOrgCreate      bool
@@ -136,6 +154,7 @@ var (
 	ExternalUserMngLinkUrl  string
 	ExternalUserMngLinkName string
 	ExternalUserMngInfo     string
+	DeletedRetentionDays    int
 	OAuthAutoLogin          bool
 	ViewersCanEdit          bool
 
@@ -159,9 +178,47 @@ var (
 	AuthProxyWhitelist        string
 	AuthProxyHeaders          map[string]string
 
+	AuthProxyJWTAssertionHeader  string
+	AuthProxyJWKSetURL           string
+	AuthProxyJWKSetFile          string
+	AuthProxyJWTExpectedIssuer   string
+	AuthProxyJWTExpectedAudience string
+
+	// JWT auth settings
+	JWTAuthEnabled           bool
+	JWTAuthHeaderName        string
+	JWTAuthURLParamName      string
+	JWTAuthJWKSetURL         string
+	JWTAuthJWKSetFile        string
+	JWTAuthCacheTTL          time.Duration
+	JWTAuthExpectedIssuer    string
+	JWTAuthExpectedAudience  string
+	JWTAuthLoginClaim        string
+	JWTAuthEmailClaim        string
+	JWTAuthRoleAttributePath string
+	JWTAuthAutoSignUp        bool
+
 	// Basic Auth
 	BasicAuthEnabled bool
 
+	// Multi-factor authentication settings
+	MFAEnabled        bool
+	MFAEnforced       bool
+	MFAEnforcedOrgIds []int64
+	MFAIssuer         string
+
+	// SCIM provisioning
+	SCIMEnabled bool
+
+	// Password policy settings
+	PasswordMinLength        int
+	PasswordRequireUppercase bool
+	PasswordRequireLowercase bool
+	PasswordRequireDigit     bool
+	PasswordRequireSymbol    bool
+	PasswordHistoryCount     int
+	PasswordMaxAgeDays       int
+
 	// Session settings.
 	SessionOptions         session.Options
 	SessionConnMaxLifetime int64
@@ -290,6 +347,7 @@ type Cfg struct {
 	LoginMaxInactiveLifetimeDays int
 	LoginMaxLifetimeDays         int
 	TokenRotationIntervalMinutes int
+	LoginMaxActiveSessions       int
 
 	// OAuth
 	OAuthCookieMaxAge int
@@ -303,6 +361,8 @@ type Cfg struct {
 	// DistributedCache
 	RemoteCacheOptions *RemoteCacheOptions
 
+	RateLimiting RateLimitSettings
+
 	EditorsCanAdmin bool
 
 	ApiKeyMaxSecondsToLive int64
@@ -318,6 +378,11 @@ func (c Cfg) IsExpressionsEnabled() bool {
 	return c.FeatureToggles["expressions"]
 }
 
+// IsAccessControlEnabled returns whether fine-grained access control is enabled.
+func (c Cfg) IsAccessControlEnabled() bool {
+	return c.FeatureToggles["accesscontrol"]
+}
+
 // IsStandaloneAlertsEnabled returns whether the standalone alerts feature is enabled.
 func (c Cfg) IsStandaloneAlertsEnabled() bool {
 	return c.FeatureToggles["standaloneAlerts"]
@@ -738,6 +803,11 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	DisableGravatar = security.Key("disable_gravatar").MustBool(true)
 	cfg.DisableBruteForceLoginProtection = security.Key("disable_brute_force_login_protection").MustBool(false)
 	DisableBruteForceLoginProtection = cfg.DisableBruteForceLoginProtection
+	LoginLockoutAttempts = security.Key("login_lockout_attempts").MustInt64(5)
+	LoginLockoutDuration = security.Key("login_lockout_duration").MustDuration(5 * time.Minute)
+	if LoginLockoutAttempts < 1 {
+		return errors.New("login_lockout_attempts must be at least 1")
+	}
 
 	CookieSecure = security.Key("cookie_secure").MustBool(false)
 	cfg.CookieSecure = CookieSecure
@@ -788,10 +858,47 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	ExternalEnabled = snapshots.Key("external_enabled").MustBool(true)
 	SnapShotRemoveExpired = snapshots.Key("snapshot_remove_expired").MustBool(true)
 	SnapshotPublicMode = snapshots.Key("public_mode").MustBool(false)
+	minRefreshInterval, err := valueAsString(snapshots, "min_refresh_interval", "1m")
+	if err != nil {
+		return err
+	}
+	if SnapshotMinRefreshInterval, err = gtime.ParseInterval(minRefreshInterval); err != nil {
+		return fmt.Errorf("invalid min_refresh_interval %q: %w", minRefreshInterval, err)
+	}
+	SnapshotVersionsToKeep = snapshots.Key("versions_to_keep").MustInt(20)
+	if SnapshotVersionsToKeep < 0 {
+		SnapshotVersionsToKeep = 0
+	}
 
 	// read dashboard settings
 	dashboards := iniFile.Section("dashboards")
 	DashboardVersionsToKeep = dashboards.Key("versions_to_keep").MustInt(20)
+	versionsMaxAge, err := valueAsString(dashboards, "versions_max_age", "")
+	if err != nil {
+		return err
+	}
+	DashboardVersionsMaxAge = 0
+	if versionsMaxAge != "" && versionsMaxAge != "0" {
+		if DashboardVersionsMaxAge, err = gtime.ParseInterval(versionsMaxAge); err != nil {
+			return fmt.Errorf("invalid versions_max_age %q: %w", versionsMaxAge, err)
+		}
+	}
+	DashboardUsageAnalytics = dashboards.Key("usage_analytics").MustBool(true)
+	DashboardPublicSharesEnabled = dashboards.Key("public_shares_enabled").MustBool(true)
+	DashboardBundleMaxSize = dashboards.Key("bundle_max_size_mb").MustInt64(100) * 1024 * 1024
+	if DashboardBundleMaxSize <= 0 {
+		return errors.New("bundle_max_size_mb must be at least 1")
+	}
+	usageEventsMaxAge, err := valueAsString(dashboards, "usage_events_max_age", "30d")
+	if err != nil {
+		return err
+	}
+	DashboardUsageEventsMaxAge = 0
+	if usageEventsMaxAge != "" && usageEventsMaxAge != "0" {
+		if DashboardUsageEventsMaxAge, err = gtime.ParseInterval(usageEventsMaxAge); err != nil {
+			return fmt.Errorf("invalid usage_events_max_age %q: %w", usageEventsMaxAge, err)
+		}
+	}
 	MinRefreshInterval, err = valueAsString(dashboards, "min_refresh_interval", "5s")
 	if err != nil {
 		return err
@@ -854,6 +961,10 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	}
 	ViewersCanEdit = users.Key("viewers_can_edit").MustBool(false)
 	cfg.EditorsCanAdmin = users.Key("editors_can_admin").MustBool(false)
+	DeletedRetentionDays = users.Key("deleted_retention_days").MustInt(30)
+	if DeletedRetentionDays < 0 {
+		DeletedRetentionDays = 0
+	}
 
 	// auth
 	auth := iniFile.Section("auth")
@@ -867,6 +978,7 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 
 	LoginMaxLifetimeDays = auth.Key("login_maximum_lifetime_days").MustInt(30)
 	cfg.LoginMaxLifetimeDays = LoginMaxLifetimeDays
+	cfg.LoginMaxActiveSessions = auth.Key("login_maximum_active_sessions").MustInt(0)
 	cfg.ApiKeyMaxSecondsToLive = auth.Key("api_key_max_seconds_to_live").MustInt64(-1)
 
 	cfg.TokenRotationIntervalMinutes = auth.Key("token_rotation_interval_minutes").MustInt(10)
@@ -940,10 +1052,105 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 		}
 	}
 
+	AuthProxyJWTAssertionHeader, err = valueAsString(authProxy, "jwt_assertion_header", "")
+	if err != nil {
+		return err
+	}
+	AuthProxyJWKSetURL, err = valueAsString(authProxy, "jwk_set_url", "")
+	if err != nil {
+		return err
+	}
+	AuthProxyJWKSetFile, err = valueAsString(authProxy, "jwk_set_file", "")
+	if err != nil {
+		return err
+	}
+	AuthProxyJWTExpectedIssuer, err = valueAsString(authProxy, "expected_issuer", "")
+	if err != nil {
+		return err
+	}
+	AuthProxyJWTExpectedAudience, err = valueAsString(authProxy, "expected_audience", "")
+	if err != nil {
+		return err
+	}
+	if AuthProxyJWTAssertionHeader != "" && AuthProxyJWKSetURL == "" && AuthProxyJWKSetFile == "" {
+		return errors.New("auth proxy jwt_assertion_header is set but neither jwk_set_url nor jwk_set_file is set")
+	}
+
+	// JWT auth
+	authJWT := iniFile.Section("auth.jwt")
+	JWTAuthEnabled = authJWT.Key("enabled").MustBool(false)
+	JWTAuthHeaderName, err = valueAsString(authJWT, "header_name", "X-JWT-Assertion")
+	if err != nil {
+		return err
+	}
+	JWTAuthURLParamName, err = valueAsString(authJWT, "url_param_name", "")
+	if err != nil {
+		return err
+	}
+	JWTAuthJWKSetURL, err = valueAsString(authJWT, "jwk_set_url", "")
+	if err != nil {
+		return err
+	}
+	JWTAuthJWKSetFile, err = valueAsString(authJWT, "jwk_set_file", "")
+	if err != nil {
+		return err
+	}
+	JWTAuthCacheTTL = authJWT.Key("cache_ttl").MustDuration(time.Minute * 60)
+	JWTAuthExpectedIssuer, err = valueAsString(authJWT, "expected_issuer", "")
+	if err != nil {
+		return err
+	}
+	JWTAuthExpectedAudience, err = valueAsString(authJWT, "expected_audience", "")
+	if err != nil {
+		return err
+	}
+	JWTAuthLoginClaim, err = valueAsString(authJWT, "login_claim", "sub")
+	if err != nil {
+		return err
+	}
+	JWTAuthEmailClaim, err = valueAsString(authJWT, "email_claim", "email")
+	if err != nil {
+		return err
+	}
+	JWTAuthRoleAttributePath, err = valueAsString(authJWT, "role_attribute_path", "")
+	if err != nil {
+		return err
+	}
+	JWTAuthAutoSignUp = authJWT.Key("auto_sign_up").MustBool(false)
+	if JWTAuthEnabled && JWTAuthJWKSetURL == "" && JWTAuthJWKSetFile == "" {
+		return errors.New("JWT auth is enabled but neither jwk_set_url nor jwk_set_file is set")
+	}
+
 	// basic auth
 	authBasic := iniFile.Section("auth.basic")
 	BasicAuthEnabled = authBasic.Key("enabled").MustBool(true)
 
+	// multi-factor auth
+	authMFA := iniFile.Section("auth.mfa")
+	MFAEnabled = authMFA.Key("enabled").MustBool(false)
+	MFAEnforced = authMFA.Key("enforced").MustBool(false)
+	MFAEnforcedOrgIds = authMFA.Key("enforced_org_ids").Int64s(",")
+	MFAIssuer, err = valueAsString(authMFA, "issuer", "Grafana")
+	if err != nil {
+		return err
+	}
+
+	// SCIM provisioning
+	SCIMEnabled = iniFile.Section("scim").Key("enabled").MustBool(false)
+
+	// password policy
+	passwordPolicy := iniFile.Section("password_policy")
+	PasswordMinLength = passwordPolicy.Key("min_length").MustInt(4)
+	if PasswordMinLength < 1 {
+		return errors.New("password_policy min_length must be at least 1")
+	}
+	PasswordRequireUppercase = passwordPolicy.Key("require_uppercase").MustBool(false)
+	PasswordRequireLowercase = passwordPolicy.Key("require_lowercase").MustBool(false)
+	PasswordRequireDigit = passwordPolicy.Key("require_digit").MustBool(false)
+	PasswordRequireSymbol = passwordPolicy.Key("require_symbol").MustBool(false)
+	PasswordHistoryCount = passwordPolicy.Key("history_count").MustInt(0)
+	PasswordMaxAgeDays = passwordPolicy.Key("max_age_days").MustInt(0)
+
 	// Rendering
 	renderSec := iniFile.Section("rendering")
 	cfg.RendererUrl, err = valueAsString(renderSec, "server_url", "")
@@ -1071,6 +1278,9 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	cfg.readSessionConfig()
 	cfg.readSmtpSettings()
 	cfg.readQuotaSettings()
+	if err := cfg.readRateLimitSettings(); err != nil {
+		return err
+	}
 
 	if VerifyEmailEnabled && !cfg.Smtp.Enabled {
 		log.Warn("require_email_validation is enabled but smtp is disabled")
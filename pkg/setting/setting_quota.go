@@ -5,10 +5,16 @@ import (
 )
 
 type OrgQuota struct {
-	User       int64 `target:"org_user"`
-	DataSource int64 `target:"data_source"`
-	Dashboard  int64 `target:"dashboard"`
-	ApiKey     int64 `target:"api_key"`
+	User             int64 `target:"org_user"`
+	DataSource       int64 `target:"data_source"`
+	Dashboard        int64 `target:"dashboard"`
+	ApiKey           int64 `target:"api_key"`
+	Alert            int64 `target:"alert"`
+	Annotation       int64 `target:"annotation"`
+	DashboardVersion int64 `target:"dashboard_version"`
+	Snapshot         int64 `target:"dashboard_snapshot"`
+	Playlist         int64 `target:"playlist"`
+	DashboardBytes   int64 `target:"dashboard_bytes"`
 }
 
 type UserQuota struct {
@@ -70,10 +76,16 @@ func (cfg *Cfg) readQuotaSettings() {
 
 	// per ORG Limits
 	Quota.Org = &OrgQuota{
-		User:       quota.Key("org_user").MustInt64(10),
-		DataSource: quota.Key("org_data_source").MustInt64(10),
-		Dashboard:  quota.Key("org_dashboard").MustInt64(10),
-		ApiKey:     quota.Key("org_api_key").MustInt64(10),
+		User:             quota.Key("org_user").MustInt64(10),
+		DataSource:       quota.Key("org_data_source").MustInt64(10),
+		Dashboard:        quota.Key("org_dashboard").MustInt64(10),
+		ApiKey:           quota.Key("org_api_key").MustInt64(10),
+		Alert:            quota.Key("org_alert").MustInt64(-1),
+		Annotation:       quota.Key("org_annotation").MustInt64(-1),
+		DashboardVersion: quota.Key("org_dashboard_version").MustInt64(-1),
+		Snapshot:         quota.Key("org_dashboard_snapshot").MustInt64(-1),
+		Playlist:         quota.Key("org_playlist").MustInt64(-1),
+		DashboardBytes:   quota.Key("org_dashboard_bytes").MustInt64(-1),
 	}
 
 	// per User limits
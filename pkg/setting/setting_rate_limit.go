@@ -0,0 +1,108 @@
+package setting
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// RateLimit is a token bucket limit: the bucket holds up to Burst requests
+// and is refilled with RequestsPerMinute requests per minute.
+type RateLimit struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+type RateLimitSettings struct {
+	Enabled bool
+	// Limits per route group, e.g. "api" or "search"
+	Limits map[string]RateLimit
+	// TrustedProxies are the networks of the proxies whose X-Forwarded-For
+	// and X-Real-IP headers are used to get the IP address of the client.
+	TrustedProxies []*net.IPNet
+}
+
+func (cfg *Cfg) readRateLimitSettings() error {
+	section := cfg.Raw.Section("rate_limiting")
+	cfg.RateLimiting.Enabled = section.Key("enabled").MustBool(false)
+
+	limits, err := parseRateLimits(section.Key("limits").MustString("api:600:100"))
+	if err != nil {
+		return err
+	}
+	cfg.RateLimiting.Limits = limits
+
+	proxies, err := parseTrustedProxies(section.Key("trusted_proxies").MustString(""))
+	if err != nil {
+		return err
+	}
+	cfg.RateLimiting.TrustedProxies = proxies
+
+	return nil
+}
+
+// parseTrustedProxies parses a list of IP addresses and networks in CIDR
+// notation.
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+
+	for _, item := range util.SplitString(value) {
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", item)
+		}
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
+
+// parseRateLimits parses a list of limits formatted as group:requests_per_minute:burst.
+func parseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := map[string]RateLimit{}
+
+	for _, item := range util.SplitString(value) {
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid rate limit %q, expected group:requests_per_minute:burst", item)
+		}
+
+		requestsPerMinute, err := strconv.Atoi(parts[1])
+		if err != nil || requestsPerMinute <= 0 {
+			return nil, fmt.Errorf("invalid requests per minute in rate limit %q", item)
+		}
+
+		burst, err := strconv.Atoi(parts[2])
+		if err != nil || burst <= 0 {
+			return nil, fmt.Errorf("invalid burst in rate limit %q", item)
+		}
+
+		limits[parts[0]] = RateLimit{RequestsPerMinute: requestsPerMinute, Burst: burst}
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,40 @@
+package setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitSettings(t *testing.T) {
+	cfg := NewCfg()
+	sec, err := cfg.Raw.NewSection("rate_limiting")
+	require.NoError(t, err)
+	_, err = sec.NewKey("enabled", "true")
+	require.NoError(t, err)
+	_, err = sec.NewKey("limits", "api:600:100, search:60:10")
+	require.NoError(t, err)
+	_, err = sec.NewKey("trusted_proxies", "10.0.0.1, 192.168.0.0/16 ::1")
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.readRateLimitSettings())
+	require.True(t, cfg.RateLimiting.Enabled)
+	require.Equal(t, map[string]RateLimit{
+		"api":    {RequestsPerMinute: 600, Burst: 100},
+		"search": {RequestsPerMinute: 60, Burst: 10},
+	}, cfg.RateLimiting.Limits)
+	require.Len(t, cfg.RateLimiting.TrustedProxies, 3)
+	require.Equal(t, "10.0.0.1/32", cfg.RateLimiting.TrustedProxies[0].String())
+	require.Equal(t, "192.168.0.0/16", cfg.RateLimiting.TrustedProxies[1].String())
+	require.Equal(t, "::1/128", cfg.RateLimiting.TrustedProxies[2].String())
+
+	for _, invalid := range []string{"api:600", "api:fast:100", "api:600:0"} {
+		_, err := parseRateLimits(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	for _, invalid := range []string{"proxy", "10.0.0.1/33"} {
+		_, err := parseTrustedProxies(invalid)
+		require.Error(t, err, invalid)
+	}
+}